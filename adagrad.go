@@ -0,0 +1,67 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const defaultAdaGradLearningRate = 0.01
+
+// AdaGrad implements the AdaGrad stochastic gradient method. It takes the
+// step
+//  x_{k+1} = x_k - η_k g_k / (sqrt(Σ_{j<=k} g_j^2) + ε)
+// componentwise, so that coordinates with large past gradients take
+// smaller steps. AdaGrad is intended for Problems obtained from
+// StochasticProblem.Problem, and every step is a major iteration.
+//
+// References:
+//  - Duchi, J., Hazan, E., Singer, Y.: Adaptive subgradient methods for
+//    online learning and stochastic optimization. J. Mach. Learn. Res. 12
+//    (2011), 2121-2159
+type AdaGrad struct {
+	// LearningRate is the learning rate schedule η_k. If LearningRate is
+	// nil, it will be set to ConstantLearningRate(0.01).
+	LearningRate LearningRateSchedule
+	// Epsilon is the regularization of the denominator. If Epsilon is zero,
+	// it will be set to 1e-8.
+	Epsilon float64
+
+	k   int
+	sum []float64 // Sum of the squared gradients.
+}
+
+func (a *AdaGrad) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if a.LearningRate == nil {
+		a.LearningRate = ConstantLearningRate(defaultAdaGradLearningRate)
+	}
+	if a.Epsilon == 0 {
+		a.Epsilon = defaultStochasticEps
+	}
+	a.sum = resize(a.sum, len(loc.X))
+	for i := range a.sum {
+		a.sum[i] = 0
+	}
+	a.k = 0
+	return a.Iterate(loc, xNext)
+}
+
+func (a *AdaGrad) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	a.k++
+	eta := a.LearningRate.LearningRate(a.k)
+	for i, g := range loc.Gradient {
+		a.sum[i] += g * g
+		xNext[i] = loc.X[i] - eta*g/(math.Sqrt(a.sum[i])+a.Epsilon)
+	}
+	return FuncEvaluation | GradEvaluation, MajorIteration, nil
+}
+
+func (*AdaGrad) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
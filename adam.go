@@ -0,0 +1,91 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const (
+	defaultAdamLearningRate = 0.001
+	defaultAdamBeta1        = 0.9
+	defaultAdamBeta2        = 0.999
+	defaultStochasticEps    = 1e-8
+)
+
+// Adam implements the Adam stochastic gradient method. It takes the step
+//  x_{k+1} = x_k - η_k m̂_k / (sqrt(v̂_k) + ε)
+// componentwise, where m̂_k and v̂_k are the bias-corrected exponential
+// moving averages of the gradients and of their squares with the decay
+// factors Beta1 and Beta2. Adam is intended for Problems obtained from
+// StochasticProblem.Problem, and every step is a major iteration.
+//
+// References:
+//  - Kingma, D.P., Ba, J.: Adam: A method for stochastic optimization.
+//    arXiv:1412.6980
+type Adam struct {
+	// LearningRate is the learning rate schedule η_k. If LearningRate is
+	// nil, it will be set to ConstantLearningRate(0.001).
+	LearningRate LearningRateSchedule
+	// Beta1 and Beta2 are the decay factors of the moment estimates. If they
+	// are zero, they will be set to 0.9 and 0.999. Adam panics if they are
+	// not in [0, 1).
+	Beta1, Beta2 float64
+	// Epsilon is the regularization of the denominator. If Epsilon is zero,
+	// it will be set to 1e-8.
+	Epsilon float64
+
+	k int
+	m []float64 // First moment estimate.
+	v []float64 // Second moment estimate.
+}
+
+func (a *Adam) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if a.LearningRate == nil {
+		a.LearningRate = ConstantLearningRate(defaultAdamLearningRate)
+	}
+	if a.Beta1 == 0 {
+		a.Beta1 = defaultAdamBeta1
+	}
+	if a.Beta2 == 0 {
+		a.Beta2 = defaultAdamBeta2
+	}
+	if a.Beta1 < 0 || a.Beta1 >= 1 || a.Beta2 < 0 || a.Beta2 >= 1 {
+		panic("adam: decay factors must be in [0, 1)")
+	}
+	if a.Epsilon == 0 {
+		a.Epsilon = defaultStochasticEps
+	}
+	dim := len(loc.X)
+	a.m = resize(a.m, dim)
+	a.v = resize(a.v, dim)
+	for i := range a.m {
+		a.m[i] = 0
+		a.v[i] = 0
+	}
+	a.k = 0
+	return a.Iterate(loc, xNext)
+}
+
+func (a *Adam) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	a.k++
+	eta := a.LearningRate.LearningRate(a.k)
+	c1 := 1 - math.Pow(a.Beta1, float64(a.k))
+	c2 := 1 - math.Pow(a.Beta2, float64(a.k))
+	for i, g := range loc.Gradient {
+		a.m[i] = a.Beta1*a.m[i] + (1-a.Beta1)*g
+		a.v[i] = a.Beta2*a.v[i] + (1-a.Beta2)*g*g
+		xNext[i] = loc.X[i] - eta*(a.m[i]/c1)/(math.Sqrt(a.v[i]/c2)+a.Epsilon)
+	}
+	return FuncEvaluation | GradEvaluation, MajorIteration, nil
+}
+
+func (*Adam) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
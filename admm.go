@@ -0,0 +1,299 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultADMMAbsTol = 1e-8
+	defaultADMMRelTol = 1e-6
+	// admmBalance and admmScale are the parameters μ and τ of the residual
+	// balancing of the adaptive penalty parameter.
+	admmBalance = 10
+	admmScale   = 2
+)
+
+// ADMMProblem describes the separable problem
+//  minimize f(x) + g(z) subject to A x + B z = c
+// with x of dimension n, z of dimension p, and m constraints. The functions f
+// and g enter the problem only through the updates of x and z, which are
+// minimizations of f and g with a quadratic penalty.
+type ADMMProblem struct {
+	// A and B are the m×n and m×p constraint matrices, and C is the right
+	// hand side of the constraints. If A is nil, it is the identity. If B is
+	// nil, it is the negative identity. If C is nil, it is zero. If both A
+	// and B are nil, the constraint is the consensus constraint x = z + c.
+	A, B *mat64.Dense
+	C    []float64
+
+	// XUpdate stores a minimizer of
+	//  f(x) + ρ/2 |A x - v|_2^2
+	// in x. If XUpdate is nil, A must be nil, and the minimizer
+	// prox_{f/ρ}(v) is computed by XProx.
+	XUpdate func(x, v []float64, rho float64)
+	XProx   ProximalOperator
+	// ZUpdate stores a minimizer of
+	//  g(z) + ρ/2 |B z - v|_2^2
+	// in z. If ZUpdate is nil, B must be nil, and the minimizer
+	// prox_{g/ρ}(-v) is computed by ZProx.
+	ZUpdate func(z, v []float64, rho float64)
+	ZProx   ProximalOperator
+}
+
+// ADMMResult is the solution of an ADMMProblem.
+type ADMMResult struct {
+	X, Z []float64
+	// Dual is the estimate of the Lagrange multipliers y of the constraints,
+	// with the Lagrangian f(x) + g(z) + yᵀ(A x + B z - c).
+	Dual []float64
+	// PrimalResidual and DualResidual are the Euclidean norms of the primal
+	// residual A x + B z - c and of the dual residual ρ Aᵀ B (z - z_prev)
+	// at the last iteration.
+	PrimalResidual float64
+	DualResidual   float64
+	// Rho is the penalty parameter at the last iteration.
+	Rho float64
+	// Iterations is the number of iterations.
+	Iterations int
+}
+
+// ADMM implements the alternating direction method of multipliers for
+// problems described by ADMMProblem. Every iteration minimizes the augmented
+// Lagrangian over x, then over z, and updates the scaled dual variable u:
+//  x_{k+1} = argmin f(x) + ρ/2 |A x + B z_k - c + u_k|_2^2,
+//  z_{k+1} = argmin g(z) + ρ/2 |h_{k+1} + B z - c + u_k|_2^2,
+//  u_{k+1} = u_k + h_{k+1} + B z_{k+1} - c,
+// where
+//  h_{k+1} = α A x_{k+1} - (1 - α) (B z_k - c)
+// is the over-relaxed value of A x_{k+1} with the relaxation parameter α.
+// The dual variable of the constraints is y = ρ u.
+//
+// Because f and g are only accessed through the updates, ADMM solves problems
+// whose parts are easy to minimize separately, like the LASSO, problems with
+// an indicator function of a constraint set, and consensus and sharing
+// problems that can be distributed over many workers. It converges for
+// closed, proper and convex f and g, typically to moderate accuracy.
+//
+// ADMM terminates when the primal and dual residuals are below the tolerances
+//  ε_pri = sqrt(m) AbsoluteTolerance + RelativeTolerance max(|A x|, |B z|, |c|),
+//  ε_dual = sqrt(n) AbsoluteTolerance + RelativeTolerance |ρ Aᵀ u|.
+//
+// References:
+//  - Boyd, S., Parikh, N., Chu, E., Peleato, B., Eckstein, J.: Distributed
+//    optimization and statistical learning via the alternating direction
+//    method of multipliers. Found. Trends Mach. Learn. 3 (2011), 1-122
+type ADMM struct {
+	// Rho is the initial penalty parameter ρ. If Rho is zero, it will be set
+	// to 1.
+	Rho float64
+	// Relaxation is the relaxation parameter α in (0, 2). Values between 1.5
+	// and 1.8 often speed up the convergence. If Relaxation is zero, it will
+	// be set to 1, and the method is not relaxed.
+	Relaxation float64
+	// Adaptive specifies whether the penalty parameter is adapted by
+	// residual balancing: ρ is doubled if the primal residual is more than
+	// ten times the dual residual, and halved in the opposite case.
+	Adaptive bool
+	// AbsoluteTolerance and RelativeTolerance are the tolerances of the
+	// termination criteria. If they are zero, they will be set to 1e-8 and
+	// 1e-6.
+	AbsoluteTolerance float64
+	RelativeTolerance float64
+	// MaxIterations is the maximum number of iterations. If MaxIterations is
+	// zero, it will be set to 1000.
+	MaxIterations int
+}
+
+// Solve solves the ADMMProblem p starting from the initial x and z, which
+// determine the dimensions of the problem. If the iteration limit is reached,
+// Solve returns the last iterate together with ErrIterationLimit.
+func (ad ADMM) Solve(p *ADMMProblem, x, z []float64) (*ADMMResult, error) {
+	n, np := len(x), len(z)
+	if n == 0 || np == 0 {
+		panic("optimize: zero-length initial location")
+	}
+	a := admmOperator{mat: p.A, sign: 1}
+	b := admmOperator{mat: p.B, sign: -1}
+	m := a.rows(n)
+	if b.rows(np) != m || (p.C != nil && len(p.C) != m) {
+		panic("optimize: mismatched constraint dimensions")
+	}
+	if a.cols(n) != n || b.cols(np) != np {
+		panic("optimize: mismatched dimensions of x or z")
+	}
+	if p.XUpdate == nil && (p.A != nil || p.XProx == nil) {
+		panic("optimize: x update needs XUpdate, or XProx with identity A")
+	}
+	if p.ZUpdate == nil && (p.B != nil || p.ZProx == nil) {
+		panic("optimize: z update needs ZUpdate, or ZProx with negative identity B")
+	}
+	rho := ad.Rho
+	if rho == 0 {
+		rho = 1
+	}
+	if rho < 0 {
+		panic("optimize: negative penalty parameter")
+	}
+	alpha := ad.Relaxation
+	if alpha == 0 {
+		alpha = 1
+	}
+	if alpha <= 0 || alpha >= 2 {
+		panic("optimize: relaxation parameter must be in (0, 2)")
+	}
+	absTol := ad.AbsoluteTolerance
+	if absTol == 0 {
+		absTol = defaultADMMAbsTol
+	}
+	relTol := ad.RelativeTolerance
+	if relTol == 0 {
+		relTol = defaultADMMRelTol
+	}
+	if absTol < 0 || relTol < 0 {
+		panic("optimize: negative tolerance")
+	}
+	maxIter := ad.MaxIterations
+	if maxIter == 0 {
+		maxIter = 1000
+	}
+
+	res := &ADMMResult{
+		X:    make([]float64, n),
+		Z:    make([]float64, np),
+		Dual: make([]float64, m),
+	}
+	copy(res.X, x)
+	copy(res.Z, z)
+	c := p.C
+	if c == nil {
+		c = make([]float64, m)
+	}
+	u := make([]float64, m)
+	ax := make([]float64, m)
+	bz := make([]float64, m)
+	h := make([]float64, m)
+	v := make([]float64, m)
+	r := make([]float64, m)
+	bdz := make([]float64, m)
+	s := make([]float64, n)
+	b.mul(bz, res.Z)
+
+	var err error
+	for {
+		if res.Iterations == maxIter {
+			err = ErrIterationLimit
+			break
+		}
+		res.Iterations++
+
+		// Update x with v = c - B z - u.
+		for i := range v {
+			v[i] = c[i] - bz[i] - u[i]
+		}
+		if p.XUpdate != nil {
+			p.XUpdate(res.X, v, rho)
+		} else {
+			p.XProx.Prox(res.X, v, 1/rho)
+		}
+		a.mul(ax, res.X)
+
+		// Update z with the relaxed h and v = c - h - u.
+		for i := range h {
+			h[i] = alpha*ax[i] - (1-alpha)*(bz[i]-c[i])
+			v[i] = c[i] - h[i] - u[i]
+		}
+		if p.ZUpdate != nil {
+			p.ZUpdate(res.Z, v, rho)
+		} else {
+			floats.Scale(-1, v)
+			p.ZProx.Prox(res.Z, v, 1/rho)
+		}
+		copy(bdz, bz)
+		b.mul(bz, res.Z)
+
+		// Update the scaled dual variable and compute the residuals.
+		for i := range u {
+			u[i] += h[i] + bz[i] - c[i]
+			r[i] = ax[i] + bz[i] - c[i]
+			bdz[i] = bz[i] - bdz[i]
+		}
+		a.mulTrans(s, bdz)
+		res.PrimalResidual = floats.Norm(r, 2)
+		res.DualResidual = rho * floats.Norm(s, 2)
+
+		epsPri := math.Sqrt(float64(m))*absTol +
+			relTol*math.Max(floats.Norm(ax, 2), math.Max(floats.Norm(bz, 2), floats.Norm(c, 2)))
+		a.mulTrans(s, u)
+		epsDual := math.Sqrt(float64(n))*absTol + relTol*rho*floats.Norm(s, 2)
+		if res.PrimalResidual <= epsPri && res.DualResidual <= epsDual {
+			break
+		}
+
+		if ad.Adaptive {
+			switch {
+			case res.PrimalResidual > admmBalance*res.DualResidual:
+				rho *= admmScale
+				floats.Scale(1.0/admmScale, u)
+			case res.DualResidual > admmBalance*res.PrimalResidual:
+				rho /= admmScale
+				floats.Scale(admmScale, u)
+			}
+		}
+	}
+	for i, v := range u {
+		res.Dual[i] = rho * v
+	}
+	res.Rho = rho
+	return res, err
+}
+
+// admmOperator is a constraint matrix of ADMMProblem. A nil mat is sign
+// times the identity.
+type admmOperator struct {
+	mat  *mat64.Dense
+	sign float64
+}
+
+func (op admmOperator) rows(n int) int {
+	if op.mat == nil {
+		return n
+	}
+	r, _ := op.mat.Dims()
+	return r
+}
+
+func (op admmOperator) cols(n int) int {
+	if op.mat == nil {
+		return n
+	}
+	_, c := op.mat.Dims()
+	return c
+}
+
+// mul stores the product of the operator with x in dst.
+func (op admmOperator) mul(dst, x []float64) {
+	if op.mat == nil {
+		copy(dst, x)
+		floats.Scale(op.sign, dst)
+		return
+	}
+	mat64.NewVector(len(dst), dst).MulVec(op.mat, false, mat64.NewVector(len(x), x))
+}
+
+// mulTrans stores the product of the transpose of the operator with y in
+// dst.
+func (op admmOperator) mulTrans(dst, y []float64) {
+	if op.mat == nil {
+		copy(dst, y)
+		floats.Scale(op.sign, dst)
+		return
+	}
+	mat64.NewVector(len(dst), dst).MulVec(op.mat, true, mat64.NewVector(len(y), y))
+}
@@ -0,0 +1,140 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// leastSquaresXUpdate returns the x update of ADMM for f(x) = ½ |D x - b|_2^2
+// and the identity constraint matrix, the solution of
+//  (DᵀD + ρ I) x = Dᵀb + ρ v.
+func leastSquaresXUpdate(d *mat64.Dense, b []float64) func(x, v []float64, rho float64) {
+	_, n := d.Dims()
+	return func(x, v []float64, rho float64) {
+		sys := mat64.NewSymDense(n, nil)
+		rhs := make([]float64, n)
+		for i := 0; i < n; i++ {
+			ci := d.Col(nil, i)
+			for j := i; j < n; j++ {
+				sys.SetSym(i, j, floats.Dot(ci, d.Col(nil, j)))
+			}
+			sys.SetSym(i, i, sys.At(i, i)+rho)
+			rhs[i] = floats.Dot(ci, b) + rho*v[i]
+		}
+		var chol mat64.TriDense
+		if !chol.Cholesky(sys, true) {
+			panic("admm test: singular system")
+		}
+		mat64.NewVector(n, x).SolveCholeskyVec(&chol, mat64.NewVector(n, rhs))
+	}
+}
+
+func TestADMM(t *testing.T) {
+	d := mat64.NewDense(5, 3, []float64{
+		1, 0.5, 0,
+		0, 1, 0.2,
+		0.3, 0, 1,
+		1, 1, 1,
+		2, -1, 0.5,
+	})
+	b := []float64{1, -2, 0.05, 0.5, 3}
+	const lambda = 0.5
+	lasso := &ADMMProblem{
+		XUpdate: leastSquaresXUpdate(d, b),
+		ZProx:   L1Prox{Lambda: lambda},
+	}
+	lassoGrad := leastSquaresProblem(d, b).Grad
+
+	// The quadratic ½ (x - q)ᵀ(x - q) in the unit box.
+	box := &ADMMProblem{
+		XProx: quadraticProx{q: []float64{2, -0.5, 0.3}},
+		ZProx: BoxProx{Lower: []float64{0, 0, 0}, Upper: []float64{1, 1, 1}},
+	}
+
+	// The minimum of (x - 1)^2 + (z - 2)^2 subject to x + 2z = 3 is at
+	// x = 0.6, z = 1.2 with the multiplier 0.8.
+	scalar := &ADMMProblem{
+		A: mat64.NewDense(1, 1, []float64{1}),
+		B: mat64.NewDense(1, 1, []float64{2}),
+		C: []float64{3},
+		XUpdate: func(x, v []float64, rho float64) {
+			x[0] = (2 + rho*v[0]) / (2 + rho)
+		},
+		ZUpdate: func(z, v []float64, rho float64) {
+			z[0] = (2 + rho*v[0]) / (1 + 2*rho)
+		},
+	}
+
+	for _, method := range []struct {
+		name string
+		admm ADMM
+	}{
+		{"Default", ADMM{}},
+		{"Relaxed", ADMM{Relaxation: 1.6}},
+		{"Adaptive", ADMM{Rho: 100, Adaptive: true}},
+	} {
+		res, err := method.admm.Solve(lasso, make([]float64, 3), make([]float64, 3))
+		if err != nil {
+			t.Errorf("%s Lasso: unexpected error: %v", method.name, err)
+		} else {
+			grad := make([]float64, 3)
+			lassoGrad(res.Z, grad)
+			for j, zj := range res.Z {
+				if zj != 0 {
+					if math.Abs(grad[j]+math.Copysign(lambda, zj)) > 1e-5 {
+						t.Errorf("%s Lasso: coordinate %d not optimal: z = %v, gradient %v", method.name, j, zj, grad[j])
+					}
+				} else if math.Abs(grad[j]) > lambda+1e-5 {
+					t.Errorf("%s Lasso: zero coordinate %d not optimal: gradient %v", method.name, j, grad[j])
+				}
+			}
+		}
+
+		res, err = method.admm.Solve(box, make([]float64, 3), make([]float64, 3))
+		if err != nil {
+			t.Errorf("%s Box: unexpected error: %v", method.name, err)
+		} else if want := []float64{1, 0, 0.3}; !floats.EqualApprox(res.Z, want, 1e-6) {
+			t.Errorf("%s Box: unexpected solution, want %v, got %v", method.name, want, res.Z)
+		}
+
+		res, err = method.admm.Solve(scalar, []float64{0}, []float64{0})
+		if err != nil {
+			t.Errorf("%s Scalar: unexpected error: %v", method.name, err)
+			continue
+		}
+		if math.Abs(res.X[0]-0.6) > 1e-6 || math.Abs(res.Z[0]-1.2) > 1e-6 {
+			t.Errorf("%s Scalar: unexpected solution, want [0.6 1.2], got [%v %v]", method.name, res.X[0], res.Z[0])
+		}
+		if math.Abs(res.Dual[0]-0.8) > 1e-5 {
+			t.Errorf("%s Scalar: unexpected multiplier, want 0.8, got %v", method.name, res.Dual[0])
+		}
+	}
+
+	_, err := ADMM{MaxIterations: 2}.Solve(lasso, make([]float64, 3), make([]float64, 3))
+	if err != ErrIterationLimit {
+		t.Errorf("unexpected error at the iteration limit: %v", err)
+	}
+}
+
+// quadraticProx is g(x) = ½ |x - q|_2^2.
+type quadraticProx struct {
+	q []float64
+}
+
+func (qp quadraticProx) Value(x []float64) float64 {
+	d := floats.Distance(x, qp.q, 2)
+	return 0.5 * d * d
+}
+
+func (qp quadraticProx) Prox(dst, x []float64, t float64) {
+	for i, v := range x {
+		dst[i] = (v + t*qp.q[i]) / (1 + t)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+// methodRunner drives a Method on a Problem through the reverse
+// communication interface, performing the evaluations itself, so that only
+// the allocations of the Method are measured.
+type methodRunner struct {
+	p      Problem
+	method Method
+	loc    *Location
+	xNext  []float64
+	eval   EvaluationType
+	err    error
+}
+
+func newMethodRunner(p Problem, method Method, x []float64) *methodRunner {
+	dim := len(x)
+	r := &methodRunner{
+		p:      p,
+		method: method,
+		loc: &Location{
+			X:        make([]float64, dim),
+			Gradient: make([]float64, dim),
+		},
+		xNext: make([]float64, dim),
+	}
+	if method.Needs().Hessian {
+		r.loc.Hessian = mat64.NewSymDense(dim, nil)
+	}
+	copy(r.loc.X, x)
+	r.evaluate(FuncEvaluation | GradEvaluation | HessEvaluation)
+	r.eval, _, r.err = method.Init(r.loc, newProblemInfo(&p, &Stats{}), r.xNext)
+	return r
+}
+
+func (r *methodRunner) evaluate(eval EvaluationType) {
+	if eval&FuncEvaluation != 0 {
+		r.loc.F = r.p.Func(r.loc.X)
+	}
+	if eval&GradEvaluation != 0 {
+		r.p.Grad(r.loc.X, r.loc.Gradient)
+	}
+	if eval&HessEvaluation != 0 && r.loc.Hessian != nil {
+		r.p.Hess(r.loc.X, r.loc.Hessian)
+	}
+}
+
+// iterate performs the evaluations and the calls to Iterate until the next
+// major iteration. It does nothing after the Method has failed, for example
+// because it has converged to the precision of the problem.
+func (r *methodRunner) iterate() {
+	for r.err == nil {
+		copy(r.loc.X, r.xNext)
+		r.evaluate(r.eval)
+		var iterType IterationType
+		r.eval, iterType, r.err = r.method.Iterate(r.loc, r.xNext)
+		if iterType == MajorIteration {
+			return
+		}
+	}
+}
+
+// allocTests are the Methods whose iterations must not allocate.
+func allocTests() []struct {
+	name   string
+	method Method
+} {
+	return []struct {
+		name   string
+		method Method
+	}{
+		{"BFGS", &BFGS{}},
+		{"LBFGS", &LBFGS{}},
+		{"CG", &CG{}},
+		{"Newton", &Newton{}},
+	}
+}
+
+func allocProblem(dim int) (Problem, []float64) {
+	f := functions.ExtendedRosenbrock{}
+	p := Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		Hess: func(x []float64, hess *mat64.SymDense) {
+			for i := range x {
+				for j := i; j < len(x); j++ {
+					hess.SetSym(i, j, 0)
+				}
+			}
+			for i := 0; i < len(x)-1; i++ {
+				hess.SetSym(i, i, hess.At(i, i)+2+1200*x[i]*x[i]-400*x[i+1])
+				hess.SetSym(i, i+1, -400*x[i])
+				hess.SetSym(i+1, i+1, hess.At(i+1, i+1)+200)
+			}
+		},
+	}
+	x := make([]float64, dim)
+	for i := range x {
+		x[i] = -1.2
+		if i%2 == 1 {
+			x[i] = 1
+		}
+	}
+	return p, x
+}
+
+func TestIterationAllocations(t *testing.T) {
+	for _, test := range allocTests() {
+		p, x := allocProblem(10)
+		r := newMethodRunner(p, test.method, x)
+		// The first iterations may allocate workspace.
+		for i := 0; i < 5; i++ {
+			r.iterate()
+		}
+		if allocs := testing.AllocsPerRun(20, r.iterate); allocs != 0 {
+			t.Errorf("%s: %v allocations per major iteration", test.name, allocs)
+		}
+		if r.err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, r.err)
+		}
+	}
+}
+
+func benchmarkIteration(b *testing.B, method func() Method) {
+	p, x := allocProblem(100)
+	r := newMethodRunner(p, method(), x)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.iterate()
+		if r.err != nil {
+			// Restart the method after it has converged.
+			b.StopTimer()
+			r = newMethodRunner(p, method(), x)
+			b.StartTimer()
+		}
+	}
+}
+
+func BenchmarkBFGSIteration(b *testing.B) {
+	benchmarkIteration(b, func() Method { return &BFGS{} })
+}
+
+func BenchmarkLBFGSIteration(b *testing.B) {
+	benchmarkIteration(b, func() Method { return &LBFGS{} })
+}
+
+func BenchmarkCGIteration(b *testing.B) {
+	benchmarkIteration(b, func() Method { return &CG{} })
+}
+
+func BenchmarkNewtonIteration(b *testing.B) {
+	benchmarkIteration(b, func() Method { return &Newton{} })
+}
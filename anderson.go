@@ -0,0 +1,180 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// defaultAndersonRegularization is the default regularization of the normal
+// equations of Anderson relative to their largest diagonal element.
+const defaultAndersonRegularization = 1e-12
+
+// FixedPoint finds a fixed point x = g(x) starting from initX. The function g
+// stores g(x) in-place in dst and must not modify x. FixedPoint solves the
+// system F(x) = g(x) - x = 0 with Root, so settings, the Status and the
+// Stats of the result have the same meaning, and the F of the result is
+// g(X) - X. If method is nil, Anderson is used.
+func FixedPoint(g func(x, dst []float64), initX []float64, settings *RootSettings, method RootMethod) (*RootResult, error) {
+	if g == nil {
+		panic("optimize: fixed-point function is undefined")
+	}
+	if method == nil {
+		method = &Anderson{}
+	}
+	p := RootProblem{
+		Func: func(x, dst []float64) {
+			g(x, dst)
+			floats.Sub(dst, x)
+		},
+	}
+	return Root(p, initX, settings, method)
+}
+
+// Anderson implements Anderson acceleration, or Anderson mixing, of the
+// fixed-point iteration x_{k+1} = g(x_k) for the system F(x) = g(x) - x = 0.
+// It uses the residuals F at the last m+1 iterates to extrapolate the next
+// iterate: with the differences ΔX and ΔF of the iterates and the residuals,
+// it finds
+//  γ = argmin |F(x_k) - ΔF γ|_2,
+// and takes
+//  x_{k+1} = x_k + β F(x_k) - (ΔX + β ΔF) γ,
+// where β is the damping parameter. For m = 0 this is the damped fixed-point
+// iteration. Anderson only evaluates F, and every evaluation is a major
+// iteration. It is commonly used to accelerate slowly converging
+// self-consistent field and equilibrium iterations, and it is related to a
+// multisecant quasi-Newton method.
+//
+// References:
+//  - Anderson, D.G.: Iterative procedures for nonlinear integral equations.
+//    J. ACM 12 (1965), 547-560
+//  - Walker, H.F., Ni, P.: Anderson acceleration for fixed-point iterations.
+//    SIAM J. Numer. Anal. 49 (2011), 1715-1735
+type Anderson struct {
+	// Memory is the history depth m, the number of previous differences
+	// used in the extrapolation. If Memory is zero, it will be set to 5.
+	// If Memory is negative, no history is used and the method is the
+	// damped fixed-point iteration.
+	Memory int
+	// Damping is the damping parameter β in (0, 1]. If Damping is zero, it
+	// will be set to 1.
+	Damping float64
+	// Regularization is the Tikhonov regularization of the least-squares
+	// problem for γ relative to the largest squared norm of the residual
+	// differences. Larger values limit the growth of γ when the differences
+	// are nearly dependent. If Regularization is zero, it will be set to
+	// 1e-12. Anderson panics if Regularization is negative.
+	Regularization float64
+
+	x, f   []float64   // Last iterate and its residual.
+	dx, df [][]float64 // Differences of the iterates and the residuals.
+	oldest int         // Index of the oldest difference in the history.
+	count  int         // Number of differences in the history.
+	gamma  []float64
+	rhs    []float64
+	a      *mat64.SymDense
+	chol   *mat64.TriDense
+}
+
+func (an *Anderson) Init(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	if an.Memory == 0 {
+		an.Memory = 5
+	}
+	if an.Damping == 0 {
+		an.Damping = 1
+	}
+	if an.Damping < 0 || an.Damping > 1 {
+		panic("anderson: damping must be in (0, 1]")
+	}
+	if an.Regularization == 0 {
+		an.Regularization = defaultAndersonRegularization
+	}
+	if an.Regularization < 0 {
+		panic("anderson: regularization is negative")
+	}
+	n := len(loc.X)
+	m := an.Memory
+	if m < 0 {
+		m = 0
+	}
+	an.x = resize(an.x, n)
+	an.f = resize(an.f, n)
+	an.dx = resizeHistory(an.dx, m, n)
+	an.df = resizeHistory(an.df, m, n)
+	an.oldest = 0
+	an.count = 0
+	copy(an.x, loc.X)
+	copy(an.f, loc.F)
+	floats.AddScaledTo(xNext, an.x, an.Damping, an.f)
+	return FuncEvaluation, MajorIteration, nil
+}
+
+func (an *Anderson) Iterate(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	m := len(an.dx)
+	if m > 0 {
+		// Store the differences to the last iterate, replacing the oldest
+		// if the history is full.
+		i := (an.oldest + an.count) % m
+		if an.count == m {
+			an.oldest = (an.oldest + 1) % m
+		} else {
+			an.count++
+		}
+		floats.SubTo(an.dx[i], loc.X, an.x)
+		floats.SubTo(an.df[i], loc.F, an.f)
+	}
+	copy(an.x, loc.X)
+	copy(an.f, loc.F)
+
+	floats.AddScaledTo(xNext, an.x, an.Damping, an.f)
+	for an.count > 0 && !an.solve() {
+		// The differences are linearly dependent to working precision.
+		// Drop the oldest one.
+		an.oldest = (an.oldest + 1) % m
+		an.count--
+	}
+	for k := 0; k < an.count; k++ {
+		i := (an.oldest + k) % m
+		floats.AddScaled(xNext, -an.gamma[k], an.dx[i])
+		floats.AddScaled(xNext, -an.Damping*an.gamma[k], an.df[i])
+	}
+	return FuncEvaluation, MajorIteration, nil
+}
+
+// solve stores the solution of the least-squares problem for γ in an.gamma
+// and returns whether it is well defined.
+func (an *Anderson) solve() bool {
+	k := an.count
+	m := len(an.dx)
+	an.gamma = resize(an.gamma, k)
+	an.rhs = resize(an.rhs, k)
+	an.a = resizeSymDense(an.a, k)
+	an.chol = resizeTriDense(an.chol, k)
+	var maxDiag float64
+	for p := 0; p < k; p++ {
+		dp := an.df[(an.oldest+p)%m]
+		for q := p; q < k; q++ {
+			an.a.SetSym(p, q, floats.Dot(dp, an.df[(an.oldest+q)%m]))
+		}
+		an.rhs[p] = floats.Dot(dp, an.f)
+		if d := an.a.At(p, p); d > maxDiag {
+			maxDiag = d
+		}
+	}
+	if maxDiag == 0 {
+		return false
+	}
+	// Solve the regularized normal equations.
+	for p := 0; p < k; p++ {
+		an.a.SetSym(p, p, an.a.At(p, p)+an.Regularization*maxDiag)
+	}
+	if !an.chol.Cholesky(an.a, true) {
+		return false
+	}
+	g := mat64.NewVector(k, an.gamma)
+	g.SolveCholeskyVec(an.chol, mat64.NewVector(k, an.rhs))
+	return true
+}
@@ -0,0 +1,174 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestFixedPoint(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		g    func(x, dst []float64)
+		x    []float64
+		fp   []float64 // nil if the fixed point is not known in closed form.
+	}{
+		{
+			name: "Cos",
+			g: func(x, dst []float64) {
+				for i, v := range x {
+					dst[i] = math.Cos(v)
+				}
+			},
+			x:  []float64{0, 1, 2},
+			fp: []float64{0.7390851332151607, 0.7390851332151607, 0.7390851332151607},
+		},
+		{
+			// A linear contraction with spectral radius 0.95, for which the
+			// fixed-point iteration converges slowly.
+			name: "SlowLinear",
+			g: func(x, dst []float64) {
+				dst[0] = 0.95*x[0] + 0.05
+				dst[1] = 0.5*x[0] + 0.4*x[1] - 0.1*x[2]
+				dst[2] = 0.9*x[2] + 0.1*x[1] + 1
+			},
+			x: []float64{0, 0, 0},
+		},
+		{
+			// A nonlinear equilibrium x = A tanh(x) + b.
+			name: "Equilibrium",
+			g: func(x, dst []float64) {
+				n := len(x)
+				for i := range x {
+					v := 0.5 + 0.1*float64(i)
+					v += 0.45 * math.Tanh(x[(i+1)%n])
+					v += 0.45 * math.Tanh(x[(i+n-1)%n])
+					dst[i] = v
+				}
+			},
+			x: make([]float64, 20),
+		},
+	} {
+		for _, method := range []*Anderson{
+			{},
+			{Memory: 1},
+			{Memory: 10, Damping: 0.5},
+		} {
+			settings := DefaultRootSettings()
+			settings.MajorIterations = 200
+			result, err := FixedPoint(test.g, test.x, settings, method)
+			if err != nil {
+				t.Errorf("%s, memory %d: unexpected error: %v", test.name, method.Memory, err)
+				continue
+			}
+			if result.Status != MethodConverge {
+				t.Errorf("%s, memory %d: unexpected status %v", test.name, method.Memory, result.Status)
+			}
+			gx := make([]float64, len(test.x))
+			test.g(result.X, gx)
+			if d := floats.Distance(gx, result.X, math.Inf(1)); d > 1e-10 {
+				t.Errorf("%s, memory %d: not a fixed point, |g(x) - x| = %v", test.name, method.Memory, d)
+			}
+			if test.fp != nil && !floats.EqualApprox(result.X, test.fp, 1e-9) {
+				t.Errorf("%s, memory %d: unexpected fixed point. Want %v, got %v", test.name, method.Memory, test.fp, result.X)
+			}
+		}
+	}
+}
+
+func TestAndersonAcceleration(t *testing.T) {
+	// The damped fixed-point iteration with Memory < 0 needs many more
+	// iterations than Anderson acceleration on a slowly converging problem.
+	g := func(x, dst []float64) {
+		for i, v := range x {
+			dst[i] = 0.98*v + 0.02*math.Sin(float64(i)) + 0.001*math.Cos(v)
+		}
+	}
+	x := make([]float64, 5)
+	settings := DefaultRootSettings()
+	settings.MajorIterations = 5000
+	picard, err := FixedPoint(g, x, settings, &Anderson{Memory: -1})
+	if err != nil || picard.Status != MethodConverge {
+		t.Fatalf("fixed-point iteration failed: %v, %v", picard.Status, err)
+	}
+	anderson, err := FixedPoint(g, x, settings, &Anderson{})
+	if err != nil || anderson.Status != MethodConverge {
+		t.Fatalf("Anderson acceleration failed: %v, %v", anderson.Status, err)
+	}
+	if 10*anderson.MajorIterations > picard.MajorIterations {
+		t.Errorf("no acceleration: %d iterations with Anderson, %d without", anderson.MajorIterations, picard.MajorIterations)
+	}
+}
+
+func TestAndersonGradient(t *testing.T) {
+	// An ill-conditioned quadratic for which gradient descent with a fixed
+	// step converges slowly. The gradient is Lipschitz continuous with the
+	// constant 100.
+	quadratic := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i, v := range x {
+				c := math.Pow(10, float64(i)/2)
+				f += 0.5 * c * (v - 1) * (v - 1)
+			}
+			return f
+		},
+		Grad: func(x, grad []float64) {
+			for i, v := range x {
+				c := math.Pow(10, float64(i)/2)
+				grad[i] = c * (v - 1)
+			}
+		},
+	}
+	x := []float64{0, 0, 0, 0, 0}
+	settings := DefaultSettings()
+	settings.FunctionConverge = nil
+	settings.GradientThreshold = 1e-8
+	settings.MajorIterations = 10000
+	gd, err := Local(quadratic, x, settings, &GradientDescent{
+		LinesearchMethod: FixedStep{},
+		StepSizer:        ConstantStepSize{Size: 0.01},
+	})
+	if err != nil || gd.Status != GradientThreshold {
+		t.Fatalf("gradient descent failed: %v, %v", gd.Status, err)
+	}
+	for _, method := range []*AndersonGradient{
+		{StepSize: 0.01},
+		{StepSize: 0.01, Memory: 10, Regularization: 1e-10},
+		// The step size is too long and must be halved.
+		{StepSize: 0.05},
+	} {
+		result, err := Local(quadratic, x, settings, method)
+		if err != nil || result.Status != GradientThreshold {
+			t.Errorf("step size %v, memory %d: unexpected status %v, %v", method.StepSize, method.Memory, result.Status, err)
+			continue
+		}
+		if !floats.EqualApprox(result.X, []float64{1, 1, 1, 1, 1}, 1e-8) {
+			t.Errorf("step size %v, memory %d: unexpected minimum location %v", method.StepSize, method.Memory, result.X)
+		}
+		if 5*result.MajorIterations > gd.MajorIterations {
+			t.Errorf("step size %v, memory %d: no acceleration: %d iterations with Anderson, %d without",
+				method.StepSize, method.Memory, result.MajorIterations, gd.MajorIterations)
+		}
+	}
+
+	// Accelerate a damped gradient step with a diagonal preconditioner.
+	precond := func(loc *Location, dst []float64) {
+		for i, v := range loc.X {
+			c := math.Pow(10, float64(i)/2)
+			dst[i] = v - 0.5*loc.Gradient[i]/c
+		}
+	}
+	result, err := Local(quadratic, x, settings, &AndersonGradient{Map: precond})
+	if err != nil || result.Status != GradientThreshold {
+		t.Fatalf("preconditioned step: unexpected status %v, %v", result.Status, err)
+	}
+	if !floats.EqualApprox(result.X, []float64{1, 1, 1, 1, 1}, 1e-8) {
+		t.Errorf("preconditioned step: unexpected minimum location %v", result.X)
+	}
+}
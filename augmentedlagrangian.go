@@ -0,0 +1,388 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultAugLagGradientTol   = 1e-6
+	defaultAugLagConstraintTol = 1e-6
+	defaultAugLagPenalty       = 10
+	defaultAugLagIncrease      = 10
+	maxAugLagPenalty           = 1e20
+	// The penalty is increased if the violation of the constraints has not
+	// decreased by this factor in a major iteration.
+	augLagDecrease = 0.25
+)
+
+type augLagState int
+
+const (
+	augLagSubproblem augLagState = iota // The inner Method is running.
+	augLagMajor                         // A MajorIteration has been announced.
+	augLagRestore                       // The last inner major location is being evaluated.
+)
+
+// AugmentedLagrangian implements the augmented Lagrangian method for
+// minimization subject to the nonlinear constraints Problem.Equality and
+// Problem.Inequality. At every major iteration it minimizes the augmented
+// Lagrangian
+//  L(x) = f(x) + λᵀc_E(x) + ρ/2 |c_E(x)|^2
+//              + 1/(2ρ) Σ_i (max(0, μ_i + ρ c_I,i(x))^2 - μ_i^2)
+// with an inner Method, and then updates the multiplier estimates
+//  λ = λ + ρ c_E(x),  μ = max(0, μ + ρ c_I(x)).
+// The penalty parameter ρ is increased if the violation of the constraints
+// has not decreased sufficiently. The major iterations of AugmentedLagrangian
+// are the solutions of the subproblems, and the iterations of the inner
+// Method are reported as minor iterations.
+//
+// The optimization terminates with MethodConverge when a subproblem has been
+// solved and the constraint violation, including the violation of
+// complementarity for the inequality constraints, is below
+// ConstraintTolerance. Because the gradient of the Lagrangian
+// f + λᵀc_E + μᵀc_I with the updated multipliers equals the gradient of the
+// augmented Lagrangian, the first-order optimality conditions then hold to
+// within GradientTolerance.
+//
+// Bound constraints given by Problem.Bounds are passed on to the inner Method.
+//
+// Reference:
+//  Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//  (2006), Section 17.4
+type AugmentedLagrangian struct {
+	// Method is the Method used to minimize the augmented Lagrangian. It
+	// must use the gradient but not the Hessian of the objective function,
+	// and it must handle bounds if the problem has them.
+	// If Method is nil, it will be set to LBFGSB.
+	Method Method
+	// GradientTolerance is the tolerance on the infinity norm of the
+	// (projected) gradient of the augmented Lagrangian to which the
+	// subproblems are solved.
+	// If GradientTolerance is zero, it will be set to 1e-6.
+	GradientTolerance float64
+	// ConstraintTolerance is the tolerance on the violation of the
+	// constraints.
+	// If ConstraintTolerance is zero, it will be set to 1e-6.
+	ConstraintTolerance float64
+	// Penalty is the initial penalty parameter ρ.
+	// If Penalty is zero, it will be set to 10.
+	Penalty float64
+	// Increase is the factor by which the penalty parameter is increased.
+	// It must be greater than one.
+	// If Increase is zero, it will be set to 10.
+	Increase float64
+
+	info   ProblemInfo // Problem information for the inner Method.
+	eq, in Constraint
+	status Status
+
+	state    augLagState
+	evalType EvaluationType // Last evaluation type requested by Method.
+	iterType IterationType  // Last iteration type returned by Method.
+	majors   int            // Number of major iterations of Method in the current subproblem.
+	stalled  bool           // Whether the last subproblem ended without progress.
+
+	rho    float64
+	viol   float64 // Violation measure at the last major iteration.
+	lambda []float64
+	mu     []float64
+
+	inner  Location  // Location seen by Method.
+	xMajor []float64 // Location of the last major iteration of Method.
+
+	cEq, cIn     []float64
+	jacEq, jacIn *mat64.Dense
+	w            []float64
+}
+
+func (al *AugmentedLagrangian) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if al.Method == nil {
+		al.Method = &LBFGSB{}
+	}
+	if al.GradientTolerance == 0 {
+		al.GradientTolerance = defaultAugLagGradientTol
+	}
+	if al.ConstraintTolerance == 0 {
+		al.ConstraintTolerance = defaultAugLagConstraintTol
+	}
+	if al.Penalty == 0 {
+		al.Penalty = defaultAugLagPenalty
+	}
+	if al.Increase == 0 {
+		al.Increase = defaultAugLagIncrease
+	}
+	if al.GradientTolerance < 0 {
+		panic("auglag: negative gradient tolerance")
+	}
+	if al.ConstraintTolerance < 0 {
+		panic("auglag: negative constraint tolerance")
+	}
+	if al.Penalty < 0 {
+		panic("auglag: negative penalty")
+	}
+	if al.Increase <= 1 {
+		panic("auglag: Increase must be greater than 1")
+	}
+	needs := al.Method.Needs()
+	if !needs.Gradient || needs.Hessian {
+		return NoEvaluation, NoIteration, errors.New("auglag: inner method must use the gradient and not the Hessian")
+	}
+	if p.Bounds != nil {
+		if b, ok := al.Method.(BoundsHandler); !ok || !b.HandlesBounds() {
+			return NoEvaluation, NoIteration, errors.New("auglag: inner method does not handle bound constraints")
+		}
+	}
+
+	dim := len(loc.X)
+	al.info = ProblemInfo{
+		HasGradient: p.HasGradient,
+		Bounds:      p.Bounds,
+	}
+	al.eq = p.Equality
+	al.in = p.Inequality
+	al.status = NotTerminated
+	al.rho = al.Penalty
+	al.viol = math.Inf(1)
+	al.stalled = false
+
+	var nEq, nIn int
+	if al.eq != nil {
+		nEq = al.eq.Len()
+		al.jacEq = resizeDense(al.jacEq, nEq, dim)
+	}
+	if al.in != nil {
+		nIn = al.in.Len()
+		al.jacIn = resizeDense(al.jacIn, nIn, dim)
+	}
+	al.cEq = resize(al.cEq, nEq)
+	al.cIn = resize(al.cIn, nIn)
+	al.lambda = resize(al.lambda, nEq)
+	al.mu = resize(al.mu, nIn)
+	for i := range al.lambda {
+		al.lambda[i] = 0
+	}
+	for i := range al.mu {
+		al.mu[i] = 0
+	}
+	if nEq > nIn {
+		al.w = resize(al.w, nEq)
+	} else {
+		al.w = resize(al.w, nIn)
+	}
+	al.xMajor = resize(al.xMajor, dim)
+	al.inner.X = resize(al.inner.X, dim)
+	al.inner.Gradient = resize(al.inner.Gradient, dim)
+	al.inner.Hessian = nil
+
+	al.evaluateConstraints(loc.X, FuncEvaluation|GradEvaluation)
+	return al.initSubproblem(loc, xNext)
+}
+
+func (al *AugmentedLagrangian) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch al.state {
+	case augLagMajor:
+		return al.initSubproblem(loc, xNext)
+	case augLagRestore:
+		al.evaluateConstraints(loc.X, FuncEvaluation|GradEvaluation)
+		return al.finishSubproblem(loc, xNext)
+	}
+
+	al.evaluateConstraints(loc.X, al.evalType)
+	al.evaluateInner(loc, al.evalType)
+	if al.iterType == MajorIteration {
+		al.majors++
+		copy(al.xMajor, loc.X)
+		if al.info.Bounds.ProjectedGradientNorm(al.inner.X, al.inner.Gradient) <= al.GradientTolerance {
+			return al.finishSubproblem(loc, xNext)
+		}
+	}
+
+	evalType, iterType, err := al.Method.Iterate(&al.inner, xNext)
+	if err != nil {
+		// The inner Method cannot make further progress, which is usually
+		// caused by a tolerance that is too tight for the current penalty.
+		// Accept the last major iteration of the inner Method, or the
+		// starting location of the subproblem, instead. Give up if two
+		// subsequent subproblems fail without any progress.
+		if al.majors == 0 && al.stalled {
+			return NoEvaluation, NoIteration, err
+		}
+		al.stalled = al.majors == 0
+		copy(xNext, al.xMajor)
+		al.state = augLagRestore
+		return FuncEvaluation | GradEvaluation, MinorIteration, nil
+	}
+	al.evalType = evalType
+	al.iterType = iterType
+	if iterType == MajorIteration {
+		iterType = MinorIteration
+	}
+	return evalType, iterType, nil
+}
+
+// initSubproblem initializes the inner Method at loc for the minimization of
+// the augmented Lagrangian with the current multipliers and penalty.
+func (al *AugmentedLagrangian) initSubproblem(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	al.state = augLagSubproblem
+	al.majors = 0
+	copy(al.xMajor, loc.X)
+	al.evaluateInner(loc, FuncEvaluation|GradEvaluation)
+	evalType, iterType, err := al.Method.Init(&al.inner, &al.info, xNext)
+	if err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	al.evalType = evalType
+	al.iterType = iterType
+	if iterType == MajorIteration {
+		iterType = MinorIteration
+	}
+	return evalType, iterType, nil
+}
+
+// finishSubproblem updates the multipliers and the penalty parameter after a
+// subproblem has been solved at loc, and announces a MajorIteration.
+func (al *AugmentedLagrangian) finishSubproblem(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	var viol float64
+	for i, c := range al.cEq {
+		viol = math.Max(viol, math.Abs(c))
+		al.lambda[i] += al.rho * c
+	}
+	for i, c := range al.cIn {
+		// The violation of an inequality constraint includes the violation
+		// of complementarity, min(-c, μ) != 0.
+		viol = math.Max(viol, math.Abs(math.Max(c, -al.mu[i]/al.rho)))
+		al.mu[i] = math.Max(0, al.mu[i]+al.rho*c)
+	}
+	if viol <= al.ConstraintTolerance {
+		al.status = MethodConverge
+	}
+	if al.majors > 0 {
+		al.stalled = false
+	}
+	if viol > augLagDecrease*al.viol {
+		al.rho = math.Min(al.Increase*al.rho, maxAugLagPenalty)
+	}
+	al.viol = viol
+
+	copy(xNext, loc.X)
+	al.state = augLagMajor
+	return NoEvaluation, MajorIteration, nil
+}
+
+// evaluateConstraints evaluates the constraint functions at x, and their
+// Jacobians if evalType includes GradEvaluation.
+func (al *AugmentedLagrangian) evaluateConstraints(x []float64, evalType EvaluationType) {
+	if evalType == NoEvaluation {
+		return
+	}
+	if al.eq != nil {
+		al.eq.Func(x, al.cEq)
+		if evalType&GradEvaluation != 0 {
+			al.eq.Jac(x, al.jacEq)
+		}
+	}
+	if al.in != nil {
+		al.in.Func(x, al.cIn)
+		if evalType&GradEvaluation != 0 {
+			al.in.Jac(x, al.jacIn)
+		}
+	}
+}
+
+// evaluateInner stores the value and the gradient of the augmented Lagrangian
+// at loc into the location seen by the inner Method, as requested by
+// evalType.
+func (al *AugmentedLagrangian) evaluateInner(loc *Location, evalType EvaluationType) {
+	copy(al.inner.X, loc.X)
+	if evalType&FuncEvaluation != 0 {
+		f := loc.F
+		for i, c := range al.cEq {
+			f += al.lambda[i]*c + 0.5*al.rho*c*c
+		}
+		for i, c := range al.cIn {
+			m := math.Max(0, al.mu[i]+al.rho*c)
+			f += (m*m - al.mu[i]*al.mu[i]) / (2 * al.rho)
+		}
+		al.inner.F = f
+	}
+	if evalType&GradEvaluation != 0 {
+		copy(al.inner.Gradient, loc.Gradient)
+		if al.eq != nil {
+			w := al.w[:len(al.cEq)]
+			for i, c := range al.cEq {
+				w[i] = al.lambda[i] + al.rho*c
+			}
+			addJacTVec(al.inner.Gradient, al.jacEq, w)
+		}
+		if al.in != nil {
+			w := al.w[:len(al.cIn)]
+			for i, c := range al.cIn {
+				w[i] = math.Max(0, al.mu[i]+al.rho*c)
+			}
+			addJacTVec(al.inner.Gradient, al.jacIn, w)
+		}
+	}
+}
+
+// Status returns MethodConverge when the constraints are satisfied after the
+// last major iteration.
+func (al *AugmentedLagrangian) Status() (Status, error) {
+	return al.status, nil
+}
+
+// Multipliers stores the multiplier estimates of the last major iteration into
+// eq and ineq.
+func (al *AugmentedLagrangian) Multipliers(eq, ineq []float64) {
+	copy(eq, al.lambda)
+	copy(ineq, al.mu)
+}
+
+// HandlesBounds returns whether the inner Method handles bounds. It returns
+// true if the inner Method is nil, because LBFGSB will be used.
+func (al *AugmentedLagrangian) HandlesBounds() bool {
+	if al.Method == nil {
+		return true
+	}
+	b, ok := al.Method.(BoundsHandler)
+	return ok && b.HandlesBounds()
+}
+
+func (*AugmentedLagrangian) HandlesConstraints() bool {
+	return true
+}
+
+func (*AugmentedLagrangian) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// addJacTVec adds jacᵀw to dst.
+func addJacTVec(dst []float64, jac *mat64.Dense, w []float64) {
+	for i, v := range w {
+		if v == 0 {
+			continue
+		}
+		for j := range dst {
+			dst[j] += v * jac.At(i, j)
+		}
+	}
+}
+
+func resizeDense(m *mat64.Dense, r, c int) *mat64.Dense {
+	if m == nil || cap(m.RawMatrix().Data) < r*c {
+		return mat64.NewDense(r, c, nil)
+	}
+	return mat64.NewDense(r, c, m.RawMatrix().Data[:r*c])
+}
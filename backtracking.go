@@ -24,7 +24,7 @@ const (
 // panic otherwise. If either FunConst or Decrease are zero, it will be set to a
 // reasonable default.
 type Backtracking struct {
-	FunConst float64 // Necessary function descrease for Armijo condition.
+	FunConst float64 // Necessary function decrease for Armijo condition.
 	Decrease float64 // Step size multiplier at each iteration (stepSize *= Decrease).
 
 	stepSize float64
@@ -37,7 +37,7 @@ func (b *Backtracking) Init(loc LinesearchLocation, step float64, _ *ProblemInfo
 		panic("backtracking: bad step size")
 	}
 	if loc.Derivative >= 0 {
-		panic("Backtracking: init G non-negative")
+		panic("backtracking: initial derivative is non-negative")
 	}
 
 	if b.Decrease == 0 {
@@ -0,0 +1,161 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+// BBStep selects the formula of the Barzilai-Borwein step from the previous
+// step s and the corresponding change in the gradient y.
+type BBStep int
+
+const (
+	// BB1Step uses the long step
+	//  sᵀs / sᵀy.
+	BB1Step BBStep = iota
+	// BB2Step uses the short step
+	//  sᵀy / yᵀy.
+	BB2Step
+	// AlternatingBBStep alternates between BB1Step and BB2Step, starting with
+	// BB1Step. Alternating the steps is often faster than either of them.
+	AlternatingBBStep
+)
+
+// BarzilaiBorwein implements the Barzilai-Borwein gradient method. It moves
+// along the negative gradient with the initial step size chosen by the
+// formula selected by Step, which is the inverse of a Rayleigh quotient of the
+// average Hessian along the previous step. The zero value of Step is BB1Step.
+// If the curvature along the previous step is not positive, the step is
+// chosen so that no variable moves by more than one, as in the first
+// iteration.
+//
+// The Barzilai-Borwein steps do not decrease the function monotonically, and
+// a line search that insists on a decrease at every iteration destroys their
+// efficiency. The LinesearchMethod is therefore a Nonmonotone line search by
+// default, which accepts the Barzilai-Borwein step in most iterations while
+// guaranteeing global convergence. If LinesearchMethod is nil, it will be set
+// to Nonmonotone.
+//
+// BarzilaiBorwein only needs the gradient and has a very small cost per
+// iteration, and it is often competitive with CG. For bound constrained
+// problems, ProjectedGradient implements its projected variant.
+//
+// References:
+//  Barzilai, J., Borwein, J.M.: Two-point step size gradient methods. IMA J.
+//  Numer. Anal. 8 (1988), 141-148
+//  Raydan, M.: The Barzilai and Borwein gradient method for the large scale
+//  unconstrained minimization problem. SIAM J Optim 7 (1997), 26-33
+type BarzilaiBorwein struct {
+	LinesearchMethod LinesearchMethod
+	Step             BBStep
+
+	linesearch *Linesearch
+
+	x    []float64 // Location at the last major iteration.
+	grad []float64 // Gradient at the last major iteration.
+	s, y []float64
+	iter int // Number of Barzilai-Borwein steps.
+}
+
+func (bb *BarzilaiBorwein) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if bb.LinesearchMethod == nil {
+		bb.LinesearchMethod = &Nonmonotone{}
+	}
+	if bb.linesearch == nil {
+		bb.linesearch = &Linesearch{}
+	}
+	bb.linesearch.Method = bb.LinesearchMethod
+	bb.linesearch.NextDirectioner = bb
+
+	return bb.linesearch.Init(loc, p, xNext)
+}
+
+func (bb *BarzilaiBorwein) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	return bb.linesearch.Iterate(loc, xNext)
+}
+
+func (bb *BarzilaiBorwein) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	bb.x = resize(bb.x, dim)
+	bb.grad = resize(bb.grad, dim)
+	bb.s = resize(bb.s, dim)
+	bb.y = resize(bb.y, dim)
+	copy(bb.x, loc.X)
+	copy(bb.grad, loc.Gradient)
+	bb.iter = 0
+
+	copy(dir, loc.Gradient)
+	floats.Scale(-1, dir)
+	return 1 / floats.Norm(loc.Gradient, math.Inf(1))
+}
+
+// RestartDirection discards the previous step.
+func (bb *BarzilaiBorwein) RestartDirection(loc *Location, dir []float64) (stepSize float64) {
+	return bb.InitDirection(loc, dir)
+}
+
+func (bb *BarzilaiBorwein) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	floats.SubTo(bb.s, loc.X, bb.x)
+	floats.SubTo(bb.y, loc.Gradient, bb.grad)
+	copy(bb.x, loc.X)
+	copy(bb.grad, loc.Gradient)
+
+	copy(dir, loc.Gradient)
+	floats.Scale(-1, dir)
+	stepSize, ok := bbStepSize(bb.s, bb.y, bb.Step, bb.iter)
+	bb.iter++
+	if !ok {
+		return 1 / floats.Norm(loc.Gradient, math.Inf(1))
+	}
+	return stepSize
+}
+
+// Clone returns a new BarzilaiBorwein with the configuration of bb and a copy
+// of its LinesearchMethod.
+func (bb *BarzilaiBorwein) Clone() Method {
+	return &BarzilaiBorwein{
+		LinesearchMethod: cloneLinesearchMethod(bb.LinesearchMethod),
+		Step:             bb.Step,
+	}
+}
+
+func (*BarzilaiBorwein) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// bbStepSize returns the Barzilai-Borwein step for the k-th step, k = 0, 1, ...,
+// bounded to [minSpectralStep, maxSpectralStep]. It returns false if the
+// curvature sᵀy is not positive.
+func bbStepSize(s, y []float64, rule BBStep, k int) (float64, bool) {
+	sy := floats.Dot(s, y)
+	if sy <= 0 {
+		return 0, false
+	}
+	var step float64
+	switch rule {
+	case BB1Step:
+		step = floats.Dot(s, s) / sy
+	case BB2Step:
+		step = sy / floats.Dot(y, y)
+	case AlternatingBBStep:
+		if k%2 == 0 {
+			step = floats.Dot(s, s) / sy
+		} else {
+			step = sy / floats.Dot(y, y)
+		}
+	default:
+		panic("optimize: unknown BBStep")
+	}
+	return math.Max(minSpectralStep, math.Min(step, maxSpectralStep)), true
+}
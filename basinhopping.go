@@ -0,0 +1,188 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultBasinHoppingIterations = 100
+	defaultBasinHoppingStepSize   = 0.5
+)
+
+// BasinHopping is a global optimization driver that explores the local
+// minima of a function by a random walk. In every iteration, the current
+// local minimum is perturbed by a random step, a local Method is run from
+// the perturbed location, and the new local minimum is accepted as the
+// current one according to the Metropolis criterion: always if its function
+// value is lower, and with probability
+//  exp(-(f_new - f_current) / Temperature)
+// otherwise. The best accepted local minimum is returned.
+//
+// If the Problem has Bounds, the perturbed locations are projected onto
+// the feasible region. The local runs are independent calls to Local with a
+// copy of the settings.
+//
+// References:
+//  - Wales, D.J., Doye, J.P.K.: Global optimization by basin-hopping and the
+//    lowest energy structures of Lennard-Jones clusters containing up to 110
+//    atoms. J. Phys. Chem. A 101 (1997), 5111-5116
+type BasinHopping struct {
+	// Method returns the Method for a local run. It is called once for
+	// every local run. If Method is nil, the default Method of Local is
+	// used.
+	Method func() Method
+	// Iterations is the number of perturbations of the current minimum. If
+	// Iterations is zero, it will be set to 100.
+	Iterations int
+	// Stall stops the walk early if the best minimum has not improved in
+	// Stall consecutive iterations. If Stall is zero, the walk is not
+	// stopped early.
+	Stall int
+	// Temperature is the temperature of the Metropolis criterion. It should
+	// be comparable to the difference of the function values of
+	// neighboring local minima. If Temperature is zero, it will be set to 1.
+	Temperature float64
+	// StepSize is the half-width of the interval from which the components
+	// of the random step are sampled uniformly. If StepSize is zero, it
+	// will be set to 0.5. StepSize is ignored if TakeStep is not nil.
+	StepSize float64
+	// TakeStep perturbs x in place. If TakeStep is nil, every component is
+	// displaced by a uniform random number in [-StepSize, StepSize].
+	TakeStep func(x []float64, rnd *rand.Rand)
+	// Accept is an additional acceptance test of a new local minimum. If
+	// Accept is not nil and returns false, the minimum is rejected
+	// regardless of the Metropolis criterion.
+	Accept func(x []float64, f float64) bool
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+}
+
+// BasinHoppingResult is the result of BasinHopping.
+type BasinHoppingResult struct {
+	// Minimum is the result of the local run that found the lowest
+	// function value.
+	Minimum *Result
+	// Stats are the statistics summed over all local runs, except Runtime,
+	// which is the total wall-clock time.
+	Stats
+	// Iterations is the number of perturbations, Accepted is the number
+	// of accepted local minima and Failed is the number of local runs that
+	// have returned an error.
+	Iterations int
+	Accepted   int
+	Failed     int
+}
+
+// Minimize runs the basin hopping walk for the problem p starting from the
+// local minimum found from initX. If settings is nil, the default settings
+// are used. Minimize returns an error if the first local run fails. Minimize
+// panics if settings.Maximize is true.
+func (bh *BasinHopping) Minimize(p Problem, initX []float64, settings *Settings) (*BasinHoppingResult, error) {
+	iterations := bh.Iterations
+	if iterations == 0 {
+		iterations = defaultBasinHoppingIterations
+	}
+	if iterations < 0 {
+		panic("optimize: negative number of iterations")
+	}
+	if bh.Stall < 0 {
+		panic("optimize: negative stall")
+	}
+	temp := bh.Temperature
+	if temp == 0 {
+		temp = 1
+	}
+	if temp < 0 {
+		panic("optimize: negative temperature")
+	}
+	stepSize := bh.StepSize
+	if stepSize == 0 {
+		stepSize = defaultBasinHoppingStepSize
+	}
+	if stepSize < 0 {
+		panic("optimize: negative step size")
+	}
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	rnd := newRand(bh.Src, settings.Src)
+	if settings.Maximize {
+		panic("optimize: maximization is not supported")
+	}
+
+	startTime := time.Now()
+	res := &BasinHoppingResult{}
+	localMin := func(x []float64) (*Result, error) {
+		s := *settings
+		if s.FunctionConverge != nil {
+			fc := *s.FunctionConverge
+			s.FunctionConverge = &fc
+		}
+		var method Method
+		if bh.Method != nil {
+			method = bh.Method()
+		}
+		r, err := Local(p, x, &s, method)
+		if r != nil {
+			res.MajorIterations += r.MajorIterations
+			res.FuncEvaluations += r.FuncEvaluations
+			res.GradEvaluations += r.GradEvaluations
+			res.HessEvaluations += r.HessEvaluations
+			res.HessVecEvaluations += r.HessVecEvaluations
+		}
+		return r, err
+	}
+
+	cur, err := localMin(initX)
+	if err != nil {
+		res.Failed++
+		res.Runtime = time.Since(startTime)
+		return res, err
+	}
+	res.Minimum = cur
+	x := make([]float64, len(initX))
+	var stall int
+	for res.Iterations < iterations {
+		res.Iterations++
+		copy(x, cur.X)
+		if bh.TakeStep != nil {
+			bh.TakeStep(x, rnd)
+		} else {
+			for i := range x {
+				x[i] += stepSize * (2*rnd.Float64() - 1)
+			}
+		}
+		if p.Bounds != nil {
+			p.Bounds.Project(x)
+		}
+
+		r, err := localMin(x)
+		stall++
+		if err != nil {
+			res.Failed++
+		} else {
+			accept := r.F < cur.F || rnd.Float64() < math.Exp(-(r.F-cur.F)/temp)
+			if accept && (bh.Accept == nil || bh.Accept(r.X, r.F)) {
+				cur = r
+				res.Accepted++
+				if r.F < res.Minimum.F {
+					res.Minimum = r
+					stall = 0
+				}
+			}
+		}
+		if bh.Stall > 0 && stall >= bh.Stall {
+			break
+		}
+	}
+	res.Runtime = time.Since(startTime)
+	return res, nil
+}
@@ -0,0 +1,80 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBasinHopping(t *testing.T) {
+	// A one-dimensional function with many local minima and the global
+	// minimum near x = -0.195.
+	p := Problem{
+		Func: func(x []float64) float64 {
+			return math.Cos(14.5*x[0]-0.3) + (x[0]+0.2)*x[0]
+		},
+		Grad: func(x, grad []float64) {
+			grad[0] = -14.5*math.Sin(14.5*x[0]-0.3) + 2*x[0] + 0.2
+		},
+	}
+	const (
+		xOpt = -0.1950676
+		fOpt = -1.0008762
+	)
+
+	// A single local run from x = 1 finds a local minimum only.
+	local, err := Local(p, []float64{1}, nil, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if local.F < fOpt+0.1 {
+		t.Fatalf("local run found the global minimum")
+	}
+
+	for _, bh := range []*BasinHopping{
+		{Src: rand.NewSource(1)},
+		{Src: rand.NewSource(1), Method: func() Method { return &LBFGS{} }, Stall: 30},
+		{
+			Src: rand.NewSource(1),
+			TakeStep: func(x []float64, rnd *rand.Rand) {
+				x[0] += rnd.NormFloat64() * 0.3
+			},
+		},
+	} {
+		result, err := bh.Minimize(p, []float64{1}, nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if math.Abs(result.Minimum.X[0]-xOpt) > 1e-5 || math.Abs(result.Minimum.F-fOpt) > 1e-7 {
+			t.Errorf("global minimum not found: x = %v, f = %v", result.Minimum.X[0], result.Minimum.F)
+		}
+		if result.Accepted == 0 || result.Accepted > result.Iterations {
+			t.Errorf("unexpected number of accepted minima %d in %d iterations", result.Accepted, result.Iterations)
+		}
+		if bh.Stall == 0 && result.Iterations != defaultBasinHoppingIterations {
+			t.Errorf("unexpected number of iterations %d", result.Iterations)
+		}
+		if result.FuncEvaluations <= result.Minimum.FuncEvaluations {
+			t.Errorf("unexpected total number of evaluations %d", result.FuncEvaluations)
+		}
+	}
+
+	// Rejecting all new minima keeps the first local minimum.
+	bh := &BasinHopping{
+		Iterations: 10,
+		Src:        rand.NewSource(1),
+		Accept:     func(x []float64, f float64) bool { return false },
+	}
+	result, err := bh.Minimize(p, []float64{1}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Accepted != 0 || result.Minimum.F != local.F {
+		t.Errorf("rejected minima accepted")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"sync"
+
+	"github.com/gonum/floats"
+)
+
+// batchEvaluator evaluates the batches of a Batcher concurrently and
+// hands out the results in the order in which the Method requests them.
+type batchEvaluator struct {
+	p          *Problem
+	method     Batcher
+	concurrent int
+
+	evalType EvaluationType
+	locs     []*Location // Evaluated locations of the current batch.
+	next     int         // Index of the next location to be requested.
+}
+
+// evaluate performs the evalType evaluation at xNext and stores the result
+// in loc like the function evaluate, but takes the result from the current
+// batch if possible and evaluates a new batch if needed.
+func (b *batchEvaluator) evaluate(evalType EvaluationType, xNext []float64, loc *Location, stats *Stats) {
+	if evalType == NoEvaluation || floats.Equal(loc.X, xNext) {
+		evaluate(b.p, evalType, xNext, loc, stats)
+		return
+	}
+	if !b.pending(evalType, xNext) {
+		b.fill(len(xNext), stats)
+		if !b.pending(evalType, xNext) {
+			// The Method does not request the locations it announced.
+			b.locs = b.locs[:0]
+			evaluate(b.p, evalType, xNext, loc, stats)
+			return
+		}
+	}
+	res := b.locs[b.next]
+	b.next++
+	invalidate(loc)
+	copy(loc.X, xNext)
+	if evalType&FuncEvaluation != 0 {
+		loc.F = res.F
+	}
+	if evalType&GradEvaluation != 0 {
+		copy(loc.Gradient, res.Gradient)
+	}
+	if evalType&HessEvaluation != 0 {
+		loc.Hessian.CopySym(res.Hessian)
+	}
+}
+
+// pending returns whether the next location of the current batch is x with
+// evaluations of evalType.
+func (b *batchEvaluator) pending(evalType EvaluationType, x []float64) bool {
+	return b.next < len(b.locs) && b.evalType == evalType && floats.Equal(b.locs[b.next].X, x)
+}
+
+// fill evaluates the next batch of the Method concurrently.
+func (b *batchEvaluator) fill(dim int, stats *Stats) {
+	evalType, xs := b.method.Batch()
+	b.evalType = evalType
+	b.next = 0
+	if cap(b.locs) < len(xs) {
+		b.locs = append(b.locs[:cap(b.locs)], make([]*Location, len(xs)-cap(b.locs))...)
+	}
+	b.locs = b.locs[:len(xs)]
+	for i, x := range xs {
+		loc := b.locs[i]
+		if loc == nil {
+			loc = &Location{}
+			b.locs[i] = loc
+		}
+		loc.X = resize(loc.X, dim)
+		copy(loc.X, x)
+		if evalType&GradEvaluation != 0 {
+			loc.Gradient = resize(loc.Gradient, dim)
+		}
+		if evalType&HessEvaluation != 0 {
+			loc.Hessian = resizeSymDense(loc.Hessian, dim)
+		}
+	}
+
+	workers := b.concurrent
+	if workers > len(xs) {
+		workers = len(xs)
+	}
+	jobs := make(chan *Location)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for loc := range jobs {
+				evaluateAt(b.p, evalType, loc)
+			}
+		}()
+	}
+	for _, loc := range b.locs {
+		jobs <- loc
+	}
+	close(jobs)
+	wg.Wait()
+
+	n := len(xs)
+	if evalType&FuncEvaluation != 0 {
+		stats.FuncEvaluations += n
+	}
+	if evalType&GradEvaluation != 0 {
+		stats.GradEvaluations += n
+	}
+	if evalType&HessEvaluation != 0 {
+		stats.HessEvaluations += n
+	}
+}
+
+// evaluateAt performs the evalType evaluation at loc.X without updating the
+// statistics.
+func evaluateAt(p *Problem, evalType EvaluationType, loc *Location) {
+	if p.FuncGrad != nil && evalType&(FuncEvaluation|GradEvaluation) == FuncEvaluation|GradEvaluation {
+		loc.F = p.FuncGrad(loc.X, loc.Gradient)
+		evalType &= ^(FuncEvaluation | GradEvaluation)
+	}
+	if evalType&FuncEvaluation != 0 {
+		loc.F = p.Func(loc.X)
+	}
+	if evalType&GradEvaluation != 0 {
+		p.Grad(loc.X, loc.Gradient)
+	}
+	if evalType&HessEvaluation != 0 {
+		p.Hess(loc.X, loc.Hessian)
+	}
+}
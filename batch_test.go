@@ -0,0 +1,69 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestConcurrent(t *testing.T) {
+	bounds := &Bounds{
+		Lower: []float64{-5.12, -5.12, -5.12},
+		Upper: []float64{5.12, 5.12, 5.12},
+	}
+	for _, test := range []struct {
+		name   string
+		method func() Method
+	}{
+		{"DifferentialEvolution", func() Method {
+			return &DifferentialEvolution{Src: rand.NewSource(1)}
+		}},
+		{"ParticleSwarm", func() Method {
+			return &ParticleSwarm{Src: rand.NewSource(1)}
+		}},
+	} {
+		var results []*Result
+		for _, concurrent := range []int{0, 4} {
+			var evals int64
+			p := Problem{
+				Func: func(x []float64) float64 {
+					atomic.AddInt64(&evals, 1)
+					return rastrigin(x)
+				},
+				Bounds: bounds,
+			}
+			settings := DefaultSettings()
+			settings.Recorder = nil
+			settings.Concurrent = concurrent
+			result, err := Local(p, []float64{1, 2, 3}, settings, test.method())
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+				break
+			}
+			if int(evals) != result.FuncEvaluations {
+				t.Errorf("%s, concurrent %d: evaluation count mismatch, evaluated %d, reported %d",
+					test.name, concurrent, evals, result.FuncEvaluations)
+			}
+			results = append(results, result)
+		}
+		if len(results) != 2 {
+			continue
+		}
+		// The locations of whole generations are evaluated in advance, so
+		// the concurrent run finds the same locations as the sequential one,
+		// but it may evaluate the rest of the last generation.
+		if results[0].F != results[1].F || !floats.Equal(results[0].X, results[1].X) ||
+			results[0].MajorIterations != results[1].MajorIterations {
+			t.Errorf("%s: concurrent result differs from sequential result", test.name)
+		}
+		if results[1].FuncEvaluations < results[0].FuncEvaluations {
+			t.Errorf("%s: fewer concurrent evaluations than sequential evaluations", test.name)
+		}
+	}
+}
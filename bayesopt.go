@@ -0,0 +1,337 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultBayesOptCandidates = 1000
+	defaultAcquisitionEvals   = 200
+)
+
+// Acquisition is an acquisition function of Bayesian optimization. It
+// measures how desirable it is to evaluate the function at a location where
+// the predictive distribution of the function value has the given mean and
+// standard deviation, given the lowest function value observed so far.
+// Larger values of Acquire are more desirable.
+type Acquisition interface {
+	Acquire(mean, std, best float64) float64
+}
+
+// ExpectedImprovement is the expected improvement
+//  E[max(best - f - Xi, 0)]
+// of the function value f over the lowest observed value. Xi is a
+// non-negative margin that favors exploration.
+type ExpectedImprovement struct {
+	Xi float64
+}
+
+func (ei ExpectedImprovement) Acquire(mean, std, best float64) float64 {
+	d := best - mean - ei.Xi
+	if std == 0 {
+		return math.Max(d, 0)
+	}
+	z := d / std
+	return d*normalCDF(z) + std*normalPDF(z)
+}
+
+// ProbabilityOfImprovement is the probability
+//  P[f < best - Xi]
+// that the function value f improves on the lowest observed value by at least
+// the non-negative margin Xi.
+type ProbabilityOfImprovement struct {
+	Xi float64
+}
+
+func (pi ProbabilityOfImprovement) Acquire(mean, std, best float64) float64 {
+	d := best - mean - pi.Xi
+	if std == 0 {
+		if d > 0 {
+			return 1
+		}
+		return 0
+	}
+	return normalCDF(d / std)
+}
+
+// UpperConfidenceBound is the confidence bound
+//  -(mean - Kappa std)
+// of the function value. For minimization, the lower bound of the function
+// value is used, which is negated so that larger values are more desirable.
+// Larger values of Kappa favor exploration. If Kappa is zero, 2 is used
+// instead.
+type UpperConfidenceBound struct {
+	Kappa float64
+}
+
+func (ucb UpperConfidenceBound) Acquire(mean, std, best float64) float64 {
+	kappa := ucb.Kappa
+	if kappa == 0 {
+		kappa = 2
+	}
+	return kappa*std - mean
+}
+
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+func normalPDF(z float64) float64 {
+	return math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+}
+
+// bayesOptStage is the stage of BayesOpt.
+type bayesOptStage int
+
+const (
+	bayesOptInitialize bayesOptStage = iota // Evaluating the initial samples.
+	bayesOptModel                           // Evaluating the maximizers of the acquisition.
+)
+
+// BayesOpt implements Bayesian optimization for gradient-free global
+// optimization of expensive functions subject to finite bound constraints.
+// BayesOpt models the function by a Gaussian process fitted to all
+// evaluated function values and evaluates the function next at the maximum
+// of the Acquisition function of the predictive distribution of the model.
+//
+// The initial location and InitialSamples locations generated by Sampler
+// are evaluated first. Afterwards, every evaluation is a major iteration.
+// The acquisition is maximized by computing it at Candidates random
+// locations and running Method from the best of them. The work of BayesOpt
+// grows with the cube of the number of evaluations, so it is suitable only
+// for functions that are much more expensive than the model, and the
+// optimization should be terminated by Settings.FuncEvaluations.
+//
+// The model works in coordinates normalized to the unit cube, so the length
+// scale of GP is relative to the size of the box given by the bounds.
+//
+// References:
+//  - Jones, D.R., Schonlau, M., Welch, W.J.: Efficient global optimization
+//    of expensive black-box functions. J. Global Optim. 13 (1998), 455-492
+//  - Shahriari, B., Swersky, K., Wang, Z., Adams, R.P., de Freitas, N.:
+//    Taking the human out of the loop: A review of Bayesian optimization.
+//    Proc. IEEE 104 (2016), 148-175
+type BayesOpt struct {
+	// GP is the model of the function. If GP is nil, it will be set to
+	// &GaussianProcess{}.
+	GP *GaussianProcess
+	// Acquisition is the acquisition function. If Acquisition is nil, it
+	// will be set to ExpectedImprovement{}.
+	Acquisition Acquisition
+	// InitialSamples is the number of locations sampled before the model is
+	// used. If InitialSamples is zero, it will be set to twice the dimension
+	// plus one.
+	InitialSamples int
+	// Sampler generates the initial samples. If Sampler is nil, it will be
+	// set to a LatinHypercube with a source from Src.
+	Sampler Sampler
+	// Candidates is the number of random locations at which the acquisition
+	// is computed before it is maximized by Method. If Candidates is zero,
+	// it will be set to 1000.
+	Candidates int
+	// Method returns the Method that maximizes the acquisition from the
+	// best candidate. The Method is given a finite-difference gradient, and
+	// the bounds if it handles them. If Method is nil, NelderMead is used.
+	Method func() Method
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+
+	rnd          *rand.Rand
+	lower, upper []float64
+	unit         *Bounds
+
+	stage   bayesOptStage
+	samples [][]float64 // Initial samples in normalized coordinates.
+	idx     int         // Index of the sample being evaluated.
+
+	x    [][]float64 // Evaluated locations in normalized coordinates.
+	f    []float64   // Function values at x.
+	best float64     // Lowest function value.
+	next []float64   // Next location in normalized coordinates.
+}
+
+func (bo *BayesOpt) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if bo.GP == nil {
+		bo.GP = &GaussianProcess{}
+	}
+	if bo.Acquisition == nil {
+		bo.Acquisition = ExpectedImprovement{}
+	}
+	if bo.InitialSamples == 0 {
+		bo.InitialSamples = 2*dim + 1
+	}
+	if bo.InitialSamples < 0 {
+		panic("bayesopt: negative number of initial samples")
+	}
+	if bo.Candidates == 0 {
+		bo.Candidates = defaultBayesOptCandidates
+	}
+	if bo.Candidates < 0 {
+		panic("bayesopt: negative number of candidates")
+	}
+	bo.rnd = newRand(bo.Src, p.Src)
+	b := p.Bounds
+	if b == nil || b.Lower == nil || b.Upper == nil {
+		return NoEvaluation, NoIteration, errors.New("bayesopt: problem must have finite bounds")
+	}
+	for i := 0; i < dim; i++ {
+		if math.IsInf(b.Lower[i], 0) || math.IsInf(b.Upper[i], 0) {
+			return NoEvaluation, NoIteration, errors.New("bayesopt: problem must have finite bounds")
+		}
+	}
+	bo.lower = b.Lower
+	bo.upper = b.Upper
+	bo.unit = &Bounds{Lower: make([]float64, dim), Upper: make([]float64, dim)}
+	for i := range bo.unit.Upper {
+		bo.unit.Upper[i] = 1
+	}
+
+	bo.x = bo.x[:0]
+	bo.f = bo.f[:0]
+	bo.best = math.Inf(1)
+	bo.next = resize(bo.next, dim)
+	bo.addData(loc.X, loc.F)
+
+	bo.samples = resizeMembers(bo.samples, bo.InitialSamples, dim)
+	sampler := bo.Sampler
+	if sampler == nil {
+		sampler = LatinHypercube{Src: rand.NewSource(bo.rnd.Int63())}
+	}
+	sampler.Sample(bo.samples, bo.unit)
+	bo.stage = bayesOptInitialize
+	bo.idx = 0
+	bo.fromUnit(xNext, bo.samples[0])
+	return FuncEvaluation, InitIteration, nil
+}
+
+func (bo *BayesOpt) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	bo.addData(loc.X, loc.F)
+	if bo.stage == bayesOptInitialize {
+		bo.idx++
+		if bo.idx < len(bo.samples) {
+			bo.fromUnit(xNext, bo.samples[bo.idx])
+			return FuncEvaluation, InitIteration, nil
+		}
+		bo.stage = bayesOptModel
+	}
+	return bo.iterateModel(xNext)
+}
+
+// iterateModel fits the model and stores the maximizer of the acquisition
+// into xNext.
+func (bo *BayesOpt) iterateModel(xNext []float64) (EvaluationType, IterationType, error) {
+	bo.stage = bayesOptModel
+	if err := bo.GP.Fit(bo.x, bo.f); err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	bo.maximizeAcquisition()
+	bo.fromUnit(xNext, bo.next)
+	return FuncEvaluation, MajorIteration, nil
+}
+
+// addData adds the evaluated location x with the function value f to the
+// data of the model. Infeasible locations and locations with non-finite
+// function values are ignored.
+func (bo *BayesOpt) addData(x []float64, f float64) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return
+	}
+	u := make([]float64, len(x))
+	for i, v := range x {
+		if bo.upper[i] > bo.lower[i] {
+			u[i] = (v - bo.lower[i]) / (bo.upper[i] - bo.lower[i])
+		}
+	}
+	if !bo.unit.Feasible(u) {
+		return
+	}
+	bo.x = append(bo.x, u)
+	bo.f = append(bo.f, f)
+	bo.best = math.Min(bo.best, f)
+}
+
+// maximizeAcquisition stores the maximizer of the acquisition into bo.next.
+func (bo *BayesOpt) maximizeAcquisition() {
+	dim := len(bo.next)
+	acquire := func(u []float64) float64 {
+		mean, std := bo.GP.Predict(u)
+		return bo.Acquisition.Acquire(mean, std, bo.best)
+	}
+
+	u := make([]float64, dim)
+	bestAcq := math.Inf(-1)
+	for i := 0; i < bo.Candidates; i++ {
+		for j := range u {
+			u[j] = bo.rnd.Float64()
+		}
+		if a := acquire(u); a > bestAcq {
+			bestAcq = a
+			copy(bo.next, u)
+		}
+	}
+
+	var method Method = &NelderMead{}
+	if bo.Method != nil {
+		method = bo.Method()
+	}
+	// Locations outside the unit cube are projected onto it unless the
+	// Method handles the bounds itself.
+	bounded := false
+	if b, ok := method.(BoundsHandler); ok && b.HandlesBounds() {
+		bounded = true
+	}
+	x := make([]float64, dim)
+	p := Problem{
+		Func: func(v []float64) float64 {
+			copy(x, v)
+			if !bounded {
+				bo.unit.Project(x)
+			}
+			return -acquire(x)
+		},
+	}
+	p = FDGradient{Formula: CentralDifference}.Problem(p)
+	if bounded {
+		p.Bounds = bo.unit
+	}
+	settings := DefaultSettings()
+	settings.FuncEvaluations = defaultAcquisitionEvals * dim
+	result, err := Local(p, bo.next, settings, method)
+	if err != nil || -result.F <= bestAcq {
+		return
+	}
+	copy(bo.next, result.X)
+	bo.unit.Project(bo.next)
+}
+
+// fromUnit stores the location of the normalized point u into x.
+func (bo *BayesOpt) fromUnit(x, u []float64) {
+	for i, v := range u {
+		x[i] = bo.lower[i] + v*(bo.upper[i]-bo.lower[i])
+	}
+}
+
+func (*BayesOpt) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// HandlesBounds returns true. All locations evaluated by BayesOpt are
+// feasible.
+func (*BayesOpt) HandlesBounds() bool {
+	return true
+}
@@ -0,0 +1,64 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAcquisition(t *testing.T) {
+	for _, acq := range []Acquisition{ExpectedImprovement{}, ProbabilityOfImprovement{}, UpperConfidenceBound{}} {
+		// Lower means and, at equal means below the best value, larger
+		// standard deviations are more desirable except for the probability
+		// of improvement.
+		if acq.Acquire(0, 1, 0) <= acq.Acquire(1, 1, 0) {
+			t.Errorf("%T: lower mean not preferred", acq)
+		}
+		if acq.Acquire(1, 2, 0) <= acq.Acquire(1, 1, 0) {
+			t.Errorf("%T: larger uncertainty not preferred above the best value", acq)
+		}
+	}
+	if ei := (ExpectedImprovement{}).Acquire(1, 0, 3); ei != 2 {
+		t.Errorf("unexpected expected improvement without uncertainty %v", ei)
+	}
+	if pi := (ProbabilityOfImprovement{}).Acquire(0, 1, 0); pi != 0.5 {
+		t.Errorf("unexpected probability of improvement %v", pi)
+	}
+}
+
+func TestBayesOpt(t *testing.T) {
+	const fOpt = -1.031628453
+	p := Problem{
+		Func:   sixHumpCamel,
+		Bounds: &Bounds{Lower: []float64{-3, -2}, Upper: []float64{3, 2}},
+	}
+	for _, method := range []*BayesOpt{
+		{Src: rand.NewSource(1)},
+		{Src: rand.NewSource(1), Acquisition: UpperConfidenceBound{}, Sampler: Sobol{}},
+		{Src: rand.NewSource(1), GP: &GaussianProcess{Kernel: RBFKernel{}},
+			Method: func() Method { return &LBFGSB{} }},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.FuncEvaluations = 50
+		result, err := Local(p, []float64{2.5, 1.5}, settings, method)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result.Status != FunctionEvaluationLimit {
+			t.Errorf("unexpected status %v", result.Status)
+		}
+		if result.F > fOpt+1e-2 {
+			t.Errorf("global minimum not found: x = %v, f = %v", result.X, result.F)
+		}
+	}
+
+	p.Bounds = nil
+	if _, err := Local(p, []float64{1, 1}, nil, &BayesOpt{}); err == nil {
+		t.Errorf("expected error without bounds")
+	}
+}
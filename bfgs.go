@@ -17,8 +17,17 @@ import (
 // an estimate of the inverse-Hessian of the function. It exhibits super-linear
 // convergence when in proximity to a local minimum. It has memory cost that is
 // O(n^2) relative to the input dimension.
+//
+// If InitialInverseHessian is not nil, it is used as the estimate of the
+// inverse Hessian at the initial location, and the first step is a
+// quasi-Newton step with it. Otherwise the estimate is the identity, rescaled
+// after the first step. Warm starting with the estimate of a previous run,
+// obtained by InverseHessian, can save many iterations when a sequence of
+// related problems is solved. InitialInverseHessian must be positive definite
+// and is not modified.
 type BFGS struct {
-	LinesearchMethod LinesearchMethod
+	LinesearchMethod      LinesearchMethod
+	InitialInverseHessian *mat64.SymDense
 
 	linesearch *Linesearch
 
@@ -28,10 +37,8 @@ type BFGS struct {
 
 	// Temporary memory
 	y       []float64
-	yVec    *mat64.Vector
 	s       []float64
 	tmpData []float64
-	tmpVec  *mat64.Vector
 
 	invHess *mat64.SymDense
 
@@ -70,8 +77,6 @@ func (b *BFGS) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	b.y = resize(b.y, dim)
 	b.s = resize(b.s, dim)
 	b.tmpData = resize(b.tmpData, dim)
-	b.yVec = mat64.NewVector(dim, b.y)
-	b.tmpVec = mat64.NewVector(dim, b.tmpData)
 
 	if b.invHess == nil || cap(b.invHess.RawSymmetric().Data) < dim*dim {
 		b.invHess = mat64.NewSymDense(dim, nil)
@@ -79,6 +84,18 @@ func (b *BFGS) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 		b.invHess = mat64.NewSymDense(dim, b.invHess.RawSymmetric().Data[:dim*dim])
 	}
 
+	if b.InitialInverseHessian != nil {
+		if b.InitialInverseHessian.Symmetric() != dim {
+			panic("bfgs: initial inverse Hessian size mismatch")
+		}
+		b.invHess.CopySym(b.InitialInverseHessian)
+		b.first = false
+
+		symMulVec(dir, b.invHess, loc.Gradient)
+		floats.Scale(-1, dir)
+		return 1
+	}
+
 	// The values of the hessian are initialized in the first call to NextDirection
 
 	// initial direcion is just negative of gradient because the hessian is 1
@@ -90,6 +107,18 @@ func (b *BFGS) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	return 1 / floats.Norm(dir, 2)
 }
 
+// RestartDirection discards the estimate of the inverse Hessian, which is
+// initialized again as in the first iteration, also if InitialInverseHessian
+// is not nil.
+func (b *BFGS) RestartDirection(loc *Location, dir []float64) (stepSize float64) {
+	copy(b.x, loc.X)
+	copy(b.grad, loc.Gradient)
+	copy(dir, loc.Gradient)
+	floats.Scale(-1, dir)
+	b.first = true
+	return 1 / floats.Norm(dir, 2)
+}
+
 func (b *BFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	if len(loc.X) != b.dim {
 		panic("bfgs: unexpected size mismatch")
@@ -140,26 +169,124 @@ func (b *BFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	// y_k^T B_k^-1 y_k is a scalar, and the third term is a rank-two update
 	// where B_k^-1 y_k is one vector and s_k is the other. Compute the update
 	// values then actually perform the rank updates.
-	yBy := mat64.Inner(b.yVec, b.invHess, b.yVec)
+	symMulVec(b.tmpData, b.invHess, b.y)
+	yBy := floats.Dot(b.y, b.tmpData)
 	firstTermConst := (sDotY + yBy) / (sDotYSquared)
-	b.tmpVec.MulVec(b.invHess, false, b.yVec)
 
-	b.invHess.RankTwo(b.invHess, -1/sDotY, b.tmpData, b.s)
-	b.invHess.SymRankOne(b.invHess, firstTermConst, b.s)
+	// Both updates are applied in place to the upper triangle in a single
+	// pass.
+	raw := b.invHess.RawSymmetric()
+	for i := 0; i < b.dim; i++ {
+		row := raw.Data[i*raw.Stride+i : i*raw.Stride+b.dim]
+		ti, si := b.tmpData[i], b.s[i]
+		for k := range row {
+			j := i + k
+			row[k] += firstTermConst*si*b.s[j] - (ti*b.s[j]+si*b.tmpData[j])/sDotY
+		}
+	}
 
 	// update the bfgs stored data to the new iteration
 	copy(b.x, loc.X)
 	copy(b.grad, loc.Gradient)
 
 	// Compute the new search direction
-	dirmat := mat64.NewDense(b.dim, 1, dir)
-	gradmat := mat64.NewDense(b.dim, 1, loc.Gradient)
-
-	dirmat.Mul(b.invHess, gradmat) // new direction stored in place
+	symMulVec(dir, b.invHess, loc.Gradient)
 	floats.Scale(-1, dir)
 	return 1
 }
 
+// InverseHessian stores the current estimate of the inverse Hessian in dst,
+// for example to warm start a subsequent run by InitialInverseHessian. Before
+// the estimate is updated for the first time, it is the identity.
+// InverseHessian panics if the size of dst does not match the dimension of
+// the problem.
+func (b *BFGS) InverseHessian(dst *mat64.SymDense) {
+	if dst.Symmetric() != b.dim {
+		panic("bfgs: inverse Hessian size mismatch")
+	}
+	if b.first {
+		for i := 0; i < b.dim; i++ {
+			for j := i; j < b.dim; j++ {
+				dst.SetSym(i, j, 0)
+			}
+			dst.SetSym(i, i, 1)
+		}
+		return
+	}
+	dst.CopySym(b.invHess)
+}
+
+// bfgsState is the serializable state of BFGS.
+type bfgsState struct {
+	X       []float64
+	Grad    []float64
+	InvHess []float64
+	First   bool
+}
+
+// Checkpoint returns the state of BFGS after the last major iteration.
+func (b *BFGS) Checkpoint() ([]byte, error) {
+	return encodeState(bfgsState{
+		X:       b.x,
+		Grad:    b.grad,
+		InvHess: symData(b.invHess),
+		First:   b.first,
+	})
+}
+
+// Restore restores the state of BFGS saved by Checkpoint.
+func (b *BFGS) Restore(state []byte, loc *Location, p *ProblemInfo) error {
+	var st bfgsState
+	if err := decodeState(state, &st); err != nil {
+		return err
+	}
+	dim := len(loc.X)
+	if len(st.X) != dim || len(st.Grad) != dim || len(st.InvHess) != dim*dim {
+		return errCheckpointMismatch
+	}
+	if b.LinesearchMethod == nil {
+		b.LinesearchMethod = &Bisection{}
+	}
+	if b.linesearch == nil {
+		b.linesearch = &Linesearch{}
+	}
+	b.linesearch.Method = b.LinesearchMethod
+	b.linesearch.NextDirectioner = b
+	b.linesearch.resume(p, dim)
+
+	b.dim = dim
+	b.x = resize(b.x, dim)
+	copy(b.x, st.X)
+	b.grad = resize(b.grad, dim)
+	copy(b.grad, st.Grad)
+	b.y = resize(b.y, dim)
+	b.s = resize(b.s, dim)
+	b.tmpData = resize(b.tmpData, dim)
+	b.invHess = mat64.NewSymDense(dim, st.InvHess)
+	b.first = st.First
+	return nil
+}
+
+// Reset discards the estimate of the inverse Hessian and the rest of the
+// state of the last run, keeping the exported fields. After Reset,
+// InverseHessian must not be called before the next run.
+func (b *BFGS) Reset() {
+	*b = BFGS{
+		LinesearchMethod:      b.LinesearchMethod,
+		InitialInverseHessian: b.InitialInverseHessian,
+	}
+}
+
+// Clone returns a new BFGS with the configuration of b and a copy of its
+// LinesearchMethod. InitialInverseHessian is shared and must not be
+// modified while the clones are running.
+func (b *BFGS) Clone() Method {
+	return &BFGS{
+		LinesearchMethod:      cloneLinesearchMethod(b.LinesearchMethod),
+		InitialInverseHessian: b.InitialInverseHessian,
+	}
+}
+
 func (*BFGS) Needs() struct {
 	Gradient bool
 	Hessian  bool
@@ -169,3 +296,33 @@ func (*BFGS) Needs() struct {
 		Hessian  bool
 	}{true, false}
 }
+
+// bfgsHessianUpdate performs the BFGS update of the Hessian approximation
+//  B_{k+1} = B_k - (B_k s)(B_k s)ᵀ / sᵀB_k s + y yᵀ / sᵀy
+// in place, where s is the step and y is the change in the gradient. If scale
+// is true, B_k is first replaced by (yᵀy / sᵀy) I as recommended in Nocedal,
+// Wright (2006), Section 6.1. The update is skipped if sᵀy is not positive, so
+// that B stays positive definite. tmp must have the same length as s and is
+// used as temporary storage. bfgsHessianUpdate returns whether the update was
+// performed.
+func bfgsHessianUpdate(hess *mat64.SymDense, s, y, tmp []float64, scale bool) bool {
+	dim := len(s)
+	sDotY := floats.Dot(s, y)
+	if sDotY <= 0 {
+		return false
+	}
+	if scale {
+		gamma := floats.Dot(y, y) / sDotY
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				hess.SetSym(i, j, 0)
+			}
+			hess.SetSym(i, i, gamma)
+		}
+	}
+	symMulVec(tmp, hess, s)
+	sBs := floats.Dot(s, tmp)
+	hess.SymRankOne(hess, -1/sBs, tmp)
+	hess.SymRankOne(hess, 1/sDotY, y)
+	return true
+}
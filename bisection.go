@@ -10,9 +10,13 @@ import "math"
 // satisfies the strong Wolfe conditions with the given gradient constant and
 // function constant of zero. If GradConst is zero, it will be set to a reasonable
 // value. Bisection will panic if GradConst is not between zero and one.
+//
+// Bisection does not extend the step size beyond Settings.LinesearchMaxStep.
 type Bisection struct {
 	GradConst float64
 
+	maxBound float64 // Upper bound on the step size, or +Inf.
+
 	minStep  float64
 	maxStep  float64
 	currStep float64
@@ -26,7 +30,7 @@ type Bisection struct {
 	maxGrad  float64
 }
 
-func (b *Bisection) Init(loc LinesearchLocation, step float64, _ *ProblemInfo) EvaluationType {
+func (b *Bisection) Init(loc LinesearchLocation, step float64, p *ProblemInfo) EvaluationType {
 	if loc.Derivative >= 0 {
 		panic("bisection: init G non-negative")
 	}
@@ -44,6 +48,10 @@ func (b *Bisection) Init(loc LinesearchLocation, step float64, _ *ProblemInfo) E
 	b.minStep = 0
 	b.maxStep = math.Inf(1)
 	b.currStep = step
+	b.maxBound = math.Inf(1)
+	if p != nil && p.LinesearchMaxStep > 0 {
+		b.maxBound = p.LinesearchMaxStep
+	}
 
 	b.initF = loc.F
 	b.minF = loc.F
@@ -93,7 +101,7 @@ func (b *Bisection) Iterate(loc LinesearchLocation) (float64, EvaluationType, er
 			b.minStep = b.currStep
 			b.minF = f
 			b.minGrad = g
-			return b.checkStepEqual(b.currStep*2, FuncEvaluation|GradEvaluation)
+			return b.checkStepEqual(math.Min(b.currStep*2, b.maxBound), FuncEvaluation|GradEvaluation)
 		default:
 			// Increase in function value, but the gradient is still negative.
 			// Means we must have skipped over a local minimum, so set this point
@@ -0,0 +1,85 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+// Bounds specifies simple bound constraints on the variables of a Problem,
+//  Lower[i] <= x[i] <= Upper[i].
+// If Lower or Upper is nil, the variables are not bounded from below or above,
+// respectively. Individual variables can be left unbounded by setting the
+// corresponding element of Lower to -Inf or of Upper to +Inf.
+type Bounds struct {
+	Lower []float64
+	Upper []float64
+}
+
+func (b *Bounds) lower(i int) float64 {
+	if b.Lower == nil {
+		return math.Inf(-1)
+	}
+	return b.Lower[i]
+}
+
+func (b *Bounds) upper(i int) float64 {
+	if b.Upper == nil {
+		return math.Inf(1)
+	}
+	return b.Upper[i]
+}
+
+// validate panics if the bounds are not consistent with a problem of
+// dimension dim.
+func (b *Bounds) validate(dim int) {
+	if b.Lower != nil && len(b.Lower) != dim {
+		panic("optimize: lower bound size mismatch")
+	}
+	if b.Upper != nil && len(b.Upper) != dim {
+		panic("optimize: upper bound size mismatch")
+	}
+	for i := 0; i < dim; i++ {
+		l, u := b.lower(i), b.upper(i)
+		if math.IsNaN(l) || math.IsNaN(u) {
+			panic("optimize: NaN bound")
+		}
+		if l > u {
+			panic("optimize: lower bound greater than upper bound")
+		}
+	}
+}
+
+// Project projects x in place onto the feasible region.
+func (b *Bounds) Project(x []float64) {
+	for i, v := range x {
+		x[i] = math.Max(b.lower(i), math.Min(v, b.upper(i)))
+	}
+}
+
+// Feasible returns whether x satisfies the bounds.
+func (b *Bounds) Feasible(x []float64) bool {
+	for i, v := range x {
+		if v < b.lower(i) || v > b.upper(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// ProjectedGradientNorm returns the infinity norm of the projected gradient
+// P(x - grad) - x, where P is the projection onto the feasible region. The
+// projected gradient is zero if and only if x is a first-order critical point
+// of the bound-constrained problem. If b is nil, the infinity norm of grad is
+// returned.
+func (b *Bounds) ProjectedGradientNorm(x, grad []float64) float64 {
+	var norm float64
+	for i, v := range x {
+		g := grad[i]
+		if b != nil {
+			g = v - math.Max(b.lower(i), math.Min(v-g, b.upper(i)))
+		}
+		norm = math.Max(norm, math.Abs(g))
+	}
+	return norm
+}
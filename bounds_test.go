@@ -0,0 +1,178 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+// shiftedQuadratic is f(x) = Σ_i (i+1) (x_i - c_i)^2.
+type shiftedQuadratic struct {
+	c []float64
+}
+
+func (q shiftedQuadratic) Func(x []float64) float64 {
+	var f float64
+	for i, v := range x {
+		d := v - q.c[i]
+		f += float64(i+1) * d * d
+	}
+	return f
+}
+
+func (q shiftedQuadratic) Grad(x, grad []float64) {
+	for i, v := range x {
+		grad[i] = 2 * float64(i+1) * (v - q.c[i])
+	}
+}
+
+func (q shiftedQuadratic) Hess(x []float64, hess *mat64.SymDense) {
+	for i := range x {
+		for j := i; j < len(x); j++ {
+			hess.SetSym(i, j, 0)
+		}
+		hess.SetSym(i, i, 2*float64(i+1))
+	}
+}
+
+type boundedTest struct {
+	name   string
+	p      Problem
+	x      []float64
+	optLoc []float64 // Known solution. If nil, only criticality is checked.
+}
+
+func boundedTests() []boundedTest {
+	quad := shiftedQuadratic{c: []float64{-2, 0.5, 3, 1, -1}}
+	return []boundedTest{
+		{
+			name: "QuadraticOutside",
+			p: Problem{
+				Func: quad.Func,
+				Grad: quad.Grad,
+				Hess: quad.Hess,
+				Bounds: &Bounds{
+					Lower: []float64{-1, -1, -1, -1, -1},
+					Upper: []float64{1, 1, 1, 1, 1},
+				},
+			},
+			x:      []float64{0, 0, 0, 0, 0},
+			optLoc: []float64{-1, 0.5, 1, 1, -1},
+		},
+		{
+			name: "QuadraticOneSided",
+			p: Problem{
+				Func: quad.Func,
+				Grad: quad.Grad,
+				Hess: quad.Hess,
+				Bounds: &Bounds{
+					Lower: []float64{0, 0, 0, 0, 0},
+				},
+			},
+			// Infeasible initial location is projected.
+			x:      []float64{-5, 5, -5, 5, -5},
+			optLoc: []float64{0, 0.5, 3, 1, 0},
+		},
+		{
+			name: "WoodActive",
+			p: Problem{
+				Func: functions.Wood{}.Func,
+				Grad: functions.Wood{}.Grad,
+				Hess: functions.Wood{}.Hess,
+				Bounds: &Bounds{
+					Lower: []float64{-2, -2, -2, -2},
+					Upper: []float64{0.5, 2, 0.5, math.Inf(1)},
+				},
+			},
+			x: []float64{-3, -1, -2, -1},
+		},
+		{
+			name: "BealeInactive",
+			p: Problem{
+				Func: functions.Beale{}.Func,
+				Grad: functions.Beale{}.Grad,
+				Hess: functions.Beale{}.Hess,
+				Bounds: &Bounds{
+					Lower: []float64{-4.5, -4.5},
+					Upper: []float64{4.5, 4.5},
+				},
+			},
+			x:      []float64{1, 1},
+			optLoc: []float64{3, 0.5},
+		},
+	}
+}
+
+// testBounded tests method on boundedTests. The solution must be accurate to
+// gradTol in the projected gradient and to 100*gradTol in the location.
+func testBounded(t *testing.T, method Method, gradTol float64) {
+	for _, test := range boundedTests() {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.GradientThreshold = gradTol
+		if !method.Needs().Hessian {
+			test.p.Hess = nil
+		}
+		result, err := Local(test.p, test.x, settings, method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != GradientThreshold {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if !test.p.Bounds.Feasible(result.X) {
+			t.Errorf("%s: solution %v outside of bounds", test.name, result.X)
+		}
+		g := make([]float64, len(result.X))
+		test.p.Grad(result.X, g)
+		if norm := test.p.Bounds.ProjectedGradientNorm(result.X, g); norm >= settings.GradientThreshold {
+			t.Errorf("%s: projected gradient norm %v not smaller than tolerance", test.name, norm)
+		}
+		if test.optLoc != nil && !floats.EqualApprox(result.X, test.optLoc, 100*gradTol) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.optLoc, result.X)
+		}
+	}
+}
+
+func TestTwoMetricBounds(t *testing.T) {
+	testBounded(t, &TwoMetric{}, 1e-10)
+	testBounded(t, &TwoMetric{QuasiNewton: true}, 1e-10)
+}
+
+func TestBoundsNotHandled(t *testing.T) {
+	p := Problem{
+		Func:   functions.ExtendedRosenbrock{}.Func,
+		Grad:   functions.ExtendedRosenbrock{}.Grad,
+		Bounds: &Bounds{Lower: []float64{0, 0}},
+	}
+	_, err := Local(p, []float64{1, 2}, nil, &BFGS{})
+	if err == nil {
+		t.Errorf("expected error for method that does not handle bounds")
+	}
+}
+
+func TestProjectedGradientBounds(t *testing.T) {
+	// ProjectedGradient converges only linearly, and the Armijo condition
+	// cannot be resolved much beyond this tolerance on WoodActive.
+	testBounded(t, &ProjectedGradient{}, 1e-6)
+}
+
+func TestSPGBounds(t *testing.T) {
+	testBounded(t, &ProjectedGradient{LinesearchMethod: &Nonmonotone{}}, 1e-6)
+}
+
+func TestLBFGSBBounds(t *testing.T) {
+	// Close to the solution of WoodActive, the decrease predicted by the
+	// model is below the rounding errors in f, and the Armijo condition
+	// cannot be resolved reliably beyond this tolerance.
+	testBounded(t, &LBFGSB{}, 1e-6)
+	testBounded(t, &LBFGSB{Store: 2}, 1e-6)
+}
@@ -0,0 +1,367 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+const (
+	defaultBranchAndBoundAbsGap  = 1e-6
+	defaultBranchAndBoundRelGap  = 1e-4
+	defaultBranchAndBoundIntTol  = 1e-6
+	defaultBranchAndBoundMaxNode = 10000
+)
+
+// IntegerConstraint specifies the variables of a problem that must take
+// integer values. Indices are the indices of these variables.
+type IntegerConstraint struct {
+	Indices []int
+}
+
+// NodeSelection is the rule by which BranchAndBound selects the next node to
+// process.
+type NodeSelection int
+
+const (
+	// BestFirst selects the open node with the lowest bound. It processes
+	// the fewest nodes to prove a gap, but keeps many nodes open.
+	BestFirst NodeSelection = iota
+	// DepthFirst selects the most recently created node. It finds integer
+	// solutions early and keeps few nodes open.
+	DepthFirst
+)
+
+// BranchAndBound is a driver for mixed-integer problems, in which some of the
+// variables must take integer values. It solves the continuous relaxation of
+// the problem, in which the integer constraints are dropped, and if a variable
+// that must be integral has a fractional value v in the solution, it branches
+// into two subproblems with the additional bounds x_i ≤ floor(v) and
+// x_i ≥ ceil(v). The relaxations of the subproblems, the nodes of the search
+// tree, are solved in the order given by NodeSelection, and a node is pruned
+// when the value of its relaxation is not below the value of the best integer
+// solution found, the incumbent, by more than the gap tolerance.
+//
+// The values of the relaxations are lower bounds of the subproblems only if
+// they are global minima, so the result is guaranteed to be optimal within the
+// gap for convex problems. For nonconvex problems, BranchAndBound is a
+// heuristic.
+type BranchAndBound struct {
+	// Method returns the Method for solving a relaxation with Local. It is
+	// called once for every node, and the Method must handle bounds. If
+	// Method is nil, LBFGSB is used.
+	Method func() Method
+	// Relax solves a relaxation instead of Local if it is not nil, for
+	// example with a linear programming solver. The Problem p has the bounds
+	// of the node, and x is a starting point within them. Relax returns the
+	// location of the minimum and its function value, or an error if the
+	// relaxation cannot be solved, in which case the node is discarded.
+	Relax func(p Problem, x []float64) (*Result, error)
+	// NodeSelection is the rule for selecting the next node.
+	NodeSelection NodeSelection
+	// AbsoluteGap and RelativeGap are the tolerances of the gap between the
+	// value of the incumbent and the lowest bound of the open nodes. The
+	// search terminates when the gap is at most
+	//  max(AbsoluteGap, RelativeGap |f_incumbent|).
+	// If AbsoluteGap is zero, it will be set to 1e-6, and if RelativeGap is
+	// zero, it will be set to 1e-4.
+	AbsoluteGap float64
+	RelativeGap float64
+	// IntegerTolerance is the tolerance within which a value is considered
+	// integral. If IntegerTolerance is zero, it will be set to 1e-6.
+	IntegerTolerance float64
+	// MaxNodes is the maximum number of nodes processed. If MaxNodes is
+	// zero, it will be set to 10000.
+	MaxNodes int
+}
+
+// BranchAndBoundResult is the result of BranchAndBound.
+type BranchAndBoundResult struct {
+	// X is the best integer solution found and F is its function value. The
+	// integer variables of X have integer values.
+	X []float64
+	F float64
+	// LowerBound is the lowest bound of the nodes that were open when the
+	// search terminated, or F if the search space has been exhausted.
+	LowerBound float64
+	// Nodes is the number of processed nodes, and Failed is the number of
+	// relaxations that could not be solved.
+	Nodes  int
+	Failed int
+	// Stats are the statistics summed over the solutions of all
+	// relaxations, except Runtime, which is the total wall-clock time.
+	Stats
+	// Status is MethodConverge if the gap is within the tolerances, and
+	// IterationLimit if MaxNodes has been reached.
+	Status Status
+}
+
+// bbNode is a node of the branch-and-bound tree.
+type bbNode struct {
+	lower, upper []float64
+	x            []float64 // Starting point of the relaxation.
+	bound        float64   // Value of the relaxation of the parent.
+}
+
+// Minimize solves the mixed-integer problem p with the integer variables
+// given by ic, starting the relaxation at the root from initX. If settings is
+// nil, the default settings are used for the relaxations. The bounds of the
+// integer variables need not be finite, but the search terminates only if the
+// relaxations of the nodes are bounded. Minimize returns ErrNoIntegerSolution
+// if no integer solution has been found, and the error of the root relaxation
+// if it cannot be solved. Minimize panics if settings.Maximize is true.
+func (bb *BranchAndBound) Minimize(p Problem, initX []float64, ic IntegerConstraint, settings *Settings) (*BranchAndBoundResult, error) {
+	dim := len(initX)
+	if dim == 0 {
+		panic("optimize: initial X has zero length")
+	}
+	for _, i := range ic.Indices {
+		if i < 0 || i >= dim {
+			panic("optimize: integer variable index out of range")
+		}
+	}
+	absGap := bb.AbsoluteGap
+	if absGap == 0 {
+		absGap = defaultBranchAndBoundAbsGap
+	}
+	relGap := bb.RelativeGap
+	if relGap == 0 {
+		relGap = defaultBranchAndBoundRelGap
+	}
+	intTol := bb.IntegerTolerance
+	if intTol == 0 {
+		intTol = defaultBranchAndBoundIntTol
+	}
+	if absGap < 0 || relGap < 0 || intTol < 0 {
+		panic("optimize: negative tolerance")
+	}
+	maxNodes := bb.MaxNodes
+	if maxNodes == 0 {
+		maxNodes = defaultBranchAndBoundMaxNode
+	}
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	if settings.Maximize {
+		panic("optimize: maximization is not supported")
+	}
+
+	startTime := time.Now()
+	res := &BranchAndBoundResult{F: math.Inf(1)}
+	root := &bbNode{
+		lower: make([]float64, dim),
+		upper: make([]float64, dim),
+		x:     make([]float64, dim),
+		bound: math.Inf(-1),
+	}
+	for i := range root.lower {
+		root.lower[i] = math.Inf(-1)
+		root.upper[i] = math.Inf(1)
+		if p.Bounds != nil {
+			root.lower[i] = p.Bounds.lower(i)
+			root.upper[i] = p.Bounds.upper(i)
+		}
+	}
+	copy(root.x, initX)
+	open := &bbQueue{depthFirst: bb.NodeSelection == DepthFirst}
+	open.push(root)
+
+	// withinGap returns whether the bound is not below the incumbent by more
+	// than the gap tolerance.
+	withinGap := func(bound float64) bool {
+		if math.IsInf(res.F, 1) {
+			return false
+		}
+		return res.F-bound <= math.Max(absGap, relGap*math.Abs(res.F))
+	}
+
+	var rootErr error
+	res.Status = MethodConverge
+	for open.Len() > 0 {
+		if withinGap(open.lowest()) {
+			break
+		}
+		if res.Nodes == maxNodes {
+			res.Status = IterationLimit
+			break
+		}
+		node := open.pop()
+		if withinGap(node.bound) {
+			continue
+		}
+		res.Nodes++
+
+		r, err := bb.relax(p, node, settings)
+		if r != nil {
+			res.add(&r.Stats)
+		}
+		if err != nil || math.IsNaN(r.F) {
+			if res.Nodes == 1 {
+				rootErr = err
+			}
+			res.Failed++
+			continue
+		}
+		if withinGap(r.F) {
+			continue
+		}
+
+		// Branch on the most fractional integer variable.
+		branch := -1
+		var maxFrac float64
+		for _, i := range ic.Indices {
+			frac := math.Abs(r.X[i] - math.Floor(r.X[i]+0.5))
+			if frac > intTol && frac > maxFrac {
+				branch, maxFrac = i, frac
+			}
+		}
+		if branch < 0 {
+			// The solution of the relaxation is integral. Round it and
+			// update the incumbent.
+			x := make([]float64, dim)
+			copy(x, r.X)
+			for _, i := range ic.Indices {
+				x[i] = math.Floor(x[i] + 0.5)
+			}
+			f := p.Func(x)
+			res.FuncEvaluations++
+			if f < res.F {
+				res.X, res.F = x, f
+			}
+			continue
+		}
+		v := r.X[branch]
+		down := node.child(r, r.F)
+		down.upper[branch] = math.Floor(v)
+		up := node.child(r, r.F)
+		up.lower[branch] = math.Ceil(v)
+		// Push the child on the side of the nearest integer last, so that
+		// DepthFirst processes it first.
+		first, second := up, down
+		if v-math.Floor(v) > 0.5 {
+			first, second = down, up
+		}
+		for _, c := range []*bbNode{first, second} {
+			if c.lower[branch] <= c.upper[branch] {
+				open.push(c)
+			}
+		}
+	}
+	res.Runtime = time.Since(startTime)
+	if res.X == nil {
+		res.LowerBound = math.Inf(1)
+		if open.Len() > 0 {
+			res.LowerBound = open.lowest()
+		}
+		if rootErr != nil {
+			return res, rootErr
+		}
+		return res, ErrNoIntegerSolution
+	}
+	res.LowerBound = res.F
+	if open.Len() > 0 {
+		res.LowerBound = math.Min(res.F, open.lowest())
+	}
+	return res, nil
+}
+
+// relax solves the relaxation of the node.
+func (bb *BranchAndBound) relax(p Problem, node *bbNode, settings *Settings) (*Result, error) {
+	p.Bounds = &Bounds{Lower: node.lower, Upper: node.upper}
+	x := make([]float64, len(node.x))
+	copy(x, node.x)
+	p.Bounds.Project(x)
+	if bb.Relax != nil {
+		return bb.Relax(p, x)
+	}
+	s := *settings
+	if s.FunctionConverge != nil {
+		fc := *s.FunctionConverge
+		s.FunctionConverge = &fc
+	}
+	var method Method = &LBFGSB{}
+	if bb.Method != nil {
+		method = bb.Method()
+	}
+	return Local(p, x, &s, method)
+}
+
+// child returns a child of the node with the same bounds that starts at the
+// solution of the relaxation r of the node.
+func (n *bbNode) child(r *Result, bound float64) *bbNode {
+	c := &bbNode{
+		lower: make([]float64, len(n.lower)),
+		upper: make([]float64, len(n.upper)),
+		x:     make([]float64, len(r.X)),
+		bound: bound,
+	}
+	copy(c.lower, n.lower)
+	copy(c.upper, n.upper)
+	copy(c.x, r.X)
+	return c
+}
+
+// add adds the statistics of a relaxation to the result.
+func (res *BranchAndBoundResult) add(s *Stats) {
+	res.MajorIterations += s.MajorIterations
+	res.FuncEvaluations += s.FuncEvaluations
+	res.GradEvaluations += s.GradEvaluations
+	res.HessEvaluations += s.HessEvaluations
+	res.HessVecEvaluations += s.HessVecEvaluations
+}
+
+// bbQueue is the queue of open nodes. It is a heap ordered by the bounds of
+// the nodes for BestFirst, and a stack for DepthFirst.
+type bbQueue struct {
+	nodes      bbHeap
+	depthFirst bool
+}
+
+func (q *bbQueue) Len() int { return len(q.nodes) }
+
+func (q *bbQueue) push(n *bbNode) {
+	if q.depthFirst {
+		q.nodes = append(q.nodes, n)
+		return
+	}
+	heap.Push(&q.nodes, n)
+}
+
+func (q *bbQueue) pop() *bbNode {
+	if q.depthFirst {
+		n := q.nodes[len(q.nodes)-1]
+		q.nodes = q.nodes[:len(q.nodes)-1]
+		return n
+	}
+	return heap.Pop(&q.nodes).(*bbNode)
+}
+
+// lowest returns the lowest bound of the open nodes.
+func (q *bbQueue) lowest() float64 {
+	if !q.depthFirst {
+		return q.nodes[0].bound
+	}
+	lowest := math.Inf(1)
+	for _, n := range q.nodes {
+		lowest = math.Min(lowest, n.bound)
+	}
+	return lowest
+}
+
+// bbHeap is a min-heap of nodes ordered by their bounds.
+type bbHeap []*bbNode
+
+func (h bbHeap) Len() int            { return len(h) }
+func (h bbHeap) Less(i, j int) bool  { return h[i].bound < h[j].bound }
+func (h bbHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *bbHeap) Push(x interface{}) { *h = append(*h, x.(*bbNode)) }
+
+func (h *bbHeap) Pop() interface{} {
+	n := (*h)[len(*h)-1]
+	*h = (*h)[:len(*h)-1]
+	return n
+}
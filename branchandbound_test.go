@@ -0,0 +1,116 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestBranchAndBound(t *testing.T) {
+	// A separable quadratic with two integer variables and one continuous
+	// variable.
+	separable := Problem{
+		Func: func(x []float64) float64 {
+			return (x[0]-2.6)*(x[0]-2.6) + (x[1]+1.3)*(x[1]+1.3) + (x[2]-0.4)*(x[2]-0.4)
+		},
+		Grad: func(x, grad []float64) {
+			grad[0] = 2 * (x[0] - 2.6)
+			grad[1] = 2 * (x[1] + 1.3)
+			grad[2] = 2 * (x[2] - 0.4)
+		},
+	}
+
+	// A coupled convex quadratic ½ xᵀ Q x - bᵀ x, whose integer minimum is not
+	// the rounded continuous minimum.
+	q := [3][3]float64{{4, 3, 0}, {3, 4, 1}, {0, 1, 2}}
+	b := []float64{1.7, -2.9, 3.3}
+	coupledFunc := func(x []float64) float64 {
+		var f float64
+		for i := range x {
+			for j := range x {
+				f += 0.5 * x[i] * q[i][j] * x[j]
+			}
+			f -= b[i] * x[i]
+		}
+		return f
+	}
+	coupled := Problem{
+		Func: coupledFunc,
+		Grad: func(x, grad []float64) {
+			for i := range x {
+				grad[i] = -b[i]
+				for j := range x {
+					grad[i] += q[i][j] * x[j]
+				}
+			}
+		},
+		Bounds: &Bounds{Lower: []float64{-5, -5, -5}, Upper: []float64{5, 5, 5}},
+	}
+	// Find the integer minimum of the coupled quadratic by enumeration.
+	coupledMin := make([]float64, 3)
+	fMin := math.Inf(1)
+	x := make([]float64, 3)
+	for x[0] = -5; x[0] <= 5; x[0]++ {
+		for x[1] = -5; x[1] <= 5; x[1]++ {
+			for x[2] = -5; x[2] <= 5; x[2]++ {
+				if f := coupledFunc(x); f < fMin {
+					fMin = f
+					copy(coupledMin, x)
+				}
+			}
+		}
+	}
+
+	noInteger := separable
+	noInteger.Bounds = &Bounds{Lower: []float64{0.2, -5, -5}, Upper: []float64{0.8, 5, 5}}
+
+	for _, sel := range []struct {
+		name string
+		sel  NodeSelection
+	}{
+		{"BestFirst", BestFirst},
+		{"DepthFirst", DepthFirst},
+	} {
+		for _, test := range []struct {
+			name    string
+			p       Problem
+			integer []int
+			want    []float64
+			err     error
+		}{
+			{"Separable", separable, []int{0, 1}, []float64{3, -1, 0.4}, nil},
+			{"Coupled", coupled, []int{0, 1, 2}, coupledMin, nil},
+			{"NoInteger", noInteger, []int{0}, nil, ErrNoIntegerSolution},
+		} {
+			name := sel.name + " " + test.name
+			bb := &BranchAndBound{NodeSelection: sel.sel}
+			res, err := bb.Minimize(test.p, []float64{0.5, 0.5, 0.5}, IntegerConstraint{Indices: test.integer}, nil)
+			if err != test.err {
+				t.Errorf("%s: unexpected error: %v", name, err)
+				continue
+			}
+			if test.err != nil {
+				continue
+			}
+			if res.Status != MethodConverge {
+				t.Errorf("%s: unexpected status %v", name, res.Status)
+			}
+			if !floats.EqualApprox(res.X, test.want, 1e-5) {
+				t.Errorf("%s: unexpected minimum location, want %v, got %v", name, test.want, res.X)
+			}
+			for _, i := range test.integer {
+				if res.X[i] != math.Floor(res.X[i]) {
+					t.Errorf("%s: variable %d not integral: %v", name, i, res.X[i])
+				}
+			}
+			if res.LowerBound > res.F+1e-10 {
+				t.Errorf("%s: lower bound %v above the minimum %v", name, res.LowerBound, res.F)
+			}
+		}
+	}
+}
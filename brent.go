@@ -0,0 +1,327 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const (
+	// goldenRatio is the factor by which the bracketing interval grows.
+	goldenRatio = 1.618033988749895
+	// cGold is 2 minus the golden ratio, the fraction of the interval at
+	// which a golden section step is taken.
+	cGold = 0.3819660112501051
+
+	maxBracketSteps  = 50
+	defaultScalarTol = 1e-8
+	// minScalarTol is the smallest relative tolerance of the minimum
+	// location. Smaller tolerances cannot be achieved in floating-point
+	// arithmetic, and they would prevent Brent's method from terminating.
+	minScalarTol = 4 * 2.220446049250313e-16
+	// brentZeps is the absolute tolerance of the minimum location near 0.
+	brentZeps = 1e-21
+)
+
+// scalarStage is the stage of scalarMinimizer.
+type scalarStage int
+
+const (
+	scalarBracketB scalarStage = iota // Evaluating the second bracketing point.
+	scalarBracketC                    // Evaluating the third bracketing point.
+	scalarBrent                       // Brent's method within the bracket.
+	scalarDone
+)
+
+// scalarMinimizer minimizes a function of one variable by reverse
+// communication. It brackets a minimum starting from 0 and a given step by
+// golden section expansion, and then locates it by Brent's method of
+// parabolic interpolation and golden section search. The caller evaluates
+// the function at the points returned by init and iterate and passes the
+// values to iterate until it reports that it is done.
+type scalarMinimizer struct {
+	tol float64
+	// golden restricts Brent's method to golden section steps.
+	golden bool
+	// bracketed is whether a bracket of a minimum has been found.
+	bracketed bool
+
+	stage scalarStage
+	steps int
+
+	// Bracketing points, with b between a and c and f(b) below f(a) and
+	// f(c) once the bracket is found.
+	a, b, c    float64
+	fa, fb, fc float64
+
+	// State of Brent's method. The minimum lies in [lo, hi], x is the point
+	// with the lowest value found so far, w the point with the second
+	// lowest value, v the previous value of w, and u the last evaluated
+	// point.
+	lo, hi     float64
+	x, w, v, u float64
+	fx, fw, fv float64
+	d, e       float64
+}
+
+// init starts the minimization with f(0) = f0 and returns the next point to
+// evaluate. step is the initial step of the bracketing and tol the relative
+// tolerance of the minimum location.
+func (s *scalarMinimizer) init(f0, step, tol float64) float64 {
+	s.tol = math.Max(tol, minScalarTol)
+	s.a, s.fa = 0, f0
+	s.b = step
+	s.steps = 0
+	s.bracketed = false
+	s.stage = scalarBracketB
+	return s.b
+}
+
+// iterate takes the function value at the last point and returns the next
+// point to evaluate, or done is true if the minimization has finished.
+func (s *scalarMinimizer) iterate(f float64) (u float64, done bool) {
+	switch s.stage {
+	case scalarBracketB:
+		s.fb = f
+		if s.fb > s.fa {
+			// Go downhill from a to b.
+			s.a, s.b = s.b, s.a
+			s.fa, s.fb = s.fb, s.fa
+		}
+		s.c = s.b + goldenRatio*(s.b-s.a)
+		s.stage = scalarBracketC
+		return s.c, false
+	case scalarBracketC:
+		s.fc = f
+		if s.fb < s.fc || (s.fb == s.fc && s.fb < s.fa) {
+			return s.startBrent()
+		}
+		s.steps++
+		if s.steps > maxBracketSteps || math.IsInf(s.c, 0) {
+			// The function decreases without bound, or at least
+			// beyond the reach of the bracketing.
+			s.x, s.fx = s.c, s.fc
+			s.stage = scalarDone
+			return 0, true
+		}
+		s.a, s.b = s.b, s.c
+		s.fa, s.fb = s.fb, s.fc
+		s.c = s.b + goldenRatio*(s.b-s.a)
+		return s.c, false
+	case scalarBrent:
+		return s.brentUpdate(f)
+	}
+	panic("optimize: scalar minimizer finished")
+}
+
+// min returns the location and value of the lowest point found.
+func (s *scalarMinimizer) min() (x, f float64) {
+	return s.x, s.fx
+}
+
+// startBracket starts Brent's method in the bracket a, b, c with the
+// function values fa, fb, fc and returns the next point to evaluate.
+func (s *scalarMinimizer) startBracket(a, b, c, fa, fb, fc, tol float64) (u float64, done bool) {
+	s.tol = math.Max(tol, minScalarTol)
+	s.a, s.b, s.c = a, b, c
+	s.fa, s.fb, s.fc = fa, fb, fc
+	return s.startBrent()
+}
+
+func (s *scalarMinimizer) startBrent() (u float64, done bool) {
+	s.bracketed = true
+	s.lo, s.hi = math.Min(s.a, s.c), math.Max(s.a, s.c)
+	s.x, s.w, s.v = s.b, s.b, s.b
+	s.fx, s.fw, s.fv = s.fb, s.fb, s.fb
+	s.d, s.e = 0, 0
+	s.stage = scalarBrent
+	return s.brentNext()
+}
+
+// brentNext returns the next point of Brent's method, or done is true if
+// the minimum is located within the tolerance.
+func (s *scalarMinimizer) brentNext() (u float64, done bool) {
+	xm := 0.5 * (s.lo + s.hi)
+	tol1 := s.tol*math.Abs(s.x) + brentZeps
+	tol2 := 2 * tol1
+	if math.Abs(s.x-xm) <= tol2-0.5*(s.hi-s.lo) {
+		s.stage = scalarDone
+		return 0, true
+	}
+	parabolic := false
+	if !s.golden && math.Abs(s.e) > tol1 {
+		// Fit a parabola through x, w and v.
+		r := (s.x - s.w) * (s.fx - s.fv)
+		q := (s.x - s.v) * (s.fx - s.fw)
+		p := (s.x-s.v)*q - (s.x-s.w)*r
+		q = 2 * (q - r)
+		if q > 0 {
+			p = -p
+		}
+		q = math.Abs(q)
+		etemp := s.e
+		s.e = s.d
+		// Accept the parabolic step if it falls within the bracket and
+		// is less than half of the step before last.
+		if math.Abs(p) < math.Abs(0.5*q*etemp) && p > q*(s.lo-s.x) && p < q*(s.hi-s.x) {
+			s.d = p / q
+			u := s.x + s.d
+			if u-s.lo < tol2 || s.hi-u < tol2 {
+				s.d = math.Copysign(tol1, xm-s.x)
+			}
+			parabolic = true
+		}
+	}
+	if !parabolic {
+		// Golden section step into the larger of the two segments.
+		if s.x >= xm {
+			s.e = s.lo - s.x
+		} else {
+			s.e = s.hi - s.x
+		}
+		s.d = cGold * s.e
+	}
+	if math.Abs(s.d) >= tol1 {
+		s.u = s.x + s.d
+	} else {
+		s.u = s.x + math.Copysign(tol1, s.d)
+	}
+	return s.u, false
+}
+
+// brentUpdate updates the state of Brent's method with the value fu at u.
+func (s *scalarMinimizer) brentUpdate(fu float64) (u float64, done bool) {
+	if fu <= s.fx {
+		if s.u >= s.x {
+			s.lo = s.x
+		} else {
+			s.hi = s.x
+		}
+		s.v, s.w, s.x = s.w, s.x, s.u
+		s.fv, s.fw, s.fx = s.fw, s.fx, fu
+		return s.brentNext()
+	}
+	if s.u < s.x {
+		s.lo = s.u
+	} else {
+		s.hi = s.u
+	}
+	switch {
+	case fu <= s.fw || s.w == s.x:
+		s.v, s.w = s.w, s.u
+		s.fv, s.fw = s.fw, fu
+	case fu <= s.fv || s.v == s.x || s.v == s.w:
+		s.v = s.u
+		s.fv = fu
+	}
+	return s.brentNext()
+}
+
+// Bracket is a bracket of a minimum of a function of one variable, three
+// locations with B between A and C and the function values at them. If FB is
+// not larger than FA and FC, the interval between A and C contains a local
+// minimum of a continuous function.
+type Bracket struct {
+	A, B, C    float64
+	FA, FB, FC float64
+}
+
+// FindBracket finds a bracket of a minimum of f. It starts at a and b and
+// steps downhill by increasing steps whose lengths grow by the golden ratio
+// until the function value increases. If no bracket is found after 50 steps,
+// FindBracket returns the last three locations and ErrNoBracket. FindBracket
+// panics if a and b are equal.
+func FindBracket(f func(float64) float64, a, b float64) (Bracket, error) {
+	if a == b {
+		panic("optimize: bracket start points are equal")
+	}
+	var s scalarMinimizer
+	t := s.init(f(a), b-a, defaultScalarTol)
+	var done bool
+	for !done && !s.bracketed {
+		t, done = s.iterate(f(a + t))
+	}
+	br := Bracket{
+		A: a + s.a, B: a + s.b, C: a + s.c,
+		FA: s.fa, FB: s.fb, FC: s.fc,
+	}
+	if !s.bracketed {
+		return br, ErrNoBracket
+	}
+	return br, nil
+}
+
+// ScalarResult is the result of a minimization of a function of one
+// variable.
+type ScalarResult struct {
+	X float64 // Location of the minimum.
+	F float64 // Function value at X.
+	// FuncEvaluations is the number of evaluations of the function, not
+	// counting the evaluations at the bracket.
+	FuncEvaluations int
+}
+
+// Brent minimizes a function of one variable within a Bracket by Brent's
+// method, which takes parabolic interpolation steps when they make good
+// progress and golden section steps otherwise. It converges superlinearly
+// for smooth functions and never much slower than GoldenSection.
+//
+// References:
+//  - Brent, R.P.: Algorithms for Minimization Without Derivatives.
+//    Prentice-Hall (1973), chapter 5
+type Brent struct {
+	// Tolerance is the relative tolerance of the location of the minimum.
+	// If Tolerance is zero, 1e-8 is used instead. For smooth functions,
+	// tolerances below the square root of the machine epsilon do not
+	// improve the accuracy because the function is flat near the minimum.
+	Tolerance float64
+}
+
+// Minimize locates a local minimum of f in the interval between br.A and
+// br.C, starting from br.B. Minimize panics if br.B is not strictly between
+// br.A and br.C.
+func (b Brent) Minimize(f func(float64) float64, br Bracket) ScalarResult {
+	return minimizeScalar(f, br, b.Tolerance, false)
+}
+
+// GoldenSection minimizes a function of one variable within a Bracket by
+// golden section search, which reduces the interval containing the minimum
+// by the golden ratio with every evaluation. It makes no assumption about
+// the smoothness of the function.
+//
+// References:
+//  - Kiefer, J.: Sequential minimax search for a maximum. Proc. Amer. Math.
+//    Soc. 4 (1953), 502-506
+type GoldenSection struct {
+	// Tolerance is the relative tolerance of the location of the minimum.
+	// If Tolerance is zero, 1e-8 is used instead.
+	Tolerance float64
+}
+
+// Minimize locates a local minimum of f in the interval between br.A and
+// br.C, starting from br.B. Minimize panics if br.B is not strictly between
+// br.A and br.C.
+func (g GoldenSection) Minimize(f func(float64) float64, br Bracket) ScalarResult {
+	return minimizeScalar(f, br, g.Tolerance, true)
+}
+
+func minimizeScalar(f func(float64) float64, br Bracket, tol float64, golden bool) ScalarResult {
+	if tol == 0 {
+		tol = defaultScalarTol
+	}
+	if tol < 0 {
+		panic("optimize: negative tolerance")
+	}
+	if !(br.A < br.B && br.B < br.C) && !(br.C < br.B && br.B < br.A) {
+		panic("optimize: bracket middle point not between end points")
+	}
+	s := scalarMinimizer{golden: golden}
+	var evals int
+	u, done := s.startBracket(br.A, br.B, br.C, br.FA, br.FB, br.FC, tol)
+	for !done {
+		evals++
+		u, done = s.iterate(f(u))
+	}
+	x, fx := s.min()
+	return ScalarResult{X: x, F: fx, FuncEvaluations: evals}
+}
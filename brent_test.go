@@ -0,0 +1,105 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+)
+
+var scalarTests = []struct {
+	name string
+	f    func(float64) float64
+	a, b float64
+	xOpt float64
+}{
+	{
+		name: "Quadratic",
+		f:    func(x float64) float64 { return (x - 2) * (x - 2) },
+		a:    0,
+		b:    1,
+		xOpt: 2,
+	},
+	{
+		name: "QuadraticBackward",
+		f:    func(x float64) float64 { return (x + 30) * (x + 30) },
+		a:    0,
+		b:    1,
+		xOpt: -30,
+	},
+	{
+		name: "Cos",
+		f:    math.Cos,
+		a:    2,
+		b:    2.5,
+		xOpt: math.Pi,
+	},
+	{
+		name: "Quartic",
+		f:    func(x float64) float64 { return math.Pow(x-1, 4) + x*x },
+		a:    -5,
+		b:    -4,
+		xOpt: 0.4102454,
+	},
+	{
+		name: "Abs",
+		f:    func(x float64) float64 { return math.Abs(x - 0.7) },
+		a:    10,
+		b:    9,
+		xOpt: 0.7,
+	},
+}
+
+func TestFindBracket(t *testing.T) {
+	for _, test := range scalarTests {
+		br, err := FindBracket(test.f, test.a, test.b)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if br.FB > br.FA || br.FB > br.FC {
+			t.Errorf("%s: middle value not lowest: %+v", test.name, br)
+		}
+		lo, hi := math.Min(br.A, br.C), math.Max(br.A, br.C)
+		if br.B <= lo || br.B >= hi || test.xOpt < lo || test.xOpt > hi {
+			t.Errorf("%s: minimum %v not bracketed by %+v", test.name, test.xOpt, br)
+		}
+		for _, v := range []struct{ x, f float64 }{{br.A, br.FA}, {br.B, br.FB}, {br.C, br.FC}} {
+			if test.f(v.x) != v.f {
+				t.Errorf("%s: function value mismatch at %v", test.name, v.x)
+			}
+		}
+	}
+
+	_, err := FindBracket(func(x float64) float64 { return -x }, 0, 1)
+	if err != ErrNoBracket {
+		t.Errorf("unexpected error for unbounded function: %v", err)
+	}
+}
+
+func TestScalarMinimizers(t *testing.T) {
+	for _, test := range scalarTests {
+		br, err := FindBracket(test.f, test.a, test.b)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		brent := Brent{}.Minimize(test.f, br)
+		golden := GoldenSection{}.Minimize(test.f, br)
+		for _, v := range []struct {
+			method string
+			result ScalarResult
+		}{{"Brent", brent}, {"GoldenSection", golden}} {
+			if math.Abs(v.result.X-test.xOpt) > 1e-6*math.Max(1, math.Abs(test.xOpt)) {
+				t.Errorf("%s, %s: unexpected minimum location, want %v, got %v", test.name, v.method, test.xOpt, v.result.X)
+			}
+			if v.result.F != test.f(v.result.X) {
+				t.Errorf("%s, %s: function value mismatch", test.name, v.method)
+			}
+		}
+		if brent.FuncEvaluations > golden.FuncEvaluations {
+			t.Errorf("%s: Brent used more evaluations than golden section search: %d > %d", test.name, brent.FuncEvaluations, golden.FuncEvaluations)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// Broyden implements Broyden's method for systems of nonlinear equations. It
+// is a quasi-Newton method that evaluates the Jacobian only at the initial
+// location and then maintains an approximation B_k of it with the rank-one
+// updates
+//  B_{k+1} = B_k + (y_k - B_k s_k) s_kᵀ / (s_kᵀ s_k),
+// where s_k = x_{k+1} - x_k and y_k = F(x_{k+1}) - F(x_k). The steps are
+// computed like in NewtonRaphson with B_k instead of the Jacobian. If the
+// line search fails or B_k becomes singular, the Jacobian is evaluated again.
+// The method converges superlinearly and needs fewer evaluations of the
+// Jacobian than NewtonRaphson, which makes it attractive when the Jacobian
+// is approximated by finite differences.
+//
+// References:
+//  - Broyden, C.G.: A class of methods for solving nonlinear simultaneous
+//    equations. Math. Comp. 19 (1965), 577-593
+type Broyden struct {
+	x, f  []float64 // Location and F of the last major iteration.
+	fnorm float64   // |F|^2 at x.
+	dir   []float64
+	step  float64
+	s, y  []float64
+	b     *mat64.Dense // Approximation of the Jacobian at x.
+	fresh bool         // Whether b is the Jacobian at x.
+	state broydenState
+	lu    rootLU
+}
+
+type broydenState int
+
+const (
+	broydenJacobian broydenState = iota // The Jacobian at x has been evaluated.
+	broydenMajor                        // x has been announced as a major iteration.
+	broydenTrial                        // F has been evaluated at a trial step.
+)
+
+func (b *Broyden) Init(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	n := len(loc.X)
+	b.x = resize(b.x, n)
+	b.f = resize(b.f, n)
+	b.dir = resize(b.dir, n)
+	b.s = resize(b.s, n)
+	b.y = resize(b.y, n)
+	b.b = resizeDense(b.b, n, n)
+	copy(b.x, loc.X)
+	copy(b.f, loc.F)
+	b.fnorm = floats.Dot(b.f, b.f)
+	b.state = broydenJacobian
+	copy(xNext, loc.X)
+	return GradEvaluation, NoIteration, nil
+}
+
+func (b *Broyden) Iterate(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	switch b.state {
+	case broydenJacobian:
+		b.b.Copy(loc.Jacobian)
+		b.fresh = true
+	case broydenTrial:
+		fnorm := floats.Dot(loc.F, loc.F)
+		if fnorm <= (1-2*rootArmijo*b.step)*b.fnorm {
+			// The step is accepted. Update the approximation of the
+			// Jacobian.
+			floats.SubTo(b.s, loc.X, b.x)
+			floats.SubTo(b.y, loc.F, b.f)
+			mat64.NewVector(len(b.x), b.dir).MulVec(b.b, false, mat64.NewVector(len(b.s), b.s))
+			floats.Sub(b.y, b.dir)
+			ss := floats.Dot(b.s, b.s)
+			n := len(b.x)
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					b.b.Set(i, j, b.b.At(i, j)+b.y[i]*b.s[j]/ss)
+				}
+			}
+			copy(b.x, loc.X)
+			copy(b.f, loc.F)
+			b.fnorm = fnorm
+			b.fresh = false
+			b.state = broydenMajor
+			copy(xNext, loc.X)
+			return NoEvaluation, MajorIteration, nil
+		}
+		b.step /= 2
+		if b.step >= rootMinStep {
+			floats.AddScaledTo(xNext, b.x, b.step, b.dir)
+			return FuncEvaluation, MinorIteration, nil
+		}
+		if b.fresh {
+			return NoEvaluation, NoIteration, ErrLinesearchFailure
+		}
+		// The approximation does not give a descent direction. Evaluate
+		// the Jacobian.
+		return b.refresh(xNext)
+	}
+
+	if !b.lu.factorize(b.b) {
+		if b.fresh {
+			return NoEvaluation, NoIteration, ErrSingularJacobian
+		}
+		return b.refresh(xNext)
+	}
+	b.lu.solve(b.dir, b.f)
+	floats.Scale(-1, b.dir)
+	b.step = 1
+	b.state = broydenTrial
+	floats.AddTo(xNext, b.x, b.dir)
+	return FuncEvaluation, MinorIteration, nil
+}
+
+// refresh requests the evaluation of the Jacobian at x.
+func (b *Broyden) refresh(xNext []float64) (EvaluationType, IterationType, error) {
+	b.state = broydenJacobian
+	copy(xNext, b.x)
+	return FuncEvaluation | GradEvaluation, NoIteration, nil
+}
@@ -0,0 +1,211 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"sync"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const defaultCacheSize = 16
+
+// CacheStats are the statistics of a FunctionCache.
+type CacheStats struct {
+	// FuncCalls, GradCalls and HessCalls are the numbers of calls of the
+	// wrapped Func, Grad and Hess, including those answered from the cache.
+	FuncCalls int
+	GradCalls int
+	HessCalls int
+	// FuncEvaluations, GradEvaluations and HessEvaluations are the numbers
+	// of evaluations of the original functions.
+	FuncEvaluations int
+	GradEvaluations int
+	HessEvaluations int
+}
+
+// FunctionCache memoizes the values of the objective function, the gradient
+// and the Hessian of a Problem at recently evaluated locations, and counts the
+// evaluations. Methods evaluate the same location repeatedly, for example
+// when a line search returns to a location it has already evaluated or when
+// the gradient is requested at a location where only the function value was
+// computed, so caching saves evaluations of expensive objective functions.
+//
+// A location is found in the cache only if it is equal to a cached location
+// element by element. The statistics can be inspected with Stats during and
+// after the optimization. A FunctionCache is safe for concurrent use.
+type FunctionCache struct {
+	// Size is the number of locations whose values are cached. The least
+	// recently used location is evicted when the cache is full. If Size is
+	// zero, it will be set to 16.
+	Size int
+
+	mu      sync.Mutex
+	entries []*cacheEntry // Ordered from the most recently used.
+	stats   CacheStats
+}
+
+// cacheEntry holds the values evaluated at a location.
+type cacheEntry struct {
+	x    []float64
+	f    float64
+	grad []float64
+	hess *mat64.SymDense
+
+	hasF, hasGrad, hasHess bool
+}
+
+// Problem returns a copy of p in which Func, Grad, FuncGrad and Hess, if they
+// are not nil, are answered from the cache of c. A call of FuncGrad is counted
+// as a call of both Func and Grad. The other fields of p are not modified. A
+// FunctionCache must only be used to wrap a single Problem. Problem panics if
+// c.Size is negative.
+func (c *FunctionCache) Problem(p Problem) Problem {
+	if c.Size == 0 {
+		c.Size = defaultCacheSize
+	}
+	if c.Size < 0 {
+		panic("optimize: negative cache size")
+	}
+	if f := p.Func; f != nil {
+		p.Func = func(x []float64) float64 {
+			c.mu.Lock()
+			c.stats.FuncCalls++
+			if e := c.lookup(x); e != nil && e.hasF {
+				v := e.f
+				c.mu.Unlock()
+				return v
+			}
+			c.mu.Unlock()
+
+			v := f(x)
+
+			c.mu.Lock()
+			c.stats.FuncEvaluations++
+			e := c.insert(x)
+			e.f = v
+			e.hasF = true
+			c.mu.Unlock()
+			return v
+		}
+	}
+	if grad := p.Grad; grad != nil {
+		p.Grad = func(x, g []float64) {
+			c.mu.Lock()
+			c.stats.GradCalls++
+			if e := c.lookup(x); e != nil && e.hasGrad {
+				copy(g, e.grad)
+				c.mu.Unlock()
+				return
+			}
+			c.mu.Unlock()
+
+			grad(x, g)
+
+			c.mu.Lock()
+			c.stats.GradEvaluations++
+			e := c.insert(x)
+			e.grad = resize(e.grad, len(g))
+			copy(e.grad, g)
+			e.hasGrad = true
+			c.mu.Unlock()
+		}
+	}
+	if fg := p.FuncGrad; fg != nil {
+		p.FuncGrad = func(x, g []float64) float64 {
+			c.mu.Lock()
+			c.stats.FuncCalls++
+			c.stats.GradCalls++
+			if e := c.lookup(x); e != nil && e.hasF && e.hasGrad {
+				v := e.f
+				copy(g, e.grad)
+				c.mu.Unlock()
+				return v
+			}
+			c.mu.Unlock()
+
+			v := fg(x, g)
+
+			c.mu.Lock()
+			c.stats.FuncEvaluations++
+			c.stats.GradEvaluations++
+			e := c.insert(x)
+			e.f = v
+			e.grad = resize(e.grad, len(g))
+			copy(e.grad, g)
+			e.hasF = true
+			e.hasGrad = true
+			c.mu.Unlock()
+			return v
+		}
+	}
+	if hess := p.Hess; hess != nil {
+		p.Hess = func(x []float64, h *mat64.SymDense) {
+			c.mu.Lock()
+			c.stats.HessCalls++
+			if e := c.lookup(x); e != nil && e.hasHess {
+				h.CopySym(e.hess)
+				c.mu.Unlock()
+				return
+			}
+			c.mu.Unlock()
+
+			hess(x, h)
+
+			c.mu.Lock()
+			c.stats.HessEvaluations++
+			e := c.insert(x)
+			if e.hess == nil || e.hess.Symmetric() != h.Symmetric() {
+				e.hess = mat64.NewSymDense(h.Symmetric(), nil)
+			}
+			e.hess.CopySym(h)
+			e.hasHess = true
+			c.mu.Unlock()
+		}
+	}
+	return p
+}
+
+// Stats returns the statistics of the evaluations made so far.
+func (c *FunctionCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// lookup returns the entry of the location x and marks it as the most
+// recently used, or returns nil if x is not cached.
+func (c *FunctionCache) lookup(x []float64) *cacheEntry {
+	for i, e := range c.entries {
+		if len(e.x) == len(x) && floats.Equal(e.x, x) {
+			copy(c.entries[1:i+1], c.entries[:i])
+			c.entries[0] = e
+			return e
+		}
+	}
+	return nil
+}
+
+// insert returns the entry of the location x, creating it and evicting the
+// least recently used entry if x is not cached.
+func (c *FunctionCache) insert(x []float64) *cacheEntry {
+	if e := c.lookup(x); e != nil {
+		return e
+	}
+	var e *cacheEntry
+	if len(c.entries) < c.Size {
+		e = &cacheEntry{}
+		c.entries = append(c.entries, nil)
+	} else {
+		// Reuse the storage of the evicted entry.
+		e = c.entries[len(c.entries)-1]
+		*e = cacheEntry{x: e.x[:0], grad: e.grad, hess: e.hess}
+	}
+	copy(c.entries[1:], c.entries[:len(c.entries)-1])
+	c.entries[0] = e
+	e.x = append(e.x, x...)
+	return e
+}
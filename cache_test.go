@@ -0,0 +1,96 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestFunctionCache(t *testing.T) {
+	f := functions.ExtendedRosenbrock{}
+	rosenHess := func(x []float64, hess *mat64.SymDense) {
+		hess.SetSym(0, 0, 1200*x[0]*x[0]-400*x[1]+2)
+		hess.SetSym(0, 1, -400*x[0])
+		hess.SetSym(1, 1, 200)
+	}
+	c := &FunctionCache{Size: 2}
+	p := c.Problem(Problem{Func: f.Func, Grad: f.Grad, Hess: rosenHess})
+
+	x1 := []float64{-1.2, 1}
+	x2 := []float64{0.5, 0.5}
+	x3 := []float64{1, 1}
+	grad := make([]float64, 2)
+	hess := mat64.NewSymDense(2, nil)
+	want := mat64.NewSymDense(2, nil)
+
+	if v := p.Func(x1); v != f.Func(x1) {
+		t.Errorf("unexpected function value %v", v)
+	}
+	p.Func(x1)
+	p.Grad(x1, grad)
+	p.Grad(x1, grad)
+	rosenHess(x1, want)
+	p.Hess(x1, hess)
+	p.Hess(x1, hess)
+	for i := 0; i < 2; i++ {
+		for j := i; j < 2; j++ {
+			if hess.At(i, j) != want.At(i, j) {
+				t.Errorf("unexpected cached Hessian")
+			}
+		}
+	}
+	p.Func(x2)
+	p.Func(x1) // x1 is the most recently used location now.
+	p.Func(x3) // Evicts x2.
+	p.Grad(x1, grad)
+	wantGrad := make([]float64, 2)
+	f.Grad(x1, wantGrad)
+	if !floats.Equal(grad, wantGrad) {
+		t.Errorf("unexpected cached gradient %v", grad)
+	}
+	p.Func(x2) // Evicts x3.
+	p.Func(x1)
+
+	want2 := CacheStats{
+		FuncCalls:       7,
+		GradCalls:       3,
+		HessCalls:       2,
+		FuncEvaluations: 4,
+		GradEvaluations: 1,
+		HessEvaluations: 1,
+	}
+	if s := c.Stats(); s != want2 {
+		t.Errorf("unexpected statistics. Want %+v, got %+v", want2, s)
+	}
+
+	// The cache must not change the result of an optimization.
+	for _, method := range []Method{&BFGS{}, &NelderMead{}} {
+		prob := Problem{Func: f.Func, Grad: f.Grad}
+		x := []float64{-1.2, 1}
+		want, err := Local(prob, x, nil, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &FunctionCache{}
+		got, err := Local(c.Problem(prob), x, nil, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !floats.Equal(got.X, want.X) || got.F != want.F {
+			t.Errorf("%T: cached optimization differs", method)
+		}
+		s := c.Stats()
+		if s.FuncCalls != got.FuncEvaluations || s.GradCalls != got.GradEvaluations {
+			t.Errorf("%T: calls %+v do not match the statistics of the result", method, s)
+		}
+		if s.FuncEvaluations > s.FuncCalls || s.GradEvaluations > s.GradCalls {
+			t.Errorf("%T: more evaluations than calls: %+v", method, s)
+		}
+	}
+}
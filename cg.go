@@ -1,3 +1,7 @@
+// Copyright ©2014 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
 package optimize
 
 import (
@@ -22,6 +26,20 @@ type CGVariant interface {
 	Beta(grad, gradPrev, dirPrev []float64) float64
 }
 
+// PreconditionedCGVariant is a CGVariant that also computes the scaling
+// parameter β_k of the preconditioned CG method, which generates the search
+// directions
+//  d_{k+1} = -M^{-1}∇f_{k+1} + β_k*d_k,   d_0 = -M^{-1}∇f_0.
+// The formulas for β_k follow from those of the CG method applied in the
+// variables transformed by the preconditioner.
+type PreconditionedCGVariant interface {
+	CGVariant
+	// PreconditionedBeta returns the value of the scaling parameter given
+	// additionally the preconditioned gradients M^{-1}∇f_{k+1} and
+	// M^{-1}∇f_k.
+	PreconditionedBeta(grad, gradPrev, precGrad, precGradPrev, dirPrev []float64) float64
+}
+
 // CG implements the nonlinear conjugate gradient method for solving nonlinear
 // unconstrained optimization problems. It is a line search method that
 // generates the search directions d_k according to the formula
@@ -49,6 +67,10 @@ type CGVariant interface {
 // by setting the gradient constant in the strong Wolfe conditions to a small
 // value.
 //
+// If Preconditioner is not nil, CG generates the directions of the
+// preconditioned CG method as described in PreconditionedCGVariant, and the
+// Variant must implement PreconditionedCGVariant.
+//
 // See also William Hager, Hongchao Zhang, A survey of nonlinear conjugate
 // gradient methods. Pacific Journal of Optimization, 2 (2006), pp. 35-58, and
 // references therein.
@@ -63,6 +85,9 @@ type CG struct {
 	// method does not generate well-scaled search directions.
 	// If InitialStep is nil, an appropriate default is chosen.
 	InitialStep StepSizer
+	// Preconditioner is the preconditioner of the method. If Preconditioner
+	// is nil, no preconditioning is used.
+	Preconditioner Preconditioner
 
 	// IterationRestartFactor determines the frequency of restarts based on the
 	// problem dimension. The negative gradient direction is taken whenever
@@ -92,6 +117,9 @@ type CG struct {
 	dirPrev      []float64
 	gradPrev     []float64
 	gradPrevNorm float64
+
+	precGrad     []float64 // Preconditioned gradient.
+	precGradPrev []float64
 }
 
 func (cg *CG) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
@@ -111,6 +139,11 @@ func (cg *CG) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationTy
 	if cg.InitialStep == nil {
 		cg.InitialStep = &FirstOrderStepSize{}
 	}
+	if cg.Preconditioner != nil {
+		if _, ok := cg.Variant.(PreconditionedCGVariant); !ok {
+			panic("cg: Variant does not support preconditioning")
+		}
+	}
 
 	if cg.IterationRestartFactor == 0 {
 		cg.IterationRestartFactor = iterationRestartFactor
@@ -138,8 +171,17 @@ func (cg *CG) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	cg.restartAfter = int(math.Ceil(cg.IterationRestartFactor * float64(dim)))
 	cg.iterFromRestart = 0
 
-	// The initial direction is always the negative gradient.
-	copy(dir, loc.Gradient)
+	// The initial direction is always the negative (preconditioned) gradient.
+	if cg.Preconditioner != nil {
+		cg.precGrad = resize(cg.precGrad, dim)
+		cg.precGradPrev = resize(cg.precGradPrev, dim)
+		updatePreconditioner(cg.Preconditioner, loc)
+		cg.Preconditioner.Apply(cg.precGrad, loc.Gradient)
+		copy(cg.precGradPrev, cg.precGrad)
+		copy(dir, cg.precGrad)
+	} else {
+		copy(dir, loc.Gradient)
+	}
 	floats.Scale(-1, dir)
 
 	cg.dirPrev = resize(cg.dirPrev, dim)
@@ -152,8 +194,22 @@ func (cg *CG) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	return cg.InitialStep.Init(loc, dir)
 }
 
+// RestartDirection restarts the method with the negative (preconditioned)
+// gradient.
+func (cg *CG) RestartDirection(loc *Location, dir []float64) (stepSize float64) {
+	return cg.InitDirection(loc, dir)
+}
+
 func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
-	copy(dir, loc.Gradient)
+	// steepest is the negative of the direction taken when the method is
+	// restarted.
+	steepest := loc.Gradient
+	if cg.Preconditioner != nil {
+		updatePreconditioner(cg.Preconditioner, loc)
+		cg.Preconditioner.Apply(cg.precGrad, loc.Gradient)
+		steepest = cg.precGrad
+	}
+	copy(dir, steepest)
 	floats.Scale(-1, dir)
 
 	cg.iterFromRestart++
@@ -174,7 +230,12 @@ func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	// Compute the scaling factor β_k according to the given CG variant. Do
 	// this even when restarting, because cg.Variant may be keeping an inner
 	// state that needs to be updated at every iteration.
-	beta := cg.Variant.Beta(loc.Gradient, cg.gradPrev, cg.dirPrev)
+	var beta float64
+	if cg.Preconditioner != nil {
+		beta = cg.Variant.(PreconditionedCGVariant).PreconditionedBeta(loc.Gradient, cg.gradPrev, cg.precGrad, cg.precGradPrev, cg.dirPrev)
+	} else {
+		beta = cg.Variant.Beta(loc.Gradient, cg.gradPrev, cg.dirPrev)
+	}
 	if beta == 0 {
 		// β_k == 0 means that the steepest descent direction will be taken, so
 		// indicate that the method is in fact being restarted.
@@ -186,7 +247,7 @@ func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 		if floats.Dot(loc.Gradient, dir) >= 0 {
 			// Restart because the new direction is not a descent direction.
 			restart = true
-			copy(dir, loc.Gradient)
+			copy(dir, steepest)
 			floats.Scale(-1, dir)
 		}
 	}
@@ -206,9 +267,38 @@ func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	copy(cg.gradPrev, loc.Gradient)
 	copy(cg.dirPrev, dir)
 	cg.gradPrevNorm = gNorm
+	if cg.Preconditioner != nil {
+		copy(cg.precGradPrev, cg.precGrad)
+	}
 	return stepSize
 }
 
+// Reset discards the previous direction and gradient of the last run,
+// keeping the exported fields.
+func (cg *CG) Reset() {
+	*cg = CG{
+		LinesearchMethod:       cg.LinesearchMethod,
+		Variant:                cg.Variant,
+		InitialStep:            cg.InitialStep,
+		Preconditioner:         cg.Preconditioner,
+		IterationRestartFactor: cg.IterationRestartFactor,
+		AngleRestartThreshold:  cg.AngleRestartThreshold,
+	}
+}
+
+// Clone returns a new CG with the configuration of cg and copies of its
+// LinesearchMethod, Variant and InitialStep.
+func (cg *CG) Clone() Method {
+	return &CG{
+		LinesearchMethod:       cloneLinesearchMethod(cg.LinesearchMethod),
+		Variant:                cloneCGVariant(cg.Variant),
+		InitialStep:            cloneStepSizer(cg.InitialStep),
+		Preconditioner:         cg.Preconditioner,
+		IterationRestartFactor: cg.IterationRestartFactor,
+		AngleRestartThreshold:  cg.AngleRestartThreshold,
+	}
+}
+
 func (*CG) Needs() struct {
 	Gradient bool
 	Hessian  bool
@@ -237,6 +327,13 @@ func (fr *FletcherReeves) Beta(grad, _, _ []float64) (beta float64) {
 	return beta
 }
 
+// PreconditionedBeta returns
+//  β_k = ∇f_{k+1}·M^{-1}∇f_{k+1} / ∇f_k·M^{-1}∇f_k.
+func (fr *FletcherReeves) PreconditionedBeta(grad, gradPrev, precGrad, precGradPrev, _ []float64) float64 {
+	fr.prevNorm = floats.Norm(grad, 2)
+	return floats.Dot(grad, precGrad) / floats.Dot(gradPrev, precGradPrev)
+}
+
 // PolakRibierePolyak implements the Polak-Ribiere-Polyak variant of the CG
 // method that computes the scaling parameter β_k according to the formula
 //  β_k = max(0, ∇f_{k+1}·y_k / |∇f_k|^2),
@@ -257,6 +354,14 @@ func (pr *PolakRibierePolyak) Beta(grad, gradPrev, _ []float64) (beta float64) {
 	return math.Max(0, beta)
 }
 
+// PreconditionedBeta returns
+//  β_k = max(0, M^{-1}∇f_{k+1}·y_k / ∇f_k·M^{-1}∇f_k).
+func (pr *PolakRibierePolyak) PreconditionedBeta(grad, gradPrev, precGrad, precGradPrev, _ []float64) float64 {
+	pr.prevNorm = floats.Norm(grad, 2)
+	beta := (floats.Dot(precGrad, grad) - floats.Dot(precGrad, gradPrev)) / floats.Dot(gradPrev, precGradPrev)
+	return math.Max(0, beta)
+}
+
 // HestenesStiefel implements the Hestenes-Stiefel variant of the CG method
 // that computes the scaling parameter β_k according to the formula
 //  β_k = max(0, ∇f_{k+1}·y_k / d_k·y_k),
@@ -275,6 +380,14 @@ func (hs *HestenesStiefel) Beta(grad, gradPrev, dirPrev []float64) (beta float64
 	return math.Max(0, beta)
 }
 
+// PreconditionedBeta returns
+//  β_k = max(0, M^{-1}∇f_{k+1}·y_k / d_k·y_k).
+func (hs *HestenesStiefel) PreconditionedBeta(grad, gradPrev, precGrad, _, dirPrev []float64) float64 {
+	floats.SubTo(hs.y, grad, gradPrev)
+	beta := floats.Dot(precGrad, hs.y) / floats.Dot(dirPrev, hs.y)
+	return math.Max(0, beta)
+}
+
 // DaiYuan implements the Dai-Yuan variant of the CG method that computes the
 // scaling parameter β_k according to the formula
 //  β_k = |∇f_{k+1}|^2 / d_k·y_k,
@@ -293,16 +406,25 @@ func (dy *DaiYuan) Beta(grad, gradPrev, dirPrev []float64) (beta float64) {
 	return norm * norm / floats.Dot(dirPrev, dy.y)
 }
 
+// PreconditionedBeta returns
+//  β_k = ∇f_{k+1}·M^{-1}∇f_{k+1} / d_k·y_k.
+func (dy *DaiYuan) PreconditionedBeta(grad, gradPrev, precGrad, _, dirPrev []float64) float64 {
+	floats.SubTo(dy.y, grad, gradPrev)
+	return floats.Dot(grad, precGrad) / floats.Dot(dirPrev, dy.y)
+}
+
 // HagerZhang implements the Hager-Zhang variant of the CG method that computes the
 // scaling parameter β_k according to the formula
 //  β_k = (y_k - 2 d_k |y_k|^2/(d_k·y_k))·∇f_{k+1} / (d_k·y_k),
 // where y_k = ∇f_{k+1} - ∇f_k.
 type HagerZhang struct {
 	y []float64
+	w []float64 // Difference of the preconditioned gradients.
 }
 
 func (hz *HagerZhang) Init(loc *Location) {
 	hz.y = resize(hz.y, len(loc.Gradient))
+	hz.w = resize(hz.w, len(loc.Gradient))
 }
 
 func (hz *HagerZhang) Beta(grad, gradPrev, dirPrev []float64) (beta float64) {
@@ -313,3 +435,35 @@ func (hz *HagerZhang) Beta(grad, gradPrev, dirPrev []float64) (beta float64) {
 	yNorm := floats.Norm(hz.y, 2)
 	return (gDotY - 2*gDotDir*yNorm*yNorm/dirDotY) / dirDotY
 }
+
+// PreconditionedBeta returns
+//  β_k = (M^{-1}y_k - 2 d_k y_k·M^{-1}y_k/(d_k·y_k))·∇f_{k+1} / (d_k·y_k),
+// where M^{-1}y_k is computed as the difference of the preconditioned
+// gradients.
+func (hz *HagerZhang) PreconditionedBeta(grad, gradPrev, precGrad, precGradPrev, dirPrev []float64) float64 {
+	floats.SubTo(hz.y, grad, gradPrev)
+	floats.SubTo(hz.w, precGrad, precGradPrev)
+	dirDotY := floats.Dot(dirPrev, hz.y)
+	gDotW := floats.Dot(grad, hz.w)
+	gDotDir := floats.Dot(grad, dirPrev)
+	yDotW := floats.Dot(hz.y, hz.w)
+	return (gDotW - 2*gDotDir*yDotW/dirDotY) / dirDotY
+}
+
+// cloneCGVariant returns a CGVariant of the type of v without its state.
+// CGVariants of types defined outside this package are returned unchanged.
+func cloneCGVariant(v CGVariant) CGVariant {
+	switch v.(type) {
+	case *FletcherReeves:
+		return &FletcherReeves{}
+	case *PolakRibierePolyak:
+		return &PolakRibierePolyak{}
+	case *HestenesStiefel:
+		return &HestenesStiefel{}
+	case *DaiYuan:
+		return &DaiYuan{}
+	case *HagerZhang:
+		return &HagerZhang{}
+	}
+	return v
+}
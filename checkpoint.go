@@ -0,0 +1,126 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Checkpoint is the state of an optimization run at a major iteration from
+// which the run can be resumed by ResumeLocal, for example after a crash.
+// Checkpoints are created by Local if Settings.CheckpointIterations is
+// positive, and can be stored with encoding/gob, or with encoding/json if
+// all function values are finite.
+type Checkpoint struct {
+	// Location is the location of the major iteration.
+	Location CheckpointLocation
+	// Optimum is the best location found so far.
+	Optimum CheckpointLocation
+	// Stats are the statistics of the run so far.
+	Stats Stats
+	// FunctionConvergeBest and FunctionConvergeIter are the state of
+	// Settings.FunctionConverge.
+	FunctionConvergeBest float64
+	FunctionConvergeIter int
+	// Method is the state of the Method returned by Checkpointer.Checkpoint.
+	Method []byte
+}
+
+// CheckpointLocation is the serializable form of a Location.
+type CheckpointLocation struct {
+	X        []float64
+	F        float64
+	Gradient []float64
+	// Hessian holds the elements of the Hessian in row-major order, or is
+	// nil if the Location has no Hessian.
+	Hessian []float64
+}
+
+func newCheckpointLocation(loc *Location) CheckpointLocation {
+	c := CheckpointLocation{
+		X: append([]float64(nil), loc.X...),
+		F: loc.F,
+	}
+	if loc.Gradient != nil {
+		c.Gradient = append([]float64(nil), loc.Gradient...)
+	}
+	if loc.Hessian != nil {
+		c.Hessian = symData(loc.Hessian)
+	}
+	return c
+}
+
+// location stores the checkpointed location into loc, whose fields must be
+// allocated as needed by the Method.
+func (c CheckpointLocation) location(loc *Location) error {
+	dim := len(loc.X)
+	if len(c.X) != dim ||
+		(loc.Gradient != nil && len(c.Gradient) != dim) ||
+		(loc.Hessian != nil && len(c.Hessian) != dim*dim) {
+		return errCheckpointMismatch
+	}
+	copy(loc.X, c.X)
+	loc.F = c.F
+	copy(loc.Gradient, c.Gradient)
+	if loc.Hessian != nil {
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				loc.Hessian.SetSym(i, j, c.Hessian[i*dim+j])
+			}
+		}
+	}
+	return nil
+}
+
+// newCheckpoint returns the checkpoint of a run at the major iteration loc.
+func newCheckpoint(method Checkpointer, loc, optLoc *Location, stats *Stats, fc *FunctionConverge) (*Checkpoint, error) {
+	state, err := method.Checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{
+		Location: newCheckpointLocation(loc),
+		Optimum:  newCheckpointLocation(optLoc),
+		Stats:    *stats,
+		Method:   state,
+	}
+	if fc != nil {
+		cp.FunctionConvergeBest = fc.best
+		cp.FunctionConvergeIter = fc.iter
+	}
+	return cp, nil
+}
+
+// symData returns the elements of a in row-major order.
+func symData(a *mat64.SymDense) []float64 {
+	n := a.Symmetric()
+	data := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			data[i*n+j] = a.At(i, j)
+		}
+	}
+	return data
+}
+
+// encodeState and decodeState serialize the state of a Method with
+// encoding/gob.
+func encodeState(state interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(state)
+	return buf.Bytes(), err
+}
+
+func decodeState(data []byte, state interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(state)
+}
+
+// errCheckpointMismatch is returned by Checkpointer.Restore if the state
+// does not match the problem.
+var errCheckpointMismatch = errors.New("optimize: checkpoint does not match the problem")
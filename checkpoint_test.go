@@ -0,0 +1,91 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestCheckpoint(t *testing.T) {
+	p := Problem{
+		Func: functions.Wood{}.Func,
+		Grad: functions.Wood{}.Grad,
+		Hess: functions.Wood{}.Hess,
+	}
+	x := []float64{-3, -1, -3, -1}
+	for _, test := range []struct {
+		name   string
+		method func() Method
+	}{
+		{"BFGS", func() Method { return &BFGS{} }},
+		{"LBFGS", func() Method { return &LBFGS{Store: 3} }},
+		{"Newton", func() Method { return &Newton{} }},
+	} {
+		// Encode the checkpoints like a program that stores them in files.
+		var gobs [][]byte
+		settings := DefaultSettings()
+		settings.Recorder = nil
+		settings.CheckpointIterations = 5
+		settings.SaveCheckpoint = func(cp *Checkpoint) error {
+			var buf bytes.Buffer
+			err := gob.NewEncoder(&buf).Encode(cp)
+			gobs = append(gobs, buf.Bytes())
+			return err
+		}
+		want, err := Local(p, x, settings, test.method())
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(gobs) < 2 {
+			t.Errorf("%s: too few checkpoints %d", test.name, len(gobs))
+			continue
+		}
+
+		for i, data := range gobs {
+			var cp Checkpoint
+			if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&cp); err != nil {
+				t.Fatalf("%s: unexpected decoding error: %v", test.name, err)
+			}
+			if i == 0 {
+				// Checkpoints can also be stored as JSON.
+				b, err := json.Marshal(&cp)
+				if err != nil {
+					t.Fatalf("%s: unexpected JSON error: %v", test.name, err)
+				}
+				cp = Checkpoint{}
+				if err := json.Unmarshal(b, &cp); err != nil {
+					t.Fatalf("%s: unexpected JSON error: %v", test.name, err)
+				}
+			}
+			if cp.Stats.MajorIterations != 5*(i+1) {
+				t.Errorf("%s: unexpected iteration of checkpoint %d: %d", test.name, i, cp.Stats.MajorIterations)
+			}
+			settings := DefaultSettings()
+			settings.Recorder = nil
+			got, err := ResumeLocal(&cp, p, settings, test.method())
+			if err != nil {
+				t.Errorf("%s: unexpected error resuming from checkpoint %d: %v", test.name, i, err)
+				continue
+			}
+			// Resuming continues the original run exactly.
+			if got.Status != want.Status || got.F != want.F || !floats.Equal(got.X, want.X) ||
+				got.MajorIterations != want.MajorIterations || got.FuncEvaluations != want.FuncEvaluations {
+				t.Errorf("%s: resumed run from checkpoint %d differs from the original run", test.name, i)
+			}
+		}
+	}
+
+	var cp Checkpoint
+	if _, err := ResumeLocal(&cp, p, nil, &NelderMead{}); err == nil {
+		t.Errorf("expected error resuming a method that is not a Checkpointer")
+	}
+}
@@ -0,0 +1,435 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultCmaEsStepSize = 0.5
+	defaultCmaEsStepTol  = 1e-12
+	defaultCmaEsFuncTol  = 1e-12
+	// cmaEsMaxCond is the largest ratio of the largest and the smallest
+	// diagonal element of the Cholesky factor of the covariance matrix,
+	// that is roughly the square root of the largest allowed condition
+	// number of the covariance matrix.
+	cmaEsMaxCond = 1e7
+)
+
+// CmaEsRestart specifies the restart strategy of CmaEs.
+type CmaEsRestart int
+
+const (
+	// CmaEsNoRestart performs a single run.
+	CmaEsNoRestart CmaEsRestart = iota
+	// CmaEsIPOP doubles the population size at every restart.
+	CmaEsIPOP
+	// CmaEsBIPOP alternates between the restart regime of CmaEsIPOP and
+	// runs with a small population and a small initial step size, choosing
+	// the regime that has used fewer function evaluations so far.
+	CmaEsBIPOP
+)
+
+// CmaEs implements the covariance matrix adaptation evolution strategy for
+// gradient-free minimization of non-smooth, noisy or multimodal functions.
+// In every generation, CmaEs samples Population locations from the normal
+// distribution
+//  N(m, σ^2 C),
+// and moves the mean m towards the weighted mean of the better half of the
+// samples. The step size σ is adapted by cumulative step-size adaptation and
+// the covariance matrix C by a combination of the rank-one and the rank-μ
+// update, so that the distribution learns the scaling and the correlations
+// of the variables. The samples are drawn through the Cholesky factor of C.
+//
+// A run stops when the standard deviation of the distribution in all
+// coordinates is smaller than StepTolerance times InitStepSize, when the
+// function values of the recent generations differ by less than
+// FunctionTolerance, or when C becomes ill-conditioned. If Restart is not
+// CmaEsNoRestart, a new run is then started from a mean drawn from
+//  N(x_0, InitStepSize^2 I),
+// where x_0 is the initial location, with a population size and an initial
+// step size determined by the restart strategy. The best location over all
+// runs is returned. Each generation is a major iteration.
+//
+// If Src is not nil, the sequence of evaluated locations is fully
+// determined by the initial location and by the state of Src, so seeding
+// Src makes the run reproducible.
+//
+// References:
+//  - Hansen, N.: The CMA evolution strategy: A tutorial. arXiv:1604.00772
+//  - Auger, A., Hansen, N.: A restart CMA evolution strategy with increasing
+//    population size. In Proc. IEEE Congress on Evolutionary Computation
+//    (2005), 1769-1776
+//  - Hansen, N.: Benchmarking a BI-population CMA-ES on the BBOB-2009
+//    function testbed. In Proc. GECCO (2009), 2389-2396
+type CmaEs struct {
+	// InitStepSize is the initial step size σ. It should be about a third of
+	// the expected distance to the minimum. If InitStepSize is zero, it will
+	// be set to 0.5.
+	InitStepSize float64
+	// Population is the number of samples in the first run. If Population is
+	// zero, it will be set to 4 + ⌊3 ln n⌋, where n is the dimension of the
+	// problem. CmaEs panics if Population is nonzero and smaller than 2.
+	Population int
+	// StepTolerance is the relative tolerance of the standard deviation of
+	// the distribution that stops a run. If StepTolerance is zero, it will
+	// be set to 1e-12.
+	StepTolerance float64
+	// FunctionTolerance is the absolute tolerance of the function values
+	// that stops a run. If FunctionTolerance is zero, it will be set to
+	// 1e-12.
+	FunctionTolerance float64
+	// Restart is the restart strategy.
+	Restart CmaEsRestart
+	// MaxRestarts is the maximum number of restarts. If MaxRestarts is zero,
+	// the number of restarts is not limited and the minimization is
+	// terminated by the Settings.
+	MaxRestarts int
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+
+	rnd    *rand.Rand
+	x0     []float64
+	status Status
+
+	// Restart state.
+	restarts    int
+	defaultPop  int // Default population size.
+	largePop    int // Population size of the last large population run.
+	largeEvals  int // Number of evaluations in large population runs.
+	smallEvals  int // Number of evaluations in small population runs.
+	smallRegime bool
+
+	// Strategy parameters of the current run.
+	lambda  int
+	weights []float64
+	mueff   float64
+	cs, ds  float64
+	cc      float64
+	c1, cmu float64
+	chiN    float64 // Expected length of a standard normal vector.
+
+	// State of the current run.
+	gen   int
+	sigma float64
+	mean  []float64
+	ps    []float64 // Evolution path of the step size.
+	pc    []float64 // Evolution path of the covariance matrix.
+	c     *mat64.SymDense
+	chol  *mat64.TriDense // Lower Cholesky factor of c.
+	hist  []float64       // Best function values of the recent generations.
+
+	// Samples of the current generation.
+	idx   int
+	z     [][]float64 // Standard normal samples.
+	y     [][]float64 // Samples from N(0, C).
+	xs    [][]float64 // Locations mean + sigma*y.
+	fs    []float64
+	order []int
+	yw    []float64
+	zw    []float64
+}
+
+func (cm *CmaEs) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if cm.InitStepSize == 0 {
+		cm.InitStepSize = defaultCmaEsStepSize
+	}
+	if cm.InitStepSize < 0 {
+		panic("cmaes: InitStepSize must be positive")
+	}
+	if cm.Population < 0 || cm.Population == 1 {
+		panic("cmaes: Population must be at least 2")
+	}
+	if cm.StepTolerance == 0 {
+		cm.StepTolerance = defaultCmaEsStepTol
+	}
+	if cm.FunctionTolerance == 0 {
+		cm.FunctionTolerance = defaultCmaEsFuncTol
+	}
+	if cm.StepTolerance < 0 || cm.FunctionTolerance < 0 {
+		panic("cmaes: negative tolerance")
+	}
+	if cm.MaxRestarts < 0 {
+		panic("cmaes: negative number of restarts")
+	}
+	switch cm.Restart {
+	case CmaEsNoRestart, CmaEsIPOP, CmaEsBIPOP:
+	default:
+		panic("cmaes: unknown restart strategy")
+	}
+	cm.rnd = newRand(cm.Src, p.Src)
+
+	cm.x0 = resize(cm.x0, dim)
+	copy(cm.x0, loc.X)
+	cm.mean = resize(cm.mean, dim)
+	cm.ps = resize(cm.ps, dim)
+	cm.pc = resize(cm.pc, dim)
+	cm.yw = resize(cm.yw, dim)
+	cm.zw = resize(cm.zw, dim)
+	cm.c = resizeSymDense(cm.c, dim)
+	cm.chol = resizeTriDense(cm.chol, dim)
+	cm.chiN = math.Sqrt(float64(dim)) * (1 - 1/(4*float64(dim)) + 1/(21*float64(dim*dim)))
+
+	cm.status = NotTerminated
+	cm.restarts = 0
+	cm.defaultPop = 4 + int(3*math.Log(float64(dim)))
+	pop := cm.Population
+	if pop == 0 {
+		pop = cm.defaultPop
+	}
+	cm.largePop = pop
+	cm.largeEvals = 0
+	cm.smallEvals = 0
+	cm.smallRegime = false
+
+	cm.newRun(loc.X, pop, cm.InitStepSize)
+	copy(xNext, cm.xs[0])
+	return FuncEvaluation, MinorIteration, nil
+}
+
+func (cm *CmaEs) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	cm.fs[cm.idx] = loc.F
+	cm.idx++
+	if cm.idx < cm.lambda {
+		copy(xNext, cm.xs[cm.idx])
+		return FuncEvaluation, MinorIteration, nil
+	}
+
+	if cm.smallRegime {
+		cm.smallEvals += cm.lambda
+	} else {
+		cm.largeEvals += cm.lambda
+	}
+	if cm.update() {
+		cm.sample()
+		copy(xNext, cm.xs[0])
+		return FuncEvaluation, MajorIteration, nil
+	}
+
+	// The run has stopped.
+	if cm.Restart == CmaEsNoRestart || (cm.MaxRestarts > 0 && cm.restarts == cm.MaxRestarts) {
+		cm.status = MethodConverge
+		copy(xNext, loc.X)
+		return NoEvaluation, MajorIteration, nil
+	}
+	cm.restart()
+	copy(xNext, cm.xs[0])
+	return FuncEvaluation, MajorIteration, nil
+}
+
+// Status returns MethodConverge when the last run has stopped and no more
+// restarts are allowed, and NotTerminated otherwise.
+func (cm *CmaEs) Status() (Status, error) {
+	return cm.status, nil
+}
+
+// restart starts a new run according to the restart strategy.
+func (cm *CmaEs) restart() {
+	cm.restarts++
+	pop := 2 * cm.largePop
+	sigma := cm.InitStepSize
+	cm.smallRegime = false
+	if cm.Restart == CmaEsBIPOP && cm.smallEvals < cm.largeEvals {
+		// Small population regime.
+		u := cm.rnd.Float64()
+		pop = int(float64(cm.defaultPop) * math.Pow(0.5*float64(cm.largePop)/float64(cm.defaultPop), u*u))
+		if pop < 2 {
+			pop = 2
+		}
+		sigma *= math.Pow(10, -2*cm.rnd.Float64())
+		cm.smallRegime = true
+	} else {
+		cm.largePop = pop
+	}
+
+	mean := cm.yw
+	for i := range mean {
+		mean[i] = cm.x0[i] + cm.InitStepSize*cm.rnd.NormFloat64()
+	}
+	cm.newRun(mean, pop, sigma)
+}
+
+// newRun initializes the strategy parameters and the state of a new run
+// with the given mean, population size and step size, and samples the first
+// generation.
+func (cm *CmaEs) newRun(mean []float64, pop int, sigma float64) {
+	dim := len(mean)
+	n := float64(dim)
+
+	cm.lambda = pop
+	mu := pop / 2
+	cm.weights = resize(cm.weights, mu)
+	for i := range cm.weights {
+		cm.weights[i] = math.Log(float64(pop+1)/2) - math.Log(float64(i+1))
+	}
+	floats.Scale(1/floats.Sum(cm.weights), cm.weights)
+	cm.mueff = 1 / floats.Dot(cm.weights, cm.weights)
+
+	cm.cs = (cm.mueff + 2) / (n + cm.mueff + 5)
+	cm.ds = 1 + 2*math.Max(0, math.Sqrt((cm.mueff-1)/(n+1))-1) + cm.cs
+	cm.cc = (4 + cm.mueff/n) / (n + 4 + 2*cm.mueff/n)
+	cm.c1 = 2 / ((n+1.3)*(n+1.3) + cm.mueff)
+	cm.cmu = math.Min(1-cm.c1, 2*(cm.mueff-2+1/cm.mueff)/((n+2)*(n+2)+cm.mueff))
+
+	cm.gen = 0
+	cm.sigma = sigma
+	copy(cm.mean, mean)
+	for i := 0; i < dim; i++ {
+		cm.ps[i] = 0
+		cm.pc[i] = 0
+		for j := i; j < dim; j++ {
+			var v float64
+			if i == j {
+				v = 1
+			}
+			cm.c.SetSym(i, j, v)
+		}
+	}
+	cm.chol.Cholesky(cm.c, false)
+	cm.hist = resize(cm.hist, 10+int(math.Ceil(30*n/float64(pop))))
+
+	cm.z = resizeMembers(cm.z, pop, dim)
+	cm.y = resizeMembers(cm.y, pop, dim)
+	cm.xs = resizeMembers(cm.xs, pop, dim)
+	cm.fs = resize(cm.fs, pop)
+	if cap(cm.order) < pop {
+		cm.order = make([]int, pop)
+	}
+	cm.order = cm.order[:pop]
+	cm.sample()
+}
+
+// sample draws a new generation.
+func (cm *CmaEs) sample() {
+	for k := range cm.xs {
+		z, y, x := cm.z[k], cm.y[k], cm.xs[k]
+		for i := range z {
+			z[i] = cm.rnd.NormFloat64()
+		}
+		for i := range y {
+			var v float64
+			for j := 0; j <= i; j++ {
+				v += cm.chol.At(i, j) * z[j]
+			}
+			y[i] = v
+			x[i] = cm.mean[i] + cm.sigma*v
+		}
+	}
+	cm.idx = 0
+}
+
+// update updates the distribution from the evaluated generation. It returns
+// false if the run should be stopped.
+func (cm *CmaEs) update() bool {
+	dim := len(cm.mean)
+	for i := range cm.order {
+		cm.order[i] = i
+	}
+	sort.Sort(cmaEsSorter{cm.order, cm.fs})
+
+	for i := range cm.yw {
+		cm.yw[i] = 0
+		cm.zw[i] = 0
+	}
+	for k, w := range cm.weights {
+		floats.AddScaled(cm.yw, w, cm.y[cm.order[k]])
+		floats.AddScaled(cm.zw, w, cm.z[cm.order[k]])
+	}
+	floats.AddScaled(cm.mean, cm.sigma, cm.yw)
+
+	// Since y = L z with C = L Lᵀ, the weighted mean of z has the same
+	// distribution as C^{-1/2} times the weighted mean of y under random
+	// selection, up to a rotation that leaves its length unchanged.
+	floats.Scale(1-cm.cs, cm.ps)
+	floats.AddScaled(cm.ps, math.Sqrt(cm.cs*(2-cm.cs)*cm.mueff), cm.zw)
+	cm.gen++
+	psNorm := floats.Norm(cm.ps, 2)
+	var hsig float64
+	if psNorm/math.Sqrt(1-math.Pow(1-cm.cs, float64(2*cm.gen))) < (1.4+2/float64(dim+1))*cm.chiN {
+		hsig = 1
+	}
+	floats.Scale(1-cm.cc, cm.pc)
+	floats.AddScaled(cm.pc, hsig*math.Sqrt(cm.cc*(2-cm.cc)*cm.mueff), cm.yw)
+
+	decay := 1 - cm.c1 - cm.cmu + (1-hsig)*cm.c1*cm.cc*(2-cm.cc)
+	for i := 0; i < dim; i++ {
+		for j := i; j < dim; j++ {
+			v := decay*cm.c.At(i, j) + cm.c1*cm.pc[i]*cm.pc[j]
+			for k, w := range cm.weights {
+				y := cm.y[cm.order[k]]
+				v += cm.cmu * w * y[i] * y[j]
+			}
+			cm.c.SetSym(i, j, v)
+		}
+	}
+	cm.sigma *= math.Exp(cm.cs / cm.ds * (psNorm/cm.chiN - 1))
+
+	if !cm.chol.Cholesky(cm.c, false) || math.IsInf(cm.sigma, 0) {
+		return false
+	}
+
+	// Check the stopping criteria of the run.
+	minL, maxL := math.Inf(1), 0.0
+	small := true
+	for i := 0; i < dim; i++ {
+		l := cm.chol.At(i, i)
+		minL = math.Min(minL, l)
+		maxL = math.Max(maxL, l)
+		if cm.sigma*math.Max(math.Sqrt(cm.c.At(i, i)), math.Abs(cm.pc[i])) >= cm.StepTolerance*cm.InitStepSize {
+			small = false
+		}
+	}
+	if small || maxL > cmaEsMaxCond*minL {
+		return false
+	}
+	cm.hist[(cm.gen-1)%len(cm.hist)] = cm.fs[cm.order[0]]
+	if cm.gen >= len(cm.hist) {
+		fMin := math.Min(floats.Min(cm.hist), cm.fs[cm.order[0]])
+		fMax := math.Max(floats.Max(cm.hist), cm.fs[cm.order[cm.lambda-1]])
+		if fMax-fMin < cm.FunctionTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func (*CmaEs) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// cmaEsSorter sorts indices of samples in ascending order of their function
+// values. NaN values are sorted last.
+type cmaEsSorter struct {
+	idx []int
+	f   []float64
+}
+
+func (s cmaEsSorter) Len() int {
+	return len(s.idx)
+}
+
+func (s cmaEsSorter) Less(i, j int) bool {
+	fi, fj := s.f[s.idx[i]], s.f[s.idx[j]]
+	return fi < fj || (math.IsNaN(fj) && !math.IsNaN(fi))
+}
+
+func (s cmaEsSorter) Swap(i, j int) {
+	s.idx[i], s.idx[j] = s.idx[j], s.idx[i]
+}
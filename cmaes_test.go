@@ -0,0 +1,93 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestCmaEs(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		p      Problem
+		x      []float64
+		optLoc []float64
+	}{
+		{
+			name:   "ExtendedRosenbrock",
+			p:      Problem{Func: functions.ExtendedRosenbrock{}.Func},
+			x:      []float64{-1.2, 1, -1.2, 1},
+			optLoc: []float64{1, 1, 1, 1},
+		},
+		{
+			name:   "Beale",
+			p:      Problem{Func: functions.Beale{}.Func},
+			x:      []float64{1, 1},
+			optLoc: []float64{3, 0.5},
+		},
+	} {
+		var results []*Result
+		for i := 0; i < 2; i++ {
+			settings := DefaultSettings()
+			settings.Recorder = nil
+			settings.FunctionConverge = nil
+			method := &CmaEs{Src: rand.NewSource(1)}
+			result, err := Local(test.p, test.x, settings, method)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+				break
+			}
+			if result.Status != MethodConverge {
+				t.Errorf("%s: unexpected status %v", test.name, result.Status)
+			}
+			if !floats.EqualApprox(result.X, test.optLoc, 1e-5) {
+				t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.optLoc, result.X)
+			}
+			results = append(results, result)
+		}
+		if len(results) == 2 && (results[0].F != results[1].F || !floats.Equal(results[0].X, results[1].X)) {
+			t.Errorf("%s: different result with the same source", test.name)
+		}
+	}
+}
+
+func TestCmaEsRestart(t *testing.T) {
+	for _, test := range []struct {
+		restart     CmaEsRestart
+		maxRestarts int
+	}{
+		{CmaEsIPOP, 6},
+		// The small population runs of BIPOP are cheap, so it restarts
+		// more often.
+		{CmaEsBIPOP, 12},
+	} {
+		restart := test.restart
+		settings := DefaultSettings()
+		settings.Recorder = nil
+		settings.FunctionConverge = nil
+		method := &CmaEs{
+			InitStepSize: 2,
+			Restart:      restart,
+			MaxRestarts:  test.maxRestarts,
+			Src:          rand.NewSource(1),
+		}
+		// Start close to a local minimum of the multimodal Rastrigin function.
+		result, err := Local(Problem{Func: rastrigin}, []float64{3, -4, 2, 1}, settings, method)
+		if err != nil {
+			t.Errorf("restart %d: unexpected error: %v", restart, err)
+			continue
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("restart %d: unexpected status %v", restart, result.Status)
+		}
+		if result.F > 1e-10 {
+			t.Errorf("restart %d: global minimum not found, got f(%v) = %v", restart, result.X, result.F)
+		}
+	}
+}
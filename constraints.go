@@ -0,0 +1,52 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Constraint is a smooth vector-valued function c: R^n → R^m that describes
+// nonlinear constraints on the variables of a Problem. Depending on the field
+// of Problem it is assigned to, the constraints are either equality
+// constraints c(x) = 0 or inequality constraints c(x) <= 0.
+type Constraint interface {
+	// Len returns the number of constraint functions m.
+	Len() int
+	// Func evaluates the constraint functions at x and stores the result
+	// in-place in dst. Func must not modify x.
+	Func(x, dst []float64)
+	// Jac evaluates the m×n Jacobian of the constraint functions at x and
+	// stores the result in-place in jac. Jac must not modify x.
+	Jac(x []float64, jac *mat64.Dense)
+}
+
+// constrained returns whether p has nonlinear constraints.
+func (p *Problem) constrained() bool {
+	return p.Equality != nil || p.Inequality != nil
+}
+
+// constraintViolation returns the infinity norm of the violation of the
+// nonlinear constraints of p at x.
+func (p *Problem) constraintViolation(x []float64) float64 {
+	var viol float64
+	if p.Equality != nil {
+		c := make([]float64, p.Equality.Len())
+		p.Equality.Func(x, c)
+		for _, v := range c {
+			viol = math.Max(viol, math.Abs(v))
+		}
+	}
+	if p.Inequality != nil {
+		c := make([]float64, p.Inequality.Len())
+		p.Inequality.Func(x, c)
+		for _, v := range c {
+			viol = math.Max(viol, v)
+		}
+	}
+	return viol
+}
@@ -0,0 +1,387 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+// linearConstraint is c(x) = A x - b.
+type linearConstraint struct {
+	a *mat64.Dense
+	b []float64
+}
+
+func (c linearConstraint) Len() int {
+	return len(c.b)
+}
+
+func (c linearConstraint) Func(x, dst []float64) {
+	for i := range dst {
+		dst[i] = -c.b[i]
+		for j, v := range x {
+			dst[i] += c.a.At(i, j) * v
+		}
+	}
+}
+
+func (c linearConstraint) Jac(x []float64, jac *mat64.Dense) {
+	jac.Copy(c.a)
+}
+
+// ballConstraint is c(x) = |x|^2 - r^2.
+type ballConstraint struct {
+	r float64
+}
+
+func (ballConstraint) Len() int {
+	return 1
+}
+
+func (c ballConstraint) Func(x, dst []float64) {
+	dst[0] = floats.Dot(x, x) - c.r*c.r
+}
+
+func (ballConstraint) Jac(x []float64, jac *mat64.Dense) {
+	for j, v := range x {
+		jac.Set(0, j, 2*v)
+	}
+}
+
+type constrainedTest struct {
+	name   string
+	p      Problem
+	x      []float64
+	optLoc []float64
+}
+
+func constrainedTests() []constrainedTest {
+	quad := shiftedQuadratic{c: []float64{2, 1}}
+	return []constrainedTest{
+		{
+			// Minimize a weighted distance to (2, 1) subject to x + y = 1.
+			name: "QuadraticEquality",
+			p: Problem{
+				Func: quad.Func,
+				Grad: quad.Grad,
+				Equality: linearConstraint{
+					a: mat64.NewDense(1, 2, []float64{1, 1}),
+					b: []float64{1},
+				},
+			},
+			x:      []float64{0, 0},
+			optLoc: []float64{2.0 / 3, 1.0 / 3},
+		},
+		{
+			// Minimize a weighted distance to (2, 1) subject to x + y <= 4,
+			// which is not active at the solution.
+			name: "QuadraticInactive",
+			p: Problem{
+				Func: quad.Func,
+				Grad: quad.Grad,
+				Inequality: linearConstraint{
+					a: mat64.NewDense(1, 2, []float64{1, 1}),
+					b: []float64{4},
+				},
+			},
+			x:      []float64{0, 0},
+			optLoc: []float64{2, 1},
+		},
+		{
+			name: "RosenbrockBall",
+			p: Problem{
+				Func:       functions.ExtendedRosenbrock{}.Func,
+				Grad:       functions.ExtendedRosenbrock{}.Grad,
+				Inequality: ballConstraint{r: 1},
+			},
+			x:      []float64{-1.2, 1},
+			optLoc: []float64{0.7864151541684, 0.6176983125253},
+		},
+		{
+			// The equality constraint and the bound on the last variable
+			// are active at the solution, the inequality constraint is not.
+			name: "RosenbrockMixed",
+			p: Problem{
+				Func: functions.ExtendedRosenbrock{}.Func,
+				Grad: functions.ExtendedRosenbrock{}.Grad,
+				Equality: linearConstraint{
+					a: mat64.NewDense(1, 3, []float64{1, -1, 0}),
+					b: []float64{0},
+				},
+				Inequality: ballConstraint{r: 2},
+				Bounds: &Bounds{
+					Upper: []float64{math.Inf(1), math.Inf(1), 0.5},
+				},
+			},
+			x:      []float64{0.5, 0.5, 0.5},
+			optLoc: []float64{0.7521899484, 0.7521899484, 0.5},
+		},
+	}
+}
+
+func TestAugmentedLagrangian(t *testing.T) {
+	for _, method := range []*AugmentedLagrangian{
+		{},
+		{Method: &LBFGSB{}, Penalty: 1, Increase: 2},
+	} {
+		for _, test := range constrainedTests() {
+			settings := DefaultSettings()
+			settings.FunctionConverge = nil
+			result, err := Local(test.p, test.x, settings, method)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+				continue
+			}
+			if result.Status != MethodConverge {
+				t.Errorf("%s: unexpected status %v", test.name, result.Status)
+			}
+			if result.ConstraintViolation > method.ConstraintTolerance {
+				t.Errorf("%s: constraint violation %v larger than tolerance", test.name, result.ConstraintViolation)
+			}
+			if test.p.Bounds != nil && !test.p.Bounds.Feasible(result.X) {
+				t.Errorf("%s: solution %v outside of bounds", test.name, result.X)
+			}
+			if !floats.EqualApprox(result.X, test.optLoc, 1e-5) {
+				t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.optLoc, result.X)
+			}
+			checkMultipliers(t, test.name, &test.p, result, 1e-4)
+		}
+	}
+}
+
+func TestSQP(t *testing.T) {
+	tests := constrainedTests()
+	tests = append(tests, constrainedTest{
+		// The linearized constraint is inconsistent at the initial
+		// location.
+		name: "LinearCircle",
+		p: Problem{
+			Func: func(x []float64) float64 {
+				return x[0]
+			},
+			Grad: func(x, grad []float64) {
+				grad[0] = 1
+			},
+			Equality: ballConstraint{r: 1},
+		},
+		x:      []float64{0},
+		optLoc: []float64{-1},
+	})
+	for _, test := range tests {
+		method := &SQP{}
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		result, err := Local(test.p, test.x, settings, method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if result.ConstraintViolation > method.ConstraintTolerance {
+			t.Errorf("%s: constraint violation %v larger than tolerance", test.name, result.ConstraintViolation)
+		}
+		if test.p.Bounds != nil && !test.p.Bounds.Feasible(result.X) {
+			t.Errorf("%s: solution %v outside of bounds", test.name, result.X)
+		}
+		if !floats.EqualApprox(result.X, test.optLoc, 1e-5) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.optLoc, result.X)
+		}
+		checkMultipliers(t, test.name, &test.p, result, 1e-5)
+	}
+}
+
+func TestSLP(t *testing.T) {
+	tests := constrainedTests()
+	tests = append(tests,
+		constrainedTest{
+			// The linearized constraint is inconsistent at the initial
+			// location.
+			name: "LinearCircle",
+			p: Problem{
+				Func: func(x []float64) float64 {
+					return x[0]
+				},
+				Grad: func(x, grad []float64) {
+					grad[0] = 1
+				},
+				Equality: ballConstraint{r: 1},
+			},
+			x:      []float64{0},
+			optLoc: []float64{-1},
+		},
+		constrainedTest{
+			// Two constraints are active at the solution, which is a vertex
+			// of the feasible region.
+			name: "LinearVertex",
+			p: Problem{
+				Func: func(x []float64) float64 {
+					return -x[0] - 2*x[1]
+				},
+				Grad: func(x, grad []float64) {
+					grad[0] = -1
+					grad[1] = -2
+				},
+				Inequality: ballConstraint{r: math.Sqrt2},
+				Bounds: &Bounds{
+					Upper: []float64{math.Inf(1), 1},
+				},
+			},
+			x:      []float64{0, 0},
+			optLoc: []float64{1, 1},
+		},
+	)
+	for _, test := range tests {
+		method := &SLP{}
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		result, err := Local(test.p, test.x, settings, method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if result.ConstraintViolation > method.ConstraintTolerance {
+			t.Errorf("%s: constraint violation %v larger than tolerance", test.name, result.ConstraintViolation)
+		}
+		if test.p.Bounds != nil && !test.p.Bounds.Feasible(result.X) {
+			t.Errorf("%s: solution %v outside of bounds", test.name, result.X)
+		}
+		if !floats.EqualApprox(result.X, test.optLoc, 1e-5) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.optLoc, result.X)
+		}
+		checkMultipliers(t, test.name, &test.p, result, 1e-5)
+	}
+}
+
+func TestInteriorPoint(t *testing.T) {
+	for _, test := range constrainedTests() {
+		if test.p.Bounds != nil {
+			continue
+		}
+		method := &InteriorPoint{}
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		result, err := Local(test.p, test.x, settings, method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if result.ConstraintViolation > method.ConstraintTolerance {
+			t.Errorf("%s: constraint violation %v larger than tolerance", test.name, result.ConstraintViolation)
+		}
+		if !floats.EqualApprox(result.X, test.optLoc, 1e-5) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.optLoc, result.X)
+		}
+		checkMultipliers(t, test.name, &test.p, result, 1e-5)
+	}
+}
+
+// checkMultipliers checks that the gradient of the Lagrangian with the
+// multipliers of result vanishes in the variables whose bounds are not active.
+func checkMultipliers(t *testing.T, name string, p *Problem, result *Result, tol float64) {
+	if result.Multipliers == nil {
+		t.Errorf("%s: no multipliers in result", name)
+		return
+	}
+	x := result.X
+	grad := make([]float64, len(x))
+	p.Grad(x, grad)
+	if p.Equality != nil {
+		jac := mat64.NewDense(p.Equality.Len(), len(x), nil)
+		p.Equality.Jac(x, jac)
+		addJacTVec(grad, jac, result.Multipliers.Equality)
+	}
+	if p.Inequality != nil {
+		for i, v := range result.Multipliers.Inequality {
+			if v < 0 {
+				t.Errorf("%s: negative multiplier %v of inequality constraint %d", name, v, i)
+			}
+		}
+		jac := mat64.NewDense(p.Inequality.Len(), len(x), nil)
+		p.Inequality.Jac(x, jac)
+		addJacTVec(grad, jac, result.Multipliers.Inequality)
+	}
+	for j, g := range grad {
+		if p.Bounds != nil && (x[j] <= p.Bounds.lower(j)+tol || x[j] >= p.Bounds.upper(j)-tol) {
+			continue
+		}
+		if math.Abs(g) > tol {
+			t.Errorf("%s: gradient of the Lagrangian %v not zero", name, grad)
+			return
+		}
+	}
+}
+
+func TestConstraintsNotHandled(t *testing.T) {
+	test := constrainedTests()[0]
+	_, err := Local(test.p, test.x, nil, &BFGS{})
+	if err == nil {
+		t.Errorf("expected error for method that does not handle constraints")
+	}
+	result, err := Local(test.p, test.x, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error with default method: %v", err)
+	}
+	if result.Status != MethodConverge {
+		t.Errorf("unexpected status with default method: %v", result.Status)
+	}
+}
+
+func TestHockSchittkowski(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		f    functions.ConstrainedFunction
+		x    []float64
+	}{
+		{"HS6", functions.HS6{}, []float64{-1.2, 1}},
+		{"HS7", functions.HS7{}, []float64{2, 2}},
+		{"HS12", functions.HS12{}, []float64{0, 0}},
+		{"HS26", functions.HS26{}, []float64{-2.6, 2, 2}},
+		{"HS27", functions.HS27{}, []float64{2, 2, 2}},
+		{"HS28", functions.HS28{}, []float64{-4, 1, 1}},
+		{"HS39", functions.HS39{}, []float64{2, 2, 2, 2}},
+		{"HS43", functions.HS43{}, []float64{0, 0, 0, 0}},
+		{"HS48", functions.HS48{}, []float64{3, 5, -3, 2, -2}},
+		{"HS100", functions.HS100{}, []float64{1, 2, 0, 4, 0, 1, 1}},
+	} {
+		p := Problem{
+			Func:       test.f.Func,
+			Grad:       test.f.Grad,
+			Equality:   test.f.Equality(),
+			Inequality: test.f.Inequality(),
+		}
+		method := &InteriorPoint{}
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		result, err := Local(p, test.x, settings, method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if result.ConstraintViolation > method.ConstraintTolerance {
+			t.Errorf("%s: constraint violation %v larger than tolerance", test.name, result.ConstraintViolation)
+		}
+		want := test.f.KKTPoints()[0]
+		if math.Abs(result.F-want.F) > 1e-6*math.Max(1, math.Abs(want.F)) {
+			t.Errorf("%s: unexpected minimum. Want %v, got %v", test.name, want.F, result.F)
+		}
+		checkMultipliers(t, test.name, &p, result, 1e-5)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	defaultContinuationSteps   = 10
+	defaultContinuationMinStep = 1e-6
+)
+
+// Continuation is a driver that solves a family of problems f(x; t)
+// parameterized by t ∈ [0, 1], where the problem at t = 0 is easy to solve
+// and the problem at t = 1 is the one of interest. Starting at t = 0, the
+// parameter is advanced in steps, and every problem is solved by a local run
+// starting at the solution of the previous one. This homotopy approach can
+// solve very nonlinear problems that cannot be solved from a cold start, and
+// it also tracks the solution along the family.
+//
+// The step of t is adaptive. If a local run fails, the run is repeated with
+// half the step from the previous solution, and after a successful run the
+// step is doubled again, up to the initial step 1/Steps.
+//
+// References:
+//  - Allgower, E.L., Georg, K.: Introduction to Numerical Continuation
+//    Methods. SIAM (2003)
+type Continuation struct {
+	// Method returns the Method for a local run. It is called once for
+	// every local run. If Method is nil, the default Method of Local is
+	// used.
+	Method func() Method
+	// Steps is the number of equal steps of t from 0 to 1 that are taken
+	// if no local run fails. If Steps is zero, it will be set to 10.
+	Steps int
+	// MinStep is the smallest step of t. If a local run fails with a step
+	// smaller than MinStep, the continuation stops. If MinStep is zero, it
+	// will be set to 1e-6.
+	MinStep float64
+	// Success reports whether a local run has solved the problem. If Success
+	// is nil, a run is successful if it returns no error and its status is
+	// not early, see Status.Early.
+	Success func(*Result) bool
+}
+
+// ContinuationResult is the result of Continuation.
+type ContinuationResult struct {
+	// Minimum is the result of the last successful local run, which is the
+	// solution of the problem at t = 1 if the continuation has finished.
+	Minimum *Result
+	// T and X are the values of the parameter and the solutions of the
+	// successful local runs, in the order of the runs.
+	T []float64
+	X [][]float64
+	// Stats are the statistics summed over all local runs, except Runtime,
+	// which is the total wall-clock time.
+	Stats
+	// Runs is the number of local runs and Failed is the number of runs
+	// that have not been successful.
+	Runs   int
+	Failed int
+}
+
+// Minimize solves the problems family(t) for t from 0 to 1, starting the
+// local run at t = 0 from initX. If settings is nil, the default settings are
+// used for the local runs, which are independent calls to Local with a copy
+// of the settings. Minimize returns the error of the local run at t = 0 if
+// it fails, and ErrContinuationFailure if the step of t falls below MinStep.
+func (c *Continuation) Minimize(family func(t float64) Problem, initX []float64, settings *Settings) (*ContinuationResult, error) {
+	steps := c.Steps
+	if steps == 0 {
+		steps = defaultContinuationSteps
+	}
+	if steps < 0 {
+		panic("optimize: negative number of steps")
+	}
+	minStep := c.MinStep
+	if minStep == 0 {
+		minStep = defaultContinuationMinStep
+	}
+	if minStep < 0 {
+		panic("optimize: negative minimum step")
+	}
+	success := c.Success
+	if success == nil {
+		success = func(r *Result) bool { return !r.Status.Early() }
+	}
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+
+	startTime := time.Now()
+	res := &ContinuationResult{}
+	solve := func(t float64, x []float64) (*Result, error) {
+		s := *settings
+		if s.FunctionConverge != nil {
+			fc := *s.FunctionConverge
+			s.FunctionConverge = &fc
+		}
+		var method Method
+		if c.Method != nil {
+			method = c.Method()
+		}
+		r, err := Local(family(t), x, &s, method)
+		res.Runs++
+		if r != nil {
+			res.MajorIterations += r.MajorIterations
+			res.FuncEvaluations += r.FuncEvaluations
+			res.GradEvaluations += r.GradEvaluations
+			res.HessEvaluations += r.HessEvaluations
+			res.HessVecEvaluations += r.HessVecEvaluations
+		}
+		if err == nil && !success(r) {
+			err = ErrContinuationFailure
+		}
+		if err != nil {
+			res.Failed++
+			return nil, err
+		}
+		res.Minimum = r
+		res.T = append(res.T, t)
+		res.X = append(res.X, r.X)
+		return r, nil
+	}
+
+	cur, err := solve(0, initX)
+	if err != nil {
+		res.Runtime = time.Since(startTime)
+		return res, err
+	}
+	var t float64
+	maxStep := 1 / float64(steps)
+	step := maxStep
+	for t < 1 {
+		next := t + step
+		if next > 1-minStep {
+			// Avoid a tiny last step due to rounding.
+			next = 1
+		}
+		r, err := solve(next, cur.X)
+		if err != nil {
+			step /= 2
+			if step < minStep {
+				res.Runtime = time.Since(startTime)
+				return res, ErrContinuationFailure
+			}
+			continue
+		}
+		cur = r
+		t = next
+		step = math.Min(2*step, maxStep)
+	}
+	res.Runtime = time.Since(startTime)
+	return res, nil
+}
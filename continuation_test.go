@@ -0,0 +1,96 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestContinuation(t *testing.T) {
+	// The homotopy from a quadratic with its minimum at the origin to the
+	// Rosenbrock function.
+	rosen := functions.ExtendedRosenbrock{}
+	var requested float64
+	family := func(t float64) Problem {
+		requested = t
+		return Problem{
+			Func: func(x []float64) float64 {
+				return (1-t)*floats.Dot(x, x) + t*rosen.Func(x)
+			},
+			Grad: func(x, grad []float64) {
+				rosen.Grad(x, grad)
+				for i, v := range x {
+					grad[i] = 2*(1-t)*v + t*grad[i]
+				}
+			},
+		}
+	}
+
+	c := &Continuation{}
+	res, err := c.Minimize(family, []float64{-1.2, 1}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Runs != 11 || res.Failed != 0 || len(res.T) != 11 || len(res.X) != 11 {
+		t.Errorf("unexpected number of runs %d, failures %d", res.Runs, res.Failed)
+	}
+	for k, v := range res.T {
+		if math.Abs(v-0.1*float64(k)) > 1e-14 {
+			t.Errorf("unexpected parameter %v at step %d", v, k)
+		}
+	}
+	if !floats.EqualApprox(res.X[0], []float64{0, 0}, 1e-6) {
+		t.Errorf("unexpected solution %v at t = 0", res.X[0])
+	}
+	if !floats.EqualApprox(res.Minimum.X, []float64{1, 1}, 1e-4) {
+		t.Errorf("unexpected minimum location %v", res.Minimum.X)
+	}
+
+	// Runs with a step of t larger than 0.04 fail, so the step is halved
+	// twice and doubled again after every success.
+	var last float64
+	c = &Continuation{
+		Success: func(r *Result) bool {
+			if requested-last > 0.04 {
+				return false
+			}
+			last = requested
+			return true
+		},
+	}
+	res, err = c.Minimize(family, []float64{-1.2, 1}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Failed == 0 || res.T[len(res.T)-1] != 1 {
+		t.Errorf("continuation with failures did not finish")
+	}
+	for k := 1; k < len(res.T); k++ {
+		if d := res.T[k] - res.T[k-1]; d <= 0 || d > 0.04 {
+			t.Errorf("unexpected step %v", d)
+		}
+	}
+	if !floats.EqualApprox(res.Minimum.X, []float64{1, 1}, 1e-4) {
+		t.Errorf("unexpected minimum location %v", res.Minimum.X)
+	}
+
+	// The continuation stops if no progress beyond t = 0.5 is possible.
+	c = &Continuation{
+		Success: func(r *Result) bool {
+			return requested <= 0.5
+		},
+	}
+	res, err = c.Minimize(family, []float64{-1.2, 1}, nil)
+	if err != ErrContinuationFailure {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if tEnd := res.T[len(res.T)-1]; tEnd > 0.5 || tEnd < 0.5-1e-5 {
+		t.Errorf("unexpected final parameter %v", tEnd)
+	}
+}
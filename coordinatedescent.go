@@ -0,0 +1,321 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultCoordinateLineTol = 1e-8
+	defaultCoordinateTol     = 1e-12
+)
+
+// CoordinateRule is the rule by which CoordinateDescent selects the
+// coordinate, or the block of coordinates, of the next line minimization.
+type CoordinateRule int
+
+const (
+	// CyclicCoordinates selects the coordinates in turn.
+	CyclicCoordinates CoordinateRule = iota
+	// RandomCoordinates selects the coordinates in a random order that is
+	// drawn anew for every sweep.
+	RandomCoordinates
+	// GaussSouthwell selects the coordinate with the largest gradient
+	// component in magnitude, or the block with the largest norm of the
+	// gradient components. It needs the gradient.
+	GaussSouthwell
+)
+
+// CoordinateDescent implements the coordinate descent method. Every step
+// minimizes the function along a single coordinate, or along a block of
+// coordinates, while the other coordinates are kept fixed. The coordinate is
+// selected by Rule. If Blocks is nil, the line minimizations are along the
+// coordinate directions and need no gradient unless Rule is GaussSouthwell.
+// If Blocks is not nil, the line minimization for a block is along the
+// negative gradient restricted to the coordinates of the block, so the
+// gradient is needed.
+//
+// The line minimizations bracket a minimum and locate it by Brent's method up
+// to the relative tolerance LineTolerance. A sweep is a sequence of as many
+// line minimizations as there are coordinates or blocks, or a single line
+// minimization for GaussSouthwell. Every sweep is a major iteration, and
+// CoordinateDescent converges with MethodConverge status when a sweep
+// decreases the function value by a relative amount less than Tolerance.
+//
+// Coordinate descent is effective when the function is separable or nearly
+// so, and when minimizing along a coordinate is cheap or exact, as for the
+// LASSO objective
+//  ½ |A x - b|_2^2 + λ |x|_1,
+// whose non-differentiable part is separable. It can stall at non-stationary
+// points of functions that are neither smooth nor separable.
+//
+// References:
+//  - Wright, S.J.: Coordinate descent algorithms. Math. Program. 151 (2015),
+//    3-34
+//  - Nutini, J., Schmidt, M., Laradji, I., Friedlander, M., Koepke, H.:
+//    Coordinate descent converges faster with the Gauss-Southwell rule than
+//    random selection. ICML (2015)
+type CoordinateDescent struct {
+	// Rule is the rule for selecting the coordinates.
+	Rule CoordinateRule
+	// Blocks are the blocks of coordinates minimized together. The blocks
+	// must be non-empty, and every coordinate must be in exactly one block.
+	// If Blocks is nil, every coordinate is a block of its own.
+	Blocks [][]int
+	// InitialStep is the first step of the line minimizations. If
+	// InitialStep is zero, it will be set to 1.
+	InitialStep float64
+	// LineTolerance is the relative tolerance of the line minimizations. If
+	// LineTolerance is zero, it will be set to 1e-8.
+	LineTolerance float64
+	// Tolerance is the relative decrease of the function value in a sweep
+	// below which CoordinateDescent converges. If Tolerance is zero, it will
+	// be set to 1e-12.
+	Tolerance float64
+	// Src is the source of random numbers for RandomCoordinates. If Src is
+	// nil, the source is seeded from Settings.Src, or from the global source
+	// of math/rand if Settings.Src is nil.
+	Src rand.Source
+
+	status Status
+	line   scalarMinimizer
+	rnd    *rand.Rand
+
+	x     []float64 // Current location.
+	fx    float64
+	grad  []float64 // Gradient at x, if it is needed.
+	dir   []float64 // Direction of the current line minimization.
+	block int       // Index of the current block.
+	perm  []int     // Order of the blocks in the current sweep.
+	steps int       // Number of line minimizations in the current sweep.
+	f0    float64   // Function value at the start of the sweep.
+	final bool      // Whether x is being evaluated at the end of a step.
+}
+
+func (cd *CoordinateDescent) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if cd.InitialStep == 0 {
+		cd.InitialStep = 1
+	}
+	if cd.InitialStep < 0 {
+		panic("coordinatedescent: initial step must be positive")
+	}
+	if cd.LineTolerance == 0 {
+		cd.LineTolerance = defaultCoordinateLineTol
+	}
+	if cd.LineTolerance < 0 {
+		panic("coordinatedescent: line tolerance must be positive")
+	}
+	if cd.Tolerance == 0 {
+		cd.Tolerance = defaultCoordinateTol
+	}
+	if cd.Tolerance < 0 {
+		panic("coordinatedescent: tolerance must be positive")
+	}
+	switch cd.Rule {
+	case CyclicCoordinates, GaussSouthwell:
+	case RandomCoordinates:
+		cd.rnd = newRand(cd.Src, p.Src)
+	default:
+		panic("coordinatedescent: unknown coordinate rule")
+	}
+	if cd.Blocks != nil {
+		seen := make([]bool, dim)
+		for _, b := range cd.Blocks {
+			if len(b) == 0 {
+				panic("coordinatedescent: empty block")
+			}
+			for _, i := range b {
+				if i < 0 || i >= dim || seen[i] {
+					panic("coordinatedescent: invalid block")
+				}
+				seen[i] = true
+			}
+		}
+		for _, s := range seen {
+			if !s {
+				panic("coordinatedescent: blocks do not cover all coordinates")
+			}
+		}
+	}
+	cd.status = NotTerminated
+
+	cd.x = resize(cd.x, dim)
+	cd.dir = resize(cd.dir, dim)
+	copy(cd.x, loc.X)
+	cd.fx = loc.F
+	if cd.needsGradient() {
+		cd.grad = resize(cd.grad, dim)
+		copy(cd.grad, loc.Gradient)
+	} else {
+		cd.grad = nil
+	}
+	cd.block = -1
+	cd.final = false
+	return cd.startSweep(xNext)
+}
+
+func (cd *CoordinateDescent) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	if cd.final {
+		// The function, and the gradient if it is needed, have been
+		// evaluated at x at the end of a step.
+		cd.final = false
+		if cd.grad != nil {
+			copy(cd.grad, loc.Gradient)
+		}
+		if cd.steps == cd.sweepLength() {
+			if 2*(cd.f0-cd.fx) <= cd.Tolerance*(math.Abs(cd.f0)+math.Abs(cd.fx))+1e-25 {
+				cd.status = MethodConverge
+				copy(xNext, loc.X)
+				return NoEvaluation, MajorIteration, nil
+			}
+			return cd.startSweep(xNext)
+		}
+		return cd.startLine(xNext)
+	}
+	t, done := cd.line.iterate(loc.F)
+	if !done {
+		floats.AddScaledTo(xNext, cd.x, t, cd.dir)
+		return FuncEvaluation, MinorIteration, nil
+	}
+	t, f := cd.line.min()
+	if f < cd.fx {
+		floats.AddScaled(cd.x, t, cd.dir)
+		cd.fx = f
+	}
+	cd.steps++
+	sweepEnd := cd.steps == cd.sweepLength()
+	if !sweepEnd && !cd.needsGradient() {
+		return cd.startLine(xNext)
+	}
+	// Evaluate x to start a major iteration at the end of the sweep, or to
+	// obtain the gradient for the next selection.
+	cd.final = true
+	copy(xNext, cd.x)
+	evalType := FuncEvaluation
+	if cd.needsGradient() {
+		evalType |= GradEvaluation
+	}
+	iterType := MinorIteration
+	if sweepEnd {
+		iterType = MajorIteration
+	}
+	return evalType, iterType, nil
+}
+
+// startSweep starts a new sweep of line minimizations.
+func (cd *CoordinateDescent) startSweep(xNext []float64) (EvaluationType, IterationType, error) {
+	cd.f0 = cd.fx
+	cd.steps = 0
+	if cd.Rule == RandomCoordinates {
+		cd.perm = cd.rnd.Perm(cd.numBlocks())
+	}
+	return cd.startLine(xNext)
+}
+
+// startLine selects the next block and starts the line minimization along
+// it.
+func (cd *CoordinateDescent) startLine(xNext []float64) (EvaluationType, IterationType, error) {
+	cd.block = cd.nextBlock()
+	for i := range cd.dir {
+		cd.dir[i] = 0
+	}
+	step := cd.InitialStep
+	if cd.Blocks == nil {
+		i := cd.block
+		cd.dir[i] = 1
+		if cd.grad != nil && cd.grad[i] > 0 {
+			cd.dir[i] = -1
+		}
+	} else {
+		var norm float64
+		for _, i := range cd.Blocks[cd.block] {
+			cd.dir[i] = -cd.grad[i]
+			norm += cd.grad[i] * cd.grad[i]
+		}
+		if norm == 0 {
+			// The block is stationary, so the line minimization only
+			// confirms that x is its minimum.
+			for _, i := range cd.Blocks[cd.block] {
+				cd.dir[i] = 1
+			}
+			norm = float64(len(cd.Blocks[cd.block]))
+		}
+		step /= math.Sqrt(norm)
+	}
+	t := cd.line.init(cd.fx, step, cd.LineTolerance)
+	floats.AddScaledTo(xNext, cd.x, t, cd.dir)
+	return FuncEvaluation, MinorIteration, nil
+}
+
+// nextBlock returns the index of the block of the next line minimization.
+func (cd *CoordinateDescent) nextBlock() int {
+	n := cd.numBlocks()
+	switch cd.Rule {
+	case RandomCoordinates:
+		return cd.perm[cd.steps]
+	case GaussSouthwell:
+		best, bestNorm := 0, -1.0
+		if cd.Blocks == nil {
+			for i, g := range cd.grad {
+				if math.Abs(g) > bestNorm {
+					best, bestNorm = i, math.Abs(g)
+				}
+			}
+			return best
+		}
+		for k, b := range cd.Blocks {
+			var norm float64
+			for _, i := range b {
+				norm += cd.grad[i] * cd.grad[i]
+			}
+			if norm > bestNorm {
+				best, bestNorm = k, norm
+			}
+		}
+		return best
+	}
+	return (cd.block + 1) % n
+}
+
+// numBlocks returns the number of blocks.
+func (cd *CoordinateDescent) numBlocks() int {
+	if cd.Blocks == nil {
+		return len(cd.x)
+	}
+	return len(cd.Blocks)
+}
+
+// sweepLength returns the number of line minimizations in a sweep.
+func (cd *CoordinateDescent) sweepLength() int {
+	if cd.Rule == GaussSouthwell {
+		return 1
+	}
+	return cd.numBlocks()
+}
+
+func (cd *CoordinateDescent) needsGradient() bool {
+	return cd.Rule == GaussSouthwell || cd.Blocks != nil
+}
+
+// Status returns MethodConverge when a sweep has not decreased the function
+// value by more than Tolerance.
+func (cd *CoordinateDescent) Status() (Status, error) {
+	return cd.status, nil
+}
+
+func (cd *CoordinateDescent) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{cd.needsGradient(), false}
+}
@@ -0,0 +1,218 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultDerivCheckTol    = 1e-5
+	defaultDerivCheckPoints = 10
+)
+
+// DerivativeCheckSettings are the settings of CheckGradient and CheckHessian.
+type DerivativeCheckSettings struct {
+	// Step is the relative step size of the central differences. The step
+	// in the i-th dimension is Step·max(1, |x_i|). If Step is zero, it will
+	// be set to approximately the cube root of the machine epsilon.
+	Step float64
+	// Tolerance is the largest acceptable error of a component of the
+	// derivative, see DerivativeMismatch. If Tolerance is zero, it will be
+	// set to 1e-5.
+	Tolerance float64
+	// Points is the number of random locations at which the derivatives are
+	// checked if no locations are given. If Points is zero, it will be set
+	// to 10.
+	Points int
+	// Bounds is the box from which the random locations are drawn
+	// uniformly. Its bounds must be finite. It is only used if no locations
+	// are given.
+	Bounds *Bounds
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from the global source of math/rand.
+	Src rand.Source
+}
+
+// DerivativeMismatch is a component of a derivative that does not match its
+// finite-difference approximation.
+type DerivativeMismatch struct {
+	// Point is the index of the location in DerivativeCheck.X.
+	Point int
+	// I and J are the indices of the component. J is zero for gradients.
+	I, J int
+	// Analytic is the value of the derivative, and Numeric is the value of
+	// the finite-difference approximation.
+	Analytic float64
+	Numeric  float64
+	// Error is the error of the derivative relative to the magnitude of the
+	// component,
+	//  |Analytic - Numeric| / max(1, |Analytic|, |Numeric|).
+	Error float64
+}
+
+// DerivativeCheck is the result of CheckGradient and CheckHessian.
+type DerivativeCheck struct {
+	// X are the locations at which the derivatives have been checked.
+	X [][]float64
+	// MaxError is the largest error of all components at all locations.
+	MaxError float64
+	// Mismatches are the components whose error is larger than the
+	// tolerance, ordered by location and component. The derivatives are
+	// consistent with the function if Mismatches is empty.
+	Mismatches []DerivativeMismatch
+}
+
+// CheckGradient compares the gradient computed by grad with central
+// differences of f at the locations x, for example to find errors in a
+// hand-written gradient before an optimization. If x is empty, the gradient
+// is checked at settings.Points random locations within settings.Bounds. If
+// settings is nil, the default settings are used. CheckGradient panics if x is
+// empty and settings.Bounds is nil or not finite.
+func CheckGradient(f func(x []float64) float64, grad func(x, grad []float64), x [][]float64, settings *DerivativeCheckSettings) *DerivativeCheck {
+	if f == nil || grad == nil {
+		panic("optimize: nil function")
+	}
+	c, s := newDerivativeCheck(x, settings)
+	for k, xk := range c.X {
+		dim := len(xk)
+		g := make([]float64, dim)
+		grad(xk, g)
+		xh := make([]float64, dim)
+		copy(xh, xk)
+		for i, v := range xk {
+			h := s.Step * math.Max(1, math.Abs(v))
+			xh[i] = v + h
+			fPlus := f(xh)
+			xh[i] = v - h
+			fMinus := f(xh)
+			xh[i] = v
+			c.compare(k, i, 0, g[i], (fPlus-fMinus)/(2*h), s.Tolerance)
+		}
+	}
+	return c
+}
+
+// CheckHessian compares the Hessian computed by hess with central differences
+// of the gradient computed by grad at the locations x. The gradient should be
+// checked first with CheckGradient. The finite-difference approximation is
+// symmetrized, and the upper triangle of the Hessian is compared. If x is
+// empty, the Hessian is checked at settings.Points random locations within
+// settings.Bounds. If settings is nil, the default settings are used.
+// CheckHessian panics if x is empty and settings.Bounds is nil or not finite.
+func CheckHessian(grad func(x, grad []float64), hess func(x []float64, hess *mat64.SymDense), x [][]float64, settings *DerivativeCheckSettings) *DerivativeCheck {
+	if grad == nil || hess == nil {
+		panic("optimize: nil function")
+	}
+	c, s := newDerivativeCheck(x, settings)
+	for k, xk := range c.X {
+		dim := len(xk)
+		h := mat64.NewSymDense(dim, nil)
+		hess(xk, h)
+		// Column i of fd is the derivative of the gradient along e_i.
+		fd := mat64.NewDense(dim, dim, nil)
+		gPlus := make([]float64, dim)
+		gMinus := make([]float64, dim)
+		xh := make([]float64, dim)
+		copy(xh, xk)
+		for i, v := range xk {
+			step := s.Step * math.Max(1, math.Abs(v))
+			xh[i] = v + step
+			grad(xh, gPlus)
+			xh[i] = v - step
+			grad(xh, gMinus)
+			xh[i] = v
+			for j := range gPlus {
+				fd.Set(j, i, (gPlus[j]-gMinus[j])/(2*step))
+			}
+		}
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				num := (fd.At(i, j) + fd.At(j, i)) / 2
+				c.compare(k, i, j, h.At(i, j), num, s.Tolerance)
+			}
+		}
+	}
+	return c
+}
+
+// newDerivativeCheck returns a DerivativeCheck with the locations to check
+// and the settings with the defaults filled in.
+func newDerivativeCheck(x [][]float64, settings *DerivativeCheckSettings) (*DerivativeCheck, DerivativeCheckSettings) {
+	var s DerivativeCheckSettings
+	if settings != nil {
+		s = *settings
+	}
+	if s.Step == 0 {
+		s.Step = defaultCentralStep
+	}
+	if s.Tolerance == 0 {
+		s.Tolerance = defaultDerivCheckTol
+	}
+	if s.Points == 0 {
+		s.Points = defaultDerivCheckPoints
+	}
+	if s.Step < 0 || s.Tolerance < 0 || s.Points < 0 {
+		panic("optimize: negative derivative check setting")
+	}
+	c := &DerivativeCheck{}
+	if len(x) != 0 {
+		c.X = make([][]float64, len(x))
+		for i, xi := range x {
+			c.X[i] = make([]float64, len(xi))
+			copy(c.X[i], xi)
+		}
+		return c, s
+	}
+
+	b := s.Bounds
+	if b == nil || b.Lower == nil || b.Upper == nil {
+		panic("optimize: derivative check needs locations or finite bounds")
+	}
+	dim := len(b.Lower)
+	b.validate(dim)
+	for i, l := range b.Lower {
+		if math.IsInf(l, 0) || math.IsInf(b.Upper[i], 0) {
+			panic("optimize: derivative check needs locations or finite bounds")
+		}
+	}
+	var rnd *rand.Rand
+	if s.Src != nil {
+		rnd = rand.New(s.Src)
+	} else {
+		rnd = rand.New(rand.NewSource(rand.Int63()))
+	}
+	c.X = make([][]float64, s.Points)
+	for k := range c.X {
+		c.X[k] = make([]float64, dim)
+		for i := range c.X[k] {
+			c.X[k][i] = b.Lower[i] + rnd.Float64()*(b.Upper[i]-b.Lower[i])
+		}
+	}
+	return c, s
+}
+
+// compare records the error of a component of a derivative.
+func (c *DerivativeCheck) compare(point, i, j int, analytic, numeric, tol float64) {
+	scale := math.Max(1, math.Max(math.Abs(analytic), math.Abs(numeric)))
+	err := math.Abs(analytic-numeric) / scale
+	if math.IsNaN(err) {
+		err = math.Inf(1)
+	}
+	c.MaxError = math.Max(c.MaxError, err)
+	if err > tol {
+		c.Mismatches = append(c.Mismatches, DerivativeMismatch{
+			Point:    point,
+			I:        i,
+			J:        j,
+			Analytic: analytic,
+			Numeric:  numeric,
+			Error:    err,
+		})
+	}
+}
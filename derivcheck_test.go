@@ -0,0 +1,60 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestCheckDerivatives(t *testing.T) {
+	f := functions.Wood{}
+	settings := &DerivativeCheckSettings{
+		Bounds: &Bounds{Lower: []float64{-2, -2, -2, -2}, Upper: []float64{2, 2, 2, 2}},
+		Src:    rand.NewSource(1),
+	}
+	c := CheckGradient(f.Func, f.Grad, nil, settings)
+	if len(c.X) != defaultDerivCheckPoints {
+		t.Errorf("unexpected number of locations %d", len(c.X))
+	}
+	if len(c.Mismatches) != 0 {
+		t.Errorf("unexpected gradient mismatches %+v", c.Mismatches)
+	}
+	c = CheckHessian(f.Grad, f.Hess, nil, settings)
+	if len(c.Mismatches) != 0 {
+		t.Errorf("unexpected Hessian mismatches %+v", c.Mismatches)
+	}
+
+	x := [][]float64{{-3, -1, -3, -1}, {1, 1, 1, 1}}
+	badGrad := func(x, grad []float64) {
+		f.Grad(x, grad)
+		grad[2] = 1.1*grad[2] + 0.1
+	}
+	c = CheckGradient(f.Func, badGrad, x, nil)
+	if len(c.Mismatches) != len(x) {
+		t.Fatalf("unexpected gradient mismatches %+v", c.Mismatches)
+	}
+	for k, m := range c.Mismatches {
+		if m.Point != k || m.I != 2 || m.J != 0 {
+			t.Errorf("unexpected gradient mismatch %+v", m)
+		}
+	}
+	badHess := func(x []float64, hess *mat64.SymDense) {
+		f.Hess(x, hess)
+		hess.SetSym(3, 0, hess.At(3, 0)+1)
+	}
+	c = CheckHessian(f.Grad, badHess, x, nil)
+	if len(c.Mismatches) != len(x) {
+		t.Fatalf("unexpected Hessian mismatches %+v", c.Mismatches)
+	}
+	for k, m := range c.Mismatches {
+		if m.Point != k || m.I != 0 || m.J != 3 {
+			t.Errorf("unexpected Hessian mismatch %+v", m)
+		}
+	}
+}
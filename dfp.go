@@ -0,0 +1,166 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// dfpGradConst is the curvature constant of the default line search of DFP.
+const dfpGradConst = 0.1
+
+// DFP implements the Method interface to perform the Davidon–Fletcher–Powell
+// optimization method with the given linesearch method. If LinesearchMethod
+// is nil, it will be set to Bisection with GradConst 0.1, because DFP needs
+// an accurate line search.
+//
+// DFP is a quasi-Newton method that, like BFGS, performs successive rank-two
+// updates to an estimate of the inverse-Hessian of the function
+//  H_{k+1} = H_k - (H_k y_k)(H_k y_k)ᵀ / y_kᵀH_k y_k + s_k s_kᵀ / s_kᵀy_k,
+// where s_k is the step and y_k is the change in the gradient. DFP is the
+// dual of BFGS and is generally less effective at correcting a poor
+// inverse-Hessian estimate, so it is more sensitive to the accuracy of the
+// line search. The update is skipped if s_kᵀy_k is not positive. DFP has
+// memory cost that is O(n^2) relative to the input dimension.
+//
+// References:
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Section 6.1
+type DFP struct {
+	LinesearchMethod LinesearchMethod
+
+	linesearch *Linesearch
+
+	x    []float64 // Location of the last major iteration.
+	grad []float64 // Gradient at the last major iteration.
+	dim  int
+
+	y       []float64
+	s       []float64
+	tmpData []float64 // H_k y_k.
+
+	invHess *mat64.SymDense
+
+	first bool // Whether the initial inverse-Hessian has to be scaled.
+}
+
+func (d *DFP) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if d.LinesearchMethod == nil {
+		d.LinesearchMethod = &Bisection{GradConst: dfpGradConst}
+	}
+	if d.linesearch == nil {
+		d.linesearch = &Linesearch{}
+	}
+	d.linesearch.Method = d.LinesearchMethod
+	d.linesearch.NextDirectioner = d
+
+	return d.linesearch.Init(loc, p, xNext)
+}
+
+func (d *DFP) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	return d.linesearch.Iterate(loc, xNext)
+}
+
+func (d *DFP) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	d.dim = dim
+
+	d.x = resize(d.x, dim)
+	copy(d.x, loc.X)
+	d.grad = resize(d.grad, dim)
+	copy(d.grad, loc.Gradient)
+
+	d.y = resize(d.y, dim)
+	d.s = resize(d.s, dim)
+	d.tmpData = resize(d.tmpData, dim)
+	d.invHess = resizeSymDense(d.invHess, dim)
+
+	// The inverse-Hessian is initialized in the first call to NextDirection.
+	copy(dir, loc.Gradient)
+	floats.Scale(-1, dir)
+
+	d.first = true
+
+	return 1 / floats.Norm(dir, 2)
+}
+
+// RestartDirection discards the estimate of the inverse Hessian, which is
+// initialized again as in the first iteration.
+func (d *DFP) RestartDirection(loc *Location, dir []float64) (stepSize float64) {
+	copy(d.x, loc.X)
+	copy(d.grad, loc.Gradient)
+	copy(dir, loc.Gradient)
+	floats.Scale(-1, dir)
+	d.first = true
+	return 1 / floats.Norm(dir, 2)
+}
+
+func (d *DFP) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	if len(loc.X) != d.dim || len(loc.Gradient) != d.dim || len(dir) != d.dim {
+		panic("dfp: unexpected size mismatch")
+	}
+
+	floats.SubTo(d.y, loc.Gradient, d.grad)
+	floats.SubTo(d.s, loc.X, d.x)
+	copy(d.x, loc.X)
+	copy(d.grad, loc.Gradient)
+
+	sDotY := floats.Dot(d.s, d.y)
+	if sDotY > 0 {
+		if d.first {
+			// Rescale the initial inverse-Hessian as in Nocedal, Wright
+			// (2006), Eq. 6.20.
+			scale := sDotY / floats.Dot(d.y, d.y)
+			for i := 0; i < d.dim; i++ {
+				for j := i; j < d.dim; j++ {
+					d.invHess.SetSym(i, j, 0)
+				}
+				d.invHess.SetSym(i, i, scale)
+			}
+			d.first = false
+		}
+		hy := mat64.NewVector(d.dim, d.tmpData)
+		hy.MulVec(d.invHess, false, mat64.NewVector(d.dim, d.y))
+		yHy := floats.Dot(d.y, d.tmpData)
+		d.invHess.SymRankOne(d.invHess, -1/yHy, d.tmpData)
+		d.invHess.SymRankOne(d.invHess, 1/sDotY, d.s)
+	}
+
+	if !d.first {
+		mat64.NewVector(d.dim, dir).MulVec(d.invHess, false, mat64.NewVector(d.dim, loc.Gradient))
+		floats.Scale(-1, dir)
+		if floats.Dot(dir, loc.Gradient) < 0 {
+			return 1
+		}
+		// The inverse-Hessian has lost positive definiteness due to
+		// rounding errors, so restart from a scaled identity.
+		d.first = true
+	}
+	copy(dir, loc.Gradient)
+	floats.Scale(-1, dir)
+	return 1 / floats.Norm(dir, 2)
+}
+
+// Reset discards the estimate of the inverse Hessian of the last run,
+// keeping the exported fields.
+func (d *DFP) Reset() {
+	*d = DFP{LinesearchMethod: d.LinesearchMethod}
+}
+
+// Clone returns a new DFP with a copy of the LinesearchMethod of d.
+func (d *DFP) Clone() Method {
+	return &DFP{LinesearchMethod: cloneLinesearchMethod(d.LinesearchMethod)}
+}
+
+func (*DFP) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
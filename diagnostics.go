@@ -0,0 +1,187 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// Relative tolerance on the smallest eigenvalue for the second-order
+	// optimality check.
+	diagnosticsEigenTol = 1e-6
+	// Maximum number of sweeps of the Jacobi eigenvalue method.
+	maxJacobiSweeps = 50
+)
+
+// Diagnostics describes the quality of the location returned by Local. It is
+// computed when Settings.Diagnostics is true and helps to distinguish a true
+// local minimum from premature termination, for example due to stagnation.
+//
+// For problems with bounds, the gradient residual is that of the projected
+// gradient and the curvature information refers to the Hessian restricted to
+// the variables that are not at a bound.
+type Diagnostics struct {
+	// GradientNorm is the infinity norm of the gradient at the returned
+	// location, or of the projected gradient for problems with bounds. It is
+	// the residual of the first-order optimality conditions.
+	// GradientNorm is NaN if the gradient is not available.
+	GradientNorm float64
+
+	// HessianEvaluated is true if curvature information was available, and
+	// the remaining fields are valid. The Hessian is taken from the final
+	// location if the Method uses it, otherwise it is evaluated using
+	// Problem.Hess, or approximated by finite differences of Problem.Grad.
+	HessianEvaluated bool
+	// HessianApproximated is true if the Hessian was approximated by finite
+	// differences.
+	HessianApproximated bool
+	// MinEigenvalue and MaxEigenvalue are the extreme eigenvalues of the
+	// Hessian.
+	MinEigenvalue float64
+	MaxEigenvalue float64
+	// Condition is the condition number of the Hessian in the 2-norm. It is
+	// +Inf if the Hessian is singular.
+	Condition float64
+	// SecondOrder is true if the Hessian is positive semidefinite up to a
+	// small relative tolerance, that is, if the second-order necessary
+	// conditions for a minimum hold.
+	SecondOrder bool
+}
+
+// computeDiagnostics computes the Diagnostics at loc. Evaluations of the
+// problem are counted in stats.
+func computeDiagnostics(p *Problem, loc *Location, stats *Stats) *Diagnostics {
+	dim := len(loc.X)
+	d := &Diagnostics{
+		GradientNorm:  math.NaN(),
+		MinEigenvalue: math.NaN(),
+		MaxEigenvalue: math.NaN(),
+		Condition:     math.NaN(),
+	}
+
+	grad := loc.Gradient
+	if grad == nil && p.Grad != nil {
+		grad = make([]float64, dim)
+		p.Grad(loc.X, grad)
+		stats.GradEvaluations++
+	}
+	if grad != nil {
+		d.GradientNorm = p.Bounds.ProjectedGradientNorm(loc.X, grad)
+	}
+
+	var hess *mat64.SymDense
+	switch {
+	case loc.Hessian != nil:
+		hess = loc.Hessian
+	case p.Hess != nil:
+		hess = mat64.NewSymDense(dim, nil)
+		p.Hess(loc.X, hess)
+		stats.HessEvaluations++
+	case grad != nil:
+		// A dense sparsity pattern yields one gradient evaluation per
+		// variable.
+		var pattern SparsityPattern
+		for i := 0; i < dim; i++ {
+			for j := i + 1; j < dim; j++ {
+				pattern.Row = append(pattern.Row, i)
+				pattern.Col = append(pattern.Col, j)
+			}
+		}
+		fd := NewSparseHessian(dim, pattern)
+		hess = mat64.NewSymDense(dim, nil)
+		fd.Hessian(hess, p.Grad, loc.X, grad)
+		stats.GradEvaluations += fd.Colors()
+		d.HessianApproximated = true
+	default:
+		return d
+	}
+	d.HessianEvaluated = true
+
+	// Restrict the Hessian to the variables that are not at an active bound.
+	var free []int
+	for i, v := range loc.X {
+		if p.Bounds != nil && grad != nil {
+			if (v <= p.Bounds.lower(i) && grad[i] > 0) || (v >= p.Bounds.upper(i) && grad[i] < 0) {
+				continue
+			}
+		}
+		free = append(free, i)
+	}
+	if len(free) == 0 {
+		// All variables are at a bound, so the second-order conditions
+		// hold trivially.
+		d.SecondOrder = true
+		return d
+	}
+	a := make([]float64, len(free)*len(free))
+	for k, i := range free {
+		for l, j := range free {
+			a[k*len(free)+l] = hess.At(i, j)
+		}
+	}
+	eig := symEigenvalues(len(free), a)
+	d.MinEigenvalue = floats.Min(eig)
+	d.MaxEigenvalue = floats.Max(eig)
+	maxAbs := math.Max(math.Abs(d.MinEigenvalue), math.Abs(d.MaxEigenvalue))
+	minAbs := math.Inf(1)
+	for _, v := range eig {
+		minAbs = math.Min(minAbs, math.Abs(v))
+	}
+	d.Condition = maxAbs / minAbs
+	d.SecondOrder = d.MinEigenvalue >= -diagnosticsEigenTol*math.Max(1, maxAbs)
+	return d
+}
+
+// symEigenvalues computes the eigenvalues of the n×n symmetric matrix stored
+// in row-major order in a using the cyclic Jacobi method. a is overwritten.
+func symEigenvalues(n int, a []float64) []float64 {
+	for sweep := 0; sweep < maxJacobiSweeps; sweep++ {
+		var off, diag float64
+		for i := 0; i < n; i++ {
+			diag += a[i*n+i] * a[i*n+i]
+			for j := i + 1; j < n; j++ {
+				off += a[i*n+j] * a[i*n+j]
+			}
+		}
+		if off <= 1e-30*diag || off == 0 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				apq := a[p*n+q]
+				if apq == 0 {
+					continue
+				}
+				// Compute the rotation that annihilates a[p][q].
+				theta := (a[q*n+q] - a[p*n+p]) / (2 * apq)
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k*n+p], a[k*n+q]
+					a[k*n+p] = c*akp - s*akq
+					a[k*n+q] = s*akp + c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p*n+k], a[q*n+k]
+					a[p*n+k] = c*apk - s*aqk
+					a[q*n+k] = s*apk + c*aqk
+				}
+			}
+		}
+	}
+	eig := make([]float64, n)
+	for i := range eig {
+		eig[i] = a[i*n+i]
+	}
+	return eig
+}
@@ -0,0 +1,113 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestSymEigenvalues(t *testing.T) {
+	// Eigenvalues of the tridiagonal matrix with 2 on the diagonal and -1 on
+	// the off-diagonals are 2 - 2cos(kπ/(n+1)).
+	n := 6
+	a := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		a[i*n+i] = 2
+		if i > 0 {
+			a[i*n+i-1] = -1
+			a[(i-1)*n+i] = -1
+		}
+	}
+	got := symEigenvalues(n, a)
+	sort.Float64s(got)
+	want := make([]float64, n)
+	for k := range want {
+		want[k] = 2 - 2*math.Cos(float64(k+1)*math.Pi/float64(n+1))
+	}
+	if !floats.EqualApprox(got, want, 1e-12) {
+		t.Errorf("unexpected eigenvalues. Want %v, got %v", want, got)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Diagnostics = true
+
+	// Minimum found by a gradient-based method, the Hessian is approximated
+	// by finite differences.
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := result.Diagnostics
+	if d == nil {
+		t.Fatalf("diagnostics not computed")
+	}
+	if d.GradientNorm >= settings.GradientThreshold {
+		t.Errorf("unexpected gradient norm %v", d.GradientNorm)
+	}
+	if !d.HessianEvaluated || !d.HessianApproximated {
+		t.Errorf("Hessian not approximated")
+	}
+	// The Hessian of Rosenbrock at (1,1) is [802 -400; -400 200].
+	wantMin := 501 - math.Sqrt(301*301+400*400)
+	wantMax := 501 + math.Sqrt(301*301+400*400)
+	if math.Abs(d.MinEigenvalue-wantMin) > 1e-3 || math.Abs(d.MaxEigenvalue-wantMax) > 1e-3 {
+		t.Errorf("unexpected eigenvalues. Want %v and %v, got %v and %v", wantMin, wantMax, d.MinEigenvalue, d.MaxEigenvalue)
+	}
+	if !d.SecondOrder {
+		t.Errorf("second-order conditions do not hold at a minimum")
+	}
+
+	// Gradient descent started on the stable manifold of a saddle point
+	// converges to the saddle.
+	saddle := Problem{
+		Func: func(x []float64) float64 { return x[0]*x[0] - x[1]*x[1] },
+		Grad: func(x, grad []float64) {
+			grad[0] = 2 * x[0]
+			grad[1] = -2 * x[1]
+		},
+	}
+	result, err = Local(saddle, []float64{1, 0}, settings, &GradientDescent{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d = result.Diagnostics
+	if d.GradientNorm >= settings.GradientThreshold {
+		t.Errorf("unexpected gradient norm %v at saddle", d.GradientNorm)
+	}
+	if d.SecondOrder {
+		t.Errorf("second-order conditions hold at a saddle point")
+	}
+	if math.Abs(d.Condition-1) > 1e-6 {
+		t.Errorf("unexpected condition number %v", d.Condition)
+	}
+
+	// Bounded problem: the Hessian is restricted to the free variables.
+	quad := shiftedQuadratic{c: []float64{-2, 0.5, 3}}
+	bounded := Problem{
+		Func:   quad.Func,
+		Grad:   quad.Grad,
+		Hess:   quad.Hess,
+		Bounds: &Bounds{Lower: []float64{-1, -1, -1}, Upper: []float64{1, 1, 1}},
+	}
+	result, err = Local(bounded, []float64{0, 0, 0}, settings, &TwoMetric{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d = result.Diagnostics
+	if d.HessianApproximated || d.MinEigenvalue != 4 || d.MaxEigenvalue != 4 {
+		t.Errorf("unexpected curvature of the free variables: %v, %v", d.MinEigenvalue, d.MaxEigenvalue)
+	}
+}
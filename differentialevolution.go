@@ -0,0 +1,311 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultDECrossover = 0.9
+	defaultDEMutation  = 0.8
+)
+
+// DEStrategy specifies how DifferentialEvolution forms the mutant vector v
+// for the member x_i of the population. In the formulas below, x_best is
+// the best member of the current generation, F is the Mutation factor and
+// x_r1, x_r2, x_r3 are distinct members chosen at random, all different
+// from x_i.
+type DEStrategy int
+
+const (
+	// DERand1 is the classic strategy
+	//  v = x_r1 + F (x_r2 - x_r3).
+	// It explores the search space most thoroughly.
+	DERand1 DEStrategy = iota
+	// DEBest1 is the strategy
+	//  v = x_best + F (x_r1 - x_r2).
+	// It converges faster than DERand1 but is more prone to premature
+	// convergence to a local minimum.
+	DEBest1
+	// DECurrentToBest1 is the strategy
+	//  v = x_i + F (x_best - x_i) + F (x_r1 - x_r2).
+	DECurrentToBest1
+)
+
+// deStage is the stage of DifferentialEvolution.
+type deStage int
+
+const (
+	deInitialize deStage = iota // Evaluating the initial population.
+	deTrial                     // Evaluating the trial vectors.
+)
+
+// DifferentialEvolution implements the differential evolution method of
+// Storn and Price for gradient-free global optimization of multimodal
+// functions. It evolves a population of candidate locations. In every
+// generation, a trial vector is created for each member of the population
+// by mutation according to Strategy and by binomial crossover with the
+// member, and the trial vector replaces the member in the next generation if
+// its function value is not larger.
+//
+// The initial population contains the initial location. The other members
+// are sampled uniformly from the bounds of the problem along coordinates
+// where both bounds are finite, and from the interval of half-width
+// InitialRadius around the initial location along the others. Components of
+// a trial vector that violate a bound are set halfway between the
+// corresponding component of the member and the bound, so all evaluated
+// locations are feasible.
+//
+// Each generation is a major iteration. DifferentialEvolution does not
+// converge in the sense of a vanishing gradient, and the run should be
+// terminated by Settings.FunctionConverge or by the evaluation limits.
+//
+// The population is evaluated sequentially, one member per call to
+// Iterate.
+//
+// References:
+//  - Storn, R., Price, K.: Differential evolution - a simple and efficient
+//    heuristic for global optimization over continuous spaces. J. Global
+//    Optim. 11 (1997), 341-359
+type DifferentialEvolution struct {
+	// PopulationSize is the number of members of the population. If
+	// PopulationSize is zero, it will be set to ten times the dimension of
+	// the problem, but at least to 4. DifferentialEvolution panics if
+	// PopulationSize is nonzero and smaller than 4.
+	PopulationSize int
+	// CrossoverProbability is the probability that a component of the trial
+	// vector is taken from the mutant vector. If CrossoverProbability is
+	// zero, it will be set to 0.9. DifferentialEvolution panics if
+	// CrossoverProbability is not in (0, 1].
+	CrossoverProbability float64
+	// Mutation is the differential weight F. If Mutation is zero, it will be
+	// set to 0.8. DifferentialEvolution panics if Mutation is not in (0, 2].
+	Mutation float64
+	// Strategy is the mutation strategy.
+	Strategy DEStrategy
+	// InitialRadius is the half-width of the interval around the initial
+	// location from which the initial population is sampled along
+	// coordinates that are not bounded on both sides. If InitialRadius is
+	// zero, it will be set to 1.
+	InitialRadius float64
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+
+	rnd    *rand.Rand
+	bounds *Bounds
+
+	stage deStage
+	idx   int // Index of the member whose location or trial is evaluated.
+	best  int // Index of the best member of the current generation.
+
+	pop     [][]float64 // Members of the current generation.
+	f       []float64   // Function values of the members.
+	trials  [][]float64 // Trial vectors of the current generation.
+	trialsF []float64   // Function values of the trial vectors.
+}
+
+func (de *DifferentialEvolution) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if de.PopulationSize == 0 {
+		de.PopulationSize = 10 * dim
+		if de.PopulationSize < 4 {
+			de.PopulationSize = 4
+		}
+	}
+	if de.PopulationSize < 4 {
+		panic("de: population size must be at least 4")
+	}
+	if de.CrossoverProbability == 0 {
+		de.CrossoverProbability = defaultDECrossover
+	}
+	if de.CrossoverProbability < 0 || de.CrossoverProbability > 1 {
+		panic("de: crossover probability must be in (0, 1]")
+	}
+	if de.Mutation == 0 {
+		de.Mutation = defaultDEMutation
+	}
+	if de.Mutation < 0 || de.Mutation > 2 {
+		panic("de: mutation factor must be in (0, 2]")
+	}
+	if de.InitialRadius == 0 {
+		de.InitialRadius = 1
+	}
+	if de.InitialRadius < 0 {
+		panic("de: initial radius must be positive")
+	}
+	switch de.Strategy {
+	case DERand1, DEBest1, DECurrentToBest1:
+	default:
+		panic("de: unknown strategy")
+	}
+	de.rnd = newRand(de.Src, p.Src)
+	de.bounds = p.Bounds
+	if de.bounds == nil {
+		de.bounds = &Bounds{}
+	}
+
+	n := de.PopulationSize
+	de.pop = resizeMembers(de.pop, n, dim)
+	de.trials = resizeMembers(de.trials, n, dim)
+	de.f = resize(de.f, n)
+	de.trialsF = resize(de.trialsF, n)
+
+	copy(de.pop[0], loc.X)
+	de.f[0] = loc.F
+	for i := 1; i < n; i++ {
+		x := de.pop[i]
+		for j := range x {
+			l, u := initialRegion(de.bounds, loc.X, j, de.InitialRadius)
+			x[j] = l + (u-l)*de.rnd.Float64()
+		}
+	}
+
+	de.stage = deInitialize
+	de.idx = 1
+	copy(xNext, de.pop[1])
+	return FuncEvaluation, InitIteration, nil
+}
+
+func (de *DifferentialEvolution) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	n := len(de.pop)
+	if de.stage == deInitialize {
+		de.f[de.idx] = loc.F
+		de.idx++
+		if de.idx < n {
+			copy(xNext, de.pop[de.idx])
+			return FuncEvaluation, InitIteration, nil
+		}
+		de.stage = deTrial
+		return de.newGeneration(xNext)
+	}
+
+	de.trialsF[de.idx] = loc.F
+	de.idx++
+	if de.idx < n {
+		copy(xNext, de.trials[de.idx])
+		return FuncEvaluation, MinorIteration, nil
+	}
+	// Selection.
+	for i := range de.pop {
+		if de.trialsF[i] <= de.f[i] || math.IsNaN(de.f[i]) {
+			copy(de.pop[i], de.trials[i])
+			de.f[i] = de.trialsF[i]
+		}
+	}
+	return de.newGeneration(xNext)
+}
+
+// newGeneration starts a new generation, computes its trial vectors and
+// stores the first one into xNext.
+func (de *DifferentialEvolution) newGeneration(xNext []float64) (EvaluationType, IterationType, error) {
+	de.best = 0
+	for i, v := range de.f {
+		if v < de.f[de.best] || math.IsNaN(de.f[de.best]) {
+			de.best = i
+		}
+	}
+	for i := range de.trials {
+		de.trial(i)
+	}
+	de.idx = 0
+	copy(xNext, de.trials[0])
+	return FuncEvaluation, MajorIteration, nil
+}
+
+// Batch returns the members of the initial population or the trial vectors
+// of the current generation that have not been evaluated yet.
+func (de *DifferentialEvolution) Batch() (EvaluationType, [][]float64) {
+	if de.stage == deInitialize {
+		return FuncEvaluation, de.pop[de.idx:]
+	}
+	return FuncEvaluation, de.trials[de.idx:]
+}
+
+// trial computes the trial vector for the i-th member of the population.
+func (de *DifferentialEvolution) trial(i int) {
+	n := len(de.pop)
+	// Choose three distinct members different from i.
+	r := [3]int{i, i, i}
+	for k := range r {
+		for r[k] == i || (k > 0 && r[k] == r[0]) || (k > 1 && r[k] == r[1]) {
+			r[k] = de.rnd.Intn(n)
+		}
+	}
+
+	x := de.pop[i]
+	best := de.pop[de.best]
+	a, b, c := de.pop[r[0]], de.pop[r[1]], de.pop[r[2]]
+	mut := de.Mutation
+	jRand := de.rnd.Intn(len(x))
+	u := de.trials[i]
+	for j := range u {
+		if j != jRand && de.rnd.Float64() >= de.CrossoverProbability {
+			u[j] = x[j]
+			continue
+		}
+		var v float64
+		switch de.Strategy {
+		case DERand1:
+			v = a[j] + mut*(b[j]-c[j])
+		case DEBest1:
+			v = best[j] + mut*(a[j]-b[j])
+		case DECurrentToBest1:
+			v = x[j] + mut*(best[j]-x[j]) + mut*(a[j]-b[j])
+		}
+		if l := de.bounds.lower(j); v < l {
+			v = (x[j] + l) / 2
+		}
+		if up := de.bounds.upper(j); v > up {
+			v = (x[j] + up) / 2
+		}
+		u[j] = v
+	}
+}
+
+// initialRegion returns the interval of the j-th coordinate from which the
+// initial population of a population-based method is sampled. It is the
+// interval between the bounds if both are finite, and the interval of
+// half-width radius around x0 restricted to the bounds otherwise.
+func initialRegion(b *Bounds, x0 []float64, j int, radius float64) (l, u float64) {
+	l, u = b.lower(j), b.upper(j)
+	if math.IsInf(l, -1) || math.IsInf(u, 1) {
+		l = math.Max(l, x0[j]-radius)
+		u = math.Min(u, x0[j]+radius)
+	}
+	return l, u
+}
+
+// resizeMembers returns a slice of n locations of dimension dim, reusing
+// the storage of m if possible.
+func resizeMembers(m [][]float64, n, dim int) [][]float64 {
+	if cap(m) < n {
+		m = append(m[:cap(m)], make([][]float64, n-cap(m))...)
+	}
+	m = m[:n]
+	for i := range m {
+		m[i] = resize(m[i], dim)
+	}
+	return m
+}
+
+func (*DifferentialEvolution) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// HandlesBounds returns true. All locations evaluated by
+// DifferentialEvolution are feasible.
+func (*DifferentialEvolution) HandlesBounds() bool {
+	return true
+}
@@ -0,0 +1,95 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// rastrigin is the multimodal Rastrigin function
+//  f(x) = 10 n + Σ_i (x_i^2 - 10 cos(2π x_i))
+// with the global minimum f = 0 at the origin and a local minimum close to
+// every point with integer coordinates.
+func rastrigin(x []float64) float64 {
+	f := 10 * float64(len(x))
+	for _, v := range x {
+		f += v*v - 10*math.Cos(2*math.Pi*v)
+	}
+	return f
+}
+
+func TestDifferentialEvolution(t *testing.T) {
+	bounds := &Bounds{
+		Lower: []float64{-5.12, -5.12},
+		Upper: []float64{5.12, 5.12},
+	}
+	for _, strategy := range []DEStrategy{DERand1, DEBest1, DECurrentToBest1} {
+		var infeasible bool
+		p := Problem{
+			Func: func(x []float64) float64 {
+				if !bounds.Feasible(x) {
+					infeasible = true
+				}
+				return rastrigin(x)
+			},
+			Bounds: bounds,
+		}
+		var results []*Result
+		for i := 0; i < 2; i++ {
+			settings := DefaultSettings()
+			settings.Recorder = nil
+			settings.FunctionConverge.Iterations = 100
+			method := &DifferentialEvolution{
+				PopulationSize: 40,
+				Strategy:       strategy,
+				Src:            rand.NewSource(1),
+			}
+			// Start close to a local minimum.
+			result, err := Local(p, []float64{3, -4}, settings, method)
+			if err != nil {
+				t.Errorf("strategy %d: unexpected error: %v", strategy, err)
+				break
+			}
+			if result.Status != FunctionConvergence {
+				t.Errorf("strategy %d: unexpected status %v", strategy, result.Status)
+			}
+			if result.F > 1e-6 || !floats.EqualApprox(result.X, []float64{0, 0}, 1e-3) {
+				t.Errorf("strategy %d: global minimum not found, got f(%v) = %v", strategy, result.X, result.F)
+			}
+			results = append(results, result)
+		}
+		if infeasible {
+			t.Errorf("strategy %d: infeasible location evaluated", strategy)
+		}
+		if len(results) == 2 && (results[0].F != results[1].F || !floats.Equal(results[0].X, results[1].X)) {
+			t.Errorf("strategy %d: different result with the same source", strategy)
+		}
+	}
+}
+
+func TestDifferentialEvolutionUnbounded(t *testing.T) {
+	p := Problem{
+		Func: func(x []float64) float64 {
+			return (x[0]-1)*(x[0]-1) + 2*(x[1]+3)*(x[1]+3)
+		},
+	}
+	settings := DefaultSettings()
+	settings.Recorder = nil
+	method := &DifferentialEvolution{
+		InitialRadius: 5,
+		Src:           rand.NewSource(1),
+	}
+	result, err := Local(p, []float64{0, 0}, settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !floats.EqualApprox(result.X, []float64{1, -3}, 1e-4) {
+		t.Errorf("unexpected solution. Want %v, got %v", []float64{1, -3}, result.X)
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+const defaultDirectEpsilon = 1e-4
+
+// directStage is the stage of Direct.
+type directStage int
+
+const (
+	directCenter  directStage = iota // Evaluating the center of the box.
+	directSample                     // Evaluating the points of the divisions.
+	directDivided                    // The divisions of an iteration are finished.
+)
+
+// Direct implements the DIRECT (DIviding RECTangles) method of Jones,
+// Perttunen and Stuckman for gradient-free global optimization of functions
+// subject to finite bound constraints. Direct is deterministic and does not
+// use random numbers.
+//
+// Direct starts with the box given by the bounds and, in every iteration,
+// divides the potentially optimal rectangles, the rectangles that have the
+// lowest function value at their center for some value of the unknown
+// Lipschitz constant of the function, into thirds along their longest
+// sides. Larger rectangles are explored more globally and rectangles with
+// lower function values more locally, and Epsilon prevents the search from
+// becoming too local.
+//
+// The initial location is evaluated by Local but otherwise ignored. Each
+// iteration is a major iteration, and Direct converges with MethodConverge
+// status after MaxDivisions rectangles have been divided. The points of an
+// iteration are known in advance and are evaluated concurrently if
+// Settings.Concurrent is greater than one.
+//
+// References:
+//  - Jones, D.R., Perttunen, C.D., Stuckman, B.E.: Lipschitzian optimization
+//    without the Lipschitz constant. J. Optim. Theory Appl. 79 (1993),
+//    157-181
+type Direct struct {
+	// MaxDivisions is the total number of rectangle divisions after which
+	// Direct converges. If MaxDivisions is zero, the number of divisions is
+	// not limited and the optimization should be terminated by Settings.
+	MaxDivisions int
+	// Epsilon is the minimum relative improvement over the best function
+	// value that a rectangle must promise to be divided. If Epsilon is
+	// zero, it will be set to 1e-4.
+	Epsilon float64
+
+	lower, upper []float64
+
+	stage     directStage
+	status    Status
+	rects     []directRect
+	fmin      float64
+	divisions int
+
+	divs   []directDivision
+	points [][]float64 // Points of the divisions of the current iteration.
+	values []float64   // Function values at points.
+	idx    int         // Index of the point being evaluated.
+}
+
+// directRect is a rectangle of Direct in coordinates normalized to the unit
+// cube. The side of the rectangle along dimension i is 3^-level[i].
+type directRect struct {
+	center []float64
+	level  []int
+	f      float64
+	size   float64 // Half of the length of the diagonal.
+}
+
+// directDivision is the division of the rectangle rect along the dimensions
+// dims, whose sampled points are stored in the current iteration starting at
+// the index start.
+type directDivision struct {
+	rect  int
+	dims  []int
+	start int
+	w     []float64
+}
+
+func (d *Direct) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if d.MaxDivisions < 0 {
+		panic("direct: negative maximum number of divisions")
+	}
+	if d.Epsilon == 0 {
+		d.Epsilon = defaultDirectEpsilon
+	}
+	if d.Epsilon < 0 {
+		panic("direct: negative epsilon")
+	}
+	b := p.Bounds
+	if b == nil || b.Lower == nil || b.Upper == nil {
+		return NoEvaluation, NoIteration, errors.New("direct: problem must have finite bounds")
+	}
+	for i := 0; i < dim; i++ {
+		if math.IsInf(b.Lower[i], 0) || math.IsInf(b.Upper[i], 0) {
+			return NoEvaluation, NoIteration, errors.New("direct: problem must have finite bounds")
+		}
+	}
+	d.lower = b.Lower
+	d.upper = b.Upper
+
+	d.status = NotTerminated
+	d.divisions = 0
+	d.rects = d.rects[:0]
+	root := directRect{
+		center: make([]float64, dim),
+		level:  make([]int, dim),
+	}
+	for i := range root.center {
+		root.center[i] = 0.5
+	}
+	root.size = directSize(root.level)
+	d.rects = append(d.rects, root)
+
+	d.stage = directCenter
+	d.toBox(xNext, root.center)
+	return FuncEvaluation, InitIteration, nil
+}
+
+func (d *Direct) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch d.stage {
+	case directCenter:
+		d.rects[0].f = loc.F
+		d.fmin = loc.F
+		return d.nextIteration(xNext)
+	case directSample:
+		d.values[d.idx] = loc.F
+		d.idx++
+		if d.idx < len(d.points) {
+			copy(xNext, d.points[d.idx])
+			return FuncEvaluation, MinorIteration, nil
+		}
+		d.divide()
+		d.stage = directDivided
+		if d.MaxDivisions > 0 && d.divisions >= d.MaxDivisions {
+			d.status = MethodConverge
+		}
+		copy(xNext, loc.X)
+		return NoEvaluation, MajorIteration, nil
+	case directDivided:
+		return d.nextIteration(xNext)
+	}
+	panic("direct: unknown stage")
+}
+
+// nextIteration selects the potentially optimal rectangles, computes the
+// points of their divisions and stores the first point into xNext.
+func (d *Direct) nextIteration(xNext []float64) (EvaluationType, IterationType, error) {
+	selected := d.potentiallyOptimal()
+	if d.MaxDivisions > 0 && len(selected) > d.MaxDivisions-d.divisions {
+		selected = selected[:d.MaxDivisions-d.divisions]
+	}
+
+	d.divs = d.divs[:0]
+	d.points = d.points[:0]
+	for _, r := range selected {
+		rect := &d.rects[r]
+		minLevel := rect.level[0]
+		for _, l := range rect.level {
+			if l < minLevel {
+				minLevel = l
+			}
+		}
+		div := directDivision{rect: r, start: len(d.points)}
+		delta := math.Pow(3, -float64(minLevel+1))
+		for i, l := range rect.level {
+			if l != minLevel {
+				continue
+			}
+			div.dims = append(div.dims, i)
+			for _, sign := range []float64{1, -1} {
+				rect.center[i] += sign * delta
+				x := make([]float64, len(rect.center))
+				d.toBox(x, rect.center)
+				rect.center[i] -= sign * delta
+				d.points = append(d.points, x)
+			}
+		}
+		d.divs = append(d.divs, div)
+	}
+	d.values = resize(d.values, len(d.points))
+
+	d.stage = directSample
+	d.idx = 0
+	copy(xNext, d.points[0])
+	return FuncEvaluation, MinorIteration, nil
+}
+
+// potentiallyOptimal returns the indices of the potentially optimal
+// rectangles in increasing order of size.
+func (d *Direct) potentiallyOptimal() []int {
+	// Find the rectangle with the lowest function value for every size.
+	var best []int
+	for i, r := range d.rects {
+		k := sort.Search(len(best), func(k int) bool { return d.rects[best[k]].size >= r.size })
+		switch {
+		case k == len(best):
+			best = append(best, i)
+		case d.rects[best[k]].size == r.size:
+			if r.f < d.rects[best[k]].f {
+				best[k] = i
+			}
+		default:
+			best = append(best, 0)
+			copy(best[k+1:], best[k:])
+			best[k] = i
+		}
+	}
+
+	// A rectangle is potentially optimal if there is a positive Lipschitz
+	// constant K for which it has the lowest lower bound f - K*size, and
+	// that bound improves on fmin by at least Epsilon*|fmin|.
+	var selected []int
+	for j, rj := range best {
+		fj, dj := d.rects[rj].f, d.rects[rj].size
+		kLow := math.Inf(-1)
+		for _, ri := range best[:j] {
+			kLow = math.Max(kLow, (fj-d.rects[ri].f)/(dj-d.rects[ri].size))
+		}
+		kUp := math.Inf(1)
+		for _, ri := range best[j+1:] {
+			kUp = math.Min(kUp, (d.rects[ri].f-fj)/(d.rects[ri].size-dj))
+		}
+		if kUp <= 0 || kLow > kUp {
+			continue
+		}
+		if !math.IsInf(kUp, 1) && fj-kUp*dj > d.fmin-d.Epsilon*math.Abs(d.fmin) {
+			continue
+		}
+		selected = append(selected, rj)
+	}
+	return selected
+}
+
+// divide divides the rectangles of the current iteration after their points
+// have been evaluated. The dimensions of a rectangle are divided in
+// increasing order of the lowest function value of their two points, so that
+// the points with the lowest values are in the largest new rectangles.
+func (d *Direct) divide() {
+	for k := range d.divs {
+		div := &d.divs[k]
+		div.w = div.w[:0]
+		for m := range div.dims {
+			f0, f1 := d.values[div.start+2*m], d.values[div.start+2*m+1]
+			div.w = append(div.w, math.Min(f0, f1))
+			d.fmin = math.Min(d.fmin, div.w[m])
+		}
+		order := make([]int, len(div.dims))
+		for m := range order {
+			order[m] = m
+		}
+		sort.Stable(directOrder{order, div.w})
+
+		rect := d.rects[div.rect]
+		level := make([]int, len(rect.level))
+		copy(level, rect.level)
+		for _, m := range order {
+			i := div.dims[m]
+			level[i]++
+			delta := math.Pow(3, -float64(level[i]))
+			for s, sign := range []float64{1, -1} {
+				r := directRect{
+					center: make([]float64, len(rect.center)),
+					level:  make([]int, len(level)),
+					f:      d.values[div.start+2*m+s],
+				}
+				copy(r.center, rect.center)
+				r.center[i] += sign * delta
+				copy(r.level, level)
+				r.size = directSize(r.level)
+				d.rects = append(d.rects, r)
+			}
+		}
+		// The center rectangle is divided along all dimensions.
+		rect = d.rects[div.rect]
+		copy(rect.level, level)
+		rect.size = directSize(rect.level)
+		d.rects[div.rect] = rect
+	}
+	d.divisions += len(d.divs)
+}
+
+// directSize returns half of the length of the diagonal of a rectangle with
+// the given levels. The sum is accumulated by level so that rectangles with
+// the same sides in different dimensions have exactly the same size.
+func directSize(level []int) float64 {
+	maxLevel := 0
+	for _, l := range level {
+		if l > maxLevel {
+			maxLevel = l
+		}
+	}
+	var sum float64
+	for l := 0; l <= maxLevel; l++ {
+		var n int
+		for _, v := range level {
+			if v == l {
+				n++
+			}
+		}
+		sum += float64(n) * math.Pow(9, -float64(l))
+	}
+	return 0.5 * math.Sqrt(sum)
+}
+
+// toBox stores the location of the normalized point c into x.
+func (d *Direct) toBox(x, c []float64) {
+	for i, v := range c {
+		x[i] = d.lower[i] + v*(d.upper[i]-d.lower[i])
+	}
+}
+
+// Batch returns the points of the current iteration that have not been
+// evaluated yet.
+func (d *Direct) Batch() (EvaluationType, [][]float64) {
+	if d.stage != directSample {
+		return NoEvaluation, nil
+	}
+	return FuncEvaluation, d.points[d.idx:]
+}
+
+// Status returns MethodConverge when MaxDivisions rectangles have been
+// divided.
+func (d *Direct) Status() (Status, error) {
+	return d.status, nil
+}
+
+func (*Direct) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// HandlesBounds returns true. All locations evaluated by Direct are
+// feasible.
+func (*Direct) HandlesBounds() bool {
+	return true
+}
+
+// directOrder sorts the indices in increasing order of their values.
+type directOrder struct {
+	idx []int
+	w   []float64
+}
+
+func (o directOrder) Len() int           { return len(o.idx) }
+func (o directOrder) Less(i, j int) bool { return o.w[o.idx[i]] < o.w[o.idx[j]] }
+func (o directOrder) Swap(i, j int)      { o.idx[i], o.idx[j] = o.idx[j], o.idx[i] }
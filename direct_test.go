@@ -0,0 +1,73 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// sixHumpCamel is the six-hump camel function with two global minima of
+// value -1.0316 at (0.0898, -0.7126) and (-0.0898, 0.7126) and four other
+// local minima.
+func sixHumpCamel(x []float64) float64 {
+	x2 := x[0] * x[0]
+	y2 := x[1] * x[1]
+	return (4-2.1*x2+x2*x2/3)*x2 + x[0]*x[1] + (-4+4*y2)*y2
+}
+
+func TestDirect(t *testing.T) {
+	const fOpt = -1.031628453
+	bounds := &Bounds{Lower: []float64{-3, -2}, Upper: []float64{3, 2}}
+	var infeasible bool
+	p := Problem{
+		Func: func(x []float64) float64 {
+			if !bounds.Feasible(x) {
+				infeasible = true
+			}
+			return sixHumpCamel(x)
+		},
+		Bounds: bounds,
+	}
+	settings := DefaultSettings()
+	settings.FunctionConverge = nil
+	var want *Result
+	for _, concurrent := range []int{0, 4} {
+		settings.Concurrent = concurrent
+		method := &Direct{MaxDivisions: 200}
+		result, err := Local(p, []float64{1, 1}, settings, method)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("unexpected status %v", result.Status)
+		}
+		if method.divisions != 200 {
+			t.Errorf("unexpected number of divisions %d", method.divisions)
+		}
+		if math.Abs(result.F-fOpt) > 1e-4 {
+			t.Errorf("global minimum not found: x = %v, f = %v", result.X, result.F)
+		}
+		if infeasible {
+			t.Errorf("infeasible location evaluated")
+		}
+		// Direct is deterministic.
+		if want == nil {
+			want = result
+			continue
+		}
+		if result.F != want.F || !floats.Equal(result.X, want.X) ||
+			result.FuncEvaluations != want.FuncEvaluations || result.MajorIterations != want.MajorIterations {
+			t.Errorf("concurrent run differs from sequential run")
+		}
+	}
+
+	p.Bounds = &Bounds{Lower: []float64{-3, -2}}
+	if _, err := Local(p, []float64{1, 1}, nil, &Direct{}); err == nil {
+		t.Errorf("expected error for infinite bounds")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// CauchyPoint is a SubproblemSolver that returns the Cauchy point, the
+// minimizer of the quadratic model along the steepest descent direction within
+// the trust region,
+//  p = -τ Δ/|∇f|_2 ∇f,
+// where τ = 1 if ∇fᵀB∇f <= 0 and τ = min(1, |∇f|_2^3/(Δ ∇fᵀB∇f)) otherwise.
+// A trust-region method using the Cauchy point is globally convergent, but
+// it is no faster than steepest descent. Other solvers improve on the Cauchy
+// point using more information from B.
+//
+// Reference:
+//  Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//  (2006), Section 4.1
+type CauchyPoint struct {
+	tmp []float64
+}
+
+func (c *CauchyPoint) Solve(p, grad []float64, hess *mat64.SymDense, radius float64) float64 {
+	dim := len(p)
+	if len(grad) != dim || hess.Symmetric() != dim {
+		panic("cauchypoint: size mismatch")
+	}
+	if radius <= 0 {
+		panic("cauchypoint: radius not positive")
+	}
+	c.tmp = resize(c.tmp, dim)
+	cauchyPoint(p, grad, hess, radius, c.tmp)
+	return quadraticModel(p, grad, hess, c.tmp)
+}
+
+// cauchyPoint stores the Cauchy point into p. tmp is used as temporary
+// storage.
+func cauchyPoint(p, grad []float64, hess *mat64.SymDense, radius float64, tmp []float64) {
+	gNorm := floats.Norm(grad, 2)
+	if gNorm == 0 {
+		for i := range p {
+			p[i] = 0
+		}
+		return
+	}
+	gBg := curvature(grad, hess, tmp)
+	tau := 1.0
+	if gBg > 0 {
+		tau = math.Min(1, gNorm*gNorm*gNorm/(radius*gBg))
+	}
+	copy(p, grad)
+	floats.Scale(-tau*radius/gNorm, p)
+}
+
+// Dogleg is a SubproblemSolver that implements the dogleg method. If B is
+// positive definite, the dogleg path consists of the line segment from zero
+// to the unconstrained minimizer of the model along the steepest descent
+// direction, p_U, followed by the segment from p_U to the full step
+// p_B = -B^{-1}∇f. The step is the point of this path where it leaves the
+// trust region, or p_B if it lies inside. The model decreases monotonically
+// along the path, so the step is the minimizer of the model over the path.
+//
+// Dogleg requires a Cholesky factorization of B. If B is not positive
+// definite, Dogleg returns the Cauchy point. Dogleg is thus suitable for
+// quasi-Newton approximations that are kept positive definite, but
+// solvers that handle negative curvature, such as Steihaug or MoreSorensen,
+// are preferable when B is the exact Hessian.
+//
+// Reference:
+//  Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//  (2006), Section 4.1
+type Dogleg struct {
+	chol *mat64.TriDense
+	pu   []float64
+	tmp  []float64
+}
+
+func (d *Dogleg) Solve(p, grad []float64, hess *mat64.SymDense, radius float64) float64 {
+	dim := len(p)
+	if len(grad) != dim || hess.Symmetric() != dim {
+		panic("dogleg: size mismatch")
+	}
+	if radius <= 0 {
+		panic("dogleg: radius not positive")
+	}
+	d.chol = resizeTriDense(d.chol, dim)
+	d.pu = resize(d.pu, dim)
+	d.tmp = resize(d.tmp, dim)
+
+	gNorm := floats.Norm(grad, 2)
+	if gNorm == 0 || !d.chol.Cholesky(hess, true) {
+		cauchyPoint(p, grad, hess, radius, d.tmp)
+		return quadraticModel(p, grad, hess, d.tmp)
+	}
+
+	// Full step p_B = -B^{-1}∇f.
+	pVec := mat64.NewVector(dim, p)
+	pVec.SolveCholeskyVec(d.chol, mat64.NewVector(dim, grad))
+	floats.Scale(-1, p)
+	if floats.Norm(p, 2) <= radius {
+		return quadraticModel(p, grad, hess, d.tmp)
+	}
+
+	// Unconstrained minimizer along the steepest descent direction,
+	// p_U = -(∇f·∇f / ∇fᵀB∇f) ∇f. ∇fᵀB∇f is positive because B is.
+	copy(d.pu, grad)
+	gBg := curvature(grad, hess, d.tmp)
+	floats.Scale(-gNorm*gNorm/gBg, d.pu)
+	if floats.Norm(d.pu, 2) >= radius {
+		// The path leaves the trust region on the first segment.
+		copy(p, grad)
+		floats.Scale(-radius/gNorm, p)
+		return quadraticModel(p, grad, hess, d.tmp)
+	}
+
+	// The path leaves the trust region on the second segment. Find τ such
+	// that |p_U + τ(p_B - p_U)|_2 = Δ.
+	floats.Sub(p, d.pu)
+	_, tau := boundaryStep(d.pu, p, radius)
+	floats.AddScaledTo(p, d.pu, tau, p)
+	return quadraticModel(p, grad, hess, d.tmp)
+}
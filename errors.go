@@ -26,6 +26,10 @@ var (
 	// ErrGradNaN signifies the initial function value is NaN.
 	ErrGradNaN = errors.New("optimize: initial gradient is NaN")
 
+	// ErrInitialX signifies that the initial location has a NaN or
+	// infinite element.
+	ErrInitialX = errors.New("optimize: initial location is not finite")
+
 	// ErrNonNegativeStepDirection signifies that the linesearch has received a
 	// step direction in which the gradient is not negative.
 	ErrNonNegativeStepDirection = errors.New("linesearch: projected gradient not negative")
@@ -37,6 +41,34 @@ var (
 	// because there is no change in location after LinesearchMethod step due
 	// to floating-point arithmetic.
 	ErrNoProgress = errors.New("linesearch: no change in location after linesearch step")
+
+	// ErrNoBracket signifies that FindBracket has not found a bracket of a
+	// minimum, which may occur if the function decreases without bound.
+	ErrNoBracket = errors.New("optimize: no bracket of a minimum found")
+
+	// ErrNoRootBracket signifies that the function values at the end points
+	// of the interval given to a root finder do not have opposite signs.
+	ErrNoRootBracket = errors.New("optimize: root not bracketed")
+
+	// ErrZeroDerivative signifies that a root finder has encountered a zero
+	// derivative.
+	ErrZeroDerivative = errors.New("optimize: zero derivative")
+
+	// ErrIterationLimit signifies that a solver has reached its maximum
+	// number of iterations before converging.
+	ErrIterationLimit = errors.New("optimize: iteration limit reached")
+
+	// ErrSingularJacobian signifies that a root-finding method has
+	// encountered a Jacobian that is singular to working precision.
+	ErrSingularJacobian = errors.New("optimize: singular Jacobian")
+
+	// ErrNoIntegerSolution signifies that BranchAndBound has not found a
+	// solution that satisfies the integer constraints.
+	ErrNoIntegerSolution = errors.New("optimize: no integer solution found")
+
+	// ErrContinuationFailure signifies that Continuation has not been able
+	// to solve the problems of the family up to t = 1.
+	ErrContinuationFailure = errors.New("optimize: continuation failed")
 )
 
 // ErrMismatch signifies that the optimization function did not implement the
@@ -48,3 +80,22 @@ type ErrMismatch struct {
 func (e ErrMismatch) Error() string {
 	return fmt.Sprintf("optimizer wanted to use evaluation type %v, but the user supplied function does not implement it", e.Type)
 }
+
+// ErrNonFinite signifies that the objective function value or the gradient
+// is NaN or infinite at a location evaluated during the optimization,
+// including the initial location, see Settings.NonFiniteRetries.
+type ErrNonFinite struct {
+	// Location is a copy of the offending location with the values
+	// evaluated there.
+	Location *Location
+	// Gradient specifies whether the gradient is not finite. Otherwise, the
+	// function value is NaN or +Inf.
+	Gradient bool
+}
+
+func (e ErrNonFinite) Error() string {
+	if e.Gradient {
+		return fmt.Sprintf("optimize: gradient %v is not finite at x = %v", e.Location.Gradient, e.Location.X)
+	}
+	return fmt.Sprintf("optimize: function value %v is not finite at x = %v", e.Location.F, e.Location.X)
+}
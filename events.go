@@ -0,0 +1,85 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+// Event is a snapshot of an optimization run that Local publishes to
+// Settings.Events.
+type Event struct {
+	// Iteration is InitIteration for the initial location and for locations
+	// modified by Settings.Callback, MajorIteration for every major
+	// iteration and PostIteration for the last event of the run.
+	Iteration IterationType
+	// Status is NotTerminated except in the last event, where it is the
+	// Status of the Result.
+	Status Status
+	// Err is the error returned by Local in the last event, and nil
+	// otherwise.
+	Err error
+	// Location is a copy of the location of the iteration, or of the optimal
+	// location in the last event. It holds the values of the original
+	// problem also if Settings.Maximize or Settings.Scale are set.
+	Location *Location
+	// Stats are the statistics of the run at the time of the event.
+	Stats Stats
+	// Dropped is the number of events that were dropped before this event
+	// because the channel was full, including the events that were dropped
+	// before the dropped events, so that no dropped event goes unnoticed.
+	Dropped int
+}
+
+// eventRecorder publishes the recorded initial location and major
+// iterations to a channel and passes all records to Recorder, if it is not
+// nil. The last event is published by Local with the status of the run.
+type eventRecorder struct {
+	Recorder Recorder
+	events   chan Event
+	dropped  int
+}
+
+func (r *eventRecorder) Init() error {
+	r.dropped = 0
+	if r.Recorder != nil {
+		return r.Recorder.Init()
+	}
+	return nil
+}
+
+func (r *eventRecorder) Record(loc *Location, e EvaluationType, iter IterationType, stats *Stats) error {
+	if iter == InitIteration || iter == MajorIteration {
+		r.publish(Event{Iteration: iter, Status: NotTerminated, Location: loc, Stats: *stats})
+	}
+	if r.Recorder != nil {
+		return r.Recorder.Record(loc, e, iter, stats)
+	}
+	return nil
+}
+
+// publish sends a copy of ev to the channel without blocking. If the channel
+// is full, the oldest event in the channel is dropped to make room, so that
+// the receiver always gets the most recent state of the run. If the channel
+// is still full, ev is dropped.
+func (r *eventRecorder) publish(ev Event) {
+	loc := &Location{}
+	copyLocation(loc, ev.Location)
+	ev.Location = loc
+	ev.Dropped = r.dropped
+	select {
+	case r.events <- ev:
+		r.dropped = 0
+		return
+	default:
+	}
+	select {
+	case old := <-r.events:
+		ev.Dropped += old.Dropped + 1
+	default:
+	}
+	select {
+	case r.events <- ev:
+		r.dropped = 0
+	default:
+		r.dropped = ev.Dropped + 1
+	}
+}
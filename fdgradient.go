@@ -0,0 +1,175 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/diff/fd"
+	"github.com/gonum/floats"
+)
+
+const (
+	// defaultCentralStep is the default relative step size for central
+	// differences, approximately the cube root of the machine epsilon.
+	defaultCentralStep = 6e-6
+	// defaultComplexStep is the default step size for complex-step
+	// differentiation. The complex step does not suffer from cancellation,
+	// so the step can be tiny.
+	defaultComplexStep = 1e-20
+)
+
+// FDFormula selects the formula of a finite-difference approximation.
+type FDFormula int
+
+const (
+	// ForwardDifference approximates the derivatives by
+	//  ∂f/∂x_i ≈ (f(x + h_i e_i) - f(x)) / h_i,
+	// which requires n function evaluations per gradient in addition to the
+	// one at x.
+	ForwardDifference FDFormula = iota
+	// CentralDifference approximates the derivatives by
+	//  ∂f/∂x_i ≈ (f(x + h_i e_i) - f(x - h_i e_i)) / 2h_i,
+	// which requires 2n function evaluations per gradient and is more
+	// accurate than ForwardDifference.
+	CentralDifference
+	// ComplexStep computes the derivatives by
+	//  ∂f/∂x_i ≈ Im(f(x + i h_i e_i)) / h_i,
+	// which requires n evaluations of the complex extension of the function
+	// per gradient and is accurate to machine precision. It can only be used
+	// when the function is analytic and its complex extension is available,
+	// see FDGradient.FuncComplex.
+	ComplexStep
+)
+
+// FDGradient approximates the gradient of a function by finite differences.
+// It allows gradient-based Methods to be used with Problems that provide only
+// Func.
+type FDGradient struct {
+	// Formula is the finite-difference formula. The default is
+	// ForwardDifference.
+	Formula FDFormula
+	// Step specifies the step size h_i for every dimension. If Step is nil,
+	// the step sizes are relative to the magnitude of the location,
+	// h_i = h·max(1, |x_i|), where h is approximately the square root of the
+	// machine epsilon for ForwardDifference and the cube root for
+	// CentralDifference. For ComplexStep, h_i = 1e-20 is used.
+	Step []float64
+	// FuncComplex evaluates the complex extension of the objective function
+	// and must be set if Formula is ComplexStep. FuncComplex must not modify
+	// x.
+	FuncComplex func(x []complex128) complex128
+}
+
+// Problem returns a copy of p in which Grad is approximated by finite
+// differences of p.Func, or of g.FuncComplex for ComplexStep, and FuncGrad is
+// nil. The function evaluations made by Grad are not counted in
+// Stats.FuncEvaluations, each evaluation of Grad is counted as one gradient
+// evaluation. The value of p.Func at the last evaluated location is reused by
+// ForwardDifference. The returned Problem must not be used concurrently.
+//
+// Problem panics if p.Func is nil, if the length of g.Step does not match
+// the dimension of the evaluated locations, or if Formula is ComplexStep and
+// FuncComplex is nil.
+func (g FDGradient) Problem(p Problem) Problem {
+	if p.Func == nil {
+		panic("optimize: objective function is undefined")
+	}
+	if g.Formula == ComplexStep && g.FuncComplex == nil {
+		panic("optimize: complex step without complex function")
+	}
+	fg := &fdGradient{g: g, f: p.Func}
+	p.Func = fg.Func
+	p.Grad = fg.Grad
+	p.FuncGrad = nil
+	return p
+}
+
+// fdGradient evaluates the finite-difference approximation of the gradient.
+type fdGradient struct {
+	g FDGradient
+	f func(x []float64) float64
+
+	x     []float64 // Location of the last evaluation of f.
+	fx    float64   // Value of f at x.
+	xh    []float64 // Perturbed location.
+	xc    []complex128
+	valid bool // Whether fx is valid.
+}
+
+func (fg *fdGradient) Func(x []float64) float64 {
+	f := fg.f(x)
+	fg.x = resize(fg.x, len(x))
+	copy(fg.x, x)
+	fg.fx = f
+	fg.valid = true
+	return f
+}
+
+func (fg *fdGradient) Grad(x, grad []float64) {
+	dim := len(x)
+	if len(grad) != dim {
+		panic("optimize: slice length mismatch")
+	}
+	if fg.g.Step != nil && len(fg.g.Step) != dim {
+		panic("optimize: step size length mismatch")
+	}
+	if fg.g.Formula == ComplexStep {
+		fg.complexStep(x, grad)
+		return
+	}
+
+	settings := &fd.Settings{Formula: fd.Forward}
+	if fg.g.Formula == CentralDifference {
+		settings.Formula = fd.Central
+	} else {
+		if !fg.valid || !floats.Equal(x, fg.x) {
+			fg.Func(x)
+		}
+		settings.OriginKnown = true
+		settings.OriginValue = fg.fx
+	}
+	fg.xh = resize(fg.xh, dim)
+	copy(fg.xh, x)
+	for i := range x {
+		settings.Step = fg.step(x, i)
+		xi := x[i]
+		grad[i] = fd.Derivative(func(t float64) float64 {
+			fg.xh[i] = t
+			return fg.f(fg.xh)
+		}, xi, settings)
+		fg.xh[i] = xi
+	}
+}
+
+func (fg *fdGradient) complexStep(x, grad []float64) {
+	if cap(fg.xc) < len(x) {
+		fg.xc = make([]complex128, len(x))
+	}
+	fg.xc = fg.xc[:len(x)]
+	for i, v := range x {
+		fg.xc[i] = complex(v, 0)
+	}
+	for i, v := range x {
+		h := fg.step(x, i)
+		fg.xc[i] = complex(v, h)
+		grad[i] = imag(fg.g.FuncComplex(fg.xc)) / h
+		fg.xc[i] = complex(v, 0)
+	}
+}
+
+// step returns the step size in the i-th dimension at x.
+func (fg *fdGradient) step(x []float64, i int) float64 {
+	if fg.g.Step != nil {
+		return fg.g.Step[i]
+	}
+	switch fg.g.Formula {
+	case CentralDifference:
+		return defaultCentralStep * math.Max(1, math.Abs(x[i]))
+	case ComplexStep:
+		return defaultComplexStep
+	}
+	return defaultFDStep * math.Max(1, math.Abs(x[i]))
+}
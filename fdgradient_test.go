@@ -0,0 +1,63 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+// rosenbrockComplex is the complex extension of the extended Rosenbrock
+// function.
+func rosenbrockComplex(x []complex128) complex128 {
+	var f complex128
+	for i := 0; i < len(x)-1; i++ {
+		a := 1 - x[i]
+		b := x[i+1] - x[i]*x[i]
+		f += a*a + 100*b*b
+	}
+	return f
+}
+
+func TestFDGradient(t *testing.T) {
+	f := functions.ExtendedRosenbrock{}
+	x := []float64{-1.2, 1, 0.5, 30}
+	want := make([]float64, len(x))
+	f.Grad(x, want)
+
+	for _, test := range []struct {
+		name string
+		g    FDGradient
+		tol  float64
+	}{
+		{"Forward", FDGradient{}, 1e-5},
+		{"Central", FDGradient{Formula: CentralDifference}, 1e-7},
+		{"Complex", FDGradient{Formula: ComplexStep, FuncComplex: rosenbrockComplex}, 1e-13},
+		{"Step", FDGradient{Formula: CentralDifference, Step: []float64{1e-6, 1e-6, 1e-6, 1e-4}}, 1e-7},
+	} {
+		p := test.g.Problem(Problem{Func: f.Func})
+		got := make([]float64, len(x))
+		p.Grad(x, got)
+		if !floats.EqualApprox(got, want, test.tol*floats.Norm(want, math.Inf(1))) {
+			t.Errorf("%s: unexpected gradient. Want %v, got %v", test.name, want, got)
+		}
+
+		// The accuracy of forward differences limits the attainable norm of
+		// the gradient.
+		settings := DefaultSettings()
+		settings.GradientThreshold = 1e-4
+		result, err := Local(p, []float64{-1.2, 1, -1.2, 1}, settings, &BFGS{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !floats.EqualApprox(result.X, []float64{1, 1, 1, 1}, 1e-3) {
+			t.Errorf("%s: unexpected minimum location %v", test.name, result.X)
+		}
+	}
+}
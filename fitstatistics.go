@@ -0,0 +1,231 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// FitStatistics are the statistics of the parameters x of a least-squares fit
+// to data with independent errors of equal variance σ^2, obtained from the
+// linearization of the residuals at the solution. The covariance of the
+// parameters is approximately
+//  σ^2 (JᵀJ)^{-1},
+// where J is the Jacobian of the residuals at x.
+type FitStatistics struct {
+	// X are the fitted parameters.
+	X []float64
+	// Residuals and Jacobian are the residuals and their Jacobian at X.
+	Residuals []float64
+	Jacobian  *mat64.Dense
+	// DegreesOfFreedom is the number of residuals minus the number of
+	// parameters.
+	DegreesOfFreedom int
+	// Variance is the variance σ^2 of the residuals. It is either given, or
+	// estimated by the sum of squares of the residuals divided by
+	// DegreesOfFreedom, in which case EstimatedVariance is true.
+	Variance          float64
+	EstimatedVariance bool
+	// Covariance is the estimate of the covariance of the parameters, and
+	// StdErr are the standard errors, the square roots of its diagonal.
+	Covariance *mat64.SymDense
+	StdErr     []float64
+}
+
+// Statistics returns the statistics of the fit of rp at the solution x. If
+// variance is positive, it is the known variance of the residuals, otherwise
+// the variance is estimated from the residuals at x. Statistics returns
+// ErrSingularJacobian if JᵀJ is singular, so that some parameters are not
+// determined by the data.
+//
+// If rp.Jacobian is nil, the Jacobian is approximated by forward differences
+// with the sparsity pattern rp.JacobianSparsity.
+//
+// Statistics panics if both rp.Jacobian and rp.JacobianSparsity are nil, if
+// rp.Loss is not nil, if variance is negative, or if it is zero and the
+// number of residuals is not larger than the number of parameters.
+func (rp ResidualProblem) Statistics(x []float64, variance float64) (*FitStatistics, error) {
+	if rp.Jacobian == nil && rp.JacobianSparsity == nil {
+		panic("optimize: Jacobian is undefined")
+	}
+	if rp.Loss != nil {
+		panic("optimize: statistics of fits with a loss function are not supported")
+	}
+	if variance < 0 {
+		panic("optimize: negative variance")
+	}
+	m, n := rp.Len, len(x)
+	if variance == 0 && m <= n {
+		panic("optimize: too few residuals to estimate the variance")
+	}
+
+	s := &FitStatistics{
+		X:                make([]float64, n),
+		Residuals:        make([]float64, m),
+		Jacobian:         mat64.NewDense(m, n, nil),
+		DegreesOfFreedom: m - n,
+		Variance:         variance,
+		Covariance:       mat64.NewSymDense(n, nil),
+		StdErr:           make([]float64, n),
+	}
+	copy(s.X, x)
+	rp.Residual(x, s.Residuals)
+	if rp.Jacobian != nil {
+		rp.Jacobian(x, s.Jacobian)
+	} else {
+		NewSparseJacobian(m, n, *rp.JacobianSparsity).Jacobian(s.Jacobian, rp.Residual, x, s.Residuals)
+	}
+	if variance == 0 {
+		s.Variance = floats.Dot(s.Residuals, s.Residuals) / float64(s.DegreesOfFreedom)
+		s.EstimatedVariance = true
+	}
+
+	jtj := mat64.NewSymDense(n, nil)
+	for j := 0; j < n; j++ {
+		for k := j; k < n; k++ {
+			var v float64
+			for i := 0; i < m; i++ {
+				v += s.Jacobian.At(i, j) * s.Jacobian.At(i, k)
+			}
+			jtj.SetSym(j, k, v)
+		}
+	}
+	if !choleskyInverse(s.Covariance, jtj, mat64.NewTriDense(n, true, nil)) {
+		return nil, ErrSingularJacobian
+	}
+	for j := 0; j < n; j++ {
+		for k := j; k < n; k++ {
+			s.Covariance.SetSym(j, k, s.Variance*s.Covariance.At(j, k))
+		}
+		s.StdErr[j] = math.Sqrt(s.Covariance.At(j, j))
+	}
+	return s, nil
+}
+
+// ConfidenceIntervals stores the bounds of the confidence intervals of the
+// parameters with the confidence level, for example 0.95, into lower and
+// upper. The intervals are X_j ± q StdErr_j, where q is the quantile of the
+// Student's t-distribution with DegreesOfFreedom degrees of freedom if the
+// variance is estimated, and of the standard normal distribution otherwise.
+// ConfidenceIntervals panics if level is not in (0, 1) or if the lengths of
+// lower and upper do not match the number of parameters.
+func (s *FitStatistics) ConfidenceIntervals(lower, upper []float64, level float64) {
+	if level <= 0 || level >= 1 {
+		panic("optimize: confidence level not in (0, 1)")
+	}
+	if len(lower) != len(s.X) || len(upper) != len(s.X) {
+		panic("optimize: slice length mismatch")
+	}
+	p := (1 + level) / 2
+	var q float64
+	if s.EstimatedVariance {
+		q = studentTQuantile(p, float64(s.DegreesOfFreedom))
+	} else {
+		q = math.Sqrt2 * math.Erfinv(2*p-1)
+	}
+	for j, v := range s.X {
+		lower[j] = v - q*s.StdErr[j]
+		upper[j] = v + q*s.StdErr[j]
+	}
+}
+
+// studentTQuantile returns the quantile t with P(T <= t) = p > 0.5 of the
+// Student's t-distribution with nu degrees of freedom.
+func studentTQuantile(p, nu float64) float64 {
+	// The upper tail probability of t > 0 is ½ I_{ν/(ν+t²)}(ν/2, ½).
+	tail := func(t float64) float64 {
+		return 0.5 * regIncBeta(nu/2, 0.5, nu/(nu+t*t))
+	}
+	lo, hi := 0.0, 1.0
+	for tail(hi) > 1-p {
+		lo = hi
+		hi *= 2
+	}
+	for i := 0; i < 100 && hi-lo > 1e-14*hi; i++ {
+		mid := (lo + hi) / 2
+		if tail(mid) > 1-p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regIncBeta returns the regularized incomplete beta function I_x(a, b).
+//
+// Reference:
+//  Press, W.H., Teukolsky, S.A., Vetterling, W.T., Flannery, B.P.:
+//  Numerical Recipes (3rd ed). Cambridge University Press (2007), Section 6.4
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges rapidly for x < (a+1)/(a+b+2), and
+	// the symmetry I_x(a, b) = 1 - I_{1-x}(b, a) is used otherwise.
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete
+// beta function by the modified Lentz method.
+func betaContinuedFraction(a, b, x float64) float64 {
+	const (
+		tiny  = 1e-300
+		eps   = 1e-15
+		maxIt = 300
+	)
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIt; m++ {
+		fm := float64(m)
+		// Even step.
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// Odd step.
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,34 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+// FixedStep is a LinesearchMethod that accepts the initial step size given
+// by the NextDirectioner without checking any condition on the function
+// value, so every line search costs a single evaluation. The step sizes are
+// then entirely determined by the StepSizer, for example ConstantStepSize or
+// DiminishingStepSize.
+//
+// FixedStep is intended for methods whose directions do not guarantee a
+// decrease of the function, such as subgradient and stochastic gradient
+// methods, for which sufficient decrease conditions are meaningless. For
+// smooth functions, the steps must be small enough for the iteration to
+// converge, for example smaller than 2/L for gradient descent on a function
+// with an L-Lipschitz gradient.
+type FixedStep struct{}
+
+func (FixedStep) Init(_ LinesearchLocation, step float64, _ *ProblemInfo) EvaluationType {
+	if step <= 0 {
+		panic("fixedstep: bad step size")
+	}
+	return FuncEvaluation | GradEvaluation
+}
+
+func (FixedStep) Finished(_ LinesearchLocation) bool {
+	return true
+}
+
+func (FixedStep) Iterate(_ LinesearchLocation) (float64, EvaluationType, error) {
+	panic("fixedstep: line search already finished")
+}
@@ -0,0 +1,99 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import "math"
+
+const defaultBenchmarkTol = 1e-6
+
+// BenchmarkMethod is an optimization method run by Benchmark.
+type BenchmarkMethod struct {
+	// Name identifies the method in the results.
+	Name string
+	// Run minimizes the function given by f, and by its gradient grad,
+	// starting from x. grad is nil if the benchmarked function does not
+	// implement Grad. Run must not modify x. For example, a Method of
+	// github.com/gonum/optimize is run by
+	//  func(f func([]float64) float64, grad func(x, grad []float64), x []float64) {
+	//  	optimize.Local(optimize.Problem{Func: f, Grad: grad}, x, nil, &optimize.BFGS{})
+	//  }
+	Run func(f func(x []float64) float64, grad func(x, grad []float64), x []float64)
+}
+
+// BenchmarkResult is the performance of a method measured by Benchmark.
+type BenchmarkResult struct {
+	// Name is the name of the method.
+	Name string
+	// F is the lowest function value found.
+	F float64
+	// FuncEvaluations and GradEvaluations are the numbers of evaluations of
+	// the function and of the gradient made by the method.
+	FuncEvaluations int
+	GradEvaluations int
+	// Converged reports whether the lowest function value reached the
+	// global minimum within the tolerance, in which case
+	// FuncEvaluationsToTol and GradEvaluationsToTol are the numbers of
+	// evaluations made until it was reached for the first time.
+	Converged            bool
+	FuncEvaluationsToTol int
+	GradEvaluationsToTol int
+}
+
+// Benchmark runs the methods on the function f from the starting location x
+// and measures the number of evaluations every method needs to reach the
+// lowest of the known minima of f, which must implement
+// Minima() []Minimum. The minimum is reached when the function value is
+// below
+//  f_min + tol·max(1, |f_min|).
+// If tol is zero, it is set to 1e-6. If f implements Grad(x, grad []float64),
+// the methods are given the gradient. Benchmark panics if f has no known
+// minima.
+func Benchmark(f Function, x []float64, methods []BenchmarkMethod, tol float64) []BenchmarkResult {
+	fm, ok := f.(minimumer)
+	if !ok || len(fm.Minima()) == 0 {
+		panic("functions: no known minima")
+	}
+	if tol == 0 {
+		tol = defaultBenchmarkTol
+	}
+	if tol < 0 {
+		panic("functions: negative tolerance")
+	}
+	fMin := math.Inf(1)
+	for _, m := range fm.Minima() {
+		fMin = math.Min(fMin, m.F)
+	}
+	target := fMin + tol*math.Max(1, math.Abs(fMin))
+	fg, hasGrad := f.(gradient)
+
+	results := make([]BenchmarkResult, len(methods))
+	for i, method := range methods {
+		res := &results[i]
+		res.Name = method.Name
+		res.F = math.Inf(1)
+		fn := func(x []float64) float64 {
+			v := f.Func(x)
+			res.FuncEvaluations++
+			res.F = math.Min(res.F, v)
+			if !res.Converged && v <= target {
+				res.Converged = true
+				res.FuncEvaluationsToTol = res.FuncEvaluations
+				res.GradEvaluationsToTol = res.GradEvaluations
+			}
+			return v
+		}
+		var grad func(x, grad []float64)
+		if hasGrad {
+			grad = func(x, grad []float64) {
+				fg.Grad(x, grad)
+				res.GradEvaluations++
+			}
+		}
+		x0 := make([]float64, len(x))
+		copy(x0, x)
+		method.Run(fn, grad, x0)
+	}
+	return results
+}
@@ -0,0 +1,84 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import (
+	"fmt"
+	"testing"
+)
+
+// recorder is a Reporter that records the reported failures.
+type recorder []string
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	*r = append(*r, fmt.Sprintf(format, args...))
+}
+
+// wrongGradient is the Wood function with an incorrect gradient.
+type wrongGradient struct{ Wood }
+
+func (w wrongGradient) Grad(x, grad []float64) {
+	w.Wood.Grad(x, grad)
+	grad[0] += 1
+}
+
+func TestTestReporter(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{-3, -1, -3, -1},
+			F:        19192,
+			Gradient: []float64{-12008, -2080, -10808, -1880},
+		},
+	}
+	var r recorder
+	Test(Wood{}, tests, &r)
+	if len(r) != 0 {
+		t.Errorf("unexpected failures for a correct function: %v", r)
+	}
+	// The gradient is wrong at the test location and at the minimum.
+	r = nil
+	Test(wrongGradient{}, tests, &r)
+	if len(r) != 2 {
+		t.Errorf("unexpected failures for an incorrect gradient: %v", r)
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	x := []float64{-3, -1, -3, -1}
+	methods := []BenchmarkMethod{
+		{
+			Name: "Exact",
+			Run: func(f func([]float64) float64, grad func(x, grad []float64), x []float64) {
+				f(x)
+				grad(x, make([]float64, len(x)))
+				f([]float64{1, 1, 1, 1})
+				f(x)
+			},
+		},
+		{
+			Name: "Stuck",
+			Run: func(f func([]float64) float64, grad func(x, grad []float64), x []float64) {
+				for i := 0; i < 3; i++ {
+					f(x)
+				}
+				x[0] = 0
+			},
+		},
+	}
+	results := Benchmark(Wood{}, x, methods, 0)
+	want := []BenchmarkResult{
+		{Name: "Exact", F: 0, FuncEvaluations: 3, GradEvaluations: 1,
+			Converged: true, FuncEvaluationsToTol: 2, GradEvaluationsToTol: 1},
+		{Name: "Stuck", F: 19192, FuncEvaluations: 3},
+	}
+	for i, r := range results {
+		if r != want[i] {
+			t.Errorf("unexpected result, want %+v, got %+v", want[i], r)
+		}
+	}
+	if x[0] != -3 {
+		t.Errorf("starting location modified")
+	}
+}
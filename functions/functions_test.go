@@ -4,10 +4,16 @@
 
 package functions
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/gonum/diff/fd"
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
 
 func TestBeale(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{1, 1},
 			F:        14.203125,
@@ -19,33 +25,52 @@ func TestBeale(t *testing.T) {
 			Gradient: []float64{8813.25, 6585},
 		},
 	}
-	testFunction(Beale{}, tests, t)
+	Test(Beale{}, tests, t)
+
+	// Check the Hessian against finite differences of the gradient.
+	for _, x := range [][]float64{{1, 1}, {1, 4}, {-2, 0.5}, {3, 0.5}, {0.5, -1.5}} {
+		hess := mat64.NewSymDense(2, nil)
+		Beale{}.Hess(x, hess)
+		for i := 0; i < 2; i++ {
+			gi := func(x []float64) float64 {
+				grad := make([]float64, 2)
+				Beale{}.Grad(x, grad)
+				return grad[i]
+			}
+			fdRow := fd.Gradient(nil, gi, x, nil)
+			row := []float64{hess.At(i, 0), hess.At(i, 1)}
+			if !floats.EqualApprox(row, fdRow, 1e-5) {
+				t.Errorf("Beale: row %d of the Hessian at %v does not match finite differences. Want: %v, Got: %v",
+					i, x, fdRow, row)
+			}
+		}
+	}
 }
 
 func TestBiggsEXP2(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{1, 2},
 			F:        32.26255055084012,
 			Gradient: []float64{8.308203800550878, -25.32607145221645},
 		},
 	}
-	testFunction(BiggsEXP2{}, tests, t)
+	Test(BiggsEXP2{}, tests, t)
 }
 
 func TestBiggsEXP3(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{1, 2, 1},
 			F:        1.598844540607779,
 			Gradient: []float64{1.0633795027631927, -0.5196392672262664, -0.3180919155433357},
 		},
 	}
-	testFunction(BiggsEXP3{}, tests, t)
+	Test(BiggsEXP3{}, tests, t)
 }
 
 func TestBiggsEXP4(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X: []float64{1, 2, 1, 1},
 			F: 1.598844540607779,
@@ -53,11 +78,11 @@ func TestBiggsEXP4(t *testing.T) {
 				-0.44245622408151464, -0.3180919155433357},
 		},
 	}
-	testFunction(BiggsEXP4{}, tests, t)
+	Test(BiggsEXP4{}, tests, t)
 }
 
 func TestBiggsEXP5(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X: []float64{1, 2, 1, 1, 1},
 			F: 13.386420552801937,
@@ -65,11 +90,11 @@ func TestBiggsEXP5(t *testing.T) {
 				14.36984212995392, -9.522506150695783, -19.639956134327882},
 		},
 	}
-	testFunction(BiggsEXP5{}, tests, t)
+	Test(BiggsEXP5{}, tests, t)
 }
 
 func TestBiggsEXP6(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X: []float64{1, 2, 1, 1, 1, 1},
 			F: 0.77907007565597,
@@ -78,29 +103,29 @@ func TestBiggsEXP6(t *testing.T) {
 				-1.483958013575642},
 		},
 	}
-	testFunction(BiggsEXP6{}, tests, t)
+	Test(BiggsEXP6{}, tests, t)
 }
 
 func TestBox3D(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{0, 10, 20},
 			F:        1031.1538106093985,
 			Gradient: []float64{98.22343149849218, -2.11937420675874, 112.38817362220350},
 		},
 	}
-	testFunction(Box3D{}, tests, t)
+	Test(Box3D{}, tests, t)
 }
 
 func TestBrownBadlyScaled(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{1, 1},
 			F:        999998000003,
 			Gradient: []float64{-2e+6, -4e-6},
 		},
 	}
-	testFunction(BrownBadlyScaled{}, tests, t)
+	Test(BrownBadlyScaled{}, tests, t)
 }
 
 // TODO(vladimir-ch): The minimum of BrownAndDennis is not known accurately
@@ -108,18 +133,18 @@ func TestBrownBadlyScaled(t *testing.T) {
 // tests to pass. This is the only function that causes problems, so disable
 // this test until the minimum is more accurate.
 // func TestBrownAndDennis(t *testing.T) {
-// 	tests := []funcTest{
+// 	tests := []FuncTest{
 // 		{
 // 			X:        []float64{25, 5, -5, -1},
 // 			F:        7926693.33699744,
 // 			Gradient: []float64{1149322.836365895, 1779291.674339785, -254579.585463521, -173400.429253115},
 // 		},
 // 	}
-// 	testFunction(BrownAndDennis{}, tests, t)
+// 	Test(BrownAndDennis{}, tests, t)
 // }
 
 func TestExtendedPowellSingular(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{3, -1, 0, 3},
 			F:        95,
@@ -131,11 +156,11 @@ func TestExtendedPowellSingular(t *testing.T) {
 			Gradient: []float64{-14, -144, -22, 30, -14, -144, -22, 30},
 		},
 	}
-	testFunction(ExtendedPowellSingular{}, tests, t)
+	Test(ExtendedPowellSingular{}, tests, t)
 }
 
 func TestExtendedRosenbrock(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{-1.2, 1},
 			F:        24.2,
@@ -152,44 +177,44 @@ func TestExtendedRosenbrock(t *testing.T) {
 			Gradient: []float64{-215.6, 792, -655.6, -88},
 		},
 	}
-	testFunction(ExtendedRosenbrock{}, tests, t)
+	Test(ExtendedRosenbrock{}, tests, t)
 }
 
 func TestGaussian(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{0.4, 1, 0},
 			F:        3.88810699116688e-06,
 			Gradient: []float64{7.41428466839991e-03, -7.44126392165149e-04, -5.30189685421989e-20},
 		},
 	}
-	testFunction(Gaussian{}, tests, t)
+	Test(Gaussian{}, tests, t)
 }
 
 func TestGulfResearchAndDevelopment(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{5, 2.5, 0.15},
 			F:        12.11070582556949,
 			Gradient: []float64{2.0879783574289799, 0.0345792619697154, -39.6766801029386400},
 		},
 	}
-	testFunction(GulfResearchAndDevelopment{}, tests, t)
+	Test(GulfResearchAndDevelopment{}, tests, t)
 }
 
 func TestHelicalValley(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{-1, 0, 0},
 			F:        2500,
 			Gradient: []float64{0, -1.59154943091895e+03, -1e+03},
 		},
 	}
-	testFunction(HelicalValley{}, tests, t)
+	Test(HelicalValley{}, tests, t)
 }
 
 func TestPenaltyI(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{1, 2, 3, 4},
 			F:        885.06264,
@@ -202,11 +227,11 @@ func TestPenaltyI(t *testing.T) {
 				7695.00008, 9234.0001, 10773.00012, 12312.00014, 13851.00016, 15390.00018},
 		},
 	}
-	testFunction(PenaltyI{}, tests, t)
+	Test(PenaltyI{}, tests, t)
 }
 
 func TestPenaltyII(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X: []float64{0.5, 0.5, 0.5, 0.5},
 			F: 2.34000880546302,
@@ -222,22 +247,22 @@ func TestPenaltyII(t *testing.T) {
 				50.9999884118158, 25.4999938418451},
 		},
 	}
-	testFunction(PenaltyII{}, tests, t)
+	Test(PenaltyII{}, tests, t)
 }
 
 func TestPowelBadlyScaled(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{0, 1},
 			F:        1.13526171734838,
 			Gradient: []float64{-2.00007355588823e+04, -2.70596990584991e-01},
 		},
 	}
-	testFunction(PowellBadlyScaled{}, tests, t)
+	Test(PowellBadlyScaled{}, tests, t)
 }
 
 func TestTrigonometric(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{0.5, 0.5},
 			F:        0.0126877761614045,
@@ -258,11 +283,11 @@ func TestTrigonometric(t *testing.T) {
 				-0.04377317726073873, -0.04472077967504980},
 		},
 	}
-	testFunction(Trigonometric{}, tests, t)
+	Test(Trigonometric{}, tests, t)
 }
 
 func TestVariablyDimensioned(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{0.5, 0},
 			F:        46.5625,
@@ -279,11 +304,11 @@ func TestVariablyDimensioned(t *testing.T) {
 			Gradient: []float64{-1703, -3406, -5109, -6812},
 		},
 	}
-	testFunction(VariablyDimensioned{}, tests, t)
+	Test(VariablyDimensioned{}, tests, t)
 }
 
 func TestWatson(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{0, 0},
 			F:        30,
@@ -311,16 +336,16 @@ func TestWatson(t *testing.T) {
 				-68.516667837400661, -69.631282933991471},
 		},
 	}
-	testFunction(Watson{}, tests, t)
+	Test(Watson{}, tests, t)
 }
 
 func TestWood(t *testing.T) {
-	tests := []funcTest{
+	tests := []FuncTest{
 		{
 			X:        []float64{-3, -1, -3, -1},
 			F:        19192,
 			Gradient: []float64{-12008, -2080, -10808, -1880},
 		},
 	}
-	testFunction(Wood{}, tests, t)
+	Test(Wood{}, tests, t)
 }
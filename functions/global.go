@@ -0,0 +1,345 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import "math"
+
+// This file contains multimodal test functions for global optimization. The
+// functions are defined for any dimension, which is given by the Dim field,
+// and they have a large number of local minima within the standard search
+// domain returned by Bounds.
+
+// schwefelMinimizer is the coordinate of the global minimizer of Schwefel,
+// and schwefelConst is the value of x sin(sqrt(|x|)) at it.
+const (
+	schwefelMinimizer = 420.96874635998199
+	schwefelConst     = 418.98288727243369
+)
+
+// globalBounds returns the search domain [lower, upper]^dim.
+func globalBounds(dim int, lower, upper float64) (l, u []float64) {
+	l = make([]float64, dim)
+	u = make([]float64, dim)
+	for i := range l {
+		l[i] = lower
+		u[i] = upper
+	}
+	return l, u
+}
+
+// globalMinimum returns the minimum at the location [v, ..., v] of dimension
+// dim with the value f.
+func globalMinimum(dim int, v, f float64) []Minimum {
+	if dim <= 0 {
+		return nil
+	}
+	x := make([]float64, dim)
+	for i := range x {
+		x[i] = v
+	}
+	return []Minimum{
+		{
+			X:      x,
+			F:      f,
+			Global: true,
+		},
+	}
+}
+
+// Rastrigin implements the Rastrigin function
+//  f(x) = 10 n + Σ_i [x_i^2 - 10 cos(2π x_i)].
+// The global minimum is at the origin, surrounded by a regular grid of local
+// minima.
+//
+// Standard search domain:
+//  [-5.12, 5.12]^n
+//
+// References:
+//  - Rastrigin, L.A.: Systems of extremal control. Nauka, Moscow (1974)
+//  - Muhlenbein, H., Schomisch, D., Born, J.: The parallel genetic algorithm
+//    as function optimizer. Parallel Comput 17 (1991), 619-632
+type Rastrigin struct {
+	// Dim is the dimension of the problem.
+	Dim int
+}
+
+func (r Rastrigin) Func(x []float64) float64 {
+	if len(x) != r.Dim {
+		panic("functions: problem size mismatch")
+	}
+	sum := 10 * float64(len(x))
+	for _, v := range x {
+		sum += v*v - 10*math.Cos(2*math.Pi*v)
+	}
+	return sum
+}
+
+func (r Rastrigin) Grad(x, grad []float64) {
+	if len(x) != r.Dim {
+		panic("functions: problem size mismatch")
+	}
+	if len(x) != len(grad) {
+		panic("incorrect size of the gradient")
+	}
+	for i, v := range x {
+		grad[i] = 2*v + 20*math.Pi*math.Sin(2*math.Pi*v)
+	}
+}
+
+// Bounds returns the standard search domain.
+func (r Rastrigin) Bounds() (lower, upper []float64) {
+	return globalBounds(r.Dim, -5.12, 5.12)
+}
+
+func (r Rastrigin) Minima() []Minimum {
+	return globalMinimum(r.Dim, 0, 0)
+}
+
+// Ackley implements the Ackley function
+//  f(x) = -20 exp(-0.2 sqrt(Σ_i x_i^2 / n)) - exp(Σ_i cos(2π x_i) / n) + 20 + e.
+// The global minimum is at the origin, where the function is not
+// differentiable. Grad returns zero there.
+//
+// Standard search domain:
+//  [-32.768, 32.768]^n
+//
+// References:
+//  - Ackley, D.H.: A connectionist machine for genetic hillclimbing. Kluwer
+//    Academic Publishers (1987)
+//  - Back, T.: Evolutionary Algorithms in Theory and Practice. Oxford
+//    University Press (1996)
+type Ackley struct {
+	// Dim is the dimension of the problem.
+	Dim int
+}
+
+func (a Ackley) Func(x []float64) float64 {
+	if len(x) != a.Dim {
+		panic("functions: problem size mismatch")
+	}
+	n := float64(len(x))
+	var sq, cs float64
+	for _, v := range x {
+		sq += v * v
+		cs += math.Cos(2 * math.Pi * v)
+	}
+	return -20*math.Exp(-0.2*math.Sqrt(sq/n)) - math.Exp(cs/n) + 20 + math.E
+}
+
+func (a Ackley) Grad(x, grad []float64) {
+	if len(x) != a.Dim {
+		panic("functions: problem size mismatch")
+	}
+	if len(x) != len(grad) {
+		panic("incorrect size of the gradient")
+	}
+	n := float64(len(x))
+	var sq, cs float64
+	for _, v := range x {
+		sq += v * v
+		cs += math.Cos(2 * math.Pi * v)
+	}
+	s := math.Sqrt(sq / n)
+	e1 := math.Exp(-0.2 * s)
+	e2 := math.Exp(cs / n)
+	for i, v := range x {
+		grad[i] = 2 * math.Pi * e2 * math.Sin(2*math.Pi*v) / n
+		if s != 0 {
+			grad[i] += 4 * e1 * v / (n * s)
+		}
+	}
+}
+
+// Bounds returns the standard search domain.
+func (a Ackley) Bounds() (lower, upper []float64) {
+	return globalBounds(a.Dim, -32.768, 32.768)
+}
+
+func (a Ackley) Minima() []Minimum {
+	return globalMinimum(a.Dim, 0, 0)
+}
+
+// Griewank implements the Griewank function
+//  f(x) = 1 + Σ_i x_i^2 / 4000 - Π_i cos(x_i / sqrt(i)).
+// The global minimum is at the origin. The local minima become shallower
+// relative to the quadratic term as the dimension increases.
+//
+// Standard search domain:
+//  [-600, 600]^n
+//
+// References:
+//  - Griewank, A.O.: Generalized descent for global optimization. J Optim
+//    Theory Appl 34 (1981), 11-39
+type Griewank struct {
+	// Dim is the dimension of the problem.
+	Dim int
+}
+
+func (g Griewank) Func(x []float64) float64 {
+	if len(x) != g.Dim {
+		panic("functions: problem size mismatch")
+	}
+	sum := 1.0
+	prod := 1.0
+	for i, v := range x {
+		sum += v * v / 4000
+		prod *= math.Cos(v / math.Sqrt(float64(i+1)))
+	}
+	return sum - prod
+}
+
+func (g Griewank) Grad(x, grad []float64) {
+	if len(x) != g.Dim {
+		panic("functions: problem size mismatch")
+	}
+	if len(x) != len(grad) {
+		panic("incorrect size of the gradient")
+	}
+	for i, v := range x {
+		si := math.Sqrt(float64(i + 1))
+		// The product of the cosines of the other components is computed
+		// directly because the cosine of x_i may be zero.
+		prod := math.Sin(v/si) / si
+		for j, w := range x {
+			if j != i {
+				prod *= math.Cos(w / math.Sqrt(float64(j+1)))
+			}
+		}
+		grad[i] = v/2000 + prod
+	}
+}
+
+// Bounds returns the standard search domain.
+func (g Griewank) Bounds() (lower, upper []float64) {
+	return globalBounds(g.Dim, -600, 600)
+}
+
+func (g Griewank) Minima() []Minimum {
+	return globalMinimum(g.Dim, 0, 0)
+}
+
+// Schwefel implements the Schwefel function
+//  f(x) = Σ_i [c - x_i sin(sqrt(|x_i|))],
+// where c ≈ 418.9829 is chosen such that the global minimum value is zero.
+// The global minimum is at [420.9687, ..., 420.9687], near the corner of the
+// search domain and far from the next best local minima, which makes the
+// function deceptive.
+//
+// Standard search domain:
+//  [-500, 500]^n
+//
+// References:
+//  - Schwefel, H.-P.: Numerical Optimization of Computer Models. Wiley (1981)
+type Schwefel struct {
+	// Dim is the dimension of the problem.
+	Dim int
+}
+
+func (s Schwefel) Func(x []float64) float64 {
+	if len(x) != s.Dim {
+		panic("functions: problem size mismatch")
+	}
+	var sum float64
+	for _, v := range x {
+		sum += schwefelConst - v*math.Sin(math.Sqrt(math.Abs(v)))
+	}
+	return sum
+}
+
+func (s Schwefel) Grad(x, grad []float64) {
+	if len(x) != s.Dim {
+		panic("functions: problem size mismatch")
+	}
+	if len(x) != len(grad) {
+		panic("incorrect size of the gradient")
+	}
+	for i, v := range x {
+		r := math.Sqrt(math.Abs(v))
+		grad[i] = -math.Sin(r) - r*math.Cos(r)/2
+	}
+}
+
+// Bounds returns the standard search domain.
+func (s Schwefel) Bounds() (lower, upper []float64) {
+	return globalBounds(s.Dim, -500, 500)
+}
+
+func (s Schwefel) Minima() []Minimum {
+	return globalMinimum(s.Dim, schwefelMinimizer, 0)
+}
+
+// Levy implements the Levy function
+//  f(x) = sin^2(π w_1) + Σ_{i<n} (w_i - 1)^2 [1 + 10 sin^2(π w_i + 1)]
+//         + (w_n - 1)^2 [1 + sin^2(2π w_n)],
+// where w_i = 1 + (x_i - 1)/4. The global minimum is at [1, ..., 1].
+//
+// Standard search domain:
+//  [-10, 10]^n
+//
+// References:
+//  - Levy, A.V., Montalvo, A.: The tunneling algorithm for the global
+//    minimization of functions. SIAM J Sci Stat Comput 6 (1985), 15-29
+type Levy struct {
+	// Dim is the dimension of the problem.
+	Dim int
+}
+
+func (l Levy) Func(x []float64) float64 {
+	if len(x) != l.Dim {
+		panic("functions: problem size mismatch")
+	}
+	n := len(x)
+	if n == 0 {
+		return 0
+	}
+	w := 1 + (x[0]-1)/4
+	s := math.Sin(math.Pi * w)
+	sum := s * s
+	for _, v := range x[:n-1] {
+		w = 1 + (v-1)/4
+		s = math.Sin(math.Pi*w + 1)
+		sum += (w - 1) * (w - 1) * (1 + 10*s*s)
+	}
+	w = 1 + (x[n-1]-1)/4
+	s = math.Sin(2 * math.Pi * w)
+	return sum + (w-1)*(w-1)*(1+s*s)
+}
+
+func (l Levy) Grad(x, grad []float64) {
+	if len(x) != l.Dim {
+		panic("functions: problem size mismatch")
+	}
+	if len(x) != len(grad) {
+		panic("incorrect size of the gradient")
+	}
+	n := len(x)
+	if n == 0 {
+		return
+	}
+	// The derivatives are computed with respect to w and scaled by
+	// dw/dx = 1/4 at the end.
+	for i, v := range x[:n-1] {
+		w := 1 + (v-1)/4
+		s := math.Sin(math.Pi*w + 1)
+		grad[i] = 2*(w-1)*(1+10*s*s) + 10*math.Pi*(w-1)*(w-1)*math.Sin(2*(math.Pi*w+1))
+	}
+	w := 1 + (x[n-1]-1)/4
+	s := math.Sin(2 * math.Pi * w)
+	grad[n-1] = 2*(w-1)*(1+s*s) + 2*math.Pi*(w-1)*(w-1)*math.Sin(4*math.Pi*w)
+	w = 1 + (x[0]-1)/4
+	grad[0] += math.Pi * math.Sin(2*math.Pi*w)
+	for i := range grad {
+		grad[i] /= 4
+	}
+}
+
+// Bounds returns the standard search domain.
+func (l Levy) Bounds() (lower, upper []float64) {
+	return globalBounds(l.Dim, -10, 10)
+}
+
+func (l Levy) Minima() []Minimum {
+	return globalMinimum(l.Dim, 1, 0)
+}
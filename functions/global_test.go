@@ -0,0 +1,68 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import "testing"
+
+func TestRastrigin(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.5, -1.5, 2.25},
+			F:        57.5625,
+			Gradient: []float64{1, -3, 67.33185307179586},
+		},
+	}
+	Test(Rastrigin{Dim: 3}, tests, t)
+	Test(Rastrigin{Dim: 10}, nil, t)
+}
+
+func TestAckley(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.5, -1.5, 2.25},
+			F:        7.64615200795026,
+			Gradient: []float64{0.30565369808188025, -0.916961094245641, 2.450739940955146},
+		},
+	}
+	Test(Ackley{Dim: 3}, tests, t)
+	Test(Ackley{Dim: 10}, nil, t)
+}
+
+func TestGriewank(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.5, -1.5, 2.25},
+			F:        0.8868648787110904,
+			Gradient: []float64{0.06308885159377149, -0.14611199526977164, 0.23945150450239364},
+		},
+	}
+	Test(Griewank{Dim: 3}, tests, t)
+	Test(Griewank{Dim: 10}, nil, t)
+}
+
+func TestSchwefel(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.5, -1.5, 2.25},
+			F:        1255.7905586285142,
+			Gradient: []float64{-0.9184239940563688, -1.1484274839712678, -1.0505478878548316},
+		},
+	}
+	Test(Schwefel{Dim: 3}, tests, t)
+	Test(Schwefel{Dim: 10}, nil, t)
+}
+
+func TestLevy(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.5, -1.5, 2.25},
+			F:        3.418789169954546,
+			Gradient: []float64{-0.7063729507125172, -5.295247123106318, 0.18114889554781702},
+		},
+	}
+	Test(Levy{Dim: 3}, tests, t)
+	Test(Levy{Dim: 1}, nil, t)
+	Test(Levy{Dim: 10}, nil, t)
+}
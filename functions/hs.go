@@ -0,0 +1,672 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// This file contains constrained test problems from the collection of Hock
+// and Schittkowski,
+//  minimize f(x) subject to c_E(x) = 0, c_I(x) <= 0.
+// The problems have no bound constraints. Unlike in the collection, the
+// inequality constraints are written as c_I(x) <= 0 as in the optimize
+// package, so that they can be used directly as Problem.Equality and
+// Problem.Inequality.
+//
+// References:
+//  - Hock, W., Schittkowski, K.: Test Examples for Nonlinear Programming
+//    Codes. Lecture Notes in Economics and Mathematical Systems 187, Springer
+//    (1981)
+
+// Constraint is a vector-valued function c: R^n → R^m that describes
+// nonlinear constraints. It has the method set of optimize.Constraint.
+type Constraint interface {
+	// Len returns the number of constraint functions m.
+	Len() int
+	// Func evaluates the constraint functions at x and stores the result
+	// in-place in dst.
+	Func(x, dst []float64)
+	// Jac evaluates the m×n Jacobian of the constraint functions at x and
+	// stores the result in-place in jac.
+	Jac(x []float64, jac *mat64.Dense)
+}
+
+// KKTPoint is a solution of a constrained problem that satisfies the
+// Karush-Kuhn-Tucker conditions
+//  ∇f(x) + J_E(x)ᵀ Equality + J_I(x)ᵀ Inequality = 0,
+// where J_E and J_I are the Jacobians of the equality and the inequality
+// constraints. The multipliers of the inequality constraints are
+// non-negative and zero for inactive constraints.
+type KKTPoint struct {
+	Minimum
+	// Equality and Inequality are the Lagrange multipliers of the
+	// constraints.
+	Equality   []float64
+	Inequality []float64
+}
+
+// ConstrainedFunction is a constrained test problem.
+type ConstrainedFunction interface {
+	Function
+	Grad(x, grad []float64)
+	// Equality and Inequality return the equality and the inequality
+	// constraints, or nil if the problem has none.
+	Equality() Constraint
+	Inequality() Constraint
+	// KKTPoints returns the known solutions of the problem.
+	KKTPoints() []KKTPoint
+}
+
+// constraint implements Constraint for a fixed dimension.
+type constraint struct {
+	dim, len int
+	fn       func(x, dst []float64)
+	jac      func(x []float64, jac *mat64.Dense)
+}
+
+func (c constraint) Len() int {
+	return c.len
+}
+
+func (c constraint) Func(x, dst []float64) {
+	if len(x) != c.dim {
+		panic("functions: problem size mismatch")
+	}
+	if len(dst) != c.len {
+		panic("functions: incorrect size of the constraint values")
+	}
+	c.fn(x, dst)
+}
+
+func (c constraint) Jac(x []float64, jac *mat64.Dense) {
+	if len(x) != c.dim {
+		panic("functions: problem size mismatch")
+	}
+	if r, cols := jac.Dims(); r != c.len || cols != c.dim {
+		panic("functions: incorrect size of the Jacobian")
+	}
+	c.jac(x, jac)
+}
+
+// checkHS panics if x or grad do not have the dimension n of the problem.
+func checkHS(x, grad []float64, n int) {
+	if len(x) != n {
+		panic("functions: problem size mismatch")
+	}
+	if grad != nil && len(grad) != n {
+		panic("incorrect size of the gradient")
+	}
+}
+
+// HS6 implements the problem 6 of Hock and Schittkowski,
+//  minimize (1 - x_1)^2
+//  subject to 10 (x_2 - x_1^2) = 0.
+//
+// Standard starting point:
+//  [-1.2, 1]
+type HS6 struct{}
+
+func (HS6) Func(x []float64) float64 {
+	checkHS(x, nil, 2)
+	return (1 - x[0]) * (1 - x[0])
+}
+
+func (HS6) Grad(x, grad []float64) {
+	checkHS(x, grad, 2)
+	grad[0] = -2 * (1 - x[0])
+	grad[1] = 0
+}
+
+func (HS6) Equality() Constraint {
+	return constraint{
+		dim: 2,
+		len: 1,
+		fn: func(x, dst []float64) {
+			dst[0] = 10 * (x[1] - x[0]*x[0])
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, -20*x[0])
+			jac.Set(0, 1, 10)
+		},
+	}
+}
+
+func (HS6) Inequality() Constraint {
+	return nil
+}
+
+func (HS6) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:  Minimum{X: []float64{1, 1}, F: 0, Global: true},
+			Equality: []float64{0},
+		},
+	}
+}
+
+// HS7 implements the problem 7 of Hock and Schittkowski,
+//  minimize log(1 + x_1^2) - x_2
+//  subject to (1 + x_1^2)^2 + x_2^2 - 4 = 0.
+//
+// Standard starting point:
+//  [2, 2]
+type HS7 struct{}
+
+func (HS7) Func(x []float64) float64 {
+	checkHS(x, nil, 2)
+	return math.Log1p(x[0]*x[0]) - x[1]
+}
+
+func (HS7) Grad(x, grad []float64) {
+	checkHS(x, grad, 2)
+	grad[0] = 2 * x[0] / (1 + x[0]*x[0])
+	grad[1] = -1
+}
+
+func (HS7) Equality() Constraint {
+	return constraint{
+		dim: 2,
+		len: 1,
+		fn: func(x, dst []float64) {
+			a := 1 + x[0]*x[0]
+			dst[0] = a*a + x[1]*x[1] - 4
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, 4*x[0]*(1+x[0]*x[0]))
+			jac.Set(0, 1, 2*x[1])
+		},
+	}
+}
+
+func (HS7) Inequality() Constraint {
+	return nil
+}
+
+func (HS7) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:  Minimum{X: []float64{0, math.Sqrt(3)}, F: -math.Sqrt(3), Global: true},
+			Equality: []float64{1 / (2 * math.Sqrt(3))},
+		},
+	}
+}
+
+// HS12 implements the problem 12 of Hock and Schittkowski,
+//  minimize x_1^2/2 + x_2^2 - x_1 x_2 - 7 x_1 - 7 x_2
+//  subject to 4 x_1^2 + x_2^2 - 25 <= 0.
+//
+// Standard starting point:
+//  [0, 0]
+type HS12 struct{}
+
+func (HS12) Func(x []float64) float64 {
+	checkHS(x, nil, 2)
+	return x[0]*x[0]/2 + x[1]*x[1] - x[0]*x[1] - 7*x[0] - 7*x[1]
+}
+
+func (HS12) Grad(x, grad []float64) {
+	checkHS(x, grad, 2)
+	grad[0] = x[0] - x[1] - 7
+	grad[1] = 2*x[1] - x[0] - 7
+}
+
+func (HS12) Equality() Constraint {
+	return nil
+}
+
+func (HS12) Inequality() Constraint {
+	return constraint{
+		dim: 2,
+		len: 1,
+		fn: func(x, dst []float64) {
+			dst[0] = 4*x[0]*x[0] + x[1]*x[1] - 25
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, 8*x[0])
+			jac.Set(0, 1, 2*x[1])
+		},
+	}
+}
+
+func (HS12) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:    Minimum{X: []float64{2, 3}, F: -30, Global: true},
+			Inequality: []float64{0.5},
+		},
+	}
+}
+
+// HS26 implements the problem 26 of Hock and Schittkowski,
+//  minimize (x_1 - x_2)^2 + (x_2 - x_3)^4
+//  subject to (1 + x_2^2) x_1 + x_3^4 - 3 = 0.
+//
+// Standard starting point:
+//  [-2.6, 2, 2]
+type HS26 struct{}
+
+func (HS26) Func(x []float64) float64 {
+	checkHS(x, nil, 3)
+	a := x[0] - x[1]
+	b := x[1] - x[2]
+	return a*a + b*b*b*b
+}
+
+func (HS26) Grad(x, grad []float64) {
+	checkHS(x, grad, 3)
+	a := x[0] - x[1]
+	b := x[1] - x[2]
+	grad[0] = 2 * a
+	grad[1] = -2*a + 4*b*b*b
+	grad[2] = -4 * b * b * b
+}
+
+func (HS26) Equality() Constraint {
+	return constraint{
+		dim: 3,
+		len: 1,
+		fn: func(x, dst []float64) {
+			dst[0] = (1+x[1]*x[1])*x[0] + x[2]*x[2]*x[2]*x[2] - 3
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, 1+x[1]*x[1])
+			jac.Set(0, 1, 2*x[0]*x[1])
+			jac.Set(0, 2, 4*x[2]*x[2]*x[2])
+		},
+	}
+}
+
+func (HS26) Inequality() Constraint {
+	return nil
+}
+
+func (HS26) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:  Minimum{X: []float64{1, 1, 1}, F: 0, Global: true},
+			Equality: []float64{0},
+		},
+	}
+}
+
+// HS27 implements the problem 27 of Hock and Schittkowski,
+//  minimize 0.01 (x_1 - 1)^2 + (x_2 - x_1^2)^2
+//  subject to x_1 + x_3^2 + 1 = 0.
+//
+// Standard starting point:
+//  [2, 2, 2]
+type HS27 struct{}
+
+func (HS27) Func(x []float64) float64 {
+	checkHS(x, nil, 3)
+	a := x[0] - 1
+	b := x[1] - x[0]*x[0]
+	return 0.01*a*a + b*b
+}
+
+func (HS27) Grad(x, grad []float64) {
+	checkHS(x, grad, 3)
+	b := x[1] - x[0]*x[0]
+	grad[0] = 0.02*(x[0]-1) - 4*x[0]*b
+	grad[1] = 2 * b
+	grad[2] = 0
+}
+
+func (HS27) Equality() Constraint {
+	return constraint{
+		dim: 3,
+		len: 1,
+		fn: func(x, dst []float64) {
+			dst[0] = x[0] + x[2]*x[2] + 1
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, 1)
+			jac.Set(0, 1, 0)
+			jac.Set(0, 2, 2*x[2])
+		},
+	}
+}
+
+func (HS27) Inequality() Constraint {
+	return nil
+}
+
+func (HS27) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:  Minimum{X: []float64{-1, 1, 0}, F: 0.04, Global: true},
+			Equality: []float64{0.04},
+		},
+	}
+}
+
+// HS28 implements the problem 28 of Hock and Schittkowski,
+//  minimize (x_1 + x_2)^2 + (x_2 + x_3)^2
+//  subject to x_1 + 2 x_2 + 3 x_3 - 1 = 0.
+//
+// Standard starting point:
+//  [-4, 1, 1]
+type HS28 struct{}
+
+func (HS28) Func(x []float64) float64 {
+	checkHS(x, nil, 3)
+	a := x[0] + x[1]
+	b := x[1] + x[2]
+	return a*a + b*b
+}
+
+func (HS28) Grad(x, grad []float64) {
+	checkHS(x, grad, 3)
+	a := x[0] + x[1]
+	b := x[1] + x[2]
+	grad[0] = 2 * a
+	grad[1] = 2 * (a + b)
+	grad[2] = 2 * b
+}
+
+func (HS28) Equality() Constraint {
+	return constraint{
+		dim: 3,
+		len: 1,
+		fn: func(x, dst []float64) {
+			dst[0] = x[0] + 2*x[1] + 3*x[2] - 1
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, 1)
+			jac.Set(0, 1, 2)
+			jac.Set(0, 2, 3)
+		},
+	}
+}
+
+func (HS28) Inequality() Constraint {
+	return nil
+}
+
+func (HS28) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:  Minimum{X: []float64{0.5, -0.5, 0.5}, F: 0, Global: true},
+			Equality: []float64{0},
+		},
+	}
+}
+
+// HS39 implements the problem 39 of Hock and Schittkowski,
+//  minimize -x_1
+//  subject to x_2 - x_1^3 - x_3^2 = 0,
+//             x_1^2 - x_2 - x_4^2 = 0.
+//
+// Standard starting point:
+//  [2, 2, 2, 2]
+type HS39 struct{}
+
+func (HS39) Func(x []float64) float64 {
+	checkHS(x, nil, 4)
+	return -x[0]
+}
+
+func (HS39) Grad(x, grad []float64) {
+	checkHS(x, grad, 4)
+	grad[0] = -1
+	grad[1] = 0
+	grad[2] = 0
+	grad[3] = 0
+}
+
+func (HS39) Equality() Constraint {
+	return constraint{
+		dim: 4,
+		len: 2,
+		fn: func(x, dst []float64) {
+			dst[0] = x[1] - x[0]*x[0]*x[0] - x[2]*x[2]
+			dst[1] = x[0]*x[0] - x[1] - x[3]*x[3]
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, -3*x[0]*x[0])
+			jac.Set(0, 1, 1)
+			jac.Set(0, 2, -2*x[2])
+			jac.Set(0, 3, 0)
+			jac.Set(1, 0, 2*x[0])
+			jac.Set(1, 1, -1)
+			jac.Set(1, 2, 0)
+			jac.Set(1, 3, -2*x[3])
+		},
+	}
+}
+
+func (HS39) Inequality() Constraint {
+	return nil
+}
+
+func (HS39) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:  Minimum{X: []float64{1, 1, 0, 0}, F: -1, Global: true},
+			Equality: []float64{-1, -1},
+		},
+	}
+}
+
+// HS43 implements the problem 43 of Hock and Schittkowski, the Rosen-Suzuki
+// problem,
+//  minimize x_1^2 + x_2^2 + 2 x_3^2 + x_4^2 - 5 x_1 - 5 x_2 - 21 x_3 + 7 x_4
+//  subject to x_1^2 + x_2^2 + x_3^2 + x_4^2 + x_1 - x_2 + x_3 - x_4 - 8 <= 0,
+//             x_1^2 + 2 x_2^2 + x_3^2 + 2 x_4^2 - x_1 - x_4 - 10 <= 0,
+//             2 x_1^2 + x_2^2 + x_3^2 + 2 x_1 - x_2 - x_4 - 5 <= 0.
+//
+// Standard starting point:
+//  [0, 0, 0, 0]
+type HS43 struct{}
+
+func (HS43) Func(x []float64) float64 {
+	checkHS(x, nil, 4)
+	return x[0]*x[0] + x[1]*x[1] + 2*x[2]*x[2] + x[3]*x[3] -
+		5*x[0] - 5*x[1] - 21*x[2] + 7*x[3]
+}
+
+func (HS43) Grad(x, grad []float64) {
+	checkHS(x, grad, 4)
+	grad[0] = 2*x[0] - 5
+	grad[1] = 2*x[1] - 5
+	grad[2] = 4*x[2] - 21
+	grad[3] = 2*x[3] + 7
+}
+
+func (HS43) Equality() Constraint {
+	return nil
+}
+
+func (HS43) Inequality() Constraint {
+	return constraint{
+		dim: 4,
+		len: 3,
+		fn: func(x, dst []float64) {
+			dst[0] = x[0]*x[0] + x[1]*x[1] + x[2]*x[2] + x[3]*x[3] + x[0] - x[1] + x[2] - x[3] - 8
+			dst[1] = x[0]*x[0] + 2*x[1]*x[1] + x[2]*x[2] + 2*x[3]*x[3] - x[0] - x[3] - 10
+			dst[2] = 2*x[0]*x[0] + x[1]*x[1] + x[2]*x[2] + 2*x[0] - x[1] - x[3] - 5
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, 2*x[0]+1)
+			jac.Set(0, 1, 2*x[1]-1)
+			jac.Set(0, 2, 2*x[2]+1)
+			jac.Set(0, 3, 2*x[3]-1)
+			jac.Set(1, 0, 2*x[0]-1)
+			jac.Set(1, 1, 4*x[1])
+			jac.Set(1, 2, 2*x[2])
+			jac.Set(1, 3, 4*x[3]-1)
+			jac.Set(2, 0, 4*x[0]+2)
+			jac.Set(2, 1, 2*x[1]-1)
+			jac.Set(2, 2, 2*x[2])
+			jac.Set(2, 3, -1)
+		},
+	}
+}
+
+func (HS43) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:    Minimum{X: []float64{0, 1, 2, -1}, F: -44, Global: true},
+			Inequality: []float64{1, 0, 2},
+		},
+	}
+}
+
+// HS48 implements the problem 48 of Hock and Schittkowski,
+//  minimize (x_1 - 1)^2 + (x_2 - x_3)^2 + (x_4 - x_5)^2
+//  subject to x_1 + x_2 + x_3 + x_4 + x_5 - 5 = 0,
+//             x_3 - 2 (x_4 + x_5) + 3 = 0.
+//
+// Standard starting point:
+//  [3, 5, -3, 2, -2]
+type HS48 struct{}
+
+func (HS48) Func(x []float64) float64 {
+	checkHS(x, nil, 5)
+	a := x[0] - 1
+	b := x[1] - x[2]
+	c := x[3] - x[4]
+	return a*a + b*b + c*c
+}
+
+func (HS48) Grad(x, grad []float64) {
+	checkHS(x, grad, 5)
+	grad[0] = 2 * (x[0] - 1)
+	grad[1] = 2 * (x[1] - x[2])
+	grad[2] = -grad[1]
+	grad[3] = 2 * (x[3] - x[4])
+	grad[4] = -grad[3]
+}
+
+func (HS48) Equality() Constraint {
+	return constraint{
+		dim: 5,
+		len: 2,
+		fn: func(x, dst []float64) {
+			dst[0] = x[0] + x[1] + x[2] + x[3] + x[4] - 5
+			dst[1] = x[2] - 2*(x[3]+x[4]) + 3
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			for j := 0; j < 5; j++ {
+				jac.Set(0, j, 1)
+			}
+			jac.Set(1, 0, 0)
+			jac.Set(1, 1, 0)
+			jac.Set(1, 2, 1)
+			jac.Set(1, 3, -2)
+			jac.Set(1, 4, -2)
+		},
+	}
+}
+
+func (HS48) Inequality() Constraint {
+	return nil
+}
+
+func (HS48) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum:  Minimum{X: []float64{1, 1, 1, 1, 1}, F: 0, Global: true},
+			Equality: []float64{0, 0},
+		},
+	}
+}
+
+// HS100 implements the problem 100 of Hock and Schittkowski,
+//  minimize (x_1 - 10)^2 + 5 (x_2 - 12)^2 + x_3^4 + 3 (x_4 - 11)^2 + 10 x_5^6
+//           + 7 x_6^2 + x_7^4 - 4 x_6 x_7 - 10 x_6 - 8 x_7
+//  subject to 2 x_1^2 + 3 x_2^4 + x_3 + 4 x_4^2 + 5 x_5 - 127 <= 0,
+//             7 x_1 + 3 x_2 + 10 x_3^2 + x_4 - x_5 - 282 <= 0,
+//             23 x_1 + x_2^2 + 6 x_6^2 - 8 x_7 - 196 <= 0,
+//             4 x_1^2 + x_2^2 - 3 x_1 x_2 + 2 x_3^2 + 5 x_6 - 11 x_7 <= 0.
+//
+// Standard starting point:
+//  [1, 2, 0, 4, 0, 1, 1]
+type HS100 struct{}
+
+func (HS100) Func(x []float64) float64 {
+	checkHS(x, nil, 7)
+	a := x[0] - 10
+	b := x[1] - 12
+	c := x[3] - 11
+	x3 := x[2] * x[2]
+	x5 := x[4] * x[4] * x[4]
+	x7 := x[6] * x[6]
+	return a*a + 5*b*b + x3*x3 + 3*c*c + 10*x5*x5 + 7*x[5]*x[5] + x7*x7 -
+		4*x[5]*x[6] - 10*x[5] - 8*x[6]
+}
+
+func (HS100) Grad(x, grad []float64) {
+	checkHS(x, grad, 7)
+	grad[0] = 2 * (x[0] - 10)
+	grad[1] = 10 * (x[1] - 12)
+	grad[2] = 4 * x[2] * x[2] * x[2]
+	grad[3] = 6 * (x[3] - 11)
+	grad[4] = 60 * math.Pow(x[4], 5)
+	grad[5] = 14*x[5] - 4*x[6] - 10
+	grad[6] = 4*x[6]*x[6]*x[6] - 4*x[5] - 8
+}
+
+func (HS100) Equality() Constraint {
+	return nil
+}
+
+func (HS100) Inequality() Constraint {
+	return constraint{
+		dim: 7,
+		len: 4,
+		fn: func(x, dst []float64) {
+			x2 := x[1] * x[1]
+			dst[0] = 2*x[0]*x[0] + 3*x2*x2 + x[2] + 4*x[3]*x[3] + 5*x[4] - 127
+			dst[1] = 7*x[0] + 3*x[1] + 10*x[2]*x[2] + x[3] - x[4] - 282
+			dst[2] = 23*x[0] + x2 + 6*x[5]*x[5] - 8*x[6] - 196
+			dst[3] = 4*x[0]*x[0] + x2 - 3*x[0]*x[1] + 2*x[2]*x[2] + 5*x[5] - 11*x[6]
+		},
+		jac: func(x []float64, jac *mat64.Dense) {
+			for i := 0; i < 4; i++ {
+				for j := 0; j < 7; j++ {
+					jac.Set(i, j, 0)
+				}
+			}
+			jac.Set(0, 0, 4*x[0])
+			jac.Set(0, 1, 12*x[1]*x[1]*x[1])
+			jac.Set(0, 2, 1)
+			jac.Set(0, 3, 8*x[3])
+			jac.Set(0, 4, 5)
+			jac.Set(1, 0, 7)
+			jac.Set(1, 1, 3)
+			jac.Set(1, 2, 20*x[2])
+			jac.Set(1, 3, 1)
+			jac.Set(1, 4, -1)
+			jac.Set(2, 0, 23)
+			jac.Set(2, 1, 2*x[1])
+			jac.Set(2, 5, 12*x[5])
+			jac.Set(2, 6, -8)
+			jac.Set(3, 0, 8*x[0]-3*x[1])
+			jac.Set(3, 1, 2*x[1]-3*x[0])
+			jac.Set(3, 2, 4*x[2])
+			jac.Set(3, 5, 5)
+			jac.Set(3, 6, -11)
+		},
+	}
+}
+
+func (HS100) KKTPoints() []KKTPoint {
+	return []KKTPoint{
+		{
+			Minimum: Minimum{
+				X: []float64{2.33049937287957, 1.9513723728968888, -0.47754139238887167,
+					4.36572623365581, -0.6244869705268175, 1.0381310186079584,
+					1.5942267116118685},
+				F:      680.63005737440199,
+				Global: true,
+			},
+			Inequality: []float64{1.1397199591673761, 0, 0, 0.3686145171872113},
+		},
+	}
+}
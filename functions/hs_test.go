@@ -0,0 +1,105 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/diff/fd"
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestHockSchittkowski(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		f    ConstrainedFunction
+		x    []float64 // Standard starting point.
+	}{
+		{"HS6", HS6{}, []float64{-1.2, 1}},
+		{"HS7", HS7{}, []float64{2, 2}},
+		{"HS12", HS12{}, []float64{0, 0}},
+		{"HS26", HS26{}, []float64{-2.6, 2, 2}},
+		{"HS27", HS27{}, []float64{2, 2, 2}},
+		{"HS28", HS28{}, []float64{-4, 1, 1}},
+		{"HS39", HS39{}, []float64{2, 2, 2, 2}},
+		{"HS43", HS43{}, []float64{0, 0, 0, 0}},
+		{"HS48", HS48{}, []float64{3, 5, -3, 2, -2}},
+		{"HS100", HS100{}, []float64{1, 2, 0, 4, 0, 1, 1}},
+	} {
+		// Check the derivatives at the starting point.
+		grad := make([]float64, len(test.x))
+		test.f.Grad(test.x, grad)
+		fdGrad := fd.Gradient(nil, test.f.Func, test.x, nil)
+		if !floats.EqualApprox(grad, fdGrad, 1e-5) {
+			t.Errorf("%s: gradient does not match finite differences. Want: %v, Got: %v", test.name, fdGrad, grad)
+		}
+		for _, c := range []Constraint{test.f.Equality(), test.f.Inequality()} {
+			if c == nil {
+				continue
+			}
+			jac := mat64.NewDense(c.Len(), len(test.x), nil)
+			c.Jac(test.x, jac)
+			for i := 0; i < c.Len(); i++ {
+				ci := func(x []float64) float64 {
+					dst := make([]float64, c.Len())
+					c.Func(x, dst)
+					return dst[i]
+				}
+				fdRow := fd.Gradient(nil, ci, test.x, nil)
+				row := make([]float64, len(test.x))
+				jac.Row(row, i)
+				if !floats.EqualApprox(row, fdRow, 1e-5) {
+					t.Errorf("%s: row %d of the Jacobian does not match finite differences. Want: %v, Got: %v",
+						test.name, i, fdRow, row)
+				}
+			}
+		}
+
+		// Check the KKT conditions at the known solutions.
+		for k, p := range test.f.KKTPoints() {
+			if f := test.f.Func(p.X); math.Abs(f-p.F) > 1e-12 {
+				t.Errorf("%s: function value at solution %d is incorrect. Want: %v, Got: %v", test.name, k, p.F, f)
+			}
+			stat := make([]float64, len(p.X))
+			test.f.Grad(p.X, stat)
+			check := func(c Constraint, mult []float64, ineq bool) {
+				if c == nil {
+					if mult != nil {
+						t.Errorf("%s: multipliers of missing constraints", test.name)
+					}
+					return
+				}
+				if len(mult) != c.Len() {
+					t.Errorf("%s: incorrect number of multipliers", test.name)
+					return
+				}
+				val := make([]float64, c.Len())
+				c.Func(p.X, val)
+				jac := mat64.NewDense(c.Len(), len(p.X), nil)
+				c.Jac(p.X, jac)
+				for i, v := range val {
+					switch {
+					case !ineq && math.Abs(v) > 1e-12:
+						t.Errorf("%s: equality constraint %d violated at solution %d: %v", test.name, i, k, v)
+					case ineq && v > 1e-12:
+						t.Errorf("%s: inequality constraint %d violated at solution %d: %v", test.name, i, k, v)
+					case ineq && (mult[i] < 0 || math.Abs(mult[i]*v) > 1e-12):
+						t.Errorf("%s: complementarity violated for constraint %d at solution %d", test.name, i, k)
+					}
+					for j := range stat {
+						stat[j] += mult[i] * jac.At(i, j)
+					}
+				}
+			}
+			check(test.f.Equality(), p.Equality, false)
+			check(test.f.Inequality(), p.Inequality, true)
+			if floats.Norm(stat, math.Inf(1)) > 1e-9 {
+				t.Errorf("%s: stationarity violated at solution %d: %v", test.name, k, stat)
+			}
+		}
+	}
+}
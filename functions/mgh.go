@@ -0,0 +1,1132 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// This file contains the functions of the Moré-Garbow-Hillstrom collection
+// of unconstrained test problems that are not in functions.go. All of them
+// are sums of squares
+//  f(x) = Σ_i f_i(x)^2
+// of m residuals f_i, and their gradients are computed from the Jacobian of
+// the residuals.
+
+// leastSquares is a function given by the sum of squares of residuals.
+type leastSquares interface {
+	// residuals returns the number of residuals for a problem of dimension
+	// n. It panics if the dimension is not valid.
+	residuals(n int) int
+	// eval stores the residuals at x into r and, if jac is not nil, their
+	// Jacobian into jac.
+	eval(r []float64, jac *mat64.Dense, x []float64)
+}
+
+// lsFuncGrad returns the value of ls at x and, if grad is not nil, stores the
+// gradient into grad.
+func lsFuncGrad(ls leastSquares, x, grad []float64) float64 {
+	m := ls.residuals(len(x))
+	if grad != nil && len(grad) != len(x) {
+		panic("incorrect size of the gradient")
+	}
+	r := make([]float64, m)
+	var jac *mat64.Dense
+	if grad != nil {
+		jac = mat64.NewDense(m, len(x), nil)
+	}
+	ls.eval(r, jac, x)
+	if grad != nil {
+		for j := range grad {
+			var s float64
+			for i, ri := range r {
+				s += jac.At(i, j) * ri
+			}
+			grad[j] = 2 * s
+		}
+	}
+	return floats.Dot(r, r)
+}
+
+// FreudensteinRoth implements the Freudenstein-Roth function.
+//
+// Standard starting point:
+//  [0.5, -2]
+//
+// References:
+//  - Freudenstein, F., Roth, B.: Numerical solution of systems of nonlinear
+//    equations. J ACM 10 (1963), 550-556
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type FreudensteinRoth struct{}
+
+func (f FreudensteinRoth) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f FreudensteinRoth) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f FreudensteinRoth) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (FreudensteinRoth) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{5, 4},
+			F:      0,
+			Global: true,
+		},
+		{
+			X:      []float64{11.412778986902097, -0.8968052532744764},
+			F:      48.984253679240027,
+			Global: false,
+		},
+	}
+}
+
+func (FreudensteinRoth) residuals(n int) int {
+	if n != 2 {
+		panic("dimension of the problem must be 2")
+	}
+	return 2
+}
+
+func (FreudensteinRoth) eval(r []float64, jac *mat64.Dense, x []float64) {
+	r[0] = -13 + x[0] + ((5-x[1])*x[1]-2)*x[1]
+	r[1] = -29 + x[0] + ((x[1]+1)*x[1]-14)*x[1]
+	if jac == nil {
+		return
+	}
+	jac.Set(0, 0, 1)
+	jac.Set(0, 1, (10-3*x[1])*x[1]-2)
+	jac.Set(1, 0, 1)
+	jac.Set(1, 1, (3*x[1]+2)*x[1]-14)
+}
+
+// JennrichSampson implements the Jennrich-Sampson function with ten
+// residuals.
+//
+// Standard starting point:
+//  [0.3, 0.4]
+//
+// References:
+//  - Jennrich, R.I., Sampson, P.F.: Application of stepwise regression to
+//    nonlinear estimation. Technometrics 10 (1968), 63-72
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type JennrichSampson struct{}
+
+func (f JennrichSampson) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f JennrichSampson) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f JennrichSampson) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (JennrichSampson) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{0.2578252136703641, 0.2578252136703641},
+			F:      124.36218235561483,
+			Global: true,
+		},
+	}
+}
+
+func (JennrichSampson) residuals(n int) int {
+	if n != 2 {
+		panic("dimension of the problem must be 2")
+	}
+	return 10
+}
+
+func (JennrichSampson) eval(r []float64, jac *mat64.Dense, x []float64) {
+	for i := range r {
+		t := float64(i + 1)
+		e1 := math.Exp(t * x[0])
+		e2 := math.Exp(t * x[1])
+		r[i] = 2 + 2*t - (e1 + e2)
+		if jac != nil {
+			jac.Set(i, 0, -t*e1)
+			jac.Set(i, 1, -t*e2)
+		}
+	}
+}
+
+// Bard implements the Bard function.
+//
+// Standard starting point:
+//  [1, 1, 1]
+//
+// References:
+//  - Bard, Y.: Comparison of gradient methods for the solution of nonlinear
+//    parameter estimation problems. SIAM J Numer Anal 7 (1970), 157-186
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type Bard struct{}
+
+var bardY = []float64{0.14, 0.18, 0.22, 0.25, 0.29, 0.32, 0.35, 0.39,
+	0.37, 0.58, 0.73, 0.96, 1.34, 2.10, 4.39}
+
+func (f Bard) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f Bard) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f Bard) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (Bard) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{0.08241055974978892, 1.1330360920297216, 2.343695178642537},
+			F:      0.0082148773065789764,
+			Global: true,
+		},
+	}
+}
+
+func (Bard) residuals(n int) int {
+	if n != 3 {
+		panic("dimension of the problem must be 3")
+	}
+	return len(bardY)
+}
+
+func (Bard) eval(r []float64, jac *mat64.Dense, x []float64) {
+	for i, y := range bardY {
+		u := float64(i + 1)
+		v := 16 - u
+		w := math.Min(u, v)
+		d := v*x[1] + w*x[2]
+		r[i] = y - (x[0] + u/d)
+		if jac != nil {
+			jac.Set(i, 0, -1)
+			jac.Set(i, 1, u*v/(d*d))
+			jac.Set(i, 2, u*w/(d*d))
+		}
+	}
+}
+
+// Meyer implements the Meyer function. The function is badly scaled.
+//
+// Standard starting point:
+//  [0.02, 4000, 250]
+//
+// References:
+//  - Meyer, R.R.: Theoretical and computational aspects of nonlinear
+//    regression. In: Rosen, J., Mangasarian, O., Ritter, K. (eds.): Nonlinear
+//    Programming. Academic Press (1970), 465-486
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type Meyer struct{}
+
+var meyerY = []float64{34780, 28610, 23650, 19630, 16370, 13720, 11540, 9744,
+	8261, 7030, 6005, 5147, 4427, 3820, 3307, 2872}
+
+func (f Meyer) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f Meyer) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f Meyer) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (Meyer) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{0.00560963647102772, 6181.346346286422, 345.2236346241382},
+			F:      87.945855171278268,
+			Global: true,
+		},
+	}
+}
+
+func (Meyer) residuals(n int) int {
+	if n != 3 {
+		panic("dimension of the problem must be 3")
+	}
+	return len(meyerY)
+}
+
+func (Meyer) eval(r []float64, jac *mat64.Dense, x []float64) {
+	for i, y := range meyerY {
+		t := 45 + 5*float64(i+1)
+		d := t + x[2]
+		e := math.Exp(x[1] / d)
+		r[i] = x[0]*e - y
+		if jac != nil {
+			jac.Set(i, 0, e)
+			jac.Set(i, 1, x[0]*e/d)
+			jac.Set(i, 2, -x[0]*e*x[1]/(d*d))
+		}
+	}
+}
+
+// KowalikOsborne implements the Kowalik-Osborne function.
+//
+// Standard starting point:
+//  [0.25, 0.39, 0.415, 0.39]
+//
+// References:
+//  - Kowalik, J.S., Osborne, M.R.: Methods for Unconstrained Optimization
+//    Problems. Elsevier (1968)
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type KowalikOsborne struct{}
+
+var (
+	kowalikOsborneY = []float64{0.1957, 0.1947, 0.1735, 0.1600, 0.0844, 0.0627,
+		0.0456, 0.0342, 0.0323, 0.0235, 0.0246}
+	kowalikOsborneU = []float64{4, 2, 1, 0.5, 0.25, 0.167, 0.125, 0.1,
+		0.0833, 0.0714, 0.0625}
+)
+
+func (f KowalikOsborne) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f KowalikOsborne) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f KowalikOsborne) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (KowalikOsborne) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{0.1928069345931097, 0.19128232841830325, 0.1230565068668022, 0.1360623305374589},
+			F:      0.00030750560384923653,
+			Global: true,
+		},
+	}
+}
+
+func (KowalikOsborne) residuals(n int) int {
+	if n != 4 {
+		panic("dimension of the problem must be 4")
+	}
+	return len(kowalikOsborneY)
+}
+
+func (KowalikOsborne) eval(r []float64, jac *mat64.Dense, x []float64) {
+	for i, y := range kowalikOsborneY {
+		u := kowalikOsborneU[i]
+		num := u * (u + x[1])
+		den := u*(u+x[2]) + x[3]
+		r[i] = y - x[0]*num/den
+		if jac != nil {
+			jac.Set(i, 0, -num/den)
+			jac.Set(i, 1, -x[0]*u/den)
+			jac.Set(i, 2, x[0]*num*u/(den*den))
+			jac.Set(i, 3, x[0]*num/(den*den))
+		}
+	}
+}
+
+// OsborneI implements the first Osborne function.
+//
+// Standard starting point:
+//  [0.5, 1.5, -1, 0.01, 0.02]
+//
+// References:
+//  - Osborne, M.R.: Some aspects of nonlinear least squares calculations. In:
+//    Lootsma, F.A. (ed.): Numerical Methods for Nonlinear Optimization.
+//    Academic Press (1972), 171-189
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type OsborneI struct{}
+
+var osborneIY = []float64{0.844, 0.908, 0.932, 0.936, 0.925, 0.908, 0.881,
+	0.850, 0.818, 0.784, 0.751, 0.718, 0.685, 0.658, 0.628, 0.603, 0.580,
+	0.558, 0.538, 0.522, 0.506, 0.490, 0.478, 0.467, 0.457, 0.448, 0.438,
+	0.431, 0.424, 0.420, 0.414, 0.411, 0.406}
+
+func (f OsborneI) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f OsborneI) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f OsborneI) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (OsborneI) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{0.3754100521069523, 1.9358469127123874, -1.4646871366134435, 0.012867534640057332, 0.022122699661672535},
+			F:      5.4648946974829786e-05,
+			Global: true,
+		},
+	}
+}
+
+func (OsborneI) residuals(n int) int {
+	if n != 5 {
+		panic("dimension of the problem must be 5")
+	}
+	return len(osborneIY)
+}
+
+func (OsborneI) eval(r []float64, jac *mat64.Dense, x []float64) {
+	for i, y := range osborneIY {
+		t := 10 * float64(i)
+		e4 := math.Exp(-t * x[3])
+		e5 := math.Exp(-t * x[4])
+		r[i] = y - (x[0] + x[1]*e4 + x[2]*e5)
+		if jac != nil {
+			jac.Set(i, 0, -1)
+			jac.Set(i, 1, -e4)
+			jac.Set(i, 2, -e5)
+			jac.Set(i, 3, t*x[1]*e4)
+			jac.Set(i, 4, t*x[2]*e5)
+		}
+	}
+}
+
+// OsborneII implements the second Osborne function.
+//
+// Standard starting point:
+//  [1.3, 0.65, 0.65, 0.7, 0.6, 3, 5, 7, 2, 4.5, 5.5]
+//
+// References:
+//  - Osborne, M.R.: Some aspects of nonlinear least squares calculations. In:
+//    Lootsma, F.A. (ed.): Numerical Methods for Nonlinear Optimization.
+//    Academic Press (1972), 171-189
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type OsborneII struct{}
+
+var osborneIIY = []float64{1.366, 1.191, 1.112, 1.013, 0.991, 0.885, 0.831,
+	0.847, 0.786, 0.725, 0.746, 0.679, 0.608, 0.655, 0.616, 0.606, 0.602,
+	0.626, 0.651, 0.724, 0.649, 0.649, 0.694, 0.644, 0.624, 0.661, 0.612,
+	0.558, 0.533, 0.495, 0.500, 0.423, 0.395, 0.375, 0.372, 0.391, 0.396,
+	0.405, 0.428, 0.429, 0.523, 0.562, 0.607, 0.653, 0.672, 0.708, 0.633,
+	0.668, 0.645, 0.632, 0.591, 0.559, 0.597, 0.625, 0.739, 0.710, 0.729,
+	0.720, 0.636, 0.581, 0.428, 0.292, 0.162, 0.098, 0.054}
+
+func (f OsborneII) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f OsborneII) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f OsborneII) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (OsborneII) Minima() []Minimum {
+	return []Minimum{
+		{
+			X: []float64{1.3099771546273007, 0.431553794602989, 0.6336616989607241,
+				0.5994305347859165, 0.7541832263280115, 0.9042885798596333,
+				1.3658118352370285, 4.823698817227156, 2.3986848661317546,
+				4.568874597667672, 5.6753414705806415},
+			F:      0.040137736293547804,
+			Global: true,
+		},
+	}
+}
+
+func (OsborneII) residuals(n int) int {
+	if n != 11 {
+		panic("dimension of the problem must be 11")
+	}
+	return len(osborneIIY)
+}
+
+func (OsborneII) eval(r []float64, jac *mat64.Dense, x []float64) {
+	for i, y := range osborneIIY {
+		t := float64(i) / 10
+		e1 := math.Exp(-t * x[4])
+		s := x[0] * e1
+		if jac != nil {
+			jac.Set(i, 0, -e1)
+			jac.Set(i, 4, t*x[0]*e1)
+		}
+		// The three Gaussian terms with the amplitudes x[1:4], the widths
+		// x[5:8] and the centers x[8:11].
+		for k := 0; k < 3; k++ {
+			d := t - x[8+k]
+			e := math.Exp(-d * d * x[5+k])
+			s += x[1+k] * e
+			if jac != nil {
+				jac.Set(i, 1+k, -e)
+				jac.Set(i, 5+k, x[1+k]*d*d*e)
+				jac.Set(i, 8+k, -2*x[1+k]*x[5+k]*d*e)
+			}
+		}
+		r[i] = y - s
+	}
+}
+
+// BrownAlmostLinear implements the Brown almost-linear function.
+//
+// Standard starting point:
+//  [0.5, ..., 0.5]
+//
+// References:
+//  - Brown, K.M.: A quadratically convergent Newton-like method based upon
+//    Gaussian elimination. SIAM J Numer Anal 6 (1969), 560-569
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type BrownAlmostLinear struct{}
+
+func (f BrownAlmostLinear) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f BrownAlmostLinear) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f BrownAlmostLinear) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (BrownAlmostLinear) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{1, 1, 1, 1, 1},
+			F:      0,
+			Global: true,
+		},
+		{
+			X:      []float64{0, 0, 0, 0, 6},
+			F:      1,
+			Global: false,
+		},
+	}
+}
+
+func (BrownAlmostLinear) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (BrownAlmostLinear) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	sum := floats.Sum(x)
+	for i := 0; i < n-1; i++ {
+		r[i] = x[i] + sum - float64(n+1)
+		if jac != nil {
+			for j := 0; j < n; j++ {
+				jac.Set(i, j, 1)
+			}
+			jac.Set(i, i, 2)
+		}
+	}
+	r[n-1] = floats.Prod(x) - 1
+	if jac != nil {
+		for j := 0; j < n; j++ {
+			p := 1.0
+			for k, v := range x {
+				if k != j {
+					p *= v
+				}
+			}
+			jac.Set(n-1, j, p)
+		}
+	}
+}
+
+// DiscreteBoundaryValue implements the discrete boundary value function.
+//
+// Standard starting point:
+//  [t_1 (t_1 - 1), ..., t_n (t_n - 1)], where t_i = i/(n+1)
+//
+// References:
+//  - More, J.J., Cosnard, M.Y.: Numerical solution of nonlinear equations.
+//    ACM Trans Math Softw 5 (1979), 64-85
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type DiscreteBoundaryValue struct{}
+
+func (f DiscreteBoundaryValue) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f DiscreteBoundaryValue) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f DiscreteBoundaryValue) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (DiscreteBoundaryValue) Minima() []Minimum {
+	return []Minimum{
+		{
+			X: []float64{-0.07502212929232047, -0.13197621035219062, -0.16484877190933728,
+				-0.1646646802158007, -0.1174176516841936},
+			F:      0,
+			Global: true,
+		},
+		{
+			X: []float64{-0.04316498251876488, -0.0815771565353869, -0.11448571438052932,
+				-0.1409735768625967, -0.15990869618198314, -0.16987720231277492,
+				-0.16908998378120835, -0.15524953522183182, -0.125355891678935,
+				-0.07541653368589209},
+			F:      0,
+			Global: true,
+		},
+	}
+}
+
+func (DiscreteBoundaryValue) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (DiscreteBoundaryValue) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	h := 1 / float64(n+1)
+	for i, v := range x {
+		t := float64(i+1) * h
+		c := v + t + 1
+		r[i] = 2*v + h*h*c*c*c/2
+		if i > 0 {
+			r[i] -= x[i-1]
+		}
+		if i < n-1 {
+			r[i] -= x[i+1]
+		}
+		if jac != nil {
+			jac.Set(i, i, 2+1.5*h*h*c*c)
+			if i > 0 {
+				jac.Set(i, i-1, -1)
+			}
+			if i < n-1 {
+				jac.Set(i, i+1, -1)
+			}
+		}
+	}
+}
+
+// DiscreteIntegralEquation implements the discrete integral equation
+// function.
+//
+// Standard starting point:
+//  [t_1 (t_1 - 1), ..., t_n (t_n - 1)], where t_i = i/(n+1)
+//
+// References:
+//  - More, J.J., Cosnard, M.Y.: Numerical solution of nonlinear equations.
+//    ACM Trans Math Softw 5 (1979), 64-85
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type DiscreteIntegralEquation struct{}
+
+func (f DiscreteIntegralEquation) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f DiscreteIntegralEquation) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f DiscreteIntegralEquation) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (DiscreteIntegralEquation) Minima() []Minimum {
+	return []Minimum{
+		{
+			X: []float64{-0.07502212929232048, -0.13197621035219062, -0.1648487719093373,
+				-0.16466468021580072, -0.11741765168419363},
+			F:      0,
+			Global: true,
+		},
+		{
+			X: []float64{-0.04316498251876487, -0.08157715653538689, -0.11448571438052925,
+				-0.14097357686259668, -0.15990869618198314, -0.1698772023127749,
+				-0.16908998378120835, -0.1552495352218318, -0.12535589167893496,
+				-0.07541653368589202},
+			F:      0,
+			Global: true,
+		},
+	}
+}
+
+func (DiscreteIntegralEquation) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (DiscreteIntegralEquation) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	h := 1 / float64(n+1)
+	for i, v := range x {
+		ti := float64(i+1) * h
+		r[i] = v
+		if jac != nil {
+			jac.Set(i, i, 1)
+		}
+		for j, w := range x {
+			tj := float64(j+1) * h
+			c := w + tj + 1
+			k := ti * (1 - tj)
+			if j <= i {
+				k = (1 - ti) * tj
+			}
+			r[i] += h * k * c * c * c / 2
+			if jac != nil {
+				jac.Set(i, j, jac.At(i, j)+1.5*h*k*c*c)
+			}
+		}
+	}
+}
+
+// BroydenTridiagonal implements the Broyden tridiagonal function.
+//
+// Standard starting point:
+//  [-1, ..., -1]
+//
+// References:
+//  - Broyden, C.G.: A class of methods for solving nonlinear simultaneous
+//    equations. Math Comput 19 (1965), 577-593
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type BroydenTridiagonal struct{}
+
+func (f BroydenTridiagonal) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f BroydenTridiagonal) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f BroydenTridiagonal) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (BroydenTridiagonal) Minima() []Minimum {
+	return []Minimum{
+		{
+			X: []float64{-0.564828398615079, -0.666273717804693, -0.6609170444367877,
+				-0.5950500473798939, -0.41620110773826097},
+			F:      0,
+			Global: true,
+		},
+		{
+			X: []float64{-0.5707221320112248, -0.681806949984275, -0.7022100760176601,
+				-0.7055106298950804, -0.7049061557287437, -0.7014966070298512,
+				-0.6918893223547983, -0.6657965144058537, -0.5960351090263657,
+				-0.4164122575286933},
+			F:      0,
+			Global: true,
+		},
+	}
+}
+
+func (BroydenTridiagonal) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (BroydenTridiagonal) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	for i, v := range x {
+		r[i] = (3-2*v)*v + 1
+		if i > 0 {
+			r[i] -= x[i-1]
+		}
+		if i < n-1 {
+			r[i] -= 2 * x[i+1]
+		}
+		if jac != nil {
+			jac.Set(i, i, 3-4*v)
+			if i > 0 {
+				jac.Set(i, i-1, -1)
+			}
+			if i < n-1 {
+				jac.Set(i, i+1, -2)
+			}
+		}
+	}
+}
+
+// BroydenBanded implements the Broyden banded function.
+//
+// Standard starting point:
+//  [-1, ..., -1]
+//
+// References:
+//  - Broyden, C.G.: The convergence of an algorithm for solving sparse
+//    nonlinear systems. Math Comput 25 (1971), 285-294
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type BroydenBanded struct{}
+
+func (f BroydenBanded) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f BroydenBanded) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f BroydenBanded) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (BroydenBanded) Minima() []Minimum {
+	return []Minimum{
+		{
+			X: []float64{-0.4283028635883737, -0.47659642447033596, -0.5196524479617339,
+				-0.5581001416140893, -0.5924766997602233, -0.6253598762280751,
+				-0.5920068828405864},
+			F:      0,
+			Global: true,
+		},
+		{
+			X: []float64{-0.4283028635872503, -0.47659642435629024, -0.5196524636468617,
+				-0.5580993248321809, -0.5925061568294574, -0.624503682199468,
+				-0.6232394714405911, -0.6213938417965735, -0.6204535966590873,
+				-0.5864692707204351},
+			F:      0,
+			Global: true,
+		},
+	}
+}
+
+func (BroydenBanded) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (BroydenBanded) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	for i, v := range x {
+		r[i] = v*(2+5*v*v) + 1
+		if jac != nil {
+			jac.Set(i, i, 2+15*v*v)
+		}
+		// The band contains the five preceding and the following element.
+		for j := max(0, i-5); j <= min(n-1, i+1); j++ {
+			if j == i {
+				continue
+			}
+			r[i] -= x[j] * (1 + x[j])
+			if jac != nil {
+				jac.Set(i, j, -(1 + 2*x[j]))
+			}
+		}
+	}
+}
+
+// LinearFullRank implements the linear function of full rank with m = n
+// residuals.
+//
+// Standard starting point:
+//  [1, ..., 1]
+//
+// References:
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type LinearFullRank struct{}
+
+func (f LinearFullRank) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f LinearFullRank) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f LinearFullRank) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (LinearFullRank) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{-1, -1, -1, -1, -1},
+			F:      0,
+			Global: true,
+		},
+		{
+			X:      []float64{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+			F:      0,
+			Global: true,
+		},
+	}
+}
+
+func (LinearFullRank) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (LinearFullRank) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	s := 2 * floats.Sum(x) / float64(n)
+	for i, v := range x {
+		r[i] = v - s - 1
+		if jac != nil {
+			for j := 0; j < n; j++ {
+				jac.Set(i, j, -2/float64(n))
+			}
+			jac.Set(i, i, 1-2/float64(n))
+		}
+	}
+}
+
+// LinearRank1 implements the linear function of rank 1 with m = n residuals.
+// The minimum is attained on a hyperplane.
+//
+// Standard starting point:
+//  [1, ..., 1]
+//
+// References:
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type LinearRank1 struct{}
+
+func (f LinearRank1) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f LinearRank1) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f LinearRank1) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (LinearRank1) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{1.0 / 30, 1.0 / 30, 1.0 / 30, 1.0 / 30},
+			F:      2.0 / 3,
+			Global: true,
+		},
+		{
+			X: []float64{1.0 / 385, 1.0 / 385, 1.0 / 385, 1.0 / 385, 1.0 / 385,
+				1.0 / 385, 1.0 / 385, 1.0 / 385, 1.0 / 385, 1.0 / 385},
+			F:      15.0 / 7,
+			Global: true,
+		},
+	}
+}
+
+func (LinearRank1) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (LinearRank1) eval(r []float64, jac *mat64.Dense, x []float64) {
+	var s float64
+	for j, v := range x {
+		s += float64(j+1) * v
+	}
+	for i := range r {
+		r[i] = float64(i+1)*s - 1
+		if jac != nil {
+			for j := range x {
+				jac.Set(i, j, float64((i+1)*(j+1)))
+			}
+		}
+	}
+}
+
+// LinearRank1ZeroColumnsAndRows implements the linear function of rank 1 with
+// zero columns and rows with m = n residuals. The minimum is attained on a
+// hyperplane.
+//
+// Standard starting point:
+//  [1, ..., 1]
+//
+// References:
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type LinearRank1ZeroColumnsAndRows struct{}
+
+func (f LinearRank1ZeroColumnsAndRows) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f LinearRank1ZeroColumnsAndRows) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f LinearRank1ZeroColumnsAndRows) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (LinearRank1ZeroColumnsAndRows) Minima() []Minimum {
+	return []Minimum{
+		{
+			X:      []float64{0, 0.12, 0.12, 0},
+			F:      2.2,
+			Global: true,
+		},
+		{
+			X: []float64{0, 3.0 / 748, 3.0 / 748, 3.0 / 748, 3.0 / 748,
+				3.0 / 748, 3.0 / 748, 3.0 / 748, 3.0 / 748, 0},
+			F:      62.0 / 17,
+			Global: true,
+		},
+	}
+}
+
+func (LinearRank1ZeroColumnsAndRows) residuals(n int) int {
+	if n < 3 {
+		panic("dimension of the problem must be at least 3")
+	}
+	return n
+}
+
+func (LinearRank1ZeroColumnsAndRows) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	var s float64
+	for j := 1; j < n-1; j++ {
+		s += float64(j+1) * x[j]
+	}
+	for i := range r {
+		if i == 0 || i == n-1 {
+			r[i] = -1
+			continue
+		}
+		r[i] = float64(i)*s - 1
+		if jac != nil {
+			for j := 1; j < n-1; j++ {
+				jac.Set(i, j, float64(i*(j+1)))
+			}
+		}
+	}
+}
+
+// Chebyquad implements the Chebyquad function with m = n residuals, which
+// measure the error of the equal-weight quadrature rule with the nodes x for
+// the shifted Chebyshev polynomials on [0, 1].
+//
+// Standard starting point:
+//  [1/(n+1), ..., n/(n+1)]
+//
+// References:
+//  - Fletcher, R.: Function minimization without evaluating derivatives - a
+//    review. Comput J 8 (1965), 33-41
+//  - More, J., Garbow, B.S., Hillstrom, K.E.: Testing unconstrained
+//    optimization software. ACM Trans Math Softw 7 (1981), 17-41
+type Chebyquad struct{}
+
+func (f Chebyquad) Func(x []float64) float64 {
+	return lsFuncGrad(f, x, nil)
+}
+
+func (f Chebyquad) Grad(x, grad []float64) {
+	lsFuncGrad(f, x, grad)
+}
+
+func (f Chebyquad) FuncGrad(x, grad []float64) float64 {
+	return lsFuncGrad(f, x, grad)
+}
+
+func (Chebyquad) Minima() []Minimum {
+	return []Minimum{
+		{
+			X: []float64{0.08375125649950906, 0.3127292952232094, 0.5,
+				0.6872707047767905, 0.9162487435004909},
+			F:      0,
+			Global: true,
+		},
+		{
+			X: []float64{0.04315276015101664, 0.19309084038418617, 0.26632870689027294,
+				0.5, 0.5, 0.7336712931097271, 0.8069091596158139, 0.9568472398489833},
+			F:      0.0035168737256779277,
+			Global: true,
+		},
+	}
+}
+
+func (Chebyquad) residuals(n int) int {
+	if n < 1 {
+		panic("dimension of the problem must be positive")
+	}
+	return n
+}
+
+func (Chebyquad) eval(r []float64, jac *mat64.Dense, x []float64) {
+	n := len(x)
+	m := len(r)
+	for i := range r {
+		// The integral of the shifted Chebyshev polynomial of degree i+1.
+		r[i] = 0
+		if d := i + 1; d%2 == 0 {
+			r[i] = 1 / float64(d*d-1)
+		}
+	}
+	for j, v := range x {
+		// Evaluate the polynomials and their derivatives at v by the
+		// three-term recurrence.
+		y := 2*v - 1
+		t0, t1 := 1.0, y
+		d0, d1 := 0.0, 2.0
+		for i := 0; i < m; i++ {
+			r[i] += t1 / float64(n)
+			if jac != nil {
+				jac.Set(i, j, d1/float64(n))
+			}
+			t0, t1 = t1, 2*y*t1-t0
+			d0, d1 = d1, 4*t0+2*y*d1-d0
+		}
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
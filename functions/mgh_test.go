@@ -0,0 +1,224 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFreudensteinRoth(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.5, -2},
+			F:        400.5,
+			Gradient: []float64{30, -1272},
+		},
+	}
+	Test(FreudensteinRoth{}, tests, t)
+}
+
+func TestJennrichSampson(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.3, 0.4},
+			F:        4171.306161960493,
+			Gradient: []float64{33796.55882384698, 87402.1466703449},
+		},
+	}
+	Test(JennrichSampson{}, tests, t)
+}
+
+func TestBard(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{1, 1, 1},
+			F:        41.68169586167801,
+			Gradient: []float64{43.76571428571428, -51.87123752834467, -50.55998752834468},
+		},
+	}
+	Test(Bard{}, tests, t)
+}
+
+// meyerNoMinima hides the minimum of Meyer from Test. The function is so
+// badly scaled that its gradient at the minimum cannot be evaluated to zero
+// in floating point.
+type meyerNoMinima struct{}
+
+func (meyerNoMinima) Func(x []float64) float64 {
+	return Meyer{}.Func(x)
+}
+
+func (meyerNoMinima) Grad(x, grad []float64) {
+	Meyer{}.Grad(x, grad)
+}
+
+func TestMeyer(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{0.02, 4000, 250},
+			F:        1.6936078094361455e+09,
+			Gradient: []float64{-8.7276662983667e+10, -5.619363134236187e+06, 7.247907705414926e+07},
+		},
+	}
+	Test(meyerNoMinima{}, tests, t)
+
+	m := Meyer{}.Minima()[0]
+	if f := (Meyer{}).Func(m.X); math.Abs(f-m.F) > 1e-12*math.Abs(m.F) {
+		t.Errorf("Meyer: function value at the minimum is incorrect. Want: %v, Got: %v", m.F, f)
+	}
+	// The gradient is compared with the gradient at the starting point.
+	grad := make([]float64, 3)
+	Meyer{}.Grad(m.X, grad)
+	for _, v := range grad {
+		if math.Abs(v) > 1e-12*8.7276662983667e+10 {
+			t.Errorf("Meyer: gradient at the minimum is not zero. Got: %v", grad)
+			break
+		}
+	}
+}
+
+func TestKowalikOsborne(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X: []float64{0.25, 0.39, 0.415, 0.39},
+			F: 0.00531317227210854,
+			Gradient: []float64{0.13357645325189554, -0.0007475349551313914,
+				-0.009005561577392445, 0.011135535073328495},
+		},
+	}
+	Test(KowalikOsborne{}, tests, t)
+}
+
+func TestOsborneI(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X: []float64{0.5, 1.5, -1, 0.01, 0.02},
+			F: 0.8790262935446405,
+			Gradient: []float64{10.709952367202987, 3.064645176078916,
+				1.5810647869019359, -411.65596667741596, 76.2617360323789},
+		},
+	}
+	Test(OsborneI{}, tests, t)
+}
+
+func TestOsborneII(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X: []float64{1.3, 0.65, 0.65, 0.7, 0.6, 3, 5, 7, 2, 4.5, 5.5},
+			F: 2.093419514212064,
+			Gradient: []float64{1.630376985757048, 4.4861865237074365,
+				-0.9346409291935086, -1.184164015298041, -0.4129027257476785,
+				-0.10057838640297964, 0.15750782205644934, 0.11783204344065797,
+				-2.8188566927276706, 0.04249603754425811, -1.218405428800463},
+		},
+	}
+	Test(OsborneII{}, tests, t)
+}
+
+func TestBrownAlmostLinear(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X: []float64{0.5, 0.5, 0.5, 0.5, 0.5},
+			F: 36.9384765625,
+			Gradient: []float64{-30.12109375, -30.12109375, -30.12109375,
+				-30.12109375, -24.12109375},
+		},
+	}
+	Test(BrownAlmostLinear{}, tests, t)
+}
+
+func TestDiscreteBoundaryValue(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X: []float64{-0.1388888888888889, -0.22222222222222224, -0.25,
+				-0.22222222222222224, -0.13888888888888887},
+			F: 0.004111057211949791,
+			Gradient: []float64{-0.09246314485819732, -0.011958577990470093,
+				-0.01701304513204177, -0.024345065860243216, 0.07832620364559373},
+		},
+	}
+	Test(DiscreteBoundaryValue{}, tests, t)
+}
+
+func TestDiscreteIntegralEquation(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X: []float64{-0.1388888888888889, -0.22222222222222224, -0.25,
+				-0.22222222222222224, -0.13888888888888887},
+			F: 0.0358886191752409,
+			Gradient: []float64{-0.16901971804547178, -0.255909243100363,
+				-0.2662219769621697, -0.2101137250928764, -0.10763704802672089},
+		},
+	}
+	Test(DiscreteIntegralEquation{}, tests, t)
+}
+
+func TestBroydenTridiagonal(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{-1, -1, -1, -1, -1},
+			F:        16,
+			Gradient: []float64{-26, -4, -8, -4, -38},
+		},
+	}
+	Test(BroydenTridiagonal{}, tests, t)
+}
+
+func TestBroydenBanded(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{-1, -1, -1, -1, -1, -1, -1},
+			F:        252,
+			Gradient: []float64{-264, -276, -264, -252, -240, -228, -216},
+		},
+	}
+	Test(BroydenBanded{}, tests, t)
+}
+
+func TestLinearFullRank(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{1, 1, 1, 1, 1},
+			F:        20,
+			Gradient: []float64{4, 4, 4, 4, 4},
+		},
+	}
+	Test(LinearFullRank{}, tests, t)
+}
+
+func TestLinearRank1(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{1, 1, 1, 1},
+			F:        2804,
+			Gradient: []float64{580, 1160, 1740, 2320},
+		},
+	}
+	Test(LinearRank1{}, tests, t)
+}
+
+func TestLinearRank1ZeroColumnsAndRows(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X:        []float64{1, 1, 1, 1},
+			F:        99,
+			Gradient: []float64{0, 88, 132, 0},
+		},
+	}
+	Test(LinearRank1ZeroColumnsAndRows{}, tests, t)
+}
+
+func TestChebyquad(t *testing.T) {
+	tests := []FuncTest{
+		{
+			X: []float64{1.0 / 6, 2.0 / 6, 3.0 / 6, 4.0 / 6, 5.0 / 6},
+			F: 0.05094345374180765,
+			Gradient: []float64{0.43661636945587573, 0.10593507087334242, 0,
+				-0.10593507087334236, -0.43661636945587573},
+		},
+	}
+	Test(Chebyquad{}, tests, t)
+}
@@ -12,14 +12,13 @@ package functions
 
 import (
 	"math"
-	"testing"
 
 	"github.com/gonum/diff/fd"
 	"github.com/gonum/floats"
 )
 
-// function represents an objective function.
-type function interface {
+// Function is an objective function.
+type Function interface {
 	Func(x []float64) float64
 }
 
@@ -34,7 +33,7 @@ type functionGradient interface {
 // minimumer is an objective function that can also provide information about
 // its minima.
 type minimumer interface {
-	function
+	Function
 
 	// Minima returns _known_ minima of the function.
 	Minima() []Minimum
@@ -50,7 +49,8 @@ type Minimum struct {
 	Global bool
 }
 
-type funcTest struct {
+// FuncTest is a test case of Test.
+type FuncTest struct {
 	X []float64
 
 	// F is the expected function value at X.
@@ -59,9 +59,11 @@ type funcTest struct {
 	Gradient []float64
 }
 
-// TODO(vladimir-ch): Decide and implement an exported testing function:
-// func Test(f Function, ??? ) ??? {
-// }
+// Reporter reports the failures of Test. *testing.T and *testing.B
+// implement Reporter.
+type Reporter interface {
+	Errorf(format string, args ...interface{})
+}
 
 const (
 	defaultTol       = 1e-12
@@ -69,11 +71,18 @@ const (
 	defaultFDGradTol = 1e-5
 )
 
-// testFunction checks that the function can evaluate itself (and its gradient)
-// correctly.
-func testFunction(f function, ftests []funcTest, t *testing.T) {
+// Test checks that the function f evaluates its value, and its gradient if
+// it implements Grad(x, grad []float64) or
+// FuncGrad(x, grad []float64) float64, correctly at the locations of the
+// tests and at its known minima, if it implements Minima() []Minimum. The
+// function values are compared with an absolute tolerance, or with a relative
+// tolerance if they are larger than one in magnitude. The expected gradients
+// are also checked against finite differences. Test reports every failure to
+// r, so that it can be used to validate objective functions from tests outside
+// of this package.
+func Test(f Function, ftests []FuncTest, r Reporter) {
 	// Make a copy of tests because we may append to the slice.
-	tests := make([]funcTest, len(ftests))
+	tests := make([]FuncTest, len(ftests))
 	copy(tests, ftests)
 
 	// Get information about the function.
@@ -92,7 +101,7 @@ func testFunction(f function, ftests []funcTest, t *testing.T) {
 					grad[i] = 0
 				}
 			}
-			tests = append(tests, funcTest{
+			tests = append(tests, FuncTest{
 				X:        minimum.X,
 				F:        minimum.F,
 				Gradient: grad,
@@ -104,8 +113,8 @@ func testFunction(f function, ftests []funcTest, t *testing.T) {
 		F := f.Func(test.X)
 
 		// Check that the function value is as expected.
-		if math.Abs(F-test.F) > defaultTol {
-			t.Errorf("Test #%d: function value given by Func() is incorrect. Want: %v, Got: %v",
+		if !floats.EqualWithinAbsOrRel(F, test.F, defaultTol, defaultTol) {
+			r.Errorf("Test #%d: function value given by Func() is incorrect. Want: %v, Got: %v",
 				i, test.F, F)
 		}
 
@@ -119,7 +128,7 @@ func testFunction(f function, ftests []funcTest, t *testing.T) {
 		// Check that the finite difference and expected gradients match.
 		if !floats.EqualApprox(fdGrad, test.Gradient, defaultFDGradTol) {
 			dist := floats.Distance(fdGrad, test.Gradient, math.Inf(1))
-			t.Errorf("Test #%d: numerical and expected gradients do not match. |fdGrad - WantGrad|_∞ = %v",
+			r.Errorf("Test #%d: numerical and expected gradients do not match. |fdGrad - WantGrad|_∞ = %v",
 				i, dist)
 		}
 
@@ -130,7 +139,7 @@ func testFunction(f function, ftests []funcTest, t *testing.T) {
 
 			if !floats.EqualApprox(grad, test.Gradient, defaultGradTol) {
 				dist := floats.Distance(grad, test.Gradient, math.Inf(1))
-				t.Errorf("Test #%d: gradient given by Grad() is incorrect. |grad - WantGrad|_∞ = %v",
+				r.Errorf("Test #%d: gradient given by Grad() is incorrect. |grad - WantGrad|_∞ = %v",
 					i, dist)
 			}
 		}
@@ -141,14 +150,14 @@ func testFunction(f function, ftests []funcTest, t *testing.T) {
 			grad := make([]float64, len(test.Gradient))
 			F := fFunctionGradient.FuncGrad(test.X, grad)
 
-			if math.Abs(F-test.F) > defaultTol {
-				t.Errorf("Test #%d: function value given by FuncGrad() is incorrect. Want: %v, Got: %v",
+			if !floats.EqualWithinAbsOrRel(F, test.F, defaultTol, defaultTol) {
+				r.Errorf("Test #%d: function value given by FuncGrad() is incorrect. Want: %v, Got: %v",
 					i, test.F, F)
 			}
 
 			if !floats.EqualApprox(grad, test.Gradient, defaultGradTol) {
 				dist := floats.Distance(grad, test.Gradient, math.Inf(1))
-				t.Errorf("Test #%d: gradient given by FuncGrad() is incorrect. |grad - WantGrad|_∞ = %v",
+				r.Errorf("Test #%d: gradient given by FuncGrad() is incorrect. |grad - WantGrad|_∞ = %v",
 					i, dist)
 			}
 		}
@@ -0,0 +1,302 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// This file contains wrappers that transform a test function in order to test
+// the robustness of optimization methods to noise, translation, rotation and
+// ill-conditioning. The wrappers can be composed. A wrapper implements
+// Grad(x, grad []float64) if the transformation is smooth and the wrapped
+// function implements Grad, and its Minima are the transformed minima of the
+// wrapped function.
+
+// minimaOf returns the known minima of f, or nil if f has none.
+func minimaOf(f Function) []Minimum {
+	if fm, ok := f.(minimumer); ok {
+		return fm.Minima()
+	}
+	return nil
+}
+
+// GaussianNoise is a function whose values are perturbed by additive Gaussian
+// noise. The noise is independent at every evaluation, even at the same
+// location.
+type GaussianNoise struct {
+	// F is the noise-free function.
+	F Function
+	// Sigma is the standard deviation of the noise.
+	Sigma float64
+	// Src is the source of random numbers. If Src is nil, the global source
+	// of math/rand is used.
+	Src rand.Source
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// WithGaussianNoise returns the function f with additive Gaussian noise of
+// standard deviation sigma. WithGaussianNoise panics if sigma is negative.
+func WithGaussianNoise(f Function, sigma float64) *GaussianNoise {
+	if sigma < 0 {
+		panic("functions: negative noise level")
+	}
+	return &GaussianNoise{F: f, Sigma: sigma}
+}
+
+func (g *GaussianNoise) Func(x []float64) float64 {
+	return g.F.Func(x) + g.Sigma*g.normFloat64()
+}
+
+// Minima returns the minima of the noise-free function. The values are the
+// expected function values at the minima.
+func (g *GaussianNoise) Minima() []Minimum {
+	return minimaOf(g.F)
+}
+
+func (g *GaussianNoise) normFloat64() float64 {
+	if g.Src == nil {
+		return rand.NormFloat64()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.rnd == nil {
+		g.rnd = rand.New(g.Src)
+	}
+	return g.rnd.NormFloat64()
+}
+
+// Shifted returns the function f translated by offset,
+//  g(x) = f(x - offset),
+// so that the minima of g are the minima of f shifted by offset.
+func Shifted(f Function, offset []float64) Function {
+	s := shifted{f: f, offset: offset}
+	if _, ok := f.(gradient); ok {
+		return shiftedGrad{s}
+	}
+	return s
+}
+
+type shifted struct {
+	f      Function
+	offset []float64
+}
+
+func (s shifted) Func(x []float64) float64 {
+	return s.f.Func(s.transform(x))
+}
+
+func (s shifted) Minima() []Minimum {
+	minima := minimaOf(s.f)
+	var res []Minimum
+	for _, m := range minima {
+		if len(m.X) != len(s.offset) {
+			continue
+		}
+		x := make([]float64, len(m.X))
+		floats.AddTo(x, m.X, s.offset)
+		res = append(res, Minimum{X: x, F: m.F, Global: m.Global})
+	}
+	return res
+}
+
+func (s shifted) transform(x []float64) []float64 {
+	if len(x) != len(s.offset) {
+		panic("functions: problem size mismatch")
+	}
+	y := make([]float64, len(x))
+	floats.SubTo(y, x, s.offset)
+	return y
+}
+
+type shiftedGrad struct {
+	shifted
+}
+
+func (s shiftedGrad) Grad(x, grad []float64) {
+	s.f.(gradient).Grad(s.transform(x), grad)
+}
+
+// Rotated returns the function f composed with the orthogonal matrix q,
+//  g(x) = f(q x),
+// so that the minima of g are the minima of f rotated by the transpose of q.
+// Rotation makes separable functions like Rastrigin non-separable. Rotated
+// panics if q is not square. The orthogonality of q is not checked, but the
+// minima of g are incorrect if q is not orthogonal.
+func Rotated(f Function, q mat64.Matrix) Function {
+	r, c := q.Dims()
+	if r != c {
+		panic("functions: rotation matrix not square")
+	}
+	rot := rotated{f: f, q: q}
+	if _, ok := f.(gradient); ok {
+		return rotatedGrad{rot}
+	}
+	return rot
+}
+
+type rotated struct {
+	f Function
+	q mat64.Matrix
+}
+
+func (r rotated) Func(x []float64) float64 {
+	return r.f.Func(r.mul(x, false))
+}
+
+func (r rotated) Minima() []Minimum {
+	n, _ := r.q.Dims()
+	var res []Minimum
+	for _, m := range minimaOf(r.f) {
+		if len(m.X) != n {
+			continue
+		}
+		res = append(res, Minimum{X: r.mul(m.X, true), F: m.F, Global: m.Global})
+	}
+	return res
+}
+
+// mul returns q x, or qᵀ x if trans is true.
+func (r rotated) mul(x []float64, trans bool) []float64 {
+	n, _ := r.q.Dims()
+	if len(x) != n {
+		panic("functions: problem size mismatch")
+	}
+	y := make([]float64, n)
+	for i := range y {
+		for j, v := range x {
+			if trans {
+				y[i] += r.q.At(j, i) * v
+			} else {
+				y[i] += r.q.At(i, j) * v
+			}
+		}
+	}
+	return y
+}
+
+type rotatedGrad struct {
+	rotated
+}
+
+func (r rotatedGrad) Grad(x, grad []float64) {
+	if len(x) != len(grad) {
+		panic("incorrect size of the gradient")
+	}
+	g := make([]float64, len(x))
+	r.f.(gradient).Grad(r.mul(x, false), g)
+	copy(grad, r.mul(g, true))
+}
+
+// RandomRotation returns a random orthogonal n×n matrix for use with Rotated.
+// The matrix is obtained by Gram-Schmidt orthogonalization of a matrix with
+// independent standard normal elements. If src is nil, the global source of
+// math/rand is used.
+func RandomRotation(n int, src rand.Source) *mat64.Dense {
+	norm := rand.NormFloat64
+	if src != nil {
+		norm = rand.New(src).NormFloat64
+	}
+	q := mat64.NewDense(n, n, nil)
+	col := make([]float64, n)
+	for j := 0; j < n; j++ {
+		// A random vector is orthogonal to the previous columns with
+		// probability one after the projection. The projection is repeated
+		// for numerical orthogonality.
+		for {
+			for i := range col {
+				col[i] = norm()
+			}
+			for pass := 0; pass < 2; pass++ {
+				for k := 0; k < j; k++ {
+					var dot float64
+					for i, v := range col {
+						dot += q.At(i, k) * v
+					}
+					for i := range col {
+						col[i] -= dot * q.At(i, k)
+					}
+				}
+			}
+			if nrm := floats.Norm(col, 2); nrm > 1e-8 {
+				floats.Scale(1/nrm, col)
+				break
+			}
+		}
+		for i, v := range col {
+			q.Set(i, j, v)
+		}
+	}
+	return q
+}
+
+// IllConditioned returns the function f with the variables scaled by scales,
+//  g(x) = f(scales ⊙ x),
+// where ⊙ is the element-wise product. The condition number of the Hessian
+// of g at a minimum increases by up to the square of the ratio of the largest
+// to the smallest scale. The minima of g are the minima of f divided by
+// scales element-wise. IllConditioned panics if a scale is not positive.
+func IllConditioned(f Function, scales []float64) Function {
+	for _, s := range scales {
+		if !(s > 0) || math.IsInf(s, 1) {
+			panic("functions: scale not positive and finite")
+		}
+	}
+	ill := illConditioned{f: f, scales: scales}
+	if _, ok := f.(gradient); ok {
+		return illConditionedGrad{ill}
+	}
+	return ill
+}
+
+type illConditioned struct {
+	f      Function
+	scales []float64
+}
+
+func (ic illConditioned) Func(x []float64) float64 {
+	return ic.f.Func(ic.transform(x))
+}
+
+func (ic illConditioned) Minima() []Minimum {
+	var res []Minimum
+	for _, m := range minimaOf(ic.f) {
+		if len(m.X) != len(ic.scales) {
+			continue
+		}
+		x := make([]float64, len(m.X))
+		floats.DivTo(x, m.X, ic.scales)
+		res = append(res, Minimum{X: x, F: m.F, Global: m.Global})
+	}
+	return res
+}
+
+func (ic illConditioned) transform(x []float64) []float64 {
+	if len(x) != len(ic.scales) {
+		panic("functions: problem size mismatch")
+	}
+	y := make([]float64, len(x))
+	floats.MulTo(y, x, ic.scales)
+	return y
+}
+
+type illConditionedGrad struct {
+	illConditioned
+}
+
+func (ic illConditionedGrad) Grad(x, grad []float64) {
+	if len(x) != len(grad) {
+		panic("incorrect size of the gradient")
+	}
+	ic.f.(gradient).Grad(ic.transform(x), grad)
+	floats.Mul(grad, ic.scales)
+}
@@ -0,0 +1,150 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package functions
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// funcOnly hides all methods of a function except Func.
+type funcOnly struct {
+	f Function
+}
+
+func (f funcOnly) Func(x []float64) float64 {
+	return f.f.Func(x)
+}
+
+func TestShifted(t *testing.T) {
+	offset := []float64{1.5, -2}
+	f := Shifted(Rastrigin{Dim: 2}, offset)
+	tests := []FuncTest{
+		{
+			X:        []float64{2, -3.5},
+			F:        42.5,
+			Gradient: []float64{1, -3},
+		},
+	}
+	Test(f, tests, t)
+	if m := f.(minimumer).Minima(); len(m) != 1 || !floats.Equal(m[0].X, offset) {
+		t.Errorf("unexpected minima of shifted function: %v", m)
+	}
+	if _, ok := Shifted(funcOnly{Rastrigin{Dim: 2}}, offset).(gradient); ok {
+		t.Errorf("shifted function without gradient implements Grad")
+	}
+}
+
+func TestRotated(t *testing.T) {
+	q := RandomRotation(4, rand.NewSource(1))
+	// Check that q is orthogonal.
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			var dot float64
+			for k := 0; k < 4; k++ {
+				dot += q.At(k, i) * q.At(k, j)
+			}
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(dot-want) > 1e-14 {
+				t.Fatalf("rotation matrix not orthogonal: column product (%d,%d) = %v", i, j, dot)
+			}
+		}
+	}
+
+	f := Rotated(ExtendedRosenbrock{}, q)
+	x := []float64{-1.2, 1, -1.2, 1}
+	qx := make([]float64, 4)
+	for i := range qx {
+		for j, v := range x {
+			qx[i] += q.At(i, j) * v
+		}
+	}
+	g := make([]float64, 4)
+	ExtendedRosenbrock{}.Grad(qx, g)
+	grad := make([]float64, 4)
+	for i := range grad {
+		for j, v := range g {
+			grad[i] += q.At(j, i) * v
+		}
+	}
+	tests := []FuncTest{
+		{
+			X:        x,
+			F:        ExtendedRosenbrock{}.Func(qx),
+			Gradient: grad,
+		},
+	}
+	Test(f, tests, t)
+	if m := f.(minimumer).Minima(); len(m) != 2 {
+		t.Errorf("unexpected number of minima of rotated function: %d", len(m))
+	}
+	if _, ok := Rotated(funcOnly{ExtendedRosenbrock{}}, q).(gradient); ok {
+		t.Errorf("rotated function without gradient implements Grad")
+	}
+}
+
+func TestIllConditioned(t *testing.T) {
+	scales := []float64{1, 4, 16, 64}
+	f := IllConditioned(ExtendedRosenbrock{}, scales)
+	y := []float64{-1.2, 1, -1.2, 1}
+	grad := make([]float64, 4)
+	ExtendedRosenbrock{}.Grad(y, grad)
+	floats.Mul(grad, scales)
+	tests := []FuncTest{
+		{
+			X:        []float64{-1.2, 0.25, -0.075, 1.0 / 64},
+			F:        ExtendedRosenbrock{}.Func(y),
+			Gradient: grad,
+		},
+	}
+	Test(f, tests, t)
+	m := f.(minimumer).Minima()
+	if len(m) != 2 || !floats.Equal(m[0].X, []float64{1, 0.25, 0.0625, 1.0 / 64}) {
+		t.Errorf("unexpected minima of ill-conditioned function: %v", m)
+	}
+	if _, ok := IllConditioned(funcOnly{ExtendedRosenbrock{}}, scales).(gradient); ok {
+		t.Errorf("ill-conditioned function without gradient implements Grad")
+	}
+}
+
+func TestGaussianNoise(t *testing.T) {
+	x := []float64{0.5, -1.5, 2.25}
+	want := Rastrigin{Dim: 3}.Func(x)
+
+	f := WithGaussianNoise(Rastrigin{Dim: 3}, 0)
+	if v := f.Func(x); v != want {
+		t.Errorf("function value without noise is incorrect. Want: %v, Got: %v", want, v)
+	}
+	if len(f.Minima()) != 1 {
+		t.Errorf("minima of the noise-free function not returned")
+	}
+
+	const (
+		sigma = 0.5
+		n     = 10000
+	)
+	f = WithGaussianNoise(Rastrigin{Dim: 3}, sigma)
+	f.Src = rand.NewSource(1)
+	var sum, sumSq float64
+	for i := 0; i < n; i++ {
+		d := f.Func(x) - want
+		sum += d
+		sumSq += d * d
+	}
+	mean := sum / n
+	std := math.Sqrt(sumSq/n - mean*mean)
+	if math.Abs(mean) > 4*sigma/math.Sqrt(n) {
+		t.Errorf("mean of the noise too large: %v", mean)
+	}
+	if math.Abs(std-sigma) > 0.05*sigma {
+		t.Errorf("unexpected standard deviation of the noise. Want: %v, Got: %v", sigma, std)
+	}
+}
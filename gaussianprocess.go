@@ -0,0 +1,211 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultGPNoise = 1e-8
+	maxGPJitter    = 1e-2
+)
+
+// defaultLengthScales are the length scales among which GaussianProcess
+// chooses the one with the largest marginal likelihood.
+var defaultLengthScales = []float64{0.05, 0.1, 0.2, 0.3, 0.5, 1, 2}
+
+// Kernel is the correlation function of a stationary Gaussian process. Corr
+// returns the correlation of the function values at two locations whose
+// Euclidean distance divided by the length scale is r. Corr(0) must be 1.
+type Kernel interface {
+	Corr(r float64) float64
+}
+
+// RBFKernel is the squared exponential kernel
+//  exp(-r^2 / 2).
+// The sample paths of the process are infinitely differentiable.
+type RBFKernel struct{}
+
+func (RBFKernel) Corr(r float64) float64 {
+	return math.Exp(-r * r / 2)
+}
+
+// Matern32Kernel is the Matérn kernel with smoothness 3/2,
+//  (1 + √3 r) exp(-√3 r).
+// The sample paths of the process are once differentiable.
+type Matern32Kernel struct{}
+
+func (Matern32Kernel) Corr(r float64) float64 {
+	s := math.Sqrt(3) * r
+	return (1 + s) * math.Exp(-s)
+}
+
+// Matern52Kernel is the Matérn kernel with smoothness 5/2,
+//  (1 + √5 r + 5 r^2 / 3) exp(-√5 r).
+// The sample paths of the process are twice differentiable.
+type Matern52Kernel struct{}
+
+func (Matern52Kernel) Corr(r float64) float64 {
+	s := math.Sqrt(5) * r
+	return (1 + s + s*s/3) * math.Exp(-s)
+}
+
+// GaussianProcess is a Gaussian process regression model of a function from
+// its values at a set of locations. The function values are standardized to
+// zero mean and unit variance, and the prior covariance of the standardized
+// values at x and y is
+//  σ^2 (Kernel.Corr(|x - y| / LengthScale) + Noise δ_xy),
+// where the variance σ^2 is estimated by maximum likelihood.
+type GaussianProcess struct {
+	// Kernel is the correlation function. If Kernel is nil, it will be set
+	// to Matern52Kernel{}.
+	Kernel Kernel
+	// LengthScale is the length scale of the correlation. If LengthScale
+	// is zero, Fit chooses among a few length scales between 0.05 and 2 the
+	// one with the largest marginal likelihood, which is suitable for
+	// locations in the unit cube.
+	LengthScale float64
+	// Noise is the variance of the observation noise relative to the
+	// variance of the function values. If Noise is zero, it will be set to
+	// 1e-8, and Fit increases it if needed to make the covariance matrix
+	// numerically positive definite.
+	Noise float64
+
+	x         [][]float64
+	y         []float64 // Standardized function values.
+	mean, std float64
+	scale     float64
+	variance  float64 // Variance of the standardized process.
+	chol      *mat64.TriDense
+	alpha     *mat64.Vector
+	k         *mat64.Vector
+	tmp       *mat64.Vector
+}
+
+// Fit fits the Gaussian process to the function values y at the locations
+// x. The locations are used by the model until the next call to Fit and must
+// not be modified. Fit returns an error if the covariance matrix is not
+// positive definite.
+func (gp *GaussianProcess) Fit(x [][]float64, y []float64) error {
+	n := len(x)
+	if n == 0 {
+		return errors.New("optimize: no data for Gaussian process")
+	}
+	if len(y) != n {
+		panic("optimize: data size mismatch")
+	}
+	if gp.Kernel == nil {
+		gp.Kernel = Matern52Kernel{}
+	}
+	if gp.Noise == 0 {
+		gp.Noise = defaultGPNoise
+	}
+	if gp.Noise < 0 || gp.LengthScale < 0 {
+		panic("optimize: negative Gaussian process parameter")
+	}
+
+	gp.x = x
+	gp.mean = floats.Sum(y) / float64(n)
+	var ss float64
+	for _, v := range y {
+		ss += (v - gp.mean) * (v - gp.mean)
+	}
+	gp.std = math.Sqrt(ss / float64(n))
+	if gp.std == 0 || math.IsNaN(gp.std) || math.IsInf(gp.std, 0) {
+		gp.std = 1
+	}
+	gp.y = resize(gp.y, n)
+	for i, v := range y {
+		gp.y[i] = (v - gp.mean) / gp.std
+	}
+
+	scales := defaultLengthScales
+	if gp.LengthScale != 0 {
+		scales = []float64{gp.LengthScale}
+	}
+	best := math.Inf(-1)
+	var bestScale float64
+	for _, scale := range scales {
+		ll, ok := gp.factorize(scale)
+		if ok && ll > best {
+			best = ll
+			bestScale = scale
+		}
+	}
+	if math.IsInf(best, -1) {
+		return errors.New("optimize: Gaussian process covariance not positive definite")
+	}
+	if len(scales) > 1 {
+		gp.factorize(bestScale)
+	}
+	return nil
+}
+
+// factorize computes the Cholesky factorization of the covariance matrix
+// for the length scale and the weights of the predictive mean, and returns
+// the log marginal likelihood of the data up to a constant.
+func (gp *GaussianProcess) factorize(scale float64) (ll float64, ok bool) {
+	n := len(gp.x)
+	gp.scale = scale
+	cov := mat64.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			cov.SetSym(i, j, gp.Kernel.Corr(floats.Distance(gp.x[i], gp.x[j], 2)/scale))
+		}
+	}
+	if gp.chol == nil {
+		gp.chol = &mat64.TriDense{}
+	}
+	if r, _ := gp.chol.Dims(); r != n {
+		gp.chol = mat64.NewTriDense(n, false, nil)
+	}
+	for jitter := gp.Noise; jitter <= maxGPJitter; jitter *= 10 {
+		for i := 0; i < n; i++ {
+			cov.SetSym(i, i, 1+jitter)
+		}
+		if ok = gp.chol.Cholesky(cov, false); ok {
+			break
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	gp.alpha = mat64.NewVector(n, nil)
+	gp.alpha.SolveCholeskyVec(gp.chol, mat64.NewVector(n, gp.y))
+	// The variance of the process is estimated by maximum likelihood.
+	gp.variance = floats.Dot(gp.y, gp.alpha.RawVector().Data) / float64(n)
+	ll = -0.5 * float64(n) * math.Log(gp.variance)
+	for i := 0; i < n; i++ {
+		ll -= math.Log(gp.chol.At(i, i))
+	}
+	return ll, true
+}
+
+// Predict returns the mean and the standard deviation of the predictive
+// distribution of the function value at x. Predict panics if the process
+// has not been fitted.
+func (gp *GaussianProcess) Predict(x []float64) (mean, std float64) {
+	if gp.alpha == nil {
+		panic("optimize: Gaussian process not fitted")
+	}
+	n := len(gp.x)
+	if gp.k == nil || gp.k.Len() != n {
+		gp.k = mat64.NewVector(n, nil)
+		gp.tmp = mat64.NewVector(n, nil)
+	}
+	k := gp.k.RawVector().Data
+	for i, xi := range gp.x {
+		k[i] = gp.Kernel.Corr(floats.Distance(x, xi, 2) / gp.scale)
+	}
+	mean = floats.Dot(k, gp.alpha.RawVector().Data)
+	gp.tmp.SolveCholeskyVec(gp.chol, gp.k)
+	variance := gp.variance * math.Max(0, 1-floats.Dot(k, gp.tmp.RawVector().Data))
+	return gp.mean + gp.std*mean, gp.std * math.Sqrt(variance)
+}
@@ -0,0 +1,54 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGaussianProcess(t *testing.T) {
+	f := func(x []float64) float64 { return math.Sin(6*x[0]) + x[1]*x[1] }
+	rnd := rand.New(rand.NewSource(1))
+	var x [][]float64
+	var y []float64
+	for i := 0; i < 30; i++ {
+		xi := []float64{rnd.Float64(), rnd.Float64()}
+		x = append(x, xi)
+		y = append(y, f(xi))
+	}
+	for _, kernel := range []Kernel{RBFKernel{}, Matern32Kernel{}, Matern52Kernel{}} {
+		gp := &GaussianProcess{Kernel: kernel}
+		if err := gp.Fit(x, y); err != nil {
+			t.Fatalf("%T: unexpected error: %v", kernel, err)
+		}
+		// The model interpolates the data.
+		for i, xi := range x {
+			mean, std := gp.Predict(xi)
+			if math.Abs(mean-y[i]) > 1e-3 || std > 1e-2 {
+				t.Errorf("%T: unexpected prediction %v ± %v at data point %d, want %v", kernel, mean, std, i, y[i])
+			}
+		}
+		// The model predicts the function between the data points.
+		const n = 100
+		var meanErr float64
+		for i := 0; i < n; i++ {
+			xi := []float64{0.1 + 0.8*rnd.Float64(), 0.1 + 0.8*rnd.Float64()}
+			mean, std := gp.Predict(xi)
+			meanErr += math.Abs(mean-f(xi)) / n
+			if std <= 0 {
+				t.Errorf("%T: zero standard deviation between data points", kernel)
+			}
+		}
+		if meanErr > 0.1 {
+			t.Errorf("%T: mean prediction error %v too large", kernel, meanErr)
+		}
+		// The uncertainty grows away from the data.
+		if _, std := gp.Predict([]float64{5, 5}); std < 0.5*gp.std {
+			t.Errorf("%T: small standard deviation %v away from the data", kernel, std)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// GaussNewton implements the Gauss-Newton method for nonlinear least-squares
+// problems. It is intended for Problems obtained from ResidualProblem.Problem,
+// whose Hessian is the Gauss-Newton approximation JᵀJ. It generates a
+// sequence of locations x_k by means of
+//  solve J_kᵀJ_k d_k = -J_kᵀr_k for d_k,
+//  x_{k+1} = x_k + α_k d_k,
+// where r_k and J_k are the residuals and their Jacobian at x_k and α_k is a
+// step size found by a line search.
+//
+// GaussNewton converges quickly on problems with small residuals at the
+// solution and a well-conditioned Jacobian. If JᵀJ is singular, the direction
+// falls back to the negative gradient, and LevenbergMarquardt should be used
+// instead.
+//
+// GaussNewton implements CovarianceEstimator, and Local stores the
+// approximate covariance (JᵀJ)^{-1} of the parameters at the returned
+// location in Result.Covariance. For the covariance of a fit to data, it must
+// be multiplied by the variance of the residuals, see
+// ResidualProblem.Statistics.
+type GaussNewton struct {
+	// LinesearchMethod is a method used for selecting suitable steps along the
+	// descent direction d. If LinesearchMethod == nil, Backtracking is used,
+	// which accepts the full Gauss-Newton step whenever it sufficiently
+	// decreases the objective function.
+	LinesearchMethod LinesearchMethod
+
+	linesearch *Linesearch
+
+	hess *mat64.SymDense
+	chol *mat64.TriDense
+}
+
+func (g *GaussNewton) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	g.init()
+	return g.linesearch.Init(loc, p, xNext)
+}
+
+func (g *GaussNewton) init() {
+	if g.LinesearchMethod == nil {
+		g.LinesearchMethod = &Backtracking{}
+	}
+	if g.linesearch == nil {
+		g.linesearch = &Linesearch{}
+	}
+	g.linesearch.Method = g.LinesearchMethod
+	g.linesearch.NextDirectioner = g
+}
+
+func (g *GaussNewton) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	return g.linesearch.Iterate(loc, xNext)
+}
+
+func (g *GaussNewton) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	g.hess = resizeSymDense(g.hess, dim)
+	g.chol = resizeTriDense(g.chol, dim)
+	return g.NextDirection(loc, dir)
+}
+
+func (g *GaussNewton) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	g.hess.CopySym(loc.Hessian)
+	if g.chol.Cholesky(g.hess, true) {
+		d := mat64.NewVector(dim, dir)
+		d.SolveCholeskyVec(g.chol, mat64.NewVector(dim, loc.Gradient))
+		floats.Scale(-1, dir)
+		return 1
+	}
+	// JᵀJ is singular. Return the negative gradient as the descent
+	// direction.
+	copy(dir, loc.Gradient)
+	floats.Scale(-1, dir)
+	return 1
+}
+
+// Covariance stores (JᵀJ)^{-1} at loc into cov. It returns false if JᵀJ is
+// singular.
+func (g *GaussNewton) Covariance(cov *mat64.SymDense, loc *Location) bool {
+	if loc.Hessian == nil {
+		return false
+	}
+	g.chol = resizeTriDense(g.chol, len(loc.X))
+	return choleskyInverse(cov, loc.Hessian, g.chol)
+}
+
+// choleskyInverse stores the inverse of the symmetric positive definite
+// matrix a into dst, using chol as storage for the Cholesky factorization. It
+// returns false if a is not positive definite.
+func choleskyInverse(dst, a *mat64.SymDense, chol *mat64.TriDense) bool {
+	if !chol.Cholesky(a, true) {
+		return false
+	}
+	dim := a.Symmetric()
+	e := make([]float64, dim)
+	col := mat64.NewVector(dim, make([]float64, dim))
+	for j := 0; j < dim; j++ {
+		e[j] = 1
+		col.SolveCholeskyVec(chol, mat64.NewVector(dim, e))
+		e[j] = 0
+		for i := 0; i <= j; i++ {
+			dst.SetSym(i, j, col.At(i, 0))
+		}
+	}
+	return true
+}
+
+func (g *GaussNewton) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, true}
+}
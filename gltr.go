@@ -0,0 +1,332 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultGLTRTolerance     = 1e-6
+	defaultGLTRMaxIterations = 100
+
+	// gltrTridiagTolerance is the relative accuracy of |h|_2 = Δ in the
+	// solution of the tridiagonal subproblem on the boundary.
+	gltrTridiagTolerance = 1e-10
+	// gltrTridiagIterations is the maximum number of iterations in the
+	// solution of the tridiagonal subproblem.
+	gltrTridiagIterations = 100
+)
+
+// GLTR is a SubproblemSolver that implements the Generalized Lanczos Trust
+// Region method. GLTR builds an orthonormal basis Q_k of the Krylov subspace
+//  K_k = span{∇f, B∇f, ..., B^{k-1}∇f}
+// by the Lanczos process, which transforms B into a tridiagonal matrix
+// T_k = Q_kᵀ B Q_k, and solves the trust-region subproblem restricted to K_k
+//  minimize   |∇f|_2 e_1·h + ½ hᵀ T_k h
+//  subject to |h|_2 <= Δ
+// with the Moré–Sorensen method, using LDLᵀ factorizations of the tridiagonal
+// matrices T_k + λI, so that every iteration costs O(k) operations. The step
+// is then recovered as p = Q_k h. As long as the iterates stay inside the
+// trust region and T_k is positive definite, GLTR generates the same steps as
+// the Steihaug-Toint conjugate gradient method, but unlike it, GLTR continues
+// to improve the step when it reaches the boundary of the trust region or
+// encounters negative curvature.
+//
+// GLTR accesses B only through matrix-vector products, so it is suitable for
+// large problems where B is not formed explicitly, see SolveHessVec. The
+// Lanczos vectors are stored, so the memory cost is O(n*k), where k is the
+// number of Lanczos iterations. The Lanczos vectors are not reorthogonalized.
+// In floating-point arithmetic they lose orthogonality as the iteration
+// proceeds, which may slow down the convergence and limits the attainable
+// accuracy, but it does not prevent a useful step from being computed.
+//
+// If ∇f is zero, GLTR returns the zero step even if B is indefinite.
+//
+// References:
+//  - Gould, N.I.M., Lucidi, S., Roma, M., Toint, Ph.L.: Solving the
+//    trust-region subproblem using the Lanczos method. SIAM J Optim 9 (1999),
+//    504-525
+//  - Conn, A.R., Gould, N.I.M., Toint, Ph.L.: Trust-Region Methods. SIAM
+//    (2000), Section 7.5
+type GLTR struct {
+	// Tolerance is the relative accuracy of the solution. The iteration
+	// terminates when the norm of the gradient of the Lagrangian of the
+	// subproblem, |∇f + (B + λI)p|_2, is less than Tolerance*|∇f|_2.
+	// If Tolerance is zero, it will be set to 1e-6.
+	Tolerance float64
+	// MaxIterations is the maximum number of Lanczos iterations, and thus of
+	// matrix-vector products with B.
+	// If MaxIterations is zero, it will be set to the problem dimension, but
+	// at most to 100.
+	MaxIterations int
+
+	lanczos [][]float64 // Lanczos vectors.
+	alpha   []float64   // Diagonal of T_k.
+	beta    []float64   // Subdiagonal of T_k.
+	w       []float64
+
+	// Storage for the solution of the tridiagonal subproblem.
+	lambda float64   // Multiplier λ of the last solution.
+	h      []float64 // Solution of the tridiagonal subproblem.
+	d, l   []float64 // LDLᵀ factorization of T_k + λI.
+	v      []float64
+}
+
+// Solve computes the step p using the explicit matrix hess for computing
+// matrix-vector products.
+func (g *GLTR) Solve(p, grad []float64, hess *mat64.SymDense, radius float64) float64 {
+	dim := len(p)
+	if hess.Symmetric() != dim {
+		panic("gltr: size mismatch")
+	}
+	return g.SolveHessVec(p, grad, func(dst, v []float64) {
+		mat64.NewVector(dim, dst).MulVec(hess, false, mat64.NewVector(dim, v))
+	}, radius)
+}
+
+// SolveHessVec computes the step p using hessVec to compute matrix-vector
+// products with B. hessVec must store the product B*v in place into dst and
+// must not modify v. SolveHessVec stores the step in place into p and returns
+// the value of the quadratic model m(p).
+func (g *GLTR) SolveHessVec(p, grad []float64, hessVec func(dst, v []float64), radius float64) float64 {
+	dim := len(p)
+	if len(grad) != dim {
+		panic("gltr: size mismatch")
+	}
+	if radius <= 0 {
+		panic("gltr: radius not positive")
+	}
+	if g.Tolerance == 0 {
+		g.Tolerance = defaultGLTRTolerance
+	}
+	maxIter := g.MaxIterations
+	if maxIter == 0 {
+		maxIter = dim
+		if maxIter > defaultGLTRMaxIterations {
+			maxIter = defaultGLTRMaxIterations
+		}
+	}
+
+	for i := range p {
+		p[i] = 0
+	}
+	gNorm := floats.Norm(grad, 2)
+	if gNorm == 0 {
+		return 0
+	}
+
+	g.w = resize(g.w, dim)
+	g.alpha = g.alpha[:0]
+	g.beta = g.beta[:0]
+	g.lanczos = g.lanczos[:0]
+	g.lambda = 0
+
+	var mh float64
+	// First Lanczos vector is q_0 = ∇f / |∇f|_2.
+	q := g.lanczosVector(0, dim)
+	copy(q, grad)
+	floats.Scale(1/gNorm, q)
+	for k := 0; k < maxIter; k++ {
+		// w = B q_k - β_{k-1} q_{k-1} - α_k q_k.
+		hessVec(g.w, q)
+		if k > 0 {
+			floats.AddScaled(g.w, -g.beta[k-1], g.lanczos[k-1])
+		}
+		alpha := floats.Dot(q, g.w)
+		floats.AddScaled(g.w, -alpha, q)
+		beta := floats.Norm(g.w, 2)
+		g.alpha = append(g.alpha, alpha)
+		g.beta = append(g.beta, beta)
+
+		// Solve the trust-region subproblem with the tridiagonal matrix T_k.
+		mh = g.solveTridiag(gNorm, radius)
+
+		// The gradient of the Lagrangian of the full subproblem at p = Q_k h
+		// is β_k (e_kᵀh) q_{k+1}.
+		if beta == 0 || beta*math.Abs(g.h[k]) <= g.Tolerance*gNorm {
+			// Converged, or the Krylov subspace is invariant under B.
+			break
+		}
+		q = g.lanczosVector(k+1, dim)
+		copy(q, g.w)
+		floats.Scale(1/beta, q)
+	}
+
+	// Recover the step p = Q_k h.
+	for i, hi := range g.h {
+		floats.AddScaled(p, hi, g.lanczos[i])
+	}
+	return mh
+}
+
+// solveTridiag solves the trust-region subproblem
+//  minimize   gNorm e_1·h + ½ hᵀ T h
+//  subject to |h|_2 <= radius
+// where T is the tridiagonal matrix with the diagonal g.alpha and the
+// subdiagonal g.beta[:len(g.alpha)-1]. It stores the solution into g.h and
+// returns the value of the model.
+//
+// solveTridiag finds the multiplier λ >= max(0, -λ_min(T)) with
+// (T + λI)h = -gNorm e_1 and either λ = 0 or |h|_2 = radius by the safeguarded
+// Newton iteration of Moré and Sorensen on the secular equation
+// 1/|h(λ)|_2 - 1/radius = 0. The iteration starts from the multiplier of the
+// previous call, which is a good estimate because T grows by one row and
+// column between the calls. The subdiagonal of T is nonzero, so e_1 is not
+// orthogonal to the eigenvector of the smallest eigenvalue of T and the hard
+// case cannot occur.
+func (g *GLTR) solveTridiag(gNorm, radius float64) float64 {
+	n := len(g.alpha)
+	g.h = resize(g.h, n)
+	g.d = resize(g.d, n)
+	g.l = resize(g.l, n)
+	g.v = resize(g.v, n)
+
+	// Bound the multiplier. The smallest diagonal element of T is an upper
+	// bound and the Gershgorin bound a lower bound on λ_min(T). At λ = hi,
+	// T + λI has all eigenvalues at least gNorm/radius, so |h|_2 <= radius.
+	minDiag := math.Inf(1)
+	minGersh := math.Inf(1)
+	for i, a := range g.alpha {
+		minDiag = math.Min(minDiag, a)
+		r := 0.0
+		if i > 0 {
+			r += math.Abs(g.beta[i-1])
+		}
+		if i < n-1 {
+			r += math.Abs(g.beta[i])
+		}
+		minGersh = math.Min(minGersh, a-r)
+	}
+	lo := math.Max(0, -minDiag)
+	hi := gNorm/radius + math.Max(0, -minGersh)
+
+	if lo == 0 && g.factorTridiag(0) {
+		g.solveShiftedTridiag(gNorm)
+		if floats.Norm(g.h, 2) <= radius {
+			// The unconstrained minimizer is inside the trust region.
+			g.lambda = 0
+			return g.tridiagModel(gNorm)
+		}
+	}
+
+	lambda := math.Max(lo, math.Min(g.lambda, hi))
+	found := false
+	for iter := 0; iter < gltrTridiagIterations; iter++ {
+		if !g.factorTridiag(lambda) {
+			// T + λI is not positive definite.
+			lo = lambda
+			lambda = math.Max(math.Sqrt(lo*hi), lo+0.01*(hi-lo))
+			continue
+		}
+		g.solveShiftedTridiag(gNorm)
+		found = true
+		hNorm := floats.Norm(g.h, 2)
+		if math.Abs(hNorm-radius) <= gltrTridiagTolerance*radius {
+			break
+		}
+		if hNorm < radius {
+			hi = lambda
+		} else {
+			lo = lambda
+		}
+		// Newton step on the secular equation. With T + λI = LDLᵀ and
+		// Lv = h, the derivative of |h|_2 is given by vᵀD⁻¹v.
+		vNorm2 := 0.0
+		for i := range g.v {
+			if i == 0 {
+				g.v[i] = g.h[i]
+			} else {
+				g.v[i] = g.h[i] - g.l[i-1]*g.v[i-1]
+			}
+			vNorm2 += g.v[i] * g.v[i] / g.d[i]
+		}
+		lambda += hNorm * hNorm / vNorm2 * (hNorm - radius) / radius
+		if lambda <= lo || lambda >= hi {
+			lambda = math.Max(math.Sqrt(lo*hi), lo+0.01*(hi-lo))
+		}
+		found = false
+		if hi-lo <= 1e-15*hi {
+			break
+		}
+	}
+	if !found {
+		// The iteration ended without a solution at the last λ. T + λI is
+		// positive definite at the upper bound.
+		if !g.factorTridiag(lambda) {
+			lambda = hi
+			g.factorTridiag(lambda)
+		}
+		g.solveShiftedTridiag(gNorm)
+	}
+	g.lambda = lambda
+	return g.tridiagModel(gNorm)
+}
+
+// factorTridiag computes the LDLᵀ factorization of T + λI into g.d and g.l,
+// and returns whether T + λI is positive definite.
+func (g *GLTR) factorTridiag(lambda float64) bool {
+	n := len(g.alpha)
+	g.d[0] = g.alpha[0] + lambda
+	if g.d[0] <= 0 {
+		return false
+	}
+	for i := 1; i < n; i++ {
+		g.l[i-1] = g.beta[i-1] / g.d[i-1]
+		g.d[i] = g.alpha[i] + lambda - g.l[i-1]*g.beta[i-1]
+		if g.d[i] <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// solveShiftedTridiag solves (T + λI)h = -gNorm e_1 into g.h using the
+// factorization computed by factorTridiag.
+func (g *GLTR) solveShiftedTridiag(gNorm float64) {
+	n := len(g.h)
+	// Solve Ly = -gNorm e_1 and Dz = y.
+	y := -gNorm
+	g.h[0] = y / g.d[0]
+	for i := 1; i < n; i++ {
+		y *= -g.l[i-1]
+		g.h[i] = y / g.d[i]
+	}
+	// Solve Lᵀh = z.
+	for i := n - 2; i >= 0; i-- {
+		g.h[i] -= g.l[i] * g.h[i+1]
+	}
+}
+
+// tridiagModel returns the value of the model gNorm e_1·h + ½ hᵀ T h at g.h.
+func (g *GLTR) tridiagModel(gNorm float64) float64 {
+	n := len(g.h)
+	m := gNorm * g.h[0]
+	for i, hi := range g.h {
+		th := g.alpha[i] * hi
+		if i > 0 {
+			th += g.beta[i-1] * g.h[i-1]
+		}
+		if i < n-1 {
+			th += g.beta[i] * g.h[i+1]
+		}
+		m += 0.5 * hi * th
+	}
+	return m
+}
+
+// lanczosVector returns storage for the k-th Lanczos vector.
+func (g *GLTR) lanczosVector(k, dim int) []float64 {
+	if k < cap(g.lanczos) {
+		g.lanczos = g.lanczos[:k+1]
+	} else {
+		g.lanczos = append(g.lanczos, nil)
+	}
+	g.lanczos[k] = resize(g.lanczos[k], dim)
+	return g.lanczos[k]
+}
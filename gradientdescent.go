@@ -11,17 +11,45 @@ import "github.com/gonum/floats"
 // LinesearchMethod specifies the kind of linesearch to be done, and StepSizer determines
 // the initial step size of each direction. If either LinesearchMethod or StepSizer
 // are nil, a reasonable value will be chosen.
+//
+// If Momentum is positive, the search direction accumulates the previous
+// directions. With the momentum coefficient β, classical momentum uses the
+// direction
+//  m_k = β m_{k-1} - g_k
+// and Nesterov's accelerated gradient, which is selected by Nesterov, uses
+//  β m_k - g_k,
+// where g_k is the gradient at the current location. With a constant step
+// size, these are the heavy ball method of Polyak and the reformulation of
+// Nesterov's method by Sutskever et al. which needs the gradient only at the
+// iterates. If the direction is not a descent direction, the momentum is
+// reset to the steepest descent direction.
+//
+// If Preconditioner is not nil, the gradient g_k is replaced by the
+// preconditioned gradient M^{-1} g_k in the directions above.
 type GradientDescent struct {
 	LinesearchMethod LinesearchMethod
 	StepSizer        StepSizer
+	Preconditioner   Preconditioner
+
+	// Momentum is the momentum coefficient β. GradientDescent panics if
+	// Momentum is not in [0, 1).
+	Momentum float64
+	// Nesterov specifies whether Nesterov's accelerated gradient is used
+	// instead of classical momentum.
+	Nesterov bool
 
 	linesearch *Linesearch
+	m          []float64 // Momentum direction.
+	pg         []float64 // Preconditioned gradient.
 }
 
 func (g *GradientDescent) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
 	if g.StepSizer == nil {
 		g.StepSizer = &QuadraticStepSize{}
 	}
+	if g.Momentum < 0 || g.Momentum >= 1 {
+		panic("gradientdescent: momentum must be in [0, 1)")
+	}
 	if g.LinesearchMethod == nil {
 		g.LinesearchMethod = &Backtracking{}
 	}
@@ -39,17 +67,70 @@ func (g *GradientDescent) Iterate(loc *Location, xNext []float64) (EvaluationTyp
 }
 
 func (g *GradientDescent) InitDirection(loc *Location, dir []float64) (stepSize float64) {
-	copy(dir, loc.Gradient)
+	g.pg = resize(g.pg, len(dir))
+	copy(dir, g.precondGradient(loc))
 	floats.Scale(-1, dir)
+	if g.Momentum != 0 {
+		g.m = resize(g.m, len(dir))
+		copy(g.m, dir)
+	}
 	return g.StepSizer.Init(loc, dir)
 }
 
+// RestartDirection discards the momentum.
+func (g *GradientDescent) RestartDirection(loc *Location, dir []float64) (stepSize float64) {
+	return g.InitDirection(loc, dir)
+}
+
 func (g *GradientDescent) NextDirection(loc *Location, dir []float64) (stepSize float64) {
-	copy(dir, loc.Gradient)
-	floats.Scale(-1, dir)
+	pg := g.precondGradient(loc)
+	if g.Momentum == 0 {
+		copy(dir, pg)
+		floats.Scale(-1, dir)
+		return g.StepSizer.StepSize(loc, dir)
+	}
+
+	floats.Scale(g.Momentum, g.m)
+	floats.Sub(g.m, pg)
+	if g.Nesterov {
+		copy(dir, g.m)
+		floats.Scale(g.Momentum, dir)
+		floats.Sub(dir, pg)
+	} else {
+		copy(dir, g.m)
+	}
+	if floats.Dot(dir, loc.Gradient) >= 0 {
+		// Not a descent direction, reset the momentum.
+		copy(dir, pg)
+		floats.Scale(-1, dir)
+		copy(g.m, dir)
+	}
 	return g.StepSizer.StepSize(loc, dir)
 }
 
+// precondGradient returns the preconditioned gradient at loc, or the
+// gradient if there is no Preconditioner.
+func (g *GradientDescent) precondGradient(loc *Location) []float64 {
+	if g.Preconditioner == nil {
+		return loc.Gradient
+	}
+	updatePreconditioner(g.Preconditioner, loc)
+	g.Preconditioner.Apply(g.pg, loc.Gradient)
+	return g.pg
+}
+
+// Clone returns a new GradientDescent with the configuration of g and copies
+// of its LinesearchMethod and StepSizer.
+func (g *GradientDescent) Clone() Method {
+	return &GradientDescent{
+		LinesearchMethod: cloneLinesearchMethod(g.LinesearchMethod),
+		StepSizer:        cloneStepSizer(g.StepSizer),
+		Preconditioner:   g.Preconditioner,
+		Momentum:         g.Momentum,
+		Nesterov:         g.Nesterov,
+	}
+}
+
 func (*GradientDescent) Needs() struct {
 	Gradient bool
 	Hessian  bool
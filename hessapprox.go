@@ -0,0 +1,166 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// HessianApproxMethod selects how the Hessian is approximated.
+type HessianApproxMethod int
+
+const (
+	// FDHessian approximates the Hessian by forward differences of the
+	// gradient
+	//  ∇²f(x) e_j ≈ (∇f(x + h_j e_j) - ∇f(x)) / h_j,
+	// which requires n+1 gradient evaluations per Hessian.
+	FDHessian HessianApproxMethod = iota
+	// BFGSHessian accumulates BFGS updates of the Hessian approximation
+	// from the gradients at the successive locations where the Hessian is
+	// evaluated, starting from a scaled identity. It requires one gradient
+	// evaluation per Hessian, but the approximation is accurate only after
+	// enough steps have been taken.
+	BFGSHessian
+)
+
+// Symmetrization selects how the generally non-symmetric finite-difference
+// approximation of the Hessian is made symmetric.
+type Symmetrization int
+
+const (
+	// AverageSymmetrization uses (H + Hᵀ)/2.
+	AverageSymmetrization Symmetrization = iota
+	// UpperSymmetrization uses the upper triangle of H.
+	UpperSymmetrization
+)
+
+// HessianApprox specifies the approximation of the Hessian for Methods that
+// need it when the Problem does not provide Hess, see Settings.HessianApprox.
+// The approximation requires Problem.Grad, and the gradient evaluations it
+// makes are counted in Stats.GradEvaluations.
+type HessianApprox struct {
+	// Method is the approximation method. The default is FDHessian.
+	Method HessianApproxMethod
+	// Step specifies the step size h_j of FDHessian for every dimension. If
+	// Step is nil, h_j = h·max(1, |x_j|) is used, where h is approximately the
+	// square root of the machine epsilon.
+	Step []float64
+	// Symmetrize selects how FDHessian makes the approximation symmetric. The
+	// default is AverageSymmetrization.
+	Symmetrize Symmetrization
+}
+
+// hess returns a function that approximates the Hessian of the objective
+// function of p, which must provide Grad.
+func (h *HessianApprox) hess(p *Problem, stats *Stats) func(x []float64, hess *mat64.SymDense) {
+	grad := func(x, g []float64) {
+		p.Grad(x, g)
+		stats.GradEvaluations++
+	}
+	switch h.Method {
+	case FDHessian:
+		fd := &fdHessian{h: h, grad: grad}
+		return fd.Hess
+	case BFGSHessian:
+		b := &bfgsHessian{grad: grad}
+		return b.Hess
+	}
+	panic("optimize: unknown Hessian approximation method")
+}
+
+// fdHessian approximates the Hessian by finite differences of gradients.
+type fdHessian struct {
+	h    *HessianApprox
+	grad func(x, grad []float64)
+
+	g0   []float64 // Gradient at x.
+	g1   []float64 // Gradient at the perturbed location.
+	xh   []float64 // Perturbed location.
+	cols []float64 // Columns of the non-symmetric approximation.
+}
+
+func (fd *fdHessian) Hess(x []float64, hess *mat64.SymDense) {
+	dim := len(x)
+	if fd.h.Step != nil && len(fd.h.Step) != dim {
+		panic("optimize: step size length mismatch")
+	}
+	fd.g0 = resize(fd.g0, dim)
+	fd.g1 = resize(fd.g1, dim)
+	fd.xh = resize(fd.xh, dim)
+	fd.cols = resize(fd.cols, dim*dim)
+
+	fd.grad(x, fd.g0)
+	copy(fd.xh, x)
+	for j := 0; j < dim; j++ {
+		var step float64
+		if fd.h.Step != nil {
+			step = fd.h.Step[j]
+		} else {
+			step = defaultFDStep * math.Max(1, math.Abs(x[j]))
+		}
+		fd.xh[j] = x[j] + step
+		fd.grad(fd.xh, fd.g1)
+		fd.xh[j] = x[j]
+		col := fd.cols[j*dim : (j+1)*dim]
+		floats.SubTo(col, fd.g1, fd.g0)
+		floats.Scale(1/step, col)
+	}
+
+	for i := 0; i < dim; i++ {
+		for j := i; j < dim; j++ {
+			// H_ij is stored in the j-th column.
+			v := fd.cols[j*dim+i]
+			if fd.h.Symmetrize == AverageSymmetrization {
+				v = (v + fd.cols[i*dim+j]) / 2
+			}
+			hess.SetSym(i, j, v)
+		}
+	}
+}
+
+// bfgsHessian accumulates BFGS updates of the Hessian approximation.
+type bfgsHessian struct {
+	grad func(x, grad []float64)
+
+	x     []float64 // Location of the last evaluation.
+	g     []float64 // Gradient at x.
+	s     []float64
+	y     []float64
+	tmp   []float64
+	b     *mat64.SymDense
+	first bool // Whether b has not been scaled yet.
+}
+
+func (bh *bfgsHessian) Hess(x []float64, hess *mat64.SymDense) {
+	dim := len(x)
+	if bh.b == nil || bh.b.Symmetric() != dim {
+		bh.x = resize(bh.x, dim)
+		bh.g = resize(bh.g, dim)
+		bh.s = resize(bh.s, dim)
+		bh.y = resize(bh.y, dim)
+		bh.tmp = resize(bh.tmp, dim)
+		bh.b = mat64.NewSymDense(dim, nil)
+		for i := 0; i < dim; i++ {
+			bh.b.SetSym(i, i, 1)
+		}
+		bh.first = true
+		copy(bh.x, x)
+		bh.grad(x, bh.g)
+	} else if !floats.Equal(x, bh.x) {
+		floats.SubTo(bh.s, x, bh.x)
+		copy(bh.y, bh.g)
+		copy(bh.x, x)
+		bh.grad(x, bh.g)
+		floats.Sub(bh.y, bh.g)
+		floats.Scale(-1, bh.y)
+		if bfgsHessianUpdate(bh.b, bh.s, bh.y, bh.tmp, bh.first) {
+			bh.first = false
+		}
+	}
+	hess.CopySym(bh.b)
+}
@@ -0,0 +1,79 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestHessianApproxFD(t *testing.T) {
+	f := functions.Watson{}
+	x := []float64{0.1, -0.2, 0.3, -0.4, 0.5, -0.6}
+	dim := len(x)
+	want := mat64.NewSymDense(dim, nil)
+	f.Hess(x, want)
+
+	for _, sym := range []Symmetrization{AverageSymmetrization, UpperSymmetrization} {
+		stats := &Stats{}
+		h := &HessianApprox{Symmetrize: sym}
+		hess := mat64.NewSymDense(dim, nil)
+		h.hess(&Problem{Func: f.Func, Grad: f.Grad}, stats)(x, hess)
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				if !floats.EqualWithinAbsOrRel(hess.At(i, j), want.At(i, j), 1e-5, 1e-5) {
+					t.Errorf("symmetrization %d: unexpected Hessian element (%d,%d). Want %v, got %v",
+						sym, i, j, want.At(i, j), hess.At(i, j))
+				}
+			}
+		}
+		if stats.GradEvaluations != dim+1 {
+			t.Errorf("symmetrization %d: unexpected number of gradient evaluations %d", sym, stats.GradEvaluations)
+		}
+	}
+}
+
+func TestHessianApproxNewton(t *testing.T) {
+	var tests []unconstrainedTest
+	for _, test := range newtonTests {
+		switch test.name {
+		case "BrownAndDennis", "PowellBadlyScaled", "Watson":
+			// The default gradient threshold is out of reach of the
+			// approximations at the minimum of these functions.
+			continue
+		}
+		test.p.Hess = nil
+		tests = append(tests, test)
+	}
+	for _, method := range []HessianApproxMethod{FDHessian, BFGSHessian} {
+		for _, test := range tests {
+			settings := DefaultSettings()
+			settings.HessianApprox = &HessianApprox{Method: method}
+			result, err := Local(test.p, test.x, settings, &Newton{})
+			if err != nil {
+				t.Errorf("method %d: unexpected error: %v for:\n%v", method, err, test)
+				continue
+			}
+			g := make([]float64, len(test.x))
+			test.p.Grad(result.X, g)
+			if floats.Norm(g, math.Inf(1)) >= settings.GradientThreshold {
+				t.Errorf("method %d: gradient norm not smaller than tolerance for:\n%v", method, test)
+			}
+			if result.HessEvaluations == 0 {
+				t.Errorf("method %d: Hessian approximation not used for:\n%v", method, test)
+			}
+		}
+	}
+
+	// Without the approximation, Newton cannot be used.
+	_, err := Local(tests[0].p, tests[0].x, nil, &Newton{})
+	if err == nil {
+		t.Errorf("expected error for Newton without Hessian")
+	}
+}
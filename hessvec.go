@@ -0,0 +1,85 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// hessVecStep is the relative step of the finite-difference approximation
+// of Hessian-vector products, the square root of the machine precision.
+const hessVecStep = 1.4901161193847656e-08
+
+// newHessVec returns a function that computes products of the Hessian of the
+// objective function of p with vectors for ProblemInfo.HessVec, or nil if p
+// provides neither HessVec, nor Hess, nor Grad. The products are computed
+// by p.HessVec if it is not nil. Otherwise, they are computed with the
+// Hessian evaluated by p.Hess, or approximated by forward differences of
+// gradients
+//  ∇²f(x) v ≈ (∇f(x + h v) - ∇f(x)) / h
+// if p provides only Grad, where |h v|_2 is hessVecStep relative to |x|_2
+// and ∇f(x) is passed by the caller. The evaluations are counted in stats.
+func newHessVec(p *Problem, stats *Stats) func(x, grad, v, hv []float64) {
+	switch {
+	case p.HessVec != nil:
+		return func(x, _, v, hv []float64) {
+			p.HessVec(x, v, hv)
+			stats.HessVecEvaluations++
+		}
+	case p.Hess != nil:
+		h := &hessVecCache{}
+		return func(x, _, v, hv []float64) {
+			dim := len(x)
+			if !h.at(x) {
+				h.hess = resizeSymDense(h.hess, dim)
+				p.Hess(x, h.hess)
+				stats.HessEvaluations++
+			}
+			mat64.NewVector(dim, hv).MulVec(h.hess, false, mat64.NewVector(dim, v))
+			stats.HessVecEvaluations++
+		}
+	case p.Grad != nil:
+		var xh []float64
+		return func(x, grad, v, hv []float64) {
+			stats.HessVecEvaluations++
+			vNorm := floats.Norm(v, 2)
+			if vNorm == 0 {
+				for i := range hv {
+					hv[i] = 0
+				}
+				return
+			}
+			step := hessVecStep * math.Max(1, floats.Norm(x, 2)) / vNorm
+			xh = resize(xh, len(x))
+			floats.AddScaledTo(xh, x, step, v)
+			p.Grad(xh, hv)
+			stats.GradEvaluations++
+			floats.Sub(hv, grad)
+			floats.Scale(1/step, hv)
+		}
+	}
+	return nil
+}
+
+// hessVecCache stores the Hessian at the location of the last
+// Hessian-vector product.
+type hessVecCache struct {
+	x    []float64
+	hess *mat64.SymDense
+}
+
+// at returns whether the cached data are at x, and sets the location of the
+// cached data to x otherwise.
+func (h *hessVecCache) at(x []float64) bool {
+	if h.x != nil && floats.Equal(h.x, x) {
+		return true
+	}
+	h.x = resize(h.x, len(x))
+	copy(h.x, x)
+	return false
+}
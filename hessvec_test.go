@@ -0,0 +1,65 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestHessVecFallback(t *testing.T) {
+	f := functions.Watson{}
+	x := []float64{0.1, -0.2, 0.3, -0.4, 0.5, -0.6}
+	v := []float64{1, 2, 3, -1, -2, -3}
+	dim := len(x)
+	hess := mat64.NewSymDense(dim, nil)
+	f.Hess(x, hess)
+	g := make([]float64, dim)
+	f.Grad(x, g)
+	want := make([]float64, dim)
+	mat64.NewVector(dim, want).MulVec(hess, false, mat64.NewVector(dim, v))
+
+	for _, test := range []struct {
+		name string
+		p    Problem
+		tol  float64
+	}{
+		{"HessVec", Problem{Func: f.Func, Grad: f.Grad, HessVec: hessVecFromHess(f.Hess, dim)}, 1e-14},
+		{"Hess", Problem{Func: f.Func, Grad: f.Grad, Hess: f.Hess}, 1e-14},
+		{"Grad", Problem{Func: f.Func, Grad: f.Grad}, 1e-6},
+	} {
+		stats := &Stats{}
+		hessVec := newProblemInfo(&test.p, stats).HessVec
+		hv := make([]float64, dim)
+		for i := 0; i < 2; i++ {
+			hessVec(x, g, v, hv)
+			if !floats.EqualApprox(hv, want, test.tol) {
+				t.Errorf("%s: unexpected Hessian-vector product. Want %v, got %v", test.name, want, hv)
+			}
+		}
+		if stats.HessVecEvaluations != 2 {
+			t.Errorf("%s: unexpected number of products %d", test.name, stats.HessVecEvaluations)
+		}
+		switch test.name {
+		case "Hess":
+			// The Hessian is evaluated once at x.
+			if stats.HessEvaluations != 1 {
+				t.Errorf("%s: unexpected number of Hessian evaluations %d", test.name, stats.HessEvaluations)
+			}
+		case "Grad":
+			// The gradient at x is passed, so it is evaluated only once per
+			// product.
+			if stats.GradEvaluations != 2 {
+				t.Errorf("%s: unexpected number of gradient evaluations %d", test.name, stats.GradEvaluations)
+			}
+		}
+	}
+	if newProblemInfo(&Problem{Func: f.Func}, &Stats{}).HessVec != nil {
+		t.Errorf("unexpected Hessian-vector products without derivatives")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+const (
+	defaultHJContraction = 0.5
+	defaultHJMinStep     = 1e-8
+)
+
+// hjStage is the stage of HookeJeeves.
+type hjStage int
+
+const (
+	hjExploreBase    hjStage = iota // Exploratory moves around the base point.
+	hjPattern                       // Evaluating the pattern point.
+	hjExplorePattern                // Exploratory moves around the pattern point.
+)
+
+// HookeJeeves implements the pattern search method of Hooke and Jeeves for
+// gradient-free optimization. It alternates exploratory moves, which try to
+// decrease the function value by a step of the current step size along
+// each coordinate in turn, with pattern moves, which extrapolate along the
+// direction between the last two base points. If the exploratory moves
+// around the base point do not decrease the function value, the step size
+// is multiplied by Contraction, and HookeJeeves converges with
+// MethodConverge status when the step size falls below MinStep.
+//
+// HookeJeeves uses only comparisons of function values, which makes it
+// robust to noise and to the scaling of the function, but it converges
+// slowly on ill-conditioned problems and in high dimensions.
+//
+// If the Problem has Bounds, the trial locations are projected onto the
+// feasible region, so all evaluated locations are feasible.
+//
+// References:
+//  - Hooke, R., Jeeves, T.A.: "Direct search" solution of numerical and
+//    statistical problems. J. ACM 8 (1961), 212-229
+//  - Kelley, C.T.: Iterative Methods for Optimization. SIAM (1999),
+//    section 8.3
+type HookeJeeves struct {
+	// InitialStep is the initial step size of the exploratory moves. If
+	// InitialStep is zero, it will be set to 1.
+	InitialStep float64
+	// Contraction is the factor by which the step size is reduced. If
+	// Contraction is zero, it will be set to 0.5. HookeJeeves panics if
+	// Contraction is not in (0, 1).
+	Contraction float64
+	// MinStep is the step size below which HookeJeeves converges. If
+	// MinStep is zero, it will be set to 1e-8.
+	MinStep float64
+
+	bounds *Bounds
+	status Status
+
+	stage hjStage
+	step  float64
+
+	base  []float64 // Base point.
+	fBase float64
+	prev  []float64 // Previous base point.
+	cur   []float64 // Best point of the current exploration.
+	fCur  float64
+	coord int     // Coordinate of the exploratory move.
+	dir   float64 // Direction of the exploratory move, +1 or -1.
+	major bool    // Whether the next evaluation starts a major iteration.
+}
+
+func (hj *HookeJeeves) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if hj.InitialStep == 0 {
+		hj.InitialStep = 1
+	}
+	if hj.InitialStep < 0 {
+		panic("hookejeeves: initial step must be positive")
+	}
+	if hj.Contraction == 0 {
+		hj.Contraction = defaultHJContraction
+	}
+	if hj.Contraction <= 0 || hj.Contraction >= 1 {
+		panic("hookejeeves: contraction must be in (0, 1)")
+	}
+	if hj.MinStep == 0 {
+		hj.MinStep = defaultHJMinStep
+	}
+	if hj.MinStep < 0 {
+		panic("hookejeeves: minimum step must be positive")
+	}
+	hj.bounds = p.Bounds
+	hj.status = NotTerminated
+	hj.step = hj.InitialStep
+
+	hj.base = resize(hj.base, dim)
+	hj.prev = resize(hj.prev, dim)
+	hj.cur = resize(hj.cur, dim)
+	copy(hj.base, loc.X)
+	hj.fBase = loc.F
+	hj.explore(hjExploreBase, hj.base, hj.fBase)
+	return hj.next(loc, xNext)
+}
+
+func (hj *HookeJeeves) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	if hj.stage == hjPattern {
+		hj.explore(hjExplorePattern, loc.X, loc.F)
+		return hj.next(loc, xNext)
+	}
+	if loc.F < hj.fCur {
+		copy(hj.cur, loc.X)
+		hj.fCur = loc.F
+		hj.coord++
+		hj.dir = 1
+	} else if hj.dir == 1 {
+		hj.dir = -1
+	} else {
+		hj.coord++
+		hj.dir = 1
+	}
+	return hj.next(loc, xNext)
+}
+
+// explore starts the exploratory moves around x.
+func (hj *HookeJeeves) explore(stage hjStage, x []float64, f float64) {
+	hj.stage = stage
+	copy(hj.cur, x)
+	hj.fCur = f
+	hj.coord = 0
+	hj.dir = 1
+}
+
+// next stores the next location to evaluate into xNext. It skips the
+// exploratory moves that the bounds make void, and performs the pattern
+// moves and the contractions of the step size at the end of an
+// exploration.
+func (hj *HookeJeeves) next(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	for {
+		for hj.coord < len(hj.cur) {
+			i := hj.coord
+			copy(xNext, hj.cur)
+			xNext[i] += hj.dir * hj.step
+			if hj.bounds != nil {
+				hj.bounds.Project(xNext)
+			}
+			if xNext[i] != hj.cur[i] {
+				return FuncEvaluation, hj.iterType(), nil
+			}
+			// The move is void because cur is on the bound.
+			if hj.dir == 1 {
+				hj.dir = -1
+			} else {
+				hj.coord++
+				hj.dir = 1
+			}
+		}
+
+		// The exploration is finished.
+		switch {
+		case hj.fCur < hj.fBase:
+			// Pattern move from the new base point.
+			copy(hj.prev, hj.base)
+			copy(hj.base, hj.cur)
+			hj.fBase = hj.fCur
+			for i, v := range hj.base {
+				xNext[i] = 2*v - hj.prev[i]
+			}
+			if hj.bounds != nil {
+				hj.bounds.Project(xNext)
+			}
+			hj.stage = hjPattern
+			hj.major = true
+			return FuncEvaluation, hj.iterType(), nil
+		case hj.stage == hjExplorePattern:
+			// The pattern move has failed, explore around the base point.
+			hj.explore(hjExploreBase, hj.base, hj.fBase)
+		default:
+			hj.step *= hj.Contraction
+			if hj.step < hj.MinStep {
+				hj.status = MethodConverge
+				copy(xNext, loc.X)
+				return NoEvaluation, MajorIteration, nil
+			}
+			hj.explore(hjExploreBase, hj.base, hj.fBase)
+			hj.major = true
+		}
+	}
+}
+
+// iterType returns the type of the next iteration.
+func (hj *HookeJeeves) iterType() IterationType {
+	if hj.major {
+		hj.major = false
+		return MajorIteration
+	}
+	return MinorIteration
+}
+
+// Status returns MethodConverge when the step size has fallen below MinStep.
+func (hj *HookeJeeves) Status() (Status, error) {
+	return hj.status, nil
+}
+
+func (*HookeJeeves) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// HandlesBounds returns true. All locations evaluated by HookeJeeves are
+// feasible.
+func (*HookeJeeves) HandlesBounds() bool {
+	return true
+}
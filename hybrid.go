@@ -0,0 +1,170 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// Hybrid implements Powell's hybrid method for systems of nonlinear
+// equations, as in HYBRJ of MINPACK. It is a trust-region method for
+// minimizing ½ |F|_2^2 whose steps are found by the dogleg method: the step
+// is the Newton step -J^{-1} F if it lies within the trust region, and
+// otherwise it is the point on the dogleg path from the minimizer of the
+// linear model along the steepest descent direction -JᵀF to the Newton step
+// at which the path leaves the trust region. The trust region radius is
+// adapted to the ratio of the actual and the predicted reduction of |F|^2.
+//
+// Far from a solution, the steps bend towards the steepest descent direction,
+// which makes Hybrid more robust than NewtonRaphson. Close to a solution with
+// a nonsingular Jacobian the Newton step is taken, and the method converges
+// quadratically. If the Jacobian is singular, Hybrid takes steepest descent
+// steps.
+//
+// References:
+//  - Powell, M.J.D.: A hybrid method for nonlinear equations. In Numerical
+//    Methods for Nonlinear Algebraic Equations, Gordon and Breach (1970),
+//    87-114
+//  - Moré, J.J., Garbow, B.S., Hillstrom, K.E.: User guide for MINPACK-1.
+//    Technical report ANL-80-74, Argonne National Laboratory (1980)
+type Hybrid struct {
+	// InitialRadius is the initial radius of the trust region. If
+	// InitialRadius is zero, it will be set to 100 max(1, |x_0|_2).
+	InitialRadius float64
+
+	x, f   []float64 // Location and F of the last major iteration.
+	fnorm  float64   // |F|^2 at x.
+	jac    *mat64.Dense
+	lu     rootLU
+	newton []float64 // Newton step, valid if hasNewton is true.
+	grad   []float64 // Gradient Jᵀ F of ½ |F|^2.
+	cauchy float64   // Step size of the minimizer along -grad.
+	step   []float64
+	jstep  []float64
+	pred   float64 // Predicted reduction of |F|^2.
+	radius float64
+
+	hasNewton bool
+	trial     bool // Whether F has been evaluated at a trial step.
+}
+
+func (h *Hybrid) Init(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	if h.InitialRadius < 0 {
+		panic("hybrid: negative initial radius")
+	}
+	n := len(loc.X)
+	h.x = resize(h.x, n)
+	h.f = resize(h.f, n)
+	h.jac = resizeDense(h.jac, n, n)
+	h.newton = resize(h.newton, n)
+	h.grad = resize(h.grad, n)
+	h.step = resize(h.step, n)
+	h.jstep = resize(h.jstep, n)
+	copy(h.x, loc.X)
+	copy(h.f, loc.F)
+	h.fnorm = floats.Dot(h.f, h.f)
+	h.radius = h.InitialRadius
+	if h.radius == 0 {
+		h.radius = 100 * math.Max(1, floats.Norm(h.x, 2))
+	}
+	h.trial = false
+	copy(xNext, loc.X)
+	return GradEvaluation, NoIteration, nil
+}
+
+func (h *Hybrid) Iterate(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	n := len(h.x)
+	if !h.trial {
+		// The Jacobian at x has been evaluated.
+		h.jac.Copy(loc.Jacobian)
+		h.hasNewton = h.lu.factorize(h.jac)
+		if h.hasNewton {
+			h.lu.solve(h.newton, h.f)
+			floats.Scale(-1, h.newton)
+		}
+		mat64.NewVector(n, h.grad).MulVec(h.jac, true, mat64.NewVector(n, h.f))
+		gnorm := floats.Norm(h.grad, 2)
+		mat64.NewVector(n, h.jstep).MulVec(h.jac, false, mat64.NewVector(n, h.grad))
+		jgnorm := floats.Norm(h.jstep, 2)
+		if jgnorm == 0 {
+			if !h.hasNewton {
+				// x is a stationary point of |F|^2 that is not a root.
+				return NoEvaluation, NoIteration, ErrSingularJacobian
+			}
+			h.cauchy = 0
+		} else {
+			h.cauchy = (gnorm / jgnorm) * (gnorm / jgnorm)
+		}
+	} else {
+		fnorm := floats.Dot(loc.F, loc.F)
+		rho := (h.fnorm - fnorm) / h.pred
+		snorm := floats.Norm(h.step, 2)
+		switch {
+		case rho < 0.25 || math.IsNaN(fnorm):
+			h.radius = 0.25 * snorm
+		case rho > 0.75:
+			h.radius = math.Max(h.radius, 2*snorm)
+		}
+		if rho > 1e-4 {
+			// The step is accepted.
+			copy(h.x, loc.X)
+			copy(h.f, loc.F)
+			h.fnorm = fnorm
+			h.trial = false
+			copy(xNext, loc.X)
+			return GradEvaluation, MajorIteration, nil
+		}
+		if h.radius <= trustRegionRoundoff*math.Max(1, floats.Norm(h.x, 2)) {
+			return NoEvaluation, NoIteration, ErrNoProgress
+		}
+	}
+
+	h.dogleg()
+	mat64.NewVector(n, h.jstep).MulVec(h.jac, false, mat64.NewVector(n, h.step))
+	floats.Add(h.jstep, h.f)
+	h.pred = h.fnorm - floats.Dot(h.jstep, h.jstep)
+	if h.pred <= 0 {
+		// The linear model cannot be reduced, so x is a solution to
+		// working precision.
+		return NoEvaluation, NoIteration, ErrNoProgress
+	}
+	h.trial = true
+	floats.AddTo(xNext, h.x, h.step)
+	return FuncEvaluation, MinorIteration, nil
+}
+
+// dogleg stores the dogleg step within the trust region in h.step.
+func (h *Hybrid) dogleg() {
+	if h.hasNewton && floats.Norm(h.newton, 2) <= h.radius {
+		copy(h.step, h.newton)
+		return
+	}
+	gnorm := floats.Norm(h.grad, 2)
+	if !h.hasNewton || h.cauchy*gnorm >= h.radius {
+		// Truncate the steepest descent step.
+		t := math.Min(h.cauchy, h.radius/gnorm)
+		copy(h.step, h.grad)
+		floats.Scale(-t, h.step)
+		return
+	}
+	// Find τ such that |p_C + τ (p_N - p_C)| = Δ, where p_C = -cauchy*grad.
+	var a, b, c float64
+	for i, g := range h.grad {
+		pc := -h.cauchy * g
+		d := h.newton[i] - pc
+		a += d * d
+		b += 2 * pc * d
+		c += pc * pc
+	}
+	c -= h.radius * h.radius
+	tau := (-b + math.Sqrt(b*b-4*a*c)) / (2 * a)
+	for i, g := range h.grad {
+		pc := -h.cauchy * g
+		h.step[i] = pc + tau*(h.newton[i]-pc)
+	}
+}
@@ -4,6 +4,8 @@
 
 package optimize
 
+import "github.com/gonum/matrix/mat64"
+
 // LinesearchMethod is a type that can perform a line search. Typically, these
 // methods will not be called by the user directly, as they will be called by
 // a Linesearch struct.
@@ -39,7 +41,51 @@ type NextDirectioner interface {
 	NextDirection(loc *Location, dir []float64) (step float64)
 }
 
+// DirectionRestarter is a NextDirectioner that can discard the information
+// gathered in previous iterations, such as an estimate of the inverse
+// Hessian, and restart with the steepest descent direction. A Linesearch
+// restarts the direction when a line search fails, see
+// Settings.LinesearchRestarts.
+type DirectionRestarter interface {
+	NextDirectioner
+	// RestartDirection is like InitDirection, but loc is the location of
+	// the last major iteration, which has been passed to InitDirection or
+	// NextDirection before. loc.Hessian is not valid.
+	RestartDirection(loc *Location, dir []float64) (step float64)
+}
+
+// Resetter is implemented by Methods that keep state, such as estimates of
+// the Hessian, between runs. Reset discards the state of the last run and
+// releases the workspace, so that the Method behaves like a new value with
+// the same exported fields. The exported fields that were set to their
+// defaults by the last run keep the default values. LinesearchMethods that
+// keep state between line searches implement Resetter as well, and
+// Linesearch resets them at the start of every run.
+type Resetter interface {
+	Reset()
+}
+
+// Cloner is implemented by Methods that can be copied for concurrent runs,
+// for example by MultiStart. Clone returns a new Method with the exported
+// fields of the receiver and none of its state. The components of the
+// Method that keep state, such as the LinesearchMethod, the StepSizer, the
+// CGVariant and the SubproblemSolver, are copied if they are of a type
+// defined in this package. Components of other types, and Preconditioners,
+// are shared by the clones and must be safe for concurrent use.
+type Cloner interface {
+	Clone() Method
+}
+
 // A Method can optimize an objective function.
+//
+// Init starts a new run, so a Method value can be used for sequential runs,
+// also on problems of different dimensions, but not for concurrent runs.
+// Concurrent runs need separate Method values, which can be obtained from a
+// configured Method by Clone if it implements Cloner.
+// Methods allocate their workspace in Init, and reuse it in later runs of
+// the same dimension. The Iterate methods of BFGS, LBFGS, CG and Newton do
+// not allocate, so that the cost of an iteration of these methods on cheap
+// objective functions is not dominated by garbage collection.
 type Method interface {
 	// Initializes the method and returns the first location to evaluate
 	Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error)
@@ -57,6 +103,88 @@ type Method interface {
 	}
 }
 
+// BoundsHandler is implemented by Methods that can minimize functions subject
+// to the bound constraints given by Problem.Bounds. The bounds are available
+// to the Method in ProblemInfo. Local returns an error if a Problem with
+// bounds is solved by a Method that does not handle them.
+type BoundsHandler interface {
+	// HandlesBounds returns whether the Method keeps all evaluated
+	// locations within the bounds.
+	HandlesBounds() bool
+}
+
+// ConstraintHandler is implemented by Methods that can minimize functions
+// subject to the nonlinear constraints given by Problem.Equality and
+// Problem.Inequality. The constraints are available to the Method in
+// ProblemInfo. Local returns an error if a Problem with nonlinear constraints
+// is solved by a Method that does not handle them.
+//
+// The objective function value alone does not determine the best location
+// of a constrained problem, so Local returns the location of the last
+// MajorIteration instead of the location with the lowest function value, and
+// the Method is responsible for signaling convergence through Statuser.
+type ConstraintHandler interface {
+	HandlesConstraints() bool
+}
+
+// ProximalHandler is implemented by Methods that can minimize composite
+// objective functions with the non-smooth term given by Problem.Prox. The
+// proximal operator is available to the Method in ProblemInfo. Local returns
+// an error if a Problem with a proximal term is solved by a Method that does
+// not handle it.
+//
+// The gradient of the smooth part does not vanish at a minimum of a composite
+// objective function, so Local does not check Settings.GradientThreshold, and
+// the Method is responsible for signaling convergence through Statuser.
+type ProximalHandler interface {
+	HandlesProx() bool
+}
+
+// MultiplierEstimator is implemented by ConstraintHandlers that estimate the
+// Lagrange multipliers of the nonlinear constraints. Local stores the
+// estimates at the end of the optimization in Result.Multipliers.
+type MultiplierEstimator interface {
+	// Multipliers stores the current estimates of the multipliers of the
+	// equality and inequality constraints into eq and ineq.
+	Multipliers(eq, ineq []float64)
+}
+
+// CovarianceEstimator is implemented by Methods for least-squares problems
+// that estimate the covariance of the parameters. Local stores the estimate at
+// the returned location in Result.Covariance.
+type CovarianceEstimator interface {
+	// Covariance stores the estimate of the covariance at loc into cov,
+	// which has the dimension of loc.X. It returns whether the estimate is
+	// available.
+	Covariance(cov *mat64.SymDense, loc *Location) bool
+}
+
+// Batcher is implemented by Methods that know in advance several of the
+// locations they will request, for example all members of a generation of a
+// population-based method. If Settings.Concurrent is greater than one, Local
+// evaluates these locations concurrently before the Method requests them one
+// at a time, so the Method itself is unaffected by the concurrency.
+type Batcher interface {
+	// Batch returns the type of the evaluations and the locations that the
+	// Method will request, in order, starting with the location stored into
+	// xNext by the last call to Init or Iterate. The Method must not modify
+	// the returned locations until it has requested them.
+	Batch() (EvaluationType, [][]float64)
+}
+
+// Checkpointer is implemented by Methods whose state can be saved at a major
+// iteration and restored later to resume the optimization, see Checkpoint.
+type Checkpointer interface {
+	// Checkpoint returns the serialized state of the Method after the last
+	// major iteration.
+	Checkpoint() ([]byte, error)
+	// Restore restores the state returned by Checkpoint. It is called by
+	// ResumeLocal instead of Init, with the location of the major iteration
+	// at which the state was saved, and it is followed by a call to Iterate
+	// at that location.
+	Restore(state []byte, loc *Location, p *ProblemInfo) error
+}
+
 // StepSizer can set the next step size of the optimization given the last Location.
 // Returned step size must be positive.
 type StepSizer interface {
@@ -64,6 +192,24 @@ type StepSizer interface {
 	StepSize(loc *Location, dir []float64) float64
 }
 
+// Preconditioner applies the inverse of a symmetric positive definite matrix
+// M, an approximation of the Hessian, to vectors. Gradient-based methods
+// that accept a Preconditioner search along directions transformed by M^{-1},
+// which makes them converge faster on poorly scaled problems.
+type Preconditioner interface {
+	// Apply stores M^{-1} v into dst. dst and v do not overlap.
+	Apply(dst, v []float64)
+}
+
+// PreconditionerUpdater is a Preconditioner that changes with the location.
+// Update is called at the initial location and at every major iteration
+// before the Preconditioner is applied at that location. Update must not
+// modify Location.
+type PreconditionerUpdater interface {
+	Preconditioner
+	Update(loc *Location)
+}
+
 // Statuser returns the status of the Function being optimized. This can be used
 // by the Function to terminate early, for example with an error. The user can
 // use one of the pre-provided Status constants, or may call NewStatus to create
@@ -74,6 +220,7 @@ type Statuser interface {
 
 // A Recorder can record the progress of the optimization, for example to print
 // the progress to StdOut or to a log file. A Recorder must not modify any data.
+// Printer, PrintRecorder, CSVRecorder and JSONRecorder implement Recorder.
 type Recorder interface {
 	Init() error
 	Record(*Location, EvaluationType, IterationType, *Stats) error
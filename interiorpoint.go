@@ -0,0 +1,542 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultInteriorPointGradientTol   = 1e-6
+	defaultInteriorPointConstraintTol = 1e-6
+	defaultFractionToBoundary         = 0.995
+	// interiorPointDecrease is the sufficient decrease parameter of the line
+	// search on the merit function.
+	interiorPointDecrease = 1e-4
+	// interiorPointMinStep is the smallest step size tried by the line
+	// search.
+	interiorPointMinStep = 1e-12
+	// interiorPointPenaltyDecrease is the fraction of the penalty term by
+	// which the merit function must decrease along the step to first order.
+	interiorPointPenaltyDecrease = 0.1
+	// interiorPointPenaltyIncrease is the amount by which the penalty
+	// parameter exceeds its smallest acceptable value when it is increased.
+	interiorPointPenaltyIncrease = 1
+	// interiorPointMinSlack is the smallest initial slack.
+	interiorPointMinSlack = 1e-2
+	// interiorPointInitialBarrier is the initial barrier parameter.
+	interiorPointInitialBarrier = 0.1
+	// interiorPointBarrierTol is the factor of the barrier parameter below
+	// which the error of the barrier problem must be before the barrier
+	// parameter is decreased.
+	interiorPointBarrierTol = 10
+	// interiorPointBarrierDecrease is the largest factor by which the
+	// barrier parameter is decreased.
+	interiorPointBarrierDecrease = 0.2
+)
+
+var errInteriorPointSingular = errors.New("interiorpoint: singular Jacobian of the equality constraints")
+
+// InteriorPoint implements a primal-dual interior-point method for
+// minimization subject to the nonlinear constraints Problem.Inequality and
+// Problem.Equality. The inequality constraints are written as
+// c_I(x) + s = 0 with slacks s > 0, and the method follows the solutions of
+// the barrier problems
+//  minimize    f(x) - μ Σ log(s_i)
+//  subject to  c_E(x) = 0,  c_I(x) + s = 0
+// as the barrier parameter μ decreases to zero. At every major iteration it
+// takes a Newton step on the perturbed optimality conditions
+//  ∇f(x) + J_E(x)ᵀ λ + J_I(x)ᵀ z = 0,
+//  s ∘ z = μ,
+//  c_E(x) = 0,  c_I(x) + s = 0
+// in the variables x, s, λ and z, where the Hessian of the Lagrangian is
+// approximated by damped BFGS updates. The barrier parameter is adapted to
+// the progress of the iteration: it is decreased as soon as the error in the
+// optimality conditions of the current barrier problem is below a multiple
+// of μ, linearly while μ is large and superlinearly close to the solution. The slacks and the multipliers z of the
+// inequality constraints are kept positive by the fraction-to-the-boundary
+// rule, which limits the step to the fraction max(FractionToBoundary, 1-μ) of
+// the distance to the boundary. The primal step is then shortened by a
+// backtracking line search on the merit function
+//  φ(x, s) = f(x) - μ Σ log(s_i) + ν (|c_E(x)|_1 + |c_I(x) + s|_1),
+// whose penalty parameter ν is increased as needed for the step to decrease
+// the violation of the constraints sufficiently. The evaluations of the line
+// search are reported as minor iterations.
+//
+// Evaluated locations need not satisfy the constraints, and InteriorPoint
+// does not handle bounds, which can be given as inequality constraints
+// instead. It requires the Jacobian of the equality constraints to have full
+// row rank.
+//
+// The optimization terminates with MethodConverge when the infinity norm of
+// the gradient of the Lagrangian is below GradientTolerance, and the
+// violation of the constraints and of complementarity is below
+// ConstraintTolerance. InteriorPoint implements MultiplierEstimator, so
+// Result.Multipliers holds the multiplier estimates λ and z at the solution.
+//
+// References:
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Chapter 19
+//  - Vanderbei, R.J., Shanno, D.F.: An interior-point algorithm for nonconvex
+//    nonlinear programming. Comput. Optim. Appl. 13 (1999), 231-252
+type InteriorPoint struct {
+	// GradientTolerance is the tolerance on the infinity norm of the
+	// gradient of the Lagrangian.
+	// If GradientTolerance is zero, it will be set to 1e-6.
+	GradientTolerance float64
+	// ConstraintTolerance is the tolerance on the violation of the
+	// constraints and of complementarity.
+	// If ConstraintTolerance is zero, it will be set to 1e-6.
+	ConstraintTolerance float64
+	// FractionToBoundary is the smallest fraction of the distance to the
+	// boundary of the slacks and of the multipliers that a step may take.
+	// It must be in (0, 1).
+	// If FractionToBoundary is zero, it will be set to 0.995.
+	FractionToBoundary float64
+
+	eq, in Constraint
+	status Status
+	first  bool // Whether the Hessian approximation has not been updated yet.
+
+	hess  *mat64.SymDense // Approximation of the Hessian of the Lagrangian.
+	k     *mat64.SymDense // Hessian with the barrier term of the inequalities.
+	chol  *mat64.TriDense
+	schur *mat64.SymDense // Schur complement of k for the equalities.
+	schc  *mat64.TriDense
+
+	mu      float64   // Barrier parameter.
+	penalty float64   // Penalty parameter of the merit function.
+	lambda  []float64 // Multipliers of the equality constraints.
+	z       []float64 // Multipliers of the inequality constraints.
+	sl      []float64 // Slacks of the inequality constraints.
+
+	// Data at the last major iteration.
+	x, grad        []float64
+	f              float64
+	cEqK, cInK     []float64
+	jacEqK, jacInK *mat64.Dense
+	merit, deriv   float64 // Merit function and its directional derivative.
+
+	// Data at the last evaluated location.
+	cEq, cIn     []float64
+	jacEq, jacIn *mat64.Dense
+
+	dx, ds, dl, dz []float64 // Newton step.
+	maxStep        float64   // Largest step size of x and s.
+	alphaZ         float64   // Step size of the multipliers z.
+	step           float64   // Step size of the line search.
+	slTrial        []float64 // Slacks at the trial location.
+
+	s, y, bs, gl []float64 // Work space of the BFGS update.
+	rhs, w, v    []float64
+	jkj          *mat64.Dense // K⁻¹ J_Eᵀ
+}
+
+func (ip *InteriorPoint) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if ip.GradientTolerance == 0 {
+		ip.GradientTolerance = defaultInteriorPointGradientTol
+	}
+	if ip.ConstraintTolerance == 0 {
+		ip.ConstraintTolerance = defaultInteriorPointConstraintTol
+	}
+	if ip.FractionToBoundary == 0 {
+		ip.FractionToBoundary = defaultFractionToBoundary
+	}
+	if ip.GradientTolerance < 0 {
+		panic("interiorpoint: negative gradient tolerance")
+	}
+	if ip.ConstraintTolerance < 0 {
+		panic("interiorpoint: negative constraint tolerance")
+	}
+	if ip.FractionToBoundary <= 0 || ip.FractionToBoundary >= 1 {
+		panic("interiorpoint: FractionToBoundary not in (0, 1)")
+	}
+
+	dim := len(loc.X)
+	ip.eq = p.Equality
+	ip.in = p.Inequality
+	ip.status = NotTerminated
+	ip.first = true
+	ip.mu = interiorPointInitialBarrier
+	ip.penalty = 0
+
+	var nEq, nIn int
+	if ip.eq != nil {
+		nEq = ip.eq.Len()
+		ip.jacEq = resizeDense(ip.jacEq, nEq, dim)
+		ip.jacEqK = resizeDense(ip.jacEqK, nEq, dim)
+		ip.jkj = resizeDense(ip.jkj, dim, nEq)
+		if ip.schur == nil || ip.schur.Symmetric() != nEq {
+			ip.schur = mat64.NewSymDense(nEq, nil)
+		}
+		ip.schc = resizeTriDense(ip.schc, nEq)
+	}
+	if ip.in != nil {
+		nIn = ip.in.Len()
+		ip.jacIn = resizeDense(ip.jacIn, nIn, dim)
+		ip.jacInK = resizeDense(ip.jacInK, nIn, dim)
+	}
+	ip.cEq = resize(ip.cEq, nEq)
+	ip.cEqK = resize(ip.cEqK, nEq)
+	ip.cIn = resize(ip.cIn, nIn)
+	ip.cInK = resize(ip.cInK, nIn)
+	ip.lambda = resize(ip.lambda, nEq)
+	ip.z = resize(ip.z, nIn)
+	ip.sl = resize(ip.sl, nIn)
+	ip.slTrial = resize(ip.slTrial, nIn)
+	ip.dx = resize(ip.dx, dim)
+	ip.ds = resize(ip.ds, nIn)
+	ip.dl = resize(ip.dl, nEq)
+	ip.dz = resize(ip.dz, nIn)
+	ip.x = resize(ip.x, dim)
+	ip.grad = resize(ip.grad, dim)
+	ip.s = resize(ip.s, dim)
+	ip.y = resize(ip.y, dim)
+	ip.bs = resize(ip.bs, dim)
+	ip.gl = resize(ip.gl, dim)
+	ip.rhs = resize(ip.rhs, dim)
+	ip.w = resize(ip.w, nIn)
+	ip.v = resize(ip.v, dim)
+	if ip.hess == nil || ip.hess.Symmetric() != dim {
+		ip.hess = mat64.NewSymDense(dim, nil)
+		ip.k = mat64.NewSymDense(dim, nil)
+	}
+	for i := 0; i < dim; i++ {
+		for j := i; j < dim; j++ {
+			ip.hess.SetSym(i, j, 0)
+		}
+		ip.hess.SetSym(i, i, 1)
+	}
+	ip.chol = resizeTriDense(ip.chol, dim)
+
+	ip.evaluateConstraints(loc.X)
+	ip.accept(loc)
+	for i := range ip.lambda {
+		ip.lambda[i] = 0
+	}
+	for i, c := range ip.cInK {
+		ip.sl[i] = math.Max(-c, interiorPointMinSlack)
+		ip.z[i] = 1
+	}
+	if ip.converged() {
+		ip.status = MethodConverge
+		ip.step = 0
+		copy(xNext, loc.X)
+		return NoEvaluation, MajorIteration, nil
+	}
+	if err := ip.direction(); err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	return ip.nextStep(xNext)
+}
+
+func (ip *InteriorPoint) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	if ip.step == 0 {
+		// A MajorIteration has been announced and the new Newton step is
+		// tried.
+		return ip.nextStep(xNext)
+	}
+
+	ip.evaluateConstraints(loc.X)
+	floats.AddScaledTo(ip.slTrial, ip.sl, ip.step, ip.ds)
+	if ip.meritFunc(loc.F, ip.cEq, ip.cIn, ip.slTrial) > ip.merit+interiorPointDecrease*ip.step*ip.deriv {
+		ip.step /= 2
+		if ip.step < interiorPointMinStep {
+			return NoEvaluation, NoIteration, ErrLinesearchFailure
+		}
+		floats.AddScaledTo(xNext, ip.x, ip.step, ip.dx)
+		return FuncEvaluation | GradEvaluation, MinorIteration, nil
+	}
+
+	// The step is accepted. Update the multipliers, and the Hessian
+	// approximation with the new multipliers.
+	floats.AddScaled(ip.lambda, ip.alphaZ, ip.dl)
+	floats.AddScaled(ip.z, ip.alphaZ, ip.dz)
+	copy(ip.sl, ip.slTrial)
+	floats.SubTo(ip.s, loc.X, ip.x)
+	ip.gradLagrangian(ip.y, loc.Gradient, ip.jacEq, ip.jacIn)
+	ip.gradLagrangian(ip.gl, ip.grad, ip.jacEqK, ip.jacInK)
+	floats.Sub(ip.y, ip.gl)
+	dampedBFGSUpdate(ip.hess, ip.s, ip.y, ip.bs, ip.first)
+	ip.first = false
+
+	ip.accept(loc)
+	if ip.converged() {
+		ip.status = MethodConverge
+	} else if err := ip.direction(); err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	ip.step = 0
+	copy(xNext, loc.X)
+	return NoEvaluation, MajorIteration, nil
+}
+
+// accept stores loc and the constraints evaluated at loc as the last major
+// iteration.
+func (ip *InteriorPoint) accept(loc *Location) {
+	copy(ip.x, loc.X)
+	copy(ip.grad, loc.Gradient)
+	ip.f = loc.F
+	if ip.eq != nil {
+		ip.jacEqK.Copy(ip.jacEq)
+		copy(ip.cEqK, ip.cEq)
+	}
+	if ip.in != nil {
+		ip.jacInK.Copy(ip.jacIn)
+		copy(ip.cInK, ip.cIn)
+	}
+}
+
+// nextStep starts the line search along the Newton step from the last major
+// iteration.
+func (ip *InteriorPoint) nextStep(xNext []float64) (EvaluationType, IterationType, error) {
+	if ip.deriv >= 0 {
+		return NoEvaluation, NoIteration, ErrNonNegativeStepDirection
+	}
+	ip.step = ip.maxStep
+	floats.AddScaledTo(xNext, ip.x, ip.step, ip.dx)
+	return FuncEvaluation | GradEvaluation, MinorIteration, nil
+}
+
+// direction updates the barrier parameter and computes the Newton step at
+// the last major iteration, the initial step sizes, the penalty parameter and
+// the merit function with its directional derivative.
+func (ip *InteriorPoint) direction() error {
+	n := len(ip.x)
+
+	ip.updateBarrier()
+
+	// The Newton step is computed by eliminating ds and dz:
+	//  (H + J_Iᵀ S⁻¹ Z J_I) dx + J_Eᵀ dλ = -r_d - J_Iᵀ S⁻¹ (r_c + Z r_p),
+	//  J_E dx = -c_E,
+	// with the residuals r_d = ∇f + J_Eᵀ λ + J_Iᵀ z, r_p = c_I + s and
+	// r_c = μ - s ∘ z.
+	ip.gradLagrangian(ip.rhs, ip.grad, ip.jacEqK, ip.jacInK)
+	floats.Scale(-1, ip.rhs)
+	ip.k.CopySym(ip.hess)
+	for i, s := range ip.sl {
+		rp := ip.cInK[i] + s
+		ip.w[i] = (ip.mu - s*ip.z[i] + ip.z[i]*rp) / s
+		sigma := ip.z[i] / s
+		for a := 0; a < n; a++ {
+			ja := ip.jacInK.At(i, a)
+			ip.rhs[a] -= ja * ip.w[i]
+			if ja == 0 {
+				continue
+			}
+			for b := a; b < n; b++ {
+				ip.k.SetSym(a, b, ip.k.At(a, b)+sigma*ja*ip.jacInK.At(i, b))
+			}
+		}
+	}
+	if !ip.chol.Cholesky(ip.k, false) {
+		// The matrix is positive definite in exact arithmetic, so it is
+		// regularized only to overcome rounding errors.
+		var scale float64
+		for i := 0; i < n; i++ {
+			scale = math.Max(scale, ip.k.At(i, i))
+		}
+		for i := 0; i < n; i++ {
+			ip.k.SetSym(i, i, ip.k.At(i, i)+1e-8*scale)
+		}
+		if !ip.chol.Cholesky(ip.k, false) {
+			return errors.New("interiorpoint: Hessian approximation not positive definite")
+		}
+	}
+	dxVec := mat64.NewVector(n, ip.dx)
+	dxVec.SolveCholeskyVec(ip.chol, mat64.NewVector(n, ip.rhs))
+	if ip.eq != nil {
+		// Solve for dλ with the Schur complement J_E K⁻¹ J_Eᵀ and correct
+		// dx = K⁻¹ rhs - K⁻¹ J_Eᵀ dλ.
+		nEq := len(ip.lambda)
+		for i := 0; i < nEq; i++ {
+			mat64.NewVector(n, ip.v).SolveCholeskyVec(ip.chol, mat64.NewVector(n, ip.jacEqK.Row(nil, i)))
+			ip.jkj.SetCol(i, ip.v)
+		}
+		for i := 0; i < nEq; i++ {
+			for j := i; j < nEq; j++ {
+				var v float64
+				for a := 0; a < n; a++ {
+					v += ip.jacEqK.At(i, a) * ip.jkj.At(a, j)
+				}
+				ip.schur.SetSym(i, j, v)
+			}
+			ip.dl[i] = floats.Dot(ip.jacEqK.Row(ip.v, i), ip.dx) + ip.cEqK[i]
+		}
+		if !ip.schc.Cholesky(ip.schur, false) {
+			return errInteriorPointSingular
+		}
+		dl := mat64.NewVector(nEq, ip.dl)
+		dl.SolveCholeskyVec(ip.schc, mat64.NewVector(nEq, append([]float64(nil), ip.dl...)))
+		for a := 0; a < n; a++ {
+			for i, l := range ip.dl {
+				ip.dx[a] -= ip.jkj.At(a, i) * l
+			}
+		}
+	}
+	for i, s := range ip.sl {
+		jdx := floats.Dot(ip.jacInK.Row(ip.v, i), ip.dx)
+		ip.ds[i] = -ip.cInK[i] - s - jdx
+		ip.dz[i] = ip.w[i] + ip.z[i]/s*jdx
+	}
+
+	// Fraction-to-the-boundary rule.
+	tau := math.Max(ip.FractionToBoundary, 1-ip.mu)
+	ip.maxStep = math.Min(1, tau*maxPositiveStep(ip.sl, ip.ds))
+	ip.alphaZ = math.Min(1, tau*maxPositiveStep(ip.z, ip.dz))
+
+	// The Newton step satisfies the linearized constraints, so the
+	// directional derivative of the penalty term is minus the penalty term.
+	// The penalty parameter is increased such that the directional
+	// derivative is sufficiently negative.
+	ip.deriv = floats.Dot(ip.grad, ip.dx)
+	for i, s := range ip.sl {
+		ip.deriv -= ip.mu * ip.ds[i] / s
+	}
+	viol := ip.violation(ip.cEqK, ip.cInK, ip.sl)
+	if viol > 0 {
+		mat64.NewVector(n, ip.v).MulVec(ip.hess, false, mat64.NewVector(n, ip.dx))
+		nu := (ip.deriv + 0.5*floats.Dot(ip.dx, ip.v)) / ((1 - interiorPointPenaltyDecrease) * viol)
+		if ip.penalty < nu {
+			ip.penalty = nu + interiorPointPenaltyIncrease
+		}
+	}
+	ip.deriv -= ip.penalty * viol
+	ip.merit = ip.meritFunc(ip.f, ip.cEqK, ip.cInK, ip.sl)
+	return nil
+}
+
+// updateBarrier decreases the barrier parameter while the last major
+// iteration solves the barrier problem to a tolerance proportional to the
+// barrier parameter.
+func (ip *InteriorPoint) updateBarrier() {
+	if len(ip.sl) == 0 {
+		ip.mu = 0
+		return
+	}
+	minMu := math.Min(ip.GradientTolerance, ip.ConstraintTolerance) / 10
+	ip.gradLagrangian(ip.gl, ip.grad, ip.jacEqK, ip.jacInK)
+	errBase := floats.Norm(ip.gl, math.Inf(1))
+	for _, c := range ip.cEqK {
+		errBase = math.Max(errBase, math.Abs(c))
+	}
+	for i, c := range ip.cInK {
+		errBase = math.Max(errBase, math.Abs(c+ip.sl[i]))
+	}
+	for ip.mu > minMu {
+		e := errBase
+		for i, s := range ip.sl {
+			e = math.Max(e, math.Abs(s*ip.z[i]-ip.mu))
+		}
+		if e > interiorPointBarrierTol*ip.mu {
+			return
+		}
+		ip.mu = math.Max(minMu, math.Min(interiorPointBarrierDecrease*ip.mu, math.Pow(ip.mu, 1.5)))
+	}
+}
+
+// converged returns whether the first-order optimality conditions hold at the
+// last major iteration.
+func (ip *InteriorPoint) converged() bool {
+	ip.gradLagrangian(ip.gl, ip.grad, ip.jacEqK, ip.jacInK)
+	if floats.Norm(ip.gl, math.Inf(1)) > ip.GradientTolerance {
+		return false
+	}
+	var viol float64
+	for _, c := range ip.cEqK {
+		viol = math.Max(viol, math.Abs(c))
+	}
+	for i, c := range ip.cInK {
+		viol = math.Max(viol, math.Max(c, math.Abs(ip.z[i]*c)))
+	}
+	return viol <= ip.ConstraintTolerance
+}
+
+// gradLagrangian stores the gradient of the Lagrangian for the gradient grad
+// and the Jacobians jacEq and jacIn into dst.
+func (ip *InteriorPoint) gradLagrangian(dst, grad []float64, jacEq, jacIn *mat64.Dense) {
+	copy(dst, grad)
+	if ip.eq != nil {
+		addJacTVec(dst, jacEq, ip.lambda)
+	}
+	if ip.in != nil {
+		addJacTVec(dst, jacIn, ip.z)
+	}
+}
+
+// meritFunc returns the merit function for the function value f, the
+// constraint values cEq and cIn and the slacks sl.
+func (ip *InteriorPoint) meritFunc(f float64, cEq, cIn, sl []float64) float64 {
+	phi := f + ip.penalty*ip.violation(cEq, cIn, sl)
+	for _, s := range sl {
+		phi -= ip.mu * math.Log(s)
+	}
+	return phi
+}
+
+// violation returns the l1 norm of the violation of the constraints with the
+// slacks sl.
+func (ip *InteriorPoint) violation(cEq, cIn, sl []float64) float64 {
+	viol := floats.Norm(cEq, 1)
+	for i, c := range cIn {
+		viol += math.Abs(c + sl[i])
+	}
+	return viol
+}
+
+// evaluateConstraints evaluates the constraint functions and their Jacobians
+// at x.
+func (ip *InteriorPoint) evaluateConstraints(x []float64) {
+	if ip.eq != nil {
+		ip.eq.Func(x, ip.cEq)
+		ip.eq.Jac(x, ip.jacEq)
+	}
+	if ip.in != nil {
+		ip.in.Func(x, ip.cIn)
+		ip.in.Jac(x, ip.jacIn)
+	}
+}
+
+// Status returns MethodConverge when the first-order optimality conditions
+// hold at the last major iteration.
+func (ip *InteriorPoint) Status() (Status, error) {
+	return ip.status, nil
+}
+
+// Multipliers stores the current multiplier estimates into eq and ineq.
+func (ip *InteriorPoint) Multipliers(eq, ineq []float64) {
+	copy(eq, ip.lambda)
+	copy(ineq, ip.z)
+}
+
+func (*InteriorPoint) HandlesConstraints() bool {
+	return true
+}
+
+func (*InteriorPoint) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// maxPositiveStep returns the largest step α such that v + α dv is
+// non-negative.
+func maxPositiveStep(v, dv []float64) float64 {
+	alpha := math.Inf(1)
+	for i, d := range dv {
+		if d < 0 {
+			alpha = math.Min(alpha, -v[i]/d)
+		}
+	}
+	return alpha
+}
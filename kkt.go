@@ -0,0 +1,100 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// KKTReport holds the residuals of the Karush-Kuhn-Tucker conditions, the
+// first-order optimality conditions of a Problem with constraints, at a
+// location x with the multipliers λ of the equality constraints and μ of
+// the inequality constraints. All residuals are zero at a KKT point.
+type KKTReport struct {
+	// Stationarity is the infinity norm of the gradient of the Lagrangian
+	//  ∇f(x) + J_E(x)ᵀλ + J_I(x)ᵀμ,
+	// projected as by Bounds.ProjectedGradientNorm if the problem has
+	// bounds, so that the multipliers of the bounds are not needed.
+	Stationarity float64
+	// PrimalFeasibility is the infinity norm of the violation of the
+	// equality constraints, the inequality constraints and the bounds.
+	PrimalFeasibility float64
+	// DualFeasibility is the largest negative part of the multipliers of
+	// the inequality constraints, max_i max(0, -μ_i).
+	DualFeasibility float64
+	// Complementarity is the largest violation of complementary slackness,
+	// max_i |μ_i c_I,i(x)|.
+	Complementarity float64
+}
+
+// Satisfied returns whether all residuals in r are at most tol.
+func (r *KKTReport) Satisfied(tol float64) bool {
+	return r.Stationarity <= tol && r.PrimalFeasibility <= tol &&
+		r.DualFeasibility <= tol && r.Complementarity <= tol
+}
+
+// CheckKKT evaluates the residuals of the Karush-Kuhn-Tucker conditions of
+// the problem p at x with the multipliers mult, for example the ones returned
+// in Result.Multipliers, to verify the quality of a solution. The multipliers
+// of the nonlinear constraints that p does not have are ignored, and mult can
+// be nil if p has no nonlinear constraints.
+//
+// CheckKKT panics if p.Grad is nil, if mult is nil for a problem with nonlinear
+// constraints, or if the lengths of the multipliers do not match the numbers
+// of constraints.
+func CheckKKT(p Problem, x []float64, mult *Multipliers) *KKTReport {
+	if p.Grad == nil {
+		panic("optimize: gradient is undefined")
+	}
+	if mult == nil && p.constrained() {
+		panic("optimize: multipliers are undefined")
+	}
+	dim := len(x)
+	r := &KKTReport{}
+
+	grad := make([]float64, dim)
+	p.Grad(x, grad)
+	if p.Equality != nil {
+		n := p.Equality.Len()
+		if len(mult.Equality) != n {
+			panic("optimize: multiplier length mismatch")
+		}
+		c := make([]float64, n)
+		p.Equality.Func(x, c)
+		for _, v := range c {
+			r.PrimalFeasibility = math.Max(r.PrimalFeasibility, math.Abs(v))
+		}
+		jac := mat64.NewDense(n, dim, nil)
+		p.Equality.Jac(x, jac)
+		addJacTVec(grad, jac, mult.Equality)
+	}
+	if p.Inequality != nil {
+		n := p.Inequality.Len()
+		if len(mult.Inequality) != n {
+			panic("optimize: multiplier length mismatch")
+		}
+		c := make([]float64, n)
+		p.Inequality.Func(x, c)
+		for i, v := range c {
+			mu := mult.Inequality[i]
+			r.PrimalFeasibility = math.Max(r.PrimalFeasibility, v)
+			r.DualFeasibility = math.Max(r.DualFeasibility, -mu)
+			r.Complementarity = math.Max(r.Complementarity, math.Abs(mu*v))
+		}
+		jac := mat64.NewDense(n, dim, nil)
+		p.Inequality.Jac(x, jac)
+		addJacTVec(grad, jac, mult.Inequality)
+	}
+	if p.Bounds != nil {
+		for i, v := range x {
+			r.PrimalFeasibility = math.Max(r.PrimalFeasibility, p.Bounds.lower(i)-v)
+			r.PrimalFeasibility = math.Max(r.PrimalFeasibility, v-p.Bounds.upper(i))
+		}
+	}
+	r.Stationarity = p.Bounds.ProjectedGradientNorm(x, grad)
+	return r
+}
@@ -0,0 +1,85 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/optimize/functions"
+)
+
+func TestCheckKKT(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		f    functions.ConstrainedFunction
+	}{
+		{"HS6", functions.HS6{}},
+		{"HS12", functions.HS12{}},
+		{"HS43", functions.HS43{}},
+		{"HS100", functions.HS100{}},
+	} {
+		p := Problem{
+			Func:       test.f.Func,
+			Grad:       test.f.Grad,
+			Equality:   test.f.Equality(),
+			Inequality: test.f.Inequality(),
+		}
+		kkt := test.f.KKTPoints()[0]
+		mult := &Multipliers{Equality: kkt.Equality, Inequality: kkt.Inequality}
+		r := CheckKKT(p, kkt.X, mult)
+		if !r.Satisfied(1e-9) {
+			t.Errorf("%s: KKT conditions not satisfied at the solution: %+v", test.name, r)
+		}
+
+		// Moving away from the solution violates stationarity.
+		x := make([]float64, len(kkt.X))
+		copy(x, kkt.X)
+		x[0] += 0.1
+		if r := CheckKKT(p, x, mult); r.Satisfied(1e-3) {
+			t.Errorf("%s: KKT conditions satisfied away from the solution: %+v", test.name, r)
+		}
+	}
+
+	// The active inequality constraint of HS12 with a multiplier of the
+	// wrong sign and an inactive one with a non-zero multiplier.
+	f := functions.HS12{}
+	p := Problem{Func: f.Func, Grad: f.Grad, Inequality: f.Inequality()}
+	kkt := f.KKTPoints()[0]
+	r := CheckKKT(p, kkt.X, &Multipliers{Inequality: []float64{-kkt.Inequality[0]}})
+	if r.DualFeasibility != kkt.Inequality[0] || r.Stationarity == 0 {
+		t.Errorf("unexpected report for a negative multiplier: %+v", r)
+	}
+	r = CheckKKT(p, []float64{0, 0}, &Multipliers{Inequality: []float64{1}})
+	if r.Complementarity != 25 || r.PrimalFeasibility != 0 {
+		t.Errorf("unexpected report for an inactive constraint: %+v", r)
+	}
+
+	// The multiplier of an active bound is not needed.
+	p = Problem{
+		Func: func(x []float64) float64 { return (x[0] - 2) * (x[0] - 2) },
+		Grad: func(x, grad []float64) { grad[0] = 2 * (x[0] - 2) },
+		Bounds: &Bounds{
+			Upper: []float64{1},
+		},
+	}
+	if r := CheckKKT(p, []float64{1}, nil); !r.Satisfied(0) {
+		t.Errorf("KKT conditions not satisfied at an active bound: %+v", r)
+	}
+	if r := CheckKKT(p, []float64{1.5}, nil); r.PrimalFeasibility != 0.5 {
+		t.Errorf("unexpected violation of the bound: %+v", r)
+	}
+
+	// The solution of a constrained Method satisfies the conditions.
+	hs := functions.HS43{}
+	p = Problem{Func: hs.Func, Grad: hs.Grad, Inequality: hs.Inequality()}
+	res, err := Local(p, []float64{0, 0, 0, 0}, nil, &InteriorPoint{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := CheckKKT(p, res.X, res.Multipliers); !r.Satisfied(1e-5) || math.IsNaN(r.Stationarity) {
+		t.Errorf("KKT conditions not satisfied at the solution of InteriorPoint: %+v", r)
+	}
+}
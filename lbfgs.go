@@ -1,3 +1,7 @@
+// Copyright ©2014 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
 package optimize
 
 import (
@@ -13,12 +17,26 @@ import (
 // for very large problems. This "forgetful" nature of LBFGS may also make it perform
 // better than BFGS for functions with Hessians that vary rapidly spatially.
 //
-// If Store is 0, Store is defaulted to 15.
+// If Store is 0, Store is defaulted to 15. LBFGS panics if Store is negative.
 // A LinesearchMethod for LBFGS must satisfy the strong Wolfe conditions at every
 // iteration. If LinesearchMethod == nil, an appropriate default is chosen.
+//
+// If Preconditioner is not nil, the initial approximation of the inverse
+// Hessian in every iteration is γ M^{-1} instead of the scaled identity γ I,
+// with the scaling factor γ = s_k·y_k / y_k·M^{-1}y_k.
+//
+// If InitialDiagonal is not nil, it holds the diagonal of the approximation of
+// the inverse Hessian at the initial location, and the first step is a
+// quasi-Newton step with it instead of a steepest descent step of unit length.
+// Warm starting with the curvature of a related problem, for example the
+// reciprocal diagonal of its Hessian at the solution, can save iterations when
+// a sequence of related problems is solved. The elements of InitialDiagonal
+// must be positive.
 type LBFGS struct {
 	LinesearchMethod LinesearchMethod
 	Store            int // how many past iterations to store
+	Preconditioner   Preconditioner
+	InitialDiagonal  []float64
 
 	linesearch *Linesearch
 
@@ -31,6 +49,7 @@ type LBFGS struct {
 	y []float64 // holds g_{k+1} - g_k
 	s []float64 // holds x_{k+1} - x_k
 	a []float64 // holds cache of hessian updates
+	p []float64 // holds preconditioned vectors
 
 	// History
 	yHist   [][]float64 // last Store iterations of y
@@ -61,6 +80,9 @@ func (l *LBFGS) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	if l.Store == 0 {
 		l.Store = 15
 	}
+	if l.Store < 0 {
+		panic("lbfgs: Store must not be negative")
+	}
 
 	l.oldest = l.Store - 1 // the first vector will be put in at 0
 
@@ -97,12 +119,33 @@ func (l *LBFGS) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 		}
 	}
 
-	copy(dir, loc.Gradient)
+	if l.Preconditioner != nil {
+		l.p = resize(l.p, dim)
+		updatePreconditioner(l.Preconditioner, loc)
+	}
+	if l.InitialDiagonal != nil {
+		if len(l.InitialDiagonal) != dim {
+			panic("lbfgs: initial diagonal size mismatch")
+		}
+		floats.MulTo(dir, l.InitialDiagonal, loc.Gradient)
+		floats.Scale(-1, dir)
+		return 1
+	}
+	if l.Preconditioner != nil {
+		l.Preconditioner.Apply(dir, loc.Gradient)
+	} else {
+		copy(dir, loc.Gradient)
+	}
 	floats.Scale(-1, dir)
 
 	return 1 / floats.Norm(dir, 2)
 }
 
+// RestartDirection discards the stored updates of the inverse Hessian.
+func (l *LBFGS) RestartDirection(loc *Location, dir []float64) (stepSize float64) {
+	return l.InitDirection(loc, dir)
+}
+
 func (l *LBFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	if len(loc.X) != l.dim {
 		panic("lbfgs: unexpected size mismatch")
@@ -143,8 +186,17 @@ func (l *LBFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	}
 
 	// Scale the initial Hessian.
-	gamma := sDotY / floats.Dot(l.y, l.y)
-	floats.Scale(gamma, dir)
+	if l.Preconditioner != nil {
+		updatePreconditioner(l.Preconditioner, loc)
+		l.Preconditioner.Apply(l.p, l.y)
+		gamma := sDotY / floats.Dot(l.y, l.p)
+		l.Preconditioner.Apply(l.p, dir)
+		copy(dir, l.p)
+		floats.Scale(gamma, dir)
+	} else {
+		gamma := sDotY / floats.Dot(l.y, l.y)
+		floats.Scale(gamma, dir)
+	}
 
 	for i := 0; i < l.Store; i++ {
 		idx := i + l.oldest
@@ -159,6 +211,96 @@ func (l *LBFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	return 1
 }
 
+// lbfgsState is the serializable state of LBFGS.
+type lbfgsState struct {
+	X       []float64
+	Grad    []float64
+	Oldest  int
+	YHist   [][]float64
+	SHist   [][]float64
+	RhoHist []float64
+}
+
+// Checkpoint returns the state of LBFGS after the last major iteration.
+func (l *LBFGS) Checkpoint() ([]byte, error) {
+	return encodeState(lbfgsState{
+		X:       l.x,
+		Grad:    l.grad,
+		Oldest:  l.oldest,
+		YHist:   l.yHist,
+		SHist:   l.sHist,
+		RhoHist: l.rhoHist,
+	})
+}
+
+// Restore restores the state of LBFGS saved by Checkpoint. The Store of l
+// must be the same as when the state was saved.
+func (l *LBFGS) Restore(state []byte, loc *Location, p *ProblemInfo) error {
+	var st lbfgsState
+	if err := decodeState(state, &st); err != nil {
+		return err
+	}
+	dim := len(loc.X)
+	if l.Store == 0 {
+		l.Store = 15
+	}
+	if len(st.X) != dim || len(st.Grad) != dim || len(st.RhoHist) != l.Store ||
+		len(st.YHist) != l.Store || len(st.SHist) != l.Store {
+		return errCheckpointMismatch
+	}
+	for i := range st.YHist {
+		// Gob does not distinguish empty and nil slices.
+		st.YHist[i] = resize(st.YHist[i], dim)
+		st.SHist[i] = resize(st.SHist[i], dim)
+	}
+	if l.LinesearchMethod == nil {
+		l.LinesearchMethod = &Bisection{}
+	}
+	if l.linesearch == nil {
+		l.linesearch = &Linesearch{}
+	}
+	l.linesearch.Method = l.LinesearchMethod
+	l.linesearch.NextDirectioner = l
+	l.linesearch.resume(p, dim)
+
+	l.dim = dim
+	l.oldest = st.Oldest
+	l.x = st.X
+	l.grad = st.Grad
+	l.y = resize(l.y, dim)
+	l.s = resize(l.s, dim)
+	l.a = resize(l.a, l.Store)
+	if l.Preconditioner != nil {
+		l.p = resize(l.p, dim)
+	}
+	l.yHist = st.YHist
+	l.sHist = st.SHist
+	l.rhoHist = st.RhoHist
+	return nil
+}
+
+// Reset discards the history of steps and gradient differences of the last
+// run, keeping the exported fields.
+func (l *LBFGS) Reset() {
+	*l = LBFGS{
+		LinesearchMethod: l.LinesearchMethod,
+		Store:            l.Store,
+		Preconditioner:   l.Preconditioner,
+		InitialDiagonal:  l.InitialDiagonal,
+	}
+}
+
+// Clone returns a new LBFGS with the configuration of l and a copy of its
+// LinesearchMethod.
+func (l *LBFGS) Clone() Method {
+	return &LBFGS{
+		LinesearchMethod: cloneLinesearchMethod(l.LinesearchMethod),
+		Store:            l.Store,
+		Preconditioner:   l.Preconditioner,
+		InitialDiagonal:  l.InitialDiagonal,
+	}
+}
+
 func (*LBFGS) Needs() struct {
 	Gradient bool
 	Hessian  bool
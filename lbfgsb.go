@@ -0,0 +1,401 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonum/floats"
+)
+
+// Relative tolerance of the curvature condition sᵀy > tol*yᵀy below which a
+// correction pair is not stored.
+const lbfgsbCurvatureTol = 2.2e-16
+
+// LBFGSB implements a limited-memory BFGS method for minimization subject to
+// bound constraints in the style of L-BFGS-B. Every iteration consists of
+// three stages:
+//  - The generalized Cauchy point is computed as the first local minimizer
+//    of the quadratic model along the projected steepest descent path
+//    P(x - t∇f), t >= 0. The variables that are at a bound at the generalized
+//    Cauchy point form the active set.
+//  - The quadratic model is minimized over the remaining free variables by
+//    the conjugate gradient method, and the result is truncated so that it
+//    stays within the bounds.
+//  - A backtracking line search satisfying the Armijo condition is performed
+//    along the direction towards this point.
+// The model Hessian is the limited-memory BFGS approximation built from the
+// last Store correction pairs. It is accessed only through matrix-vector
+// products, and the cost of an iteration is O(Store^2 * dim) plus
+// O(Store * dim) per encountered breakpoint and per conjugate gradient
+// iteration.
+//
+// Unlike the original algorithm, LBFGSB does not use a line search satisfying
+// the Wolfe conditions, and correction pairs that violate the curvature
+// condition are skipped instead.
+//
+// If Store is 0, it will be set to 15. LBFGSB panics if Store is negative.
+//
+// Reference:
+//  Byrd, R.H., Lu, P., Nocedal, J., Zhu, C.: A limited memory algorithm for
+//  bound constrained optimization. SIAM J Sci Comput 16 (1995), 1190-1208
+type LBFGSB struct {
+	Store int // how many past iterations to store
+
+	linesearch *Linesearch
+
+	bounds *Bounds
+	dim    int
+
+	x    []float64 // location at the last major iteration
+	grad []float64 // gradient at the last major iteration
+	s    []float64 // holds x_{k+1} - x_k
+	y    []float64 // holds g_{k+1} - g_k
+
+	// History of correction pairs ordered from the oldest to the newest.
+	n     int         // number of stored pairs
+	sHist [][]float64 // x_{k+1} - x_k
+	yHist [][]float64 // g_{k+1} - g_k
+	bHist [][]float64 // B_k s_k, where B_k is built from the older pairs
+	ys    []float64   // yᵀs
+	sbs   []float64   // sᵀB_k s
+	theta float64     // scaling of the initial approximation θI
+
+	brk   []float64 // breakpoints of the projected steepest descent path
+	order []int     // indices of variables with finite positive breakpoint
+	free  []int
+	xc    []float64 // generalized Cauchy point
+	z     []float64
+	d     []float64
+	bz    []float64
+	bd    []float64
+	col   []float64
+	unit  []float64
+	r     []float64
+	p     []float64
+	bp    []float64
+	du    []float64
+}
+
+func (l *LBFGSB) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	l.bounds = p.Bounds
+	if l.bounds == nil {
+		l.bounds = &Bounds{}
+	}
+	if l.linesearch == nil {
+		l.linesearch = &Linesearch{}
+	}
+	l.linesearch.Method = &Backtracking{}
+	l.linesearch.NextDirectioner = l
+	return l.linesearch.Init(loc, p, xNext)
+}
+
+func (l *LBFGSB) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	return l.linesearch.Iterate(loc, xNext)
+}
+
+func (l *LBFGSB) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	l.dim = dim
+
+	if l.Store == 0 {
+		l.Store = 15
+	}
+	if l.Store < 0 {
+		panic("lbfgsb: Store must not be negative")
+	}
+
+	l.x = resize(l.x, dim)
+	l.grad = resize(l.grad, dim)
+	copy(l.x, loc.X)
+	copy(l.grad, loc.Gradient)
+	l.s = resize(l.s, dim)
+	l.y = resize(l.y, dim)
+
+	l.n = 0
+	l.theta = 1
+	l.sHist = resizeHistory(l.sHist, l.Store, dim)
+	l.yHist = resizeHistory(l.yHist, l.Store, dim)
+	l.bHist = resizeHistory(l.bHist, l.Store, dim)
+	l.ys = resize(l.ys, l.Store)
+	l.sbs = resize(l.sbs, l.Store)
+
+	l.brk = resize(l.brk, dim)
+	l.xc = resize(l.xc, dim)
+	l.z = resize(l.z, dim)
+	l.d = resize(l.d, dim)
+	l.bz = resize(l.bz, dim)
+	l.bd = resize(l.bd, dim)
+	l.col = resize(l.col, dim)
+	l.unit = resize(l.unit, dim)
+	l.r = resize(l.r, dim)
+	l.p = resize(l.p, dim)
+	l.bp = resize(l.bp, dim)
+	l.du = resize(l.du, dim)
+
+	l.direction(loc, dir)
+	// Without curvature information, limit the length of the first step.
+	return math.Min(1, 1/floats.Norm(dir, 2))
+}
+
+func (l *LBFGSB) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	if len(loc.X) != l.dim {
+		panic("lbfgsb: unexpected size mismatch")
+	}
+	if len(loc.Gradient) != l.dim {
+		panic("lbfgsb: unexpected size mismatch")
+	}
+	if len(dir) != l.dim {
+		panic("lbfgsb: unexpected size mismatch")
+	}
+
+	l.update(loc)
+	copy(l.x, loc.X)
+	copy(l.grad, loc.Gradient)
+
+	l.direction(loc, dir)
+	return 1
+}
+
+// update stores the correction pair from the last major iteration to loc if
+// it satisfies the curvature condition, and recomputes the products B_k s_k.
+func (l *LBFGSB) update(loc *Location) {
+	floats.SubTo(l.s, loc.X, l.x)
+	floats.SubTo(l.y, loc.Gradient, l.grad)
+	ys := floats.Dot(l.y, l.s)
+	yy := floats.Dot(l.y, l.y)
+	if ys <= lbfgsbCurvatureTol*yy {
+		return
+	}
+	if l.n == l.Store {
+		// Discard the oldest pair and reuse its storage for the newest.
+		l.sHist = append(l.sHist[1:], l.sHist[0])
+		l.yHist = append(l.yHist[1:], l.yHist[0])
+		l.bHist = append(l.bHist[1:], l.bHist[0])
+		copy(l.ys, l.ys[1:])
+		l.n--
+	}
+	copy(l.sHist[l.n], l.s)
+	copy(l.yHist[l.n], l.y)
+	l.ys[l.n] = ys
+	l.n++
+	l.theta = yy / ys
+
+	// B_k s_k depends on θ, so it must be recomputed for all pairs.
+	for k := 0; k < l.n; k++ {
+		l.hessVecPairs(l.bHist[k], l.sHist[k], k)
+		l.sbs[k] = floats.Dot(l.sHist[k], l.bHist[k])
+	}
+}
+
+// hessVec stores B v into dst.
+func (l *LBFGSB) hessVec(dst, v []float64) {
+	l.hessVecPairs(dst, v, l.n)
+}
+
+// hessVecPairs stores B v into dst, where B is built from the oldest n
+// correction pairs by the recursion
+//  B_{k+1} = B_k - (B_k s_k)(B_k s_k)ᵀ/(s_kᵀB_k s_k) + y_k y_kᵀ/(y_kᵀs_k).
+func (l *LBFGSB) hessVecPairs(dst, v []float64, n int) {
+	copy(dst, v)
+	floats.Scale(l.theta, dst)
+	for k := 0; k < n; k++ {
+		floats.AddScaled(dst, -floats.Dot(l.bHist[k], v)/l.sbs[k], l.bHist[k])
+		floats.AddScaled(dst, floats.Dot(l.yHist[k], v)/l.ys[k], l.yHist[k])
+	}
+}
+
+// direction computes the search direction at loc and stores it into dir.
+func (l *LBFGSB) direction(loc *Location, dir []float64) {
+	l.cauchyPoint(loc.X, loc.Gradient)
+	// subspaceMin keeps the step to the Cauchy point in l.z.
+	l.subspaceMin(loc.X, loc.Gradient)
+	floats.SubTo(dir, l.xc, loc.X)
+	if floats.Dot(dir, loc.Gradient) >= 0 {
+		// Rounding errors have spoiled the subspace minimization, but the
+		// generalized Cauchy point is a descent direction unless x is
+		// stationary.
+		copy(dir, l.z)
+	}
+}
+
+// cauchyPoint computes the generalized Cauchy point at x and stores it into
+// l.xc. The step from x to the Cauchy point is stored into l.z.
+func (l *LBFGSB) cauchyPoint(x, grad []float64) {
+	l.order = l.order[:0]
+	for i, v := range x {
+		g := grad[i]
+		t := math.Inf(1)
+		switch {
+		case g < 0:
+			t = (v - l.bounds.upper(i)) / g
+		case g > 0:
+			t = (v - l.bounds.lower(i)) / g
+		}
+		l.brk[i] = t
+		l.d[i] = -g
+		if t == 0 {
+			l.d[i] = 0
+		}
+		if t > 0 && !math.IsInf(t, 1) {
+			l.order = append(l.order, i)
+		}
+		l.z[i] = 0
+		l.bz[i] = 0
+		l.unit[i] = 0
+	}
+	sort.Sort(breakpoints{l.order, l.brk})
+
+	// Along the segment starting at the current breakpoint, the model is
+	//  m(z + Δt d) = m(z) + Δt f' + ½ Δt^2 f'',
+	// with f' = ∇fᵀd + zᵀB d and f'' = dᵀB d.
+	l.hessVec(l.bd, l.d)
+	fp := floats.Dot(grad, l.d)
+	fpp := floats.Dot(l.d, l.bd)
+	var tOld float64
+	done := false
+	for _, i := range l.order {
+		if fp >= 0 {
+			done = true
+			break
+		}
+		dt := l.brk[i] - tOld
+		if fpp > 0 && -fp/fpp < dt {
+			floats.AddScaled(l.z, -fp/fpp, l.d)
+			done = true
+			break
+		}
+		floats.AddScaled(l.z, dt, l.d)
+		floats.AddScaled(l.bz, dt, l.bd)
+
+		// Variable i reaches its bound and is fixed from now on.
+		di := l.d[i]
+		if di < 0 {
+			l.z[i] = l.bounds.lower(i) - x[i]
+		} else {
+			l.z[i] = l.bounds.upper(i) - x[i]
+		}
+		l.d[i] = 0
+		l.unit[i] = 1
+		l.hessVec(l.col, l.unit)
+		l.unit[i] = 0
+		floats.AddScaled(l.bd, -di, l.col)
+
+		fp = floats.Dot(grad, l.d) + floats.Dot(l.bz, l.d)
+		fpp = floats.Dot(l.d, l.bd)
+		tOld = l.brk[i]
+	}
+	if !done && fp < 0 && fpp > 0 {
+		// The last segment is not bounded.
+		floats.AddScaled(l.z, -fp/fpp, l.d)
+	}
+	floats.AddTo(l.xc, x, l.z)
+	l.bounds.Project(l.xc)
+}
+
+// subspaceMin minimizes the quadratic model over the variables that are free
+// at the generalized Cauchy point by the conjugate gradient method, starting
+// from the Cauchy point. The result is truncated to stay within the bounds
+// and stored into l.xc.
+func (l *LBFGSB) subspaceMin(x, grad []float64) {
+	floats.SubTo(l.z, l.xc, x)
+	l.free = l.free[:0]
+	for i, v := range l.xc {
+		if v > l.bounds.lower(i) && v < l.bounds.upper(i) {
+			l.free = append(l.free, i)
+		}
+	}
+	if len(l.free) == 0 {
+		return
+	}
+
+	// Reduced gradient r = Zᵀ(∇f + B(xc - x)).
+	l.hessVec(l.r, l.z)
+	floats.Add(l.r, grad)
+	for i := range l.p {
+		l.p[i] = 0
+		l.du[i] = 0
+	}
+	var rr float64
+	for _, i := range l.free {
+		l.p[i] = -l.r[i]
+		rr += l.r[i] * l.r[i]
+	}
+	tol := 1e-10 * math.Sqrt(rr)
+	for iter := 0; iter < len(l.free) && math.Sqrt(rr) > tol; iter++ {
+		// Multiply by ZᵀBZ. l.p is zero on the fixed variables.
+		l.hessVec(l.bp, l.p)
+		var pBp float64
+		for _, i := range l.free {
+			pBp += l.p[i] * l.bp[i]
+		}
+		if pBp <= 0 {
+			break
+		}
+		alpha := rr / pBp
+		var rrNext float64
+		for _, i := range l.free {
+			l.du[i] += alpha * l.p[i]
+			l.r[i] += alpha * l.bp[i]
+			rrNext += l.r[i] * l.r[i]
+		}
+		beta := rrNext / rr
+		for _, i := range l.free {
+			l.p[i] = -l.r[i] + beta*l.p[i]
+		}
+		rr = rrNext
+	}
+
+	// Truncate the step so that xc + α du is feasible.
+	alpha := 1.0
+	for _, i := range l.free {
+		switch du := l.du[i]; {
+		case du > 0:
+			alpha = math.Min(alpha, (l.bounds.upper(i)-l.xc[i])/du)
+		case du < 0:
+			alpha = math.Min(alpha, (l.bounds.lower(i)-l.xc[i])/du)
+		}
+	}
+	floats.AddScaled(l.xc, alpha, l.du)
+	l.bounds.Project(l.xc)
+}
+
+func (*LBFGSB) HandlesBounds() bool {
+	return true
+}
+
+func (*LBFGSB) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// resizeHistory returns a slice of n vectors of length dim, reusing the
+// storage in h if possible.
+func resizeHistory(h [][]float64, n, dim int) [][]float64 {
+	if cap(h) < n {
+		h = append(h[:cap(h)], make([][]float64, n-cap(h))...)
+	}
+	h = h[:n]
+	for i := range h {
+		h[i] = resize(h[i], dim)
+	}
+	return h
+}
+
+// breakpoints sorts variable indices by their breakpoints.
+type breakpoints struct {
+	idx []int
+	t   []float64
+}
+
+func (b breakpoints) Len() int           { return len(b.idx) }
+func (b breakpoints) Less(i, j int) bool { return b.t[b.idx[i]] < b.t[b.idx[j]] }
+func (b breakpoints) Swap(i, j int)      { b.idx[i], b.idx[j] = b.idx[j], b.idx[i] }
@@ -0,0 +1,249 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// lossMinWeight is the lower bound on the weights of the Hessian of a
+// ResidualProblem with a Loss.
+const lossMinWeight = 1e-10
+
+// ResidualProblem describes a nonlinear least-squares problem
+//  minimize ½ Σ_i r_i(x)^2,
+// where r: R^n → R^m is the vector of residuals, for example the differences
+// between a model and measured data.
+type ResidualProblem struct {
+	// Len is the number of residuals m.
+	Len int
+	// Residual evaluates the residuals at x and stores the result in-place
+	// in dst. Residual must not modify x.
+	Residual func(x, dst []float64)
+	// Jacobian evaluates the m×n Jacobian of the residuals at x and stores
+	// the result in-place in jac. Jacobian must not modify x.
+	Jacobian func(x []float64, jac *mat64.Dense)
+	// JacobianSparsity is the sparsity pattern of the Jacobian. If it is
+	// not nil, the gradient and the Hessian are computed from the elements
+	// in the pattern only, and if Jacobian is nil, the Jacobian is
+	// approximated by forward differences with a SparseJacobian, which
+	// needs one evaluation of Residual per column group instead of one per
+	// variable. These evaluations are not counted by Local.
+	JacobianSparsity *SparsityPattern
+	// Loss is the robust loss function applied to the squared residuals.
+	// If Loss is nil, the objective function is ½ |r(x)|_2^2.
+	Loss Loss
+}
+
+// Problem returns a Problem that minimizes ½ |r(x)|_2^2. The gradient is
+// Jᵀr, and the Hessian is the Gauss-Newton approximation JᵀJ, which omits
+// the second derivatives of the residuals. The approximation is accurate
+// close to a solution with small residuals, and it is what
+// LevenbergMarquardt expects. If both Jacobian and JacobianSparsity are nil,
+// the Problem has neither a gradient nor a Hessian.
+//
+// If Loss is not nil, the Problem minimizes ½ Σ_i ρ(r_i^2) instead. The
+// gradient is Jᵀ diag(ρ') r, and the Hessian is the approximation
+// Jᵀ diag(ρ' + 2 ρ'' r^2) J of Triggs et al., where the weights are bounded
+// away from zero so that the Hessian remains positive semi-definite.
+//
+// References:
+//  - Triggs, B., McLauchlan, P.F., Hartley, R.I., Fitzgibbon, A.W.: Bundle
+//    adjustment - a modern synthesis. In Vision Algorithms: Theory and
+//    Practice, Lecture Notes in Comput. Sci. 1883, Springer (2000), 298-372
+//
+// The returned Problem caches the residuals and the Jacobian at the last
+// evaluated location, so that Func, Grad and Hess at the same location
+// evaluate them only once. It must not be used concurrently.
+func (rp ResidualProblem) Problem() Problem {
+	if rp.Len <= 0 {
+		panic("optimize: number of residuals not positive")
+	}
+	if rp.Residual == nil {
+		panic("optimize: residual function is undefined")
+	}
+	c := &residualCache{rp: rp, r: make([]float64, rp.Len)}
+	if rp.JacobianSparsity != nil {
+		c.sparsity(*rp.JacobianSparsity)
+	}
+	p := Problem{Func: c.Func}
+	if rp.Jacobian != nil || rp.JacobianSparsity != nil {
+		p.Grad = c.Grad
+		p.Hess = c.Hess
+	}
+	return p
+}
+
+// residualCache evaluates the objective function of a ResidualProblem and
+// its derivatives.
+type residualCache struct {
+	rp ResidualProblem
+
+	xr  []float64 // Location of the residuals in r.
+	r   []float64
+	d1  []float64 // First derivatives of the loss at r.
+	w   []float64 // Weights of the Hessian at r.
+	xj  []float64 // Location of the Jacobian in jac.
+	jac *mat64.Dense
+
+	rowCols [][]int         // Sorted columns of the nonzero elements in every row, if sparse.
+	fd      *SparseJacobian // Finite-difference Jacobian, if Jacobian is nil.
+}
+
+// sparsity stores the columns of the nonzero elements of every row of the
+// Jacobian in the given pattern.
+func (c *residualCache) sparsity(pattern SparsityPattern) {
+	if len(pattern.Row) != len(pattern.Col) {
+		panic("optimize: sparsity pattern length mismatch")
+	}
+	c.rowCols = make([][]int, c.rp.Len)
+	for k, i := range pattern.Row {
+		if i < 0 || i >= c.rp.Len {
+			panic("optimize: sparsity pattern index out of range")
+		}
+		c.rowCols[i] = append(c.rowCols[i], pattern.Col[k])
+	}
+	for i, cols := range c.rowCols {
+		sort.Ints(cols)
+		// Remove duplicate entries.
+		var n int
+		for k, j := range cols {
+			if k == 0 || j != cols[n-1] {
+				cols[n] = j
+				n++
+			}
+		}
+		c.rowCols[i] = cols[:n]
+	}
+}
+
+func (c *residualCache) residual(x []float64) {
+	if c.xr != nil && floats.Equal(x, c.xr) {
+		return
+	}
+	c.xr = resize(c.xr, len(x))
+	copy(c.xr, x)
+	c.rp.Residual(x, c.r)
+	if c.rp.Loss == nil {
+		return
+	}
+	c.d1 = resize(c.d1, len(c.r))
+	c.w = resize(c.w, len(c.r))
+	for i, v := range c.r {
+		z := v * v
+		_, d1, d2 := c.rp.Loss.Loss(z)
+		c.d1[i] = d1
+		c.w[i] = math.Max(d1+2*d2*z, lossMinWeight)
+	}
+}
+
+func (c *residualCache) jacobian(x []float64) {
+	if c.xj != nil && floats.Equal(x, c.xj) {
+		return
+	}
+	if c.jac == nil {
+		c.jac = mat64.NewDense(c.rp.Len, len(x), nil)
+		if c.rp.Jacobian == nil {
+			c.fd = NewSparseJacobian(c.rp.Len, len(x), *c.rp.JacobianSparsity)
+		}
+	}
+	c.xj = resize(c.xj, len(x))
+	copy(c.xj, x)
+	if c.fd != nil {
+		c.residual(x)
+		c.fd.Jacobian(c.jac, c.rp.Residual, x, c.r)
+		return
+	}
+	c.rp.Jacobian(x, c.jac)
+}
+
+func (c *residualCache) Func(x []float64) float64 {
+	c.residual(x)
+	if c.rp.Loss == nil {
+		return 0.5 * floats.Dot(c.r, c.r)
+	}
+	var f float64
+	for _, v := range c.r {
+		rho, _, _ := c.rp.Loss.Loss(v * v)
+		f += rho
+	}
+	return 0.5 * f
+}
+
+func (c *residualCache) Grad(x, grad []float64) {
+	c.residual(x)
+	c.jacobian(x)
+	if c.rowCols != nil {
+		for j := range grad {
+			grad[j] = 0
+		}
+		for i, v := range c.r {
+			if c.d1 != nil {
+				v *= c.d1[i]
+			}
+			for _, j := range c.rowCols[i] {
+				grad[j] += c.jac.At(i, j) * v
+			}
+		}
+		return
+	}
+	for j := range grad {
+		var g float64
+		for i, v := range c.r {
+			if c.d1 != nil {
+				v *= c.d1[i]
+			}
+			g += c.jac.At(i, j) * v
+		}
+		grad[j] = g
+	}
+}
+
+func (c *residualCache) Hess(x []float64, hess *mat64.SymDense) {
+	if c.rp.Loss != nil {
+		c.residual(x)
+	}
+	c.jacobian(x)
+	n := len(x)
+	if c.rowCols != nil {
+		// Every row of the Jacobian contributes to the elements of the
+		// Hessian of the pairs of its nonzero columns only.
+		for j := 0; j < n; j++ {
+			for k := j; k < n; k++ {
+				hess.SetSym(j, k, 0)
+			}
+		}
+		for i, cols := range c.rowCols {
+			w := 1.0
+			if c.w != nil {
+				w = c.w[i]
+			}
+			for a, j := range cols {
+				v := w * c.jac.At(i, j)
+				for _, k := range cols[a:] {
+					hess.SetSym(j, k, hess.At(j, k)+v*c.jac.At(i, k))
+				}
+			}
+		}
+		return
+	}
+	for j := 0; j < n; j++ {
+		for k := j; k < n; k++ {
+			var h float64
+			for i := 0; i < c.rp.Len; i++ {
+				v := c.jac.At(i, j) * c.jac.At(i, k)
+				if c.w != nil {
+					v *= c.w[i]
+				}
+				h += v
+			}
+			hess.SetSym(j, k, h)
+		}
+	}
+}
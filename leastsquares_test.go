@@ -0,0 +1,691 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+type leastSquaresTest struct {
+	name   string
+	p      ResidualProblem
+	x      []float64
+	optLoc []float64
+}
+
+// expFitTimes and expFitData are samples of 2 exp(-0.5 t) + 0.3.
+var expFitTimes = []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+func expFitData() []float64 {
+	y := make([]float64, len(expFitTimes))
+	for i, t := range expFitTimes {
+		y[i] = 2*math.Exp(-0.5*t) + 0.3
+	}
+	return y
+}
+
+func leastSquaresTests() []leastSquaresTest {
+	y := expFitData()
+	beale := []float64{1.5, 2.25, 2.625}
+	return []leastSquaresTest{
+		{
+			name: "Rosenbrock",
+			p: ResidualProblem{
+				Len: 2,
+				Residual: func(x, dst []float64) {
+					dst[0] = 10 * (x[1] - x[0]*x[0])
+					dst[1] = 1 - x[0]
+				},
+				Jacobian: func(x []float64, jac *mat64.Dense) {
+					jac.Set(0, 0, -20*x[0])
+					jac.Set(0, 1, 10)
+					jac.Set(1, 0, -1)
+					jac.Set(1, 1, 0)
+				},
+			},
+			x:      []float64{-1.2, 1},
+			optLoc: []float64{1, 1},
+		},
+		{
+			name: "Beale",
+			p: ResidualProblem{
+				Len: 3,
+				Residual: func(x, dst []float64) {
+					for i := range dst {
+						dst[i] = beale[i] - x[0]*(1-math.Pow(x[1], float64(i+1)))
+					}
+				},
+				Jacobian: func(x []float64, jac *mat64.Dense) {
+					for i := 0; i < 3; i++ {
+						k := float64(i + 1)
+						jac.Set(i, 0, -(1 - math.Pow(x[1], k)))
+						jac.Set(i, 1, x[0]*k*math.Pow(x[1], k-1))
+					}
+				},
+			},
+			x:      []float64{1, 1},
+			optLoc: []float64{3, 0.5},
+		},
+		{
+			name: "ExponentialFit",
+			p: ResidualProblem{
+				Len: len(y),
+				Residual: func(x, dst []float64) {
+					for i, t := range expFitTimes {
+						dst[i] = x[0]*math.Exp(x[1]*t) + x[2] - y[i]
+					}
+				},
+				Jacobian: func(x []float64, jac *mat64.Dense) {
+					for i, t := range expFitTimes {
+						e := math.Exp(x[1] * t)
+						jac.Set(i, 0, e)
+						jac.Set(i, 1, x[0]*t*e)
+						jac.Set(i, 2, 1)
+					}
+				},
+			},
+			x:      []float64{1, -1, 0},
+			optLoc: []float64{2, -0.5, 0.3},
+		},
+	}
+}
+
+func TestLevenbergMarquardt(t *testing.T) {
+	for _, method := range []*LevenbergMarquardt{
+		{},
+		{Scale: true},
+	} {
+		for _, test := range leastSquaresTests() {
+			settings := DefaultSettings()
+			settings.FunctionConverge = nil
+			settings.GradientThreshold = 1e-10
+			result, err := Local(test.p.Problem(), test.x, settings, method)
+			if err != nil {
+				t.Errorf("%s, scale %t: unexpected error: %v", test.name, method.Scale, err)
+				continue
+			}
+			if result.Status != GradientThreshold {
+				t.Errorf("%s, scale %t: unexpected status %v", test.name, method.Scale, result.Status)
+			}
+			if !floats.EqualApprox(result.X, test.optLoc, 1e-8) {
+				t.Errorf("%s, scale %t: unexpected solution. Want %v, got %v", test.name, method.Scale, test.optLoc, result.X)
+			}
+			if result.Covariance == nil {
+				t.Errorf("%s, scale %t: missing covariance", test.name, method.Scale)
+			}
+		}
+	}
+}
+
+func TestFitStatistics(t *testing.T) {
+	// Straight line fit y = a + b t, whose covariance is known in closed
+	// form.
+	ts := []float64{0, 1, 2, 3, 4, 5}
+	ys := []float64{1.1, 2.9, 5.2, 6.8, 9.1, 11.0}
+	rp := ResidualProblem{
+		Len: len(ts),
+		Residual: func(x, dst []float64) {
+			for i, v := range ts {
+				dst[i] = x[0] + x[1]*v - ys[i]
+			}
+		},
+		Jacobian: func(x []float64, jac *mat64.Dense) {
+			for i, v := range ts {
+				jac.Set(i, 0, 1)
+				jac.Set(i, 1, v)
+			}
+		},
+	}
+	result, err := Local(rp.Problem(), []float64{0, 0}, nil, &LevenbergMarquardt{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, err := rp.Statistics(result.X, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n := float64(len(ts))
+	tMean := floats.Sum(ts) / n
+	var sxx, ssr float64
+	for i, v := range ts {
+		sxx += (v - tMean) * (v - tMean)
+		r := result.X[0] + result.X[1]*v - ys[i]
+		ssr += r * r
+	}
+	variance := ssr / (n - 2)
+	wantVar := []float64{
+		variance * (1/n + tMean*tMean/sxx),
+		variance / sxx,
+	}
+	wantCov := -variance * tMean / sxx
+	if !s.EstimatedVariance || s.DegreesOfFreedom != 4 || math.Abs(s.Variance-variance) > 1e-12 {
+		t.Errorf("unexpected variance %v", s.Variance)
+	}
+	for j := range wantVar {
+		if math.Abs(s.Covariance.At(j, j)-wantVar[j]) > 1e-10 || math.Abs(s.StdErr[j]-math.Sqrt(wantVar[j])) > 1e-10 {
+			t.Errorf("unexpected variance of parameter %d", j)
+		}
+	}
+	if math.Abs(s.Covariance.At(0, 1)-wantCov) > 1e-10 {
+		t.Errorf("unexpected covariance %v, want %v", s.Covariance.At(0, 1), wantCov)
+	}
+	// The estimated covariance is the variance times Result.Covariance.
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			if math.Abs(s.Covariance.At(i, j)-variance*result.Covariance.At(i, j)) > 1e-10 {
+				t.Errorf("covariance does not match Result.Covariance")
+			}
+		}
+	}
+
+	// The quantile of the t-distribution with 4 degrees of freedom for the
+	// 95% level is 2.7764451051977.
+	lower := make([]float64, 2)
+	upper := make([]float64, 2)
+	s.ConfidenceIntervals(lower, upper, 0.95)
+	for j := range lower {
+		if math.Abs(upper[j]-s.X[j]-2.7764451051977*s.StdErr[j]) > 1e-10 || math.Abs(s.X[j]-lower[j]-(upper[j]-s.X[j])) > 1e-12 {
+			t.Errorf("unexpected confidence interval [%v, %v] of parameter %d", lower[j], upper[j], j)
+		}
+	}
+
+	// With a known variance, the normal quantile 1.959963984540054 is used.
+	s, err = rp.Statistics(result.X, 0.01)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.EstimatedVariance || math.Abs(s.StdErr[1]-math.Sqrt(0.01/sxx)) > 1e-12 {
+		t.Errorf("unexpected standard error %v with known variance", s.StdErr[1])
+	}
+	s.ConfidenceIntervals(lower, upper, 0.95)
+	if math.Abs(upper[1]-s.X[1]-1.959963984540054*s.StdErr[1]) > 1e-12 {
+		t.Errorf("unexpected confidence interval [%v, %v] with known variance", lower[1], upper[1])
+	}
+
+	// A parameter that does not influence the residuals is not determined.
+	rp.Jacobian = func(x []float64, jac *mat64.Dense) {
+		for i := range ts {
+			jac.Set(i, 0, 1)
+			jac.Set(i, 1, 0)
+		}
+	}
+	if _, err := rp.Statistics(result.X, 0); err != ErrSingularJacobian {
+		t.Errorf("unexpected error for singular Jacobian: %v", err)
+	}
+
+	for _, test := range []struct{ p, nu, want float64 }{
+		{0.975, 1, 12.706204736174698},
+		{0.975, 10, 2.2281388519649385},
+		{0.995, 30, 2.7499956535672259},
+		{0.9, 2.5, 1.7302509288071732},
+	} {
+		if got := studentTQuantile(test.p, test.nu); math.Abs(got-test.want) > 1e-9*test.want {
+			t.Errorf("unexpected quantile of the t-distribution for p = %v, nu = %v: want %v, got %v", test.p, test.nu, test.want, got)
+		}
+	}
+}
+
+func TestGaussNewton(t *testing.T) {
+	for _, test := range leastSquaresTests() {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.GradientThreshold = 1e-10
+		result, err := Local(test.p.Problem(), test.x, settings, &GaussNewton{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != GradientThreshold {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if !floats.EqualApprox(result.X, test.optLoc, 1e-8) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.optLoc, result.X)
+		}
+		if result.Covariance == nil {
+			t.Errorf("%s: missing covariance", test.name)
+			continue
+		}
+		// The covariance is the inverse of JᵀJ.
+		dim := len(test.x)
+		var prod mat64.Dense
+		prod.Mul(result.Covariance, result.Hessian)
+	check:
+		for i := 0; i < dim; i++ {
+			for j := 0; j < dim; j++ {
+				var want float64
+				if i == j {
+					want = 1
+				}
+				if math.Abs(prod.At(i, j)-want) > 1e-10 {
+					t.Errorf("%s: covariance is not the inverse of JᵀJ", test.name)
+					break check
+				}
+			}
+		}
+	}
+}
+
+func TestResidualProblem(t *testing.T) {
+	for _, test := range leastSquaresTests() {
+		p := test.p.Problem()
+		dim := len(test.x)
+		x := make([]float64, dim)
+		for i := range x {
+			x[i] = test.x[i] + 0.1*float64(i+1)
+		}
+
+		r := make([]float64, test.p.Len)
+		test.p.Residual(x, r)
+		jac := mat64.NewDense(test.p.Len, dim, nil)
+		test.p.Jacobian(x, jac)
+
+		if f := p.Func(x); math.Abs(f-0.5*floats.Dot(r, r)) > 1e-14*math.Max(1, f) {
+			t.Errorf("%s: unexpected function value %v", test.name, f)
+		}
+		grad := make([]float64, dim)
+		p.Grad(x, grad)
+		hess := mat64.NewSymDense(dim, nil)
+		p.Hess(x, hess)
+		for j := 0; j < dim; j++ {
+			var g float64
+			for i := range r {
+				g += jac.At(i, j) * r[i]
+			}
+			if math.Abs(grad[j]-g) > 1e-14*math.Max(1, math.Abs(g)) {
+				t.Errorf("%s: unexpected gradient element %d: want %v, got %v", test.name, j, g, grad[j])
+			}
+			for k := 0; k < dim; k++ {
+				var h float64
+				for i := range r {
+					h += jac.At(i, j) * jac.At(i, k)
+				}
+				if math.Abs(hess.At(j, k)-h) > 1e-14*math.Max(1, math.Abs(h)) {
+					t.Errorf("%s: unexpected Hessian element (%d,%d): want %v, got %v", test.name, j, k, h, hess.At(j, k))
+				}
+			}
+		}
+	}
+}
+
+func TestNNLS(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		a    *mat64.Dense
+		b    []float64
+		want []float64
+	}{
+		{
+			name: "Identity",
+			a:    mat64.NewDense(3, 3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}),
+			b:    []float64{1, -2, 3},
+			want: []float64{1, 0, 3},
+		},
+		{
+			// The unconstrained solution is (2, -1).
+			name: "Coupled",
+			a:    mat64.NewDense(3, 2, []float64{1, 1, 1, 2, 1, 3}),
+			b:    []float64{1, 0, -1},
+			want: []float64{0, 0},
+		},
+		{
+			name: "Interior",
+			a:    mat64.NewDense(3, 2, []float64{1, 0, 1, 1, 0, 1}),
+			b:    []float64{1, 3, 2},
+			want: []float64{1, 2},
+		},
+	} {
+		var s NNLS
+		x, err := s.Solve(test.a, test.b)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !floats.EqualApprox(x, test.want, 1e-12) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.want, x)
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	var s NNLS
+	for _, dims := range [][2]int{{10, 5}, {20, 20}, {5, 10}} {
+		m, n := dims[0], dims[1]
+		for trial := 0; trial < 20; trial++ {
+			a, b := randomLeastSquares(rnd, m, n)
+			lower := make([]float64, n)
+			upper := make([]float64, n)
+			for j := range upper {
+				upper[j] = math.Inf(1)
+			}
+			x, err := s.Solve(a, b)
+			if err != nil {
+				t.Errorf("%d×%d, trial %d: unexpected error: %v", m, n, trial, err)
+				continue
+			}
+			checkBoundedLeastSquares(t, a, b, lower, upper, x, 1e-8)
+		}
+	}
+}
+
+func TestBVLS(t *testing.T) {
+	identity := mat64.NewDense(3, 3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 1})
+	for _, test := range []struct {
+		name         string
+		a            *mat64.Dense
+		b            []float64
+		lower, upper []float64
+		want         []float64
+	}{
+		{
+			name:  "Identity",
+			a:     identity,
+			b:     []float64{1, -2, 0.25},
+			lower: []float64{-1, -1, -1},
+			upper: []float64{0.5, 0.5, 0.5},
+			want:  []float64{0.5, -1, 0.25},
+		},
+		{
+			name: "Unbounded",
+			a:    identity,
+			b:    []float64{1, -2, 3},
+			want: []float64{1, -2, 3},
+		},
+		{
+			name:  "Mixed",
+			a:     identity,
+			b:     []float64{1, -2, 3},
+			lower: []float64{math.Inf(-1), -1, 4},
+			upper: []float64{0, math.Inf(1), 4},
+			want:  []float64{0, -1, 4},
+		},
+		{
+			// The unconstrained solution is (2, -1).
+			name:  "Coupled",
+			a:     mat64.NewDense(3, 2, []float64{1, 1, 1, 2, 1, 3}),
+			b:     []float64{1, 0, -1},
+			lower: []float64{math.Inf(-1), -0.5},
+			want:  []float64{1, -0.5},
+		},
+	} {
+		var s BVLS
+		x, err := s.Solve(test.a, test.b, test.lower, test.upper)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !floats.EqualApprox(x, test.want, 1e-12) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, test.want, x)
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	var s BVLS
+	for _, dims := range [][2]int{{10, 5}, {20, 20}, {5, 10}} {
+		m, n := dims[0], dims[1]
+		for trial := 0; trial < 20; trial++ {
+			a, b := randomLeastSquares(rnd, m, n)
+			lower := make([]float64, n)
+			upper := make([]float64, n)
+			for j := range lower {
+				lower[j] = -rnd.Float64()
+				upper[j] = rnd.Float64()
+				switch rnd.Intn(4) {
+				case 0:
+					lower[j] = math.Inf(-1)
+				case 1:
+					upper[j] = math.Inf(1)
+				}
+			}
+			x, err := s.Solve(a, b, lower, upper)
+			if err != nil {
+				t.Errorf("%d×%d, trial %d: unexpected error: %v", m, n, trial, err)
+				continue
+			}
+			checkBoundedLeastSquares(t, a, b, lower, upper, x, 1e-8)
+		}
+	}
+}
+
+// randomLeastSquares returns a random m×n matrix and a right-hand side of
+// length m.
+func randomLeastSquares(rnd *rand.Rand, m, n int) (*mat64.Dense, []float64) {
+	a := mat64.NewDense(m, n, nil)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			a.Set(i, j, rnd.NormFloat64())
+		}
+	}
+	b := make([]float64, m)
+	for i := range b {
+		b[i] = 2 * rnd.NormFloat64()
+	}
+	return a, b
+}
+
+// checkBoundedLeastSquares checks that x satisfies the optimality conditions
+// of the bounded-variable least-squares problem.
+func checkBoundedLeastSquares(t *testing.T, a *mat64.Dense, b, lower, upper, x []float64, tol float64) {
+	m, n := a.Dims()
+	r := mat64.NewVector(m, nil)
+	r.MulVec(a, false, mat64.NewVector(n, x))
+	for i := range b {
+		r.SetVec(i, b[i]-r.At(i, 0))
+	}
+	w := mat64.NewVector(n, nil)
+	w.MulVec(a, true, r)
+	for j, v := range x {
+		g := w.At(j, 0)
+		switch {
+		case v < lower[j] || v > upper[j]:
+			t.Errorf("%d×%d: variable %d = %v outside bounds [%v, %v]", m, n, j, v, lower[j], upper[j])
+		case v == lower[j] && v == upper[j]:
+		case v == lower[j]:
+			if g > tol {
+				t.Errorf("%d×%d: variable %d at lower bound with gradient %v", m, n, j, -g)
+			}
+		case v == upper[j]:
+			if g < -tol {
+				t.Errorf("%d×%d: variable %d at upper bound with gradient %v", m, n, j, -g)
+			}
+		default:
+			if math.Abs(g) > tol {
+				t.Errorf("%d×%d: free variable %d with gradient %v", m, n, j, -g)
+			}
+		}
+	}
+}
+
+func TestLoss(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		loss Loss
+	}{
+		{"Huber", HuberLoss{}},
+		{"Huber2", HuberLoss{Scale: 2}},
+		{"SoftL1", SoftL1Loss{}},
+		{"SoftL1Half", SoftL1Loss{Scale: 0.5}},
+		{"Cauchy", CauchyLoss{}},
+		{"Cauchy3", CauchyLoss{Scale: 3}},
+		{"Arctan", ArctanLoss{}},
+		{"Arctan2", ArctanLoss{Scale: 2}},
+	} {
+		rho, d1, _ := test.loss.Loss(0)
+		if rho != 0 || d1 != 1 {
+			t.Errorf("%s: unexpected loss at zero: ρ = %v, ρ' = %v", test.name, rho, d1)
+		}
+		const h = 1e-6
+		for _, z := range []float64{0.1, 0.5, 2, 10, 100} {
+			rho, d1, d2 := test.loss.Loss(z)
+			rhoP, d1P, _ := test.loss.Loss(z + h)
+			rhoM, d1M, _ := test.loss.Loss(z - h)
+			if fd := (rhoP - rhoM) / (2 * h); math.Abs(fd-d1) > 1e-6 {
+				t.Errorf("%s: unexpected first derivative at %v: want %v, got %v", test.name, z, fd, d1)
+			}
+			if fd := (d1P - d1M) / (2 * h); math.Abs(fd-d2) > 1e-6 {
+				t.Errorf("%s: unexpected second derivative at %v: want %v, got %v", test.name, z, fd, d2)
+			}
+			if rho > z || d1 <= 0 {
+				t.Errorf("%s: loss at %v is not robust: ρ = %v, ρ' = %v", test.name, z, rho, d1)
+			}
+		}
+	}
+}
+
+func TestRobustFit(t *testing.T) {
+	// Fit a line to data with two gross outliers.
+	xs := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	ys := make([]float64, len(xs))
+	for i, x := range xs {
+		ys[i] = 2*x + 1 + 0.01*math.Sin(float64(3*i))
+	}
+	ys[3] += 20
+	ys[8] -= 30
+	want := []float64{2, 1}
+	fit := ResidualProblem{
+		Len: len(xs),
+		Residual: func(p, dst []float64) {
+			for i, x := range xs {
+				dst[i] = p[0]*x + p[1] - ys[i]
+			}
+		},
+		Jacobian: func(p []float64, jac *mat64.Dense) {
+			for i, x := range xs {
+				jac.Set(i, 0, x)
+				jac.Set(i, 1, 1)
+			}
+		},
+	}
+	for _, test := range []struct {
+		name string
+		loss Loss
+	}{
+		{"Huber", HuberLoss{Scale: 0.1}},
+		{"SoftL1", SoftL1Loss{Scale: 0.1}},
+		{"Cauchy", CauchyLoss{Scale: 0.1}},
+		{"Arctan", ArctanLoss{Scale: 0.1}},
+	} {
+		fit.Loss = test.loss
+		p := fit.Problem()
+
+		// Check the gradient against finite differences.
+		x := []float64{1.5, 0.5}
+		grad := make([]float64, 2)
+		p.Grad(x, grad)
+		fdGrad := make([]float64, 2)
+		for j := range x {
+			const h = 1e-6
+			x[j] += h
+			fp := p.Func(x)
+			x[j] -= 2 * h
+			fm := p.Func(x)
+			x[j] += h
+			fdGrad[j] = (fp - fm) / (2 * h)
+		}
+		if !floats.EqualApprox(grad, fdGrad, 1e-5) {
+			t.Errorf("%s: unexpected gradient. Want %v, got %v", test.name, fdGrad, grad)
+		}
+
+		settings := DefaultSettings()
+		settings.GradientThreshold = 1e-10
+		result, err := Local(p, []float64{0, 0}, settings, &LevenbergMarquardt{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !floats.EqualApprox(result.X, want, 0.05) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, want, result.X)
+		}
+	}
+}
+
+func TestSparseResidualProblem(t *testing.T) {
+	// The residuals of the extended Rosenbrock function, where every pair of
+	// residuals depends on a pair of variables.
+	const n = 40
+	var evals int
+	residual := func(x, dst []float64) {
+		evals++
+		for i := 0; i < n; i += 2 {
+			dst[i] = 10 * (x[i+1] - x[i]*x[i])
+			dst[i+1] = 1 - x[i]
+		}
+	}
+	jacobian := func(x []float64, jac *mat64.Dense) {
+		for i := 0; i < n; i += 2 {
+			jac.Set(i, i, -20*x[i])
+			jac.Set(i, i+1, 10)
+			jac.Set(i+1, i, -1)
+		}
+	}
+	var pattern SparsityPattern
+	for i := 0; i < n; i += 2 {
+		pattern.Row = append(pattern.Row, i, i, i+1)
+		pattern.Col = append(pattern.Col, i, i+1, i)
+	}
+	x0 := make([]float64, n)
+	for i := 0; i < n; i += 2 {
+		x0[i] = -1.2
+		x0[i+1] = 1
+	}
+
+	dense := ResidualProblem{Len: n, Residual: residual, Jacobian: jacobian}.Problem()
+	for _, analytic := range []bool{true, false} {
+		rp := ResidualProblem{
+			Len:              n,
+			Residual:         residual,
+			JacobianSparsity: &pattern,
+		}
+		if analytic {
+			rp.Jacobian = jacobian
+		}
+		p := rp.Problem()
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = x0[i] + 0.01*float64(i)
+		}
+		grad := make([]float64, n)
+		want := make([]float64, n)
+		evals = 0
+		p.Grad(x, grad)
+		if !analytic && evals != 3 {
+			t.Errorf("unexpected number of residual evaluations. Want 3, got %d", evals)
+		}
+		dense.Grad(x, want)
+		if !floats.EqualApprox(grad, want, 1e-5) {
+			t.Errorf("analytic %t: unexpected gradient", analytic)
+		}
+		hess := mat64.NewSymDense(n, nil)
+		wantHess := mat64.NewSymDense(n, nil)
+		p.Hess(x, hess)
+		dense.Hess(x, wantHess)
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				if math.Abs(hess.At(i, j)-wantHess.At(i, j)) > 1e-5*math.Max(1, math.Abs(wantHess.At(i, j))) {
+					t.Errorf("analytic %t: unexpected Hessian element (%d,%d)", analytic, i, j)
+				}
+			}
+		}
+
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.GradientThreshold = 1e-8
+		result, err := Local(p, x0, settings, &LevenbergMarquardt{})
+		if err != nil {
+			t.Errorf("analytic %t: unexpected error: %v", analytic, err)
+			continue
+		}
+		for _, v := range result.X {
+			if math.Abs(v-1) > 1e-6 {
+				t.Errorf("analytic %t: unexpected solution %v", analytic, result.X)
+				break
+			}
+		}
+	}
+}
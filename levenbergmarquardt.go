@@ -0,0 +1,245 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultLevMarDamping = 1e-3
+	levMarEta            = 1e-4
+)
+
+// LevenbergMarquardt implements the Levenberg-Marquardt method for nonlinear
+// least-squares problems. It is intended for Problems obtained from
+// ResidualProblem.Problem, whose Hessian is the Gauss-Newton approximation
+// JᵀJ. At every iteration, the step p is the solution of
+//  (JᵀJ + λD) p = -Jᵀr,
+// where λ >= 0 is the damping parameter and D is a positive diagonal
+// matrix. For small λ the step approaches the Gauss-Newton step, for large
+// λ a short step along the scaled steepest descent direction. The damping is
+// adapted to the ratio ρ of the actual and the predicted reduction of the
+// objective function: a step is accepted if ρ is positive and λ is then
+// decreased, otherwise λ is increased and the step is recomputed.
+//
+// If Scale is false, D is the identity. Otherwise, D is the diagonal of JᵀJ,
+// or more precisely its largest value so far, which makes the method
+// invariant to the scaling of the variables.
+//
+// The damped equations are solved by a Cholesky factorization in envelope
+// format, which exploits the sparsity of JᵀJ when every residual depends only
+// on a few neighboring variables, see ResidualProblem.JacobianSparsity.
+//
+// LevenbergMarquardt implements CovarianceEstimator, and Local stores the
+// approximate covariance (JᵀJ)^{-1} of the parameters at the returned
+// location in Result.Covariance. For the covariance of a fit to data, it must
+// be multiplied by the variance of the residuals, see
+// ResidualProblem.Statistics.
+//
+// References:
+//  - Moré, J.J.: The Levenberg-Marquardt algorithm: Implementation and
+//    theory. In Numerical Analysis, Lecture Notes in Math 630, Springer
+//    (1978), 105-116
+//  - Nielsen, H.B.: Damping parameter in Marquardt's method. Technical report
+//    IMM-REP-1999-05, Technical University of Denmark (1999)
+type LevenbergMarquardt struct {
+	// InitialDamping τ determines the initial damping parameter
+	//  λ = τ max_i (JᵀJ)_ii/D_ii.
+	// If InitialDamping is zero, it will be set to 1e-3.
+	InitialDamping float64
+	// Scale specifies whether the damping term is scaled by the diagonal of
+	// JᵀJ.
+	Scale bool
+
+	iterType IterationType
+	evalType EvaluationType
+
+	lambda float64 // Damping parameter.
+	nu     float64 // Factor of the next increase of lambda.
+	first  bool    // Whether the damping has not been initialized yet.
+
+	x    []float64 // Location of the last major iteration.
+	f    float64   // Function value at x.
+	grad []float64 // Gradient at x.
+	step []float64
+	mp   float64 // Predicted change of the function value.
+
+	hess *mat64.SymDense // JᵀJ at x.
+	a    *mat64.SymDense // JᵀJ + λD.
+	env  envelopeCholesky
+	chol *mat64.TriDense // Dense factorization for Covariance.
+	diag []float64       // Diagonal of D.
+	tmp  []float64
+}
+
+func (lm *LevenbergMarquardt) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if lm.InitialDamping == 0 {
+		lm.InitialDamping = defaultLevMarDamping
+	}
+	if lm.InitialDamping < 0 {
+		panic("levmar: InitialDamping must be positive")
+	}
+
+	dim := len(loc.X)
+	lm.x = resize(lm.x, dim)
+	lm.grad = resize(lm.grad, dim)
+	lm.step = resize(lm.step, dim)
+	lm.diag = resize(lm.diag, dim)
+	lm.tmp = resize(lm.tmp, dim)
+	lm.hess = resizeSymDense(lm.hess, dim)
+	lm.a = resizeSymDense(lm.a, dim)
+	for i := range lm.diag {
+		lm.diag[i] = 0
+	}
+	lm.first = true
+	lm.nu = 2
+	return lm.newStep(loc, xNext)
+}
+
+func (lm *LevenbergMarquardt) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch lm.iterType {
+	case SubIteration:
+		// The remaining fields of the accepted location have been evaluated.
+		lm.iterType = MajorIteration
+		copy(xNext, loc.X)
+		return NoEvaluation, lm.iterType, nil
+	case MajorIteration:
+		return lm.newStep(loc, xNext)
+	}
+
+	// The reductions are safeguarded against rounding errors as in
+	// TrustRegion.
+	delta := trustRegionRoundoff * math.Max(1, math.Abs(lm.f))
+	rho := (lm.f - loc.F + delta) / (-lm.mp + delta)
+	if loc.F > lm.f || math.IsNaN(rho) {
+		rho = 0
+	}
+	if rho > levMarEta {
+		// The step is accepted, decrease the damping.
+		lm.lambda *= math.Max(1.0/3, 1-math.Pow(2*rho-1, 3))
+		lm.nu = 2
+		copy(xNext, loc.X)
+		complEval := complementEval(loc, lm.evalType)
+		if complEval == NoEvaluation {
+			lm.iterType = MajorIteration
+		} else {
+			lm.iterType = SubIteration
+		}
+		return complEval, lm.iterType, nil
+	}
+	// The step is rejected, increase the damping and compute a new one.
+	lm.lambda *= lm.nu
+	lm.nu *= 2
+	return lm.computeStep(xNext)
+}
+
+// newStep stores the data at the new location loc and computes the first
+// trial step.
+func (lm *LevenbergMarquardt) newStep(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	copy(lm.x, loc.X)
+	copy(lm.grad, loc.Gradient)
+	lm.f = loc.F
+	lm.hess.CopySym(loc.Hessian)
+
+	dim := len(lm.x)
+	for i := 0; i < dim; i++ {
+		d := 1.0
+		if lm.Scale {
+			d = math.Max(lm.diag[i], lm.hess.At(i, i))
+			if d == 0 {
+				// Zero column of the Jacobian.
+				d = 1
+			}
+		}
+		lm.diag[i] = d
+	}
+	if lm.first {
+		var maxRatio float64
+		for i, d := range lm.diag {
+			maxRatio = math.Max(maxRatio, lm.hess.At(i, i)/d)
+		}
+		lm.lambda = lm.InitialDamping * maxRatio
+		lm.first = false
+	}
+	return lm.computeStep(xNext)
+}
+
+// computeStep solves the damped Gauss-Newton equations at the stored location
+// and stores the trial location into xNext.
+func (lm *LevenbergMarquardt) computeStep(xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(lm.x)
+	var ok bool
+	for k := 0; k < maxNewtonModifications && !ok; k++ {
+		lm.a.CopySym(lm.hess)
+		for i := 0; i < dim; i++ {
+			lm.a.SetSym(i, i, lm.hess.At(i, i)+lm.lambda*lm.diag[i])
+		}
+		ok = lm.env.factorize(lm.a)
+		if !ok {
+			// JᵀJ is singular and not sufficiently damped.
+			lm.lambda = math.Max(lm.nu*lm.lambda, lm.InitialDamping)
+			lm.nu *= 2
+		}
+	}
+	if !ok {
+		return NoEvaluation, NoIteration, ErrNoProgress
+	}
+	lm.env.solve(lm.step, lm.grad)
+	floats.Scale(-1, lm.step)
+
+	// The predicted change of the function value by the Gauss-Newton model,
+	//  gᵀp + ½ pᵀJᵀJ p.
+	lm.mp = floats.Dot(lm.grad, lm.step) + 0.5*curvature(lm.step, lm.hess, lm.tmp)
+	floats.AddTo(xNext, lm.x, lm.step)
+	if !(lm.mp < 0) || floats.Equal(lm.x, xNext) {
+		// The model predicts no decrease or the step is too small to
+		// change the location.
+		return NoEvaluation, NoIteration, ErrNoProgress
+	}
+	lm.evalType = FuncEvaluation
+	lm.iterType = MinorIteration
+	return lm.evalType, lm.iterType, nil
+}
+
+// Covariance stores (JᵀJ)^{-1} at loc into cov. It returns false if JᵀJ is
+// singular.
+func (lm *LevenbergMarquardt) Covariance(cov *mat64.SymDense, loc *Location) bool {
+	if loc.Hessian == nil {
+		return false
+	}
+	lm.chol = resizeTriDense(lm.chol, len(loc.X))
+	return choleskyInverse(cov, loc.Hessian, lm.chol)
+}
+
+// Reset discards the damping parameter and the scaling of the last run,
+// keeping the exported fields.
+func (lm *LevenbergMarquardt) Reset() {
+	*lm = LevenbergMarquardt{
+		InitialDamping: lm.InitialDamping,
+		Scale:          lm.Scale,
+	}
+}
+
+// Clone returns a new LevenbergMarquardt with the configuration of lm.
+func (lm *LevenbergMarquardt) Clone() Method {
+	return &LevenbergMarquardt{
+		InitialDamping: lm.InitialDamping,
+		Scale:          lm.Scale,
+	}
+}
+
+func (*LevenbergMarquardt) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, true}
+}
@@ -14,13 +14,30 @@ import (
 // It consists of a NextDirectioner, which specifies the next linesearch method,
 // and a LinesearchMethod which performs the linesearch in the direction specified
 // by the NextDirectioner.
+//
+// If InitialStep is not nil, it chooses the initial step size of every line
+// search instead of the NextDirectioner, which is useful if the directions
+// are not well scaled. InitialStep is initialized at the first location and
+// after a restart of the direction.
 type Linesearch struct {
 	NextDirectioner NextDirectioner
 	Method          LinesearchMethod
+	InitialStep     StepSizer
 
 	initX []float64
 	dir   []float64
 
+	// Function value, gradient and projected gradient at initX, and the
+	// last step size, to restart the line search at non-finite values and
+	// after failures.
+	initF        float64
+	initGradient []float64
+	initGrad     float64
+	step         float64
+	iter         int
+	retries      int
+	restarts     int
+
 	probInfo *ProblemInfo
 
 	lastEvalType EvaluationType
@@ -29,28 +46,20 @@ type Linesearch struct {
 
 func (ls *Linesearch) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
 	ls.initX = resize(ls.initX, len(loc.X))
-	copy(ls.initX, loc.X)
-
 	ls.dir = resize(ls.dir, len(loc.X))
-	stepSize := ls.NextDirectioner.InitDirection(loc, ls.dir)
-
-	projGrad := math.NaN()
-	if loc.Gradient != nil {
-		projGrad = floats.Dot(loc.Gradient, ls.dir)
-		if projGrad >= 0 {
-			return NoEvaluation, NoIteration, ErrNonNegativeStepDirection
-		}
+	ls.setInitLocation(loc)
+	ls.probInfo = p
+	ls.restarts = 0
+	if r, ok := ls.Method.(Resetter); ok {
+		// Discard the state of the line searches of a previous run.
+		r.Reset()
 	}
-	lsLoc := LinesearchLocation{
-		F:          loc.F,
-		Derivative: projGrad,
+
+	stepSize := ls.NextDirectioner.InitDirection(loc, ls.dir)
+	if ls.InitialStep != nil {
+		stepSize = ls.InitialStep.Init(loc, ls.dir)
 	}
-	evalType := ls.Method.Init(lsLoc, stepSize, p)
-	floats.AddScaledTo(xNext, ls.initX, stepSize, ls.dir)
-	ls.probInfo = p
-	ls.lastEvalType = evalType
-	ls.iterType = MinorIteration
-	return evalType, ls.iterType, nil
+	return ls.beginLinesearch(stepSize, xNext)
 }
 
 func (ls *Linesearch) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
@@ -67,6 +76,11 @@ func (ls *Linesearch) Iterate(loc *Location, xNext []float64) (EvaluationType, I
 		// so start the next linesearch.
 		return ls.initNextLinesearch(loc, xNext)
 	}
+	if ls.probInfo.NonFiniteRetries > 0 {
+		if err := checkFinite(loc, ls.lastEvalType, false); err != nil {
+			return ls.retry(xNext, err)
+		}
+	}
 	projGrad := math.NaN()
 	if loc.Gradient != nil {
 		projGrad = floats.Dot(loc.Gradient, ls.dir)
@@ -76,6 +90,7 @@ func (ls *Linesearch) Iterate(loc *Location, xNext []float64) (EvaluationType, I
 		Derivative: projGrad,
 	}
 	if ls.Method.Finished(lsLoc) {
+		ls.restarts = 0
 		copy(xNext, loc.X)
 		// Check if the last evaluation evaluated all fields of Location.
 		complEval := complementEval(loc, ls.lastEvalType)
@@ -92,46 +107,137 @@ func (ls *Linesearch) Iterate(loc *Location, xNext []float64) (EvaluationType, I
 	// Line search not done, just iterate.
 	stepSize, evalType, err := ls.Method.Iterate(lsLoc)
 	if err != nil {
-		return NoEvaluation, NoIteration, err
+		return ls.restart(xNext, err)
+	}
+	ls.iter++
+	p := ls.probInfo
+	if (p.LinesearchIterations > 0 && ls.iter > p.LinesearchIterations) ||
+		stepSize < p.LinesearchMinStep || (p.LinesearchMaxStep > 0 && stepSize > p.LinesearchMaxStep) {
+		return ls.restart(xNext, ErrLinesearchFailure)
 	}
 	floats.AddScaledTo(xNext, ls.initX, stepSize, ls.dir)
+	ls.step = stepSize
 	// Compare the starting point for the current iteration with the next
 	// evaluation point to make sure that rounding errors do not prevent progress.
 	if floats.Equal(ls.initX, xNext) {
-		return NoEvaluation, NoIteration, ErrNoProgress
+		return ls.restart(xNext, ErrNoProgress)
 	}
 	ls.lastEvalType = evalType
 	ls.iterType = MinorIteration
 	return evalType, ls.iterType, nil
 }
 
-func (ls *Linesearch) initNextLinesearch(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
-	// Find the next direction, and start the next line search.
+// setInitLocation stores the location at which the next line search starts.
+func (ls *Linesearch) setInitLocation(loc *Location) {
 	copy(ls.initX, loc.X)
-	stepsize := ls.NextDirectioner.NextDirection(loc, ls.dir)
-	projGrad := math.NaN()
+	ls.initF = loc.F
 	if loc.Gradient != nil {
-		projGrad = floats.Dot(loc.Gradient, ls.dir)
+		ls.initGradient = resize(ls.initGradient, len(loc.Gradient))
+		copy(ls.initGradient, loc.Gradient)
+	} else {
+		ls.initGradient = nil
+	}
+}
+
+// beginLinesearch starts a line search from initX along dir with the initial
+// step size step.
+func (ls *Linesearch) beginLinesearch(step float64, xNext []float64) (EvaluationType, IterationType, error) {
+	projGrad := math.NaN()
+	if ls.initGradient != nil {
+		projGrad = floats.Dot(ls.initGradient, ls.dir)
 	}
 	if projGrad >= 0 {
-		return NoEvaluation, NoIteration, ErrNonNegativeStepDirection
+		return ls.restart(xNext, ErrNonNegativeStepDirection)
+	}
+	if maxStep := ls.probInfo.LinesearchMaxStep; maxStep > 0 && step > maxStep {
+		step = maxStep
 	}
 	lsLoc := LinesearchLocation{
-		F:          loc.F,
+		F:          ls.initF,
 		Derivative: projGrad,
 	}
-	evalType := ls.Method.Init(lsLoc, stepsize, ls.probInfo)
-	floats.AddScaledTo(xNext, ls.initX, stepsize, ls.dir)
+	evalType := ls.Method.Init(lsLoc, step, ls.probInfo)
+	floats.AddScaledTo(xNext, ls.initX, step, ls.dir)
+	ls.initGrad = projGrad
+	ls.step = step
+	ls.iter = 0
+	ls.retries = 0
 	// Compare the starting point for the current iteration with the next
 	// evaluation point to make sure that rounding errors do not prevent progress.
 	if floats.Equal(ls.initX, xNext) {
-		return NoEvaluation, NoIteration, ErrNoProgress
+		return ls.restart(xNext, ErrNoProgress)
+	}
+	ls.lastEvalType = evalType
+	ls.iterType = MinorIteration
+	return evalType, ls.iterType, nil
+}
+
+// retry restarts the line search with half the last step after the values
+// at the last step are not finite, see Settings.NonFiniteRetries. If the
+// retries are exhausted, the direction is restarted.
+func (ls *Linesearch) retry(xNext []float64, err error) (EvaluationType, IterationType, error) {
+	if ls.retries >= ls.probInfo.NonFiniteRetries {
+		return ls.restart(xNext, err)
+	}
+	ls.retries++
+	ls.step /= 2
+	lsLoc := LinesearchLocation{
+		F:          ls.initF,
+		Derivative: ls.initGrad,
+	}
+	evalType := ls.Method.Init(lsLoc, ls.step, ls.probInfo)
+	floats.AddScaledTo(xNext, ls.initX, ls.step, ls.dir)
+	if floats.Equal(ls.initX, xNext) {
+		return ls.restart(xNext, ErrNoProgress)
 	}
 	ls.lastEvalType = evalType
 	ls.iterType = MinorIteration
 	return evalType, ls.iterType, nil
 }
 
+// restart restarts the direction at the location of the last major
+// iteration after the line search has failed with err, see
+// Settings.LinesearchRestarts. It returns err if the NextDirectioner is not a
+// DirectionRestarter or if the restarts are exhausted.
+func (ls *Linesearch) restart(xNext []float64, err error) (EvaluationType, IterationType, error) {
+	r, ok := ls.NextDirectioner.(DirectionRestarter)
+	if !ok || ls.restarts >= ls.probInfo.LinesearchRestarts {
+		return NoEvaluation, NoIteration, err
+	}
+	ls.restarts++
+	loc := &Location{
+		X:        ls.initX,
+		F:        ls.initF,
+		Gradient: ls.initGradient,
+	}
+	stepSize := r.RestartDirection(loc, ls.dir)
+	if ls.InitialStep != nil {
+		stepSize = ls.InitialStep.Init(loc, ls.dir)
+	}
+	return ls.beginLinesearch(stepSize, xNext)
+}
+
+// resume prepares ls to start the next line search at the next call to
+// Iterate, as after a MajorIteration. It is used to restore linesearch-based
+// Methods from a Checkpoint.
+func (ls *Linesearch) resume(p *ProblemInfo, dim int) {
+	ls.initX = resize(ls.initX, dim)
+	ls.dir = resize(ls.dir, dim)
+	ls.probInfo = p
+	ls.restarts = 0
+	ls.iterType = MajorIteration
+}
+
+func (ls *Linesearch) initNextLinesearch(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	// Find the next direction, and start the next line search.
+	ls.setInitLocation(loc)
+	stepsize := ls.NextDirectioner.NextDirection(loc, ls.dir)
+	if ls.InitialStep != nil {
+		stepsize = ls.InitialStep.StepSize(loc, ls.dir)
+	}
+	return ls.beginLinesearch(stepsize, xNext)
+}
+
 // ArmijoConditionMet returns true if the Armijo condition (aka sufficient decrease)
 // has been met. Under normal conditions, the following should be true, though this is not enforced:
 //  - initGrad < 0
@@ -168,3 +274,18 @@ func WeakWolfeConditionsMet(currObj, currGrad, initObj, initGrad, step, funConst
 	}
 	return currGrad >= gradConst*initGrad
 }
+
+// cloneLinesearchMethod returns a copy of the configuration of m without its
+// state. LinesearchMethods of types defined outside this package are
+// returned unchanged.
+func cloneLinesearchMethod(m LinesearchMethod) LinesearchMethod {
+	switch m := m.(type) {
+	case *Backtracking:
+		return &Backtracking{FunConst: m.FunConst, Decrease: m.Decrease}
+	case *Bisection:
+		return &Bisection{GradConst: m.GradConst}
+	case *Nonmonotone:
+		return &Nonmonotone{Memory: m.Memory, FunConst: m.FunConst, Decrease: m.Decrease}
+	}
+	return m
+}
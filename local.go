@@ -5,8 +5,11 @@
 package optimize
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/gonum/floats"
@@ -14,7 +17,7 @@ import (
 )
 
 // Local finds a local minimum of a function using a sequential algorithm.
-// In order to maximize a function, multiply the output by -1.
+// In order to maximize a function, set Settings.Maximize.
 //
 // The first argument is of Function type representing the function to be minimized.
 // Type switching is used to see if the function implements Gradient, FunctionGradient
@@ -50,18 +53,129 @@ import (
 // maximum runtime or maximum function evaluations, please modify the Settings
 // input struct.
 func Local(p Problem, initX []float64, settings *Settings, method Method) (*Result, error) {
+	return LocalContext(context.Background(), p, initX, settings, method)
+}
+
+// LocalContext is like Local, but the optimization can be cancelled through
+// ctx. The context is checked before every evaluation, and if it is done, the
+// optimization stops and the best location found so far is returned with
+// Canceled status. Cancellation does not interrupt an evaluation in progress.
+func LocalContext(ctx context.Context, p Problem, initX []float64, settings *Settings, method Method) (*Result, error) {
+	return local(ctx, p, initX, settings, method, nil)
+}
+
+// ResumeLocal resumes the optimization run from which cp was saved, see
+// Settings.CheckpointIterations. The Problem and the Settings should be the
+// same as in the original run, and method must be of the same type and have
+// the same settings as the Method of the original run, but it need not be the
+// same value. The returned Stats include the statistics of the original run
+// up to the checkpoint. ResumeLocal returns an error if method does not
+// implement Checkpointer or if cp does not match the problem.
+func ResumeLocal(cp *Checkpoint, p Problem, settings *Settings, method Method) (*Result, error) {
+	if _, ok := method.(Checkpointer); !ok {
+		return nil, errors.New("optimize: method cannot be resumed from a checkpoint")
+	}
+	return local(context.Background(), p, cp.Location.X, settings, method, cp)
+}
+
+// local implements LocalContext and ResumeLocal. If cp is not nil, the run
+// is resumed from cp instead of starting at initX.
+func local(ctx context.Context, p Problem, initX []float64, settings *Settings, method Method, cp *Checkpoint) (*Result, error) {
 	if p.Func == nil {
 		panic("optimize: objective function is undefined")
 	}
 	if len(initX) == 0 {
 		panic("optimize: initial X has zero length")
 	}
-
+	if p.Bounds != nil {
+		p.Bounds.validate(len(initX))
+	}
 	startTime := time.Now()
 
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	stats := &Stats{}
+
+	if settings.FuncAverages < 0 {
+		panic("optimize: negative number of function averages")
+	}
+	if settings.LinesearchIterations < 0 {
+		panic("optimize: negative number of linesearch iterations")
+	}
+	if settings.LinesearchMinStep < 0 || settings.LinesearchMaxStep < 0 {
+		panic("optimize: negative linesearch step bound")
+	}
+	if settings.LinesearchMaxStep > 0 && settings.LinesearchMinStep >= settings.LinesearchMaxStep {
+		panic("optimize: LinesearchMinStep not smaller than LinesearchMaxStep")
+	}
+	if p.FuncGrad != nil && p.Grad == nil {
+		// The gradient alone is evaluated by FuncGrad, and the function
+		// value is discarded.
+		fg := p.FuncGrad
+		p.Grad = func(x, grad []float64) {
+			fg(x, grad)
+		}
+	}
+	if settings.FuncAverages > 1 {
+		p.Func = averagedFunc(p.Func, settings.FuncAverages, stats)
+		// The averaged values must not be replaced by single evaluations.
+		p.FuncGrad = nil
+	}
+	if p.Prox != nil {
+		if settings.Scale != nil {
+			panic("optimize: scaling of problems with a proximal term is not supported")
+		}
+		f, prox := p.Func, p.Prox
+		p.Func = func(x []float64) float64 {
+			return f(x) + prox.Value(x)
+		}
+		if fg := p.FuncGrad; fg != nil {
+			p.FuncGrad = func(x, grad []float64) float64 {
+				return fg(x, grad) + prox.Value(x)
+			}
+		}
+	}
+	// The events are published by a Recorder that is installed before the
+	// wrappers below, so that it sees the values of the original problem.
+	var events *eventRecorder
+	if settings.Events != nil {
+		events = &eventRecorder{Recorder: settings.Recorder, events: settings.Events}
+		s := *settings
+		s.Recorder = events
+		settings = &s
+	}
+	// A maximization runs as the minimization of the negated objective
+	// function, and the reported values are negated back.
+	if settings.Maximize {
+		if p.Prox != nil {
+			panic("optimize: maximization of problems with a proximal term is not supported")
+		}
+		p = negatedProblem(p)
+		settings = negatedSettings(settings)
+	}
+
+	// The optimization runs in the scaled variables if requested, and the
+	// optimal location is transformed back at the end.
+	origP := p
+	var scaling *variableScaling
+	if settings.Scale != nil {
+		vs := newVariableScaling(settings.Scale, len(initX))
+		scaling = &vs
+		p = vs.problem(p)
+		if cp == nil {
+			// A checkpoint holds the scaled location already.
+			initX = vs.toScaled(initX)
+		}
+		settings = vs.settings(settings)
+	}
+
 	if method == nil {
 		method = getDefaultMethod(&p)
 	}
+	if method.Needs().Hessian && p.Hess == nil && p.Grad != nil && settings.HessianApprox != nil {
+		p.Hess = settings.HessianApprox.hess(&p, stats)
+	}
 	if err := p.satisfies(method); err != nil {
 		return nil, err
 	}
@@ -73,10 +187,6 @@ func Local(p Problem, initX []float64, settings *Settings, method Method) (*Resu
 		}
 	}
 
-	if settings == nil {
-		settings = DefaultSettings()
-	}
-
 	if settings.Recorder != nil {
 		// Initialize Recorder first. If it fails, we avoid the (possibly
 		// time-consuming) evaluation of Func() and Grad() at the starting location.
@@ -86,57 +196,171 @@ func Local(p Problem, initX []float64, settings *Settings, method Method) (*Resu
 		}
 	}
 
-	stats := &Stats{}
-	optLoc, evalType, err := getStartingLocation(&p, method, initX, stats, settings)
-	if err != nil {
-		return nil, err
-	}
-
-	if settings.FunctionConverge != nil {
-		settings.FunctionConverge.Init(optLoc.F)
-	}
+	var (
+		optLoc *Location
+		status Status
+		err    error
+	)
+	if cp == nil {
+		var evalType EvaluationType
+		optLoc, evalType, err = getStartingLocation(&p, method, initX, stats, settings)
+		if err != nil {
+			// The offending starting location is returned with the error.
+			status = Failure
+		} else {
+			if settings.FunctionConverge != nil {
+				settings.FunctionConverge.Init(optLoc.F)
+			}
 
-	// Runtime is the only Stats field that needs to be updated here.
-	stats.Runtime = time.Since(startTime)
-	// Send optLoc to Recorder before checking it for convergence.
-	if settings.Recorder != nil {
-		err = settings.Recorder.Record(optLoc, evalType, InitIteration, stats)
-	}
+			// Runtime is the only Stats field that needs to be updated here.
+			stats.Runtime = time.Since(startTime)
+			// Send optLoc to Recorder before checking it for convergence.
+			if settings.Recorder != nil {
+				err = settings.Recorder.Record(optLoc, evalType, InitIteration, stats)
+			}
 
-	// Check if the starting location satisfies the convergence criteria.
-	status := checkConvergence(optLoc, InitIteration, stats, settings)
-	if status == NotTerminated && err == nil {
-		// The starting location is not good enough, we need to perform a
-		// minimization. The optimal location will be stored in-place in
-		// optLoc.
-		status, err = minimize(settings, method, &p, stats, optLoc, startTime)
+			// Check if the starting location satisfies the convergence criteria.
+			status = checkConvergence(optLoc, InitIteration, stats, settings, &p)
+			if status == NotTerminated && err == nil {
+				// The starting location is not good enough, we need to perform a
+				// minimization. The optimal location will be stored in-place in
+				// optLoc.
+				status, err = minimize(ctx, settings, method, &p, stats, optLoc, nil, startTime)
+			}
+		}
+	} else {
+		optLoc = newLocation(len(initX), method)
+		if err := cp.Optimum.location(optLoc); err != nil {
+			return nil, err
+		}
+		*stats = cp.Stats
+		// Continue the runtime of the original run.
+		startTime = startTime.Add(-cp.Stats.Runtime)
+		if fc := settings.FunctionConverge; fc != nil {
+			fc.best = cp.FunctionConvergeBest
+			fc.iter = cp.FunctionConvergeIter
+		}
+		status, err = minimize(ctx, settings, method, &p, stats, optLoc, cp, startTime)
 	}
 
 	if settings.Recorder != nil && err == nil {
 		// Send the optimal location to Recorder.
 		err = settings.Recorder.Record(optLoc, NoEvaluation, PostIteration, stats)
 	}
+	// The covariance is estimated at the location of the method, in the
+	// scaled variables.
+	var cov *mat64.SymDense
+	if ce, ok := method.(CovarianceEstimator); ok && err == nil {
+		cov = mat64.NewSymDense(len(optLoc.X), nil)
+		if !ce.Covariance(cov, optLoc) {
+			cov = nil
+		}
+	}
+	nonFinite, isNonFinite := err.(ErrNonFinite)
+	if scaling != nil {
+		scaling.unscaleLocation(optLoc)
+		if isNonFinite {
+			scaling.unscaleLocation(nonFinite.Location)
+		}
+		if cov != nil {
+			scaling.unscaleCovariance(cov)
+		}
+		p = origP
+	}
+	var diag *Diagnostics
+	if settings.Diagnostics && err == nil {
+		diag = computeDiagnostics(&p, optLoc, stats)
+	}
+	var viol float64
+	if p.constrained() {
+		viol = p.constraintViolation(optLoc.X)
+	}
+	var mult *Multipliers
+	if me, ok := method.(MultiplierEstimator); ok && p.constrained() && err == nil {
+		mult = &Multipliers{}
+		if p.Equality != nil {
+			mult.Equality = make([]float64, p.Equality.Len())
+		}
+		if p.Inequality != nil {
+			mult.Inequality = make([]float64, p.Inequality.Len())
+		}
+		me.Multipliers(mult.Equality, mult.Inequality)
+	}
+	if settings.Maximize {
+		negateLocation(optLoc)
+		if isNonFinite {
+			negateLocation(nonFinite.Location)
+		}
+		if diag != nil {
+			negateDiagnostics(diag)
+		}
+	}
 	stats.Runtime = time.Since(startTime)
-	return &Result{
-		Location: *optLoc,
-		Stats:    *stats,
-		Status:   status,
-	}, err
+	result := &Result{
+		Location:            *optLoc,
+		Stats:               *stats,
+		Status:              status,
+		Diagnostics:         diag,
+		ConstraintViolation: viol,
+		Multipliers:         mult,
+		Covariance:          cov,
+	}
+	if events != nil {
+		events.publish(Event{Iteration: PostIteration, Status: status, Err: err, Location: optLoc, Stats: *stats})
+	}
+	return result, err
 }
 
-func minimize(settings *Settings, method Method, p *Problem, stats *Stats, optLoc *Location, startTime time.Time) (status Status, err error) {
+func minimize(ctx context.Context, settings *Settings, method Method, p *Problem, stats *Stats, optLoc *Location, cp *Checkpoint, startTime time.Time) (status Status, err error) {
 	loc := &Location{}
 	copyLocation(loc, optLoc)
 	xNext := make([]float64, len(loc.X))
+	info := newProblemInfo(p, stats)
+	info.NonFiniteRetries = settings.NonFiniteRetries
+	info.LinesearchRestarts = settings.LinesearchRestarts
+	info.LinesearchIterations = settings.LinesearchIterations
+	info.LinesearchMinStep = settings.LinesearchMinStep
+	info.LinesearchMaxStep = settings.LinesearchMaxStep
+	info.Src = settings.Src
+	// lastX is the location of the last major iteration.
+	lastX := make([]float64, len(loc.X))
+	copy(lastX, loc.X)
+
+	checkpointer, canCheckpoint := method.(Checkpointer)
+	canCheckpoint = canCheckpoint && settings.CheckpointIterations > 0
 
 	methodStatus, methodIsStatuser := method.(Statuser)
+	var batch *batchEvaluator
+	if batcher, ok := method.(Batcher); ok && settings.Concurrent > 1 {
+		batch = &batchEvaluator{p: p, method: batcher, concurrent: settings.Concurrent}
+	}
 
-	evalType, iterType, err := method.Init(loc, newProblemInfo(p), xNext)
+	var (
+		evalType EvaluationType
+		iterType IterationType
+	)
+	if cp == nil {
+		evalType, iterType, err = method.Init(loc, info, xNext)
+	} else {
+		// Resume after the checkpointed major iteration.
+		err = cp.Location.location(loc)
+		if err == nil {
+			err = checkpointer.Restore(cp.Method, loc, info)
+		}
+		if err == nil {
+			evalType, iterType, err = method.Iterate(loc, xNext)
+		}
+	}
 	if err != nil {
 		return Failure, err
 	}
 
 	for {
+		select {
+		case <-ctx.Done():
+			return Canceled, nil
+		default:
+		}
 		if p.Status != nil {
 			// Check the function status before evaluating.
 			status, err = p.Status()
@@ -147,11 +371,32 @@ func minimize(settings *Settings, method Method, p *Problem, stats *Stats, optLo
 
 		// Perform evalType evaluation of the function at xNext and store the
 		// result in location.
-		evaluate(p, evalType, xNext, loc, stats)
+		if batch != nil {
+			batch.evaluate(evalType, xNext, loc, stats)
+		} else {
+			evaluate(p, evalType, xNext, loc, stats)
+		}
+		if settings.NonFiniteRetries <= 0 || iterType != MinorIteration {
+			// The Method can reject +Inf function values at minor
+			// iterations.
+			if err = checkFinite(loc, evalType, iterType == MinorIteration); err != nil {
+				status = Failure
+				return
+			}
+		}
 		// Update the stats and optLoc.
-		update(loc, optLoc, stats, iterType, startTime)
+		update(loc, optLoc, stats, iterType, startTime, p.constrained())
 		// Get the convergence status before recording the new location.
-		status = checkConvergence(optLoc, iterType, stats, settings)
+		status = checkConvergence(optLoc, iterType, stats, settings, p)
+		if iterType == MajorIteration {
+			// The step is checked here because checkConvergence receives
+			// the best location instead of the location of the iteration.
+			if status == NotTerminated && settings.StepThreshold > 0 &&
+				floats.Distance(loc.X, lastX, math.Inf(1)) < settings.StepThreshold {
+				status = StepConvergence
+			}
+			copy(lastX, loc.X)
+		}
 
 		if settings.Recorder != nil {
 			err = settings.Recorder.Record(loc, evalType, iterType, stats)
@@ -167,6 +412,26 @@ func minimize(settings *Settings, method Method, p *Problem, stats *Stats, optLo
 			return
 		}
 
+		if settings.Callback != nil && iterType == MajorIteration {
+			status, err = settings.Callback(stats.MajorIterations, loc)
+			if err != nil && status == NotTerminated {
+				status = Failure
+			}
+			if err != nil || status != NotTerminated {
+				return
+			}
+			if !floats.Equal(loc.X, lastX) {
+				// The Callback has moved the location. Restart the Method
+				// there as at the start of the run.
+				status, evalType, iterType, err = restart(settings, method, p, info, stats, loc, optLoc, xNext, startTime)
+				if err != nil || status != NotTerminated {
+					return
+				}
+				copy(lastX, loc.X)
+				continue
+			}
+		}
+
 		if methodIsStatuser {
 			status, err = methodStatus.Status()
 			if err != nil || status != NotTerminated {
@@ -174,6 +439,18 @@ func minimize(settings *Settings, method Method, p *Problem, stats *Stats, optLo
 			}
 		}
 
+		if canCheckpoint && iterType == MajorIteration && stats.MajorIterations%settings.CheckpointIterations == 0 {
+			var c *Checkpoint
+			c, err = newCheckpoint(checkpointer, loc, optLoc, stats, settings.FunctionConverge)
+			if err == nil && settings.SaveCheckpoint != nil {
+				err = settings.SaveCheckpoint(c)
+			}
+			if err != nil {
+				status = Failure
+				return
+			}
+		}
+
 		// Find the next location (stored in-place into xNext).
 		evalType, iterType, err = method.Iterate(loc, xNext)
 		if err != nil {
@@ -184,6 +461,47 @@ func minimize(settings *Settings, method Method, p *Problem, stats *Stats, optLo
 	panic("optimize: unreachable")
 }
 
+// restart evaluates the problem at loc.X, which has been modified by
+// Settings.Callback, and initializes method there. The evaluated location
+// replaces optLoc. restart returns the convergence status at loc.X, and the
+// evaluation and iteration types requested by method.
+func restart(settings *Settings, method Method, p *Problem, info *ProblemInfo, stats *Stats, loc, optLoc *Location, xNext []float64, startTime time.Time) (Status, EvaluationType, IterationType, error) {
+	if p.Bounds != nil {
+		p.Bounds.Project(loc.X)
+	}
+	evalType := FuncEvaluation
+	if loc.Gradient != nil {
+		evalType |= GradEvaluation
+	}
+	if loc.Hessian != nil {
+		evalType |= HessEvaluation
+	}
+	copy(xNext, loc.X)
+	invalidate(loc)
+	evaluate(p, evalType, xNext, loc, stats)
+	if err := checkFinite(loc, evalType, false); err != nil {
+		return Failure, NoEvaluation, NoIteration, err
+	}
+	copyLocation(optLoc, loc)
+	stats.Runtime = time.Since(startTime)
+	if settings.FunctionConverge != nil {
+		settings.FunctionConverge.Init(loc.F)
+	}
+	if settings.Recorder != nil {
+		if err := settings.Recorder.Record(loc, evalType, InitIteration, stats); err != nil {
+			return Failure, NoEvaluation, NoIteration, err
+		}
+	}
+	if status := checkConvergence(optLoc, InitIteration, stats, settings, p); status != NotTerminated {
+		return status, NoEvaluation, NoIteration, nil
+	}
+	evalType, iterType, err := method.Init(loc, info, xNext)
+	if err != nil {
+		return Failure, NoEvaluation, NoIteration, err
+	}
+	return NotTerminated, evalType, iterType, nil
+}
+
 func copyLocation(dst, src *Location) {
 	dst.X = resize(dst.X, len(src.X))
 	copy(dst.X, src.X)
@@ -202,25 +520,45 @@ func copyLocation(dst, src *Location) {
 }
 
 func getDefaultMethod(p *Problem) Method {
+	if p.constrained() {
+		return &AugmentedLagrangian{}
+	}
 	if p.Grad != nil {
 		return &BFGS{}
 	}
 	return &NelderMead{}
 }
 
-// getStartingLocation allocates and initializes the starting location for the minimization.
-func getStartingLocation(p *Problem, method Method, initX []float64, stats *Stats, settings *Settings) (*Location, EvaluationType, error) {
-	dim := len(initX)
+// newLocation allocates a Location with the fields needed by method.
+func newLocation(dim int, method Method) *Location {
 	loc := &Location{
 		X: make([]float64, dim),
 	}
-	copy(loc.X, initX)
 	if method.Needs().Gradient {
 		loc.Gradient = make([]float64, dim)
 	}
 	if method.Needs().Hessian {
 		loc.Hessian = mat64.NewSymDense(dim, nil)
 	}
+	return loc
+}
+
+// getStartingLocation allocates and initializes the starting location for the minimization.
+func getStartingLocation(p *Problem, method Method, initX []float64, stats *Stats, settings *Settings) (*Location, EvaluationType, error) {
+	dim := len(initX)
+	loc := newLocation(dim, method)
+	copy(loc.X, initX)
+	if !allFinite(loc.X) {
+		// None of the fields of loc are known at the initial location.
+		invalidate(loc)
+		return loc, NoEvaluation, ErrInitialX
+	}
+	if p.Bounds != nil {
+		p.Bounds.Project(loc.X)
+		if settings.UseInitialData && !floats.Equal(loc.X, initX) {
+			panic("optimize: initial X outside bounds with initial data supplied")
+		}
+	}
 
 	evalType := NoEvaluation
 	if settings.UseInitialData {
@@ -253,31 +591,24 @@ func getStartingLocation(p *Problem, method Method, initX []float64, stats *Stat
 		if loc.Hessian != nil {
 			evalType |= HessEvaluation
 		}
+		// None of the fields of loc are known at the initial location.
+		invalidate(loc)
 		evaluate(p, evalType, loc.X, loc, stats)
 	}
 
-	if math.IsNaN(loc.F) {
-		return loc, evalType, ErrNaN
-	}
-	if math.IsInf(loc.F, 1) {
-		return loc, evalType, ErrInf
+	if err := checkFinite(loc, FuncEvaluation|GradEvaluation, false); err != nil {
+		return loc, evalType, err
 	}
-	for _, v := range loc.Gradient {
-		if math.IsInf(v, 0) {
-			return loc, evalType, ErrGradInf
-		}
-		if math.IsNaN(v) {
-			return loc, evalType, ErrGradNaN
-		}
-	}
-
 	return loc, evalType, nil
 }
 
-func checkConvergence(loc *Location, iterType IterationType, stats *Stats, settings *Settings) Status {
+func checkConvergence(loc *Location, iterType IterationType, stats *Stats, settings *Settings, p *Problem) Status {
 	if iterType == MajorIteration || iterType == InitIteration {
-		if loc.Gradient != nil {
-			norm := floats.Norm(loc.Gradient, math.Inf(1))
+		// The gradient of the objective function does not vanish at a
+		// solution of a problem with nonlinear constraints, and the gradient
+		// of the smooth part not at a solution of a composite problem.
+		if loc.Gradient != nil && !p.constrained() && p.Prox == nil {
+			norm := p.Bounds.ProjectedGradientNorm(loc.X, loc.Gradient)
 			if norm < settings.GradientThreshold {
 				return GradientThreshold
 			}
@@ -318,6 +649,12 @@ func checkConvergence(loc *Location, iterType IterationType, stats *Stats, setti
 		}
 	}
 
+	if settings.HessVecEvaluations > 0 {
+		if stats.HessVecEvaluations >= settings.HessVecEvaluations {
+			return HessianVectorEvaluationLimit
+		}
+	}
+
 	if settings.Runtime > 0 {
 		// TODO(vladimir-ch): It would be nice to update Runtime here.
 		if stats.Runtime >= settings.Runtime {
@@ -351,7 +688,9 @@ func invalidate(loc *Location) {
 // evaluate evaluates the problem given by p at xNext, stores the answer into
 // loc and updates stats. If loc.X is not equal to xNext, then unused fields of
 // loc are set to NaN.
-// evaluate panics if the function does not support the requested evalType.
+//
+// If p.FuncGrad is not nil, it is used when both the function value and the
+// gradient are requested. evaluate panics if the function does not support the requested evalType.
 func evaluate(p *Problem, evalType EvaluationType, xNext []float64, loc *Location, stats *Stats) {
 	if !floats.Equal(loc.X, xNext) {
 		if evalType == NoEvaluation {
@@ -364,12 +703,18 @@ func evaluate(p *Problem, evalType EvaluationType, xNext []float64, loc *Locatio
 	}
 
 	toEval := evalType
-	if evalType&FuncEvaluation != 0 {
+	if p.FuncGrad != nil && evalType&(FuncEvaluation|GradEvaluation) == FuncEvaluation|GradEvaluation {
+		loc.F = p.FuncGrad(loc.X, loc.Gradient)
+		stats.FuncEvaluations++
+		stats.GradEvaluations++
+		toEval &= ^(FuncEvaluation | GradEvaluation)
+	}
+	if toEval&FuncEvaluation != 0 {
 		loc.F = p.Func(loc.X)
 		stats.FuncEvaluations++
 		toEval &= ^FuncEvaluation
 	}
-	if evalType&GradEvaluation != 0 {
+	if toEval&GradEvaluation != 0 {
 		p.Grad(loc.X, loc.Gradient)
 		stats.GradEvaluations++
 		toEval &= ^GradEvaluation
@@ -385,12 +730,63 @@ func evaluate(p *Problem, evalType EvaluationType, xNext []float64, loc *Locatio
 	}
 }
 
-// update updates the stats given the new evaluation
-func update(loc *Location, optLoc *Location, stats *Stats, iterType IterationType, startTime time.Time) {
+// checkFinite returns an ErrNonFinite error if the function value or the
+// gradient evaluated at loc as given by evalType is NaN or infinite. +Inf
+// function values are accepted if acceptInf is true.
+func checkFinite(loc *Location, evalType EvaluationType, acceptInf bool) error {
+	if evalType&FuncEvaluation != 0 {
+		if math.IsNaN(loc.F) || (math.IsInf(loc.F, 1) && !acceptInf) {
+			return newErrNonFinite(loc, false)
+		}
+	}
+	if evalType&GradEvaluation != 0 && !allFinite(loc.Gradient) {
+		return newErrNonFinite(loc, true)
+	}
+	return nil
+}
+
+// newErrNonFinite returns an ErrNonFinite error with a copy of loc.
+func newErrNonFinite(loc *Location, grad bool) ErrNonFinite {
+	l := &Location{}
+	copyLocation(l, loc)
+	return ErrNonFinite{Location: l, Gradient: grad}
+}
+
+// allFinite returns whether all elements of s are neither NaN nor infinite.
+func allFinite(s []float64) bool {
+	for _, v := range s {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// averagedFunc returns a function that evaluates f n times and returns the
+// mean of the values. The calls after the first are added to the function
+// evaluations in stats, and the first is counted by the caller. The returned
+// function is safe for concurrent use if f is.
+func averagedFunc(f func([]float64) float64, n int, stats *Stats) func([]float64) float64 {
+	var mu sync.Mutex
+	return func(x []float64) float64 {
+		var sum float64
+		for i := 0; i < n; i++ {
+			sum += f(x)
+		}
+		mu.Lock()
+		stats.FuncEvaluations += n - 1
+		mu.Unlock()
+		return sum / float64(n)
+	}
+}
+
+// update updates the stats given the new evaluation. For problems with
+// nonlinear constraints, optLoc is the location of the last major iteration.
+func update(loc *Location, optLoc *Location, stats *Stats, iterType IterationType, startTime time.Time, constrained bool) {
 	if iterType == MajorIteration {
 		stats.MajorIterations++
 	}
-	if loc.F <= optLoc.F {
+	if (!constrained && loc.F <= optLoc.F) || (constrained && iterType == MajorIteration) {
 		copyLocation(optLoc, loc)
 	}
 	stats.Runtime = time.Since(startTime)
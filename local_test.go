@@ -0,0 +1,517 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestLocalContext(t *testing.T) {
+	const maxEvals = 50
+	for _, method := range []Method{&BFGS{}, &NelderMead{}} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var evals int
+		best := math.Inf(1)
+		p := Problem{
+			Func: func(x []float64) float64 {
+				evals++
+				if evals == maxEvals {
+					cancel()
+				}
+				f := functions.ExtendedRosenbrock{}.Func(x)
+				best = math.Min(best, f)
+				return f
+			},
+			Grad: functions.ExtendedRosenbrock{}.Grad,
+		}
+		result, err := LocalContext(ctx, p, []float64{-1.2, 1, -1.2, 1}, nil, method)
+		if err != nil {
+			t.Errorf("%T: unexpected error: %v", method, err)
+			continue
+		}
+		if result.Status != Canceled {
+			t.Errorf("%T: unexpected status %v", method, result.Status)
+		}
+		if result.FuncEvaluations != maxEvals {
+			t.Errorf("%T: evaluations after cancellation, got %d", method, result.FuncEvaluations)
+		}
+		if result.F != best {
+			t.Errorf("%T: best location not returned, want f = %v, got %v", method, best, result.F)
+		}
+	}
+}
+
+func TestCallback(t *testing.T) {
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	x := []float64{-1.2, 1}
+
+	// Custom stopping rule.
+	var iters []int
+	settings := DefaultSettings()
+	settings.Callback = func(iter int, loc *Location) (Status, error) {
+		iters = append(iters, iter)
+		if loc.F < 1e-2 {
+			return Success, nil
+		}
+		return NotTerminated, nil
+	}
+	result, err := Local(p, x, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != Success || result.F >= 1e-2 {
+		t.Errorf("unexpected result with status %v and f = %v", result.Status, result.F)
+	}
+	if len(iters) != result.MajorIterations {
+		t.Errorf("callback called %d times for %d major iterations", len(iters), result.MajorIterations)
+	}
+	for i, iter := range iters {
+		if iter != i+1 {
+			t.Errorf("unexpected iteration number %d in call %d", iter, i)
+			break
+		}
+	}
+
+	// Errors stop the run with Failure.
+	errStop := errors.New("stop")
+	settings = DefaultSettings()
+	settings.Callback = func(iter int, loc *Location) (Status, error) {
+		if iter == 3 {
+			return NotTerminated, errStop
+		}
+		return NotTerminated, nil
+	}
+	result, err = Local(p, x, settings, &BFGS{})
+	if err != errStop {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if result.Status != Failure || result.MajorIterations != 3 {
+		t.Errorf("unexpected status %v after %d major iterations", result.Status, result.MajorIterations)
+	}
+
+	// A modified location restarts the run there, also in the negated and
+	// the scaled problem.
+	moved := []float64{2, 4}
+	for _, test := range []struct {
+		name     string
+		maximize bool
+		scale    []float64
+	}{
+		{name: "Minimize"},
+		{name: "Maximize", maximize: true},
+		{name: "Scale", scale: []float64{4, 0.25}},
+	} {
+		var evaluated bool
+		sign := 1.0
+		if test.maximize {
+			sign = -1
+		}
+		p := Problem{
+			Func: func(x []float64) float64 {
+				if floats.Equal(x, moved) {
+					evaluated = true
+				}
+				return sign * functions.ExtendedRosenbrock{}.Func(x)
+			},
+			Grad: func(x, grad []float64) {
+				functions.ExtendedRosenbrock{}.Grad(x, grad)
+				floats.Scale(sign, grad)
+			},
+		}
+		settings = DefaultSettings()
+		settings.Maximize = test.maximize
+		settings.Scale = test.scale
+		settings.Callback = func(iter int, loc *Location) (Status, error) {
+			if iter == 3 {
+				copy(loc.X, moved)
+			}
+			return NotTerminated, nil
+		}
+		result, err = Local(p, x, settings, &BFGS{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !evaluated {
+			t.Errorf("%s: modified location not evaluated", test.name)
+		}
+		if result.Status != GradientThreshold || !floats.EqualApprox(result.X, []float64{1, 1}, 1e-6) {
+			t.Errorf("%s: unexpected result with status %v at %v", test.name, result.Status, result.X)
+		}
+	}
+}
+
+func TestStepThreshold(t *testing.T) {
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	const tol = 1e-4
+	var lastX []float64
+	var lastStep float64
+	settings := DefaultSettings()
+	settings.GradientThreshold = 0
+	settings.StepThreshold = tol
+	settings.Callback = func(iter int, loc *Location) (Status, error) {
+		if lastX != nil {
+			lastStep = floats.Distance(loc.X, lastX, math.Inf(1))
+		}
+		lastX = append(lastX[:0], loc.X...)
+		return NotTerminated, nil
+	}
+	result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != StepConvergence {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	// The callback is not called at the terminating iteration, so the last
+	// step it has seen is not small.
+	if lastStep < tol {
+		t.Errorf("step %v smaller than threshold before termination", lastStep)
+	}
+	if !floats.EqualApprox(result.X, []float64{1, 1}, 1e-3) {
+		t.Errorf("unexpected minimum location %v", result.X)
+	}
+}
+
+func TestBudgets(t *testing.T) {
+	f := functions.Wood{}
+	p := Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		Hess: f.Hess,
+	}
+	x := []float64{-3, -1, -3, -1}
+	for _, test := range []struct {
+		set    func(*Settings)
+		method Method
+		status Status
+		check  func(*Stats) bool
+	}{
+		{
+			func(s *Settings) { s.MajorIterations = 5 },
+			&BFGS{}, IterationLimit,
+			func(s *Stats) bool { return s.MajorIterations == 5 },
+		},
+		{
+			func(s *Settings) { s.FuncEvaluations = 20 },
+			&BFGS{}, FunctionEvaluationLimit,
+			func(s *Stats) bool { return s.FuncEvaluations == 20 },
+		},
+		{
+			func(s *Settings) { s.GradEvaluations = 20 },
+			&BFGS{}, GradientEvaluationLimit,
+			func(s *Stats) bool { return s.GradEvaluations == 20 },
+		},
+		{
+			func(s *Settings) { s.HessEvaluations = 3 },
+			&Newton{}, HessianEvaluationLimit,
+			func(s *Stats) bool { return s.HessEvaluations == 3 },
+		},
+		{
+			func(s *Settings) { s.HessVecEvaluations = 10 },
+			&NewtonCG{}, HessianVectorEvaluationLimit,
+			func(s *Stats) bool { return s.HessVecEvaluations >= 10 },
+		},
+		{
+			func(s *Settings) { s.Runtime = time.Nanosecond },
+			&BFGS{}, RuntimeLimit,
+			func(s *Stats) bool { return s.Runtime >= time.Nanosecond },
+		},
+	} {
+		settings := DefaultSettings()
+		test.set(settings)
+		result, err := Local(p, x, settings, test.method)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.status, err)
+			continue
+		}
+		if result.Status != test.status {
+			t.Errorf("%v: unexpected status %v", test.status, result.Status)
+		}
+		if !result.Status.Early() || result.Status.Err() == nil {
+			t.Errorf("%v: budget status not early", test.status)
+		}
+		if !test.check(&result.Stats) {
+			t.Errorf("%v: unexpected stats %+v", test.status, result.Stats)
+		}
+	}
+}
+
+func TestFuncAverages(t *testing.T) {
+	// The noise has zero mean over every three consecutive evaluations, so
+	// averaging three evaluations recovers the function value exactly.
+	noise := []float64{0.5, -1, 0.5}
+	var calls int
+	p := Problem{
+		Func: func(x []float64) float64 {
+			f := functions.ExtendedRosenbrock{}.Func(x) + noise[calls%3]
+			calls++
+			return f
+		},
+	}
+	settings := DefaultSettings()
+	settings.FuncAverages = 3
+	result, err := Local(p, []float64{-1.2, 1}, settings, &NelderMead{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.FuncEvaluations != calls {
+		t.Errorf("unexpected number of evaluations: want %d, got %d", calls, result.FuncEvaluations)
+	}
+	if !floats.EqualApprox(result.X, []float64{1, 1}, 1e-3) {
+		t.Errorf("unexpected minimum location %v", result.X)
+	}
+}
+
+func TestSettingsSrc(t *testing.T) {
+	p := Problem{
+		Func:   himmelblau{}.Func,
+		Bounds: &Bounds{Lower: []float64{-5, -5}, Upper: []float64{5, 5}},
+	}
+	settings := func(seed int64) *Settings {
+		s := DefaultSettings()
+		s.FuncEvaluations = 500
+		s.Src = rand.NewSource(seed)
+		return s
+	}
+
+	// Stochastic methods without a source of their own are reproducible
+	// with the same seed of Settings.Src.
+	for _, method := range []func() Method{
+		func() Method { return &DifferentialEvolution{} },
+		func() Method { return &SimulatedAnnealing{} },
+		func() Method { return &ParticleSwarm{} },
+	} {
+		r1, err1 := Local(p, []float64{1, 1}, settings(1), method())
+		r2, err2 := Local(p, []float64{1, 1}, settings(1), method())
+		if err1 != nil || err2 != nil {
+			t.Errorf("%T: unexpected error: %v, %v", method(), err1, err2)
+			continue
+		}
+		if !floats.Equal(r1.X, r2.X) || r1.FuncEvaluations != r2.FuncEvaluations {
+			t.Errorf("%T: runs with the same seed differ", method())
+		}
+	}
+
+	// Concurrent multi-start runs with the same seed find the same minima.
+	var results []*MultiStartResult
+	for _, concurrent := range []int{1, 3} {
+		ms := &MultiStart{
+			Samples:    8,
+			Concurrent: concurrent,
+			Method:     func() Method { return &SimulatedAnnealing{} },
+		}
+		res, err := ms.Minimize(p, 2, settings(2))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		results = append(results, res)
+	}
+	if len(results[0].Minima) != len(results[1].Minima) || results[0].FuncEvaluations != results[1].FuncEvaluations {
+		t.Fatalf("concurrent multi-start differs from the sequential one")
+	}
+	for i, m := range results[0].Minima {
+		if !floats.Equal(m.X, results[1].Minima[i].X) {
+			t.Errorf("concurrent minimum %d differs from the sequential one", i)
+		}
+	}
+}
+
+func TestNonFinite(t *testing.T) {
+	// The function is not defined for x_i > 3.
+	p := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for _, v := range x {
+				if v > 3 {
+					return math.NaN()
+				}
+				f += (v - 2) * (v - 2)
+			}
+			return f
+		},
+		Grad: func(x, grad []float64) {
+			for i, v := range x {
+				grad[i] = 2 * (v - 2)
+			}
+		},
+	}
+	method := func() Method {
+		return &GradientDescent{
+			LinesearchMethod: &Backtracking{},
+			StepSizer:        ConstantStepSize{Size: 10},
+		}
+	}
+
+	result, err := Local(p, []float64{0, math.Inf(1)}, nil, method())
+	if err != ErrInitialX {
+		t.Errorf("unexpected error for an infinite initial location: %v", err)
+	}
+	if result == nil || result.Status != Failure || !math.IsInf(result.X[1], 1) {
+		t.Errorf("unexpected result for an infinite initial location: %+v", result)
+	}
+
+	// The function is not defined at the initial location.
+	result, err = Local(p, []float64{0, 4}, nil, method())
+	nonFinite, ok := err.(ErrNonFinite)
+	if !ok {
+		t.Fatalf("unexpected error for an undefined initial location: %v", err)
+	}
+	if result.Status != Failure || !math.IsNaN(result.F) || !floats.Equal(result.X, []float64{0, 4}) {
+		t.Errorf("unexpected result for an undefined initial location: %+v", result)
+	}
+	if nonFinite.Gradient || !math.IsNaN(nonFinite.Location.F) || !floats.Equal(nonFinite.Location.X, []float64{0, 4}) {
+		t.Errorf("unexpected offending initial location %+v", nonFinite.Location)
+	}
+
+	// The first line search lands outside the domain.
+	result, err = Local(p, []float64{0, 0}, nil, method())
+	nonFinite, ok = err.(ErrNonFinite)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != Failure {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	if nonFinite.Gradient || !math.IsNaN(nonFinite.Location.F) || !floats.Equal(nonFinite.Location.X, []float64{40, 40}) {
+		t.Errorf("unexpected offending location %+v", nonFinite.Location)
+	}
+
+	// The line searches are retried with smaller steps.
+	settings := DefaultSettings()
+	settings.NonFiniteRetries = 5
+	settings.FuncEvaluations = 1000
+	result, err = Local(p, []float64{0, 0}, settings, method())
+	if err != nil {
+		t.Fatalf("unexpected error with retries: %v", err)
+	}
+	if !floats.EqualApprox(result.X, []float64{2, 2}, 1e-6) {
+		t.Errorf("unexpected minimum location with retries %v", result.X)
+	}
+
+	// The retries are exhausted.
+	settings = DefaultSettings()
+	settings.NonFiniteRetries = 2
+	_, err = Local(p, []float64{0, 0}, settings, method())
+	if _, ok := err.(ErrNonFinite); !ok {
+		t.Errorf("unexpected error with exhausted retries: %v", err)
+	}
+}
+
+func TestLinesearchBounds(t *testing.T) {
+	// The step size 0.5 along the negative gradient leads to the minimum.
+	p := Problem{
+		Func: func(x []float64) float64 {
+			return floats.Dot(x, x)
+		},
+		Grad: func(x, grad []float64) {
+			for i, v := range x {
+				grad[i] = 2 * v
+			}
+		},
+	}
+	method := func() Method {
+		return &GradientDescent{
+			LinesearchMethod: &Backtracking{},
+			StepSizer:        ConstantStepSize{Size: 10},
+		}
+	}
+
+	settings := DefaultSettings()
+	settings.LinesearchMaxStep = 0.5
+	result, err := Local(p, []float64{1, -2}, settings, method())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.MajorIterations != 1 || result.F != 0 {
+		t.Errorf("maximum step size not applied: %d iterations to f = %v", result.MajorIterations, result.F)
+	}
+
+	// Backtracking accepts the step size 0.625 after four iterations.
+	settings = DefaultSettings()
+	settings.LinesearchIterations = 3
+	_, err = Local(p, []float64{1, -2}, settings, method())
+	if err != ErrLinesearchFailure {
+		t.Errorf("unexpected error with an iteration limit: %v", err)
+	}
+	settings.LinesearchIterations = 4
+	_, err = Local(p, []float64{1, -2}, settings, method())
+	if err != nil {
+		t.Errorf("unexpected error with a sufficient iteration limit: %v", err)
+	}
+
+	settings = DefaultSettings()
+	settings.LinesearchMinStep = 1
+	_, err = Local(p, []float64{1, -2}, settings, method())
+	if err != ErrLinesearchFailure {
+		t.Errorf("unexpected error with a minimum step size: %v", err)
+	}
+}
+
+func TestFuncGrad(t *testing.T) {
+	f := functions.ExtendedRosenbrock{}
+	for _, method := range []Method{&BFGS{}, &GradientDescent{}, &CG{}} {
+		x := []float64{-1.2, 1}
+		want, err := Local(Problem{Func: f.Func, Grad: f.Grad}, x, nil, method)
+		if err != nil {
+			t.Fatalf("%T: unexpected error: %v", method, err)
+		}
+
+		for _, hasGrad := range []bool{true, false} {
+			var funcCalls, gradCalls, funcGradCalls int
+			p := Problem{
+				Func: func(x []float64) float64 {
+					funcCalls++
+					return f.Func(x)
+				},
+				FuncGrad: func(x, grad []float64) float64 {
+					funcGradCalls++
+					f.Grad(x, grad)
+					return f.Func(x)
+				},
+			}
+			if hasGrad {
+				p.Grad = func(x, grad []float64) {
+					gradCalls++
+					f.Grad(x, grad)
+				}
+			}
+			got, err := Local(p, x, nil, method)
+			if err != nil {
+				t.Fatalf("%T, Grad %t: unexpected error: %v", method, hasGrad, err)
+			}
+			if !floats.Equal(got.X, want.X) || got.F != want.F {
+				t.Errorf("%T, Grad %t: result differs from the one without FuncGrad", method, hasGrad)
+			}
+			if got.FuncEvaluations != want.FuncEvaluations || got.GradEvaluations != want.GradEvaluations {
+				t.Errorf("%T, Grad %t: unexpected number of evaluations: want %d and %d, got %d and %d",
+					method, hasGrad, want.FuncEvaluations, want.GradEvaluations, got.FuncEvaluations, got.GradEvaluations)
+			}
+			if funcGradCalls == 0 {
+				t.Errorf("%T, Grad %t: FuncGrad not called", method, hasGrad)
+			}
+			if hasGrad && funcCalls+funcGradCalls != got.FuncEvaluations {
+				t.Errorf("%T: function evaluated without a request", method)
+			}
+			if hasGrad && gradCalls+funcGradCalls != got.GradEvaluations {
+				t.Errorf("%T: gradient evaluated without a request", method)
+			}
+		}
+	}
+}
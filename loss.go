@@ -0,0 +1,104 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+// Loss is a robust loss function for least-squares problems. It transforms
+// the squared residuals z = r_i^2, and the objective function becomes
+//  ½ Σ_i ρ(r_i^2).
+// For ρ(z) = z this is the ordinary least-squares objective. Robust losses
+// grow more slowly than z for large z, which reduces the influence of
+// outliers on the solution.
+//
+// The losses provided by the package have a Scale parameter C, which is the
+// size of the residuals at which the loss starts to deviate from z. They are
+// defined by ρ(z) = C^2 ρ_1(z/C^2), where ρ_1 is the loss for C = 1.
+type Loss interface {
+	// Loss returns ρ(z) and its first and second derivatives with respect
+	// to z for z ≥ 0. The first derivative must be positive and equal to 1
+	// at z = 0.
+	Loss(z float64) (rho, d1, d2 float64)
+}
+
+// HuberLoss is the Huber loss
+//  ρ_1(z) = z           if z ≤ 1,
+//  ρ_1(z) = 2 √z - 1    otherwise,
+// which is quadratic in the small residuals and linear in the large ones.
+type HuberLoss struct {
+	// Scale is the size of the residuals at which the loss becomes linear.
+	// If Scale is zero, it is taken to be 1.
+	Scale float64
+}
+
+func (l HuberLoss) Loss(z float64) (rho, d1, d2 float64) {
+	return scaleLoss(l.Scale, z, func(z float64) (rho, d1, d2 float64) {
+		if z <= 1 {
+			return z, 1, 0
+		}
+		s := math.Sqrt(z)
+		return 2*s - 1, 1 / s, -0.5 / (z * s)
+	})
+}
+
+// SoftL1Loss is the smooth approximation of the l1 loss
+//  ρ_1(z) = 2 (√(1 + z) - 1).
+type SoftL1Loss struct {
+	// Scale is the size of the residuals at which the loss becomes
+	// approximately linear. If Scale is zero, it is taken to be 1.
+	Scale float64
+}
+
+func (l SoftL1Loss) Loss(z float64) (rho, d1, d2 float64) {
+	return scaleLoss(l.Scale, z, func(z float64) (rho, d1, d2 float64) {
+		s := math.Sqrt(1 + z)
+		return 2 * (s - 1), 1 / s, -0.5 / ((1 + z) * s)
+	})
+}
+
+// CauchyLoss is the Cauchy, or Lorentzian, loss
+//  ρ_1(z) = log(1 + z),
+// which grows logarithmically in the large residuals.
+type CauchyLoss struct {
+	// Scale is the size of the residuals at which the loss becomes
+	// approximately logarithmic. If Scale is zero, it is taken to be 1.
+	Scale float64
+}
+
+func (l CauchyLoss) Loss(z float64) (rho, d1, d2 float64) {
+	return scaleLoss(l.Scale, z, func(z float64) (rho, d1, d2 float64) {
+		return math.Log1p(z), 1 / (1 + z), -1 / ((1 + z) * (1 + z))
+	})
+}
+
+// ArctanLoss is the loss
+//  ρ_1(z) = arctan(z),
+// which is bounded and limits the contribution of a single residual to
+// π C^2 / 4.
+type ArctanLoss struct {
+	// Scale is the size of the residuals at which the loss starts to
+	// saturate. If Scale is zero, it is taken to be 1.
+	Scale float64
+}
+
+func (l ArctanLoss) Loss(z float64) (rho, d1, d2 float64) {
+	return scaleLoss(l.Scale, z, func(z float64) (rho, d1, d2 float64) {
+		t := 1 + z*z
+		return math.Atan(z), 1 / t, -2 * z / (t * t)
+	})
+}
+
+// scaleLoss evaluates the loss C^2 ρ_1(z/C^2) and its derivatives.
+func scaleLoss(c, z float64, loss func(z float64) (rho, d1, d2 float64)) (rho, d1, d2 float64) {
+	if c == 0 {
+		c = 1
+	}
+	if c < 0 {
+		panic("optimize: loss scale must be positive")
+	}
+	c2 := c * c
+	rho, d1, d2 = loss(z / c2)
+	return c2 * rho, d1, d2 / c2
+}
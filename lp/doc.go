@@ -0,0 +1,6 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lp implements the simplex method for solving linear programs.
+package lp
@@ -0,0 +1,417 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lp
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Problem is a linear program in general form
+//  minimize	cᵀ x
+//  subject to	A x <= b
+//  		Aeq x = beq
+//  		lower <= x <= upper.
+type Problem struct {
+	C []float64
+
+	// A and B specify the inequality constraints. If A is nil, there are no
+	// inequality constraints.
+	A mat64.Matrix
+	B []float64
+
+	// Aeq and Beq specify the equality constraints. If Aeq is nil, there are
+	// no equality constraints.
+	Aeq mat64.Matrix
+	Beq []float64
+
+	// Lower and Upper specify the bounds on the variables, whose elements
+	// may be infinite. If Lower is nil, the variables are non-negative. If
+	// Upper is nil, the variables are not bounded above.
+	Lower, Upper []float64
+}
+
+// Result is the solution of a linear program, or a certificate that it has
+// no solution.
+type Result struct {
+	X []float64
+	F float64
+
+	// Ineq and Eq are the Lagrange multipliers, or dual values, of the
+	// inequality and the equality constraints, and ReducedCost are the
+	// reduced costs of the variables. They satisfy
+	//  c + Aᵀ Ineq + Aeqᵀ Eq = ReducedCost
+	// at the solution, with Ineq >= 0. The reduced cost of a variable is
+	// zero if it is strictly between its bounds, non-negative at its lower
+	// bound and non-positive at its upper bound. The optimal value changes
+	// by -Ineq_i per unit increase of b_i and by -Eq_i per unit increase of
+	// beq_i as long as b and beq stay within the ranges below.
+	Ineq        []float64
+	Eq          []float64
+	ReducedCost []float64
+
+	// CostLow and CostHigh hold the ranges of the elements of c within
+	// which X stays optimal, and BLow, BHigh, BeqLow and BeqHigh the ranges
+	// of the elements of b and beq within which Ineq and Eq stay optimal,
+	// if only that element changes. The ranges are those of the optimal
+	// basis found by the simplex method, and they may be narrower than
+	// necessary if the solution is degenerate. The bounds may be infinite.
+	CostLow, CostHigh []float64
+	BLow, BHigh       []float64
+	BeqLow, BeqHigh   []float64
+
+	// Farkas is set if the constraints cannot be satisfied.
+	Farkas *Farkas
+	// Ray is set if the objective is unbounded below on the feasible
+	// region. Ray is a direction of unboundedness that satisfies
+	//  cᵀ Ray < 0, A Ray <= 0, Aeq Ray = 0,
+	// and x + t Ray satisfies the bounds for all t >= 0 if x does.
+	Ray []float64
+}
+
+// Farkas is a certificate of infeasibility of the constraints
+//  A x <= b, Aeq x = beq, lower <= x <= upper.
+// Its multipliers Ineq >= 0 and Eq combine the constraints into the single
+// inequality
+//  (Aᵀ Ineq + Aeqᵀ Eq)ᵀ x <= Ineqᵀ b + Eqᵀ beq,
+// which no x within the bounds satisfies. If a lower bound is greater than
+// the corresponding upper bound, the multipliers are zero.
+type Farkas struct {
+	Ineq []float64
+	Eq   []float64
+}
+
+// Solve solves the linear program p by converting it to standard form and
+// calling Simplex with the tolerance tol, see Simplex for details. Solve
+// returns ErrInfeasible if a lower bound is greater than the corresponding
+// upper bound. Solve panics if the sizes of the elements of p do not match.
+func Solve(p Problem, tol float64) (optF float64, optX []float64, err error) {
+	res, err := SolveResult(p, tol)
+	if err != nil {
+		return math.NaN(), nil, err
+	}
+	return res.F, res.X, nil
+}
+
+// SolveResult solves the linear program p like Solve and returns the
+// solution in a Result. If p is infeasible or unbounded, SolveResult returns
+// ErrInfeasible or ErrUnbounded together with a Result that holds the
+// certificate, Farkas or Ray, instead of a solution.
+func SolveResult(p Problem, tol float64) (*Result, error) {
+	n := len(p.C)
+	var mi, me int
+	if p.A != nil {
+		var c int
+		mi, c = p.A.Dims()
+		if c != n || len(p.B) != mi {
+			panic("lp: inequality size mismatch")
+		}
+	}
+	if p.Aeq != nil {
+		var c int
+		me, c = p.Aeq.Dims()
+		if c != n || len(p.Beq) != me {
+			panic("lp: equality size mismatch")
+		}
+	}
+	if (p.Lower != nil && len(p.Lower) != n) || (p.Upper != nil && len(p.Upper) != n) {
+		panic("lp: bounds size mismatch")
+	}
+
+	// Every variable x_j is replaced by
+	//  x_j = offset_j + sign_j z_k          if it is bounded above or below,
+	//  x_j = z_k - z_{k+1}                  if it is free,
+	// with non-negative variables z. A variable bounded on both sides also
+	// gets a row z_k + s = upper_j - lower_j with a slack variable s.
+	offset := make([]float64, n)
+	sign := make([]float64, n)
+	col := make([]int, n)
+	var nz int
+	var boxed []int
+	var width []float64 // Widths of the intervals of the boxed variables.
+	for j := 0; j < n; j++ {
+		lo, up := 0.0, math.Inf(1)
+		if p.Lower != nil {
+			lo = p.Lower[j]
+		}
+		if p.Upper != nil {
+			up = p.Upper[j]
+		}
+		if lo > up {
+			return &Result{Farkas: &Farkas{Ineq: make([]float64, mi), Eq: make([]float64, me)}}, ErrInfeasible
+		}
+		col[j] = nz
+		switch {
+		case !math.IsInf(lo, -1):
+			offset[j], sign[j] = lo, 1
+			if !math.IsInf(up, 1) {
+				boxed = append(boxed, j)
+				width = append(width, up-lo)
+			}
+			nz++
+		case !math.IsInf(up, 1):
+			offset[j], sign[j] = up, -1
+			nz++
+		default:
+			sign[j] = 0
+			nz += 2
+		}
+	}
+
+	// The standard form has the rows of the inequalities, the equalities and
+	// the upper bounds, and the columns of z followed by the slack
+	// variables of the inequalities and of the upper bounds.
+	m := mi + me + len(boxed)
+	ns := nz + mi + len(boxed)
+	c := make([]float64, ns)
+	for j, cj := range p.C {
+		if sign[j] == 0 {
+			c[col[j]] = cj
+			c[col[j]+1] = -cj
+		} else {
+			c[col[j]] = sign[j] * cj
+		}
+	}
+	if m == 0 {
+		// Without constraints, the minimum is at z = 0 unless the objective
+		// decreases along a variable.
+		for k, v := range c {
+			if v < 0 {
+				ray := make([]float64, ns)
+				ray[k] = 1
+				return &Result{Ray: direction(sign, col, ray)}, ErrUnbounded
+			}
+		}
+		res := optimum(p.C, offset, sign, col, make([]float64, ns))
+		sensitivity(res, p, sign, col, nil, nil, c, &standardResult{}, tol)
+		return res, nil
+	}
+
+	a := mat64.NewDense(m, ns, nil)
+	b := make([]float64, m)
+	setRow := func(i int, row mat64.Matrix, r int, rhs float64) {
+		b[i] = rhs
+		for j := 0; j < n; j++ {
+			v := row.At(r, j)
+			b[i] -= v * offset[j]
+			if sign[j] == 0 {
+				a.Set(i, col[j], v)
+				a.Set(i, col[j]+1, -v)
+			} else {
+				a.Set(i, col[j], sign[j]*v)
+			}
+		}
+	}
+	for i := 0; i < mi; i++ {
+		setRow(i, p.A, i, p.B[i])
+		a.Set(i, nz+i, 1)
+	}
+	for i := 0; i < me; i++ {
+		setRow(mi+i, p.Aeq, i, p.Beq[i])
+	}
+	for k, j := range boxed {
+		i := mi + me + k
+		a.Set(i, col[j], 1)
+		a.Set(i, nz+mi+k, 1)
+		b[i] = width[k]
+	}
+	sr, err := simplexStandard(c, a, b, tol)
+	switch err {
+	case nil:
+		res := optimum(p.C, offset, sign, col, sr.x)
+		sensitivity(res, p, sign, col, a, b, c, sr, tol)
+		return res, nil
+	case ErrInfeasible:
+		// The rows of the standard form are those of the inequalities
+		// A x + s = b, whose multipliers have the opposite sign.
+		f := &Farkas{Ineq: make([]float64, mi), Eq: make([]float64, me)}
+		for i := range f.Ineq {
+			f.Ineq[i] = math.Max(-sr.farkas[i], 0)
+		}
+		for i := range f.Eq {
+			f.Eq[i] = -sr.farkas[mi+i]
+		}
+		return &Result{Farkas: f}, err
+	case ErrUnbounded:
+		return &Result{Ray: direction(sign, col, sr.ray)}, err
+	default:
+		return nil, err
+	}
+}
+
+// optimum returns the solution of the linear program given the solution z
+// of its standard form.
+func optimum(c, offset, sign []float64, col []int, z []float64) *Result {
+	res := &Result{X: make([]float64, len(c))}
+	for j := range res.X {
+		if sign[j] == 0 {
+			res.X[j] = z[col[j]] - z[col[j]+1]
+		} else {
+			res.X[j] = offset[j] + sign[j]*z[col[j]]
+		}
+		res.F += c[j] * res.X[j]
+	}
+	return res
+}
+
+// sensitivity stores the dual solution, the reduced costs and the ranges of
+// the optimal basis of the standard form given by a, b and c and the result
+// sr into res.
+func sensitivity(res *Result, p Problem, sign []float64, col []int, a *mat64.Dense, b, c []float64, sr *standardResult, tol float64) {
+	if tol == 0 {
+		tol = defaultTol
+	}
+	n := len(p.C)
+	ns := len(c)
+	var mi, me int
+	if p.A != nil {
+		mi, _ = p.A.Dims()
+	}
+	if p.Aeq != nil {
+		me, _ = p.Aeq.Dims()
+	}
+
+	// The rows of the inequalities and the equalities come first in the
+	// standard form. The multipliers of A x + s = b have the opposite sign
+	// of those of A x <= b.
+	res.Ineq = make([]float64, mi)
+	res.Eq = make([]float64, me)
+	for i := range res.Ineq {
+		res.Ineq[i] = -sr.y[i]
+	}
+	for i := range res.Eq {
+		res.Eq[i] = -sr.y[mi+i]
+	}
+	res.ReducedCost = make([]float64, n)
+	copy(res.ReducedCost, p.C)
+	for j := range res.ReducedCost {
+		for i, v := range res.Ineq {
+			res.ReducedCost[j] += v * p.A.At(i, j)
+		}
+		for i, v := range res.Eq {
+			res.ReducedCost[j] += v * p.Aeq.At(i, j)
+		}
+	}
+
+	// Reduced costs of the variables of the standard form, and the rows of
+	// the basic ones.
+	rc := make([]float64, ns)
+	copy(rc, c)
+	row := make([]int, ns)
+	for k := range row {
+		row[k] = -1
+		for i, v := range sr.y {
+			rc[k] -= v * a.At(i, k)
+		}
+	}
+	for r, k := range sr.basis {
+		if k < ns {
+			row[k] = r
+		}
+	}
+
+	// A change ε of the cost of the basic variable k in row r changes the
+	// reduced cost of every non-basic variable q by -ε (B⁻¹ a_q)_r. A change
+	// of the cost of a non-basic variable changes only its reduced cost.
+	costRange := func(k, twin int) (lo, hi float64) {
+		lo, hi = math.Inf(-1), math.Inf(1)
+		r := row[k]
+		if r < 0 {
+			lo = -rc[k]
+			if twin >= 0 {
+				// The reduced cost of the twin changes by -ε.
+				hi = rc[twin]
+			}
+			return lo, hi
+		}
+		for q := 0; q < ns; q++ {
+			if row[q] >= 0 || q == twin {
+				continue
+			}
+			var alpha float64
+			for i, v := range sr.binv[r] {
+				alpha += v * a.At(i, q)
+			}
+			switch {
+			case alpha > tol:
+				hi = math.Min(hi, rc[q]/alpha)
+			case alpha < -tol:
+				lo = math.Max(lo, rc[q]/alpha)
+			}
+		}
+		return lo, hi
+	}
+	res.CostLow = make([]float64, n)
+	res.CostHigh = make([]float64, n)
+	for j := range p.C {
+		// A free variable is the difference of the variables col[j] and
+		// col[j]+1 of the standard form, whose costs change in opposite
+		// directions. At most one of them is basic.
+		k, s, twin := col[j], sign[j], -1
+		if s == 0 {
+			k, s, twin = col[j], 1, col[j]+1
+			if row[twin] >= 0 {
+				k, s, twin = twin, -1, k
+			}
+		}
+		lo, hi := costRange(k, twin)
+		if s < 0 {
+			lo, hi = -hi, -lo
+		}
+		res.CostLow[j] = p.C[j] + lo
+		res.CostHigh[j] = p.C[j] + hi
+	}
+
+	// A change δ of b_i changes the basic variables by δ B⁻¹ e_i, and they
+	// must stay non-negative. Artificial variables of redundant rows must
+	// stay zero.
+	rhsRange := func(i int) (lo, hi float64) {
+		lo, hi = math.Inf(-1), math.Inf(1)
+		for r, k := range sr.basis {
+			beta := sr.binv[r][i]
+			if math.Abs(beta) <= tol {
+				continue
+			}
+			if k >= ns {
+				return 0, 0
+			}
+			if beta > 0 {
+				lo = math.Max(lo, -sr.x[k]/beta)
+			} else {
+				hi = math.Min(hi, -sr.x[k]/beta)
+			}
+		}
+		return lo, hi
+	}
+	res.BLow = make([]float64, mi)
+	res.BHigh = make([]float64, mi)
+	for i := range res.BLow {
+		lo, hi := rhsRange(i)
+		res.BLow[i] = p.B[i] + lo
+		res.BHigh[i] = p.B[i] + hi
+	}
+	res.BeqLow = make([]float64, me)
+	res.BeqHigh = make([]float64, me)
+	for i := range res.BeqLow {
+		lo, hi := rhsRange(mi + i)
+		res.BeqLow[i] = p.Beq[i] + lo
+		res.BeqHigh[i] = p.Beq[i] + hi
+	}
+}
+
+// direction returns the direction in the variables of the linear program
+// given the direction dz in the variables of its standard form.
+func direction(sign []float64, col []int, dz []float64) []float64 {
+	d := make([]float64, len(sign))
+	for j := range d {
+		if sign[j] == 0 {
+			d[j] = dz[col[j]] - dz[col[j]+1]
+		} else {
+			d[j] = sign[j] * dz[col[j]]
+		}
+	}
+	return d
+}
@@ -0,0 +1,462 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lp
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultTol = 1e-10
+	// refactorIterations is the number of pivots after which the inverse of
+	// the basis matrix is recomputed from scratch to limit the accumulation
+	// of rounding errors.
+	refactorIterations = 50
+	// maxIterationsFactor times the number of variables and constraints is
+	// the maximum number of pivots in a phase.
+	maxIterationsFactor = 100
+	// singularTol is the relative size of the pivot below which a basis
+	// matrix is considered singular.
+	singularTol = 1e-14
+)
+
+var (
+	// ErrInfeasible signifies that the constraints of the linear program
+	// cannot be satisfied.
+	ErrInfeasible = errors.New("lp: problem is infeasible")
+
+	// ErrUnbounded signifies that the objective function of the linear
+	// program is unbounded below on the feasible region.
+	ErrUnbounded = errors.New("lp: problem is unbounded")
+
+	// ErrSingular signifies that a basis matrix is numerically singular,
+	// which may occur for badly scaled problems.
+	ErrSingular = errors.New("lp: singular basis matrix")
+
+	// ErrIterationLimit signifies that the simplex method has not
+	// terminated within the maximum number of iterations, which may occur
+	// if rounding errors cause cycling.
+	ErrIterationLimit = errors.New("lp: iteration limit reached")
+)
+
+// Simplex solves the linear program in standard form
+//  minimize	cᵀ x
+//  subject to	A x = b
+//  		x >= 0
+// by the revised simplex method and returns the optimal objective value and
+// an optimal solution. The m×n matrix A need not have full row rank.
+//
+// Simplex first finds a feasible basis by minimizing the sum of artificial
+// variables (phase I), and then minimizes the objective from it (phase II).
+// The entering and leaving variables are chosen by Bland's rule, the smallest
+// index among the candidates, which prevents cycling at degenerate vertices.
+//
+// tol is the tolerance for the reduced costs, the pivot elements and the
+// feasibility. If tol is zero, it will be set to 1e-10. Simplex returns
+// ErrInfeasible if the constraints cannot be satisfied and ErrUnbounded if
+// the objective is unbounded below. Simplex panics if the sizes of c, A and b
+// do not match.
+//
+// References:
+//  - Bland, R.G.: New finite pivoting rules for the simplex method. Math.
+//    Oper. Res. 2 (1977), 103-107
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization, 2nd ed. Springer
+//    (2006), chapter 13
+func Simplex(c []float64, A mat64.Matrix, b []float64, tol float64) (optF float64, optX []float64, err error) {
+	res, err := simplexStandard(c, A, b, tol)
+	if err != nil {
+		return math.NaN(), nil, err
+	}
+	return res.f, res.x, nil
+}
+
+// standardResult is the outcome of the simplex method for a linear program
+// in standard form.
+type standardResult struct {
+	f float64
+	x []float64
+
+	// basis holds the basic variables by row, binv the inverse of the
+	// optimal basis matrix and y the simplex multipliers, which are the
+	// dual solution.
+	basis []int
+	binv  [][]float64
+	y     []float64
+
+	// farkas is set if the problem is infeasible. It satisfies
+	// Aᵀ farkas <= 0 and bᵀ farkas > 0, so that every x with A x = b has a
+	// negative element.
+	farkas []float64
+	// ray is set if the problem is unbounded. It satisfies A ray = 0,
+	// ray >= 0 and cᵀ ray < 0.
+	ray []float64
+}
+
+// simplexStandard implements Simplex. If the problem is infeasible or
+// unbounded, it returns the certificate together with the error.
+func simplexStandard(c []float64, A mat64.Matrix, b []float64, tol float64) (*standardResult, error) {
+	m, n := A.Dims()
+	if len(c) != n || len(b) != m {
+		panic("lp: size mismatch")
+	}
+	if tol == 0 {
+		tol = defaultTol
+	}
+	if tol < 0 {
+		panic("lp: negative tolerance")
+	}
+
+	// Store the columns of A, with the rows negated where b is negative so
+	// that the artificial basis is feasible.
+	cols := make([][]float64, n+m)
+	for j := 0; j < n; j++ {
+		cols[j] = make([]float64, m)
+		for i := range cols[j] {
+			cols[j][i] = A.At(i, j)
+		}
+	}
+	rhs := make([]float64, m)
+	for i, v := range b {
+		rhs[i] = v
+		if v < 0 {
+			rhs[i] = -v
+			for j := 0; j < n; j++ {
+				cols[j][i] = -cols[j][i]
+			}
+		}
+	}
+	// Artificial variables with identity columns.
+	for i := 0; i < m; i++ {
+		cols[n+i] = make([]float64, m)
+		cols[n+i][i] = 1
+	}
+
+	s := &simplex{
+		cols:  cols,
+		b:     rhs,
+		n:     n,
+		tol:   tol,
+		basis: make([]int, m),
+		binv:  newIdentity(m),
+		xb:    make([]float64, m),
+		y:     make([]float64, m),
+		u:     make([]float64, m),
+	}
+	for i := range s.basis {
+		s.basis[i] = n + i
+	}
+	copy(s.xb, rhs)
+
+	// Phase I minimizes the sum of the artificial variables.
+	cost := make([]float64, n+m)
+	for i := 0; i < m; i++ {
+		cost[n+i] = 1
+	}
+	if err := s.solve(cost, n+m); err != nil {
+		return nil, err
+	}
+	var infeas, bNorm float64
+	for i, j := range s.basis {
+		if j >= n {
+			infeas += s.xb[i]
+		}
+	}
+	for _, v := range rhs {
+		bNorm = math.Max(bNorm, v)
+	}
+	if infeas > tol*math.Max(1, bNorm) {
+		// The simplex multipliers of phase I have non-positive products
+		// with the columns of A, and their product with b is the positive
+		// sum of the artificial variables.
+		farkas := make([]float64, m)
+		for i, v := range s.y {
+			farkas[i] = v
+			if b[i] < 0 {
+				farkas[i] = -v
+			}
+		}
+		return &standardResult{farkas: farkas}, ErrInfeasible
+	}
+	if err := s.driveOutArtificials(); err != nil {
+		return nil, err
+	}
+
+	// Phase II minimizes the objective without letting the artificial
+	// variables enter the basis. Artificial variables of redundant rows
+	// stay in the basis at zero.
+	copy(cost, c)
+	for i := n; i < n+m; i++ {
+		cost[i] = 0
+	}
+	if err := s.solve(cost, n); err != nil {
+		if err != ErrUnbounded {
+			return nil, err
+		}
+		// The objective decreases without bound when the entering variable
+		// increases and the basic variables change by -u.
+		ray := make([]float64, n)
+		ray[s.enter] = 1
+		for i, j := range s.basis {
+			if j < n {
+				ray[j] = math.Max(-s.u[i], 0)
+			}
+		}
+		return &standardResult{ray: ray}, ErrUnbounded
+	}
+
+	// The rows with a negative b were negated.
+	res := &standardResult{
+		x:     make([]float64, n),
+		basis: s.basis,
+		binv:  s.binv,
+		y:     s.y,
+	}
+	for i, v := range b {
+		if v < 0 {
+			res.y[i] = -res.y[i]
+			for _, row := range res.binv {
+				row[i] = -row[i]
+			}
+		}
+	}
+	for i, j := range s.basis {
+		if j < n {
+			res.x[j] = math.Max(s.xb[i], 0)
+		}
+	}
+	for j, v := range res.x {
+		res.f += c[j] * v
+	}
+	return res, nil
+}
+
+// simplex is the state of the revised simplex method for the problem with
+// the columns cols and the non-negative right-hand side b.
+type simplex struct {
+	cols [][]float64
+	b    []float64
+	n    int // Number of variables without the artificial ones.
+	tol  float64
+
+	basis []int       // Variables in the basis, by row.
+	binv  [][]float64 // Inverse of the basis matrix.
+	xb    []float64   // Values of the basic variables.
+
+	y []float64 // Simplex multipliers.
+	u []float64 // Entering column in the basis coordinates.
+
+	enter int // Entering variable of the last iteration.
+
+	pivots int // Number of pivots since the last refactorization.
+}
+
+// solve minimizes cost over the current basis, letting only the variables
+// with index less than enter into the basis.
+func (s *simplex) solve(cost []float64, enter int) error {
+	m := len(s.b)
+	maxIter := maxIterationsFactor * (len(s.cols) + m)
+	for iter := 0; ; iter++ {
+		if iter == maxIter {
+			return ErrIterationLimit
+		}
+		// Compute the simplex multipliers y = B^{-T} c_B.
+		for k := range s.y {
+			var sum float64
+			for i, j := range s.basis {
+				sum += cost[j] * s.binv[i][k]
+			}
+			s.y[k] = sum
+		}
+		// Bland's rule: the entering variable is the one with the smallest
+		// index among those with a negative reduced cost.
+		q := -1
+		for j := 0; j < enter; j++ {
+			if s.inBasis(j) {
+				continue
+			}
+			d := cost[j] - floats.Dot(s.y, s.cols[j])
+			if d < -s.tol {
+				q = j
+				break
+			}
+		}
+		if q < 0 {
+			return nil
+		}
+		s.enter = q
+		s.basisCoords(s.u, s.cols[q])
+		// Ratio test, with ties broken by the smallest index of the leaving
+		// variable.
+		p := -1
+		var minRatio float64
+		for i, ui := range s.u {
+			if ui <= s.tol {
+				continue
+			}
+			r := s.xb[i] / ui
+			if p < 0 || r < minRatio || (r == minRatio && s.basis[i] < s.basis[p]) {
+				p = i
+				minRatio = r
+			}
+		}
+		if p < 0 {
+			return ErrUnbounded
+		}
+		if err := s.pivot(p, q); err != nil {
+			return err
+		}
+	}
+}
+
+// driveOutArtificials replaces the artificial variables that remain in the
+// basis at zero after phase I by original variables where possible.
+func (s *simplex) driveOutArtificials() error {
+	for p, j := range s.basis {
+		if j < s.n {
+			continue
+		}
+		for q := 0; q < s.n; q++ {
+			if s.inBasis(q) {
+				continue
+			}
+			s.basisCoords(s.u, s.cols[q])
+			if math.Abs(s.u[p]) > s.tol {
+				if err := s.pivot(p, q); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		// If no variable can replace the artificial one, its row is
+		// redundant.
+	}
+	return nil
+}
+
+// pivot replaces the basic variable of row p by the variable q, whose column
+// in the basis coordinates is in s.u.
+func (s *simplex) pivot(p, q int) error {
+	up := s.u[p]
+	theta := s.xb[p] / up
+	for i, ui := range s.u {
+		if i == p {
+			continue
+		}
+		s.xb[i] -= theta * ui
+		f := ui / up
+		for k, v := range s.binv[p] {
+			s.binv[i][k] -= f * v
+		}
+	}
+	s.xb[p] = theta
+	for k := range s.binv[p] {
+		s.binv[p][k] /= up
+	}
+	s.basis[p] = q
+
+	s.pivots++
+	if s.pivots == refactorIterations {
+		return s.refactor()
+	}
+	return nil
+}
+
+// refactor recomputes the inverse of the basis matrix and the values of the
+// basic variables.
+func (s *simplex) refactor() error {
+	s.pivots = 0
+	m := len(s.b)
+	bm := make([][]float64, m)
+	for i := range bm {
+		bm[i] = make([]float64, m)
+		for k, j := range s.basis {
+			bm[i][k] = s.cols[j][i]
+		}
+	}
+	if !invert(s.binv, bm) {
+		return ErrSingular
+	}
+	for i := range s.xb {
+		s.xb[i] = floats.Dot(s.binv[i], s.b)
+	}
+	return nil
+}
+
+// basisCoords stores B^{-1} a into dst.
+func (s *simplex) basisCoords(dst, a []float64) {
+	for i, row := range s.binv {
+		dst[i] = floats.Dot(row, a)
+	}
+}
+
+func (s *simplex) inBasis(j int) bool {
+	for _, v := range s.basis {
+		if v == j {
+			return true
+		}
+	}
+	return false
+}
+
+func newIdentity(m int) [][]float64 {
+	a := make([][]float64, m)
+	for i := range a {
+		a[i] = make([]float64, m)
+		a[i][i] = 1
+	}
+	return a
+}
+
+// invert stores the inverse of the square matrix a into dst by Gauss-Jordan
+// elimination with partial pivoting. a is overwritten. invert returns false
+// if a is numerically singular.
+func invert(dst, a [][]float64) bool {
+	m := len(a)
+	var scale float64
+	for _, row := range a {
+		for _, v := range row {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	for i := range dst {
+		for k := range dst[i] {
+			dst[i][k] = 0
+		}
+		dst[i][i] = 1
+	}
+	for k := 0; k < m; k++ {
+		p := k
+		for i := k + 1; i < m; i++ {
+			if math.Abs(a[i][k]) > math.Abs(a[p][k]) {
+				p = i
+			}
+		}
+		if math.Abs(a[p][k]) <= singularTol*scale {
+			return false
+		}
+		a[k], a[p] = a[p], a[k]
+		dst[k], dst[p] = dst[p], dst[k]
+		d := a[k][k]
+		for j := range a[k] {
+			a[k][j] /= d
+			dst[k][j] /= d
+		}
+		for i := 0; i < m; i++ {
+			if i == k || a[i][k] == 0 {
+				continue
+			}
+			f := a[i][k]
+			for j := range a[k] {
+				a[i][j] -= f * a[k][j]
+				dst[i][j] -= f * dst[k][j]
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,618 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestSimplex(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		c    []float64
+		a    *mat64.Dense
+		b    []float64
+		optF float64
+		optX []float64
+		err  error
+	}{
+		{
+			name: "Slack",
+			c:    []float64{-1, -1, 0, 0},
+			a:    mat64.NewDense(2, 4, []float64{1, 2, 1, 0, 3, 1, 0, 1}),
+			b:    []float64{4, 6},
+			optF: -2.8,
+			optX: []float64{1.6, 1.2, 0, 0},
+		},
+		{
+			// Beale's example, on which the simplex method with the
+			// largest reduced cost rule cycles.
+			name: "Beale",
+			c:    []float64{0, 0, 0, -0.75, 20, -0.5, 6},
+			a: mat64.NewDense(3, 7, []float64{
+				1, 0, 0, 0.25, -8, -1, 9,
+				0, 1, 0, 0.5, -12, -0.5, 3,
+				0, 0, 1, 0, 0, 1, 0,
+			}),
+			b:    []float64{0, 0, 1},
+			optF: -1.25,
+			optX: []float64{0.75, 0, 0, 1, 0, 1, 0},
+		},
+		{
+			name: "RedundantRow",
+			c:    []float64{1, 3, 2},
+			a: mat64.NewDense(3, 3, []float64{
+				1, 1, 1,
+				2, 2, 2,
+				1, 0, -1,
+			}),
+			b:    []float64{1, 2, 0},
+			optF: 1.5,
+			optX: []float64{0.5, 0, 0.5},
+		},
+		{
+			name: "NegativeRHS",
+			c:    []float64{1, 1},
+			a:    mat64.NewDense(1, 2, []float64{-1, -2}),
+			b:    []float64{-4},
+			optF: 2,
+			optX: []float64{0, 2},
+		},
+		{
+			name: "Infeasible",
+			c:    []float64{1, 1},
+			a:    mat64.NewDense(1, 2, []float64{1, 1}),
+			b:    []float64{-1},
+			err:  ErrInfeasible,
+		},
+		{
+			name: "Unbounded",
+			c:    []float64{-1, 0},
+			a:    mat64.NewDense(1, 2, []float64{1, -1}),
+			b:    []float64{1},
+			err:  ErrUnbounded,
+		},
+	} {
+		optF, optX, err := Simplex(test.c, test.a, test.b, 0)
+		if err != test.err {
+			t.Errorf("%s: unexpected error, want %v, got %v", test.name, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if math.Abs(optF-test.optF) > 1e-10 {
+			t.Errorf("%s: unexpected optimal value, want %v, got %v", test.name, test.optF, optF)
+		}
+		if !floats.EqualApprox(optX, test.optX, 1e-10) {
+			t.Errorf("%s: unexpected solution, want %v, got %v", test.name, test.optX, optX)
+		}
+	}
+}
+
+func TestSimplexRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 100; trial++ {
+		m := 1 + rnd.Intn(40)
+		n := m + rnd.Intn(40)
+		a := mat64.NewDense(m, n, nil)
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				a.Set(i, j, rnd.NormFloat64())
+			}
+		}
+		// Construct a primal solution x and a dual solution y with reduced
+		// costs s that satisfy complementary slackness, so that x is optimal.
+		x := make([]float64, n)
+		s := make([]float64, n)
+		for j := range x {
+			if rnd.Intn(2) == 0 {
+				x[j] = rnd.Float64()
+			} else {
+				s[j] = rnd.Float64()
+			}
+		}
+		y := make([]float64, m)
+		for i := range y {
+			y[i] = rnd.NormFloat64()
+		}
+		b := make([]float64, m)
+		c := make([]float64, n)
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				b[i] += a.At(i, j) * x[j]
+				c[j] += a.At(i, j) * y[i]
+			}
+		}
+		floats.Add(c, s)
+		want := floats.Dot(c, x)
+
+		optF, optX, err := Simplex(c, a, b, 0)
+		if err != nil {
+			t.Errorf("trial %d: unexpected error: %v", trial, err)
+			continue
+		}
+		if math.Abs(optF-want) > 1e-8*math.Max(1, math.Abs(want)) {
+			t.Errorf("trial %d: unexpected optimal value, want %v, got %v", trial, want, optF)
+		}
+		for i := 0; i < m; i++ {
+			var ax float64
+			for j, v := range optX {
+				ax += a.At(i, j) * v
+			}
+			if math.Abs(ax-b[i]) > 1e-8*math.Max(1, math.Abs(b[i])) {
+				t.Errorf("trial %d: constraint %d violated by %v", trial, i, ax-b[i])
+			}
+		}
+		for j, v := range optX {
+			if v < 0 {
+				t.Errorf("trial %d: negative variable %d", trial, j)
+			}
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	inf := math.Inf(1)
+	for _, test := range []struct {
+		name string
+		p    Problem
+		optF float64
+		optX []float64
+		err  error
+	}{
+		{
+			name: "FreeAndLowerBounded",
+			p: Problem{
+				C:     []float64{-1, 4},
+				A:     mat64.NewDense(2, 2, []float64{-3, 1, 1, 2}),
+				B:     []float64{6, 4},
+				Lower: []float64{-inf, -3},
+			},
+			optF: -22,
+			optX: []float64{10, -3},
+		},
+		{
+			name: "BoxedWithEquality",
+			p: Problem{
+				C:     []float64{-1, -1},
+				A:     mat64.NewDense(1, 2, []float64{1, 1}),
+				B:     []float64{3},
+				Aeq:   mat64.NewDense(1, 2, []float64{1, -1}),
+				Beq:   []float64{0},
+				Upper: []float64{2, 2},
+			},
+			optF: -3,
+			optX: []float64{1.5, 1.5},
+		},
+		{
+			name: "UpperBounded",
+			p: Problem{
+				C:     []float64{1, -1},
+				A:     mat64.NewDense(1, 2, []float64{-1, -1}),
+				B:     []float64{10},
+				Lower: []float64{-inf, 0},
+				Upper: []float64{-2, 5},
+			},
+			optF: -20,
+			optX: []float64{-15, 5},
+		},
+		{
+			name: "Unconstrained",
+			p: Problem{
+				C:     []float64{1, -2},
+				Lower: []float64{1, -inf},
+				Upper: []float64{inf, 4},
+			},
+			optF: -7,
+			optX: []float64{1, 4},
+		},
+		{
+			name: "UnconstrainedUnbounded",
+			p:    Problem{C: []float64{1, -2}},
+			err:  ErrUnbounded,
+		},
+		{
+			name: "InconsistentBounds",
+			p: Problem{
+				C:     []float64{1},
+				Lower: []float64{1},
+				Upper: []float64{0},
+			},
+			err: ErrInfeasible,
+		},
+		{
+			name: "Infeasible",
+			p: Problem{
+				C:     []float64{1, 1},
+				A:     mat64.NewDense(1, 2, []float64{1, 1}),
+				B:     []float64{1},
+				Lower: []float64{1, 1},
+			},
+			err: ErrInfeasible,
+		},
+	} {
+		optF, optX, err := Solve(test.p, 0)
+		if err != test.err {
+			t.Errorf("%s: unexpected error, want %v, got %v", test.name, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if math.Abs(optF-test.optF) > 1e-10 {
+			t.Errorf("%s: unexpected optimal value, want %v, got %v", test.name, test.optF, optF)
+		}
+		if !floats.EqualApprox(optX, test.optX, 1e-10) {
+			t.Errorf("%s: unexpected solution, want %v, got %v", test.name, test.optX, optX)
+		}
+	}
+}
+
+func TestSolveCertificate(t *testing.T) {
+	inf := math.Inf(1)
+	for _, test := range []struct {
+		name string
+		p    Problem
+		err  error
+	}{
+		{
+			name: "Infeasible",
+			p: Problem{
+				C:     []float64{1, 1},
+				A:     mat64.NewDense(1, 2, []float64{1, 1}),
+				B:     []float64{1},
+				Lower: []float64{1, 1},
+			},
+			err: ErrInfeasible,
+		},
+		{
+			name: "InfeasibleFree",
+			p: Problem{
+				C:     []float64{1, -1},
+				A:     mat64.NewDense(1, 2, []float64{1, 1}),
+				B:     []float64{0},
+				Aeq:   mat64.NewDense(1, 2, []float64{-1, -1}),
+				Beq:   []float64{-1},
+				Lower: []float64{-inf, -inf},
+			},
+			err: ErrInfeasible,
+		},
+		{
+			name: "InfeasibleBoxed",
+			p: Problem{
+				C:     []float64{1, 2},
+				A:     mat64.NewDense(2, 2, []float64{-1, -1, 1, -1}),
+				B:     []float64{-3, 1},
+				Upper: []float64{1, 1},
+			},
+			err: ErrInfeasible,
+		},
+		{
+			name: "InconsistentBounds",
+			p: Problem{
+				C:     []float64{1},
+				A:     mat64.NewDense(1, 1, []float64{1}),
+				B:     []float64{1},
+				Lower: []float64{1},
+				Upper: []float64{0},
+			},
+			err: ErrInfeasible,
+		},
+		{
+			name: "Unbounded",
+			p: Problem{
+				C: []float64{-1, 0},
+				A: mat64.NewDense(1, 2, []float64{1, -1}),
+				B: []float64{1},
+			},
+			err: ErrUnbounded,
+		},
+		{
+			name: "UnboundedUpper",
+			p: Problem{
+				C:     []float64{1, 1},
+				A:     mat64.NewDense(1, 2, []float64{1, 1}),
+				B:     []float64{2},
+				Aeq:   mat64.NewDense(1, 2, []float64{0, 1}),
+				Beq:   []float64{1},
+				Lower: []float64{-inf, -inf},
+				Upper: []float64{3, inf},
+			},
+			err: ErrUnbounded,
+		},
+		{
+			name: "UnconstrainedUnbounded",
+			p: Problem{
+				C:     []float64{1, 2},
+				Lower: []float64{0, -inf},
+				Upper: []float64{1, 0},
+			},
+			err: ErrUnbounded,
+		},
+	} {
+		res, err := SolveResult(test.p, 0)
+		if err != test.err {
+			t.Errorf("%s: unexpected error, want %v, got %v", test.name, test.err, err)
+			continue
+		}
+		if err == ErrInfeasible {
+			checkFarkas(t, test.name, test.p, res.Farkas)
+		} else {
+			checkRay(t, test.name, test.p, res.Ray)
+		}
+	}
+}
+
+// bounds returns the bounds of the variables of p.
+func bounds(p Problem) (lower, upper []float64) {
+	n := len(p.C)
+	lower = make([]float64, n)
+	upper = make([]float64, n)
+	for j := range lower {
+		if p.Lower != nil {
+			lower[j] = p.Lower[j]
+		}
+		upper[j] = math.Inf(1)
+		if p.Upper != nil {
+			upper[j] = p.Upper[j]
+		}
+	}
+	return lower, upper
+}
+
+// checkFarkas checks that no x within the bounds of p satisfies the
+// constraint combined by f.
+func checkFarkas(t *testing.T, name string, p Problem, f *Farkas) {
+	if f == nil {
+		t.Errorf("%s: no Farkas certificate", name)
+		return
+	}
+	lower, upper := bounds(p)
+	for j := range lower {
+		if lower[j] > upper[j] {
+			return
+		}
+	}
+	r := make([]float64, len(p.C))
+	var rhs float64
+	for i, v := range f.Ineq {
+		if v < 0 {
+			t.Errorf("%s: negative multiplier of inequality %d", name, i)
+		}
+		floats.AddScaled(r, v, p.A.(*mat64.Dense).Row(nil, i))
+		rhs += v * p.B[i]
+	}
+	for i, v := range f.Eq {
+		floats.AddScaled(r, v, p.Aeq.(*mat64.Dense).Row(nil, i))
+		rhs += v * p.Beq[i]
+	}
+	// Minimize rᵀx within the bounds.
+	var min float64
+	for j, v := range r {
+		switch {
+		case v > 0:
+			min += v * lower[j]
+		case v < 0:
+			min += v * upper[j]
+		}
+	}
+	if !(min > rhs) {
+		t.Errorf("%s: invalid Farkas certificate, min %v not greater than %v", name, min, rhs)
+	}
+}
+
+// checkRay checks that the objective of p decreases along ray and that ray
+// is a feasible direction.
+func checkRay(t *testing.T, name string, p Problem, ray []float64) {
+	if ray == nil {
+		t.Errorf("%s: no ray", name)
+		return
+	}
+	const tol = 1e-10
+	if floats.Dot(p.C, ray) >= 0 {
+		t.Errorf("%s: objective does not decrease along the ray", name)
+	}
+	if p.A != nil {
+		m, _ := p.A.Dims()
+		for i := 0; i < m; i++ {
+			if floats.Dot(p.A.(*mat64.Dense).Row(nil, i), ray) > tol {
+				t.Errorf("%s: inequality %d violated along the ray", name, i)
+			}
+		}
+	}
+	if p.Aeq != nil {
+		m, _ := p.Aeq.Dims()
+		for i := 0; i < m; i++ {
+			if math.Abs(floats.Dot(p.Aeq.(*mat64.Dense).Row(nil, i), ray)) > tol {
+				t.Errorf("%s: equality %d violated along the ray", name, i)
+			}
+		}
+	}
+	lower, upper := bounds(p)
+	for j, v := range ray {
+		if (!math.IsInf(lower[j], -1) && v < 0) || (!math.IsInf(upper[j], 1) && v > 0) {
+			t.Errorf("%s: bound of variable %d violated along the ray", name, j)
+		}
+	}
+}
+
+func TestSolveSensitivity(t *testing.T) {
+	// Wyndor Glass Co. problem from Hillier & Lieberman, Introduction to
+	// Operations Research, with the objective negated.
+	p := Problem{
+		C: []float64{-3, -5},
+		A: mat64.NewDense(3, 2, []float64{
+			1, 0,
+			0, 2,
+			3, 2,
+		}),
+		B: []float64{4, 12, 18},
+	}
+	res, err := SolveResult(p, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inf := math.Inf(1)
+	for _, test := range []struct {
+		name      string
+		got, want []float64
+	}{
+		{"X", res.X, []float64{2, 6}},
+		{"Ineq", res.Ineq, []float64{0, 1.5, 1}},
+		{"ReducedCost", res.ReducedCost, []float64{0, 0}},
+		{"CostLow", res.CostLow, []float64{-7.5, -inf}},
+		{"CostHigh", res.CostHigh, []float64{0, -2}},
+		{"BLow", res.BLow, []float64{2, 6, 12}},
+		{"BHigh", res.BHigh, []float64{inf, 18, 24}},
+	} {
+		if !equalApprox(test.got, test.want, 1e-10) {
+			t.Errorf("unexpected %s: want %v, got %v", test.name, test.want, test.got)
+		}
+	}
+}
+
+func TestSolveSensitivityRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 50; trial++ {
+		n := 1 + rnd.Intn(6)
+		mi := 1 + rnd.Intn(6)
+		me := rnd.Intn(n)
+		x0 := make([]float64, n)
+		lower := make([]float64, n)
+		upper := make([]float64, n)
+		for j := range x0 {
+			x0[j] = rnd.NormFloat64()
+			lower[j] = math.Inf(-1)
+			if rnd.Intn(2) == 0 {
+				lower[j] = x0[j] - 1 - rnd.Float64()
+			}
+			upper[j] = x0[j] + 1 + rnd.Float64()
+		}
+		p := Problem{C: make([]float64, n), Lower: lower, Upper: upper}
+		for j := range p.C {
+			p.C[j] = rnd.NormFloat64()
+		}
+		a := mat64.NewDense(mi, n, nil)
+		p.B = make([]float64, mi)
+		for i := 0; i < mi; i++ {
+			for j := 0; j < n; j++ {
+				a.Set(i, j, rnd.NormFloat64())
+			}
+			p.B[i] = floats.Dot(a.Row(nil, i), x0) + rnd.Float64()
+		}
+		p.A = a
+		if me > 0 {
+			aeq := mat64.NewDense(me, n, nil)
+			p.Beq = make([]float64, me)
+			for i := 0; i < me; i++ {
+				for j := 0; j < n; j++ {
+					aeq.Set(i, j, rnd.NormFloat64())
+				}
+				p.Beq[i] = floats.Dot(aeq.Row(nil, i), x0)
+			}
+			p.Aeq = aeq
+		}
+
+		res, err := SolveResult(p, 0)
+		if err == ErrUnbounded {
+			// The variables without a lower bound may be unbounded.
+			continue
+		}
+		if err != nil {
+			t.Errorf("trial %d: unexpected error: %v", trial, err)
+			continue
+		}
+		// Check the multipliers.
+		rc := make([]float64, n)
+		copy(rc, p.C)
+		for i, v := range res.Ineq {
+			if v < -1e-10 {
+				t.Errorf("trial %d: negative multiplier of inequality %d", trial, i)
+			}
+			floats.AddScaled(rc, v, a.Row(nil, i))
+		}
+		for i, v := range res.Eq {
+			floats.AddScaled(rc, v, p.Aeq.(*mat64.Dense).Row(nil, i))
+		}
+		if !floats.EqualApprox(rc, res.ReducedCost, 1e-10) {
+			t.Errorf("trial %d: reduced costs mismatch, want %v, got %v", trial, rc, res.ReducedCost)
+		}
+
+		// Within the ranges, the optimal value changes linearly with the
+		// coefficients.
+		perturb := func(v, lo, hi float64) (down, up float64) {
+			down, up = v-1, v+1
+			if !math.IsInf(lo, -1) {
+				down = v - 0.5*(v-lo)
+			}
+			if !math.IsInf(hi, 1) {
+				up = v + 0.5*(hi-v)
+			}
+			return down, up
+		}
+		check := func(name string, k int, pp Problem, delta, wantDeriv float64) {
+			r, err := SolveResult(pp, 0)
+			if err != nil {
+				t.Errorf("trial %d: %s[%d]: unexpected error: %v", trial, name, k, err)
+				return
+			}
+			want := res.F + delta*wantDeriv
+			if math.Abs(r.F-want) > 1e-8*math.Max(1, math.Abs(want)) {
+				t.Errorf("trial %d: %s[%d]: unexpected optimal value within the range, want %v, got %v",
+					trial, name, k, want, r.F)
+			}
+		}
+		for j := range p.C {
+			down, up := perturb(p.C[j], res.CostLow[j], res.CostHigh[j])
+			for _, v := range []float64{down, up} {
+				pp := p
+				pp.C = make([]float64, n)
+				copy(pp.C, p.C)
+				pp.C[j] = v
+				check("C", j, pp, v-p.C[j], res.X[j])
+			}
+		}
+		for i := range p.B {
+			down, up := perturb(p.B[i], res.BLow[i], res.BHigh[i])
+			for _, v := range []float64{down, up} {
+				pp := p
+				pp.B = make([]float64, mi)
+				copy(pp.B, p.B)
+				pp.B[i] = v
+				check("B", i, pp, v-p.B[i], -res.Ineq[i])
+			}
+		}
+		for i := range p.Beq {
+			down, up := perturb(p.Beq[i], res.BeqLow[i], res.BeqHigh[i])
+			for _, v := range []float64{down, up} {
+				pp := p
+				pp.Beq = make([]float64, me)
+				copy(pp.Beq, p.Beq)
+				pp.Beq[i] = v
+				check("Beq", i, pp, v-p.Beq[i], -res.Eq[i])
+			}
+		}
+	}
+}
+
+// equalApprox returns whether the elements of a and b are equal within tol,
+// or are infinities of the same sign.
+func equalApprox(a, b []float64, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] && !(math.Abs(v-b[i]) <= tol) {
+			return false
+		}
+	}
+	return true
+}
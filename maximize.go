@@ -0,0 +1,139 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// negatedProblem returns the problem of minimizing the negated objective
+// function of p. The constraints and the bounds of p are not changed.
+func negatedProblem(p Problem) Problem {
+	f := p.Func
+	p.Func = func(x []float64) float64 {
+		return -f(x)
+	}
+	if grad := p.Grad; grad != nil {
+		p.Grad = func(x, g []float64) {
+			grad(x, g)
+			negate(g)
+		}
+	}
+	if fg := p.FuncGrad; fg != nil {
+		p.FuncGrad = func(x, g []float64) float64 {
+			v := fg(x, g)
+			negate(g)
+			return -v
+		}
+	}
+	if hess := p.Hess; hess != nil {
+		p.Hess = func(x []float64, h *mat64.SymDense) {
+			hess(x, h)
+			negateSym(h)
+		}
+	}
+	if hessVec := p.HessVec; hessVec != nil {
+		p.HessVec = func(x, v, hv []float64) {
+			hessVec(x, v, hv)
+			negate(hv)
+		}
+	}
+	return p
+}
+
+// negatedSettings returns a copy of settings for the minimization of the
+// negated objective function.
+func negatedSettings(settings *Settings) *Settings {
+	s := *settings
+	if s.UseInitialData {
+		s.InitialValue = -s.InitialValue
+		if s.InitialGradient != nil {
+			s.InitialGradient = make([]float64, len(settings.InitialGradient))
+			for i, g := range settings.InitialGradient {
+				s.InitialGradient[i] = -g
+			}
+		}
+		if s.InitialHessian != nil {
+			s.InitialHessian = mat64.NewSymDense(settings.InitialHessian.Symmetric(), nil)
+			s.InitialHessian.CopySym(settings.InitialHessian)
+			negateSym(s.InitialHessian)
+		}
+	}
+	// Infinite thresholds have no effect in both directions.
+	if !math.IsInf(s.FunctionThreshold, 0) {
+		s.FunctionThreshold = -s.FunctionThreshold
+	} else {
+		s.FunctionThreshold = math.Inf(-1)
+	}
+	if s.Recorder != nil {
+		s.Recorder = &negatedRecorder{Recorder: s.Recorder}
+	}
+	if s.Callback != nil {
+		callback := s.Callback
+		loc := &Location{}
+		s.Callback = func(iter int, negated *Location) (Status, error) {
+			copyLocation(loc, negated)
+			if negated.Hessian == nil {
+				loc.Hessian = nil
+			}
+			negateLocation(loc)
+			status, err := callback(iter, loc)
+			// Pass a modified location back.
+			copy(negated.X, loc.X)
+			return status, err
+		}
+	}
+	return &s
+}
+
+// negatedRecorder passes the locations of the negated problem to Recorder
+// with the values of the original objective function.
+type negatedRecorder struct {
+	Recorder
+	loc Location
+}
+
+func (r *negatedRecorder) Record(loc *Location, e EvaluationType, iter IterationType, stats *Stats) error {
+	copyLocation(&r.loc, loc)
+	if loc.Hessian == nil {
+		r.loc.Hessian = nil
+	}
+	negateLocation(&r.loc)
+	return r.Recorder.Record(&r.loc, e, iter, stats)
+}
+
+// negateLocation negates the function value, the gradient and the Hessian
+// stored in loc in-place.
+func negateLocation(loc *Location) {
+	loc.F = -loc.F
+	negate(loc.Gradient)
+	if loc.Hessian != nil {
+		negateSym(loc.Hessian)
+	}
+}
+
+// negateDiagnostics transforms the Diagnostics of the negated problem to the
+// original objective function. SecondOrder then states the second-order
+// necessary conditions for a maximum.
+func negateDiagnostics(diag *Diagnostics) {
+	diag.MinEigenvalue, diag.MaxEigenvalue = -diag.MaxEigenvalue, -diag.MinEigenvalue
+}
+
+func negate(x []float64) {
+	for i, v := range x {
+		x[i] = -v
+	}
+}
+
+func negateSym(a *mat64.SymDense) {
+	n := a.Symmetric()
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			a.SetSym(i, j, -a.At(i, j))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+// maxRecorder records the largest function value and the final location it
+// receives.
+type maxRecorder struct {
+	max  float64
+	last Location
+}
+
+func (r *maxRecorder) Init() error {
+	r.max = math.Inf(-1)
+	return nil
+}
+
+func (r *maxRecorder) Record(loc *Location, _ EvaluationType, iter IterationType, _ *Stats) error {
+	if iter != PostIteration {
+		r.max = math.Max(r.max, loc.F)
+	}
+	copyLocation(&r.last, loc)
+	return nil
+}
+
+func TestMaximize(t *testing.T) {
+	// The function 3 - Rosenbrock has its maximum 3 at (1, 1).
+	rosen := functions.ExtendedRosenbrock{}
+	p := Problem{
+		Func: func(x []float64) float64 {
+			return 3 - rosen.Func(x)
+		},
+		Grad: func(x, grad []float64) {
+			rosen.Grad(x, grad)
+			floats.Scale(-1, grad)
+		},
+		Hess: func(x []float64, hess *mat64.SymDense) {
+			hess.SetSym(0, 0, -(1200*x[0]*x[0] - 400*x[1] + 2))
+			hess.SetSym(0, 1, 400*x[0])
+			hess.SetSym(1, 1, -200)
+		},
+	}
+	for _, method := range []Method{&BFGS{}, &Newton{}, &NelderMead{}} {
+		// Minimizing the negated function must give the same iterates.
+		neg := Problem{
+			Func: func(x []float64) float64 {
+				return -p.Func(x)
+			},
+			Grad: func(x, grad []float64) {
+				p.Grad(x, grad)
+				floats.Scale(-1, grad)
+			},
+			Hess: func(x []float64, hess *mat64.SymDense) {
+				p.Hess(x, hess)
+				for i := 0; i < 2; i++ {
+					for j := i; j < 2; j++ {
+						hess.SetSym(i, j, -hess.At(i, j))
+					}
+				}
+			},
+		}
+		x := []float64{-1.2, 1}
+		want, err := Local(neg, x, nil, method)
+		if err != nil {
+			t.Fatalf("%T: unexpected error: %v", method, err)
+		}
+
+		settings := DefaultSettings()
+		settings.Maximize = true
+		rec := &maxRecorder{}
+		settings.Recorder = rec
+		var calls int
+		settings.Callback = func(_ int, loc *Location) (Status, error) {
+			calls++
+			if loc.F > 3 || loc.F != p.Func(loc.X) {
+				t.Errorf("%T: unexpected function value %v in Callback", method, loc.F)
+			}
+			return NotTerminated, nil
+		}
+		got, err := Local(p, x, settings, method)
+		if err != nil {
+			t.Fatalf("%T: unexpected error: %v", method, err)
+		}
+		if !floats.Equal(got.X, want.X) || got.F != -want.F || got.Status != want.Status {
+			t.Errorf("%T: maximization differs from minimization of the negated function", method)
+		}
+		if !floats.EqualApprox(got.X, []float64{1, 1}, 1e-3) {
+			t.Errorf("%T: unexpected maximum location %v", method, got.X)
+		}
+		if got.Gradient != nil {
+			g := make([]float64, 2)
+			p.Grad(got.X, g)
+			if !floats.Equal(got.Gradient, g) {
+				t.Errorf("%T: unexpected gradient %v", method, got.Gradient)
+			}
+		}
+		if calls == 0 {
+			t.Errorf("%T: Callback not called", method)
+		}
+		if rec.max != got.F || rec.last.F != got.F {
+			t.Errorf("%T: Recorder received negated values", method)
+		}
+	}
+
+	// The run stops as soon as the function value exceeds FunctionThreshold.
+	settings := DefaultSettings()
+	settings.Maximize = true
+	settings.FunctionThreshold = 2.5
+	result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != FunctionThreshold || result.F <= 2.5 {
+		t.Errorf("unexpected result with FunctionThreshold: %v, %v", result.Status, result.F)
+	}
+
+	// Diagnostics refer to the original function.
+	settings = DefaultSettings()
+	settings.Maximize = true
+	settings.Diagnostics = true
+	result, err = Local(p, []float64{-1.2, 1}, settings, &Newton{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := result.Diagnostics; d.MaxEigenvalue >= 0 || d.MinEigenvalue > d.MaxEigenvalue || !d.SecondOrder {
+		t.Errorf("unexpected diagnostics %+v", d)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultMonitorHistory = 100
+
+// Monitor is a Recorder that keeps the current state of a running
+// optimization and serves it over HTTP, so that long runs can be inspected
+// without instrumenting the objective function. Monitor implements
+// http.Handler, and every request is answered with the MonitorState in JSON,
+// for example
+//  settings.Recorder = monitor
+//  http.Handle("/debug/optimize", monitor)
+//  go http.ListenAndServe("localhost:6060", nil)
+//
+// Monitor records the initial location, every major iteration and the
+// optimal location after the optimization. It can be read concurrently with
+// the optimization, but it must not be used by several runs at the same
+// time. If Recorder is not nil, Monitor passes all records to it as well.
+type Monitor struct {
+	// History is the number of the most recent records that are kept.
+	// If History is zero, it will be set to 100.
+	History int
+	// Location specifies whether the records in the history hold the
+	// locations.
+	Location bool
+	// Maximize specifies whether the best function value is the largest
+	// one, and should be equal to Settings.Maximize.
+	Maximize bool
+	// Recorder, if not nil, receives all records.
+	Recorder Recorder
+
+	mu      sync.Mutex
+	start   time.Time
+	end     time.Time
+	state   MonitorState
+	history []JSONRecord // Ring buffer of the most recent records.
+	next    int          // Index of the next record in history.
+}
+
+// MonitorState is the state of an optimization run kept by Monitor.
+type MonitorState struct {
+	// Running is true from the initialization of the run until the optimal
+	// location has been recorded.
+	Running bool
+	// Elapsed is the wall-clock time of the run in seconds, up to now if
+	// the run is still running.
+	Elapsed float64
+	// Current is the most recent record. Its X is the location.
+	Current *JSONRecord `json:",omitempty"`
+	// BestF and BestX are the best function value and its location among
+	// the records. BestF is nil if there is no finite function value.
+	BestF *float64  `json:",omitempty"`
+	BestX []float64 `json:",omitempty"`
+	// History holds the most recent records, the oldest first.
+	History []JSONRecord
+}
+
+func (m *Monitor) Init() error {
+	m.mu.Lock()
+	if m.History == 0 {
+		m.History = defaultMonitorHistory
+	}
+	if m.History < 0 {
+		m.mu.Unlock()
+		panic("monitor: History is negative")
+	}
+	m.start = time.Now()
+	m.end = time.Time{}
+	m.state = MonitorState{Running: true}
+	m.history = make([]JSONRecord, 0, m.History)
+	m.next = 0
+	m.mu.Unlock()
+
+	if m.Recorder != nil {
+		return m.Recorder.Init()
+	}
+	return nil
+}
+
+func (m *Monitor) Record(loc *Location, e EvaluationType, iter IterationType, stats *Stats) error {
+	if iter == MajorIteration || iter == InitIteration || iter == PostIteration {
+		m.record(loc, iter, stats)
+	}
+	if m.Recorder != nil {
+		return m.Recorder.Record(loc, e, iter, stats)
+	}
+	return nil
+}
+
+func (m *Monitor) record(loc *Location, iter IterationType, stats *Stats) {
+	rec := newJSONRecord(loc, iter, stats)
+	cur := rec
+	cur.X = make([]float64, len(loc.X))
+	copy(cur.X, loc.X)
+	if m.Location {
+		rec.X = cur.X
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state.Current = &cur
+	if rec.F != nil && m.better(*rec.F) {
+		m.state.BestF = rec.F
+		m.state.BestX = cur.X
+	}
+	if len(m.history) < m.History {
+		m.history = append(m.history, rec)
+	} else {
+		m.history[m.next] = rec
+	}
+	m.next = (m.next + 1) % m.History
+	if iter == PostIteration {
+		m.state.Running = false
+		m.end = time.Now()
+	}
+}
+
+// better returns whether f is better than the best function value.
+func (m *Monitor) better(f float64) bool {
+	best := m.state.BestF
+	if best == nil {
+		return true
+	}
+	if m.Maximize {
+		return f > *best
+	}
+	return f < *best
+}
+
+// State returns a copy of the current state.
+func (m *Monitor) State() MonitorState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.state
+	switch {
+	case m.start.IsZero():
+	case m.end.IsZero():
+		s.Elapsed = time.Since(m.start).Seconds()
+	default:
+		s.Elapsed = m.end.Sub(m.start).Seconds()
+	}
+	// The records are not modified after they have been stored, so only the
+	// history needs to be copied.
+	s.History = make([]JSONRecord, 0, len(m.history))
+	if len(m.history) == m.History {
+		s.History = append(s.History, m.history[m.next:]...)
+		s.History = append(s.History, m.history[:m.next]...)
+	} else {
+		s.History = append(s.History, m.history...)
+	}
+	return s
+}
+
+// ServeHTTP writes the current state in JSON to w.
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s := m.State()
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
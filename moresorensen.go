@@ -0,0 +1,250 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultMoreSorensenTolerance     = 1e-6
+	defaultMoreSorensenHardTolerance = 1e-6
+	defaultMoreSorensenIterations    = 100
+
+	// Fraction of the safeguarding interval [λ_L, λ_U] by which λ is
+	// increased when the Newton iterate falls outside of it.
+	moreSorensenSafeguard = 1e-3
+	// Relative amount by which the initial upper bound on λ is increased.
+	moreSorensenUpperInflation = 1e-8
+	// Number of inverse iterations used to estimate the eigenvector
+	// corresponding to the smallest eigenvalue of (B + λI) in the hard case.
+	moreSorensenInverseIterations = 3
+)
+
+// MoreSorensen is a SubproblemSolver that solves the trust-region subproblem
+// to high accuracy using the algorithm of Moré and Sorensen. It finds a scalar
+// λ >= 0 and a step p such that
+//  (B + λI) p = -∇f,
+//  λ (Δ - |p|_2) = 0,
+//  (B + λI) is positive semidefinite,
+// by applying a safeguarded Newton's method to the secular equation
+// 1/|p(λ)|_2 - 1/Δ = 0. Each iteration requires a Cholesky factorization of
+// (B + λI), so MoreSorensen is suited for small and medium-sized problems with
+// dense Hessians. Unlike dogleg-type methods, MoreSorensen can handle indefinite
+// B, including the so-called hard case when ∇f is orthogonal to the
+// eigenspace of the smallest eigenvalue of B.
+//
+// References:
+//  - Moré, J.J., Sorensen, D.C.: Computing a trust region step. SIAM J Sci
+//    Stat Comput 4 (1983), 553-572
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Section 4.3
+type MoreSorensen struct {
+	// Tolerance is the relative accuracy with which a step on the boundary
+	// of the trust region is found, that is, the iteration terminates when
+	//  (1 - Tolerance) Δ <= |p|_2 <= (1 + Tolerance) Δ.
+	// If Tolerance is zero, it will be set to 1e-6.
+	Tolerance float64
+	// HardCaseTolerance controls the accuracy of the solution in the hard
+	// case. Smaller values lead to more accurate solutions at the cost of
+	// more iterations.
+	// If HardCaseTolerance is zero, it will be set to 1e-6.
+	HardCaseTolerance float64
+	// MaxIterations is the maximum number of Cholesky factorizations
+	// performed. If the iteration does not converge, the best feasible step
+	// found so far is returned.
+	// If MaxIterations is zero, it will be set to 100.
+	MaxIterations int
+
+	hess *mat64.SymDense // Storage for (B + λI).
+	chol *mat64.TriDense // Storage for the Cholesky factorization of (B + λI).
+
+	q   []float64
+	z   []float64
+	tmp []float64
+}
+
+func (ms *MoreSorensen) Solve(p, grad []float64, hess *mat64.SymDense, radius float64) float64 {
+	dim := len(p)
+	if len(grad) != dim || hess.Symmetric() != dim {
+		panic("moresorensen: size mismatch")
+	}
+	if radius <= 0 {
+		panic("moresorensen: radius not positive")
+	}
+	if ms.Tolerance == 0 {
+		ms.Tolerance = defaultMoreSorensenTolerance
+	}
+	if ms.HardCaseTolerance == 0 {
+		ms.HardCaseTolerance = defaultMoreSorensenHardTolerance
+	}
+	if ms.MaxIterations == 0 {
+		ms.MaxIterations = defaultMoreSorensenIterations
+	}
+	ms.hess = resizeSymDense(ms.hess, dim)
+	ms.chol = resizeTriDense(ms.chol, dim)
+	ms.q = resize(ms.q, dim)
+	ms.z = resize(ms.z, dim)
+	ms.tmp = resize(ms.tmp, dim)
+
+	// Compute the initial safeguards for λ. λ_S is a lower bound on the
+	// negative of the smallest eigenvalue of B, and |B|_1 bounds its
+	// eigenvalues in magnitude.
+	gNorm := floats.Norm(grad, 2)
+	var bNorm float64
+	lambdaS := math.Inf(-1)
+	for i := 0; i < dim; i++ {
+		lambdaS = math.Max(lambdaS, -hess.At(i, i))
+		var sum float64
+		for j := 0; j < dim; j++ {
+			sum += math.Abs(hess.At(i, j))
+		}
+		bNorm = math.Max(bNorm, sum)
+	}
+	lambdaL := math.Max(0, math.Max(lambdaS, gNorm/radius-bNorm))
+	lambdaU := math.Max(0, gNorm/radius+bNorm)
+	// Inflate λ_U slightly so that (B + λ_U I) is positive definite even
+	// when the bounds coincide, for example when ∇f is zero.
+	lambdaU += math.Max(1, lambdaU) * moreSorensenUpperInflation
+
+	// If λ_L is zero, the first iteration attempts the unmodified Newton step.
+	lambda := lambdaL
+
+	// found indicates that p holds a feasible step.
+	found := false
+	for iter := 0; iter < ms.MaxIterations; iter++ {
+		if !ms.factorize(hess, lambda) {
+			// (B + λI) is not positive definite, so λ is a lower bound.
+			lambdaL = math.Max(lambdaL, lambda)
+			lambda = ms.safeguard(lambdaL, lambdaU)
+			continue
+		}
+		ms.solve(p, grad)
+		pNorm := floats.Norm(p, 2)
+
+		if pNorm <= radius {
+			found = true
+			if lambda == 0 || pNorm >= (1-ms.Tolerance)*radius {
+				// Either the interior solution or a solution on the boundary
+				// has been found.
+				break
+			}
+			lambdaU = math.Min(lambdaU, lambda)
+
+			// The step lies inside the trust region, so check for the hard
+			// case. Find a unit vector z such that |Rz|_2 is small and move
+			// along it to the boundary.
+			rz := ms.nullVector()
+			tau1, tau2 := boundaryStep(p, ms.z, radius)
+			tau := tau1
+			if math.Abs(tau2) < math.Abs(tau1) {
+				tau = tau2
+			}
+			// |R p|^2 = pᵀ(B + λI)p.
+			rp := ms.rNorm(p)
+			if tau*tau*rz*rz <= ms.HardCaseTolerance*(rp*rp+lambda*radius*radius) {
+				floats.AddScaled(p, tau, ms.z)
+				break
+			}
+			lambdaL = math.Max(lambdaL, lambda-rz*rz)
+		} else {
+			lambdaL = math.Max(lambdaL, lambda)
+			if pNorm <= (1+ms.Tolerance)*radius {
+				floats.Scale(radius/pNorm, p)
+				found = true
+				break
+			}
+		}
+
+		// Take a Newton step for the secular equation. Solve Rᵀq = p.
+		ms.solveTrans(ms.q, p)
+		qNorm := floats.Norm(ms.q, 2)
+		lambda += (pNorm / qNorm) * (pNorm / qNorm) * (pNorm - radius) / radius
+		if lambda <= lambdaL || lambda >= lambdaU {
+			lambda = ms.safeguard(lambdaL, lambdaU)
+		}
+	}
+	if !found {
+		// The iteration did not converge. Fall back to the steepest descent
+		// direction truncated to the trust region.
+		copy(p, grad)
+		if gNorm != 0 {
+			floats.Scale(-radius/gNorm, p)
+		}
+	}
+	return quadraticModel(p, grad, hess, ms.tmp)
+}
+
+// safeguard returns a value of λ inside the safeguarding interval.
+func (ms *MoreSorensen) safeguard(lambdaL, lambdaU float64) float64 {
+	return math.Max(math.Sqrt(lambdaL*lambdaU), lambdaL+moreSorensenSafeguard*(lambdaU-lambdaL))
+}
+
+// factorize computes the Cholesky factorization of (B + λI) and reports
+// whether the matrix is positive definite.
+func (ms *MoreSorensen) factorize(hess *mat64.SymDense, lambda float64) bool {
+	ms.hess.CopySym(hess)
+	for i := 0; i < hess.Symmetric(); i++ {
+		ms.hess.SetSym(i, i, hess.At(i, i)+lambda)
+	}
+	return ms.chol.Cholesky(ms.hess, true)
+}
+
+// solve solves (B + λI) p = -g using the computed factorization.
+func (ms *MoreSorensen) solve(p, grad []float64) {
+	dim := len(p)
+	pVec := mat64.NewVector(dim, p)
+	pVec.SolveCholeskyVec(ms.chol, mat64.NewVector(dim, grad))
+	floats.Scale(-1, p)
+}
+
+// solveTrans solves Rᵀx = b for x, where R is the upper triangular Cholesky
+// factor of (B + λI).
+func (ms *MoreSorensen) solveTrans(x, b []float64) {
+	for i := range x {
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= ms.chol.At(k, i) * x[k]
+		}
+		x[i] = sum / ms.chol.At(i, i)
+	}
+}
+
+// rNorm returns |Rx|_2.
+func (ms *MoreSorensen) rNorm(x []float64) float64 {
+	dim := len(x)
+	var norm float64
+	for i := 0; i < dim; i++ {
+		var sum float64
+		for j := i; j < dim; j++ {
+			sum += ms.chol.At(i, j) * x[j]
+		}
+		norm = math.Hypot(norm, sum)
+	}
+	return norm
+}
+
+// nullVector estimates the eigenvector of (B + λI) corresponding to its
+// smallest eigenvalue by inverse iteration, stores it into ms.z and returns
+// |R z|_2.
+func (ms *MoreSorensen) nullVector() float64 {
+	dim := len(ms.z)
+	// Start from a vector that is unlikely to be orthogonal to the sought
+	// eigenvector.
+	for i := range ms.z {
+		ms.z[i] = 1 + float64(i)/float64(dim)
+	}
+	floats.Scale(1/floats.Norm(ms.z, 2), ms.z)
+	zVec := mat64.NewVector(dim, ms.z)
+	for k := 0; k < moreSorensenInverseIterations; k++ {
+		copy(ms.tmp, ms.z)
+		zVec.SolveCholeskyVec(ms.chol, mat64.NewVector(dim, ms.tmp))
+		floats.Scale(1/floats.Norm(ms.z, 2), ms.z)
+	}
+	return ms.rNorm(ms.z)
+}
@@ -0,0 +1,269 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultMultiStartSamples = 10
+	defaultMultiStartDistTol = 1e-4
+	defaultMultiStartFuncTol = 1e-6
+)
+
+// MultiStart is a global optimization driver that runs a local Method from
+// many starting points and collects the distinct local minima found. The
+// starting points are the user-supplied Starts followed by Samples points
+// generated by Sampler within Region.
+//
+// The local runs are independent calls to Local with a copy of the
+// settings, so Settings.FunctionConverge is not shared between the runs.
+// If Concurrent is larger than one, the runs are executed concurrently and
+// the functions of the Problem, Settings.Recorder and Settings.Callback
+// must be safe for concurrent use. Every run has its own Method, which is
+// returned by Method or cloned from Prototype, see Cloner.
+type MultiStart struct {
+	// Method returns the Method for a local run. It is called once for
+	// every starting point. If Method is nil, the default Method of Local is
+	// used.
+	Method func() Method
+	// Prototype is a configured Method that is cloned for every starting
+	// point if Method is nil. Prototype itself is not run. Prototype must
+	// implement Cloner if Concurrent is larger than one, otherwise it is
+	// reused for the sequential runs. MultiStart panics if both Method and
+	// Prototype are not nil.
+	Prototype Method
+	// Starts are user-supplied starting points.
+	Starts [][]float64
+	// Samples is the number of starting points generated by Sampler. If
+	// Samples and the length of Starts are zero, Samples will be set to 10.
+	Samples int
+	// Sampler generates the starting points. If Sampler is nil, it will be
+	// set to LatinHypercube{}, with a source seeded from Settings.Src if
+	// Settings.Src is not nil.
+	Sampler Sampler
+	// Region is the box within which the starting points are generated. If
+	// Region is nil, the bounds of the Problem are used. All bounds of the
+	// region must be finite if Samples is not zero.
+	Region *Bounds
+	// Concurrent is the number of local runs executed concurrently. If
+	// Concurrent is zero, it will be set to 1.
+	Concurrent int
+	// DistTolerance and FuncTolerance determine when two local minima are
+	// the same. This is the case if the infinity norm of the difference of
+	// their locations is at most DistTolerance and the difference of their
+	// function values is at most FuncTolerance. If DistTolerance is zero,
+	// it will be set to 1e-4, and if FuncTolerance is zero, it will be set
+	// to 1e-6.
+	DistTolerance float64
+	FuncTolerance float64
+}
+
+// MultiStartResult is the result of MultiStart.
+type MultiStartResult struct {
+	// Minima are the distinct local minima in increasing order of their
+	// function values. Every minimum is the result of the local run with
+	// the lowest function value that converged to it. The Status of every
+	// result tells whether the local run has converged or stopped early.
+	Minima []*Result
+	// Stats are the statistics summed over all local runs, except Runtime,
+	// which is the total wall-clock time.
+	Stats
+	// Runs is the number of local runs, and Failed is the number of local
+	// runs that have returned an error.
+	Runs   int
+	Failed int
+}
+
+// Minimize runs the local optimizations for the problem p of dimension dim.
+// If settings is nil, the default settings are used. Minimize returns an
+// error only if all local runs fail, in which case it returns the error of
+// the first run. Minimize panics if settings.Maximize is true.
+func (ms *MultiStart) Minimize(p Problem, dim int, settings *Settings) (*MultiStartResult, error) {
+	for _, x := range ms.Starts {
+		if len(x) != dim {
+			panic("optimize: starting point size mismatch")
+		}
+	}
+	samples := ms.Samples
+	if samples == 0 && len(ms.Starts) == 0 {
+		samples = defaultMultiStartSamples
+	}
+	if samples < 0 {
+		panic("optimize: negative number of samples")
+	}
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	sampler := ms.Sampler
+	if sampler == nil {
+		var src rand.Source
+		if settings.Src != nil {
+			src = rand.NewSource(settings.Src.Int63())
+		}
+		sampler = LatinHypercube{Src: src}
+	}
+	region := ms.Region
+	if region == nil {
+		region = p.Bounds
+	}
+	concurrent := ms.Concurrent
+	if concurrent == 0 {
+		concurrent = 1
+	}
+	if concurrent < 0 {
+		panic("optimize: negative concurrency")
+	}
+	if ms.Method != nil && ms.Prototype != nil {
+		panic("optimize: both Method and Prototype are set")
+	}
+	newMethod := ms.Method
+	if ms.Prototype != nil {
+		if c, ok := ms.Prototype.(Cloner); ok {
+			newMethod = c.Clone
+		} else if concurrent > 1 {
+			panic("optimize: concurrent runs with a Prototype that is not a Cloner")
+		} else {
+			newMethod = func() Method { return ms.Prototype }
+		}
+	}
+	distTol := ms.DistTolerance
+	if distTol == 0 {
+		distTol = defaultMultiStartDistTol
+	}
+	funcTol := ms.FuncTolerance
+	if funcTol == 0 {
+		funcTol = defaultMultiStartFuncTol
+	}
+	if distTol < 0 || funcTol < 0 {
+		panic("optimize: negative tolerance")
+	}
+	if settings.Maximize {
+		panic("optimize: maximization is not supported")
+	}
+
+	starts := make([][]float64, len(ms.Starts), len(ms.Starts)+samples)
+	copy(starts, ms.Starts)
+	if samples > 0 {
+		sampled := make([][]float64, samples)
+		for i := range sampled {
+			sampled[i] = make([]float64, dim)
+		}
+		sampler.Sample(sampled, region)
+		starts = append(starts, sampled...)
+	}
+
+	// The seeds of the runs are drawn before the runs, so that every run has
+	// its own source independently of the order of the concurrent runs.
+	var seeds []int64
+	if settings.Src != nil {
+		seeds = make([]int64, len(starts))
+		for i := range seeds {
+			seeds[i] = settings.Src.Int63()
+		}
+	}
+
+	startTime := time.Now()
+	results := make([]*Result, len(starts))
+	errs := make([]error, len(starts))
+	run := func(i int) {
+		s := *settings
+		if s.FunctionConverge != nil {
+			fc := *s.FunctionConverge
+			s.FunctionConverge = &fc
+		}
+		if seeds != nil {
+			s.Src = rand.NewSource(seeds[i])
+		}
+		var method Method
+		if newMethod != nil {
+			method = newMethod()
+		}
+		results[i], errs[i] = Local(p, starts[i], &s, method)
+	}
+	if concurrent == 1 {
+		for i := range starts {
+			run(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		jobs := make(chan int)
+		for w := 0; w < concurrent; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					run(i)
+				}
+			}()
+		}
+		for i := range starts {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	res := &MultiStartResult{Runs: len(starts)}
+	var firstErr error
+	var found []*Result
+	for i, r := range results {
+		if errs[i] != nil {
+			res.Failed++
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		}
+		if r == nil {
+			continue
+		}
+		res.MajorIterations += r.MajorIterations
+		res.FuncEvaluations += r.FuncEvaluations
+		res.GradEvaluations += r.GradEvaluations
+		res.HessEvaluations += r.HessEvaluations
+		res.HessVecEvaluations += r.HessVecEvaluations
+		if errs[i] == nil {
+			found = append(found, r)
+		}
+	}
+	res.Runtime = time.Since(startTime)
+	if res.Failed == res.Runs && firstErr != nil {
+		return res, firstErr
+	}
+
+	// The stable sort keeps the results with equal function values in the
+	// order of their starting points.
+	sort.Stable(resultsByF(found))
+	for _, r := range found {
+		if !isKnownMinimum(res.Minima, r, distTol, funcTol) {
+			res.Minima = append(res.Minima, r)
+		}
+	}
+	return res, nil
+}
+
+// isKnownMinimum returns whether r is the same minimum as one of minima.
+func isKnownMinimum(minima []*Result, r *Result, distTol, funcTol float64) bool {
+	for _, m := range minima {
+		if math.Abs(r.F-m.F) <= funcTol && floats.Distance(r.X, m.X, math.Inf(1)) <= distTol {
+			return true
+		}
+	}
+	return false
+}
+
+// resultsByF sorts results in increasing order of their function values.
+type resultsByF []*Result
+
+func (r resultsByF) Len() int           { return len(r) }
+func (r resultsByF) Less(i, j int) bool { return r[i].F < r[j].F }
+func (r resultsByF) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
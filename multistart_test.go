@@ -0,0 +1,103 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// himmelblau is the Himmelblau function with four global minima of value
+// zero.
+type himmelblau struct{}
+
+func (himmelblau) Func(x []float64) float64 {
+	a := x[0]*x[0] + x[1] - 11
+	b := x[0] + x[1]*x[1] - 7
+	return a*a + b*b
+}
+
+func (himmelblau) Grad(x, grad []float64) {
+	a := x[0]*x[0] + x[1] - 11
+	b := x[0] + x[1]*x[1] - 7
+	grad[0] = 4*a*x[0] + 2*b
+	grad[1] = 2*a + 4*b*x[1]
+}
+
+func TestMultiStart(t *testing.T) {
+	p := Problem{
+		Func: himmelblau{}.Func,
+		Grad: himmelblau{}.Grad,
+	}
+	region := &Bounds{Lower: []float64{-5, -5}, Upper: []float64{5, 5}}
+	for _, test := range []struct {
+		name string
+		ms   *MultiStart
+	}{
+		{"LatinHypercube", &MultiStart{
+			Samples: 30,
+			Sampler: LatinHypercube{Src: rand.NewSource(1)},
+		}},
+		{"Sobol", &MultiStart{
+			Samples: 31,
+			Sampler: Sobol{},
+		}},
+		{"Concurrent", &MultiStart{
+			Samples:    31,
+			Sampler:    Sobol{},
+			Concurrent: 4,
+		}},
+		{"Starts", &MultiStart{
+			Starts: [][]float64{{4, 4}, {-4, 4}, {-4, -4}, {4, -4}, {3.5, 3.5}},
+			Method: func() Method { return &LBFGS{} },
+		}},
+		{"Prototype", &MultiStart{
+			Samples:    31,
+			Sampler:    Sobol{},
+			Concurrent: 4,
+			Prototype:  &CG{Variant: &HagerZhang{}, InitialStep: &FirstOrderStepSize{}},
+		}},
+	} {
+		test.ms.Region = region
+		result, err := test.ms.Minimize(p, 2, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(result.Minima) != 4 {
+			t.Errorf("%s: found %d minima, want 4", test.name, len(result.Minima))
+			continue
+		}
+		for i, m := range result.Minima {
+			if m.F > 1e-10 {
+				t.Errorf("%s: unexpected function value %v at minimum %d", test.name, m.F, i)
+			}
+			if i > 0 && m.F < result.Minima[i-1].F {
+				t.Errorf("%s: minima not ordered by function value", test.name)
+			}
+		}
+		var evals int
+		for _, m := range result.Minima {
+			evals += m.FuncEvaluations
+		}
+		if result.FuncEvaluations < evals {
+			t.Errorf("%s: total evaluations %d fewer than evaluations of the minima %d", test.name, result.FuncEvaluations, evals)
+		}
+	}
+
+	// The concurrent runs find the same minima.
+	seq, _ := (&MultiStart{Samples: 31, Sampler: Sobol{}, Region: region}).Minimize(p, 2, nil)
+	conc, _ := (&MultiStart{Samples: 31, Sampler: Sobol{}, Region: region, Concurrent: 3}).Minimize(p, 2, nil)
+	for i := range seq.Minima {
+		if !floats.Equal(seq.Minima[i].X, conc.Minima[i].X) {
+			t.Errorf("concurrent minimum %d differs from the sequential one", i)
+		}
+	}
+	if seq.FuncEvaluations != conc.FuncEvaluations {
+		t.Errorf("concurrent evaluations %d differ from sequential %d", conc.FuncEvaluations, seq.FuncEvaluations)
+	}
+}
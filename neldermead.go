@@ -57,6 +57,13 @@ func (n nmVertexSorter) Swap(i, j int) {
 // the recommendations in
 //
 //  http://www.webpages.uidaho.edu/~fuchang/res/ANMS.pdf
+//
+// These adaptive parameters, Reflection = 1, Expansion = 1 + 2/n,
+// Contraction = 0.75 - 1/(2n) and Shrink = 1 - 1/n, where n is the problem
+// dimension, reduce to the standard choice (1, 2, 0.5, 0.5) for n = 2 and
+// perform considerably better in high dimensions. For n = 1, Shrink is set
+// to 0.5. NelderMead panics if a nonzero parameter is outside of its valid
+// range.
 type NelderMead struct {
 	InitialVertices [][]float64
 	InitialValues   []float64
@@ -115,6 +122,21 @@ func (n *NelderMead) Init(loc *Location, p *ProblemInfo, xNext []float64) (Evalu
 	n.shrink = n.Shrink
 	if n.shrink == 0 {
 		n.shrink = 1 - 1/float64(dim)
+		if dim == 1 {
+			n.shrink = 0.5
+		}
+	}
+	if n.reflection <= 0 {
+		panic("neldermead: reflection parameter must be positive")
+	}
+	if n.expansion <= 1 {
+		panic("neldermead: expansion parameter must be greater than 1")
+	}
+	if n.contraction <= 0 || n.contraction >= 1 {
+		panic("neldermead: contraction parameter must be between 0 and 1")
+	}
+	if n.shrink <= 0 || n.shrink >= 1 {
+		panic("neldermead: shrink parameter must be between 0 and 1")
 	}
 
 	if n.InitialVertices != nil {
@@ -261,7 +283,7 @@ func (n *NelderMead) returnNext(iter nmIterType, xNext []float64) (EvaluationTyp
 		}
 		return FuncEvaluation, MinorIteration, nil
 	case nmShrink:
-		// x_shrink = x_best + delta * (x_i + x_best)
+		// x_shrink = x_best + delta * (x_i - x_best)
 		floats.SubTo(xNext, n.vertices[n.fillIdx], n.vertices[0])
 		floats.Scale(n.shrink, xNext)
 		floats.Add(xNext, n.vertices[0])
@@ -296,6 +318,21 @@ func (n *NelderMead) replaceWorst(x []float64, f float64) {
 	floats.AddScaled(n.centroid, 1/float64(dim), x)
 }
 
+// Clone returns a new NelderMead with the configuration of n. InitialVertices
+// and InitialValues are shared and must not be modified while the clones are
+// running.
+func (n *NelderMead) Clone() Method {
+	return &NelderMead{
+		InitialVertices: n.InitialVertices,
+		InitialValues:   n.InitialValues,
+		Reflection:      n.Reflection,
+		Expansion:       n.Expansion,
+		Contraction:     n.Contraction,
+		Shrink:          n.Shrink,
+		SimplexSize:     n.SimplexSize,
+	}
+}
+
 func (*NelderMead) Needs() struct {
 	Gradient bool
 	Hessian  bool
@@ -30,10 +30,15 @@ const maxNewtonModifications = 20
 // adds successively larger multiples of identity to H_k until it becomes
 // positive definite. Note that the repeated trial factorization of the
 // modified Hessian involved in this process can be computationally expensive.
+// If ModifiedCholesky is true, the Hessian is instead modified by the modified
+// Cholesky factorization of Gill and Murray, which needs a single
+// factorization per iteration.
 //
-// If the Hessian matrix cannot be formed explicitly or if the computational
-// cost of its factorization is prohibitive, BFGS or L-BFGS quasi-Newton method
-// can be used instead.
+// For large problems with a sparse Hessian, HessianSparsity enables a sparse
+// Cholesky factorization whose cost depends on the structure of the Hessian
+// rather than on the cube of the dimension. If the Hessian matrix cannot be
+// formed explicitly or if the computational cost of its factorization is
+// prohibitive, BFGS or L-BFGS quasi-Newton method can be used instead.
 type Newton struct {
 	// LinesearchMethod is a method used for selecting suitable steps along the
 	// descent direction d. Steps should satisfy at least one of the Wolfe,
@@ -46,12 +51,33 @@ type Newton struct {
 	// information in H.
 	// Increase must be greater than 1. If Increase is 0, it is defaulted to 5.
 	Increase float64
+	// InitialTau is the first multiple of identity that is tried if the
+	// Hessian at the initial location needs to be modified, for example the
+	// value of Tau after a run on a related problem. If InitialTau is zero,
+	// it is guessed from the diagonal of the Hessian. InitialTau must not be
+	// negative.
+	InitialTau float64
+	// HessianSparsity is the sparsity pattern of the Hessian, where only one
+	// of the (i,j) and (j,i) elements needs to be present. If it is not nil,
+	// the modified Hessian is factorized by a sparse Cholesky factorization
+	// after a reordering of the variables that reduces the fill-in, and only
+	// the elements of the Hessian in the pattern and on the diagonal are
+	// used.
+	HessianSparsity *SparsityPattern
+	// ModifiedCholesky specifies whether the Hessian is modified by the
+	// modified Cholesky factorization of Gill and Murray, which computes
+	// the factorization of H + E for a non-negative diagonal matrix E in a
+	// single pass, with E = 0 if H is sufficiently positive definite. Tau
+	// is then always zero and InitialTau is ignored. ModifiedCholesky is
+	// not supported together with HessianSparsity.
+	ModifiedCholesky bool
 
 	linesearch *Linesearch
 
-	hess *mat64.SymDense // Storage for a copy of the Hessian matrix.
-	chol *mat64.TriDense // Storage for the Cholesky factorization.
-	tau  float64
+	chol   envelopeCholesky // Dense Cholesky factorization with a full envelope.
+	sparse *sparseCholesky  // Sparse Cholesky factorization if HessianSparsity is set.
+	mchol  modifiedCholesky
+	tau    float64
 }
 
 func (n *Newton) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
@@ -61,6 +87,12 @@ func (n *Newton) Init(loc *Location, p *ProblemInfo, xNext []float64) (Evaluatio
 	if n.Increase <= 1 {
 		panic("optimize: Newton.Increase must be greater than 1")
 	}
+	if n.InitialTau < 0 {
+		panic("optimize: Newton.InitialTau must not be negative")
+	}
+	if n.ModifiedCholesky && n.HessianSparsity != nil {
+		panic("optimize: Newton.ModifiedCholesky is not supported with HessianSparsity")
+	}
 	if n.LinesearchMethod == nil {
 		n.LinesearchMethod = &Bisection{}
 	}
@@ -78,24 +110,39 @@ func (n *Newton) Iterate(loc *Location, xNext []float64) (EvaluationType, Iterat
 }
 
 func (n *Newton) InitDirection(loc *Location, dir []float64) (stepSize float64) {
-	dim := len(loc.X)
-	n.chol = resizeTriDense(n.chol, dim)
-	n.hess = resizeSymDense(n.hess, dim)
-	n.tau = 0
+	n.initStorage(len(loc.X))
+	n.tau = n.InitialTau
 	return n.NextDirection(loc, dir)
 }
 
+// initStorage allocates the storage for the factorization of dim×dim
+// Hessians.
+func (n *Newton) initStorage(dim int) {
+	if n.HessianSparsity != nil {
+		n.sparse = newSparseCholesky(dim, *n.HessianSparsity)
+		return
+	}
+	n.sparse = nil
+	n.chol.setEnvelope(make([]int, dim))
+}
+
 func (n *Newton) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	// This method implements Algorithm 3.3 (Cholesky with Added Multiple of
 	// the Identity) from Nocedal, Wright (2006), 2nd edition.
 
 	dim := len(loc.X)
-	n.hess.CopySym(loc.Hessian)
+	if n.ModifiedCholesky {
+		n.tau = 0
+		n.mchol.factorize(loc.Hessian)
+		n.mchol.solve(dir, loc.Gradient)
+		floats.Scale(-1, dir)
+		return 1
+	}
 
 	// Find the smallest diagonal entry of the Hesssian.
-	minA := n.hess.At(0, 0)
+	minA := loc.Hessian.At(0, 0)
 	for i := 1; i < dim; i++ {
-		a := n.hess.At(i, i)
+		a := loc.Hessian.At(i, i)
 		if a < minA {
 			minA = a
 		}
@@ -112,20 +159,26 @@ func (n *Newton) NextDirection(loc *Location, dir []float64) (stepSize float64)
 	}
 
 	for k := 0; k < maxNewtonModifications; k++ {
-		if n.tau != 0 {
-			// Add a multiple of identity to the Hessian.
-			for i := 0; i < dim; i++ {
-				n.hess.SetSym(i, i, loc.Hessian.At(i, i)+n.tau)
+		// Try to apply the Cholesky factorization to the Hessian with the
+		// multiple of identity added to its diagonal.
+		if n.sparse != nil {
+			if n.sparse.factorize(loc.Hessian, n.tau) {
+				n.sparse.solve(dir, loc.Gradient)
+				floats.Scale(-1, dir)
+				return 1
+			}
+		} else {
+			pd := n.chol.factorizeFunc(func(i, j int) float64 {
+				if i == j {
+					return loc.Hessian.At(i, i) + n.tau
+				}
+				return loc.Hessian.At(i, j)
+			})
+			if pd {
+				n.chol.solve(dir, loc.Gradient)
+				floats.Scale(-1, dir)
+				return 1
 			}
-		}
-		// Try to apply the Cholesky factorization.
-		pd := n.chol.Cholesky(n.hess, true)
-		if pd {
-			d := mat64.NewVector(dim, dir)
-			// Store the solution in d's backing array, dir.
-			d.SolveCholeskyVec(n.chol, mat64.NewVector(dim, loc.Gradient))
-			floats.Scale(-1, dir)
-			return 1
 		}
 		// Modified Hessian is not PD, so increase tau.
 		n.tau = math.Max(n.Increase*n.tau, 0.001)
@@ -138,6 +191,71 @@ func (n *Newton) NextDirection(loc *Location, dir []float64) (stepSize float64)
 	return 1
 }
 
+// Tau returns the multiple of identity that was added to the Hessian in the
+// last iteration, or zero if the Hessian was positive definite.
+func (n *Newton) Tau() float64 {
+	return n.tau
+}
+
+// Checkpoint returns the state of Newton after the last major iteration.
+func (n *Newton) Checkpoint() ([]byte, error) {
+	return encodeState(n.tau)
+}
+
+// Restore restores the state of Newton saved by Checkpoint.
+func (n *Newton) Restore(state []byte, loc *Location, p *ProblemInfo) error {
+	var tau float64
+	if err := decodeState(state, &tau); err != nil {
+		return err
+	}
+	if n.Increase == 0 {
+		n.Increase = 5
+	}
+	if n.Increase <= 1 {
+		panic("optimize: Newton.Increase must be greater than 1")
+	}
+	if n.LinesearchMethod == nil {
+		n.LinesearchMethod = &Bisection{}
+	}
+	if n.linesearch == nil {
+		n.linesearch = &Linesearch{}
+	}
+	n.linesearch.Method = n.LinesearchMethod
+	n.linesearch.NextDirectioner = n
+	dim := len(loc.X)
+	n.linesearch.resume(p, dim)
+
+	n.initStorage(dim)
+	n.tau = tau
+	return nil
+}
+
+// Reset discards the multiple of identity and the factorization of the
+// Hessian of the last run, keeping the exported fields. After Reset, Tau
+// returns zero.
+func (n *Newton) Reset() {
+	*n = Newton{
+		LinesearchMethod: n.LinesearchMethod,
+		Increase:         n.Increase,
+		InitialTau:       n.InitialTau,
+		HessianSparsity:  n.HessianSparsity,
+		ModifiedCholesky: n.ModifiedCholesky,
+	}
+}
+
+// Clone returns a new Newton with the configuration of n and a copy of its
+// LinesearchMethod. HessianSparsity is shared and must not be modified
+// while the clones are running.
+func (n *Newton) Clone() Method {
+	return &Newton{
+		LinesearchMethod: cloneLinesearchMethod(n.LinesearchMethod),
+		Increase:         n.Increase,
+		InitialTau:       n.InitialTau,
+		HessianSparsity:  n.HessianSparsity,
+		ModifiedCholesky: n.ModifiedCholesky,
+	}
+}
+
 func (n *Newton) Needs() struct {
 	Gradient bool
 	Hessian  bool
@@ -147,3 +265,85 @@ func (n *Newton) Needs() struct {
 		Hessian  bool
 	}{true, true}
 }
+
+// modifiedCholesky is the modified Cholesky factorization
+//  L D Lᵀ = A + E
+// of a symmetric matrix A, where L is unit lower triangular and D and E are
+// diagonal. The elements of D are bounded below so that A + E is
+// sufficiently positive definite and the elements of L remain bounded, and
+// E = 0 if A is sufficiently positive definite.
+//
+// References:
+//  - Gill, P.E., Murray, W.: Newton-type methods for unconstrained and
+//    linearly constrained optimization. Math Program 7 (1974), 311-350
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Algorithm 3.4
+type modifiedCholesky struct {
+	n int
+	l []float64 // L in row-major order, with the columns of C during the factorization.
+	d []float64
+}
+
+// factorize computes the modified Cholesky factorization of a.
+func (c *modifiedCholesky) factorize(a mat64.Symmetric) {
+	const eps = 2.220446049250313e-16 // Machine epsilon.
+	n := a.Symmetric()
+	c.n = n
+	c.l = resize(c.l, n*n)
+	c.d = resize(c.d, n)
+
+	// The bound β on the elements of L√D and the lower bound δ on the
+	// elements of D.
+	var gamma, xi float64
+	for i := 0; i < n; i++ {
+		gamma = math.Max(gamma, math.Abs(a.At(i, i)))
+		for j := 0; j < i; j++ {
+			xi = math.Max(xi, math.Abs(a.At(i, j)))
+		}
+	}
+	beta2 := math.Max(gamma, eps)
+	if n > 1 {
+		beta2 = math.Max(beta2, xi/math.Sqrt(float64(n*n-1)))
+	}
+	delta := eps * math.Max(gamma+xi, 1)
+
+	l := c.l
+	for j := 0; j < n; j++ {
+		// Compute the j-th column of C = A - L D Lᵀ.
+		cjj := a.At(j, j)
+		for s := 0; s < j; s++ {
+			cjj -= c.d[s] * l[j*n+s] * l[j*n+s]
+		}
+		var theta float64
+		for i := j + 1; i < n; i++ {
+			cij := a.At(i, j)
+			for s := 0; s < j; s++ {
+				cij -= c.d[s] * l[i*n+s] * l[j*n+s]
+			}
+			l[i*n+j] = cij
+			theta = math.Max(theta, math.Abs(cij))
+		}
+		d := math.Max(math.Abs(cjj), math.Max(theta*theta/beta2, delta))
+		c.d[j] = d
+		l[j*n+j] = 1
+		for i := j + 1; i < n; i++ {
+			l[i*n+j] /= d
+		}
+	}
+}
+
+// solve stores the solution of (A + E) x = b in dst. dst and b may be the
+// same.
+func (c *modifiedCholesky) solve(dst, b []float64) {
+	n := c.n
+	copy(dst, b)
+	for i := 1; i < n; i++ {
+		dst[i] -= floats.Dot(c.l[i*n:i*n+i], dst[:i])
+	}
+	for i := range dst {
+		dst[i] /= c.d[i]
+	}
+	for i := n - 1; i > 0; i-- {
+		floats.AddScaled(dst[:i], -dst[i], c.l[i*n:i*n+i])
+	}
+}
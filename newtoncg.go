@@ -0,0 +1,152 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+// NewtonCG implements the line search Newton-CG method, also known as the
+// truncated Newton method, for unconstrained minimization. At every
+// iteration, it solves the Newton equations
+//  H_k d_k = -∇f_k
+// inexactly by the conjugate gradient method, and finds the step along d_k
+// by a line search. The conjugate gradient method accesses the Hessian only
+// through the products with vectors provided by Problem.HessVec, so the
+// Hessian is never formed or stored, which makes NewtonCG suitable for large
+// problems where Newton is too expensive. If the problem does not provide
+// HessVec, the products are computed with Problem.Hess if it is available,
+// and otherwise approximated by finite differences of gradients, see
+// ProblemInfo.HessVec.
+//
+// The conjugate gradient iteration is stopped when the residual is small
+// enough, or when a direction of nonpositive curvature is encountered. In the
+// latter case the last iterate is used, or the steepest descent direction if
+// it happens in the first iteration, so d_k is always a descent direction.
+//
+// References:
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Section 7.1
+type NewtonCG struct {
+	// LinesearchMethod is a method used for selecting suitable steps along the
+	// descent direction d. If LinesearchMethod is nil, it will be set to
+	// &Bisection{}.
+	LinesearchMethod LinesearchMethod
+	// Tolerance is the relative tolerance on the residual of the Newton
+	// equations. The conjugate gradient iteration terminates when
+	//  |H_k d + ∇f_k|_2 <= Tolerance*|∇f_k|_2.
+	// If Tolerance is zero, min(0.5, sqrt(|∇f_k|_2)) is used, which yields
+	// superlinear convergence.
+	Tolerance float64
+	// MaxIterations is the maximum number of conjugate gradient iterations,
+	// and thus of Hessian-vector products, per Newton iteration. If
+	// MaxIterations is zero, it will be set to the problem dimension.
+	MaxIterations int
+
+	linesearch *Linesearch
+	hessVec    func(x, grad, v, hv []float64)
+
+	r  []float64 // Residual H d + ∇f.
+	p  []float64 // Conjugate gradient search direction.
+	hp []float64 // H p.
+}
+
+func (n *NewtonCG) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if n.Tolerance < 0 {
+		panic("newtoncg: negative tolerance")
+	}
+	if n.MaxIterations < 0 {
+		panic("newtoncg: negative number of iterations")
+	}
+	n.hessVec = p.HessVec
+	if n.LinesearchMethod == nil {
+		n.LinesearchMethod = &Bisection{}
+	}
+	if n.linesearch == nil {
+		n.linesearch = &Linesearch{}
+	}
+	n.linesearch.Method = n.LinesearchMethod
+	n.linesearch.NextDirectioner = n
+
+	return n.linesearch.Init(loc, p, xNext)
+}
+
+func (n *NewtonCG) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	return n.linesearch.Iterate(loc, xNext)
+}
+
+func (n *NewtonCG) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	n.r = resize(n.r, dim)
+	n.p = resize(n.p, dim)
+	n.hp = resize(n.hp, dim)
+	return n.NextDirection(loc, dir)
+}
+
+func (n *NewtonCG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	// This method implements Algorithm 7.1 (Line Search Newton-CG) from
+	// Nocedal, Wright (2006), 2nd edition.
+
+	grad := loc.Gradient
+	gNorm := floats.Norm(grad, 2)
+	tol := n.Tolerance
+	if tol == 0 {
+		tol = math.Min(0.5, math.Sqrt(gNorm))
+	}
+	tol *= gNorm
+	maxIter := n.MaxIterations
+	if maxIter == 0 {
+		maxIter = len(dir)
+	}
+
+	for i := range dir {
+		dir[i] = 0
+	}
+	copy(n.r, grad)
+	copy(n.p, grad)
+	floats.Scale(-1, n.p)
+	rr := floats.Dot(n.r, n.r)
+	for j := 0; j < maxIter; j++ {
+		n.hessVec(loc.X, grad, n.p, n.hp)
+		curv := floats.Dot(n.p, n.hp)
+		if curv <= 0 {
+			if j == 0 {
+				// Negative curvature along the steepest descent
+				// direction.
+				copy(dir, n.p)
+			}
+			break
+		}
+		alpha := rr / curv
+		floats.AddScaled(dir, alpha, n.p)
+		floats.AddScaled(n.r, alpha, n.hp)
+		rrNew := floats.Dot(n.r, n.r)
+		if math.Sqrt(rrNew) <= tol {
+			break
+		}
+		floats.Scale(rrNew/rr, n.p)
+		floats.Sub(n.p, n.r)
+		rr = rrNew
+	}
+	if floats.Dot(dir, grad) >= 0 {
+		// The iteration has not made progress, for example because of
+		// rounding errors.
+		copy(dir, grad)
+		floats.Scale(-1, dir)
+	}
+	return 1
+}
+
+func (*NewtonCG) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
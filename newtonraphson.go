@@ -0,0 +1,76 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"github.com/gonum/floats"
+)
+
+const (
+	// rootArmijo is the sufficient decrease constant of the line search of
+	// NewtonRaphson and Broyden.
+	rootArmijo = 1e-4
+	// rootMinStep is the smallest step of the line search of
+	// NewtonRaphson and Broyden.
+	rootMinStep = 1e-10
+)
+
+// NewtonRaphson implements the Newton-Raphson method for systems of nonlinear
+// equations. It generates a sequence of locations x_k by means of
+//  solve J(x_k) d_k = -F(x_k) for d_k,
+//  x_{k+1} = x_k + α_k d_k,
+// where J is the Jacobian of F and the step size α_k is found by
+// backtracking from 1 until ½ |F|_2^2 decreases sufficiently. The method
+// converges quadratically close to a solution with a nonsingular Jacobian.
+// If the Jacobian is singular, NewtonRaphson fails with ErrSingularJacobian,
+// and Hybrid should be used instead.
+type NewtonRaphson struct {
+	x, f  []float64 // Location and F of the last major iteration.
+	fnorm float64   // |F|^2 at x.
+	dir   []float64
+	step  float64 // Step size of the current trial, zero if a direction is needed.
+	lu    rootLU
+}
+
+func (nr *NewtonRaphson) Init(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	n := len(loc.X)
+	nr.x = resize(nr.x, n)
+	nr.f = resize(nr.f, n)
+	nr.dir = resize(nr.dir, n)
+	copy(nr.x, loc.X)
+	copy(nr.f, loc.F)
+	nr.fnorm = floats.Dot(nr.f, nr.f)
+	nr.step = 0
+	copy(xNext, loc.X)
+	return GradEvaluation, NoIteration, nil
+}
+
+func (nr *NewtonRaphson) Iterate(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error) {
+	if nr.step == 0 {
+		// The Jacobian at x has been evaluated.
+		if !nr.lu.factorize(loc.Jacobian) {
+			return NoEvaluation, NoIteration, ErrSingularJacobian
+		}
+		nr.lu.solve(nr.dir, nr.f)
+		floats.Scale(-1, nr.dir)
+		nr.step = 1
+	} else {
+		if fnorm := floats.Dot(loc.F, loc.F); fnorm <= (1-2*rootArmijo*nr.step)*nr.fnorm {
+			// The step is accepted.
+			copy(nr.x, loc.X)
+			copy(nr.f, loc.F)
+			nr.fnorm = fnorm
+			nr.step = 0
+			copy(xNext, loc.X)
+			return GradEvaluation, MajorIteration, nil
+		}
+		nr.step /= 2
+		if nr.step < rootMinStep {
+			return NoEvaluation, NoIteration, ErrLinesearchFailure
+		}
+	}
+	floats.AddScaledTo(xNext, nr.x, nr.step, nr.dir)
+	return FuncEvaluation, MinorIteration, nil
+}
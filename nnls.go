@@ -0,0 +1,384 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// NNLS solves nonnegative linear least-squares problems
+//  minimize ½ |A x - b|_2^2 subject to x ≥ 0
+// with the active-set method of Lawson and Hanson. The method keeps the
+// variables in a passive set, where they are free, and an active set, where
+// they are held at zero. At every iteration it releases the active variable
+// with the largest negative gradient element, and solves the unconstrained
+// least-squares problem in the passive variables. If the solution is not
+// feasible, it moves towards it as far as possible and returns the variables
+// that reach zero to the active set. The method terminates in a finite number
+// of iterations.
+//
+// The least-squares subproblems are solved with a Householder QR
+// factorization of the passive columns of A, so NNLS does not form AᵀA and is
+// suitable for ill-conditioned problems.
+//
+// References:
+//  - Lawson, C.L., Hanson, R.J.: Solving Least Squares Problems. SIAM (1995),
+//    Chapter 23
+type NNLS struct {
+	// Tolerance is the tolerance on the gradient Aᵀ(A x - b) relative to
+	// its infinity norm at the origin. The solution is optimal when no
+	// element of the gradient at an active variable is smaller than
+	// -Tolerance. If Tolerance is zero, it will be set to 1e-10.
+	Tolerance float64
+	// MaxIterations is the maximum number of variables released from the
+	// active set. If MaxIterations is zero, it will be set to 3 times the
+	// number of variables.
+	MaxIterations int
+
+	ls activeSetLS
+}
+
+// Solve returns the solution of the nonnegative least-squares problem with
+// the m×n matrix a and the right-hand side b of length m. If the iteration
+// limit is reached, Solve returns the current feasible iterate together with
+// ErrIterationLimit.
+func (s *NNLS) Solve(a mat64.Matrix, b []float64) ([]float64, error) {
+	_, n := a.Dims()
+	s.ls.lower = resize(s.ls.lower, n)
+	s.ls.upper = resize(s.ls.upper, n)
+	for i := range s.ls.lower {
+		s.ls.lower[i] = 0
+		s.ls.upper[i] = math.Inf(1)
+	}
+	return s.ls.solve(a, b, s.ls.lower, s.ls.upper, s.Tolerance, s.MaxIterations)
+}
+
+// BVLS solves bounded-variable linear least-squares problems
+//  minimize ½ |A x - b|_2^2 subject to lower ≤ x ≤ upper
+// with the active-set method of Stark and Parker. It generalizes the method of
+// Lawson and Hanson used by NNLS: variables are held at either of their bounds
+// or are free, and at every iteration the bound variable whose gradient
+// element points most into the feasible region is released. Variables with
+// two infinite bounds are always free.
+//
+// References:
+//  - Stark, P.B., Parker, R.L.: Bounded-variable least-squares: an algorithm
+//    and applications. Comput. Stat. 10 (1995), 129-141
+type BVLS struct {
+	// Tolerance is the tolerance on the gradient Aᵀ(A x - b) relative to
+	// its infinity norm at the initial point, where every variable with a
+	// finite bound is at one of its bounds. If Tolerance is zero, it will be
+	// set to 1e-10.
+	Tolerance float64
+	// MaxIterations is the maximum number of variables released from their
+	// bounds. If MaxIterations is zero, it will be set to 3 times the number
+	// of variables.
+	MaxIterations int
+
+	ls activeSetLS
+}
+
+// Solve returns the solution of the bounded-variable least-squares problem
+// with the m×n matrix a and the right-hand side b of length m. The lower and
+// upper bounds must have length n, and may contain infinite values. If lower
+// or upper is nil, the variables are unbounded from below or above. If the
+// iteration limit is reached, Solve returns the current feasible iterate
+// together with ErrIterationLimit.
+func (s *BVLS) Solve(a mat64.Matrix, b, lower, upper []float64) ([]float64, error) {
+	_, n := a.Dims()
+	if lower == nil {
+		s.ls.lower = resize(s.ls.lower, n)
+		for i := range s.ls.lower {
+			s.ls.lower[i] = math.Inf(-1)
+		}
+		lower = s.ls.lower
+	}
+	if upper == nil {
+		s.ls.upper = resize(s.ls.upper, n)
+		for i := range s.ls.upper {
+			s.ls.upper[i] = math.Inf(1)
+		}
+		upper = s.ls.upper
+	}
+	if len(lower) != n || len(upper) != n {
+		panic("optimize: bounds length mismatch")
+	}
+	for i, l := range lower {
+		if l > upper[i] {
+			panic("optimize: lower bound greater than upper bound")
+		}
+	}
+	return s.ls.solve(a, b, lower, upper, s.Tolerance, s.MaxIterations)
+}
+
+// lsState is the state of a variable in activeSetLS.
+type lsState int
+
+const (
+	lsFree lsState = iota
+	lsLower
+	lsUpper
+)
+
+// activeSetLS implements the active-set method shared by NNLS and BVLS.
+type activeSetLS struct {
+	lower, upper []float64 // Bounds used when the caller does not supply them.
+
+	state    []lsState
+	excluded []bool // Variables that failed to move when released.
+	free     []int
+
+	w  []float64 // Negative gradient Aᵀ(b - A x).
+	r  []float64 // Residual or right-hand side of the subproblem.
+	z  []float64 // Solution of the subproblem.
+	qr []float64 // Passive columns of A in column-major order.
+	rd []float64 // Diagonal of R.
+}
+
+func (ls *activeSetLS) solve(a mat64.Matrix, b, lower, upper []float64, tol float64, maxIter int) ([]float64, error) {
+	m, n := a.Dims()
+	if len(b) != m {
+		panic("optimize: mismatched length of b")
+	}
+	if tol == 0 {
+		tol = 1e-10
+	}
+	if maxIter == 0 {
+		maxIter = 3 * n
+	}
+	ls.r = resize(ls.r, m)
+	ls.w = resize(ls.w, n)
+	ls.z = resize(ls.z, n)
+	if cap(ls.state) < n {
+		ls.state = make([]lsState, n)
+		ls.excluded = make([]bool, n)
+	}
+	ls.state = ls.state[:n]
+	ls.excluded = ls.excluded[:n]
+
+	// Start with every variable that has a finite bound at that bound.
+	x := make([]float64, n)
+	var hasFree bool
+	for j := range x {
+		ls.excluded[j] = false
+		switch {
+		case !math.IsInf(lower[j], -1):
+			x[j] = lower[j]
+			ls.state[j] = lsLower
+		case !math.IsInf(upper[j], 1):
+			x[j] = upper[j]
+			ls.state[j] = lsUpper
+		default:
+			x[j] = 0
+			ls.state[j] = lsFree
+			hasFree = true
+		}
+	}
+	if hasFree {
+		ls.improve(a, b, x, lower, upper, -1)
+	}
+	ls.gradient(a, b, x)
+	tol *= math.Max(1, floats.Norm(ls.w, math.Inf(1)))
+
+	for iter := 0; ; {
+		// Find the bound variable whose gradient element points furthest
+		// into the feasible region.
+		t := -1
+		best := tol
+		for j, st := range ls.state {
+			if ls.excluded[j] || lower[j] == upper[j] {
+				continue
+			}
+			var v float64
+			switch st {
+			case lsLower:
+				v = ls.w[j]
+			case lsUpper:
+				v = -ls.w[j]
+			default:
+				continue
+			}
+			if v > best {
+				best = v
+				t = j
+			}
+		}
+		if t == -1 {
+			return x, nil
+		}
+		if iter == maxIter {
+			return x, ErrIterationLimit
+		}
+		iter++
+
+		prev := ls.state[t]
+		ls.state[t] = lsFree
+		if !ls.improve(a, b, x, lower, upper, t) {
+			// The gradient element was dominated by rounding errors. Keep
+			// the variable at its bound until the iterate changes.
+			ls.state[t] = prev
+			ls.excluded[t] = true
+			continue
+		}
+		for j := range ls.excluded {
+			ls.excluded[j] = false
+		}
+		ls.gradient(a, b, x)
+	}
+}
+
+// gradient stores Aᵀ(b - A x) in ls.w.
+func (ls *activeSetLS) gradient(a mat64.Matrix, b, x []float64) {
+	m, n := a.Dims()
+	r := mat64.NewVector(m, ls.r)
+	r.MulVec(a, false, mat64.NewVector(n, x))
+	floats.SubTo(ls.r, b, ls.r)
+	mat64.NewVector(n, ls.w).MulVec(a, true, r)
+}
+
+// improve minimizes the objective over the free variables while keeping x
+// feasible, moving the free variables that reach a bound to the bound. If t
+// is not negative, it is the variable that has just been released, and
+// improve returns false without modifying x if the first subproblem does not
+// move it into the feasible region.
+func (ls *activeSetLS) improve(a mat64.Matrix, b, x, lower, upper []float64, t int) bool {
+	var dir float64
+	if t >= 0 {
+		dir = 1
+		if x[t] == upper[t] {
+			dir = -1
+		}
+	}
+	for {
+		ls.solveFree(a, b, x)
+		if len(ls.free) == 0 {
+			return true
+		}
+		if t >= 0 {
+			if dir*(ls.z[t]-x[t]) <= 0 {
+				return false
+			}
+			t = -1
+		}
+
+		// Find the longest feasible step towards the solution of the
+		// subproblem.
+		alpha := 1.0
+		block := -1
+		for _, j := range ls.free {
+			z := ls.z[j]
+			var step float64
+			switch {
+			case z < lower[j]:
+				step = (x[j] - lower[j]) / (x[j] - z)
+			case z > upper[j]:
+				step = (upper[j] - x[j]) / (z - x[j])
+			default:
+				continue
+			}
+			if step < alpha {
+				alpha = step
+				block = j
+			}
+		}
+		if block == -1 {
+			for _, j := range ls.free {
+				x[j] = ls.z[j]
+			}
+			return true
+		}
+		for _, j := range ls.free {
+			x[j] += alpha * (ls.z[j] - x[j])
+			switch {
+			case j == block && ls.z[j] < lower[j], x[j] <= lower[j]:
+				x[j] = lower[j]
+				ls.state[j] = lsLower
+			case j == block, x[j] >= upper[j]:
+				x[j] = upper[j]
+				ls.state[j] = lsUpper
+			}
+		}
+	}
+}
+
+// solveFree solves the least-squares problem in the free variables with the
+// other variables held at their values in x, and stores the solution in the
+// free elements of ls.z.
+func (ls *activeSetLS) solveFree(a mat64.Matrix, b, x []float64) {
+	m, n := a.Dims()
+	ls.free = ls.free[:0]
+	copy(ls.r, b)
+	for j := 0; j < n; j++ {
+		if ls.state[j] == lsFree {
+			ls.free = append(ls.free, j)
+			continue
+		}
+		if x[j] == 0 {
+			continue
+		}
+		for i := 0; i < m; i++ {
+			ls.r[i] -= a.At(i, j) * x[j]
+		}
+	}
+	k := len(ls.free)
+	if k == 0 {
+		return
+	}
+	ls.qr = resize(ls.qr, m*k)
+	ls.rd = resize(ls.rd, k)
+	for l, j := range ls.free {
+		c := ls.qr[l*m : (l+1)*m]
+		for i := range c {
+			c[i] = a.At(i, j)
+		}
+	}
+
+	// Compute the QR factorization with Householder reflections, applying
+	// them to the right-hand side.
+	var maxDiag float64
+	for l := 0; l < k; l++ {
+		ls.rd[l] = 0
+		if l >= m {
+			continue
+		}
+		c := ls.qr[l*m+l : (l+1)*m]
+		norm := floats.Norm(c, 2)
+		if norm == 0 {
+			continue
+		}
+		if c[0] > 0 {
+			norm = -norm
+		}
+		// The reflection maps c to norm e_1. It is stored in c as v = c - norm e_1.
+		c[0] -= norm
+		denom := -norm * c[0]
+		for p := l + 1; p < k; p++ {
+			y := ls.qr[p*m+l : (p+1)*m]
+			floats.AddScaled(y, -floats.Dot(c, y)/denom, c)
+		}
+		y := ls.r[l:]
+		floats.AddScaled(y, -floats.Dot(c, y)/denom, c)
+		ls.rd[l] = norm
+		maxDiag = math.Max(maxDiag, math.Abs(norm))
+	}
+
+	// Solve R z = Qᵀ r. Variables with negligible diagonal elements are
+	// linearly dependent on the others, and they keep their values.
+	for l := k - 1; l >= 0; l-- {
+		j := ls.free[l]
+		if math.Abs(ls.rd[l]) <= 1e-12*maxDiag {
+			ls.z[j] = x[j]
+			continue
+		}
+		v := ls.r[l]
+		for p := l + 1; p < k; p++ {
+			v -= ls.qr[p*m+l] * ls.z[ls.free[p]]
+		}
+		ls.z[j] = v / ls.rd[l]
+	}
+}
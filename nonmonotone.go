@@ -0,0 +1,124 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const defaultNonmonotoneMemory = 10
+
+// Nonmonotone is a type that implements LinesearchMethod using the
+// nonmonotone backtracking line search of Grippo, Lampariello and Lucidi.
+// Instead of the function value at the start of the line search, the Armijo
+// condition is checked against the maximum of the function values at the
+// starts of the last Memory line searches,
+//  f(x_k + s p_k) <= max_{0<=j<Memory} f_{k-j} + FunConst s ∇f_k⋅p_k.
+// If the condition has not been met, the step size is decreased by a factor
+// of Decrease.
+//
+// Allowing the function to increase temporarily helps methods whose steps are
+// not designed to decrease the function monotonically, such as Barzilai-Borwein
+// and other spectral gradient methods. With Memory equal to one, Nonmonotone
+// is equivalent to Backtracking. Like Backtracking, Nonmonotone is not
+// appropriate for optimizers that require the Wolfe conditions to be met.
+//
+// The function values are kept between line searches. Linesearch discards
+// them by calling Reset at the start of every optimization run.
+//
+// Both FunConst and Decrease must be between zero and one, and Nonmonotone will
+// panic otherwise. If either FunConst or Decrease are zero, it will be set to a
+// reasonable default.
+//
+// References:
+//  Grippo, L., Lampariello, F., Lucidi, S.: A nonmonotone line search technique
+//  for Newton's method. SIAM J. Numer. Anal. 23(4), 707-716 (1986)
+type Nonmonotone struct {
+	// Memory is the number of function values that are kept.
+	// If Memory is zero, it will be set to 10.
+	// Nonmonotone will panic if Memory is negative.
+	Memory   int
+	FunConst float64 // Necessary function decrease for the nonmonotone Armijo condition.
+	Decrease float64 // Step size multiplier at each iteration (stepSize *= Decrease).
+
+	stepSize float64
+	refF     float64
+	initG    float64
+
+	fs   []float64 // Ring buffer of the most recent function values.
+	next int       // Index of the next function value in fs.
+}
+
+func (n *Nonmonotone) Init(loc LinesearchLocation, step float64, p *ProblemInfo) EvaluationType {
+	if step <= 0 {
+		panic("nonmonotone: bad step size")
+	}
+	if loc.Derivative >= 0 {
+		panic("nonmonotone: initial derivative is non-negative")
+	}
+
+	if n.Memory == 0 {
+		n.Memory = defaultNonmonotoneMemory
+	}
+	if n.Decrease == 0 {
+		n.Decrease = defaultBacktrackingDecrease
+	}
+	if n.FunConst == 0 {
+		n.FunConst = defaultBacktrackingFunConst
+	}
+	if n.Memory < 0 {
+		panic("nonmonotone: Memory is negative")
+	}
+	if n.Decrease <= 0 || n.Decrease >= 1 {
+		panic("nonmonotone: Decrease must be between 0 and 1")
+	}
+	if n.FunConst <= 0 || n.FunConst >= 1 {
+		panic("nonmonotone: FunConst must be between 0 and 1")
+	}
+
+	if cap(n.fs) != n.Memory {
+		n.fs = make([]float64, 0, n.Memory)
+		n.next = 0
+	}
+	// Linesearch may start several line searches from the same location, for
+	// example after a restart, so only record a value once.
+	last := (n.next + n.Memory - 1) % n.Memory
+	if len(n.fs) == 0 || n.fs[last] != loc.F {
+		if len(n.fs) < n.Memory {
+			n.fs = append(n.fs, loc.F)
+		} else {
+			n.fs[n.next] = loc.F
+		}
+		n.next = (n.next + 1) % n.Memory
+	}
+
+	n.refF = math.Inf(-1)
+	for _, f := range n.fs {
+		n.refF = math.Max(n.refF, f)
+	}
+	n.stepSize = step
+	n.initG = loc.Derivative
+	return FuncEvaluation
+}
+
+func (n *Nonmonotone) Finished(loc LinesearchLocation) bool {
+	return ArmijoConditionMet(loc.F, n.refF, n.initG, n.stepSize, n.FunConst)
+}
+
+func (n *Nonmonotone) Iterate(_ LinesearchLocation) (float64, EvaluationType, error) {
+	n.stepSize *= n.Decrease
+	if n.stepSize < minimumBacktrackingStepSize {
+		return 0, NoEvaluation, ErrLinesearchFailure
+	}
+	return n.stepSize, FuncEvaluation, nil
+}
+
+// Reset discards the function values of the last run, keeping the exported
+// fields.
+func (n *Nonmonotone) Reset() {
+	*n = Nonmonotone{
+		Memory:   n.Memory,
+		FunConst: n.FunConst,
+		Decrease: n.Decrease,
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	defaultNSGA2Population  = 100
+	defaultNSGA2Generations = 250
+	defaultNSGA2Crossover   = 0.9
+	defaultNSGA2CrossIndex  = 15
+	defaultNSGA2MutIndex    = 20
+)
+
+// NSGA2 implements the non-dominated sorting genetic algorithm II of Deb et
+// al. for MultiObjectiveProblems. It evolves a population whose members are
+// ranked by non-dominated sorting: the first front contains the members that
+// no other member dominates, the second front those that only members of the
+// first front dominate, and so on. Within a front, members in less crowded
+// regions of objective space are preferred, which spreads the population over
+// the Pareto front.
+//
+// Every generation creates offspring by binary tournament selection,
+// simulated binary crossover and polynomial mutation, and selects the next
+// population from the parents and the offspring by rank and crowding
+// distance. The result contains the members of the first front of the final
+// population.
+//
+// References:
+//  - Deb, K., Pratap, A., Agarwal, S., Meyarivan, T.: A fast and elitist
+//    multiobjective genetic algorithm: NSGA-II. IEEE Trans. Evol. Comput. 6
+//    (2002), 182-197
+//  - Deb, K., Agrawal, R.B.: Simulated binary crossover for continuous
+//    search space. Complex Syst. 9 (1995), 115-148
+type NSGA2 struct {
+	// PopulationSize is the number of members of the population. If
+	// PopulationSize is zero, it will be set to 100. NSGA2 panics if
+	// PopulationSize is nonzero and smaller than 4.
+	PopulationSize int
+	// Generations is the number of generations. If Generations is zero, it
+	// will be set to 250.
+	Generations int
+	// CrossoverProbability is the probability that two parents are
+	// recombined. If CrossoverProbability is zero, it will be set to 0.9.
+	CrossoverProbability float64
+	// MutationProbability is the probability that a variable of an
+	// offspring is mutated. If MutationProbability is zero, it will be set
+	// to one over the dimension of the problem.
+	MutationProbability float64
+	// CrossoverIndex and MutationIndex are the distribution indices of the
+	// crossover and the mutation. Larger values create offspring closer to
+	// their parents. If they are zero, they will be set to 15 and 20.
+	CrossoverIndex float64
+	MutationIndex  float64
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from the global source of math/rand.
+	Src rand.Source
+
+	rnd *rand.Rand
+}
+
+// nsga2Member is a member of the population of NSGA2.
+type nsga2Member struct {
+	x, f  []float64
+	rank  int
+	crowd float64 // Crowding distance.
+}
+
+// Minimize returns the approximation of the Pareto-optimal set of p found by
+// NSGA2.
+func (ns *NSGA2) Minimize(p MultiObjectiveProblem) *ParetoResult {
+	if p.Func == nil {
+		panic("optimize: objective function is undefined")
+	}
+	if p.Objectives <= 0 {
+		panic("optimize: no objectives")
+	}
+	if p.Bounds == nil || p.Bounds.Lower == nil || p.Bounds.Upper == nil {
+		panic("optimize: NSGA2 needs finite bounds")
+	}
+	dim := len(p.Bounds.Lower)
+	p.Bounds.validate(dim)
+	for i, l := range p.Bounds.Lower {
+		if math.IsInf(l, 0) || math.IsInf(p.Bounds.Upper[i], 0) {
+			panic("optimize: NSGA2 needs finite bounds")
+		}
+	}
+	popSize := ns.PopulationSize
+	if popSize == 0 {
+		popSize = defaultNSGA2Population
+	}
+	if popSize < 4 {
+		panic("nsga2: population size must be at least 4")
+	}
+	gens := ns.Generations
+	if gens == 0 {
+		gens = defaultNSGA2Generations
+	}
+	if gens < 0 {
+		panic("nsga2: negative number of generations")
+	}
+	pc := ns.CrossoverProbability
+	if pc == 0 {
+		pc = defaultNSGA2Crossover
+	}
+	pm := ns.MutationProbability
+	if pm == 0 {
+		pm = 1 / float64(dim)
+	}
+	if pc < 0 || pc > 1 || pm < 0 || pm > 1 {
+		panic("nsga2: probability must be in [0, 1]")
+	}
+	etaC := ns.CrossoverIndex
+	if etaC == 0 {
+		etaC = defaultNSGA2CrossIndex
+	}
+	etaM := ns.MutationIndex
+	if etaM == 0 {
+		etaM = defaultNSGA2MutIndex
+	}
+	if etaC < 0 || etaM < 0 {
+		panic("nsga2: negative distribution index")
+	}
+	if ns.Src != nil {
+		ns.rnd = rand.New(ns.Src)
+	} else {
+		ns.rnd = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	res := &ParetoResult{}
+	evaluate := func(m *nsga2Member) {
+		p.Func(m.x, m.f)
+		res.FuncEvaluations++
+	}
+	newMember := func() *nsga2Member {
+		return &nsga2Member{
+			x: make([]float64, dim),
+			f: make([]float64, p.Objectives),
+		}
+	}
+
+	pop := make([]*nsga2Member, popSize)
+	for i := range pop {
+		pop[i] = newMember()
+		for j := range pop[i].x {
+			l, u := p.Bounds.Lower[j], p.Bounds.Upper[j]
+			pop[i].x[j] = l + ns.rnd.Float64()*(u-l)
+		}
+		evaluate(pop[i])
+	}
+	rankAndCrowd(pop)
+
+	union := make([]*nsga2Member, 0, 2*popSize)
+	for g := 0; g < gens; g++ {
+		union = append(union[:0], pop...)
+		for len(union) < 2*popSize {
+			c1, c2 := newMember(), newMember()
+			copy(c1.x, ns.tournament(pop).x)
+			copy(c2.x, ns.tournament(pop).x)
+			if ns.rnd.Float64() < pc {
+				ns.crossover(c1.x, c2.x, p.Bounds, etaC)
+			}
+			ns.mutate(c1.x, p.Bounds, pm, etaM)
+			ns.mutate(c2.x, p.Bounds, pm, etaM)
+			evaluate(c1)
+			union = append(union, c1)
+			if len(union) < 2*popSize {
+				evaluate(c2)
+				union = append(union, c2)
+			}
+		}
+		pop = selectNext(union, popSize)
+	}
+
+	var front []*nsga2Member
+	for _, m := range pop {
+		if m.rank == 0 {
+			front = append(front, m)
+		}
+	}
+	sort.Sort(byFirstObjective(front))
+	for _, m := range front {
+		res.X = append(res.X, m.x)
+		res.F = append(res.F, m.f)
+	}
+	return res
+}
+
+// tournament returns the winner of a binary tournament between two random
+// members of pop by the crowded comparison.
+func (ns *NSGA2) tournament(pop []*nsga2Member) *nsga2Member {
+	a := pop[ns.rnd.Intn(len(pop))]
+	b := pop[ns.rnd.Intn(len(pop))]
+	if crowdedLess(a, b) {
+		return a
+	}
+	return b
+}
+
+// crossover recombines x1 and x2 in place by simulated binary crossover with
+// the distribution index eta.
+func (ns *NSGA2) crossover(x1, x2 []float64, b *Bounds, eta float64) {
+	for i := range x1 {
+		if ns.rnd.Float64() > 0.5 || math.Abs(x1[i]-x2[i]) <= 1e-14 {
+			continue
+		}
+		y1, y2 := math.Min(x1[i], x2[i]), math.Max(x1[i], x2[i])
+		l, u := b.Lower[i], b.Upper[i]
+		r := ns.rnd.Float64()
+		spread := func(beta float64) float64 {
+			alpha := 2 - math.Pow(beta, -(eta+1))
+			if r <= 1/alpha {
+				return math.Pow(r*alpha, 1/(eta+1))
+			}
+			return math.Pow(1/(2-r*alpha), 1/(eta+1))
+		}
+		c1 := 0.5 * (y1 + y2 - spread(1+2*(y1-l)/(y2-y1))*(y2-y1))
+		c2 := 0.5 * (y1 + y2 + spread(1+2*(u-y2)/(y2-y1))*(y2-y1))
+		c1 = math.Max(l, math.Min(c1, u))
+		c2 = math.Max(l, math.Min(c2, u))
+		if ns.rnd.Float64() < 0.5 {
+			c1, c2 = c2, c1
+		}
+		x1[i], x2[i] = c1, c2
+	}
+}
+
+// mutate mutates the variables of x in place with probability pm by
+// polynomial mutation with the distribution index eta.
+func (ns *NSGA2) mutate(x []float64, b *Bounds, pm, eta float64) {
+	for i, y := range x {
+		if ns.rnd.Float64() >= pm {
+			continue
+		}
+		l, u := b.Lower[i], b.Upper[i]
+		if u == l {
+			continue
+		}
+		r := ns.rnd.Float64()
+		pow := 1 / (eta + 1)
+		var dq float64
+		if r < 0.5 {
+			xy := 1 - (y-l)/(u-l)
+			val := 2*r + (1-2*r)*math.Pow(xy, eta+1)
+			dq = math.Pow(val, pow) - 1
+		} else {
+			xy := 1 - (u-y)/(u-l)
+			val := 2*(1-r) + 2*(r-0.5)*math.Pow(xy, eta+1)
+			dq = 1 - math.Pow(val, pow)
+		}
+		x[i] = math.Max(l, math.Min(y+dq*(u-l), u))
+	}
+}
+
+// crowdedLess returns whether a is preferred to b by rank, and by crowding
+// distance within the same rank.
+func crowdedLess(a, b *nsga2Member) bool {
+	if a.rank != b.rank {
+		return a.rank < b.rank
+	}
+	return a.crowd > b.crowd
+}
+
+// selectNext returns the n best members of pop by rank and crowding distance.
+func selectNext(pop []*nsga2Member, n int) []*nsga2Member {
+	fronts := rankAndCrowd(pop)
+	next := make([]*nsga2Member, 0, n)
+	for _, front := range fronts {
+		if len(next)+len(front) > n {
+			sort.Sort(byCrowding(front))
+			next = append(next, front[:n-len(next)]...)
+			break
+		}
+		next = append(next, front...)
+	}
+	return next
+}
+
+// rankAndCrowd sorts pop into non-dominated fronts, sets the ranks and the
+// crowding distances of the members, and returns the fronts.
+func rankAndCrowd(pop []*nsga2Member) [][]*nsga2Member {
+	n := len(pop)
+	dominated := make([][]int, n) // Members dominated by each member.
+	count := make([]int, n)       // Number of members dominating each member.
+	var current []int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case dominates(pop[i].f, pop[j].f):
+				dominated[i] = append(dominated[i], j)
+				count[j]++
+			case dominates(pop[j].f, pop[i].f):
+				dominated[j] = append(dominated[j], i)
+				count[i]++
+			}
+		}
+		if count[i] == 0 {
+			current = append(current, i)
+		}
+	}
+
+	var fronts [][]*nsga2Member
+	for rank := 0; len(current) > 0; rank++ {
+		front := make([]*nsga2Member, len(current))
+		var next []int
+		for k, i := range current {
+			pop[i].rank = rank
+			front[k] = pop[i]
+			for _, j := range dominated[i] {
+				count[j]--
+				if count[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		crowdingDistance(front)
+		fronts = append(fronts, front)
+		current = next
+	}
+	return fronts
+}
+
+// crowdingDistance sets the crowding distances of the members of a front,
+// the sum over the objectives of the normalized distance between the
+// neighbors of a member. The extreme members have infinite distance.
+func crowdingDistance(front []*nsga2Member) {
+	for _, m := range front {
+		m.crowd = 0
+	}
+	if len(front) == 0 {
+		return
+	}
+	for k := range front[0].f {
+		sort.Sort(byMemberObjective{front, k})
+		lo, hi := front[0].f[k], front[len(front)-1].f[k]
+		front[0].crowd = math.Inf(1)
+		front[len(front)-1].crowd = math.Inf(1)
+		if hi == lo || math.IsNaN(hi-lo) {
+			continue
+		}
+		for i := 1; i < len(front)-1; i++ {
+			front[i].crowd += (front[i+1].f[k] - front[i-1].f[k]) / (hi - lo)
+		}
+	}
+}
+
+// byFirstObjective sorts members by their first objective.
+type byFirstObjective []*nsga2Member
+
+func (b byFirstObjective) Len() int           { return len(b) }
+func (b byFirstObjective) Less(i, j int) bool { return b[i].f[0] < b[j].f[0] }
+func (b byFirstObjective) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// byCrowding sorts members by decreasing crowding distance.
+type byCrowding []*nsga2Member
+
+func (b byCrowding) Len() int           { return len(b) }
+func (b byCrowding) Less(i, j int) bool { return b[i].crowd > b[j].crowd }
+func (b byCrowding) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// byMemberObjective sorts members by objective k.
+type byMemberObjective struct {
+	m []*nsga2Member
+	k int
+}
+
+func (b byMemberObjective) Len() int           { return len(b.m) }
+func (b byMemberObjective) Less(i, j int) bool { return b.m[i].f[b.k] < b.m[j].f[b.k] }
+func (b byMemberObjective) Swap(i, j int)      { b.m[i], b.m[j] = b.m[j], b.m[i] }
@@ -0,0 +1,96 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestParetoUtilities(t *testing.T) {
+	f := [][]float64{{0, 1}, {0.2, 0.2}, {0.5, 0.5}, {1, 0}, {0.2, 0.3}}
+	front := ParetoFront(f)
+	want := []int{0, 1, 3}
+	if len(front) != len(want) {
+		t.Fatalf("unexpected Pareto front: want %v, got %v", want, front)
+	}
+	for i, v := range want {
+		if front[i] != v {
+			t.Fatalf("unexpected Pareto front: want %v, got %v", want, front)
+		}
+	}
+
+	for _, test := range []struct {
+		front [][]float64
+		ref   []float64
+		want  float64
+	}{
+		{[][]float64{{1, 2}, {2, 1}}, []float64{3, 3}, 3},
+		{[][]float64{{1, 2}, {2, 1}, {2, 2}, {4, 0}}, []float64{3, 3}, 3},
+		{[][]float64{{1, 1, 1}}, []float64{2, 2, 2}, 1},
+		{[][]float64{{0, 1, 1}, {1, 0, 1}, {1, 1, 0}}, []float64{2, 2, 2}, 4},
+		{nil, []float64{1, 1}, 0},
+	} {
+		if got := Hypervolume(test.front, test.ref); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("unexpected hypervolume of %v: want %v, got %v", test.front, test.want, got)
+		}
+	}
+
+	if k := KneePoint([][]float64{{0, 1}, {0.2, 0.2}, {1, 0}}); k != 1 {
+		t.Errorf("unexpected knee point: want 1, got %d", k)
+	}
+}
+
+func TestNSGA2(t *testing.T) {
+	// ZDT1, whose Pareto front is f_2 = 1 - sqrt(f_1) for f_1 in [0, 1].
+	const dim = 6
+	lower := make([]float64, dim)
+	upper := make([]float64, dim)
+	for i := range upper {
+		upper[i] = 1
+	}
+	zdt1 := MultiObjectiveProblem{
+		Func: func(x, dst []float64) {
+			g := 0.0
+			for _, v := range x[1:] {
+				g += v
+			}
+			g = 1 + 9*g/float64(len(x)-1)
+			dst[0] = x[0]
+			dst[1] = g * (1 - math.Sqrt(x[0]/g))
+		},
+		Objectives: 2,
+		Bounds:     &Bounds{Lower: lower, Upper: upper},
+	}
+	ns := &NSGA2{PopulationSize: 60, Generations: 200, Src: rand.NewSource(1)}
+	res := ns.Minimize(zdt1)
+	if len(res.X) < 30 {
+		t.Errorf("too few non-dominated locations: %d", len(res.X))
+	}
+	if want := 60 * 201; res.FuncEvaluations != want {
+		t.Errorf("unexpected number of evaluations: want %d, got %d", want, res.FuncEvaluations)
+	}
+	for i, f := range res.F {
+		if i > 0 && f[0] < res.F[i-1][0] {
+			t.Errorf("front not sorted by the first objective")
+		}
+		if d := f[1] - (1 - math.Sqrt(f[0])); d > 0.05 {
+			t.Errorf("location %v too far from the Pareto front: %v", res.X[i], d)
+		}
+	}
+	if len(ParetoFront(res.F)) != len(res.F) {
+		t.Errorf("result contains dominated locations")
+	}
+	// The hypervolume of the Pareto front with the reference point (1, 1) is
+	// 1/3.
+	if hv := Hypervolume(res.F, []float64{1, 1}); hv < 0.3 {
+		t.Errorf("hypervolume too small: %v", hv)
+	}
+	k := KneePoint(res.F)
+	if f := res.F[k]; f[0] < 0.1 || f[0] > 0.5 {
+		t.Errorf("unexpected knee point %v", f)
+	}
+}
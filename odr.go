@@ -0,0 +1,308 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// odrEps is the machine epsilon.
+const odrEps = 2.220446049250313e-16
+
+// ODRProblem describes an orthogonal distance regression problem, a fit of
+// the model y = f(x; β) to the observations (x_i, y_i) when both x_i and y_i
+// are subject to errors. The parameters β and the errors δ_i of the
+// explanatory variables are found by minimizing the weighted orthogonal
+// distances of the observations from the model,
+//  Σ_i WY_i (f(x_i + δ_i; β) - y_i)^2 + WX_i δ_i^2.
+// The weights are typically the reciprocals of the variances of the errors.
+type ODRProblem struct {
+	// X and Y are the observations of the explanatory and the response
+	// variable. They must have the same length.
+	X, Y []float64
+	// WX and WY are the weights of the errors of the observations. If WX
+	// or WY is nil, the corresponding weights are 1. The weights must be
+	// positive.
+	WX, WY []float64
+
+	// Model returns f(x; β). Model must not modify beta.
+	Model func(x float64, beta []float64) float64
+	// Derivative stores the derivative of f with respect to β at (x; β) in
+	// dbeta and returns the derivative of f with respect to x. Derivative
+	// must not modify beta.
+	Derivative func(x float64, beta, dbeta []float64) float64
+}
+
+// ODRResult is the solution of an ODRProblem.
+type ODRResult struct {
+	// Beta are the estimated parameters of the model.
+	Beta []float64
+	// Delta are the estimated errors of the explanatory variables. The
+	// fitted explanatory variables are X_i + Delta_i.
+	Delta []float64
+	// SumSquares is the weighted sum of squares at the solution.
+	SumSquares float64
+	// Iterations is the number of iterations.
+	Iterations int
+}
+
+// ODR solves orthogonal distance regression problems with the
+// Levenberg-Marquardt method of Boggs, Byrd and Schnabel used by ODRPACK.
+// The method treats β and δ as the variables of a single nonlinear
+// least-squares problem, but exploits the structure of its Jacobian: every
+// error δ_i enters only the residuals of the i-th observation, so the
+// Levenberg-Marquardt step for δ can be eliminated point by point, and only
+// a linear system with the dimension of β has to be solved. The cost of an
+// iteration is therefore linear in the number of observations, while a
+// general least-squares method applied to the same problem would work with
+// a Jacobian whose number of columns grows with it.
+//
+// References:
+//  - Boggs, P.T., Byrd, R.H., Schnabel, R.B.: A stable and efficient
+//    algorithm for nonlinear orthogonal distance regression. SIAM J. Sci.
+//    Stat. Comput. 8 (1987), 1052-1078
+type ODR struct {
+	// SumSquaresTolerance is the tolerance on the relative reduction of the
+	// sum of squares. If SumSquaresTolerance is zero, it will be set to the
+	// square root of the machine epsilon.
+	SumSquaresTolerance float64
+	// ParameterTolerance is the tolerance on the relative change of the
+	// parameters and the errors. If ParameterTolerance is zero, it will be
+	// set to the machine epsilon to the power of 2/3.
+	ParameterTolerance float64
+	// MaxIterations is the maximum number of iterations. If MaxIterations
+	// is zero, it will be set to 100.
+	MaxIterations int
+
+	r, s  []float64 // Weighted residuals of the response and explanatory variables.
+	a, c  []float64 // Derivatives of r_i and s_i with respect to δ_i.
+	g     []float64 // Derivatives of r with respect to β, row-major.
+	step  []float64 // Step in β followed by the step in δ.
+	beta  []float64 // Trial parameters.
+	trial []float64 // Trial errors.
+	rhs   []float64
+	sys   *mat64.SymDense
+	chol  *mat64.TriDense
+}
+
+// Solve returns the solution of the orthogonal distance regression problem
+// starting from the parameters beta and zero errors δ. If the iteration limit
+// is reached, Solve returns the best solution found together with
+// ErrIterationLimit.
+func (o *ODR) Solve(p *ODRProblem, beta []float64) (*ODRResult, error) {
+	m := len(p.X)
+	if len(p.Y) != m {
+		panic("optimize: mismatched length of X and Y")
+	}
+	if (p.WX != nil && len(p.WX) != m) || (p.WY != nil && len(p.WY) != m) {
+		panic("optimize: mismatched length of weights")
+	}
+	if p.Model == nil || p.Derivative == nil {
+		panic("optimize: ODR model is undefined")
+	}
+	n := len(beta)
+	if n == 0 {
+		panic("optimize: no parameters")
+	}
+	sstol := o.SumSquaresTolerance
+	if sstol == 0 {
+		sstol = math.Sqrt(odrEps)
+	}
+	partol := o.ParameterTolerance
+	if partol == 0 {
+		partol = math.Pow(odrEps, 2.0/3)
+	}
+	maxIter := o.MaxIterations
+	if maxIter == 0 {
+		maxIter = 100
+	}
+
+	o.r = resize(o.r, m)
+	o.s = resize(o.s, m)
+	o.a = resize(o.a, m)
+	o.c = resize(o.c, m)
+	o.g = resize(o.g, m*n)
+	o.step = resize(o.step, n+m)
+	o.beta = resize(o.beta, n)
+	o.trial = resize(o.trial, m)
+	o.rhs = resize(o.rhs, n)
+	o.sys = resizeSymDense(o.sys, n)
+	o.chol = resizeTriDense(o.chol, n)
+
+	res := &ODRResult{
+		Beta:  make([]float64, n),
+		Delta: make([]float64, m),
+	}
+	copy(res.Beta, beta)
+	res.SumSquares = o.residuals(p, res.Beta, res.Delta)
+
+	var lambda float64
+	nu := 2.0
+	for {
+		if res.SumSquares == 0 {
+			return res, nil
+		}
+		if res.Iterations == maxIter {
+			return res, ErrIterationLimit
+		}
+		res.Iterations++
+		o.jacobian(p, res.Beta, res.Delta)
+		if lambda == 0 {
+			// Initialize the damping relative to the largest diagonal
+			// element of the normal equations.
+			for i := 0; i < m; i++ {
+				gi := o.g[i*n : (i+1)*n]
+				lambda = math.Max(lambda, floats.Dot(gi, gi))
+				lambda = math.Max(lambda, o.a[i]*o.a[i]+o.c[i]*o.c[i])
+			}
+			lambda *= defaultLevMarDamping
+			if lambda == 0 {
+				lambda = defaultLevMarDamping
+			}
+		}
+
+		// Find a step that reduces the sum of squares, increasing the
+		// damping until the step is accepted.
+		for {
+			pred := o.computeStep(lambda)
+			dBeta := o.step[:n]
+			dDelta := o.step[n:]
+			floats.AddTo(o.beta, res.Beta, dBeta)
+			floats.AddTo(o.trial, res.Delta, dDelta)
+			f := o.residuals(p, o.beta, o.trial)
+			actual := res.SumSquares - f
+			rho := actual / pred
+			if pred <= 0 || math.IsNaN(f) || rho <= 0 {
+				// The step has been rejected. If the predicted reduction is
+				// below the rounding errors, the sum of squares cannot be
+				// reduced further.
+				if pred <= odrEps*res.SumSquares {
+					return res, nil
+				}
+				// Restore the residuals and increase the damping.
+				o.residuals(p, res.Beta, res.Delta)
+				lambda *= nu
+				nu *= 2
+				if math.IsInf(lambda, 1) || lambda > 1/odrEps*math.Max(1, res.SumSquares) {
+					return res, ErrNoProgress
+				}
+				continue
+			}
+			lambda *= math.Max(1.0/3, 1-math.Pow(2*rho-1, 3))
+			nu = 2
+
+			stepNorm := math.Hypot(floats.Norm(dBeta, 2), floats.Norm(dDelta, 2))
+			xNorm := math.Hypot(floats.Norm(res.Beta, 2), floats.Norm(res.Delta, 2))
+			old := res.SumSquares
+			copy(res.Beta, o.beta)
+			copy(res.Delta, o.trial)
+			res.SumSquares = f
+			if (actual <= sstol*old && pred <= sstol*old && rho <= 2) || stepNorm <= partol*(xNorm+partol) {
+				return res, nil
+			}
+			break
+		}
+	}
+}
+
+// residuals evaluates the weighted residuals at beta and delta, stores them
+// in o.r and o.s, and returns the sum of squares.
+func (o *ODR) residuals(p *ODRProblem, beta, delta []float64) float64 {
+	var sum float64
+	for i, x := range p.X {
+		wx, wy := 1.0, 1.0
+		if p.WX != nil {
+			wx = p.WX[i]
+		}
+		if p.WY != nil {
+			wy = p.WY[i]
+		}
+		o.r[i] = math.Sqrt(wy) * (p.Model(x+delta[i], beta) - p.Y[i])
+		o.s[i] = math.Sqrt(wx) * delta[i]
+		sum += o.r[i]*o.r[i] + o.s[i]*o.s[i]
+	}
+	return sum
+}
+
+// jacobian evaluates the derivatives of the weighted residuals at beta and
+// delta.
+func (o *ODR) jacobian(p *ODRProblem, beta, delta []float64) {
+	for i, x := range p.X {
+		wx, wy := 1.0, 1.0
+		if p.WX != nil {
+			wx = p.WX[i]
+		}
+		if p.WY != nil {
+			wy = p.WY[i]
+		}
+		sw := math.Sqrt(wy)
+		n := len(beta)
+		gi := o.g[i*n : (i+1)*n]
+		o.a[i] = sw * p.Derivative(x+delta[i], beta, gi)
+		floats.Scale(sw, gi)
+		o.c[i] = math.Sqrt(wx)
+	}
+}
+
+// computeStep computes the Levenberg-Marquardt step with the damping
+// parameter lambda, stores it in o.step, and returns the predicted
+// reduction of the sum of squares.
+//
+// With u_i = r_i + g_iᵀ Δβ, the minimum of the damped model
+//  (u_i + a_i Δδ_i)^2 + (s_i + c_i Δδ_i)^2 + λ Δδ_i^2
+// over Δδ_i is attained at
+//  Δδ_i = -(a_i u_i + c_i s_i) / (a_i^2 + c_i^2 + λ),
+// and equals ω_i (u_i - t_i)^2 up to a constant, where
+//  ω_i = (c_i^2 + λ) / (a_i^2 + c_i^2 + λ),  t_i = a_i c_i s_i / (c_i^2 + λ).
+// The step Δβ thus solves
+//  (Σ_i ω_i g_i g_iᵀ + λ I) Δβ = -Σ_i ω_i (r_i - t_i) g_i.
+func (o *ODR) computeStep(lambda float64) (pred float64) {
+	m := len(o.r)
+	n := len(o.rhs)
+	for j := 0; j < n; j++ {
+		o.rhs[j] = 0
+		for k := j; k < n; k++ {
+			o.sys.SetSym(j, k, 0)
+		}
+	}
+	for i := 0; i < m; i++ {
+		a, c := o.a[i], o.c[i]
+		q := a*a + c*c + lambda
+		omega := (c*c + lambda) / q
+		t := a * c * o.s[i] / (c*c + lambda)
+		gi := o.g[i*n : (i+1)*n]
+		o.sys.SymRankOne(o.sys, omega, gi)
+		floats.AddScaled(o.rhs, -omega*(o.r[i]-t), gi)
+	}
+	for j := 0; j < n; j++ {
+		o.sys.SetSym(j, j, o.sys.At(j, j)+lambda)
+	}
+	dBeta := o.step[:n]
+	if !o.chol.Cholesky(o.sys, true) {
+		// The damped system is positive definite in exact arithmetic.
+		// Fall back to a scaled gradient step.
+		copy(dBeta, o.rhs)
+		floats.Scale(1/lambda, dBeta)
+	} else {
+		mat64.NewVector(n, dBeta).SolveCholeskyVec(o.chol, mat64.NewVector(n, o.rhs))
+	}
+
+	// Recover the step in δ and evaluate the undamped model.
+	dDelta := o.step[n:]
+	var model, current float64
+	for i := 0; i < m; i++ {
+		a, c := o.a[i], o.c[i]
+		u := o.r[i] + floats.Dot(o.g[i*n:(i+1)*n], dBeta)
+		dDelta[i] = -(a*u + c*o.s[i]) / (a*a + c*c + lambda)
+		rr := u + a*dDelta[i]
+		ss := o.s[i] + c*dDelta[i]
+		model += rr*rr + ss*ss
+		current += o.r[i]*o.r[i] + o.s[i]*o.s[i]
+	}
+	return current - model
+}
@@ -0,0 +1,145 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+var (
+	odrX = []float64{0.1, 1.2, 1.9, 3.1, 4.2, 4.8, 6.1, 7.0, 7.9, 9.2}
+	odrY = []float64{1.3, 2.9, 5.2, 6.8, 9.6, 10.4, 13.5, 14.7, 17.2, 19.1}
+)
+
+func lineProblem(wx, wy []float64) *ODRProblem {
+	return &ODRProblem{
+		X:  odrX,
+		Y:  odrY,
+		WX: wx,
+		WY: wy,
+		Model: func(x float64, beta []float64) float64 {
+			return beta[0] + beta[1]*x
+		},
+		Derivative: func(x float64, beta, dbeta []float64) float64 {
+			dbeta[0] = 1
+			dbeta[1] = x
+			return beta[1]
+		},
+	}
+}
+
+func TestODRLine(t *testing.T) {
+	n := float64(len(odrX))
+	mx := floats.Sum(odrX) / n
+	my := floats.Sum(odrY) / n
+	var sxx, syy, sxy float64
+	for i, x := range odrX {
+		dx := x - mx
+		dy := odrY[i] - my
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	}
+
+	// With equal weights, the solution is the total least-squares line.
+	slope := (syy - sxx + math.Sqrt((syy-sxx)*(syy-sxx)+4*sxy*sxy)) / (2 * sxy)
+	want := []float64{my - slope*mx, slope}
+	o := ODR{SumSquaresTolerance: 1e-14}
+	result, err := o.Solve(lineProblem(nil, nil), []float64{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !floats.EqualApprox(result.Beta, want, 1e-8) {
+		t.Errorf("unexpected total least-squares line. Want %v, got %v", want, result.Beta)
+	}
+	for i, x := range odrX {
+		// The fitted points are the orthogonal projections of the
+		// observations onto the line.
+		xp := x + result.Delta[i]
+		yp := result.Beta[0] + result.Beta[1]*xp
+		if d := (xp-x)*1 + (yp-odrY[i])*result.Beta[1]; math.Abs(d) > 1e-8 {
+			t.Errorf("fitted point %d is not an orthogonal projection: %v", i, d)
+		}
+	}
+
+	// With negligible errors in x, the solution is the ordinary
+	// least-squares line.
+	slope = sxy / sxx
+	want = []float64{my - slope*mx, slope}
+	wx := make([]float64, len(odrX))
+	for i := range wx {
+		wx[i] = 1e12
+	}
+	result, err = o.Solve(lineProblem(wx, nil), []float64{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !floats.EqualApprox(result.Beta, want, 1e-6) {
+		t.Errorf("unexpected ordinary least-squares line. Want %v, got %v", want, result.Beta)
+	}
+}
+
+func TestODRNonlinear(t *testing.T) {
+	beta := []float64{2, -0.3, 0.5}
+	x := make([]float64, 12)
+	y := make([]float64, len(x))
+	wx := make([]float64, len(x))
+	wy := make([]float64, len(x))
+	for i := range x {
+		x[i] = 0.5*float64(i) + 0.05*math.Sin(float64(5*i))
+		t := 0.5 * float64(i)
+		y[i] = beta[0]*math.Exp(beta[1]*t) + beta[2] + 0.01*math.Cos(float64(7*i))
+		wx[i] = 1 / (0.05 * 0.05)
+		wy[i] = 1 / (0.01 * 0.01 * float64(1+i%3))
+	}
+	p := &ODRProblem{
+		X:  x,
+		Y:  y,
+		WX: wx,
+		WY: wy,
+		Model: func(x float64, beta []float64) float64 {
+			return beta[0]*math.Exp(beta[1]*x) + beta[2]
+		},
+		Derivative: func(x float64, beta, dbeta []float64) float64 {
+			e := math.Exp(beta[1] * x)
+			dbeta[0] = e
+			dbeta[1] = beta[0] * x * e
+			dbeta[2] = 1
+			return beta[0] * beta[1] * e
+		},
+	}
+	var o ODR
+	result, err := o.Solve(p, []float64{1, -1, 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !floats.EqualApprox(result.Beta, beta, 0.05) {
+		t.Errorf("unexpected parameters. Want %v, got %v", beta, result.Beta)
+	}
+
+	// Check the first-order optimality conditions.
+	grad := make([]float64, len(beta))
+	dbeta := make([]float64, len(beta))
+	var sum float64
+	for i := range x {
+		xi := x[i] + result.Delta[i]
+		r := p.Model(xi, result.Beta) - y[i]
+		dx := p.Derivative(xi, result.Beta, dbeta)
+		floats.AddScaled(grad, wy[i]*r, dbeta)
+		if g := wy[i]*r*dx + wx[i]*result.Delta[i]; math.Abs(g) > 1e-6*wy[i] {
+			t.Errorf("unexpected gradient with respect to δ_%d: %v", i, g)
+		}
+		sum += wy[i]*r*r + wx[i]*result.Delta[i]*result.Delta[i]
+	}
+	if floats.Norm(grad, math.Inf(1)) > 1e-6*floats.Max(wy) {
+		t.Errorf("unexpected gradient with respect to β: %v", grad)
+	}
+	if math.Abs(sum-result.SumSquares) > 1e-12*sum {
+		t.Errorf("mismatched sum of squares. Want %v, got %v", sum, result.SumSquares)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"sort"
+)
+
+// MultiObjectiveProblem describes a problem with several objective functions
+// that are minimized simultaneously subject to bound constraints. In general
+// no location minimizes all objectives, and the solution is the set of
+// Pareto-optimal locations, at which no objective can be decreased without
+// increasing another.
+type MultiObjectiveProblem struct {
+	// Func evaluates the objective functions at x and stores their values
+	// in dst, which has length Objectives. Func must not modify x.
+	Func func(x, dst []float64)
+	// Objectives is the number of objective functions.
+	Objectives int
+	// Bounds are the bounds of the variables, which must be finite. The
+	// dimension of the problem is the length of Bounds.Lower.
+	Bounds *Bounds
+}
+
+// ParetoResult is an approximation of the set of Pareto-optimal locations of
+// a MultiObjectiveProblem.
+type ParetoResult struct {
+	// X are the non-dominated locations, in increasing order of their first
+	// objective, and F are their objective values.
+	X [][]float64
+	F [][]float64
+	// FuncEvaluations is the number of evaluations of the objective
+	// functions.
+	FuncEvaluations int
+}
+
+// dominates returns whether the objective values a dominate b, that is,
+// whether a is not larger than b in any objective and smaller in at least
+// one. NaN values are dominated by all other values.
+func dominates(a, b []float64) bool {
+	var better bool
+	for i, v := range a {
+		w := b[i]
+		switch {
+		case math.IsNaN(v) && !math.IsNaN(w), v > w:
+			return false
+		case math.IsNaN(w) && !math.IsNaN(v), v < w:
+			better = true
+		}
+	}
+	return better
+}
+
+// ParetoFront returns the indices of the objective values in f that are not
+// dominated by any other values in f, in increasing order.
+func ParetoFront(f [][]float64) []int {
+	var front []int
+	for i, fi := range f {
+		dominated := false
+		for j, fj := range f {
+			if j != i && dominates(fj, fi) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front = append(front, i)
+		}
+	}
+	return front
+}
+
+// Hypervolume returns the volume of the region of objective space that is
+// dominated by the objective values in front and bounded by the reference
+// point ref, the standard measure of the quality of an approximation of a
+// Pareto front. Values that are not below ref in every objective do not
+// contribute. The cost grows exponentially with the number of objectives, so
+// Hypervolume is intended for a small number of them.
+//
+// References:
+//  - While, L., Hingston, P., Barone, L., Huband, S.: A faster algorithm for
+//    calculating hypervolume. IEEE Trans. Evol. Comput. 10 (2006), 29-38
+func Hypervolume(front [][]float64, ref []float64) float64 {
+	var pts [][]float64
+outer:
+	for _, f := range front {
+		if len(f) != len(ref) {
+			panic("optimize: objective size mismatch")
+		}
+		for i, v := range f {
+			if !(v < ref[i]) {
+				continue outer
+			}
+		}
+		pts = append(pts, f)
+	}
+	return hypervolume(pts, ref, len(ref))
+}
+
+// hypervolume returns the hypervolume of the points in the first m
+// objectives by slicing along objective m-1.
+func hypervolume(pts [][]float64, ref []float64, m int) float64 {
+	if len(pts) == 0 {
+		return 0
+	}
+	if m == 1 {
+		lowest := ref[0]
+		for _, p := range pts {
+			lowest = math.Min(lowest, p[0])
+		}
+		return ref[0] - lowest
+	}
+	sorted := make([][]float64, len(pts))
+	copy(sorted, pts)
+	k := m - 1
+	sort.Sort(byObjective{sorted, k})
+	var vol float64
+	for i, p := range sorted {
+		next := ref[k]
+		if i+1 < len(sorted) {
+			next = sorted[i+1][k]
+		}
+		if next > p[k] {
+			vol += hypervolume(sorted[:i+1], ref, k) * (next - p[k])
+		}
+	}
+	return vol
+}
+
+// byObjective sorts objective values by objective k.
+type byObjective struct {
+	f [][]float64
+	k int
+}
+
+func (b byObjective) Len() int           { return len(b.f) }
+func (b byObjective) Less(i, j int) bool { return b.f[i][b.k] < b.f[j][b.k] }
+func (b byObjective) Swap(i, j int)      { b.f[i], b.f[j] = b.f[j], b.f[i] }
+
+// KneePoint returns the index of the knee point of the Pareto front given by
+// the objective values in front, the point at which improving one objective
+// starts to cost a large deterioration of the others. Every objective is
+// normalized to [0, 1] over the front, and the knee point is the point with
+// the lowest sum of normalized objectives, which is the point farthest from
+// the hyperplane through the extreme points of the front below it. KneePoint
+// panics if front is empty.
+//
+// References:
+//  - Das, I.: On characterizing the "knee" of the Pareto curve based on
+//    normal-boundary intersection. Struct. Optim. 18 (1999), 107-115
+func KneePoint(front [][]float64) int {
+	if len(front) == 0 {
+		panic("optimize: empty Pareto front")
+	}
+	m := len(front[0])
+	lo := make([]float64, m)
+	hi := make([]float64, m)
+	for k := range lo {
+		lo[k] = math.Inf(1)
+		hi[k] = math.Inf(-1)
+	}
+	for _, f := range front {
+		for k, v := range f {
+			lo[k] = math.Min(lo[k], v)
+			hi[k] = math.Max(hi[k], v)
+		}
+	}
+	best := 0
+	bestSum := math.Inf(1)
+	for i, f := range front {
+		var sum float64
+		for k, v := range f {
+			if hi[k] > lo[k] {
+				sum += (v - lo[k]) / (hi[k] - lo[k])
+			}
+		}
+		if sum < bestSum {
+			best, bestSum = i, sum
+		}
+	}
+	return best
+}
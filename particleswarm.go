@@ -0,0 +1,306 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultPSOInertia      = 0.7298
+	defaultPSOAcceleration = 1.49618
+	// defaultPSOConstrictionAcceleration is the acceleration coefficient of
+	// the constriction variant. It gives the constriction factor 0.7298,
+	// which makes both variants equivalent with the default parameters.
+	defaultPSOConstrictionAcceleration = 2.05
+)
+
+// PSOVariant specifies the velocity update of ParticleSwarm. In the formulas
+// below, x and v are the position and the velocity of a particle, p is the
+// best position found by the particle, g is the best position found by the
+// swarm, and r_1, r_2 are vectors of independent uniform random numbers in
+// [0, 1), multiplied with the differences componentwise.
+type PSOVariant int
+
+const (
+	// PSOInertia is the update with the inertia weight w,
+	//  v = w v + c_1 r_1 (p - x) + c_2 r_2 (g - x).
+	PSOInertia PSOVariant = iota
+	// PSOConstriction is the update with the constriction factor of Clerc
+	// and Kennedy,
+	//  v = χ (v + c_1 r_1 (p - x) + c_2 r_2 (g - x)),
+	//  χ = 2 / |2 - φ - sqrt(φ^2 - 4φ)|,  φ = c_1 + c_2 > 4.
+	PSOConstriction
+)
+
+// psoStage is the stage of ParticleSwarm.
+type psoStage int
+
+const (
+	psoInitialize psoStage = iota // Evaluating the initial swarm.
+	psoMove                       // Evaluating the moved particles.
+)
+
+// ParticleSwarm implements particle swarm optimization for gradient-free
+// global optimization. A swarm of particles moves through the search space.
+// Every particle is attracted to the best position it has found so far and
+// to the best position found by the whole swarm, according to the velocity
+// update of Variant.
+//
+// The initial swarm contains the initial location. The other particles are
+// placed uniformly at random, from the bounds of the problem along
+// coordinates where both bounds are finite, and from the interval of
+// half-width InitialRadius around the initial location along the others.
+// If VelocityClamp is positive, every velocity component is limited to
+// VelocityClamp times the width of this initial interval. A particle that
+// leaves the bounds is stopped at the bound and the corresponding velocity
+// component is set to zero, so all evaluated locations are feasible.
+//
+// Each generation, in which all particles move once, is a major iteration.
+// ParticleSwarm does not converge in the sense of a vanishing gradient, and
+// the run should be terminated by Settings.FunctionConverge or by the
+// evaluation limits.
+//
+// The particles are evaluated sequentially, one particle per call to
+// Iterate.
+//
+// References:
+//  - Kennedy, J., Eberhart, R.: Particle swarm optimization. In Proc. IEEE
+//    International Conference on Neural Networks (1995), 1942-1948
+//  - Clerc, M., Kennedy, J.: The particle swarm - explosion, stability, and
+//    convergence in a multidimensional complex space. IEEE Trans. Evol.
+//    Comput. 6 (2002), 58-73
+type ParticleSwarm struct {
+	// SwarmSize is the number of particles. If SwarmSize is zero, it will be
+	// set to 10 + ⌊2 sqrt(n)⌋, where n is the dimension of the problem.
+	// ParticleSwarm panics if SwarmSize is nonzero and smaller than 2.
+	SwarmSize int
+	// Variant is the velocity update.
+	Variant PSOVariant
+	// Inertia is the inertia weight w of PSOInertia. It is not used by
+	// PSOConstriction. If Inertia is zero, it will be set to 0.7298.
+	Inertia float64
+	// Cognitive and Social are the acceleration coefficients c_1 and c_2. If
+	// they are zero, they will be set to 1.49618 for PSOInertia and to 2.05
+	// for PSOConstriction. ParticleSwarm panics if they are negative, or if
+	// their sum is not larger than 4 for PSOConstriction.
+	Cognitive float64
+	Social    float64
+	// VelocityClamp is the largest velocity relative to the width of the
+	// initial region. If VelocityClamp is zero, the velocity is not
+	// clamped.
+	VelocityClamp float64
+	// InitialRadius is the half-width of the interval around the initial
+	// location in which the initial swarm is placed along coordinates that
+	// are not bounded on both sides. If InitialRadius is zero, it will be
+	// set to 1.
+	InitialRadius float64
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+
+	rnd    *rand.Rand
+	bounds *Bounds
+
+	inertia   float64
+	factor    float64 // Constriction factor, 1 for PSOInertia.
+	cognitive float64
+	social    float64
+	maxVel    []float64
+
+	stage psoStage
+	idx   int // Index of the particle that is evaluated.
+	best  int // Index of the particle with the best position of the swarm.
+
+	x  [][]float64 // Positions.
+	v  [][]float64 // Velocities.
+	p  [][]float64 // Best positions of the particles.
+	pF []float64   // Function values at the best positions.
+}
+
+func (ps *ParticleSwarm) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if ps.SwarmSize == 0 {
+		ps.SwarmSize = 10 + int(2*math.Sqrt(float64(dim)))
+	}
+	if ps.SwarmSize < 2 {
+		panic("pso: swarm size must be at least 2")
+	}
+	if ps.InitialRadius == 0 {
+		ps.InitialRadius = 1
+	}
+	if ps.InitialRadius < 0 {
+		panic("pso: initial radius must be positive")
+	}
+	if ps.VelocityClamp < 0 {
+		panic("pso: negative velocity clamp")
+	}
+	ps.cognitive, ps.social = ps.Cognitive, ps.Social
+	switch ps.Variant {
+	case PSOInertia:
+		ps.inertia = ps.Inertia
+		if ps.inertia == 0 {
+			ps.inertia = defaultPSOInertia
+		}
+		if ps.cognitive == 0 {
+			ps.cognitive = defaultPSOAcceleration
+		}
+		if ps.social == 0 {
+			ps.social = defaultPSOAcceleration
+		}
+		ps.factor = 1
+	case PSOConstriction:
+		ps.inertia = 1
+		if ps.cognitive == 0 {
+			ps.cognitive = defaultPSOConstrictionAcceleration
+		}
+		if ps.social == 0 {
+			ps.social = defaultPSOConstrictionAcceleration
+		}
+		phi := ps.cognitive + ps.social
+		if phi <= 4 {
+			panic("pso: sum of acceleration coefficients must be larger than 4 with constriction")
+		}
+		ps.factor = 2 / math.Abs(2-phi-math.Sqrt(phi*phi-4*phi))
+	default:
+		panic("pso: unknown variant")
+	}
+	if ps.cognitive < 0 || ps.social < 0 {
+		panic("pso: negative acceleration coefficient")
+	}
+	ps.rnd = newRand(ps.Src, p.Src)
+	ps.bounds = p.Bounds
+	if ps.bounds == nil {
+		ps.bounds = &Bounds{}
+	}
+
+	n := ps.SwarmSize
+	ps.x = resizeMembers(ps.x, n, dim)
+	ps.v = resizeMembers(ps.v, n, dim)
+	ps.p = resizeMembers(ps.p, n, dim)
+	ps.pF = resize(ps.pF, n)
+	ps.maxVel = resize(ps.maxVel, dim)
+
+	for j := range ps.maxVel {
+		l, u := initialRegion(ps.bounds, loc.X, j, ps.InitialRadius)
+		ps.maxVel[j] = math.Inf(1)
+		if ps.VelocityClamp > 0 {
+			ps.maxVel[j] = ps.VelocityClamp * (u - l)
+		}
+		for i := range ps.x {
+			x := loc.X[j]
+			if i > 0 {
+				x = l + (u-l)*ps.rnd.Float64()
+			}
+			ps.x[i][j] = x
+			// The initial velocity is half the distance to a random
+			// location in the initial region.
+			ps.v[i][j] = ps.clamp(j, (l+(u-l)*ps.rnd.Float64()-x)/2)
+		}
+	}
+	for i := range ps.p {
+		copy(ps.p[i], ps.x[i])
+	}
+	ps.pF[0] = loc.F
+
+	ps.stage = psoInitialize
+	ps.idx = 1
+	ps.best = 0
+	copy(xNext, ps.x[1])
+	return FuncEvaluation, InitIteration, nil
+}
+
+func (ps *ParticleSwarm) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	n := len(ps.x)
+	if ps.stage == psoInitialize {
+		ps.pF[ps.idx] = loc.F
+		ps.idx++
+		if ps.idx < n {
+			copy(xNext, ps.x[ps.idx])
+			return FuncEvaluation, InitIteration, nil
+		}
+		ps.stage = psoMove
+		return ps.newGeneration(xNext)
+	}
+
+	if loc.F <= ps.pF[ps.idx] || math.IsNaN(ps.pF[ps.idx]) {
+		copy(ps.p[ps.idx], loc.X)
+		ps.pF[ps.idx] = loc.F
+	}
+	ps.idx++
+	if ps.idx < n {
+		copy(xNext, ps.x[ps.idx])
+		return FuncEvaluation, MinorIteration, nil
+	}
+	return ps.newGeneration(xNext)
+}
+
+// newGeneration updates the best position of the swarm, starts a new
+// generation by moving all particles and stores the new position of the
+// first particle into xNext.
+func (ps *ParticleSwarm) newGeneration(xNext []float64) (EvaluationType, IterationType, error) {
+	for i, f := range ps.pF {
+		if f < ps.pF[ps.best] || math.IsNaN(ps.pF[ps.best]) {
+			ps.best = i
+		}
+	}
+	for i := range ps.x {
+		ps.move(i)
+	}
+	ps.idx = 0
+	copy(xNext, ps.x[0])
+	return FuncEvaluation, MajorIteration, nil
+}
+
+// Batch returns the positions of the particles of the current generation
+// that have not been evaluated yet.
+func (ps *ParticleSwarm) Batch() (EvaluationType, [][]float64) {
+	return FuncEvaluation, ps.x[ps.idx:]
+}
+
+// move updates the velocity and the position of the i-th particle.
+func (ps *ParticleSwarm) move(i int) {
+	x, v, p, g := ps.x[i], ps.v[i], ps.p[i], ps.p[ps.best]
+	for j := range x {
+		vj := ps.inertia*v[j] +
+			ps.cognitive*ps.rnd.Float64()*(p[j]-x[j]) +
+			ps.social*ps.rnd.Float64()*(g[j]-x[j])
+		vj = ps.clamp(j, ps.factor*vj)
+		xj := x[j] + vj
+		if l := ps.bounds.lower(j); xj < l {
+			xj = l
+			vj = 0
+		}
+		if u := ps.bounds.upper(j); xj > u {
+			xj = u
+			vj = 0
+		}
+		x[j] = xj
+		v[j] = vj
+	}
+}
+
+// clamp limits the j-th velocity component v to the maximum velocity.
+func (ps *ParticleSwarm) clamp(j int, v float64) float64 {
+	return math.Max(-ps.maxVel[j], math.Min(v, ps.maxVel[j]))
+}
+
+func (*ParticleSwarm) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// HandlesBounds returns true. All locations evaluated by ParticleSwarm are
+// feasible.
+func (*ParticleSwarm) HandlesBounds() bool {
+	return true
+}
@@ -0,0 +1,84 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestParticleSwarm(t *testing.T) {
+	bounds := &Bounds{
+		Lower: []float64{-5.12, -5.12},
+		Upper: []float64{5.12, 5.12},
+	}
+	for _, test := range []struct {
+		name   string
+		method func() *ParticleSwarm
+	}{
+		{"Inertia", func() *ParticleSwarm { return &ParticleSwarm{} }},
+		{"Constriction", func() *ParticleSwarm { return &ParticleSwarm{Variant: PSOConstriction} }},
+		{"Clamped", func() *ParticleSwarm { return &ParticleSwarm{VelocityClamp: 0.2} }},
+	} {
+		var infeasible bool
+		p := Problem{
+			Func: func(x []float64) float64 {
+				if !bounds.Feasible(x) {
+					infeasible = true
+				}
+				return rastrigin(x)
+			},
+			Bounds: bounds,
+		}
+		var results []*Result
+		for i := 0; i < 2; i++ {
+			settings := DefaultSettings()
+			settings.Recorder = nil
+			settings.FunctionConverge.Iterations = 100
+			method := test.method()
+			method.SwarmSize = 30
+			method.Src = rand.NewSource(1)
+			// Start close to a local minimum.
+			result, err := Local(p, []float64{3, -4}, settings, method)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+				break
+			}
+			if result.Status != FunctionConvergence {
+				t.Errorf("%s: unexpected status %v", test.name, result.Status)
+			}
+			if result.F > 1e-6 || !floats.EqualApprox(result.X, []float64{0, 0}, 1e-3) {
+				t.Errorf("%s: global minimum not found, got f(%v) = %v", test.name, result.X, result.F)
+			}
+			results = append(results, result)
+		}
+		if infeasible {
+			t.Errorf("%s: infeasible location evaluated", test.name)
+		}
+		if len(results) == 2 && (results[0].F != results[1].F || !floats.Equal(results[0].X, results[1].X)) {
+			t.Errorf("%s: different result with the same source", test.name)
+		}
+	}
+}
+
+func TestParticleSwarmUnbounded(t *testing.T) {
+	p := Problem{
+		Func: func(x []float64) float64 {
+			return (x[0]-1)*(x[0]-1) + 2*(x[1]+3)*(x[1]+3)
+		},
+	}
+	settings := DefaultSettings()
+	settings.Recorder = nil
+	method := &ParticleSwarm{Src: rand.NewSource(1)}
+	result, err := Local(p, []float64{0, 0}, settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !floats.EqualApprox(result.X, []float64{1, -3}, 1e-4) {
+		t.Errorf("unexpected solution. Want %v, got %v", []float64{1, -3}, result.X)
+	}
+}
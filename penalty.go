@@ -0,0 +1,263 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultPenalty              = 10
+	defaultPenaltyIncrease      = 10
+	defaultPenaltyConstraintTol = 1e-4
+	defaultPenaltyIterations    = 10
+)
+
+// PenaltyType is the type of the penalty term of Penalty.
+type PenaltyType int
+
+const (
+	// QuadraticPenalty penalizes the constraints by
+	//  ρ/2 (|c_E(x)|^2 + |max(0, c_I(x))|^2).
+	// The penalized function is smooth, but its minimizer violates the
+	// constraints by O(1/ρ).
+	QuadraticPenalty PenaltyType = iota
+	// ExactPenalty penalizes the constraints by
+	//  ρ (|c_E(x)|_1 + |max(0, c_I(x))|_1).
+	// The minimizer of the penalized function is the solution of the
+	// constrained problem once ρ exceeds the largest Lagrange multiplier,
+	// but the penalized function is not differentiable at the solution.
+	ExactPenalty
+)
+
+// Penalty is a driver that minimizes a Problem with nonlinear constraints by
+// unconstrained minimization of the penalized objective function
+//  f(x) + penalty(x),
+// where the penalty term is given by Type. The penalty parameter ρ is
+// increased by the factor Increase until the violation of the constraints
+// is below ConstraintTolerance, and every minimization is a local run that
+// starts at the solution of the previous one.
+//
+// Penalty is a simple alternative to AugmentedLagrangian when the
+// constraints need to be satisfied only approximately. It works with any
+// Method that handles the bounds of the Problem, if it has any, but large
+// penalty parameters make the penalized problems ill-conditioned.
+//
+// Reference:
+//  Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//  (2006), Sections 17.1 and 17.2
+type Penalty struct {
+	// Method returns the Method for a local run. It is called once for
+	// every local run. If Method is nil, the default Method of Local is
+	// used for QuadraticPenalty and NelderMead for ExactPenalty, whose
+	// penalized function is not smooth.
+	Method func() Method
+	// Type is the type of the penalty term.
+	Type PenaltyType
+	// Penalty is the initial penalty parameter ρ.
+	// If Penalty is zero, it will be set to 10.
+	Penalty float64
+	// Increase is the factor by which the penalty parameter is increased.
+	// It must be greater than one.
+	// If Increase is zero, it will be set to 10.
+	Increase float64
+	// ConstraintTolerance is the tolerance on the infinity norm of the
+	// violation of the constraints.
+	// If ConstraintTolerance is zero, it will be set to 1e-4.
+	ConstraintTolerance float64
+	// Iterations is the maximum number of local runs. If Iterations is
+	// zero, it will be set to 10.
+	Iterations int
+}
+
+// PenaltyResult is the result of Penalty.
+type PenaltyResult struct {
+	// X is the solution of the last local run, F is the value of the
+	// objective function at X without the penalty term, and
+	// ConstraintViolation is the infinity norm of the violation of the
+	// constraints at X.
+	X                   []float64
+	F                   float64
+	ConstraintViolation float64
+	// Penalty is the penalty parameter of the last local run.
+	Penalty float64
+	// Iterations is the number of local runs.
+	Iterations int
+	// Stats are the statistics summed over all local runs, except Runtime,
+	// which is the total wall-clock time.
+	Stats
+	// Status is MethodConverge if the violation of the constraints is below
+	// ConstraintTolerance, and IterationLimit otherwise.
+	Status Status
+}
+
+// Minimize minimizes the problem p with nonlinear constraints starting at
+// initX. If settings is nil, the default settings are used for the local
+// runs, which are independent calls to Local with a copy of the settings.
+// Minimize returns the error of a local run if it fails without any major
+// iteration. Minimize panics if settings.Maximize is true.
+func (pe *Penalty) Minimize(p Problem, initX []float64, settings *Settings) (*PenaltyResult, error) {
+	rho := pe.Penalty
+	if rho == 0 {
+		rho = defaultPenalty
+	}
+	if rho < 0 {
+		panic("optimize: negative penalty")
+	}
+	increase := pe.Increase
+	if increase == 0 {
+		increase = defaultPenaltyIncrease
+	}
+	if increase <= 1 {
+		panic("optimize: Increase must be greater than 1")
+	}
+	tol := pe.ConstraintTolerance
+	if tol == 0 {
+		tol = defaultPenaltyConstraintTol
+	}
+	if tol < 0 {
+		panic("optimize: negative constraint tolerance")
+	}
+	iterations := pe.Iterations
+	if iterations == 0 {
+		iterations = defaultPenaltyIterations
+	}
+	if iterations < 0 {
+		panic("optimize: negative number of iterations")
+	}
+	if pe.Type != QuadraticPenalty && pe.Type != ExactPenalty {
+		panic("optimize: unknown penalty type")
+	}
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	if settings.Maximize {
+		panic("optimize: maximization is not supported")
+	}
+
+	startTime := time.Now()
+	res := &PenaltyResult{
+		X:      make([]float64, len(initX)),
+		Status: IterationLimit,
+	}
+	copy(res.X, initX)
+	for res.Iterations < iterations {
+		res.Iterations++
+		s := *settings
+		if s.FunctionConverge != nil {
+			fc := *s.FunctionConverge
+			s.FunctionConverge = &fc
+		}
+		var method Method
+		if pe.Method != nil {
+			method = pe.Method()
+		} else if pe.Type == ExactPenalty {
+			method = &NelderMead{}
+		}
+		r, err := Local(pe.problem(p, rho), res.X, &s, method)
+		if r != nil {
+			res.MajorIterations += r.MajorIterations
+			res.FuncEvaluations += r.FuncEvaluations
+			res.GradEvaluations += r.GradEvaluations
+			res.HessEvaluations += r.HessEvaluations
+			res.HessVecEvaluations += r.HessVecEvaluations
+		}
+		if err != nil && (r == nil || r.MajorIterations == 0) {
+			res.Runtime = time.Since(startTime)
+			return res, err
+		}
+		// A local run that fails after some progress, usually because of
+		// the ill-conditioning of the penalized problem, is accepted.
+		copy(res.X, r.X)
+		res.Penalty = rho
+		res.ConstraintViolation = p.constraintViolation(res.X)
+		if res.ConstraintViolation <= tol {
+			res.Status = MethodConverge
+			break
+		}
+		rho *= increase
+	}
+	res.F = p.Func(res.X)
+	res.FuncEvaluations++
+	res.Runtime = time.Since(startTime)
+	return res, nil
+}
+
+// problem returns the unconstrained problem of minimizing the objective
+// function of p with the penalty term for the penalty parameter rho. The
+// returned problem has no Hessian.
+func (pe *Penalty) problem(p Problem, rho float64) Problem {
+	pp := Problem{
+		Status: p.Status,
+		Bounds: p.Bounds,
+		Prox:   p.Prox,
+	}
+	// weights returns the values of the constraints c at x, with the
+	// satisfied inequality constraints set to zero, and the weights of the
+	// rows of the Jacobian in the gradient of the penalty term.
+	weights := func(x []float64, c Constraint, ineq bool) (val, w []float64) {
+		val = make([]float64, c.Len())
+		w = make([]float64, c.Len())
+		c.Func(x, val)
+		for i, v := range val {
+			if ineq {
+				v = math.Max(0, v)
+				val[i] = v
+			}
+			switch pe.Type {
+			case QuadraticPenalty:
+				w[i] = rho * v
+			case ExactPenalty:
+				switch {
+				case v > 0:
+					w[i] = rho
+				case v < 0:
+					w[i] = -rho
+				}
+			}
+		}
+		return val, w
+	}
+	constraints := []struct {
+		c    Constraint
+		ineq bool
+	}{{p.Equality, false}, {p.Inequality, true}}
+	pp.Func = func(x []float64) float64 {
+		f := p.Func(x)
+		for _, con := range constraints {
+			if con.c == nil {
+				continue
+			}
+			val, _ := weights(x, con.c, con.ineq)
+			for _, v := range val {
+				switch pe.Type {
+				case QuadraticPenalty:
+					f += 0.5 * rho * v * v
+				case ExactPenalty:
+					f += rho * math.Abs(v)
+				}
+			}
+		}
+		return f
+	}
+	if p.Grad != nil {
+		pp.Grad = func(x, grad []float64) {
+			p.Grad(x, grad)
+			for _, con := range constraints {
+				if con.c == nil {
+					continue
+				}
+				_, w := weights(x, con.c, con.ineq)
+				jac := mat64.NewDense(con.c.Len(), len(x), nil)
+				con.c.Jac(x, jac)
+				addJacTVec(grad, jac, w)
+			}
+		}
+	}
+	return pp
+}
@@ -0,0 +1,52 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/optimize/functions"
+)
+
+func TestPenalty(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		f       functions.ConstrainedFunction
+		x       []float64
+		penalty *Penalty
+		tol     float64 // Tolerance of the function value.
+	}{
+		{"HS6", functions.HS6{}, []float64{-1.2, 1}, &Penalty{}, 1e-3},
+		{"HS28", functions.HS28{}, []float64{-4, 1, 1}, &Penalty{}, 1e-3},
+		{"HS43", functions.HS43{}, []float64{0, 0, 0, 0}, &Penalty{}, 1e-2},
+		{"HS6", functions.HS6{}, []float64{-1.2, 1}, &Penalty{Type: ExactPenalty}, 1e-3},
+		{"HS28", functions.HS28{}, []float64{-4, 1, 1}, &Penalty{Type: ExactPenalty}, 1e-3},
+		{"HS43", functions.HS43{}, []float64{0, 0, 0, 0}, &Penalty{Type: ExactPenalty, Method: func() Method { return &NelderMead{} }}, 1e-2},
+	} {
+		p := Problem{
+			Func:       test.f.Func,
+			Grad:       test.f.Grad,
+			Equality:   test.f.Equality(),
+			Inequality: test.f.Inequality(),
+		}
+		res, err := test.penalty.Minimize(p, test.x, nil)
+		if err != nil {
+			t.Errorf("%s, type %d: unexpected error: %v", test.name, test.penalty.Type, err)
+			continue
+		}
+		if res.Status != MethodConverge || res.ConstraintViolation > 1e-4 {
+			t.Errorf("%s, type %d: constraints not satisfied: status %v, violation %v",
+				test.name, test.penalty.Type, res.Status, res.ConstraintViolation)
+		}
+		want := test.f.KKTPoints()[0]
+		if math.Abs(res.F-want.F) > test.tol*math.Max(1, math.Abs(want.F)) {
+			t.Errorf("%s, type %d: unexpected minimum. Want %v, got %v", test.name, test.penalty.Type, want.F, res.F)
+		}
+		if res.F != test.f.Func(res.X) {
+			t.Errorf("%s, type %d: function value includes the penalty", test.name, test.penalty.Type)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultPowellLineTol = 1e-8
+	defaultPowellTol     = 1e-12
+)
+
+// powellStage is the stage of Powell.
+type powellStage int
+
+const (
+	powellLine    powellStage = iota // Line minimization along a direction of the set.
+	powellExtrap                     // Evaluating the extrapolated point of a cycle.
+	powellNewLine                    // Line minimization along the new direction.
+)
+
+// Powell implements Powell's conjugate direction method for gradient-free
+// optimization. Every cycle minimizes the function along each direction of
+// a set in turn, starting with the coordinate directions, and then along the
+// overall displacement of the cycle. The displacement replaces the direction
+// of the largest decrease in the set unless the heuristic test of Powell
+// predicts that this would make the directions linearly dependent. For a
+// quadratic function, the directions become mutually conjugate.
+//
+// The line minimizations bracket a minimum and locate it by Brent's method
+// up to the relative tolerance LineTolerance. Every cycle is a major
+// iteration, and Powell converges with MethodConverge status when a cycle
+// decreases the function value by a relative amount less than Tolerance.
+//
+// References:
+//  - Powell, M.J.D.: An efficient method for finding the minimum of a
+//    function of several variables without calculating derivatives. Comput.
+//    J. 7 (1964), 155-162
+//  - Press, W.H., Teukolsky, S.A., Vetterling, W.T., Flannery, B.P.:
+//    Numerical Recipes, 3rd ed. Cambridge University Press (2007), section 10.7
+type Powell struct {
+	// InitialStep is the length of the initial directions and the first
+	// step of the line minimizations along them. If InitialStep is zero, it
+	// will be set to 1.
+	InitialStep float64
+	// LineTolerance is the relative tolerance of the line minimizations. If
+	// LineTolerance is zero, it will be set to 1e-8.
+	LineTolerance float64
+	// Tolerance is the relative decrease of the function value in a cycle
+	// below which Powell converges. If Tolerance is zero, it will be set to
+	// 1e-12.
+	Tolerance float64
+
+	status Status
+	stage  powellStage
+	line   scalarMinimizer
+
+	dirs  [][]float64 // Set of directions.
+	dir   int         // Index of the current direction.
+	x     []float64   // Current location.
+	fx    float64
+	x0    []float64 // Location at the start of the cycle.
+	f0    float64
+	fLine float64 // Function value before the current line minimization.
+	del   float64 // Largest decrease of a line minimization in the cycle.
+	iBig  int     // Index of the direction with the largest decrease.
+	fExt  float64 // Function value at the extrapolated point.
+	newD  []float64
+	major bool // Whether the next evaluation starts a major iteration.
+}
+
+func (pw *Powell) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if pw.InitialStep == 0 {
+		pw.InitialStep = 1
+	}
+	if pw.InitialStep < 0 {
+		panic("powell: initial step must be positive")
+	}
+	if pw.LineTolerance == 0 {
+		pw.LineTolerance = defaultPowellLineTol
+	}
+	if pw.LineTolerance < 0 {
+		panic("powell: line tolerance must be positive")
+	}
+	if pw.Tolerance == 0 {
+		pw.Tolerance = defaultPowellTol
+	}
+	if pw.Tolerance < 0 {
+		panic("powell: tolerance must be positive")
+	}
+	pw.status = NotTerminated
+
+	pw.dirs = resizeMembers(pw.dirs, dim, dim)
+	for i, d := range pw.dirs {
+		for j := range d {
+			d[j] = 0
+		}
+		d[i] = pw.InitialStep
+	}
+	pw.x = resize(pw.x, dim)
+	pw.x0 = resize(pw.x0, dim)
+	pw.newD = resize(pw.newD, dim)
+	copy(pw.x, loc.X)
+	pw.fx = loc.F
+	return pw.startCycle(xNext)
+}
+
+func (pw *Powell) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch pw.stage {
+	case powellLine, powellNewLine:
+		t, done := pw.line.iterate(loc.F)
+		if !done {
+			return pw.evalLine(t, xNext)
+		}
+		return pw.finishLine(loc, xNext)
+	case powellExtrap:
+		pw.fExt = loc.F
+		return pw.endCycle(xNext)
+	}
+	panic("powell: unknown stage")
+}
+
+// startCycle starts a new cycle of line minimizations.
+func (pw *Powell) startCycle(xNext []float64) (EvaluationType, IterationType, error) {
+	copy(pw.x0, pw.x)
+	pw.f0 = pw.fx
+	pw.del = 0
+	pw.iBig = 0
+	pw.dir = 0
+	pw.major = true
+	return pw.startLine(powellLine, xNext)
+}
+
+// startLine starts the line minimization along the current direction.
+func (pw *Powell) startLine(stage powellStage, xNext []float64) (EvaluationType, IterationType, error) {
+	pw.stage = stage
+	pw.fLine = pw.fx
+	t := pw.line.init(pw.fx, 1, pw.LineTolerance)
+	return pw.evalLine(t, xNext)
+}
+
+// lineDir returns the direction of the current line minimization.
+func (pw *Powell) lineDir() []float64 {
+	if pw.stage == powellNewLine {
+		return pw.newD
+	}
+	return pw.dirs[pw.dir]
+}
+
+// evalLine stores the location at the step t along the current direction
+// into xNext.
+func (pw *Powell) evalLine(t float64, xNext []float64) (EvaluationType, IterationType, error) {
+	floats.AddScaledTo(xNext, pw.x, t, pw.lineDir())
+	iter := MinorIteration
+	if pw.major {
+		iter = MajorIteration
+		pw.major = false
+	}
+	return FuncEvaluation, iter, nil
+}
+
+// finishLine moves to the minimum of the finished line minimization and
+// continues the cycle.
+func (pw *Powell) finishLine(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	t, f := pw.line.min()
+	if f < pw.fx {
+		floats.AddScaled(pw.x, t, pw.lineDir())
+		pw.fx = f
+	}
+	if pw.stage == powellNewLine {
+		return pw.startCycle(xNext)
+	}
+	if dec := pw.fLine - pw.fx; dec > pw.del {
+		pw.del = dec
+		pw.iBig = pw.dir
+	}
+	pw.dir++
+	if pw.dir < len(pw.dirs) {
+		return pw.startLine(powellLine, xNext)
+	}
+
+	// The cycle along the directions of the set is finished.
+	if 2*(pw.f0-pw.fx) <= pw.Tolerance*(math.Abs(pw.f0)+math.Abs(pw.fx))+1e-25 {
+		pw.status = MethodConverge
+		copy(xNext, loc.X)
+		return NoEvaluation, MajorIteration, nil
+	}
+	// Evaluate the extrapolated point 2x - x0.
+	for i, v := range pw.x {
+		pw.newD[i] = v - pw.x0[i]
+		xNext[i] = v + pw.newD[i]
+	}
+	pw.stage = powellExtrap
+	return FuncEvaluation, MinorIteration, nil
+}
+
+// endCycle decides whether the displacement of the cycle replaces the
+// direction of the largest decrease.
+func (pw *Powell) endCycle(xNext []float64) (EvaluationType, IterationType, error) {
+	f0, fx, fe, del := pw.f0, pw.fx, pw.fExt, pw.del
+	if fe < f0 {
+		t := 2*(f0-2*fx+fe)*(f0-fx-del)*(f0-fx-del) - del*(f0-fe)*(f0-fe)
+		if t < 0 {
+			last := len(pw.dirs) - 1
+			pw.dirs[pw.iBig], pw.dirs[last] = pw.dirs[last], pw.dirs[pw.iBig]
+			copy(pw.dirs[last], pw.newD)
+			return pw.startLine(powellNewLine, xNext)
+		}
+	}
+	return pw.startCycle(xNext)
+}
+
+// Status returns MethodConverge when a cycle has not decreased the function
+// value by more than Tolerance.
+func (pw *Powell) Status() (Status, error) {
+	return pw.status, nil
+}
+
+func (*Powell) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
@@ -0,0 +1,30 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+// DiagonalPreconditioner is a Preconditioner with the diagonal matrix
+//  M = diag(Diag).
+// A good choice of Diag is the diagonal of the Hessian at a typical location,
+// or the squares of the inverse typical magnitudes of the variables. The
+// elements of Diag must be positive.
+type DiagonalPreconditioner struct {
+	Diag []float64
+}
+
+func (d DiagonalPreconditioner) Apply(dst, v []float64) {
+	if len(d.Diag) != len(v) || len(dst) != len(v) {
+		panic("optimize: preconditioner size mismatch")
+	}
+	for i, m := range d.Diag {
+		dst[i] = v[i] / m
+	}
+}
+
+// updatePreconditioner updates pc at loc if it is a PreconditionerUpdater.
+func updatePreconditioner(pc Preconditioner, loc *Location) {
+	if u, ok := pc.(PreconditionerUpdater); ok {
+		u.Update(loc)
+	}
+}
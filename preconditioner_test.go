@@ -0,0 +1,121 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// badlyScaled is the separable function
+//  f(x) = sum_i a_i (x_i^4/4 + x_i^2/2)
+// with a_i = 10^i, whose Hessian is diagonal with the condition number of
+// at least 10^(n-1).
+type badlyScaled struct{}
+
+func (badlyScaled) Func(x []float64) (f float64) {
+	for i, v := range x {
+		f += math.Pow(10, float64(i)) * (v*v*v*v/4 + v*v/2)
+	}
+	return f
+}
+
+func (badlyScaled) Grad(x, grad []float64) {
+	for i, v := range x {
+		grad[i] = math.Pow(10, float64(i)) * (v*v*v + v)
+	}
+}
+
+// hessDiagPreconditioner is the Jacobi preconditioner of badlyScaled.
+type hessDiagPreconditioner struct {
+	diag    []float64
+	updates int
+}
+
+func (h *hessDiagPreconditioner) Update(loc *Location) {
+	h.diag = resize(h.diag, len(loc.X))
+	for i, v := range loc.X {
+		h.diag[i] = math.Pow(10, float64(i)) * (3*v*v + 1)
+	}
+	h.updates++
+}
+
+func (h *hessDiagPreconditioner) Apply(dst, v []float64) {
+	DiagonalPreconditioner{h.diag}.Apply(dst, v)
+}
+
+func TestPreconditioner(t *testing.T) {
+	p := Problem{
+		Func: badlyScaled{}.Func,
+		Grad: badlyScaled{}.Grad,
+	}
+	x := []float64{1, -1, 1, -1, 1}
+	for _, test := range []struct {
+		name   string
+		method func(Preconditioner) Method
+	}{
+		{"GradientDescent", func(pc Preconditioner) Method {
+			return &GradientDescent{Preconditioner: pc}
+		}},
+		{"GradientDescentMomentum", func(pc Preconditioner) Method {
+			return &GradientDescent{Preconditioner: pc, Momentum: 0.5}
+		}},
+		{"CGFletcherReeves", func(pc Preconditioner) Method {
+			return &CG{Preconditioner: pc, Variant: &FletcherReeves{}}
+		}},
+		{"CGPolakRibierePolyak", func(pc Preconditioner) Method {
+			return &CG{Preconditioner: pc, Variant: &PolakRibierePolyak{}}
+		}},
+		{"CGHestenesStiefel", func(pc Preconditioner) Method {
+			return &CG{Preconditioner: pc, Variant: &HestenesStiefel{}}
+		}},
+		{"CGDaiYuan", func(pc Preconditioner) Method {
+			return &CG{Preconditioner: pc, Variant: &DaiYuan{}}
+		}},
+		{"CGHagerZhang", func(pc Preconditioner) Method {
+			return &CG{Preconditioner: pc, Variant: &HagerZhang{}}
+		}},
+		{"LBFGS", func(pc Preconditioner) Method {
+			return &LBFGS{Preconditioner: pc}
+		}},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.GradientThreshold = 1e-8
+		settings.MajorIterations = 10000
+
+		plain, err := Local(p, x, settings, test.method(nil))
+		if err != nil {
+			t.Errorf("%s: unexpected error without preconditioner: %v", test.name, err)
+			continue
+		}
+		for _, pc := range []Preconditioner{
+			DiagonalPreconditioner{[]float64{1, 10, 100, 1000, 10000}},
+			&hessDiagPreconditioner{},
+		} {
+			result, err := Local(p, x, settings, test.method(pc))
+			if err != nil {
+				t.Errorf("%s, %T: unexpected error: %v", test.name, pc, err)
+				continue
+			}
+			if result.Status != GradientThreshold {
+				t.Errorf("%s, %T: unexpected status %v", test.name, pc, result.Status)
+			}
+			if !floats.EqualApprox(result.X, make([]float64, len(x)), 1e-6) {
+				t.Errorf("%s, %T: unexpected minimum location %v", test.name, pc, result.X)
+			}
+			if result.MajorIterations >= plain.MajorIterations {
+				t.Errorf("%s, %T: preconditioning did not reduce iterations: %d >= %d",
+					test.name, pc, result.MajorIterations, plain.MajorIterations)
+			}
+			if h, ok := pc.(*hessDiagPreconditioner); ok && h.updates < result.MajorIterations {
+				t.Errorf("%s: preconditioner updated %d times in %d iterations",
+					test.name, h.updates, result.MajorIterations)
+			}
+		}
+	}
+}
@@ -104,3 +104,169 @@ func (p *Printer) Record(loc *Location, _ EvaluationType, iter IterationType, st
 	p.lastValue = time.Now()
 	return nil
 }
+
+// PrintColumn is a column printed by PrintRecorder.
+type PrintColumn int
+
+const (
+	// IterationColumn is the number of major iterations.
+	IterationColumn PrintColumn = iota
+	// FuncColumn is the function value.
+	FuncColumn
+	// GradientNormColumn is the infinity norm of the gradient. It is empty
+	// if the gradient is not used.
+	GradientNormColumn
+	// StepColumn is the 2-norm of the step from the previous major
+	// iteration.
+	StepColumn
+	// RuntimeColumn is the elapsed time.
+	RuntimeColumn
+	// FuncEvaluationsColumn, GradEvaluationsColumn and
+	// HessEvaluationsColumn are the numbers of evaluations.
+	FuncEvaluationsColumn
+	GradEvaluationsColumn
+	HessEvaluationsColumn
+)
+
+var printColumns = [...]struct {
+	heading string
+	width   int
+}{
+	IterationColumn:       {"Iter", 9},
+	FuncColumn:            {"f(x)", 14},
+	GradientNormColumn:    {"|∇f(x)|∞", 11},
+	StepColumn:            {"Step", 11},
+	RuntimeColumn:         {"Runtime", 14},
+	FuncEvaluationsColumn: {"FuncEvals", 9},
+	GradEvaluationsColumn: {"GradEvals", 9},
+	HessEvaluationsColumn: {"HessEvals", 9},
+}
+
+// DefaultPrintColumns are the columns printed by PrintRecorder if Columns is
+// nil.
+var DefaultPrintColumns = []PrintColumn{
+	IterationColumn,
+	FuncColumn,
+	GradientNormColumn,
+	StepColumn,
+	RuntimeColumn,
+}
+
+// PrintRecorder writes a human-readable table of the optimization progress,
+// one line per printed major iteration, similar to the iteration display of
+// other optimization packages. The initial location is always printed, and
+// the optimal location is printed after the optimization.
+type PrintRecorder struct {
+	// Writer is the destination of the output. If Writer is nil, os.Stdout
+	// is used.
+	Writer io.Writer
+	// PrintEvery is the number of major iterations between printed lines. If
+	// PrintEvery is zero, every major iteration is printed.
+	PrintEvery int
+	// HeadingInterval is the number of printed lines after which the
+	// headings are repeated. If HeadingInterval is zero, the headings are
+	// printed only once.
+	HeadingInterval int
+	// Columns are the printed columns. If Columns is nil, DefaultPrintColumns
+	// is used.
+	Columns []PrintColumn
+
+	lines int       // Number of lines since the last heading.
+	x     []float64 // Location of the last major iteration.
+	step  float64   // Norm of the last step.
+}
+
+func (p *PrintRecorder) Init() error {
+	if p.PrintEvery < 0 {
+		panic("optimize: negative PrintEvery")
+	}
+	for _, c := range p.Columns {
+		if c < 0 || int(c) >= len(printColumns) {
+			panic("optimize: unknown print column")
+		}
+	}
+	p.lines = -1
+	p.x = p.x[:0]
+	p.step = math.NaN()
+	return nil
+}
+
+func (p *PrintRecorder) Record(loc *Location, _ EvaluationType, iter IterationType, stats *Stats) error {
+	switch iter {
+	case InitIteration:
+	case MajorIteration:
+		if len(p.x) == len(loc.X) {
+			p.step = floats.Distance(loc.X, p.x, 2)
+		}
+		if p.PrintEvery > 1 && stats.MajorIterations%p.PrintEvery != 0 {
+			p.x = append(p.x[:0], loc.X...)
+			return nil
+		}
+	case PostIteration:
+		// The optimal location is not the result of a step.
+		p.step = math.NaN()
+	default:
+		return nil
+	}
+	p.x = append(p.x[:0], loc.X...)
+
+	w := p.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	columns := p.Columns
+	if columns == nil {
+		columns = DefaultPrintColumns
+	}
+
+	if p.lines < 0 || (p.HeadingInterval > 0 && p.lines >= p.HeadingInterval && iter != PostIteration) {
+		var headings []byte
+		for i, c := range columns {
+			if i > 0 {
+				headings = append(headings, "  "...)
+			}
+			headings = append(headings, fmt.Sprintf("%*s", printColumns[c].width, printColumns[c].heading)...)
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", headings); err != nil {
+			return err
+		}
+		p.lines = 0
+	}
+
+	var values []byte
+	for i, c := range columns {
+		if i > 0 {
+			values = append(values, "  "...)
+		}
+		width := printColumns[c].width
+		var v string
+		switch c {
+		case IterationColumn:
+			v = fmt.Sprint(stats.MajorIterations)
+		case FuncColumn:
+			v = fmt.Sprintf("%.6e", loc.F)
+		case GradientNormColumn:
+			if loc.Gradient != nil {
+				v = fmt.Sprintf("%.3e", floats.Norm(loc.Gradient, math.Inf(1)))
+			}
+		case StepColumn:
+			if !math.IsNaN(p.step) {
+				v = fmt.Sprintf("%.3e", p.step)
+			}
+		case RuntimeColumn:
+			v = stats.Runtime.String()
+		case FuncEvaluationsColumn:
+			v = fmt.Sprint(stats.FuncEvaluations)
+		case GradEvaluationsColumn:
+			v = fmt.Sprint(stats.GradEvaluations)
+		case HessEvaluationsColumn:
+			v = fmt.Sprint(stats.HessEvaluations)
+		}
+		values = append(values, fmt.Sprintf("%*s", width, v)...)
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", values); err != nil {
+		return err
+	}
+	p.lines++
+	return nil
+}
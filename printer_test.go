@@ -0,0 +1,73 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gonum/optimize/functions"
+)
+
+func TestPrintRecorder(t *testing.T) {
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	for _, test := range []struct {
+		rec      *PrintRecorder
+		headings int
+	}{
+		{&PrintRecorder{}, 1},
+		{&PrintRecorder{PrintEvery: 5, HeadingInterval: 3}, 0},
+		{&PrintRecorder{Columns: []PrintColumn{IterationColumn, FuncEvaluationsColumn}}, 1},
+	} {
+		var buf bytes.Buffer
+		test.rec.Writer = &buf
+		settings := DefaultSettings()
+		settings.Recorder = test.rec
+		result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+		every := test.rec.PrintEvery
+		if every == 0 {
+			every = 1
+		}
+		values := 2 + result.MajorIterations/every
+		headings := test.headings
+		if headings == 0 {
+			// The headings are printed first and then after every
+			// HeadingInterval lines except before the last one.
+			headings = 1 + (values-2)/test.rec.HeadingInterval
+		}
+		if len(lines) != values+headings {
+			t.Errorf("unexpected number of lines %d, want %d values and %d headings:\n%s",
+				len(lines), values, headings, buf.String())
+			continue
+		}
+		columns := test.rec.Columns
+		if columns == nil {
+			columns = DefaultPrintColumns
+		}
+		fields := strings.Fields(lines[0])
+		if len(fields) != len(columns) || fields[0] != "Iter" {
+			t.Errorf("unexpected headings %q", lines[0])
+		}
+		// The step is not shown for the optimal location.
+		want := len(columns)
+		for _, c := range columns {
+			if c == StepColumn {
+				want--
+			}
+		}
+		if len(strings.Fields(lines[len(lines)-1])) != want {
+			t.Errorf("unexpected last line %q", lines[len(lines)-1])
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	minSpectralStep = 1e-30
+	maxSpectralStep = 1e30
+)
+
+// ProjectedGradient implements the spectral projected gradient method for
+// minimization subject to bound constraints. At every iteration, it moves
+// along the feasible direction
+//  d = P(x - λ∇f) - x,
+// where P is the projection onto the feasible region, and performs a
+// line search on d. The scaling λ is the Barzilai-Borwein step selected by
+// Step, computed from the previous step s and the corresponding change in the
+// gradient y, see BBStep. All evaluated locations are feasible because x and
+// x + d are.
+//
+// If LinesearchMethod is nil, it will be set to Backtracking, which satisfies
+// the Armijo condition at every iteration. With Nonmonotone,
+// ProjectedGradient is the nonmonotone spectral projected gradient method
+// (SPG) of the reference, which usually needs fewer evaluations.
+//
+// ProjectedGradient only needs the gradient and has a small cost per
+// iteration, but it converges only linearly. LBFGSB is usually more
+// efficient. For unconstrained problems, ProjectedGradient reduces to
+// BarzilaiBorwein with the same LinesearchMethod.
+//
+// Reference:
+//  Birgin, E.G., Martínez, J.M., Raydan, M.: Nonmonotone spectral projected
+//  gradient methods on convex sets. SIAM J Optim 10 (2000), 1196-1211
+type ProjectedGradient struct {
+	LinesearchMethod LinesearchMethod
+	Step             BBStep
+
+	linesearch *Linesearch
+
+	bounds *Bounds
+	x      []float64 // Location at the last major iteration.
+	grad   []float64 // Gradient at the last major iteration.
+	s, y   []float64
+	iter   int // Number of Barzilai-Borwein steps.
+}
+
+func (pg *ProjectedGradient) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	pg.bounds = p.Bounds
+	if pg.bounds == nil {
+		pg.bounds = &Bounds{}
+	}
+	if pg.linesearch == nil {
+		pg.linesearch = &Linesearch{}
+	}
+	if pg.LinesearchMethod == nil {
+		pg.LinesearchMethod = &Backtracking{}
+	}
+	pg.linesearch.Method = pg.LinesearchMethod
+	pg.linesearch.NextDirectioner = pg
+	return pg.linesearch.Init(loc, p, xNext)
+}
+
+func (pg *ProjectedGradient) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	return pg.linesearch.Iterate(loc, xNext)
+}
+
+func (pg *ProjectedGradient) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	dim := len(loc.X)
+	pg.x = resize(pg.x, dim)
+	pg.grad = resize(pg.grad, dim)
+	pg.s = resize(pg.s, dim)
+	pg.y = resize(pg.y, dim)
+	copy(pg.x, loc.X)
+	copy(pg.grad, loc.Gradient)
+	pg.iter = 0
+
+	// Without curvature information, choose λ so that no variable moves by
+	// more than one.
+	lambda := 1 / floats.Norm(loc.Gradient, math.Inf(1))
+	pg.direction(loc, dir, lambda)
+	return 1
+}
+
+func (pg *ProjectedGradient) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	floats.SubTo(pg.s, loc.X, pg.x)
+	floats.SubTo(pg.y, loc.Gradient, pg.grad)
+	copy(pg.x, loc.X)
+	copy(pg.grad, loc.Gradient)
+
+	// If the curvature along s is not positive, fall back to the initial
+	// scaling.
+	lambda, ok := bbStepSize(pg.s, pg.y, pg.Step, pg.iter)
+	pg.iter++
+	if !ok {
+		lambda = 1 / floats.Norm(loc.Gradient, math.Inf(1))
+	}
+	pg.direction(loc, dir, lambda)
+	return 1
+}
+
+// direction stores P(x - λ∇f) - x into dir.
+func (pg *ProjectedGradient) direction(loc *Location, dir []float64, lambda float64) {
+	floats.AddScaledTo(dir, loc.X, -lambda, loc.Gradient)
+	pg.bounds.Project(dir)
+	floats.Sub(dir, loc.X)
+}
+
+// Clone returns a new ProjectedGradient with the configuration of pg and a
+// copy of its LinesearchMethod.
+func (pg *ProjectedGradient) Clone() Method {
+	return &ProjectedGradient{
+		LinesearchMethod: cloneLinesearchMethod(pg.LinesearchMethod),
+		Step:             pg.Step,
+	}
+}
+
+func (*ProjectedGradient) HandlesBounds() bool {
+	return true
+}
+
+func (*ProjectedGradient) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
@@ -0,0 +1,427 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultProximalShrink = 0.5
+	defaultProximalTol    = 1e-6
+)
+
+// ProximalOperator is a convex, possibly non-differentiable function g whose
+// proximal operator
+//  prox_{t g}(x) = argmin_y g(y) + 1/(2t) |y - x|_2^2
+// is easy to evaluate. It is the non-smooth term of a composite objective
+// function f(x) + g(x), see Problem.Prox.
+type ProximalOperator interface {
+	// Value returns g(x). It returns +Inf if x is outside the domain of
+	// g. Value must not modify x.
+	Value(x []float64) float64
+	// Prox stores prox_{t g}(x) in dst for the step t > 0. dst and x have
+	// the same length and may be the same slice.
+	Prox(dst, x []float64, t float64)
+}
+
+// L1Prox is the L1 penalty g(x) = Lambda |x|_1. Its proximal operator is the
+// soft thresholding of the components of x. Minimizing a least-squares
+// objective with an L1 penalty yields sparse solutions, as in the LASSO.
+type L1Prox struct {
+	Lambda float64
+}
+
+func (l L1Prox) Value(x []float64) float64 {
+	return l.Lambda * floats.Norm(x, 1)
+}
+
+func (l L1Prox) Prox(dst, x []float64, t float64) {
+	if l.Lambda < 0 {
+		panic("optimize: negative L1 penalty")
+	}
+	thresh := t * l.Lambda
+	for i, v := range x {
+		dst[i] = math.Copysign(math.Max(math.Abs(v)-thresh, 0), v)
+	}
+}
+
+// BoxProx is the indicator function of the box Lower ≤ x ≤ Upper, which is
+// zero within the box and +Inf outside. Its proximal operator is the
+// projection onto the box. If Lower or Upper is nil, the variables are not
+// bounded from below or above.
+type BoxProx struct {
+	Lower, Upper []float64
+}
+
+func (b BoxProx) Value(x []float64) float64 {
+	for i, v := range x {
+		if (b.Lower != nil && v < b.Lower[i]) || (b.Upper != nil && v > b.Upper[i]) {
+			return math.Inf(1)
+		}
+	}
+	return 0
+}
+
+func (b BoxProx) Prox(dst, x []float64, t float64) {
+	for i, v := range x {
+		if b.Lower != nil && v < b.Lower[i] {
+			v = b.Lower[i]
+		}
+		if b.Upper != nil && v > b.Upper[i] {
+			v = b.Upper[i]
+		}
+		dst[i] = v
+	}
+}
+
+// L2BallProx is the indicator function of the ball |x|_2 ≤ Radius, which is
+// zero within the ball and +Inf outside. Its proximal operator is the
+// projection onto the ball.
+type L2BallProx struct {
+	Radius float64
+}
+
+func (b L2BallProx) Value(x []float64) float64 {
+	// Allow for the rounding errors of the projection.
+	if floats.Norm(x, 2) > b.Radius*(1+1e-14) {
+		return math.Inf(1)
+	}
+	return 0
+}
+
+func (b L2BallProx) Prox(dst, x []float64, t float64) {
+	if b.Radius < 0 {
+		panic("optimize: negative radius")
+	}
+	copy(dst, x)
+	if norm := floats.Norm(x, 2); norm > b.Radius {
+		floats.Scale(b.Radius/norm, dst)
+	}
+}
+
+// proximalStep implements the proximal gradient step with backtracking of
+// ProximalGradient and FISTA. The step from y is
+//  x = prox_{t g}(y - t ∇f(y)),
+// and the step size t is reduced until the quadratic upper bound
+//  f(x) ≤ f(y) + ∇f(y)ᵀ(x - y) + 1/(2t) |x - y|_2^2
+// holds at x.
+type proximalStep struct {
+	prox   ProximalOperator
+	shrink float64
+	t      float64
+
+	y, gy []float64 // Location and gradient of f at the start of the step.
+	fy    float64   // f at y.
+	x     []float64 // Trial location.
+	diff  []float64
+}
+
+func (ps *proximalStep) init(prox ProximalOperator, dim int, t, shrink float64) {
+	if prox == nil {
+		panic("optimize: problem has no proximal operator")
+	}
+	ps.prox = prox
+	ps.t = t
+	ps.shrink = shrink
+	ps.y = resize(ps.y, dim)
+	ps.gy = resize(ps.gy, dim)
+	ps.x = resize(ps.x, dim)
+	ps.diff = resize(ps.diff, dim)
+}
+
+// start starts a step from loc, where the composite objective function and
+// the gradient of f are known, and stores the trial location in xNext.
+func (ps *proximalStep) start(loc *Location, xNext []float64) {
+	copy(ps.y, loc.X)
+	copy(ps.gy, loc.Gradient)
+	ps.fy = loc.F - ps.prox.Value(loc.X)
+	ps.trial(xNext)
+}
+
+// trial stores the trial location for the current step size in ps.x and
+// xNext.
+func (ps *proximalStep) trial(xNext []float64) {
+	floats.AddScaledTo(ps.x, ps.y, -ps.t, ps.gy)
+	ps.prox.Prox(ps.x, ps.x, ps.t)
+	copy(xNext, ps.x)
+}
+
+// accept returns whether the trial location at loc satisfies the quadratic
+// upper bound. Otherwise, it reduces the step size and stores the next trial
+// location in xNext.
+func (ps *proximalStep) accept(loc *Location, xNext []float64) bool {
+	floats.SubTo(ps.diff, ps.x, ps.y)
+	fx := loc.F - ps.prox.Value(ps.x)
+	bound := ps.fy + floats.Dot(ps.gy, ps.diff) + floats.Dot(ps.diff, ps.diff)/(2*ps.t)
+	// Allow for the rounding errors in the function values.
+	if fx <= bound+1e-14*math.Abs(ps.fy) {
+		return true
+	}
+	ps.t *= ps.shrink
+	ps.trial(xNext)
+	return false
+}
+
+// mappingNorm returns the infinity norm of the gradient mapping (y - x)/t
+// of the accepted step, which vanishes at a minimum.
+func (ps *proximalStep) mappingNorm() float64 {
+	return floats.Norm(ps.diff, math.Inf(1)) / ps.t
+}
+
+// ProximalGradient implements the proximal gradient method, or forward-backward
+// splitting, for minimizing composite objective functions f(x) + g(x), where f
+// is smooth and its gradient is given by Problem.Grad, and g is convex and has
+// the proximal operator given by Problem.Prox. Every iteration takes a gradient
+// step on f followed by the proximal operator of g,
+//  x_{k+1} = prox_{t g}(x_k - t ∇f(x_k)),
+// and the step size t is found by backtracking until the quadratic upper bound
+// of f at x_k holds at x_{k+1}. For g = 0 the method is gradient descent, and
+// for the indicator function of a convex set it is the projected gradient
+// method.
+//
+// ProximalGradient converges with MethodConverge status when the infinity
+// norm of the gradient mapping (x_k - x_{k+1})/t is below Tolerance. The
+// objective function value decreases at every iteration and converges at the
+// rate O(1/k) for convex f.
+//
+// References:
+//  - Beck, A., Teboulle, M.: A fast iterative shrinkage-thresholding algorithm
+//    for linear inverse problems. SIAM J. Imaging Sci. 2 (2009), 183-202
+//  - Parikh, N., Boyd, S.: Proximal algorithms. Found. Trends Optim. 1
+//    (2014), 127-239
+type ProximalGradient struct {
+	// InitialStep is the initial step size t. If InitialStep is zero, it
+	// will be set to 1.
+	InitialStep float64
+	// Shrink is the factor by which the step size is reduced when the
+	// quadratic upper bound does not hold. If Shrink is zero, it will be
+	// set to 0.5.
+	Shrink float64
+	// Tolerance is the tolerance on the gradient mapping. If Tolerance is
+	// zero, it will be set to 1e-6.
+	Tolerance float64
+
+	status Status
+	step   proximalStep
+	// major is whether the gradient is being evaluated at an accepted
+	// location.
+	major bool
+}
+
+func (pg *ProximalGradient) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	t, shrink, tol := proximalDefaults(pg.InitialStep, pg.Shrink, pg.Tolerance)
+	pg.InitialStep, pg.Shrink, pg.Tolerance = t, shrink, tol
+	pg.status = NotTerminated
+	pg.step.init(p.Prox, len(loc.X), pg.InitialStep, pg.Shrink)
+	pg.major = false
+	pg.step.start(loc, xNext)
+	return FuncEvaluation, MinorIteration, nil
+}
+
+func (pg *ProximalGradient) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	if pg.major {
+		pg.major = false
+		pg.step.start(loc, xNext)
+		return FuncEvaluation, MinorIteration, nil
+	}
+	if !pg.step.accept(loc, xNext) {
+		return FuncEvaluation, MinorIteration, nil
+	}
+	copy(xNext, loc.X)
+	if pg.step.mappingNorm() <= pg.Tolerance {
+		pg.status = MethodConverge
+		return NoEvaluation, MajorIteration, nil
+	}
+	pg.major = true
+	return GradEvaluation, MajorIteration, nil
+}
+
+// Status returns MethodConverge when the gradient mapping is below Tolerance.
+func (pg *ProximalGradient) Status() (Status, error) {
+	return pg.status, nil
+}
+
+// HandlesProx returns true.
+func (*ProximalGradient) HandlesProx() bool {
+	return true
+}
+
+func (*ProximalGradient) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// FISTA implements the fast iterative shrinkage-thresholding algorithm of Beck
+// and Teboulle, the accelerated proximal gradient method, for minimizing
+// composite objective functions f(x) + g(x) as described for
+// ProximalGradient. Every iteration takes the proximal gradient step from an
+// extrapolated point,
+//  x_{k+1} = prox_{t g}(y_k - t ∇f(y_k)),
+//  θ_{k+1} = (1 + sqrt(1 + 4 θ_k^2)) / 2,
+//  y_{k+1} = x_{k+1} + (θ_k - 1)/θ_{k+1} (x_{k+1} - x_k),
+// with θ_0 = 1 and the step size found by backtracking. The objective function
+// value converges at the rate O(1/k^2) for convex f, but it need not
+// decrease at every iteration. If Restart is true, the momentum is reset
+// whenever the objective function value increases, which often speeds up the
+// convergence on strongly convex problems.
+//
+// If the extrapolated point is outside the domain of g, as can happen for
+// the indicator function of a set, the momentum is reset and y_{k+1} = x_{k+1}.
+// FISTA converges with MethodConverge status when the infinity norm of the
+// gradient mapping (y_k - x_{k+1})/t is below Tolerance. The gradient is only
+// evaluated at the extrapolated points, so the Gradient of the Location at the
+// major iterations is not valid.
+//
+// References:
+//  - Beck, A., Teboulle, M.: A fast iterative shrinkage-thresholding algorithm
+//    for linear inverse problems. SIAM J. Imaging Sci. 2 (2009), 183-202
+//  - O'Donoghue, B., Candès, E.: Adaptive restart for accelerated gradient
+//    schemes. Found. Comput. Math. 15 (2015), 715-732
+type FISTA struct {
+	// InitialStep is the initial step size t. If InitialStep is zero, it
+	// will be set to 1.
+	InitialStep float64
+	// Shrink is the factor by which the step size is reduced when the
+	// quadratic upper bound does not hold. If Shrink is zero, it will be
+	// set to 0.5.
+	Shrink float64
+	// Tolerance is the tolerance on the gradient mapping. If Tolerance is
+	// zero, it will be set to 1e-6.
+	Tolerance float64
+	// Restart specifies whether the momentum is reset when the objective
+	// function value increases.
+	Restart bool
+
+	status Status
+	stage  fistaStage
+	step   proximalStep
+	theta  float64
+	x      []float64 // Last accepted location.
+	fx     float64   // Objective function value at x.
+}
+
+// fistaStage is the stage of FISTA.
+type fistaStage int
+
+const (
+	fistaTrial    fistaStage = iota // Evaluating a trial location of a step.
+	fistaAccepted                   // The trial location has been accepted.
+	fistaExtrap                     // Evaluating the extrapolated point.
+)
+
+func (fi *FISTA) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	t, shrink, tol := proximalDefaults(fi.InitialStep, fi.Shrink, fi.Tolerance)
+	fi.InitialStep, fi.Shrink, fi.Tolerance = t, shrink, tol
+	fi.status = NotTerminated
+	fi.step.init(p.Prox, len(loc.X), fi.InitialStep, fi.Shrink)
+	fi.theta = 1
+	fi.x = resize(fi.x, len(loc.X))
+	copy(fi.x, loc.X)
+	fi.fx = loc.F
+	fi.stage = fistaTrial
+	fi.step.start(loc, xNext)
+	return FuncEvaluation, MinorIteration, nil
+}
+
+func (fi *FISTA) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch fi.stage {
+	case fistaTrial:
+		if !fi.step.accept(loc, xNext) {
+			return FuncEvaluation, MinorIteration, nil
+		}
+		copy(xNext, loc.X)
+		if fi.step.mappingNorm() <= fi.Tolerance {
+			fi.status = MethodConverge
+			return NoEvaluation, MajorIteration, nil
+		}
+		if fi.Restart && loc.F > fi.fx {
+			fi.theta = 1
+		}
+		fi.stage = fistaAccepted
+		return NoEvaluation, MajorIteration, nil
+	case fistaAccepted:
+		theta := 0.5 * (1 + math.Sqrt(1+4*fi.theta*fi.theta))
+		beta := (fi.theta - 1) / theta
+		fi.theta = theta
+		for i, v := range loc.X {
+			xNext[i] = v + beta*(v-fi.x[i])
+		}
+		if beta != 0 && math.IsInf(fi.step.prox.Value(xNext), 1) {
+			// The extrapolated point is outside the domain of g, where f
+			// cannot be recovered from the objective function value.
+			// Restart from the accepted location.
+			fi.theta = 1
+			beta = 0
+			copy(xNext, loc.X)
+		}
+		copy(fi.x, loc.X)
+		fi.fx = loc.F
+		fi.stage = fistaExtrap
+		if beta == 0 {
+			// The extrapolated point is the accepted location, where the
+			// objective function value is known.
+			return GradEvaluation, MinorIteration, nil
+		}
+		return FuncEvaluation | GradEvaluation, MinorIteration, nil
+	case fistaExtrap:
+		fi.stage = fistaTrial
+		fi.step.start(loc, xNext)
+		return FuncEvaluation, MinorIteration, nil
+	}
+	panic("fista: unknown stage")
+}
+
+// Status returns MethodConverge when the gradient mapping is below Tolerance.
+func (fi *FISTA) Status() (Status, error) {
+	return fi.status, nil
+}
+
+// HandlesProx returns true.
+func (*FISTA) HandlesProx() bool {
+	return true
+}
+
+func (*FISTA) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// proximalDefaults returns the step size, the shrink factor and the
+// tolerance of the proximal gradient methods with the zero values replaced by
+// the defaults.
+func proximalDefaults(t, shrink, tol float64) (float64, float64, float64) {
+	if t == 0 {
+		t = 1
+	}
+	if t < 0 {
+		panic("optimize: initial step must be positive")
+	}
+	if shrink == 0 {
+		shrink = defaultProximalShrink
+	}
+	if shrink <= 0 || shrink >= 1 {
+		panic("optimize: shrink factor must be in (0, 1)")
+	}
+	if tol == 0 {
+		tol = defaultProximalTol
+	}
+	if tol < 0 {
+		panic("optimize: tolerance must be positive")
+	}
+	return t, shrink, tol
+}
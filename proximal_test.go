@@ -0,0 +1,135 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// leastSquaresProblem returns the problem ½ |A x - b|_2^2.
+func leastSquaresProblem(a *mat64.Dense, b []float64) Problem {
+	m, n := a.Dims()
+	resid := func(x []float64) []float64 {
+		r := make([]float64, m)
+		for i := range r {
+			r[i] = floats.Dot(a.Row(nil, i), x) - b[i]
+		}
+		return r
+	}
+	return Problem{
+		Func: func(x []float64) float64 {
+			r := resid(x)
+			return 0.5 * floats.Dot(r, r)
+		},
+		Grad: func(x, grad []float64) {
+			r := resid(x)
+			for j := 0; j < n; j++ {
+				grad[j] = floats.Dot(a.Col(nil, j), r)
+			}
+		},
+	}
+}
+
+func TestProximal(t *testing.T) {
+	a := mat64.NewDense(5, 3, []float64{
+		1, 0.5, 0,
+		0, 1, 0.2,
+		0.3, 0, 1,
+		1, 1, 1,
+		2, -1, 0.5,
+	})
+	b := []float64{1, -2, 0.05, 0.5, 3}
+	const lambda = 0.5
+	lasso := leastSquaresProblem(a, b)
+	lasso.Prox = L1Prox{Lambda: lambda}
+
+	// The quadratic ½ xᵀ A x - bᵀ x in the unit box, whose minimum is at the
+	// upper bound of x[2].
+	quad := Problem{
+		Func: func(x []float64) float64 {
+			return 2*x[0]*x[0] + 1.5*x[1]*x[1] + x[2]*x[2] + x[0]*x[1] + x[1]*x[2] -
+				x[0] - 2*x[1] - 3*x[2]
+		},
+		Grad: func(x, grad []float64) {
+			grad[0] = 4*x[0] + x[1] - 1
+			grad[1] = x[0] + 3*x[1] + x[2] - 2
+			grad[2] = x[1] + 2*x[2] - 3
+		},
+		Prox: BoxProx{Lower: []float64{0, 0, 0}, Upper: []float64{1, 1, 1}},
+	}
+
+	ball := Problem{
+		Func: func(x []float64) float64 {
+			return 0.5 * ((x[0]-3)*(x[0]-3) + (x[1]-4)*(x[1]-4))
+		},
+		Grad: func(x, grad []float64) {
+			grad[0] = x[0] - 3
+			grad[1] = x[1] - 4
+		},
+		Prox: L2BallProx{Radius: 1},
+	}
+
+	for _, method := range []struct {
+		name   string
+		method func() Method
+	}{
+		{"ProximalGradient", func() Method { return &ProximalGradient{Tolerance: 1e-8} }},
+		{"FISTA", func() Method { return &FISTA{Tolerance: 1e-8} }},
+		{"FISTARestart", func() Method { return &FISTA{Tolerance: 1e-8, Restart: true} }},
+	} {
+		for _, test := range []struct {
+			name string
+			p    Problem
+			x    []float64
+			want []float64 // If want is nil, the LASSO conditions are checked.
+		}{
+			{"Lasso", lasso, []float64{0, 0, 0}, nil},
+			{"Box", quad, []float64{0.5, 0.5, 0.5}, []float64{2.0 / 11, 3.0 / 11, 1}},
+			{"Ball", ball, []float64{0, 0}, []float64{0.6, 0.8}},
+		} {
+			name := method.name + " " + test.name
+			settings := DefaultSettings()
+			settings.FunctionConverge = nil
+			settings.MajorIterations = 1000
+			result, err := Local(test.p, test.x, settings, method.method())
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+				continue
+			}
+			if result.Status != MethodConverge {
+				t.Errorf("%s: unexpected status %v", name, result.Status)
+			}
+			if want := test.p.Func(result.X) + test.p.Prox.Value(result.X); result.F != want {
+				t.Errorf("%s: objective function value does not include the proximal term, want %v, got %v", name, want, result.F)
+			}
+			if test.want != nil {
+				if !floats.EqualApprox(result.X, test.want, 1e-6) {
+					t.Errorf("%s: unexpected minimum location, want %v, got %v", name, test.want, result.X)
+				}
+				continue
+			}
+			grad := make([]float64, len(result.X))
+			test.p.Grad(result.X, grad)
+			for j, xj := range result.X {
+				if xj != 0 {
+					if math.Abs(grad[j]+math.Copysign(lambda, xj)) > 1e-6 {
+						t.Errorf("%s: coordinate %d not optimal: x = %v, gradient %v", name, j, xj, grad[j])
+					}
+				} else if math.Abs(grad[j]) > lambda+1e-6 {
+					t.Errorf("%s: zero coordinate %d not optimal: gradient %v", name, j, grad[j])
+				}
+			}
+		}
+	}
+
+	_, err := Local(lasso, []float64{0, 0, 0}, nil, &BFGS{})
+	if err == nil {
+		t.Errorf("expected error for a method that does not handle proximal operators")
+	}
+}
@@ -0,0 +1,690 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package qp
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/lp"
+)
+
+const (
+	defaultTol           = 1e-8
+	defaultMaxIterations = 100
+	// fractionToBoundary is the fraction of the step to the boundary of the
+	// positive orthant that is taken.
+	fractionToBoundary = 0.995
+	// regularization is the relative regularization of the KKT matrix,
+	// which keeps it nonsingular if Q is only positive semidefinite or the
+	// equality constraints are linearly dependent.
+	regularization = 1e-12
+	// centrality is the smallest ratio of the complementarity of a pair of
+	// variables to the mean complementarity that is enforced.
+	centrality = 1e-2
+	// warmStartShift is the smallest slack and multiplier of the
+	// inequality constraints and the bounds in a warm start.
+	warmStartShift = 1e-2
+)
+
+var (
+	// ErrIterationLimit signifies that the solver has not converged within
+	// the maximum number of iterations.
+	ErrIterationLimit = errors.New("qp: iteration limit reached")
+
+	// ErrInfeasible signifies that the constraints of the quadratic program
+	// cannot be satisfied.
+	ErrInfeasible = errors.New("qp: problem is infeasible")
+
+	// ErrUnbounded signifies that the objective function of the quadratic
+	// program is unbounded below on the feasible region.
+	ErrUnbounded = errors.New("qp: problem is unbounded")
+
+	// ErrSingular signifies that a linear system of the method is
+	// numerically singular.
+	ErrSingular = errors.New("qp: singular linear system")
+)
+
+// Problem is a convex quadratic program
+//  minimize	½ xᵀ Q x + cᵀ x
+//  subject to	A x <= b
+//  		Aeq x = beq
+//  		lower <= x <= upper.
+type Problem struct {
+	// Q is the positive semidefinite Hessian of the objective. If Q is nil,
+	// the objective is linear.
+	Q mat64.Symmetric
+	C []float64
+
+	// A and B specify the inequality constraints. If A is nil, there are no
+	// inequality constraints.
+	A mat64.Matrix
+	B []float64
+
+	// Aeq and Beq specify the equality constraints. If Aeq is nil, there are
+	// no equality constraints.
+	Aeq mat64.Matrix
+	Beq []float64
+
+	// Lower and Upper specify the bounds on the variables, whose elements
+	// may be infinite. If Lower or Upper is nil, the variables are not
+	// bounded below or above.
+	Lower, Upper []float64
+}
+
+// Result is the solution of a quadratic program with the Lagrange
+// multipliers of the constraints, which satisfy
+//  Q x + c + Aᵀ Ineq + Aeqᵀ Eq - Lower + Upper = 0
+// at the solution. The multipliers of the inequality constraints and the
+// bounds are non-negative, and those of infinite bounds are zero.
+type Result struct {
+	X []float64
+	F float64
+
+	Ineq  []float64
+	Eq    []float64
+	Lower []float64
+	Upper []float64
+
+	// Iterations is the number of iterations of the interior-point method.
+	Iterations int
+
+	// Farkas is set if the constraints cannot be satisfied, see lp.Farkas.
+	Farkas *lp.Farkas
+	// Ray is set if the objective is unbounded below on the feasible
+	// region. Ray is a direction of unboundedness that satisfies
+	//  Q Ray = 0, cᵀ Ray < 0, A Ray <= 0, Aeq Ray = 0,
+	// and x + t Ray satisfies the bounds for all t >= 0 if x does.
+	Ray []float64
+}
+
+// Solver solves convex quadratic programs by a primal-dual interior-point
+// method with Mehrotra's predictor-corrector steps. The iterates need not be
+// feasible, so no feasible starting point is required. A Solver keeps its
+// workspace between calls to Solve, which makes solving a sequence of
+// problems of the same size cheaper, for example the subproblems of a
+// sequential quadratic programming method. A Solver must not be used
+// concurrently.
+//
+// References:
+//  - Mehrotra, S.: On the implementation of a primal-dual interior point
+//    method. SIAM J. Optim. 2 (1992), 575-601
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization, 2nd ed. Springer
+//    (2006), section 16.6
+type Solver struct {
+	// Tolerance is the relative tolerance of the residuals of the
+	// optimality conditions. If Tolerance is zero, it will be set to 1e-8.
+	Tolerance float64
+	// MaxIterations is the maximum number of iterations. If MaxIterations
+	// is zero, it will be set to 100.
+	MaxIterations int
+
+	q    [][]float64 // Dense Q.
+	g    [][]float64 // Rows of the inequality constraints, with the bounds.
+	h    []float64
+	e    [][]float64 // Rows of the equality constraints.
+	f    []float64
+	kkt  [][]float64
+	lu   lu
+	rhs  []float64
+	sol  []float64
+	work []float64
+}
+
+// Solve solves the quadratic program p. The interior-point method does not
+// converge if p is infeasible or unbounded. In that case Solve looks for a
+// certificate with the simplex method of package lp, and returns
+// ErrInfeasible or ErrUnbounded together with a Result that holds the
+// certificate, Farkas or Ray, instead of a solution. If no certificate is
+// found, Solve returns ErrIterationLimit. Solve panics if the sizes of the
+// elements of p do not match.
+func (s *Solver) Solve(p *Problem) (*Result, error) {
+	return s.SolveFrom(p, nil)
+}
+
+// SolveFrom solves the quadratic program p like Solve, starting from the
+// primal-dual point of start, which is usually the Result of a previous
+// call for a similar problem. The multipliers of start also carry its active
+// set. Warm starting makes solving a sequence of nearly identical problems
+// much cheaper, for example in model predictive control. The slacks and the
+// multipliers of the inequality constraints and the bounds are moved into
+// the interior of the positive orthant, because the interior-point method
+// cannot start on its boundary. If start is nil, SolveFrom starts from the
+// default point like Solve. SolveFrom panics if the sizes of the elements of
+// start do not match those of p.
+func (s *Solver) SolveFrom(p *Problem, start *Result) (*Result, error) {
+	if s.Tolerance == 0 {
+		s.Tolerance = defaultTol
+	}
+	if s.Tolerance < 0 {
+		panic("qp: negative tolerance")
+	}
+	if s.MaxIterations == 0 {
+		s.MaxIterations = defaultMaxIterations
+	}
+	if s.MaxIterations < 0 {
+		panic("qp: negative maximum number of iterations")
+	}
+	n := len(p.C)
+	mi, me, lowIdx, upIdx := s.setup(p)
+	m := len(s.g)
+	tol := s.Tolerance
+
+	x := make([]float64, n)
+	y := make([]float64, me)
+	z := make([]float64, m)
+	sl := make([]float64, m) // Slacks of the inequalities.
+	if start != nil {
+		s.warmStart(start, mi, lowIdx, upIdx, x, y, z, sl)
+	} else {
+		for i := range sl {
+			sl[i] = math.Max(s.h[i], 1)
+			z[i] = 1
+		}
+	}
+
+	rd := make([]float64, n)
+	re := make([]float64, me)
+	ri := make([]float64, m)
+	rc := make([]float64, m)
+	dx := make([]float64, n)
+	dy := make([]float64, me)
+	dz := make([]float64, m)
+	ds := make([]float64, m)
+	dzAff := make([]float64, m)
+	dsAff := make([]float64, m)
+
+	cNorm := floats.Norm(p.C, math.Inf(1))
+	hNorm := floats.Norm(s.h, math.Inf(1))
+	fNorm := floats.Norm(s.f, math.Inf(1))
+
+	var iter int
+	for ; ; iter++ {
+		s.residuals(p.C, x, y, z, sl, rd, re, ri)
+		var mu float64
+		if m > 0 {
+			mu = floats.Dot(sl, z) / float64(m)
+		}
+		if floats.Norm(rd, math.Inf(1)) <= tol*(1+cNorm) &&
+			floats.Norm(re, math.Inf(1)) <= tol*(1+fNorm) &&
+			floats.Norm(ri, math.Inf(1)) <= tol*(1+hNorm) &&
+			mu <= tol {
+			break
+		}
+		if iter == s.MaxIterations {
+			return certificate(p)
+		}
+		if !s.factorize(z, sl) {
+			return nil, ErrSingular
+		}
+
+		// Predictor step towards the solution of the optimality
+		// conditions without centering.
+		for i := range rc {
+			rc[i] = -sl[i] * z[i]
+		}
+		s.direction(rd, re, ri, rc, z, sl, dx, dy, dz, ds)
+		if m == 0 {
+			// The Newton step solves the equality-constrained problem.
+			floats.Add(x, dx)
+			floats.Add(y, dy)
+			continue
+		}
+		alpha := math.Min(1, math.Min(maxStep(sl, ds), maxStep(z, dz)))
+		var sigma float64
+		if mu > 0 {
+			sigma = math.Pow(complementarity(sl, z, ds, dz, alpha)/mu, 3)
+		}
+
+		// Corrector step with centering.
+		copy(dsAff, ds)
+		copy(dzAff, dz)
+		for i := range rc {
+			rc[i] = -sl[i]*z[i] - dsAff[i]*dzAff[i] + sigma*mu
+		}
+		s.direction(rd, re, ri, rc, z, sl, dx, dy, dz, ds)
+		alpha = math.Min(1, fractionToBoundary*math.Min(maxStep(sl, ds), maxStep(z, dz)))
+		// Keep the iterates away from the boundary of the positive orthant
+		// relative to the mean complementarity, which prevents the method
+		// from stalling.
+		gamma := math.Min(centrality, 0.5*minComplementarity(sl, z, ds, dz, 0)/mu)
+		for k := 0; k < 50 && minComplementarity(sl, z, ds, dz, alpha) < gamma*complementarity(sl, z, ds, dz, alpha); k++ {
+			alpha *= 0.9
+		}
+		floats.AddScaled(x, alpha, dx)
+		floats.AddScaled(y, alpha, dy)
+		floats.AddScaled(z, alpha, dz)
+		floats.AddScaled(sl, alpha, ds)
+	}
+
+	res := &Result{
+		X:          x,
+		Ineq:       z[:mi],
+		Eq:         y,
+		Lower:      make([]float64, n),
+		Upper:      make([]float64, n),
+		Iterations: iter,
+	}
+	for k, j := range lowIdx {
+		res.Lower[j] = z[mi+k]
+	}
+	for k, j := range upIdx {
+		res.Upper[j] = z[mi+len(lowIdx)+k]
+	}
+	res.F = floats.Dot(p.C, x)
+	for i, row := range s.q {
+		res.F += 0.5 * x[i] * floats.Dot(row, x)
+	}
+	return res, nil
+}
+
+// warmStart stores the starting point given by start into x, y, z and sl.
+func (s *Solver) warmStart(start *Result, mi int, lowIdx, upIdx []int, x, y, z, sl []float64) {
+	n := len(x)
+	if len(start.X) != n || len(start.Eq) != len(y) || len(start.Ineq) != mi ||
+		len(start.Lower) != n || len(start.Upper) != n {
+		panic("qp: start size mismatch")
+	}
+	copy(x, start.X)
+	copy(y, start.Eq)
+	copy(z, start.Ineq)
+	for k, j := range lowIdx {
+		z[mi+k] = start.Lower[j]
+	}
+	for k, j := range upIdx {
+		z[mi+len(lowIdx)+k] = start.Upper[j]
+	}
+	for i, row := range s.g {
+		sl[i] = s.h[i] - floats.Dot(row, x)
+	}
+	// A slack or a multiplier smaller than the shift is set to the shift,
+	// so that the complementarity of the active constraints is of the order
+	// of the shift squared.
+	for i := range sl {
+		sl[i] = math.Max(sl[i], warmStartShift)
+		z[i] = math.Max(z[i], warmStartShift)
+	}
+}
+
+// certificate returns a certificate of infeasibility or unboundedness of p
+// with the corresponding error, or ErrIterationLimit if there is none.
+func certificate(p *Problem) (*Result, error) {
+	n := len(p.C)
+	lower, upper := p.Lower, p.Upper
+	if lower == nil {
+		// The variables of an lp.Problem are non-negative by default.
+		lower = make([]float64, n)
+		for j := range lower {
+			lower[j] = math.Inf(-1)
+		}
+	}
+	res, err := lp.SolveResult(lp.Problem{
+		C:     make([]float64, n),
+		A:     p.A,
+		B:     p.B,
+		Aeq:   p.Aeq,
+		Beq:   p.Beq,
+		Lower: lower,
+		Upper: upper,
+	}, 0)
+	if err == lp.ErrInfeasible {
+		return &Result{Farkas: res.Farkas}, ErrInfeasible
+	}
+	if err != nil {
+		return nil, ErrIterationLimit
+	}
+
+	// p is feasible, so it is unbounded if and only if the objective
+	// decreases along a direction d of non-negative curvature
+	//  Q d = 0, A d <= 0, Aeq d = 0
+	// in which the bounds allow to move. The direction is normalized by
+	// cᵀ d >= -1, so that the minimum of cᵀ d is either 0 or -1.
+	var mi, me int
+	if p.A != nil {
+		mi, _ = p.A.Dims()
+	}
+	if p.Aeq != nil {
+		me, _ = p.Aeq.Dims()
+	}
+	a := mat64.NewDense(mi+1, n, nil)
+	for i := 0; i < mi; i++ {
+		for j := 0; j < n; j++ {
+			a.Set(i, j, p.A.At(i, j))
+		}
+	}
+	for j, v := range p.C {
+		a.Set(mi, j, -v)
+	}
+	b := make([]float64, mi+1)
+	b[mi] = 1
+	var aeq *mat64.Dense
+	var beq []float64
+	if p.Q != nil || me > 0 {
+		rows := me
+		if p.Q != nil {
+			rows += n
+		}
+		aeq = mat64.NewDense(rows, n, nil)
+		beq = make([]float64, rows)
+		for i := 0; i < me; i++ {
+			for j := 0; j < n; j++ {
+				aeq.Set(i, j, p.Aeq.At(i, j))
+			}
+		}
+		if p.Q != nil {
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					aeq.Set(me+i, j, p.Q.At(i, j))
+				}
+			}
+		}
+	}
+	dLower := make([]float64, n)
+	dUpper := make([]float64, n)
+	for j := range dLower {
+		dLower[j] = math.Inf(-1)
+		if p.Lower != nil && !math.IsInf(p.Lower[j], -1) {
+			dLower[j] = 0
+		}
+		dUpper[j] = math.Inf(1)
+		if p.Upper != nil && !math.IsInf(p.Upper[j], 1) {
+			dUpper[j] = 0
+		}
+	}
+	lpp := lp.Problem{C: p.C, A: a, B: b, Lower: dLower, Upper: dUpper}
+	if aeq != nil {
+		lpp.Aeq, lpp.Beq = aeq, beq
+	}
+	res, err = lp.SolveResult(lpp, 0)
+	if err != nil || res.F > -0.5 {
+		return nil, ErrIterationLimit
+	}
+	return &Result{Ray: res.X}, ErrUnbounded
+}
+
+// setup stores the dense data of p into the workspace. The rows of the
+// inequality constraints are followed by the rows of the finite lower and
+// upper bounds of the variables lowIdx and upIdx.
+func (s *Solver) setup(p *Problem) (mi, me int, lowIdx, upIdx []int) {
+	n := len(p.C)
+	s.q = resizeMat(s.q, n, n)
+	for i := range s.q {
+		for j := range s.q[i] {
+			s.q[i][j] = 0
+		}
+	}
+	if p.Q != nil {
+		if r, c := p.Q.Dims(); r != n || c != n {
+			panic("qp: Q size mismatch")
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				s.q[i][j] = p.Q.At(i, j)
+			}
+		}
+	}
+	if p.A != nil {
+		var c int
+		mi, c = p.A.Dims()
+		if c != n || len(p.B) != mi {
+			panic("qp: inequality size mismatch")
+		}
+	}
+	if p.Aeq != nil {
+		var c int
+		me, c = p.Aeq.Dims()
+		if c != n || len(p.Beq) != me {
+			panic("qp: equality size mismatch")
+		}
+	}
+	if (p.Lower != nil && len(p.Lower) != n) || (p.Upper != nil && len(p.Upper) != n) {
+		panic("qp: bounds size mismatch")
+	}
+	for j := 0; j < n; j++ {
+		if p.Lower != nil && !math.IsInf(p.Lower[j], -1) {
+			lowIdx = append(lowIdx, j)
+		}
+		if p.Upper != nil && !math.IsInf(p.Upper[j], 1) {
+			upIdx = append(upIdx, j)
+		}
+	}
+
+	m := mi + len(lowIdx) + len(upIdx)
+	s.g = resizeMat(s.g, m, n)
+	s.h = resize(s.h, m)
+	for i := 0; i < mi; i++ {
+		for j := range s.g[i] {
+			s.g[i][j] = p.A.At(i, j)
+		}
+		s.h[i] = p.B[i]
+	}
+	for k, j := range lowIdx {
+		i := mi + k
+		for l := range s.g[i] {
+			s.g[i][l] = 0
+		}
+		s.g[i][j] = -1
+		s.h[i] = -p.Lower[j]
+	}
+	for k, j := range upIdx {
+		i := mi + len(lowIdx) + k
+		for l := range s.g[i] {
+			s.g[i][l] = 0
+		}
+		s.g[i][j] = 1
+		s.h[i] = p.Upper[j]
+	}
+	s.e = resizeMat(s.e, me, n)
+	s.f = resize(s.f, me)
+	for i := 0; i < me; i++ {
+		for j := range s.e[i] {
+			s.e[i][j] = p.Aeq.At(i, j)
+		}
+		s.f[i] = p.Beq[i]
+	}
+	s.kkt = resizeMat(s.kkt, n+me, n+me)
+	s.rhs = resize(s.rhs, n+me)
+	s.sol = resize(s.sol, n+me)
+	s.work = resize(s.work, m)
+	return mi, me, lowIdx, upIdx
+}
+
+// residuals computes the residuals of the optimality conditions
+//  rd = Q x + c + Eᵀ y + Gᵀ z,
+//  re = E x - f,
+//  ri = G x + s - h.
+func (s *Solver) residuals(c, x, y, z, sl, rd, re, ri []float64) {
+	for j := range rd {
+		rd[j] = floats.Dot(s.q[j], x) + c[j]
+	}
+	for i, row := range s.e {
+		floats.AddScaled(rd, y[i], row)
+		re[i] = floats.Dot(row, x) - s.f[i]
+	}
+	for i, row := range s.g {
+		floats.AddScaled(rd, z[i], row)
+		ri[i] = floats.Dot(row, x) + sl[i] - s.h[i]
+	}
+}
+
+// factorize factorizes the KKT matrix
+//  [Q + Gᵀ W G  Eᵀ]
+//  [E           0 ]
+// with W = diag(z/s).
+func (s *Solver) factorize(z, sl []float64) bool {
+	n := len(s.q)
+	var scale float64
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			s.kkt[i][j] = s.q[i][j]
+		}
+		scale = math.Max(scale, math.Abs(s.q[i][i]))
+	}
+	for k, row := range s.g {
+		w := z[k] / sl[k]
+		for i, gi := range row {
+			if gi == 0 {
+				continue
+			}
+			for j, gj := range row {
+				s.kkt[i][j] += w * gi * gj
+			}
+		}
+	}
+	delta := regularization * math.Max(1, scale)
+	for i := 0; i < n; i++ {
+		s.kkt[i][i] += delta
+	}
+	for i, row := range s.e {
+		for j, v := range row {
+			s.kkt[n+i][j] = v
+			s.kkt[j][n+i] = v
+		}
+		for j := range s.e {
+			s.kkt[n+i][n+j] = 0
+		}
+		s.kkt[n+i][n+i] = -delta
+	}
+	return s.lu.factorize(s.kkt)
+}
+
+// direction computes the Newton direction of the optimality conditions for
+// the complementarity residual rc.
+func (s *Solver) direction(rd, re, ri, rc, z, sl, dx, dy, dz, ds []float64) {
+	n := len(dx)
+	// work = S^{-1} (rc + Z ri).
+	for i := range s.work {
+		s.work[i] = (rc[i] + z[i]*ri[i]) / sl[i]
+	}
+	for j := 0; j < n; j++ {
+		s.rhs[j] = -rd[j]
+	}
+	for i, row := range s.g {
+		floats.AddScaled(s.rhs[:n], -s.work[i], row)
+	}
+	for i := range re {
+		s.rhs[n+i] = -re[i]
+	}
+	s.lu.solve(s.sol, s.rhs)
+	copy(dx, s.sol[:n])
+	copy(dy, s.sol[n:])
+	for i, row := range s.g {
+		gdx := floats.Dot(row, dx)
+		dz[i] = s.work[i] + z[i]/sl[i]*gdx
+		ds[i] = -ri[i] - gdx
+	}
+}
+
+// complementarity returns the mean complementarity (s + α ds)ᵀ (z + α dz) / m.
+func complementarity(sl, z, ds, dz []float64, alpha float64) float64 {
+	var mu float64
+	for i := range sl {
+		mu += (sl[i] + alpha*ds[i]) * (z[i] + alpha*dz[i])
+	}
+	return mu / float64(len(sl))
+}
+
+// minComplementarity returns the smallest element of (s + α ds) ∘ (z + α dz).
+func minComplementarity(sl, z, ds, dz []float64, alpha float64) float64 {
+	min := math.Inf(1)
+	for i := range sl {
+		min = math.Min(min, (sl[i]+alpha*ds[i])*(z[i]+alpha*dz[i]))
+	}
+	return min
+}
+
+// maxStep returns the largest step α such that v + α dv is non-negative.
+func maxStep(v, dv []float64) float64 {
+	alpha := math.Inf(1)
+	for i, d := range dv {
+		if d < 0 {
+			alpha = math.Min(alpha, -v[i]/d)
+		}
+	}
+	return alpha
+}
+
+// lu is an LU factorization with partial pivoting of a dense square matrix.
+type lu struct {
+	a   [][]float64
+	piv []int
+}
+
+// factorize computes the factorization of a, which is overwritten. It
+// returns false if a is numerically singular.
+func (f *lu) factorize(a [][]float64) bool {
+	n := len(a)
+	f.a = a
+	if cap(f.piv) < n {
+		f.piv = make([]int, n)
+	}
+	f.piv = f.piv[:n]
+	var scale float64
+	for _, row := range a {
+		for _, v := range row {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a[i][k]) > math.Abs(a[p][k]) {
+				p = i
+			}
+		}
+		if a[p][k] == 0 || math.Abs(a[p][k]) <= 1e-300*scale {
+			return false
+		}
+		f.piv[k] = p
+		a[k], a[p] = a[p], a[k]
+		for i := k + 1; i < n; i++ {
+			l := a[i][k] / a[k][k]
+			a[i][k] = l
+			if l == 0 {
+				continue
+			}
+			for j := k + 1; j < n; j++ {
+				a[i][j] -= l * a[k][j]
+			}
+		}
+	}
+	return true
+}
+
+// solve stores the solution of the factorized system with the right-hand
+// side b into dst.
+func (f *lu) solve(dst, b []float64) {
+	n := len(f.a)
+	copy(dst, b)
+	for k := 0; k < n; k++ {
+		dst[k], dst[f.piv[k]] = dst[f.piv[k]], dst[k]
+	}
+	for i := 0; i < n; i++ {
+		dst[i] -= floats.Dot(f.a[i][:i], dst[:i])
+	}
+	for i := n - 1; i >= 0; i-- {
+		dst[i] = (dst[i] - floats.Dot(f.a[i][i+1:], dst[i+1:])) / f.a[i][i]
+	}
+}
+
+func resize(x []float64, n int) []float64 {
+	if cap(x) < n {
+		return make([]float64, n)
+	}
+	return x[:n]
+}
+
+func resizeMat(a [][]float64, r, c int) [][]float64 {
+	if cap(a) < r {
+		a = append(a[:cap(a)], make([][]float64, r-cap(a))...)
+	}
+	a = a[:r]
+	for i := range a {
+		a[i] = resize(a[i], c)
+	}
+	return a
+}
@@ -0,0 +1,375 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package qp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestSolve(t *testing.T) {
+	inf := math.Inf(1)
+	for _, test := range []struct {
+		name string
+		p    Problem
+		optF float64
+		optX []float64
+	}{
+		{
+			name: "Unconstrained",
+			p: Problem{
+				Q: mat64.NewSymDense(2, []float64{2, 1, 1, 2}),
+				C: []float64{-1, -1},
+			},
+			optF: -1.0 / 3,
+			optX: []float64{1.0 / 3, 1.0 / 3},
+		},
+		{
+			name: "Equality",
+			p: Problem{
+				Q:   mat64.NewSymDense(3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}),
+				C:   []float64{0, 0, 0},
+				Aeq: mat64.NewDense(1, 3, []float64{1, 1, 1}),
+				Beq: []float64{3},
+			},
+			optF: 1.5,
+			optX: []float64{1, 1, 1},
+		},
+		{
+			// Nocedal & Wright, example 16.4.
+			name: "Inequality",
+			p: Problem{
+				Q: mat64.NewSymDense(2, []float64{2, 0, 0, 2}),
+				C: []float64{-2, -5},
+				A: mat64.NewDense(3, 2, []float64{
+					-1, 2,
+					1, 2,
+					1, -2,
+				}),
+				B:     []float64{2, 6, 2},
+				Lower: []float64{0, 0},
+			},
+			optF: 1.4*1.4 - 2*1.4 + 1.7*1.7 - 5*1.7,
+			optX: []float64{1.4, 1.7},
+		},
+		{
+			name: "Bounds",
+			p: Problem{
+				Q:     mat64.NewSymDense(2, []float64{1, 0, 0, 1}),
+				C:     []float64{-3, 1},
+				Lower: []float64{-inf, 0},
+				Upper: []float64{2, inf},
+			},
+			optF: -4,
+			optX: []float64{2, 0},
+		},
+		{
+			name: "Linear",
+			p: Problem{
+				C:     []float64{-1, -1},
+				A:     mat64.NewDense(2, 2, []float64{1, 2, 3, 1}),
+				B:     []float64{4, 6},
+				Lower: []float64{0, 0},
+			},
+			optF: -2.8,
+			optX: []float64{1.6, 1.2},
+		},
+		{
+			name: "RedundantEquality",
+			p: Problem{
+				Q:   mat64.NewSymDense(2, []float64{1, 0, 0, 1}),
+				C:   []float64{0, 0},
+				Aeq: mat64.NewDense(2, 2, []float64{1, 1, 2, 2}),
+				Beq: []float64{2, 4},
+			},
+			optF: 1,
+			optX: []float64{1, 1},
+		},
+	} {
+		var s Solver
+		res, err := s.Solve(&test.p)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.name, err)
+			continue
+		}
+		if math.Abs(res.F-test.optF) > 1e-6 {
+			t.Errorf("%v: unexpected optimal value: got %v, want %v", test.name, res.F, test.optF)
+		}
+		if !floats.EqualApprox(res.X, test.optX, 1e-6) {
+			t.Errorf("%v: unexpected optimal location: got %v, want %v", test.name, res.X, test.optX)
+		}
+		checkKKT(t, test.name, &test.p, res, 1e-6)
+	}
+}
+
+func TestSolveRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	// The same Solver is reused to test the handling of its workspace.
+	var s Solver
+	for trial := 0; trial < 50; trial++ {
+		n := 1 + rnd.Intn(10)
+		mi := rnd.Intn(2 * n)
+		me := rnd.Intn(n)
+
+		// Q = Lᵀ L with L of rank at most n is positive semidefinite.
+		k := 1 + rnd.Intn(n)
+		l := mat64.NewDense(k, n, nil)
+		for i := 0; i < k; i++ {
+			for j := 0; j < n; j++ {
+				l.Set(i, j, rnd.NormFloat64())
+			}
+		}
+		q := mat64.NewSymDense(n, nil)
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				var v float64
+				for r := 0; r < k; r++ {
+					v += l.At(r, i) * l.At(r, j)
+				}
+				q.SetSym(i, j, v)
+			}
+		}
+
+		// The constraints are satisfied by x0 and the bounds keep the
+		// problem bounded.
+		x0 := make([]float64, n)
+		lower := make([]float64, n)
+		upper := make([]float64, n)
+		c := make([]float64, n)
+		for j := range x0 {
+			x0[j] = rnd.NormFloat64()
+			lower[j] = x0[j] - 1 - rnd.Float64()
+			upper[j] = x0[j] + 1 + rnd.Float64()
+			c[j] = 5 * rnd.NormFloat64()
+		}
+		p := Problem{Q: q, C: c, Lower: lower, Upper: upper}
+		if mi > 0 {
+			a := mat64.NewDense(mi, n, nil)
+			b := make([]float64, mi)
+			for i := 0; i < mi; i++ {
+				for j := 0; j < n; j++ {
+					a.Set(i, j, rnd.NormFloat64())
+				}
+				b[i] = floats.Dot(a.Row(nil, i), x0) + rnd.Float64()
+			}
+			p.A, p.B = a, b
+		}
+		if me > 0 {
+			aeq := mat64.NewDense(me, n, nil)
+			beq := make([]float64, me)
+			for i := 0; i < me; i++ {
+				for j := 0; j < n; j++ {
+					aeq.Set(i, j, rnd.NormFloat64())
+				}
+				beq[i] = floats.Dot(aeq.Row(nil, i), x0)
+			}
+			p.Aeq, p.Beq = aeq, beq
+		}
+
+		res, err := s.Solve(&p)
+		if err != nil {
+			t.Errorf("trial %d: unexpected error: %v", trial, err)
+			continue
+		}
+		checkKKT(t, "random", &p, res, 1e-5)
+	}
+}
+
+func TestSolveFrom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var s Solver
+	var cold, warm int
+	for trial := 0; trial < 20; trial++ {
+		n := 5 + rnd.Intn(10)
+		l := mat64.NewDense(n, n, nil)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				l.Set(i, j, rnd.NormFloat64())
+			}
+		}
+		q := mat64.NewSymDense(n, nil)
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				var v float64
+				for r := 0; r < n; r++ {
+					v += l.At(r, i) * l.At(r, j)
+				}
+				q.SetSym(i, j, v)
+			}
+		}
+		mi := 2 * n
+		a := mat64.NewDense(mi, n, nil)
+		b := make([]float64, mi)
+		for i := 0; i < mi; i++ {
+			for j := 0; j < n; j++ {
+				a.Set(i, j, rnd.NormFloat64())
+			}
+			b[i] = rnd.Float64()
+		}
+		aeq := mat64.NewDense(1, n, nil)
+		for j := 0; j < n; j++ {
+			aeq.Set(0, j, rnd.NormFloat64())
+		}
+		lower := make([]float64, n)
+		upper := make([]float64, n)
+		for j := range lower {
+			lower[j] = -rnd.Float64()
+			upper[j] = math.Inf(1)
+		}
+		upper[0] = 0.1
+		c := make([]float64, n)
+		for j := range c {
+			c[j] = 5 * rnd.NormFloat64()
+		}
+		p := Problem{Q: q, C: c, A: a, B: b, Aeq: aeq, Beq: []float64{0}, Lower: lower, Upper: upper}
+		prev, err := s.Solve(&p)
+		if err != nil {
+			t.Fatalf("trial %d: unexpected error: %v", trial, err)
+		}
+
+		// Solve a sequence of slightly perturbed problems.
+		for k := 0; k < 5; k++ {
+			c := make([]float64, n)
+			for j := range c {
+				c[j] = p.C[j] + 0.01*rnd.NormFloat64()
+			}
+			p.C = c
+			want, err := s.Solve(&p)
+			if err != nil {
+				t.Fatalf("trial %d: unexpected error: %v", trial, err)
+			}
+			res, err := s.SolveFrom(&p, prev)
+			if err != nil {
+				t.Fatalf("trial %d: unexpected error with a warm start: %v", trial, err)
+			}
+			checkKKT(t, "warm start", &p, res, 1e-5)
+			if !floats.EqualApprox(res.X, want.X, 1e-4) {
+				t.Errorf("trial %d: warm start result differs, want %v, got %v", trial, want.X, res.X)
+			}
+			cold += want.Iterations
+			warm += res.Iterations
+			prev = res
+		}
+	}
+	if 3*warm > 2*cold {
+		t.Errorf("warm start not faster: %d iterations, %d without", warm, cold)
+	}
+}
+
+func TestSolveInfeasible(t *testing.T) {
+	p := Problem{
+		Q:     mat64.NewSymDense(2, []float64{1, 0, 0, 1}),
+		C:     []float64{0, 0},
+		A:     mat64.NewDense(1, 2, []float64{1, 1}),
+		B:     []float64{-1},
+		Aeq:   mat64.NewDense(1, 2, []float64{1, -1}),
+		Beq:   []float64{0},
+		Lower: []float64{0, math.Inf(-1)},
+	}
+	var s Solver
+	res, err := s.Solve(&p)
+	if err != ErrInfeasible {
+		t.Fatalf("unexpected error for an infeasible problem: got %v, want %v", err, ErrInfeasible)
+	}
+	// The combined constraint rᵀx <= rhs must be violated by every x with
+	// x_0 >= 0.
+	f := res.Farkas
+	r := make([]float64, 2)
+	rhs := f.Ineq[0]*p.B[0] + f.Eq[0]*p.Beq[0]
+	floats.AddScaled(r, f.Ineq[0], p.A.(*mat64.Dense).Row(nil, 0))
+	floats.AddScaled(r, f.Eq[0], p.Aeq.(*mat64.Dense).Row(nil, 0))
+	if f.Ineq[0] < 0 || r[0] < 0 || r[1] != 0 || rhs >= 0 {
+		t.Errorf("invalid Farkas certificate: Ineq %v, Eq %v", f.Ineq, f.Eq)
+	}
+}
+
+func TestSolveUnbounded(t *testing.T) {
+	p := Problem{
+		// The objective is flat along (1, -1, 0) and decreases along it.
+		Q: mat64.NewSymDense(3, []float64{
+			1, 1, 0,
+			1, 1, 0,
+			0, 0, 1,
+		}),
+		C:     []float64{-1, 1, 0},
+		A:     mat64.NewDense(1, 3, []float64{0, 1, 1}),
+		B:     []float64{1},
+		Lower: []float64{0, math.Inf(-1), math.Inf(-1)},
+	}
+	var s Solver
+	res, err := s.Solve(&p)
+	if err != ErrUnbounded {
+		t.Fatalf("unexpected error for an unbounded problem: got %v, want %v", err, ErrUnbounded)
+	}
+	d := res.Ray
+	qd := make([]float64, 3)
+	for i := range qd {
+		for j, v := range d {
+			qd[i] += p.Q.At(i, j) * v
+		}
+	}
+	if floats.Norm(qd, math.Inf(1)) > 1e-10 || floats.Dot(p.C, d) >= 0 ||
+		floats.Dot(p.A.(*mat64.Dense).Row(nil, 0), d) > 1e-10 || d[0] < 0 {
+		t.Errorf("invalid ray %v", d)
+	}
+}
+
+// checkKKT checks the first-order optimality conditions of the result of p.
+func checkKKT(t *testing.T, name string, p *Problem, res *Result, tol float64) {
+	n := len(p.C)
+	grad := make([]float64, n)
+	copy(grad, p.C)
+	for i := 0; i < n; i++ {
+		if p.Q != nil {
+			for j := 0; j < n; j++ {
+				grad[i] += p.Q.At(i, j) * res.X[j]
+			}
+		}
+		grad[i] += res.Upper[i] - res.Lower[i]
+		if res.Lower[i] < -tol || res.Upper[i] < -tol {
+			t.Errorf("%v: negative bound multiplier", name)
+		}
+		if p.Lower != nil && math.Abs(res.Lower[i]*(res.X[i]-p.Lower[i])) > tol {
+			t.Errorf("%v: lower bound complementarity violated", name)
+		}
+		if p.Upper != nil && math.Abs(res.Upper[i]*(p.Upper[i]-res.X[i])) > tol {
+			t.Errorf("%v: upper bound complementarity violated", name)
+		}
+		if (p.Lower != nil && res.X[i] < p.Lower[i]-tol) || (p.Upper != nil && res.X[i] > p.Upper[i]+tol) {
+			t.Errorf("%v: bounds violated", name)
+		}
+	}
+	if p.A != nil {
+		m, _ := p.A.Dims()
+		for i := 0; i < m; i++ {
+			row := p.A.(*mat64.Dense).Row(nil, i)
+			floats.AddScaled(grad, res.Ineq[i], row)
+			slack := p.B[i] - floats.Dot(row, res.X)
+			if slack < -tol {
+				t.Errorf("%v: inequality constraint %d violated", name, i)
+			}
+			if res.Ineq[i] < -tol || math.Abs(res.Ineq[i]*slack) > tol {
+				t.Errorf("%v: complementarity of inequality constraint %d violated", name, i)
+			}
+		}
+	}
+	if p.Aeq != nil {
+		m, _ := p.Aeq.Dims()
+		for i := 0; i < m; i++ {
+			row := p.Aeq.(*mat64.Dense).Row(nil, i)
+			floats.AddScaled(grad, res.Eq[i], row)
+			if math.Abs(floats.Dot(row, res.X)-p.Beq[i]) > tol {
+				t.Errorf("%v: equality constraint %d violated", name, i)
+			}
+		}
+	}
+	if floats.Norm(grad, math.Inf(1)) > tol*(1+floats.Norm(p.C, math.Inf(1))) {
+		t.Errorf("%v: gradient of the Lagrangian is not zero: %v", name, grad)
+	}
+}
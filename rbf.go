@@ -0,0 +1,145 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// RadialBasis is the basis function of a radial basis function model. Phi
+// returns the value of the basis function at the Euclidean distance r from
+// its center.
+type RadialBasis interface {
+	Phi(r float64) float64
+}
+
+// CubicBasis is the cubic basis function r^3.
+type CubicBasis struct{}
+
+func (CubicBasis) Phi(r float64) float64 {
+	return r * r * r
+}
+
+// ThinPlateBasis is the thin plate spline basis function r^2 log r.
+type ThinPlateBasis struct{}
+
+func (ThinPlateBasis) Phi(r float64) float64 {
+	if r == 0 {
+		return 0
+	}
+	return r * r * math.Log(r)
+}
+
+// MultiquadricBasis is the multiquadric basis function
+//  sqrt(r^2 + Shape^2).
+// If Shape is zero, 1 is used instead.
+type MultiquadricBasis struct {
+	Shape float64
+}
+
+func (m MultiquadricBasis) Phi(r float64) float64 {
+	shape := m.Shape
+	if shape == 0 {
+		shape = 1
+	}
+	return math.Sqrt(r*r + shape*shape)
+}
+
+// RBFModel is a radial basis function model of a function from its values at
+// a set of locations,
+//  s(x) = Σ_i λ_i Basis.Phi(|x - x_i|) + c_0 + Σ_j c_j x_j,
+// where the sum is over the locations x_i. The weights λ and the
+// coefficients c of the linear tail are determined such that s interpolates
+// the function values and λ is orthogonal to the linear polynomials. The
+// linear tail makes the interpolation problem uniquely solvable for the cubic
+// and the thin plate spline bases if the locations are not all on a
+// hyperplane. Unlike a Gaussian process, the model does not quantify its
+// uncertainty, but it is cheap to fit and accurate for smooth functions.
+type RBFModel struct {
+	// Basis is the radial basis function. If Basis is nil, it will be set
+	// to CubicBasis{}.
+	Basis RadialBasis
+	// Smoothing is added to the diagonal of the interpolation matrix. With
+	// a positive Smoothing, the model does not interpolate the function
+	// values exactly, which is useful for noisy functions.
+	Smoothing float64
+
+	x      [][]float64
+	lambda []float64
+	c      []float64 // Coefficients of the linear tail, constant first.
+	lu     rootLU
+}
+
+// Fit fits the model to the function values y at the locations x. The
+// locations are used by the model until the next call to Fit and must not be
+// modified. Fit returns an error if there are fewer locations than the
+// dimension plus one or if the interpolation problem is singular.
+func (m *RBFModel) Fit(x [][]float64, y []float64) error {
+	n := len(x)
+	if len(y) != n {
+		panic("optimize: data size mismatch")
+	}
+	if n == 0 {
+		return errors.New("optimize: no data for RBF model")
+	}
+	if m.Basis == nil {
+		m.Basis = CubicBasis{}
+	}
+	if m.Smoothing < 0 {
+		panic("optimize: negative smoothing")
+	}
+	dim := len(x[0])
+	if n < dim+1 {
+		return errors.New("optimize: too few locations for RBF model")
+	}
+
+	// Set up the saddle point system
+	//  [Φ + Smoothing I  P] [λ]   [y]
+	//  [Pᵀ               0] [c] = [0],
+	// where the rows of P are (1, x_iᵀ).
+	size := n + dim + 1
+	a := mat64.NewDense(size, size, nil)
+	for i := 0; i < n; i++ {
+		a.Set(i, i, m.Basis.Phi(0)+m.Smoothing)
+		for j := i + 1; j < n; j++ {
+			phi := m.Basis.Phi(floats.Distance(x[i], x[j], 2))
+			a.Set(i, j, phi)
+			a.Set(j, i, phi)
+		}
+		a.Set(i, n, 1)
+		a.Set(n, i, 1)
+		for k, v := range x[i] {
+			a.Set(i, n+1+k, v)
+			a.Set(n+1+k, i, v)
+		}
+	}
+	if !m.lu.factorize(a) {
+		return errors.New("optimize: singular RBF interpolation problem")
+	}
+	rhs := make([]float64, size)
+	copy(rhs, y)
+	m.lu.solve(rhs, rhs)
+	m.x = x
+	m.lambda = rhs[:n]
+	m.c = rhs[n:]
+	return nil
+}
+
+// Predict returns the value of the model at x. Predict panics if the model
+// has not been fitted.
+func (m *RBFModel) Predict(x []float64) float64 {
+	if m.lambda == nil {
+		panic("optimize: RBF model not fitted")
+	}
+	s := m.c[0] + floats.Dot(m.c[1:], x)
+	for i, xi := range m.x {
+		s += m.lambda[i] * m.Basis.Phi(floats.Distance(x, xi, 2))
+	}
+	return s
+}
@@ -0,0 +1,169 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/gonum/floats"
+)
+
+// CSVRecorder writes the optimization trajectory to Writer in CSV format.
+// It records the initial location, every major iteration and the optimal
+// location after the optimization, one row each. The first row is a header
+// with the column names
+//  Iteration, MajorIterations, Runtime, FuncEvaluations, GradEvaluations,
+//  HessEvaluations, F, GradientNorm, X0, X1, ...
+// where Iteration is the IterationType of the row, Runtime is in seconds,
+// GradientNorm is the infinity norm of the gradient, or empty if the
+// gradient is not used, and the columns X0, X1, ... of the location are
+// written only if Location is true.
+type CSVRecorder struct {
+	Writer   io.Writer
+	Location bool
+
+	w      *csv.Writer
+	header bool
+	row    []string
+}
+
+// NewCSVRecorder returns a CSVRecorder that writes to w and records the
+// locations.
+func NewCSVRecorder(w io.Writer) *CSVRecorder {
+	return &CSVRecorder{Writer: w, Location: true}
+}
+
+func (r *CSVRecorder) Init() error {
+	r.w = csv.NewWriter(r.Writer)
+	r.header = false
+	return nil
+}
+
+func (r *CSVRecorder) Record(loc *Location, _ EvaluationType, iter IterationType, stats *Stats) error {
+	if iter != MajorIteration && iter != InitIteration && iter != PostIteration {
+		return nil
+	}
+	if !r.header {
+		r.row = append(r.row[:0], "Iteration", "MajorIterations", "Runtime", "FuncEvaluations",
+			"GradEvaluations", "HessEvaluations", "F", "GradientNorm")
+		if r.Location {
+			for i := range loc.X {
+				r.row = append(r.row, "X"+strconv.Itoa(i))
+			}
+		}
+		if err := r.w.Write(r.row); err != nil {
+			return err
+		}
+		r.header = true
+	}
+
+	var gradNorm string
+	if loc.Gradient != nil {
+		gradNorm = formatFloat(floats.Norm(loc.Gradient, math.Inf(1)))
+	}
+	r.row = append(r.row[:0],
+		iter.String(),
+		strconv.Itoa(stats.MajorIterations),
+		formatFloat(stats.Runtime.Seconds()),
+		strconv.Itoa(stats.FuncEvaluations),
+		strconv.Itoa(stats.GradEvaluations),
+		strconv.Itoa(stats.HessEvaluations),
+		formatFloat(loc.F),
+		gradNorm,
+	)
+	if r.Location {
+		for _, v := range loc.X {
+			r.row = append(r.row, formatFloat(v))
+		}
+	}
+	if err := r.w.Write(r.row); err != nil {
+		return err
+	}
+	// Flush every row so that the trajectory is available if the
+	// optimization does not finish.
+	r.w.Flush()
+	return r.w.Error()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// JSONRecorder writes the optimization trajectory to Writer in the JSON
+// lines format. It records the initial location, every major iteration and
+// the optimal location after the optimization as one JSON object per line
+// with the fields of JSONRecord.
+type JSONRecorder struct {
+	Writer io.Writer
+
+	enc *json.Encoder
+}
+
+// JSONRecord is a line written by JSONRecorder.
+type JSONRecord struct {
+	// Iteration is the IterationType of the record.
+	Iteration string
+	// MajorIterations, FuncEvaluations, GradEvaluations and HessEvaluations
+	// are the corresponding Stats.
+	MajorIterations int
+	FuncEvaluations int
+	GradEvaluations int
+	HessEvaluations int
+	// Runtime is the runtime in seconds.
+	Runtime float64
+	// F is the function value, or nil if it is not finite.
+	F *float64
+	// GradientNorm is the infinity norm of the gradient, or nil if the
+	// gradient is not used or is not finite.
+	GradientNorm *float64 `json:",omitempty"`
+	// X is the location. It is omitted in the history of Monitor unless
+	// Monitor.Location is true.
+	X []float64 `json:",omitempty"`
+}
+
+func (r *JSONRecorder) Init() error {
+	r.enc = json.NewEncoder(r.Writer)
+	return nil
+}
+
+func (r *JSONRecorder) Record(loc *Location, _ EvaluationType, iter IterationType, stats *Stats) error {
+	if iter != MajorIteration && iter != InitIteration && iter != PostIteration {
+		return nil
+	}
+	rec := newJSONRecord(loc, iter, stats)
+	rec.X = loc.X
+	// The Encoder terminates every value by a newline.
+	return r.enc.Encode(&rec)
+}
+
+// newJSONRecord returns the JSONRecord of loc without the location.
+func newJSONRecord(loc *Location, iter IterationType, stats *Stats) JSONRecord {
+	rec := JSONRecord{
+		Iteration:       iter.String(),
+		MajorIterations: stats.MajorIterations,
+		FuncEvaluations: stats.FuncEvaluations,
+		GradEvaluations: stats.GradEvaluations,
+		HessEvaluations: stats.HessEvaluations,
+		Runtime:         stats.Runtime.Seconds(),
+		F:               finitePtr(loc.F),
+	}
+	if loc.Gradient != nil {
+		rec.GradientNorm = finitePtr(floats.Norm(loc.Gradient, math.Inf(1)))
+	}
+	return rec
+}
+
+// finitePtr returns a pointer to v if v is finite, and nil otherwise, because
+// JSON cannot represent infinities and NaNs.
+func finitePtr(v float64) *float64 {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,237 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestCSVRecorder(t *testing.T) {
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	var buf bytes.Buffer
+	settings := DefaultSettings()
+	settings.Recorder = NewCSVRecorder(&buf)
+	result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error reading CSV: %v", err)
+	}
+	// Header, initial location, major iterations and optimal location.
+	if len(rows) != result.MajorIterations+3 {
+		t.Fatalf("unexpected number of rows %d for %d major iterations", len(rows), result.MajorIterations)
+	}
+	if len(rows[0]) != 10 || rows[0][9] != "X1" {
+		t.Errorf("unexpected header %v", rows[0])
+	}
+	if rows[1][0] != "InitIteration" || rows[2][0] != "MajorIteration" {
+		t.Errorf("unexpected iteration types %v, %v", rows[1][0], rows[2][0])
+	}
+	last := rows[len(rows)-1]
+	if last[0] != "PostIteration" {
+		t.Errorf("unexpected iteration type of the last row %v", last[0])
+	}
+	if f, _ := strconv.ParseFloat(last[6], 64); f != result.F {
+		t.Errorf("unexpected function value in the last row, want %v, got %v", result.F, last[6])
+	}
+	if n, _ := strconv.Atoi(last[3]); n != result.FuncEvaluations {
+		t.Errorf("unexpected number of evaluations in the last row, want %v, got %v", result.FuncEvaluations, last[3])
+	}
+
+	// Without gradient and location.
+	buf.Reset()
+	settings = DefaultSettings()
+	settings.Recorder = &CSVRecorder{Writer: &buf}
+	_, err = Local(Problem{Func: p.Func}, []float64{-1.2, 1}, settings, &NelderMead{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error reading CSV: %v", err)
+	}
+	if len(rows[0]) != 8 || rows[1][7] != "" {
+		t.Errorf("unexpected columns %v, %v", rows[0], rows[1])
+	}
+}
+
+func TestJSONRecorder(t *testing.T) {
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	var buf bytes.Buffer
+	settings := DefaultSettings()
+	settings.Recorder = &JSONRecorder{Writer: &buf}
+	result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var recs []JSONRecord
+	s := bufio.NewScanner(&buf)
+	for s.Scan() {
+		var rec JSONRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			t.Fatalf("unexpected error decoding %q: %v", s.Text(), err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != result.MajorIterations+2 {
+		t.Fatalf("unexpected number of records %d for %d major iterations", len(recs), result.MajorIterations)
+	}
+	last := recs[len(recs)-1]
+	if last.Iteration != "PostIteration" || last.F == nil || *last.F != result.F ||
+		last.GradientNorm == nil || len(last.X) != 2 || last.FuncEvaluations != result.FuncEvaluations {
+		t.Errorf("unexpected last record %+v", last)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	settings := DefaultSettings()
+	settings.Events = make(chan Event, 1000)
+	var buf bytes.Buffer
+	settings.Recorder = &JSONRecorder{Writer: &buf}
+	result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Errorf("Recorder not called with Events")
+	}
+	events := make([]Event, 0, len(settings.Events))
+	for len(settings.Events) > 0 {
+		events = append(events, <-settings.Events)
+	}
+	// Initial location, major iterations and optimal location.
+	if len(events) != result.MajorIterations+2 {
+		t.Fatalf("unexpected number of events %d for %d major iterations", len(events), result.MajorIterations)
+	}
+	if events[0].Iteration != InitIteration || events[1].Iteration != MajorIteration {
+		t.Errorf("unexpected iteration types %v, %v", events[0].Iteration, events[1].Iteration)
+	}
+	for i, ev := range events[:len(events)-1] {
+		if ev.Status != NotTerminated || ev.Dropped != 0 {
+			t.Errorf("unexpected status %v and %d dropped events in event %d", ev.Status, ev.Dropped, i)
+		}
+	}
+	if events[0].Location.X[0] != -1.2 {
+		t.Errorf("event location not copied")
+	}
+	last := events[len(events)-1]
+	if last.Iteration != PostIteration || last.Status != result.Status || last.Err != nil {
+		t.Errorf("unexpected last event %v, %v, %v", last.Iteration, last.Status, last.Err)
+	}
+	if last.Location.F != result.F || last.Stats.FuncEvaluations != result.FuncEvaluations {
+		t.Errorf("last event does not match the result")
+	}
+
+	// With a full channel, the oldest events are dropped and the run does
+	// not block.
+	settings = DefaultSettings()
+	settings.Events = make(chan Event, 1)
+	settings.Maximize = true
+	neg := Problem{
+		Func: func(x []float64) float64 { return -p.Func(x) },
+		Grad: func(x, grad []float64) {
+			p.Grad(x, grad)
+			for i := range grad {
+				grad[i] = -grad[i]
+			}
+		},
+	}
+	result, err = Local(neg, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last = <-settings.Events
+	if last.Iteration != PostIteration || last.Dropped != result.MajorIterations+1 {
+		t.Errorf("unexpected last event %v with %d dropped events", last.Iteration, last.Dropped)
+	}
+	if last.Location.F != result.F || last.Location.F > 0 {
+		t.Errorf("unexpected function value %v of the maximization", last.Location.F)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	p := Problem{
+		Func: functions.ExtendedRosenbrock{}.Func,
+		Grad: functions.ExtendedRosenbrock{}.Grad,
+	}
+	var buf bytes.Buffer
+	monitor := &Monitor{History: 5, Recorder: &JSONRecorder{Writer: &buf}}
+	server := httptest.NewServer(monitor)
+	defer server.Close()
+
+	settings := DefaultSettings()
+	settings.Recorder = monitor
+	var running MonitorState
+	settings.Callback = func(iter int, loc *Location) (Status, error) {
+		if iter == 3 {
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				return Failure, err
+			}
+			defer resp.Body.Close()
+			return NotTerminated, json.NewDecoder(resp.Body).Decode(&running)
+		}
+		return NotTerminated, nil
+	}
+	result, err := Local(p, []float64{-1.2, 1}, settings, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Errorf("Recorder not called by Monitor")
+	}
+	if !running.Running || running.Current == nil || running.Current.MajorIterations != 3 {
+		t.Errorf("unexpected state during the run %+v", running)
+	}
+	// The initial location and three major iterations.
+	if len(running.History) != 4 || running.History[0].Iteration != "InitIteration" {
+		t.Errorf("unexpected history during the run %+v", running.History)
+	}
+
+	state := monitor.State()
+	if state.Running {
+		t.Errorf("run not finished")
+	}
+	if state.BestF == nil || *state.BestF != result.F || !floats.Equal(state.BestX, result.X) {
+		t.Errorf("unexpected best location %v, %v", state.BestF, state.BestX)
+	}
+	if len(state.History) != 5 {
+		t.Fatalf("unexpected history length %d", len(state.History))
+	}
+	last := state.History[4]
+	if last.Iteration != "PostIteration" || last.X != nil {
+		t.Errorf("unexpected last record %+v", last)
+	}
+	for i := 1; i < 4; i++ {
+		if state.History[i].MajorIterations != state.History[i-1].MajorIterations+1 {
+			t.Errorf("history not in order")
+		}
+	}
+	if state.Elapsed <= 0 {
+		t.Errorf("unexpected elapsed time %v", state.Elapsed)
+	}
+}
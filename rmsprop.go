@@ -0,0 +1,79 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const (
+	defaultRMSPropLearningRate = 0.001
+	defaultRMSPropDecay        = 0.9
+)
+
+// RMSProp implements the RMSProp stochastic gradient method. It takes the
+// step
+//  x_{k+1} = x_k - η_k g_k / (sqrt(v_k) + ε)
+// componentwise, where v_k is the exponential moving average of the squared
+// gradients with the decay factor Decay. RMSProp is intended for Problems
+// obtained from StochasticProblem.Problem, and every step is a major
+// iteration.
+//
+// References:
+//  - Tieleman, T., Hinton, G.: Lecture 6.5 - RMSProp. COURSERA: Neural
+//    Networks for Machine Learning (2012)
+type RMSProp struct {
+	// LearningRate is the learning rate schedule η_k. If LearningRate is
+	// nil, it will be set to ConstantLearningRate(0.001).
+	LearningRate LearningRateSchedule
+	// Decay is the decay factor of the moving average. If Decay is zero, it
+	// will be set to 0.9. RMSProp panics if Decay is not in [0, 1).
+	Decay float64
+	// Epsilon is the regularization of the denominator. If Epsilon is zero,
+	// it will be set to 1e-8.
+	Epsilon float64
+
+	k int
+	v []float64 // Moving average of the squared gradients.
+}
+
+func (r *RMSProp) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if r.LearningRate == nil {
+		r.LearningRate = ConstantLearningRate(defaultRMSPropLearningRate)
+	}
+	if r.Decay == 0 {
+		r.Decay = defaultRMSPropDecay
+	}
+	if r.Decay < 0 || r.Decay >= 1 {
+		panic("rmsprop: decay factor must be in [0, 1)")
+	}
+	if r.Epsilon == 0 {
+		r.Epsilon = defaultStochasticEps
+	}
+	r.v = resize(r.v, len(loc.X))
+	for i := range r.v {
+		r.v[i] = 0
+	}
+	r.k = 0
+	return r.Iterate(loc, xNext)
+}
+
+func (r *RMSProp) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	r.k++
+	eta := r.LearningRate.LearningRate(r.k)
+	for i, g := range loc.Gradient {
+		r.v[i] = r.Decay*r.v[i] + (1-r.Decay)*g*g
+		xNext[i] = loc.X[i] - eta*g/(math.Sqrt(r.v[i])+r.Epsilon)
+	}
+	return FuncEvaluation | GradEvaluation, MajorIteration, nil
+}
+
+func (*RMSProp) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
@@ -0,0 +1,359 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// RootProblem describes a system of n nonlinear equations
+//  F(x) = 0
+// in n unknowns.
+type RootProblem struct {
+	// Func evaluates F at x and stores the result in-place in dst. Func
+	// must not modify x.
+	Func func(x, dst []float64)
+	// Jacobian evaluates the n×n Jacobian of F at x and stores the result
+	// in-place in jac. Jacobian must not modify x. If Jacobian is nil, it is
+	// approximated by forward differences, and the evaluations of Func are
+	// counted in Stats.FuncEvaluations.
+	Jacobian func(x []float64, jac *mat64.Dense)
+	// JacobianSparsity is the sparsity pattern of the Jacobian. If it is not
+	// nil and Jacobian is nil, the forward differences are computed with a
+	// SparseJacobian, which needs one evaluation of Func per column group
+	// instead of one per unknown.
+	JacobianSparsity *SparsityPattern
+}
+
+// RootLocation is a location of a RootMethod.
+type RootLocation struct {
+	X        []float64
+	F        []float64
+	Jacobian *mat64.Dense
+}
+
+// RootMethod is a method for solving systems of nonlinear equations. Like
+// Method, it communicates with Root by returning the evaluations it needs at
+// xNext. FuncEvaluation requests the value of F and GradEvaluation requests
+// the Jacobian. The IterationType refers to the location evaluated at xNext,
+// and a MajorIteration must be a location at which F and the Jacobian, or
+// its approximation by the method, are known.
+type RootMethod interface {
+	// Init initializes the method at loc, where F is known, and stores
+	// the next location to evaluate in xNext.
+	Init(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error)
+	// Iterate stores the next location to evaluate in xNext after the
+	// evaluations requested by the last call have been stored in loc.
+	Iterate(loc *RootLocation, xNext []float64) (EvaluationType, IterationType, error)
+}
+
+// RootSettings are the settings of Root.
+type RootSettings struct {
+	// Tolerance is the tolerance on the infinity norm of F. Root terminates
+	// with status MethodConverge when |F(x)|_∞ ≤ Tolerance at a major
+	// iteration.
+	Tolerance float64
+
+	// MajorIterations, FuncEvaluations and Runtime are the budgets of the
+	// run. Root terminates with status IterationLimit,
+	// FunctionEvaluationLimit or RuntimeLimit when they are exhausted. If a
+	// budget is zero, it is not limited.
+	MajorIterations int
+	FuncEvaluations int
+	Runtime         time.Duration
+
+	// Recorder records the progress of the run. The recorded Location has
+	// the objective function ½ |F(x)|_2^2 as its value, and no gradient.
+	Recorder Recorder
+}
+
+// DefaultRootSettings returns the default settings of Root.
+func DefaultRootSettings() *RootSettings {
+	return &RootSettings{
+		Tolerance:       1e-10,
+		MajorIterations: 1000,
+	}
+}
+
+// RootResult is the result of Root.
+type RootResult struct {
+	// X is the solution and F is the value of F at X.
+	X []float64
+	F []float64
+	// Stats are the statistics of the run. The evaluations of the
+	// Jacobian are counted in GradEvaluations.
+	Stats
+	Status Status
+}
+
+// Root finds a solution of the system of nonlinear equations p starting from
+// initX with the given method. If settings is nil, DefaultRootSettings is
+// used. If method is nil, Hybrid is used.
+//
+// Root returns the location with the smallest norm of F found at a major
+// iteration. The error is non-nil if the method has failed, in which case
+// the status is Failure.
+func Root(p RootProblem, initX []float64, settings *RootSettings, method RootMethod) (*RootResult, error) {
+	if p.Func == nil {
+		panic("optimize: root function is undefined")
+	}
+	n := len(initX)
+	if n == 0 {
+		panic("optimize: initial X has zero length")
+	}
+	startTime := time.Now()
+	if settings == nil {
+		settings = DefaultRootSettings()
+	}
+	if method == nil {
+		method = &Hybrid{}
+	}
+	if settings.Recorder != nil {
+		if err := settings.Recorder.Init(); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &rootDriver{
+		p:         p,
+		settings:  settings,
+		stats:     &Stats{},
+		startTime: startTime,
+		fx:        make([]float64, n),
+	}
+	if p.Jacobian == nil && p.JacobianSparsity != nil {
+		r.fd = NewSparseJacobian(n, n, *p.JacobianSparsity)
+	}
+	loc := newRootLocation(n)
+	copy(loc.X, initX)
+	p.Func(loc.X, loc.F)
+	r.stats.FuncEvaluations++
+	optLoc := newRootLocation(n)
+	copy(optLoc.X, loc.X)
+	copy(optLoc.F, loc.F)
+
+	status, err := r.record(loc, FuncEvaluation, InitIteration)
+	if status == NotTerminated && err == nil {
+		status, err = r.solve(method, loc, optLoc)
+	}
+	r.stats.Runtime = time.Since(startTime)
+	if settings.Recorder != nil && err == nil {
+		err = settings.Recorder.Record(r.location(optLoc), NoEvaluation, PostIteration, r.stats)
+	}
+	return &RootResult{
+		X:      optLoc.X,
+		F:      optLoc.F,
+		Stats:  *r.stats,
+		Status: status,
+	}, err
+}
+
+// rootDriver evaluates a RootProblem for the RootMethod and checks the
+// termination criteria.
+type rootDriver struct {
+	p         RootProblem
+	settings  *RootSettings
+	stats     *Stats
+	startTime time.Time
+
+	fx  []float64 // F at the location of a finite-difference Jacobian.
+	tmp []float64
+	fd  *SparseJacobian
+	rec Location
+}
+
+func (r *rootDriver) solve(method RootMethod, loc, optLoc *RootLocation) (Status, error) {
+	n := len(loc.X)
+	xNext := make([]float64, n)
+	evalType, iterType, err := method.Init(loc, xNext)
+	for {
+		if err != nil {
+			return Failure, err
+		}
+		r.evaluate(evalType, xNext, loc)
+		if iterType == MajorIteration {
+			r.stats.MajorIterations++
+			if floats.Norm(loc.F, 2) < floats.Norm(optLoc.F, 2) {
+				copy(optLoc.X, loc.X)
+				copy(optLoc.F, loc.F)
+			}
+		}
+		var status Status
+		status, err = r.record(loc, evalType, iterType)
+		if status != NotTerminated || err != nil {
+			return status, err
+		}
+		evalType, iterType, err = method.Iterate(loc, xNext)
+	}
+}
+
+// evaluate performs the evaluations of evalType at xNext and stores the
+// results in loc.
+func (r *rootDriver) evaluate(evalType EvaluationType, xNext []float64, loc *RootLocation) {
+	if evalType == NoEvaluation {
+		return
+	}
+	moved := !floats.Equal(loc.X, xNext)
+	copy(loc.X, xNext)
+	if evalType&FuncEvaluation != 0 || (moved && r.p.Jacobian == nil) {
+		// A finite-difference Jacobian needs F at the location.
+		r.p.Func(loc.X, loc.F)
+		r.stats.FuncEvaluations++
+	}
+	if evalType&GradEvaluation != 0 {
+		if loc.Jacobian == nil {
+			n := len(loc.X)
+			loc.Jacobian = mat64.NewDense(n, n, nil)
+		}
+		if r.p.Jacobian != nil {
+			r.p.Jacobian(loc.X, loc.Jacobian)
+		} else {
+			r.fdJacobian(loc)
+		}
+		r.stats.GradEvaluations++
+	}
+}
+
+// fdJacobian approximates the Jacobian at loc by forward differences.
+func (r *rootDriver) fdJacobian(loc *RootLocation) {
+	if r.fd != nil {
+		r.fd.Jacobian(loc.Jacobian, r.p.Func, loc.X, loc.F)
+		r.stats.FuncEvaluations += r.fd.Colors()
+		return
+	}
+	const h = 1.4901161193847656e-08 // Square root of the machine epsilon.
+	n := len(loc.X)
+	r.tmp = resize(r.tmp, n)
+	x := r.tmp
+	copy(x, loc.X)
+	for j := 0; j < n; j++ {
+		step := h * math.Max(1, math.Abs(x[j]))
+		x[j] += step
+		r.p.Func(x, r.fx)
+		r.stats.FuncEvaluations++
+		for i := 0; i < n; i++ {
+			loc.Jacobian.Set(i, j, (r.fx[i]-loc.F[i])/step)
+		}
+		x[j] = loc.X[j]
+	}
+}
+
+// record sends loc to the Recorder and returns the termination status.
+func (r *rootDriver) record(loc *RootLocation, evalType EvaluationType, iterType IterationType) (Status, error) {
+	r.stats.Runtime = time.Since(r.startTime)
+	if r.settings.Recorder != nil {
+		if err := r.settings.Recorder.Record(r.location(loc), evalType, iterType, r.stats); err != nil {
+			return Failure, err
+		}
+	}
+	s := r.settings
+	if iterType == MajorIteration || iterType == InitIteration {
+		if floats.Norm(loc.F, math.Inf(1)) <= s.Tolerance {
+			return MethodConverge, nil
+		}
+		if s.MajorIterations > 0 && r.stats.MajorIterations >= s.MajorIterations {
+			return IterationLimit, nil
+		}
+	}
+	if s.FuncEvaluations > 0 && r.stats.FuncEvaluations >= s.FuncEvaluations {
+		return FunctionEvaluationLimit, nil
+	}
+	if s.Runtime > 0 && r.stats.Runtime >= s.Runtime {
+		return RuntimeLimit, nil
+	}
+	return NotTerminated, nil
+}
+
+// location returns loc as a Location for the Recorder.
+func (r *rootDriver) location(loc *RootLocation) *Location {
+	r.rec.X = loc.X
+	r.rec.F = 0.5 * floats.Dot(loc.F, loc.F)
+	return &r.rec
+}
+
+func newRootLocation(n int) *RootLocation {
+	return &RootLocation{
+		X: make([]float64, n),
+		F: make([]float64, n),
+	}
+}
+
+// rootLU is an LU factorization with partial pivoting of the square matrices
+// in the root-finding methods.
+type rootLU struct {
+	n   int
+	a   []float64 // Factors in row-major order.
+	piv []int
+}
+
+// factorize computes the LU factorization of a and returns false if a is
+// singular to working precision.
+func (lu *rootLU) factorize(a mat64.Matrix) bool {
+	n, _ := a.Dims()
+	lu.n = n
+	lu.a = resize(lu.a, n*n)
+	if cap(lu.piv) < n {
+		lu.piv = make([]int, n)
+	}
+	lu.piv = lu.piv[:n]
+	var scale float64
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			v := a.At(i, j)
+			lu.a[i*n+j] = v
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	if scale == 0 {
+		return false
+	}
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(lu.a[i*n+k]) > math.Abs(lu.a[p*n+k]) {
+				p = i
+			}
+		}
+		lu.piv[k] = p
+		if p != k {
+			for j := 0; j < n; j++ {
+				lu.a[k*n+j], lu.a[p*n+j] = lu.a[p*n+j], lu.a[k*n+j]
+			}
+		}
+		d := lu.a[k*n+k]
+		if math.Abs(d) <= 1e-14*scale {
+			return false
+		}
+		for i := k + 1; i < n; i++ {
+			l := lu.a[i*n+k] / d
+			lu.a[i*n+k] = l
+			if l == 0 {
+				continue
+			}
+			floats.AddScaled(lu.a[i*n+k+1:(i+1)*n], -l, lu.a[k*n+k+1:(k+1)*n])
+		}
+	}
+	return true
+}
+
+// solve stores the solution of A x = b in dst. dst and b may be the same.
+func (lu *rootLU) solve(dst, b []float64) {
+	n := lu.n
+	copy(dst, b)
+	for k, p := range lu.piv {
+		dst[k], dst[p] = dst[p], dst[k]
+	}
+	for i := 1; i < n; i++ {
+		dst[i] -= floats.Dot(lu.a[i*n:i*n+i], dst[:i])
+	}
+	for i := n - 1; i >= 0; i-- {
+		dst[i] -= floats.Dot(lu.a[i*n+i+1:(i+1)*n], dst[i+1:])
+		dst[i] /= lu.a[i*n+i]
+	}
+}
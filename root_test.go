@@ -0,0 +1,206 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+type rootTest struct {
+	name string
+	p    RootProblem
+	x    []float64
+	root []float64 // nil if the root is not known in closed form.
+}
+
+func rootTests() []rootTest {
+	const n = 10
+	broydenTridiagonal := RootProblem{
+		Func: func(x, dst []float64) {
+			for i := range x {
+				v := (3-2*x[i])*x[i] + 1
+				if i > 0 {
+					v -= x[i-1]
+				}
+				if i < len(x)-1 {
+					v -= 2 * x[i+1]
+				}
+				dst[i] = v
+			}
+		},
+		Jacobian: func(x []float64, jac *mat64.Dense) {
+			for i := range x {
+				for j := range x {
+					jac.Set(i, j, 0)
+				}
+				jac.Set(i, i, 3-4*x[i])
+				if i > 0 {
+					jac.Set(i, i-1, -1)
+				}
+				if i < len(x)-1 {
+					jac.Set(i, i+1, -2)
+				}
+			}
+		},
+	}
+	x0 := make([]float64, n)
+	for i := range x0 {
+		x0[i] = -1
+	}
+	return []rootTest{
+		{
+			name: "Rosenbrock",
+			p: RootProblem{
+				Func: func(x, dst []float64) {
+					dst[0] = 10 * (x[1] - x[0]*x[0])
+					dst[1] = 1 - x[0]
+				},
+				Jacobian: func(x []float64, jac *mat64.Dense) {
+					jac.Set(0, 0, -20*x[0])
+					jac.Set(0, 1, 10)
+					jac.Set(1, 0, -1)
+					jac.Set(1, 1, 0)
+				},
+			},
+			x:    []float64{-1.2, 1},
+			root: []float64{1, 1},
+		},
+		{
+			name: "PowellBadlyScaled",
+			p: RootProblem{
+				Func: func(x, dst []float64) {
+					dst[0] = 1e4*x[0]*x[1] - 1
+					dst[1] = math.Exp(-x[0]) + math.Exp(-x[1]) - 1.0001
+				},
+				Jacobian: func(x []float64, jac *mat64.Dense) {
+					jac.Set(0, 0, 1e4*x[1])
+					jac.Set(0, 1, 1e4*x[0])
+					jac.Set(1, 0, -math.Exp(-x[0]))
+					jac.Set(1, 1, -math.Exp(-x[1]))
+				},
+			},
+			x: []float64{0, 1},
+		},
+		{
+			name: "BroydenTridiagonal",
+			p:    broydenTridiagonal,
+			x:    x0,
+		},
+	}
+}
+
+func TestRoot(t *testing.T) {
+	for _, method := range []struct {
+		name string
+		new  func() RootMethod
+	}{
+		{"NewtonRaphson", func() RootMethod { return &NewtonRaphson{} }},
+		{"Broyden", func() RootMethod { return &Broyden{} }},
+		{"Hybrid", func() RootMethod { return &Hybrid{} }},
+	} {
+		for _, test := range rootTests() {
+			for _, fd := range []bool{false, true} {
+				p := test.p
+				if fd {
+					p.Jacobian = nil
+				}
+				name := method.name + "/" + test.name
+				if fd {
+					name += "/FD"
+				}
+				result, err := Root(p, test.x, nil, method.new())
+				if err != nil {
+					t.Errorf("%s: unexpected error: %v", name, err)
+					continue
+				}
+				if result.Status != MethodConverge {
+					t.Errorf("%s: unexpected status %v", name, result.Status)
+				}
+				f := make([]float64, len(test.x))
+				p.Func(result.X, f)
+				if !floats.Equal(f, result.F) {
+					t.Errorf("%s: F does not match X", name)
+				}
+				if norm := floats.Norm(f, math.Inf(1)); norm > 1e-10 {
+					t.Errorf("%s: |F| = %v at the returned location", name, norm)
+				}
+				if test.root != nil && !floats.EqualApprox(result.X, test.root, 1e-8) {
+					t.Errorf("%s: unexpected root. Want %v, got %v", name, test.root, result.X)
+				}
+				if fd && result.GradEvaluations > 0 && result.FuncEvaluations <= len(test.x)*result.GradEvaluations {
+					t.Errorf("%s: finite-difference evaluations not counted", name)
+				}
+			}
+		}
+	}
+}
+
+func TestRootSingular(t *testing.T) {
+	// F has no root and its Jacobian is singular at the minimum of |F|.
+	p := RootProblem{
+		Func: func(x, dst []float64) {
+			dst[0] = x[0]*x[0] + 1
+			dst[1] = x[1]
+		},
+		Jacobian: func(x []float64, jac *mat64.Dense) {
+			jac.Set(0, 0, 2*x[0])
+			jac.Set(0, 1, 0)
+			jac.Set(1, 0, 0)
+			jac.Set(1, 1, 1)
+		},
+	}
+	for _, method := range []RootMethod{&NewtonRaphson{}, &Broyden{}, &Hybrid{}} {
+		result, err := Root(p, []float64{0.5, 1}, nil, method)
+		if err == nil {
+			t.Errorf("%T: expected an error, got status %v at %v", method, result.Status, result.X)
+		}
+	}
+}
+
+func TestRootSparseJacobian(t *testing.T) {
+	var test rootTest
+	for _, v := range rootTests() {
+		if v.name == "BroydenTridiagonal" {
+			test = v
+		}
+	}
+	n := len(test.x)
+	var pattern SparsityPattern
+	for i := 0; i < n; i++ {
+		for j := i - 1; j <= i+1; j++ {
+			if j >= 0 && j < n {
+				pattern.Row = append(pattern.Row, i)
+				pattern.Col = append(pattern.Col, j)
+			}
+		}
+	}
+	dense := test.p
+	dense.Jacobian = nil
+	sparse := dense
+	sparse.JacobianSparsity = &pattern
+
+	want, err := Root(dense, test.x, nil, &NewtonRaphson{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := Root(sparse, test.x, nil, &NewtonRaphson{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != MethodConverge || !floats.EqualApprox(result.X, want.X, 1e-8) {
+		t.Errorf("unexpected root %v with status %v", result.X, result.Status)
+	}
+	// A tridiagonal Jacobian needs three evaluations of F.
+	if result.FuncEvaluations > result.MajorIterations+1+3*result.GradEvaluations {
+		t.Errorf("unexpected number of function evaluations %d for %d Jacobians", result.FuncEvaluations, result.GradEvaluations)
+	}
+	if result.FuncEvaluations >= want.FuncEvaluations {
+		t.Errorf("sparse Jacobian needs %d function evaluations, dense %d", result.FuncEvaluations, want.FuncEvaluations)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Sampler generates points in a box, for example the starting points of
+// MultiStart or the initial population of a population-based method. The
+// samplers in this package are deterministic: LatinHypercube returns the
+// same points for the same Src seed, and the quasi-random samplers Sobol and
+// Halton always return the same points for the same Skip.
+type Sampler interface {
+	// Sample fills the rows of dst with points in the box given by b. All
+	// bounds of b must be finite, and the rows of dst must have the length
+	// of the bounds.
+	Sample(dst [][]float64, b *Bounds)
+}
+
+// LatinHypercube generates Latin hypercube samples. For n points, every
+// coordinate interval of the box is divided into n strata of equal width,
+// and every stratum contains exactly one point along every coordinate. The
+// points are placed uniformly at random within their strata.
+type LatinHypercube struct {
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from the global source of math/rand.
+	Src rand.Source
+}
+
+func (l LatinHypercube) Sample(dst [][]float64, b *Bounds) {
+	n := len(dst)
+	if n == 0 {
+		return
+	}
+	dim := len(dst[0])
+	checkSampleBox(b, dim)
+	var rnd *rand.Rand
+	if l.Src != nil {
+		rnd = rand.New(l.Src)
+	} else {
+		rnd = rand.New(rand.NewSource(rand.Int63()))
+	}
+	for j := 0; j < dim; j++ {
+		lo, hi := b.lower(j), b.upper(j)
+		for i, stratum := range rnd.Perm(n) {
+			u := (float64(stratum) + rnd.Float64()) / float64(n)
+			dst[i][j] = lo + u*(hi-lo)
+		}
+	}
+}
+
+// Sobol generates points of the Sobol quasi-random sequence with the
+// direction numbers of Joe and Kuo. Sample returns the len(dst) points of
+// the sequence that follow the origin and the Skip points after it. Sobol
+// panics if the dimension is larger than 21.
+//
+// References:
+//  - Joe, S., Kuo, F.Y.: Constructing Sobol sequences with better
+//    two-dimensional projections. SIAM J. Sci. Comput. 30 (2008), 2635-2654
+type Sobol struct {
+	// Skip is the number of points skipped after the origin. Sampling
+	// again with Skip increased by the number of sampled points continues
+	// the sequence.
+	Skip int
+}
+
+// sobolDirections holds the degree s, the coefficients a and the initial
+// direction numbers m of the primitive polynomials of the dimensions after
+// the first one.
+var sobolDirections = []struct {
+	s, a int
+	m    []uint32
+}{
+	{1, 0, []uint32{1}},
+	{2, 1, []uint32{1, 3}},
+	{3, 1, []uint32{1, 3, 1}},
+	{3, 2, []uint32{1, 1, 1}},
+	{4, 1, []uint32{1, 1, 3, 3}},
+	{4, 4, []uint32{1, 3, 5, 13}},
+	{5, 2, []uint32{1, 1, 5, 5, 17}},
+	{5, 4, []uint32{1, 1, 5, 5, 5}},
+	{5, 7, []uint32{1, 1, 7, 11, 19}},
+	{5, 11, []uint32{1, 1, 5, 1, 1}},
+	{5, 13, []uint32{1, 1, 1, 3, 11}},
+	{5, 14, []uint32{1, 3, 5, 5, 31}},
+	{6, 1, []uint32{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint32{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint32{1, 3, 1, 13, 27, 49}},
+	{6, 19, []uint32{1, 1, 1, 15, 7, 5}},
+	{6, 22, []uint32{1, 3, 1, 15, 13, 25}},
+	{6, 25, []uint32{1, 1, 5, 5, 19, 61}},
+	{7, 1, []uint32{1, 3, 7, 11, 23, 15, 103}},
+	{7, 4, []uint32{1, 3, 7, 13, 13, 15, 69}},
+}
+
+const sobolBits = 32
+
+func (sb Sobol) Sample(dst [][]float64, b *Bounds) {
+	n := len(dst)
+	if n == 0 {
+		return
+	}
+	dim := len(dst[0])
+	if dim > len(sobolDirections)+1 {
+		panic("optimize: dimension too large for Sobol sequence")
+	}
+	if sb.Skip < 0 {
+		panic("optimize: negative skip")
+	}
+	checkSampleBox(b, dim)
+
+	// Direction numbers v[j][k] of the dimension j for the bit k.
+	v := make([][sobolBits]uint32, dim)
+	for k := range v[0] {
+		v[0][k] = 1 << uint(sobolBits-1-k)
+	}
+	for j := 1; j < dim; j++ {
+		d := sobolDirections[j-1]
+		for k := range v[j] {
+			if k < d.s {
+				v[j][k] = d.m[k] << uint(sobolBits-1-k)
+				continue
+			}
+			v[j][k] = v[j][k-d.s] ^ (v[j][k-d.s] >> uint(d.s))
+			for l := 1; l < d.s; l++ {
+				v[j][k] ^= uint32((d.a>>uint(d.s-1-l))&1) * v[j][k-l]
+			}
+		}
+	}
+
+	// Generate the points in Gray code order, skipping the origin. The
+	// point with index i is the XOR of the direction numbers of the set
+	// bits of the Gray code of i.
+	x := make([]uint32, dim)
+	gray := sb.Skip ^ (sb.Skip >> 1)
+	for k := 0; gray != 0; k, gray = k+1, gray>>1 {
+		if k >= sobolBits {
+			panic("optimize: too many Sobol points")
+		}
+		if gray&1 == 1 {
+			for j := range x {
+				x[j] ^= v[j][k]
+			}
+		}
+	}
+	for i := sb.Skip; i < sb.Skip+n; i++ {
+		// c is the index of the rightmost zero bit of i.
+		var c int
+		for m := i; m&1 == 1; m >>= 1 {
+			c++
+		}
+		if c >= sobolBits {
+			panic("optimize: too many Sobol points")
+		}
+		for j := range x {
+			x[j] ^= v[j][c]
+			u := float64(x[j]) / (1 << sobolBits)
+			lo, hi := b.lower(j), b.upper(j)
+			dst[i-sb.Skip][j] = lo + u*(hi-lo)
+		}
+	}
+}
+
+// Halton generates points of the Halton quasi-random sequence, whose j-th
+// coordinate is the radical inverse of the point index in the base of the
+// j-th prime. Sample returns the len(dst) points of the sequence that follow
+// the origin and the Skip points after it. The points of the Halton sequence
+// are correlated along coordinates with large bases, so Sobol is usually
+// preferable in more than about ten dimensions.
+//
+// References:
+//  - Halton, J.H.: On the efficiency of certain quasi-random sequences of
+//    points in evaluating multi-dimensional integrals. Numer. Math. 2
+//    (1960), 84-90
+type Halton struct {
+	// Skip is the number of points skipped after the origin. Sampling
+	// again with Skip increased by the number of sampled points continues
+	// the sequence.
+	Skip int
+}
+
+func (h Halton) Sample(dst [][]float64, b *Bounds) {
+	n := len(dst)
+	if n == 0 {
+		return
+	}
+	dim := len(dst[0])
+	if h.Skip < 0 {
+		panic("optimize: negative skip")
+	}
+	checkSampleBox(b, dim)
+	bases := primes(dim)
+	for i := range dst {
+		idx := h.Skip + i + 1
+		for j, base := range bases {
+			u := radicalInverse(idx, base)
+			lo, hi := b.lower(j), b.upper(j)
+			dst[i][j] = lo + u*(hi-lo)
+		}
+	}
+}
+
+// radicalInverse returns the number in [0, 1) whose digits in base are the
+// digits of i in reverse order after the radix point.
+func radicalInverse(i, base int) float64 {
+	var u float64
+	inv := 1 / float64(base)
+	f := inv
+	for ; i > 0; i /= base {
+		u += float64(i%base) * f
+		f *= inv
+	}
+	return u
+}
+
+// primes returns the first n prime numbers.
+func primes(n int) []int {
+	p := make([]int, 0, n)
+outer:
+	for c := 2; len(p) < n; c++ {
+		for _, q := range p {
+			if q*q > c {
+				break
+			}
+			if c%q == 0 {
+				continue outer
+			}
+		}
+		p = append(p, c)
+	}
+	return p
+}
+
+// checkSampleBox panics if b is not a finite box of dimension dim.
+func checkSampleBox(b *Bounds, dim int) {
+	if b == nil || len(b.Lower) != dim || len(b.Upper) != dim {
+		panic("optimize: sample box size mismatch")
+	}
+	b.validate(dim)
+	for i := 0; i < dim; i++ {
+		if math.IsInf(b.Lower[i], 0) || math.IsInf(b.Upper[i], 0) {
+			panic("optimize: infinite sample box")
+		}
+	}
+}
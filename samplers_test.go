@@ -0,0 +1,106 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestSamplers(t *testing.T) {
+	b := &Bounds{Lower: []float64{0, -1, 2}, Upper: []float64{1, 1, 10}}
+	const strata = 16
+	for _, test := range []struct {
+		sampler Sampler
+		n       int
+	}{
+		{LatinHypercube{Src: rand.NewSource(1)}, strata},
+		// The origin is skipped, so the next point would share a stratum.
+		{Sobol{}, strata - 1},
+	} {
+		dst := make([][]float64, test.n)
+		for i := range dst {
+			dst[i] = make([]float64, 3)
+		}
+		test.sampler.Sample(dst, b)
+		// The points are in different strata along every coordinate.
+		for j := 0; j < 3; j++ {
+			lo, hi := b.Lower[j], b.Upper[j]
+			seen := make([]bool, strata)
+			for _, x := range dst {
+				if x[j] < lo || x[j] > hi {
+					t.Fatalf("%T: point %v outside bounds", test.sampler, x)
+				}
+				k := int((x[j] - lo) / (hi - lo) * strata)
+				if k == strata || seen[k] {
+					t.Errorf("%T: stratum %d of coordinate %d not unique", test.sampler, k, j)
+					continue
+				}
+				seen[k] = true
+			}
+		}
+	}
+
+	// The first points of the two-dimensional Sobol sequence.
+	dst := [][]float64{make([]float64, 2), make([]float64, 2), make([]float64, 2), make([]float64, 2)}
+	Sobol{}.Sample(dst, &Bounds{Lower: []float64{0, 0}, Upper: []float64{1, 1}})
+	want := [][]float64{{0.5, 0.5}, {0.75, 0.25}, {0.25, 0.75}, {0.375, 0.375}}
+	for i := range want {
+		if !floats.Equal(dst[i], want[i]) {
+			t.Errorf("unexpected Sobol point %d: want %v, got %v", i, want[i], dst[i])
+		}
+	}
+}
+
+func TestSamplersDeterministic(t *testing.T) {
+	b := &Bounds{Lower: []float64{-1, 0, 2}, Upper: []float64{1, 3, 4}}
+	newPoints := func(n int) [][]float64 {
+		dst := make([][]float64, n)
+		for i := range dst {
+			dst[i] = make([]float64, 3)
+		}
+		return dst
+	}
+
+	// The same seed gives the same Latin hypercube.
+	x1, x2 := newPoints(10), newPoints(10)
+	LatinHypercube{Src: rand.NewSource(2)}.Sample(x1, b)
+	LatinHypercube{Src: rand.NewSource(2)}.Sample(x2, b)
+	for i := range x1 {
+		if !floats.Equal(x1[i], x2[i]) {
+			t.Errorf("LatinHypercube: point %d differs for the same seed", i)
+		}
+	}
+
+	// Skipping points continues the quasi-random sequences.
+	for _, test := range []struct {
+		all, rest Sampler
+	}{
+		{Sobol{}, Sobol{Skip: 4}},
+		{Sobol{Skip: 5}, Sobol{Skip: 9}},
+		{Halton{}, Halton{Skip: 4}},
+	} {
+		all, rest := newPoints(10), newPoints(6)
+		test.all.Sample(all, b)
+		test.rest.Sample(rest, b)
+		for i := range rest {
+			if !floats.EqualApprox(rest[i], all[i+4], 1e-14) {
+				t.Errorf("%+v: point %d differs from %+v: want %v, got %v", test.rest, i, test.all, all[i+4], rest[i])
+			}
+		}
+	}
+
+	// The first points of the two-dimensional Halton sequence.
+	dst := [][]float64{make([]float64, 2), make([]float64, 2), make([]float64, 2)}
+	Halton{}.Sample(dst, &Bounds{Lower: []float64{0, 0}, Upper: []float64{1, 1}})
+	want := [][]float64{{0.5, 1.0 / 3}, {0.25, 2.0 / 3}, {0.75, 1.0 / 9}}
+	for i := range want {
+		if !floats.EqualApprox(dst[i], want[i], 1e-15) {
+			t.Errorf("unexpected Halton point %d: want %v, got %v", i, want[i], dst[i])
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const (
+	defaultScalarRootTol = 1e-12
+	maxScalarRootIter    = 100
+	// scalarRootEps is the machine epsilon.
+	scalarRootEps = 2.220446049250313e-16
+)
+
+// ScalarRoot is the result of a root finder for a function of one variable.
+type ScalarRoot struct {
+	X float64 // Location of the root.
+	F float64 // Function value at X.
+	// FuncEvaluations is the number of evaluations of the function.
+	FuncEvaluations int
+}
+
+// BisectRoot finds a root of f in the interval between a and b by bisection.
+// The function values at a and b must have opposite signs, otherwise
+// BisectRoot returns ErrNoRootBracket. The interval is halved until its width
+// is below tol, relative to the machine precision at the root. If tol is
+// zero, 1e-12 is used instead. BisectRoot returns the end point of the final
+// interval with the smaller function value in magnitude.
+//
+// Bisection converges linearly, but it makes no assumption about f other
+// than its continuity.
+func BisectRoot(f func(float64) float64, a, b, tol float64) (ScalarRoot, error) {
+	fa, fb, tol, root, done, err := startRootBracket(f, a, b, tol)
+	if done || err != nil {
+		return root, err
+	}
+	evals := 2
+	for {
+		m := a + 0.5*(b-a)
+		if math.Abs(b-a) <= 2*scalarRootTol(tol, m) || m == a || m == b {
+			break
+		}
+		fm := f(m)
+		evals++
+		if fm == 0 {
+			return ScalarRoot{X: m, F: fm, FuncEvaluations: evals}, nil
+		}
+		if math.Signbit(fm) == math.Signbit(fa) {
+			a, fa = m, fm
+		} else {
+			b, fb = m, fm
+		}
+	}
+	if math.Abs(fa) < math.Abs(fb) {
+		return ScalarRoot{X: a, F: fa, FuncEvaluations: evals}, nil
+	}
+	return ScalarRoot{X: b, F: fb, FuncEvaluations: evals}, nil
+}
+
+// BrentRoot finds a root of f in the interval between a and b by Brent's
+// method, which combines inverse quadratic interpolation and secant steps
+// with bisection. The function values at a and b must have opposite signs,
+// otherwise BrentRoot returns ErrNoRootBracket. The root is located to
+// within tol, relative to the machine precision at the root. If tol is zero,
+// 1e-12 is used instead.
+//
+// BrentRoot converges superlinearly for smooth functions, and never needs
+// many more evaluations than BisectRoot. It is the method of choice for
+// bracketed roots.
+//
+// References:
+//  - Brent, R.P.: Algorithms for Minimization Without Derivatives.
+//    Prentice-Hall (1973), chapter 4
+func BrentRoot(f func(float64) float64, a, b, tol float64) (ScalarRoot, error) {
+	fa, fb, tol, root, done, err := startRootBracket(f, a, b, tol)
+	if done || err != nil {
+		return root, err
+	}
+	evals := 2
+	c, fc := b, fb
+	var d, e float64
+	for i := 0; i < maxScalarRootIter; i++ {
+		if math.Signbit(fb) == math.Signbit(fc) {
+			// Keep the root between b and c.
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		tol1 := scalarRootTol(tol, b)
+		xm := 0.5 * (c - b)
+		if math.Abs(xm) <= tol1 || fb == 0 {
+			return ScalarRoot{X: b, F: fb, FuncEvaluations: evals}, nil
+		}
+		if math.Abs(e) >= tol1 && math.Abs(fa) > math.Abs(fb) {
+			// Attempt inverse quadratic interpolation, or a secant step
+			// if only two points are distinct.
+			s := fb / fa
+			var p, q float64
+			if a == c {
+				p = 2 * xm * s
+				q = 1 - s
+			} else {
+				q = fa / fc
+				r := fb / fc
+				p = s * (2*xm*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			}
+			p = math.Abs(p)
+			if 2*p < math.Min(3*xm*q-math.Abs(tol1*q), math.Abs(e*q)) {
+				e = d
+				d = p / q
+			} else {
+				// The interpolation is not acceptable, bisect instead.
+				d = xm
+				e = d
+			}
+		} else {
+			d = xm
+			e = d
+		}
+		a, fa = b, fb
+		if math.Abs(d) > tol1 {
+			b += d
+		} else {
+			b += math.Copysign(tol1, xm)
+		}
+		fb = f(b)
+		evals++
+	}
+	return ScalarRoot{X: b, F: fb, FuncEvaluations: evals}, ErrIterationLimit
+}
+
+// RidderRoot finds a root of f in the interval between a and b by Ridders'
+// method. The function values at a and b must have opposite signs, otherwise
+// RidderRoot returns ErrNoRootBracket. The interval is reduced until its
+// width is below tol, relative to the machine precision at the root. If
+// tol is zero, 1e-12 is used instead.
+//
+// Ridders' method evaluates f at the midpoint of the interval and fits an
+// exponential to remove the curvature of f, which gives quadratic
+// convergence at two evaluations per iteration. Every new point is within
+// the interval, so the method is as robust as bisection.
+//
+// References:
+//  - Ridders, C.: A new algorithm for computing a single root of a real
+//    continuous function. IEEE Trans. Circuits Syst. 26 (1979), 979-980
+func RidderRoot(f func(float64) float64, a, b, tol float64) (ScalarRoot, error) {
+	fa, fb, tol, root, done, err := startRootBracket(f, a, b, tol)
+	if done || err != nil {
+		return root, err
+	}
+	evals := 2
+	x, fx := a, fa
+	if math.Abs(fb) < math.Abs(fa) {
+		x, fx = b, fb
+	}
+	for i := 0; i < maxScalarRootIter; i++ {
+		m := a + 0.5*(b-a)
+		if math.Abs(b-a) <= 2*scalarRootTol(tol, m) || m == a || m == b {
+			return ScalarRoot{X: x, F: fx, FuncEvaluations: evals}, nil
+		}
+		fm := f(m)
+		evals++
+		s := math.Sqrt(fm*fm - fa*fb)
+		if fm == 0 || s == 0 {
+			return ScalarRoot{X: m, F: fm, FuncEvaluations: evals}, nil
+		}
+		sign := 1.0
+		if fa < fb {
+			sign = -1
+		}
+		x = m + (m-a)*sign*fm/s
+		fx = f(x)
+		evals++
+		if fx == 0 {
+			return ScalarRoot{X: x, F: fx, FuncEvaluations: evals}, nil
+		}
+		// Keep the smallest interval with a sign change.
+		switch {
+		case math.Signbit(fm) != math.Signbit(fx):
+			a, fa = m, fm
+			b, fb = x, fx
+		case math.Signbit(fa) != math.Signbit(fx):
+			b, fb = x, fx
+		default:
+			a, fa = x, fx
+		}
+	}
+	return ScalarRoot{X: x, F: fx, FuncEvaluations: evals}, ErrIterationLimit
+}
+
+// NewtonRoot finds a root of f by Newton's method starting from x0. The
+// iteration stops when the step is below tol, relative to the machine
+// precision at the root. If tol is zero, 1e-12 is used instead. If df is
+// nil, the derivative is approximated by the secant through the last two
+// iterates, and the second iterate is x0 + 1e-4 max(1, |x0|).
+//
+// Newton's method converges quadratically and the secant method
+// superlinearly close to a simple root, but neither is guaranteed to
+// converge from a poor starting point. NewtonRoot returns ErrIterationLimit
+// if it does not converge within 100 iterations, and ErrZeroDerivative if the
+// derivative vanishes. If a bracket of the root is known, BrentRoot should be
+// used instead.
+func NewtonRoot(f, df func(float64) float64, x0, tol float64) (ScalarRoot, error) {
+	if tol == 0 {
+		tol = defaultScalarRootTol
+	}
+	if tol < 0 {
+		panic("optimize: negative tolerance")
+	}
+	x, fx := x0, f(x0)
+	evals := 1
+	var xPrev, fPrev float64
+	if df == nil {
+		xPrev, fPrev = x, fx
+		x = x0 + 1e-4*math.Max(1, math.Abs(x0))
+		fx = f(x)
+		evals++
+	}
+	for i := 0; i < maxScalarRootIter; i++ {
+		if fx == 0 {
+			return ScalarRoot{X: x, F: fx, FuncEvaluations: evals}, nil
+		}
+		var slope float64
+		if df != nil {
+			slope = df(x)
+		} else {
+			slope = (fx - fPrev) / (x - xPrev)
+		}
+		if slope == 0 || math.IsNaN(slope) {
+			return ScalarRoot{X: x, F: fx, FuncEvaluations: evals}, ErrZeroDerivative
+		}
+		step := fx / slope
+		xPrev, fPrev = x, fx
+		x -= step
+		fx = f(x)
+		evals++
+		if math.Abs(step) <= scalarRootTol(tol, x) {
+			if math.Abs(fPrev) < math.Abs(fx) {
+				return ScalarRoot{X: xPrev, F: fPrev, FuncEvaluations: evals}, nil
+			}
+			return ScalarRoot{X: x, F: fx, FuncEvaluations: evals}, nil
+		}
+	}
+	return ScalarRoot{X: x, F: fx, FuncEvaluations: evals}, ErrIterationLimit
+}
+
+// startRootBracket evaluates f at the end points of a bracket of a root and
+// checks that they have opposite signs. If one of the values is zero, it
+// returns the root and done is true.
+func startRootBracket(f func(float64) float64, a, b, tol float64) (fa, fb, tolOut float64, root ScalarRoot, done bool, err error) {
+	if tol == 0 {
+		tol = defaultScalarRootTol
+	}
+	if tol < 0 {
+		panic("optimize: negative tolerance")
+	}
+	fa = f(a)
+	if fa == 0 {
+		return fa, 0, tol, ScalarRoot{X: a, F: fa, FuncEvaluations: 1}, true, nil
+	}
+	fb = f(b)
+	if fb == 0 {
+		return fa, fb, tol, ScalarRoot{X: b, F: fb, FuncEvaluations: 2}, true, nil
+	}
+	if math.Signbit(fa) == math.Signbit(fb) {
+		return fa, fb, tol, ScalarRoot{FuncEvaluations: 2}, true, ErrNoRootBracket
+	}
+	return fa, fb, tol, ScalarRoot{}, false, nil
+}
+
+// scalarRootTol returns the absolute tolerance of a root near x.
+func scalarRootTol(tol, x float64) float64 {
+	return 2*scalarRootEps*math.Abs(x) + 0.5*tol
+}
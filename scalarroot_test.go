@@ -0,0 +1,139 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+)
+
+var scalarRootTests = []struct {
+	name string
+	f    func(float64) float64
+	df   func(float64) float64
+	a, b float64
+	root float64
+}{
+	{
+		name: "Cubic",
+		f:    func(x float64) float64 { return x*x*x - 2*x - 5 },
+		df:   func(x float64) float64 { return 3*x*x - 2 },
+		a:    2,
+		b:    3,
+		root: 2.0945514815423265,
+	},
+	{
+		name: "CosFixedPoint",
+		f:    func(x float64) float64 { return math.Cos(x) - x },
+		df:   func(x float64) float64 { return -math.Sin(x) - 1 },
+		a:    0,
+		b:    1,
+		root: 0.7390851332151607,
+	},
+	{
+		name: "Exp",
+		f:    func(x float64) float64 { return math.Exp(x) - 2 },
+		df:   math.Exp,
+		a:    -1,
+		b:    3,
+		root: math.Ln2,
+	},
+	{
+		name: "Steep",
+		f:    func(x float64) float64 { return math.Pow(x, 9) - 1e-9 },
+		df:   func(x float64) float64 { return 9 * math.Pow(x, 8) },
+		a:    -0.5,
+		b:    1,
+		root: 0.1,
+	},
+	{
+		name: "Atan",
+		f:    func(x float64) float64 { return math.Atan(x - 10) },
+		df:   func(x float64) float64 { return 1 / (1 + (x-10)*(x-10)) },
+		a:    0,
+		b:    100,
+		root: 10,
+	},
+}
+
+func TestBracketedRoot(t *testing.T) {
+	for _, method := range []struct {
+		name string
+		root func(f func(float64) float64, a, b, tol float64) (ScalarRoot, error)
+	}{
+		{"Bisect", BisectRoot},
+		{"Brent", BrentRoot},
+		{"Ridder", RidderRoot},
+	} {
+		for _, test := range scalarRootTests {
+			var evals int
+			f := func(x float64) float64 {
+				evals++
+				return test.f(x)
+			}
+			// The bracket may be given in either order.
+			for _, ab := range [][2]float64{{test.a, test.b}, {test.b, test.a}} {
+				evals = 0
+				r, err := method.root(f, ab[0], ab[1], 1e-12)
+				if err != nil {
+					t.Errorf("%s/%s: unexpected error: %v", method.name, test.name, err)
+					continue
+				}
+				if math.Abs(r.X-test.root) > 1e-11 {
+					t.Errorf("%s/%s: unexpected root. Want %v, got %v", method.name, test.name, test.root, r.X)
+				}
+				if r.F != test.f(r.X) {
+					t.Errorf("%s/%s: mismatched function value", method.name, test.name)
+				}
+				if r.FuncEvaluations != evals {
+					t.Errorf("%s/%s: unexpected number of evaluations. Want %d, got %d", method.name, test.name, evals, r.FuncEvaluations)
+				}
+			}
+		}
+
+		// The root must be bracketed.
+		_, err := method.root(func(x float64) float64 { return x*x + 1 }, -1, 1, 0)
+		if err != ErrNoRootBracket {
+			t.Errorf("%s: unexpected error for missing bracket: %v", method.name, err)
+		}
+		// A root at an end point is found immediately.
+		r, err := method.root(func(x float64) float64 { return x - 1 }, 1, 3, 0)
+		if err != nil || r.X != 1 || r.FuncEvaluations != 1 {
+			t.Errorf("%s: unexpected result for root at end point: %+v, %v", method.name, r, err)
+		}
+	}
+}
+
+func TestNewtonRoot(t *testing.T) {
+	for _, test := range scalarRootTests {
+		for _, secant := range []bool{false, true} {
+			df := test.df
+			name := test.name
+			if secant {
+				df = nil
+				name += "/Secant"
+			}
+			// Start close enough to the root for the iteration to converge.
+			x0 := test.root + 0.1
+			r, err := NewtonRoot(test.f, df, x0, 1e-12)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+				continue
+			}
+			if math.Abs(r.X-test.root) > 1e-11 {
+				t.Errorf("%s: unexpected root. Want %v, got %v", name, test.root, r.X)
+			}
+		}
+	}
+
+	_, err := NewtonRoot(func(x float64) float64 { return x*x + 1 }, func(x float64) float64 { return 2 * x }, 0, 0)
+	if err != ErrZeroDerivative {
+		t.Errorf("unexpected error for zero derivative: %v", err)
+	}
+	_, err = NewtonRoot(func(x float64) float64 { return x*x + 1 }, func(x float64) float64 { return 2 * x }, 0.5, 0)
+	if err != ErrIterationLimit {
+		t.Errorf("unexpected error for missing root: %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const curtisReidTolerance = 1e-8
+
+// CurtisReidScaling computes row and column scaling factors for the m×n
+// matrix a using the method of Curtis and Reid. The scaling factors are
+// chosen so that the nonzero elements of the scaled matrix
+//  diag(rowScale) * a * diag(colScale)
+// are as close to one in magnitude as possible, in the sense that the sum of
+// squares of the logarithms of their magnitudes is minimized. The returned
+// factors are integer powers of two, so that scaling does not introduce any
+// rounding errors. Rows and columns of a without nonzero elements are given a
+// scaling factor of one.
+//
+// The scaling can be applied to a matrix that describes a problem, for example
+// the Jacobian of the residuals at the initial location or the constraint
+// matrix of a linear program, in order to improve its conditioning. Solutions
+// of the scaled problem are mapped back to the original space by multiplying
+// them by the column scaling factors.
+//
+// Reference:
+//  Curtis, A.R., Reid, J.K.: On the automatic scaling of matrices for Gaussian
+//  elimination. IMA J Appl Math 10 (1972), 118-124
+func CurtisReidScaling(a mat64.Matrix) (rowScale, colScale []float64) {
+	m, n := a.Dims()
+	type nonzero struct {
+		i, j int
+		log  float64
+	}
+	var nz []nonzero
+	rowCount := make([]float64, m)
+	colCount := make([]float64, n)
+	// The right-hand side of the normal equations is stored as [s; t].
+	rhs := make([]float64, m+n)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			v := math.Abs(a.At(i, j))
+			if v == 0 {
+				continue
+			}
+			l := math.Log2(v)
+			nz = append(nz, nonzero{i, j, l})
+			rowCount[i]++
+			colCount[j]++
+			rhs[i] += l
+			rhs[m+j] += l
+		}
+	}
+
+	// mulVec computes the product of the matrix of the normal equations
+	//  [diag(rowCount)  Z              ]
+	//  [Zᵀ              diag(colCount) ]
+	// with v, where Z is the incidence matrix of the nonzero elements.
+	mulVec := func(dst, v []float64) {
+		for i := 0; i < m; i++ {
+			dst[i] = rowCount[i] * v[i]
+		}
+		for j := 0; j < n; j++ {
+			dst[m+j] = colCount[j] * v[m+j]
+		}
+		for _, e := range nz {
+			dst[e.i] += v[m+e.j]
+			dst[m+e.j] += v[e.i]
+		}
+	}
+
+	// Solve the consistent, positive semidefinite normal equations with the
+	// conjugate gradient method starting from zero. The iterates stay in the
+	// range of the matrix, so the method converges to the minimum-norm
+	// solution.
+	sol := make([]float64, m+n)
+	res := make([]float64, m+n)
+	dir := make([]float64, m+n)
+	adir := make([]float64, m+n)
+	copy(res, rhs)
+	copy(dir, res)
+	resNorm2 := floats.Dot(res, res)
+	tol := curtisReidTolerance * curtisReidTolerance * resNorm2
+	for k := 0; k < m+n && resNorm2 > tol; k++ {
+		mulVec(adir, dir)
+		alpha := resNorm2 / floats.Dot(dir, adir)
+		floats.AddScaled(sol, alpha, dir)
+		floats.AddScaled(res, -alpha, adir)
+		next := floats.Dot(res, res)
+		floats.AddScaledTo(dir, res, next/resNorm2, dir)
+		resNorm2 = next
+	}
+
+	// The solution is determined only up to adding a constant to the row
+	// logarithms and subtracting it from the column logarithms. Round the row
+	// exponents and recompute the optimal column exponents given the rounded
+	// row exponents, so that the rounding is consistent.
+	rowScale = make([]float64, m)
+	colScale = make([]float64, n)
+	rowExp := make([]float64, m)
+	for i := range rowExp {
+		rowExp[i] = math.Floor(sol[i] + 0.5)
+		rowScale[i] = math.Exp2(-rowExp[i])
+	}
+	colExp := make([]float64, n)
+	for _, e := range nz {
+		colExp[e.j] += e.log - rowExp[e.i]
+	}
+	for j := range colScale {
+		if colCount[j] == 0 {
+			colScale[j] = 1
+			continue
+		}
+		colScale[j] = math.Exp2(-math.Floor(colExp[j]/colCount[j] + 0.5))
+	}
+	return rowScale, colScale
+}
+
+// variableScaling maps the variables x of a problem to the scaled variables
+//  x_i / scale[i]
+// for Settings.Scale.
+type variableScaling struct {
+	scale []float64
+}
+
+func newVariableScaling(scale []float64, dim int) variableScaling {
+	if len(scale) != dim {
+		panic("optimize: scale size mismatch")
+	}
+	for _, v := range scale {
+		if !(v > 0) || math.IsInf(v, 1) {
+			panic("optimize: scale must be positive and finite")
+		}
+	}
+	return variableScaling{scale: scale}
+}
+
+// toScaled returns the scaled variables of x in a new slice.
+func (vs variableScaling) toScaled(x []float64) []float64 {
+	xs := make([]float64, len(x))
+	for i, v := range x {
+		xs[i] = v / vs.scale[i]
+	}
+	return xs
+}
+
+// fromScaled stores the original variables of the scaled xs into x.
+func (vs variableScaling) fromScaled(x, xs []float64) {
+	for i, v := range xs {
+		x[i] = v * vs.scale[i]
+	}
+}
+
+// unscaleLocation transforms the scaled location loc in-place to the
+// original variables.
+func (vs variableScaling) unscaleLocation(loc *Location) {
+	vs.fromScaled(loc.X, loc.X)
+	for i := range loc.Gradient {
+		loc.Gradient[i] /= vs.scale[i]
+	}
+	if loc.Hessian != nil {
+		for i := range vs.scale {
+			for j := i; j < len(vs.scale); j++ {
+				loc.Hessian.SetSym(i, j, loc.Hessian.At(i, j)/(vs.scale[i]*vs.scale[j]))
+			}
+		}
+	}
+}
+
+// unscaleCovariance transforms the covariance cov of the scaled variables
+// in-place to the covariance diag(scale) * cov * diag(scale) of the original
+// variables.
+func (vs variableScaling) unscaleCovariance(cov *mat64.SymDense) {
+	for i := range vs.scale {
+		for j := i; j < len(vs.scale); j++ {
+			cov.SetSym(i, j, cov.At(i, j)*vs.scale[i]*vs.scale[j])
+		}
+	}
+}
+
+// problem returns the problem p in the scaled variables. The functions of the
+// returned problem allocate the original variables at every call so that
+// they are safe for concurrent use if the functions of p are.
+func (vs variableScaling) problem(p Problem) Problem {
+	sp := Problem{
+		Status: p.Status,
+		Func: func(xs []float64) float64 {
+			x := make([]float64, len(xs))
+			vs.fromScaled(x, xs)
+			return p.Func(x)
+		},
+	}
+	if p.Grad != nil {
+		sp.Grad = func(xs, grad []float64) {
+			x := make([]float64, len(xs))
+			vs.fromScaled(x, xs)
+			p.Grad(x, grad)
+			for i, s := range vs.scale {
+				grad[i] *= s
+			}
+		}
+	}
+	if p.FuncGrad != nil {
+		sp.FuncGrad = func(xs, grad []float64) float64 {
+			x := make([]float64, len(xs))
+			vs.fromScaled(x, xs)
+			f := p.FuncGrad(x, grad)
+			for i, s := range vs.scale {
+				grad[i] *= s
+			}
+			return f
+		}
+	}
+	if p.Hess != nil {
+		sp.Hess = func(xs []float64, hess *mat64.SymDense) {
+			x := make([]float64, len(xs))
+			vs.fromScaled(x, xs)
+			p.Hess(x, hess)
+			for i, si := range vs.scale {
+				for j := i; j < len(vs.scale); j++ {
+					hess.SetSym(i, j, si*vs.scale[j]*hess.At(i, j))
+				}
+			}
+		}
+	}
+	if p.HessVec != nil {
+		sp.HessVec = func(xs, v, hv []float64) {
+			x := make([]float64, len(xs))
+			vs.fromScaled(x, xs)
+			w := make([]float64, len(v))
+			vs.fromScaled(w, v)
+			p.HessVec(x, w, hv)
+			for i, s := range vs.scale {
+				hv[i] *= s
+			}
+		}
+	}
+	if p.Bounds != nil {
+		sp.Bounds = &Bounds{}
+		if p.Bounds.Lower != nil {
+			sp.Bounds.Lower = vs.toScaled(p.Bounds.Lower)
+		}
+		if p.Bounds.Upper != nil {
+			sp.Bounds.Upper = vs.toScaled(p.Bounds.Upper)
+		}
+	}
+	if p.Equality != nil {
+		sp.Equality = scaledConstraint{p.Equality, vs}
+	}
+	if p.Inequality != nil {
+		sp.Inequality = scaledConstraint{p.Inequality, vs}
+	}
+	return sp
+}
+
+// settings returns a copy of settings for the problem in the scaled
+// variables.
+func (vs variableScaling) settings(settings *Settings) *Settings {
+	s := *settings
+	if s.UseInitialData {
+		if s.InitialGradient != nil {
+			s.InitialGradient = make([]float64, len(vs.scale))
+			for i, g := range settings.InitialGradient {
+				s.InitialGradient[i] = g * vs.scale[i]
+			}
+		}
+		if s.InitialHessian != nil {
+			n := len(vs.scale)
+			s.InitialHessian = mat64.NewSymDense(n, nil)
+			for i, si := range vs.scale {
+				for j := i; j < n; j++ {
+					s.InitialHessian.SetSym(i, j, si*vs.scale[j]*settings.InitialHessian.At(i, j))
+				}
+			}
+		}
+	}
+	if s.Recorder != nil {
+		s.Recorder = &scaledRecorder{Recorder: s.Recorder, vs: vs}
+	}
+	if s.Callback != nil {
+		callback := s.Callback
+		loc := &Location{}
+		var x []float64
+		s.Callback = func(iter int, scaled *Location) (Status, error) {
+			copyLocation(loc, scaled)
+			if scaled.Hessian == nil {
+				loc.Hessian = nil
+			}
+			vs.unscaleLocation(loc)
+			x = resize(x, len(loc.X))
+			copy(x, loc.X)
+			status, err := callback(iter, loc)
+			if !floats.Equal(loc.X, x) {
+				// Pass a modified location back in the scaled variables.
+				for i, v := range loc.X {
+					scaled.X[i] = v / vs.scale[i]
+				}
+			}
+			return status, err
+		}
+	}
+	return &s
+}
+
+// scaledConstraint is a Constraint in the scaled variables.
+type scaledConstraint struct {
+	c  Constraint
+	vs variableScaling
+}
+
+func (sc scaledConstraint) Len() int {
+	return sc.c.Len()
+}
+
+func (sc scaledConstraint) Func(xs, dst []float64) {
+	x := make([]float64, len(xs))
+	sc.vs.fromScaled(x, xs)
+	sc.c.Func(x, dst)
+}
+
+func (sc scaledConstraint) Jac(xs []float64, jac *mat64.Dense) {
+	x := make([]float64, len(xs))
+	sc.vs.fromScaled(x, xs)
+	sc.c.Jac(x, jac)
+	m, _ := jac.Dims()
+	for i := 0; i < m; i++ {
+		for j, s := range sc.vs.scale {
+			jac.Set(i, j, s*jac.At(i, j))
+		}
+	}
+}
+
+// scaledRecorder passes the locations of the scaled problem to Recorder in
+// the original variables.
+type scaledRecorder struct {
+	Recorder
+	vs  variableScaling
+	loc Location
+}
+
+func (r *scaledRecorder) Record(loc *Location, e EvaluationType, iter IterationType, stats *Stats) error {
+	copyLocation(&r.loc, loc)
+	if loc.Hessian == nil {
+		r.loc.Hessian = nil
+	}
+	r.vs.unscaleLocation(&r.loc)
+	return r.Recorder.Record(&r.loc, e, iter, stats)
+}
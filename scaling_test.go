@@ -0,0 +1,205 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+func TestCurtisReidScaling(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	// A matrix of the form diag(2^p) * S * diag(2^q), where S has elements
+	// ±1, must be scaled exactly to S.
+	m, n := 7, 5
+	a := mat64.NewDense(m, n, nil)
+	sign := mat64.NewDense(m, n, nil)
+	p := make([]float64, m)
+	q := make([]float64, n)
+	for i := range p {
+		p[i] = float64(rnd.Intn(40) - 20)
+	}
+	for j := range q {
+		q[j] = float64(rnd.Intn(40) - 20)
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			if (i+j)%3 == 0 {
+				// Structural zero.
+				continue
+			}
+			s := 1.0
+			if rnd.Intn(2) == 0 {
+				s = -1
+			}
+			sign.Set(i, j, s)
+			a.Set(i, j, s*math.Exp2(p[i]+q[j]))
+		}
+	}
+	rowScale, colScale := CurtisReidScaling(a)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			got := rowScale[i] * a.At(i, j) * colScale[j]
+			if got != sign.At(i, j) {
+				t.Errorf("unexpected scaled element (%d,%d): want %v, got %v", i, j, sign.At(i, j), got)
+			}
+		}
+	}
+
+	// A badly scaled random matrix must become better scaled.
+	b := mat64.NewDense(m, n, nil)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			b.Set(i, j, (rnd.Float64()+0.5)*math.Exp2(p[i]+q[j]))
+		}
+	}
+	spread := func(rowScale, colScale []float64) float64 {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				v := math.Abs(b.At(i, j))
+				if rowScale != nil {
+					v *= rowScale[i] * colScale[j]
+				}
+				lo = math.Min(lo, v)
+				hi = math.Max(hi, v)
+			}
+		}
+		return hi / lo
+	}
+	rowScale, colScale = CurtisReidScaling(b)
+	if before, after := spread(nil, nil), spread(rowScale, colScale); after > 16 || after >= before {
+		t.Errorf("scaling did not improve the element spread: before %v, after %v", before, after)
+	}
+	for _, s := range append(rowScale, colScale...) {
+		if _, e := math.Frexp(s); math.Exp2(float64(e-1)) != s {
+			t.Errorf("scaling factor %v is not a power of two", s)
+		}
+	}
+}
+
+func TestSettingsScale(t *testing.T) {
+	// The Rosenbrock function of variables with magnitudes 1e4 and 1e-3.
+	scale := []float64{1e4, 1e-3}
+	rosen := functions.ExtendedRosenbrock{}
+	p := Problem{
+		Func: func(x []float64) float64 {
+			return rosen.Func([]float64{x[0] / scale[0], x[1] / scale[1]})
+		},
+		Grad: func(x, grad []float64) {
+			rosen.Grad([]float64{x[0] / scale[0], x[1] / scale[1]}, grad)
+			grad[0] /= scale[0]
+			grad[1] /= scale[1]
+		},
+	}
+	x0 := []float64{-1.2e4, 1e-3}
+	want := []float64{1e4, 1e-3}
+	bounded := p
+	bounded.Bounds = &Bounds{Lower: []float64{-2e4, 1.5e-3}, Upper: []float64{2e4, 3e-3}}
+
+	for _, test := range []struct {
+		name   string
+		p      Problem
+		method Method
+		x      []float64
+		want   []float64
+	}{
+		{"NelderMead", p, &NelderMead{}, x0, want},
+		{"BFGS", p, &BFGS{}, x0, want},
+		{"LBFGS", p, &LBFGS{}, x0, want},
+		{"HookeJeeves", bounded, &HookeJeeves{}, []float64{1.5e4, 2e-3}, []float64{1.224371e4, 1.5e-3}},
+	} {
+		settings := DefaultSettings()
+		settings.Scale = scale
+		settings.FunctionConverge = nil
+		if !test.method.Needs().Gradient {
+			settings.FunctionConverge = &FunctionConverge{Absolute: 1e-14, Iterations: 50}
+		}
+		var last Location
+		settings.Callback = func(_ int, loc *Location) (Status, error) {
+			copyLocation(&last, loc)
+			return NotTerminated, nil
+		}
+		result, err := Local(test.p, test.x, settings, test.method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		for i, v := range result.X {
+			if math.Abs(v-test.want[i]) > 1e-4*scale[i] {
+				t.Errorf("%s: unexpected minimum location, want %v, got %v", test.name, test.want, result.X)
+				break
+			}
+		}
+		if f := test.p.Func(result.X); f != result.F {
+			t.Errorf("%s: function value %v at the result location, result has %v", test.name, f, result.F)
+		}
+		if result.Gradient != nil {
+			grad := make([]float64, 2)
+			test.p.Grad(result.X, grad)
+			if !floats.EqualApprox(grad, result.Gradient, 1e-12) {
+				t.Errorf("%s: gradient %v at the result location, result has %v", test.name, grad, result.Gradient)
+			}
+		}
+		if test.p.Bounds != nil && !test.p.Bounds.Feasible(result.X) {
+			t.Errorf("%s: infeasible minimum location %v", test.name, result.X)
+		}
+		if f := test.p.Func(last.X); f != last.F {
+			t.Errorf("%s: Callback location not in the original variables", test.name)
+		}
+	}
+}
+
+func TestSettingsScaleCovariance(t *testing.T) {
+	// Straight line fit y = a + b t. The covariance of the parameters does
+	// not depend on the scaling of the variables.
+	ts := []float64{0, 1, 2, 3, 4, 5}
+	ys := []float64{1.1, 2.9, 5.2, 6.8, 9.1, 11.0}
+	rp := ResidualProblem{
+		Len: len(ts),
+		Residual: func(x, dst []float64) {
+			for i, v := range ts {
+				dst[i] = x[0] + x[1]*v - ys[i]
+			}
+		},
+		Jacobian: func(x []float64, jac *mat64.Dense) {
+			for i, v := range ts {
+				jac.Set(i, 0, 1)
+				jac.Set(i, 1, v)
+			}
+		},
+	}
+	x0 := []float64{0, 0}
+	want, err := Local(rp.Problem(), x0, nil, &LevenbergMarquardt{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	settings := DefaultSettings()
+	settings.Scale = []float64{100, 1e-2}
+	for _, method := range []Method{&LevenbergMarquardt{}, &GaussNewton{}} {
+		result, err := Local(rp.Problem(), x0, settings, method)
+		if err != nil {
+			t.Errorf("%T: unexpected error: %v", method, err)
+			continue
+		}
+		if result.Covariance == nil {
+			t.Errorf("%T: missing covariance", method)
+			continue
+		}
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 2; j++ {
+				got := result.Covariance.At(i, j)
+				if w := want.Covariance.At(i, j); math.Abs(got-w) > 1e-8*math.Abs(w) {
+					t.Errorf("%T: unexpected covariance element (%d,%d), want %v, got %v", method, i, j, w, got)
+				}
+			}
+		}
+	}
+}
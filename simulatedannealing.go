@@ -0,0 +1,284 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultExponentialCoolingRate = 0.995
+	defaultAdaptiveCoolingTarget  = 0.2
+)
+
+// CoolingSchedule determines the temperature of SimulatedAnnealing.
+type CoolingSchedule interface {
+	// Init initializes the schedule with the initial temperature t0. Init
+	// is called at the start of the minimization and at every reheating.
+	Init(t0 float64)
+	// Temperature returns the temperature at step k >= 1 after the last
+	// call to Init. accepted reports whether the candidate at step k-1 was
+	// accepted.
+	Temperature(k int, accepted bool) float64
+}
+
+// NeighborGenerator generates the candidate locations of SimulatedAnnealing.
+type NeighborGenerator interface {
+	// Neighbor stores into dst a random candidate location close to x at
+	// the temperature t using the random number generator rnd. Neighbor
+	// must not modify x.
+	Neighbor(dst, x []float64, t float64, rnd *rand.Rand)
+}
+
+// ExponentialCooling is the geometric cooling schedule
+//  t_k = t_0 Rate^k.
+type ExponentialCooling struct {
+	// Rate is the cooling factor. If Rate is zero, it will be set to 0.995.
+	// ExponentialCooling panics if Rate is not in (0, 1).
+	Rate float64
+
+	t0 float64
+}
+
+func (e *ExponentialCooling) Init(t0 float64) {
+	if e.Rate == 0 {
+		e.Rate = defaultExponentialCoolingRate
+	}
+	if e.Rate <= 0 || e.Rate >= 1 {
+		panic("cooling: rate must be between 0 and 1")
+	}
+	e.t0 = t0
+}
+
+func (e *ExponentialCooling) Temperature(k int, _ bool) float64 {
+	return e.t0 * math.Pow(e.Rate, float64(k))
+}
+
+// LogarithmicCooling is the cooling schedule
+//  t_k = t_0 / ln(e + k).
+// It cools very slowly, which is required by the convergence theory of
+// simulated annealing but is often impractical.
+type LogarithmicCooling struct {
+	t0 float64
+}
+
+func (l *LogarithmicCooling) Init(t0 float64) {
+	l.t0 = t0
+}
+
+func (l *LogarithmicCooling) Temperature(k int, _ bool) float64 {
+	return l.t0 / math.Log(math.E+float64(k))
+}
+
+// AdaptiveCooling is a geometric cooling schedule that slows down when few
+// candidates are accepted. It keeps a moving average a of the acceptance
+// rate of the recent candidates and cools at step k by the factor
+//  Rate^min(1, a/Target),
+// so that the temperature stays roughly constant while the search is stuck
+// and decreases at the full rate while the search makes progress.
+type AdaptiveCooling struct {
+	// Rate is the cooling factor at acceptance rates of at least Target. If
+	// Rate is zero, it will be set to 0.995. AdaptiveCooling panics if Rate
+	// is not in (0, 1).
+	Rate float64
+	// Target is the target acceptance rate. If Target is zero, it will be
+	// set to 0.2. AdaptiveCooling panics if Target is not in (0, 1].
+	Target float64
+
+	t    float64
+	rate float64 // Moving average of the acceptance rate.
+}
+
+func (a *AdaptiveCooling) Init(t0 float64) {
+	if a.Rate == 0 {
+		a.Rate = defaultExponentialCoolingRate
+	}
+	if a.Rate <= 0 || a.Rate >= 1 {
+		panic("cooling: rate must be between 0 and 1")
+	}
+	if a.Target == 0 {
+		a.Target = defaultAdaptiveCoolingTarget
+	}
+	if a.Target <= 0 || a.Target > 1 {
+		panic("cooling: target acceptance rate must be between 0 and 1")
+	}
+	a.t = t0
+	a.rate = a.Target
+}
+
+func (a *AdaptiveCooling) Temperature(_ int, accepted bool) float64 {
+	const weight = 0.05
+	var acc float64
+	if accepted {
+		acc = 1
+	}
+	a.rate = (1-weight)*a.rate + weight*acc
+	a.t *= math.Pow(a.Rate, math.Min(1, a.rate/a.Target))
+	return a.t
+}
+
+// GaussianNeighbor generates candidates by adding normally distributed
+// perturbations with standard deviation StdDev to every coordinate. The
+// candidates do not depend on the temperature.
+type GaussianNeighbor struct {
+	// StdDev is the standard deviation of the perturbations. If StdDev is
+	// zero, it will be set to 1.
+	StdDev float64
+}
+
+func (g *GaussianNeighbor) Neighbor(dst, x []float64, _ float64, rnd *rand.Rand) {
+	if g.StdDev == 0 {
+		g.StdDev = 1
+	}
+	for i, v := range x {
+		dst[i] = v + g.StdDev*rnd.NormFloat64()
+	}
+}
+
+// SimulatedAnnealing implements simulated annealing for gradient-free
+// global optimization. At every iteration, a candidate location close to
+// the current location is generated by Neighbor and evaluated. The
+// candidate becomes the current location if its function value is lower,
+// and otherwise with the Metropolis probability
+//  exp(-(f(candidate) - f(current)) / t),
+// where the temperature t is determined by Schedule. Uphill moves thus
+// become less likely as the temperature decreases, which lets the search
+// escape local minima early and settle in a good minimum later.
+//
+// If ReheatAfter is positive, the schedule is restarted at
+// InitialTemperature and the search continues from the best location found
+// so far whenever ReheatAfter consecutive candidates have been rejected.
+//
+// Candidates are projected onto the bounds of the problem. Every candidate
+// is a major iteration. SimulatedAnnealing does not converge by itself, and
+// the run should be terminated by Settings.FunctionConverge or by the
+// evaluation limits. The result is a good starting location for a local
+// method.
+type SimulatedAnnealing struct {
+	// InitialTemperature is the temperature at the start and after
+	// reheating. It should be comparable to the typical differences of the
+	// function values between neighbors. If InitialTemperature is zero, it
+	// will be set to 1.
+	InitialTemperature float64
+	// Schedule is the cooling schedule. If Schedule is nil, it will be set
+	// to &ExponentialCooling{}.
+	Schedule CoolingSchedule
+	// Neighbor generates the candidates. If Neighbor is nil, it will be set
+	// to &GaussianNeighbor{}.
+	Neighbor NeighborGenerator
+	// ReheatAfter is the number of consecutive rejected candidates after
+	// which the temperature is reset. If ReheatAfter is zero, the search is
+	// never reheated.
+	ReheatAfter int
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+
+	rnd    *rand.Rand
+	bounds *Bounds
+
+	temp     float64
+	k        int  // Step since the last (re)start of the schedule.
+	rejected int  // Number of consecutive rejected candidates.
+	accepted bool // Whether the last candidate was accepted.
+
+	x     []float64 // Current location.
+	f     float64
+	bestX []float64
+	bestF float64
+}
+
+func (sa *SimulatedAnnealing) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if sa.InitialTemperature == 0 {
+		sa.InitialTemperature = 1
+	}
+	if sa.InitialTemperature < 0 {
+		panic("annealing: initial temperature must be positive")
+	}
+	if sa.ReheatAfter < 0 {
+		panic("annealing: negative ReheatAfter")
+	}
+	if sa.Schedule == nil {
+		sa.Schedule = &ExponentialCooling{}
+	}
+	if sa.Neighbor == nil {
+		sa.Neighbor = &GaussianNeighbor{}
+	}
+	sa.rnd = newRand(sa.Src, p.Src)
+	sa.bounds = p.Bounds
+
+	dim := len(loc.X)
+	sa.x = resize(sa.x, dim)
+	sa.bestX = resize(sa.bestX, dim)
+	copy(sa.x, loc.X)
+	copy(sa.bestX, loc.X)
+	sa.f = loc.F
+	sa.bestF = loc.F
+
+	sa.Schedule.Init(sa.InitialTemperature)
+	sa.temp = sa.InitialTemperature
+	sa.k = 0
+	sa.rejected = 0
+	sa.accepted = true
+	return sa.candidate(xNext)
+}
+
+func (sa *SimulatedAnnealing) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	df := loc.F - sa.f
+	sa.accepted = df <= 0 || sa.rnd.Float64() < math.Exp(-df/sa.temp)
+	if sa.accepted {
+		copy(sa.x, loc.X)
+		sa.f = loc.F
+		sa.rejected = 0
+		if loc.F < sa.bestF {
+			copy(sa.bestX, loc.X)
+			sa.bestF = loc.F
+		}
+	} else {
+		sa.rejected++
+	}
+
+	if sa.ReheatAfter > 0 && sa.rejected >= sa.ReheatAfter {
+		copy(sa.x, sa.bestX)
+		sa.f = sa.bestF
+		sa.Schedule.Init(sa.InitialTemperature)
+		sa.temp = sa.InitialTemperature
+		sa.k = 0
+		sa.rejected = 0
+		sa.accepted = true
+	}
+	return sa.candidate(xNext)
+}
+
+// candidate advances the schedule and stores the next candidate into xNext.
+func (sa *SimulatedAnnealing) candidate(xNext []float64) (EvaluationType, IterationType, error) {
+	if sa.k > 0 {
+		sa.temp = sa.Schedule.Temperature(sa.k, sa.accepted)
+	}
+	sa.k++
+	sa.Neighbor.Neighbor(xNext, sa.x, sa.temp, sa.rnd)
+	if sa.bounds != nil {
+		sa.bounds.Project(xNext)
+	}
+	return FuncEvaluation, MajorIteration, nil
+}
+
+func (*SimulatedAnnealing) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// HandlesBounds returns true. All candidates of SimulatedAnnealing are
+// projected onto the bounds.
+func (*SimulatedAnnealing) HandlesBounds() bool {
+	return true
+}
@@ -0,0 +1,119 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestSimulatedAnnealing(t *testing.T) {
+	bounds := &Bounds{
+		Lower: []float64{-5.12, -5.12},
+		Upper: []float64{5.12, 5.12},
+	}
+	for _, test := range []struct {
+		name   string
+		method func() *SimulatedAnnealing
+	}{
+		{
+			name: "Exponential",
+			method: func() *SimulatedAnnealing {
+				return &SimulatedAnnealing{}
+			},
+		},
+		{
+			name: "Logarithmic",
+			method: func() *SimulatedAnnealing {
+				return &SimulatedAnnealing{
+					InitialTemperature: 2,
+					Schedule:           &LogarithmicCooling{},
+				}
+			},
+		},
+		{
+			name: "Adaptive",
+			method: func() *SimulatedAnnealing {
+				return &SimulatedAnnealing{Schedule: &AdaptiveCooling{}}
+			},
+		},
+		{
+			name: "Reheat",
+			method: func() *SimulatedAnnealing {
+				return &SimulatedAnnealing{
+					Schedule:    &ExponentialCooling{Rate: 0.9},
+					ReheatAfter: 100,
+				}
+			},
+		},
+	} {
+		var infeasible bool
+		p := Problem{
+			Func: func(x []float64) float64 {
+				if !bounds.Feasible(x) {
+					infeasible = true
+				}
+				return rastrigin(x)
+			},
+			Bounds: bounds,
+		}
+		var results []*Result
+		for i := 0; i < 2; i++ {
+			settings := DefaultSettings()
+			settings.Recorder = nil
+			settings.FunctionConverge = nil
+			settings.FuncEvaluations = 5000
+			method := test.method()
+			method.Neighbor = &GaussianNeighbor{StdDev: 0.5}
+			method.Src = rand.NewSource(1)
+			// Start close to a local minimum.
+			result, err := Local(p, []float64{3, -4}, settings, method)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+				break
+			}
+			// The global minimum is at the origin and all other local
+			// minima have function values close to or above 1.
+			if result.F > 0.5 {
+				t.Errorf("%s: global minimum not found, got f(%v) = %v", test.name, result.X, result.F)
+			}
+			results = append(results, result)
+		}
+		if infeasible {
+			t.Errorf("%s: infeasible location evaluated", test.name)
+		}
+		if len(results) == 2 && (results[0].F != results[1].F || !floats.Equal(results[0].X, results[1].X)) {
+			t.Errorf("%s: different result with the same source", test.name)
+		}
+	}
+}
+
+func TestCoolingSchedules(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		schedule CoolingSchedule
+	}{
+		{"Exponential", &ExponentialCooling{}},
+		{"Logarithmic", &LogarithmicCooling{}},
+		{"Adaptive", &AdaptiveCooling{}},
+	} {
+		test.schedule.Init(1)
+		prev := 1.0
+		for k := 1; k <= 1000; k++ {
+			temp := test.schedule.Temperature(k, k%2 == 0)
+			if !(temp > 0) || temp > prev {
+				t.Errorf("%s: temperature not positive and nonincreasing at step %d: %v after %v", test.name, k, temp, prev)
+				break
+			}
+			prev = temp
+		}
+		if prev >= 1 || math.IsNaN(prev) {
+			t.Errorf("%s: no cooling, final temperature %v", test.name, prev)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/lp"
+)
+
+const (
+	defaultSLPGradientTol   = 1e-6
+	defaultSLPConstraintTol = 1e-6
+	defaultSLPElasticWeight = 100
+	defaultSLPInitialRadius = 1
+	// slpAccept is the smallest ratio of the actual to the predicted
+	// reduction of the merit function for which a step is accepted.
+	slpAccept = 0.1
+	// slpShrinkRatio and slpExpandRatio are the ratios of the actual to the
+	// predicted reduction below which the trust region shrinks, and above
+	// which it expands if the step reaches its boundary.
+	slpShrinkRatio = 0.25
+	slpExpandRatio = 0.75
+	// slpMinRadius is the relative trust-region radius below which the
+	// iteration stops.
+	slpMinRadius = 1e-12
+	// slpMinReduction is the relative predicted reduction of the merit
+	// function below which the iteration stops.
+	slpMinReduction = 1e-15
+)
+
+// SLP implements a sequential linear programming method for minimization
+// subject to the nonlinear constraints Problem.Equality and
+// Problem.Inequality, and the bounds Problem.Bounds. At every iteration it
+// computes the step d from the linear subproblem
+//  minimize    ∇f(x)ᵀ d + ν (|c_E(x) + J_E(x) d|_1 + |max(0, c_I(x) + J_I(x) d)|_1)
+//  subject to  lower <= x + d <= upper,  |d|_∞ <= Δ,
+// which is solved as a linear program with elastic variables by the simplex
+// method of package lp. The subproblem is always feasible, even if the
+// linearized constraints are inconsistent. The step is accepted if it
+// decreases the l1 merit function
+//  φ(x) = f(x) + ν (|c_E(x)|_1 + |max(0, c_I(x))|_1)
+// by a sufficient fraction of the decrease predicted by the subproblem, and
+// the trust-region radius Δ is adapted to the agreement of both. Rejected
+// steps are reported as minor iterations. The penalty parameter ν is
+// ElasticWeight, which must be larger than the multipliers at the solution.
+//
+// SLP needs only the gradients of the function and of the constraints and no
+// approximation of the Hessian, and every iteration costs one linear program.
+// It converges quickly if the solution is determined by the constraints, that
+// is if as many constraints as variables are active at the solution, which is
+// typical for problems with nearly linear constraints and objective. Otherwise
+// the steps are limited by the trust region and the convergence is slow, and
+// SQP should be used.
+//
+// The optimization terminates with MethodConverge when the infinity norm of
+// the gradient of the Lagrangian with the multipliers of the subproblem,
+// projected onto the bounds, is below GradientTolerance, and the violation of
+// the constraints and of complementarity is below ConstraintTolerance, or when
+// the trust-region radius or the predicted reduction of the merit function
+// become negligible. SLP implements MultiplierEstimator, so
+// Result.Multipliers holds the multiplier estimates at the solution.
+//
+// Reference:
+//  Fletcher, R., Sainz de la Maza, E.: Nonlinear programming and nonsmooth
+//  optimization by successive linear programming. Math. Program. 43 (1989),
+//  235-256
+type SLP struct {
+	// GradientTolerance is the tolerance on the infinity norm of the
+	// gradient of the Lagrangian.
+	// If GradientTolerance is zero, it will be set to 1e-6.
+	GradientTolerance float64
+	// ConstraintTolerance is the tolerance on the violation of the
+	// constraints and of complementarity.
+	// If ConstraintTolerance is zero, it will be set to 1e-6.
+	ConstraintTolerance float64
+	// ElasticWeight is the penalty parameter ν of the merit function.
+	// If ElasticWeight is zero, it will be set to 100.
+	ElasticWeight float64
+	// InitialRadius is the initial trust-region radius.
+	// If InitialRadius is zero, it will be set to 1.
+	InitialRadius float64
+
+	eq, in Constraint
+	bounds *Bounds
+	status Status
+	trial  bool // Whether a trial step is being evaluated.
+
+	radius float64
+	lambda []float64 // Multiplier estimates of the equality constraints.
+	mu     []float64 // Multiplier estimates of the inequality constraints.
+
+	// Data at the last major iteration.
+	x, grad    []float64
+	cEqK, cInK []float64
+	jacEqK     *mat64.Dense
+	jacInK     *mat64.Dense
+	merit      float64
+
+	// Data at the last evaluated location.
+	cEq, cIn     []float64
+	jacEq, jacIn *mat64.Dense
+
+	dir  []float64 // Step of the subproblem.
+	pred float64   // Reduction of the merit function predicted by the subproblem.
+	gl   []float64
+}
+
+func (s *SLP) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if s.GradientTolerance == 0 {
+		s.GradientTolerance = defaultSLPGradientTol
+	}
+	if s.ConstraintTolerance == 0 {
+		s.ConstraintTolerance = defaultSLPConstraintTol
+	}
+	if s.ElasticWeight == 0 {
+		s.ElasticWeight = defaultSLPElasticWeight
+	}
+	if s.InitialRadius == 0 {
+		s.InitialRadius = defaultSLPInitialRadius
+	}
+	if s.GradientTolerance < 0 {
+		panic("slp: negative gradient tolerance")
+	}
+	if s.ConstraintTolerance < 0 {
+		panic("slp: negative constraint tolerance")
+	}
+	if s.ElasticWeight < 0 {
+		panic("slp: negative elastic weight")
+	}
+	if s.InitialRadius < 0 {
+		panic("slp: negative initial radius")
+	}
+
+	dim := len(loc.X)
+	s.eq = p.Equality
+	s.in = p.Inequality
+	s.bounds = p.Bounds
+	s.status = NotTerminated
+	s.radius = s.InitialRadius
+
+	var nEq, nIn int
+	if s.eq != nil {
+		nEq = s.eq.Len()
+		s.jacEq = resizeDense(s.jacEq, nEq, dim)
+		s.jacEqK = resizeDense(s.jacEqK, nEq, dim)
+	}
+	if s.in != nil {
+		nIn = s.in.Len()
+		s.jacIn = resizeDense(s.jacIn, nIn, dim)
+		s.jacInK = resizeDense(s.jacInK, nIn, dim)
+	}
+	s.cEq = resize(s.cEq, nEq)
+	s.cEqK = resize(s.cEqK, nEq)
+	s.cIn = resize(s.cIn, nIn)
+	s.cInK = resize(s.cInK, nIn)
+	s.lambda = resize(s.lambda, nEq)
+	s.mu = resize(s.mu, nIn)
+	s.x = resize(s.x, dim)
+	s.grad = resize(s.grad, dim)
+	s.dir = resize(s.dir, dim)
+	s.gl = resize(s.gl, dim)
+
+	s.evaluateConstraints(loc.X)
+	s.accept(loc)
+	return s.newIteration(loc, xNext, false)
+}
+
+func (s *SLP) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	if !s.trial {
+		// A MajorIteration has been announced and the step of the new
+		// subproblem is tried.
+		return s.nextStep(xNext)
+	}
+
+	s.evaluateConstraints(loc.X)
+	stepNorm := floats.Norm(s.dir, math.Inf(1))
+	ared := s.merit - s.meritFunc(loc.F, s.cEq, s.cIn)
+	rho := ared / s.pred
+	if rho < slpShrinkRatio || math.IsNaN(rho) {
+		s.radius = slpShrinkRatio * stepNorm
+	} else if rho > slpExpandRatio && stepNorm >= (1-1e-8)*s.radius {
+		s.radius *= 2
+	}
+	if rho > slpAccept {
+		s.accept(loc)
+		return s.newIteration(loc, xNext, true)
+	}
+	// The step is rejected, compute a new one within the smaller region.
+	if err := s.subproblem(); err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	if s.stalled() {
+		// Return to the last major iteration, which must be evaluated
+		// again because loc is the rejected step.
+		s.status = MethodConverge
+		copy(xNext, s.x)
+		return FuncEvaluation | GradEvaluation, MajorIteration, nil
+	}
+	return s.nextStep(xNext)
+}
+
+// newIteration solves the subproblem at the new major iteration loc and
+// checks for convergence. If announce is true, the major iteration is
+// announced and the step is tried in the next call to Iterate.
+func (s *SLP) newIteration(loc *Location, xNext []float64, announce bool) (EvaluationType, IterationType, error) {
+	if err := s.subproblem(); err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	s.trial = false
+	if s.converged() || s.stalled() {
+		s.status = MethodConverge
+		announce = true
+	}
+	if announce {
+		copy(xNext, loc.X)
+		return NoEvaluation, MajorIteration, nil
+	}
+	return s.nextStep(xNext)
+}
+
+// nextStep tries the step of the subproblem.
+func (s *SLP) nextStep(xNext []float64) (EvaluationType, IterationType, error) {
+	s.trial = true
+	floats.AddTo(xNext, s.x, s.dir)
+	return FuncEvaluation | GradEvaluation, MinorIteration, nil
+}
+
+// accept stores loc and the constraints evaluated at loc as the last major
+// iteration.
+func (s *SLP) accept(loc *Location) {
+	copy(s.x, loc.X)
+	copy(s.grad, loc.Gradient)
+	if s.eq != nil {
+		s.jacEqK.Copy(s.jacEq)
+		copy(s.cEqK, s.cEq)
+	}
+	if s.in != nil {
+		s.jacInK.Copy(s.jacIn)
+		copy(s.cInK, s.cIn)
+	}
+	s.merit = s.meritFunc(loc.F, s.cEqK, s.cInK)
+}
+
+// subproblem solves the linear subproblem at the last major iteration
+//  minimize    ∇f(x)ᵀ d + ν Σ(v + w + t)
+//  subject to  c_E(x) + J_E(x) d - v + w = 0
+//              c_I(x) + J_I(x) d - t <= 0
+//              max(-Δ, lower - x) <= d <= min(Δ, upper - x)
+//              v, w, t >= 0
+// and stores the step, the multiplier estimates and the predicted reduction
+// of the merit function.
+func (s *SLP) subproblem() error {
+	n := len(s.x)
+	nEq, nIn := len(s.cEqK), len(s.cInK)
+	dim := n + 2*nEq + nIn
+
+	c := make([]float64, dim)
+	copy(c, s.grad)
+	lower := make([]float64, dim)
+	upper := make([]float64, dim)
+	for j := 0; j < n; j++ {
+		lower[j] = -s.radius
+		upper[j] = s.radius
+		if s.bounds != nil {
+			lower[j] = math.Max(lower[j], s.bounds.lower(j)-s.x[j])
+			upper[j] = math.Min(upper[j], s.bounds.upper(j)-s.x[j])
+		}
+	}
+	for j := n; j < dim; j++ {
+		c[j] = s.ElasticWeight
+		upper[j] = math.Inf(1)
+	}
+	p := lp.Problem{C: c, Lower: lower, Upper: upper}
+	if nEq > 0 {
+		aeq := mat64.NewDense(nEq, dim, nil)
+		p.Beq = make([]float64, nEq)
+		for i := 0; i < nEq; i++ {
+			for j := 0; j < n; j++ {
+				aeq.Set(i, j, s.jacEqK.At(i, j))
+			}
+			aeq.Set(i, n+i, -1)
+			aeq.Set(i, n+nEq+i, 1)
+			p.Beq[i] = -s.cEqK[i]
+		}
+		p.Aeq = aeq
+	}
+	if nIn > 0 {
+		a := mat64.NewDense(nIn, dim, nil)
+		p.B = make([]float64, nIn)
+		for i := 0; i < nIn; i++ {
+			for j := 0; j < n; j++ {
+				a.Set(i, j, s.jacInK.At(i, j))
+			}
+			a.Set(i, n+2*nEq+i, -1)
+			p.B[i] = -s.cInK[i]
+		}
+		p.A = a
+	}
+	res, err := lp.SolveResult(p, 0)
+	if err != nil {
+		return err
+	}
+	copy(s.dir, res.X[:n])
+	copy(s.lambda, res.Eq)
+	copy(s.mu, res.Ineq)
+	// The objective of the subproblem is the linearized merit function
+	// without f(x), which is ν times the violation at d = 0.
+	s.pred = s.ElasticWeight*l1Violation(s.cEqK, s.cInK) - res.F
+	return nil
+}
+
+// converged returns whether the first-order optimality conditions hold at the
+// last major iteration with the multiplier estimates of the last subproblem.
+func (s *SLP) converged() bool {
+	copy(s.gl, s.grad)
+	if s.eq != nil {
+		addJacTVec(s.gl, s.jacEqK, s.lambda)
+	}
+	if s.in != nil {
+		addJacTVec(s.gl, s.jacInK, s.mu)
+	}
+	if s.bounds != nil {
+		// The multipliers of the active bounds take up the components of
+		// the gradient of the Lagrangian that point out of the bounds.
+		for j, x := range s.x {
+			if x <= s.bounds.lower(j)+s.ConstraintTolerance {
+				s.gl[j] = math.Min(s.gl[j], 0)
+			}
+			if x >= s.bounds.upper(j)-s.ConstraintTolerance {
+				s.gl[j] = math.Max(s.gl[j], 0)
+			}
+		}
+	}
+	if floats.Norm(s.gl, math.Inf(1)) > s.GradientTolerance {
+		return false
+	}
+	var viol float64
+	for _, c := range s.cEqK {
+		viol = math.Max(viol, math.Abs(c))
+	}
+	for i, c := range s.cInK {
+		viol = math.Max(viol, math.Max(c, math.Abs(s.mu[i]*c)))
+	}
+	return viol <= s.ConstraintTolerance
+}
+
+// stalled returns whether the trust region or the reduction of the merit
+// function predicted by the subproblem are negligible. If the predicted
+// reduction is zero, the last major iteration is a stationary point of the
+// merit function.
+func (s *SLP) stalled() bool {
+	return s.radius < slpMinRadius*math.Max(1, floats.Norm(s.x, math.Inf(1))) ||
+		s.pred <= slpMinReduction*math.Max(1, math.Abs(s.merit))
+}
+
+// meritFunc returns the l1 merit function for the function value f and the
+// constraint values cEq and cIn.
+func (s *SLP) meritFunc(f float64, cEq, cIn []float64) float64 {
+	return f + s.ElasticWeight*l1Violation(cEq, cIn)
+}
+
+// evaluateConstraints evaluates the constraint functions and their Jacobians
+// at x.
+func (s *SLP) evaluateConstraints(x []float64) {
+	if s.eq != nil {
+		s.eq.Func(x, s.cEq)
+		s.eq.Jac(x, s.jacEq)
+	}
+	if s.in != nil {
+		s.in.Func(x, s.cIn)
+		s.in.Jac(x, s.jacIn)
+	}
+}
+
+// Status returns MethodConverge when the first-order optimality conditions
+// hold at the last major iteration or the iteration has stalled.
+func (s *SLP) Status() (Status, error) {
+	return s.status, nil
+}
+
+// Multipliers stores the multiplier estimates of the last subproblem into eq
+// and ineq.
+func (s *SLP) Multipliers(eq, ineq []float64) {
+	copy(eq, s.lambda)
+	copy(ineq, s.mu)
+}
+
+func (*SLP) HandlesBounds() bool {
+	return true
+}
+
+func (*SLP) HandlesConstraints() bool {
+	return true
+}
+
+func (*SLP) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
@@ -0,0 +1,554 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// defaultFDStep is the default relative step size for forward differences,
+// approximately the square root of the machine epsilon.
+const defaultFDStep = 1.5e-8
+
+// SparsityPattern describes the structurally nonzero elements of a matrix.
+// The k-th nonzero element is located at row Row[k] and column Col[k].
+// Duplicate entries are allowed.
+type SparsityPattern struct {
+	Row []int
+	Col []int
+}
+
+// DetectHessianSparsity probes the sparsity pattern of the Hessian of a
+// function at x by forward differences of the gradient along every coordinate
+// direction. An element of the Hessian is considered structurally nonzero if
+// its finite-difference approximation is larger than tol in absolute value.
+// The diagonal is always included. DetectHessianSparsity requires len(x)
+// gradient evaluations in addition to the one at x. To avoid spurious zeros,
+// x should be a generic point rather than, for example, the origin.
+func DetectHessianSparsity(grad func(x, grad []float64), x []float64, tol float64) SparsityPattern {
+	dim := len(x)
+	g0 := make([]float64, dim)
+	g1 := make([]float64, dim)
+	xh := make([]float64, dim)
+	copy(xh, x)
+	grad(x, g0)
+
+	var pattern SparsityPattern
+	for j := 0; j < dim; j++ {
+		h := defaultFDStep * math.Max(1, math.Abs(x[j]))
+		xh[j] = x[j] + h
+		grad(xh, g1)
+		xh[j] = x[j]
+		for i := 0; i < dim; i++ {
+			if i == j || math.Abs(g1[i]-g0[i])/h > tol {
+				pattern.Row = append(pattern.Row, i)
+				pattern.Col = append(pattern.Col, j)
+			}
+		}
+	}
+	return pattern
+}
+
+// colorColumns partitions the columns of an m×n matrix with the given sparsity
+// pattern into groups (colors) such that no two columns in the same group have
+// a nonzero element in the same row. colorColumns uses a greedy
+// largest-first ordering and returns the color of every column and the number
+// of colors.
+func colorColumns(m, n int, pattern SparsityPattern) (colors []int, nColors int) {
+	if len(pattern.Row) != len(pattern.Col) {
+		panic("optimize: sparsity pattern length mismatch")
+	}
+	// Column-wise and row-wise adjacency lists.
+	colRows := make([][]int, n)
+	rowCols := make([][]int, m)
+	for k, i := range pattern.Row {
+		j := pattern.Col[k]
+		if i < 0 || i >= m || j < 0 || j >= n {
+			panic("optimize: sparsity pattern index out of range")
+		}
+		colRows[j] = append(colRows[j], i)
+		rowCols[i] = append(rowCols[i], j)
+	}
+
+	// Order the columns by decreasing number of nonzeros.
+	order := make([]int, n)
+	for j := range order {
+		order[j] = j
+	}
+	for a := 1; a < n; a++ {
+		for b := a; b > 0 && len(colRows[order[b]]) > len(colRows[order[b-1]]); b-- {
+			order[b], order[b-1] = order[b-1], order[b]
+		}
+	}
+
+	colors = make([]int, n)
+	for j := range colors {
+		colors[j] = -1
+	}
+	// forbidden[c] == j+1 if color c cannot be used for column j.
+	forbidden := make([]int, n)
+	for _, j := range order {
+		for _, i := range colRows[j] {
+			for _, k := range rowCols[i] {
+				if colors[k] >= 0 {
+					forbidden[colors[k]] = j + 1
+				}
+			}
+		}
+		c := 0
+		for forbidden[c] == j+1 {
+			c++
+		}
+		colors[j] = c
+		if c+1 > nColors {
+			nColors = c + 1
+		}
+	}
+	return colors, nColors
+}
+
+// SparseHessian approximates a sparse Hessian matrix by forward differences of
+// the gradient. The columns of the Hessian are partitioned into groups such
+// that no two columns in the same group have a nonzero element in the same
+// row, so that all columns of a group can be estimated from a single gradient
+// difference along the sum of their coordinate directions. The number of
+// gradient evaluations needed for one Hessian approximation is thus equal to
+// the number of groups, which for structured problems is often much smaller
+// than the problem dimension. For example, a tridiagonal Hessian of any size
+// requires only three gradient evaluations.
+//
+// Both the (i,j) and (j,i) elements of the Hessian are estimated and their
+// average is stored, so the returned approximation is symmetric.
+//
+// References:
+//  - Curtis, A.R., Powell, M.J.D., Reid, J.K.: On the estimation of sparse
+//    Jacobian matrices. IMA J Appl Math 13 (1974), 117-119
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Section 8.1
+type SparseHessian struct {
+	// Step is the relative finite-difference step size. The step for the
+	// j-th coordinate is Step*max(1, |x_j|).
+	// If Step is zero, it will be set to 1.5e-8.
+	Step float64
+
+	dim     int
+	colors  []int
+	nColors int
+	rows    [][]int // Rows of the nonzero elements in every column.
+
+	xh []float64
+	gh []float64
+	h  []float64
+}
+
+// NewSparseHessian returns a new SparseHessian for n×n Hessians with the
+// given sparsity pattern. Only one of the (i,j) and (j,i) elements needs to be
+// present in the pattern, the diagonal is always assumed to be nonzero.
+func NewSparseHessian(n int, pattern SparsityPattern) *SparseHessian {
+	if len(pattern.Row) != len(pattern.Col) {
+		panic("optimize: sparsity pattern length mismatch")
+	}
+	// Symmetrize the pattern and add the diagonal.
+	present := make(map[[2]int]bool)
+	var sym SparsityPattern
+	add := func(i, j int) {
+		if present[[2]int{i, j}] {
+			return
+		}
+		present[[2]int{i, j}] = true
+		sym.Row = append(sym.Row, i)
+		sym.Col = append(sym.Col, j)
+	}
+	for i := 0; i < n; i++ {
+		add(i, i)
+	}
+	for k, i := range pattern.Row {
+		j := pattern.Col[k]
+		add(i, j)
+		add(j, i)
+	}
+
+	s := &SparseHessian{
+		dim:  n,
+		rows: make([][]int, n),
+		xh:   make([]float64, n),
+		gh:   make([]float64, n),
+		h:    make([]float64, n),
+	}
+	s.colors, s.nColors = colorColumns(n, n, sym)
+	for k, i := range sym.Row {
+		j := sym.Col[k]
+		s.rows[j] = append(s.rows[j], i)
+	}
+	return s
+}
+
+// Colors returns the number of column groups, that is, the number of
+// gradient evaluations needed by Hessian.
+func (s *SparseHessian) Colors() int {
+	return s.nColors
+}
+
+// Hessian stores into hess the finite-difference approximation of the
+// Hessian at x. grad evaluates the gradient of the function and gx must
+// contain the gradient at x. Hessian does not modify x and gx.
+func (s *SparseHessian) Hessian(hess *mat64.SymDense, grad func(x, grad []float64), x, gx []float64) {
+	if len(x) != s.dim || len(gx) != s.dim || hess.Symmetric() != s.dim {
+		panic("optimize: sparse Hessian size mismatch")
+	}
+	step := s.Step
+	if step == 0 {
+		step = defaultFDStep
+	}
+	for i := 0; i < s.dim; i++ {
+		for j := i; j < s.dim; j++ {
+			hess.SetSym(i, j, 0)
+		}
+	}
+	for c := 0; c < s.nColors; c++ {
+		copy(s.xh, x)
+		for j, cj := range s.colors {
+			if cj == c {
+				s.h[j] = step * math.Max(1, math.Abs(x[j]))
+				s.xh[j] += s.h[j]
+			}
+		}
+		grad(s.xh, s.gh)
+		floats.Sub(s.gh, gx)
+		for j, cj := range s.colors {
+			if cj != c {
+				continue
+			}
+			for _, i := range s.rows[j] {
+				// Every element is visited twice, once as (i,j) and once
+				// as (j,i), except the diagonal.
+				v := s.gh[i] / s.h[j]
+				if i == j {
+					hess.SetSym(i, i, v)
+				} else {
+					hess.SetSym(i, j, hess.At(i, j)+v/2)
+				}
+			}
+		}
+	}
+}
+
+// SparseJacobian approximates a sparse m×n Jacobian matrix of a vector-valued
+// function by forward differences. As in SparseHessian, the columns of the
+// Jacobian are partitioned into groups such that no two columns in the same
+// group have a nonzero element in the same row, and every group is estimated
+// from a single function evaluation. The number of function evaluations
+// needed for one Jacobian approximation is equal to the number of groups,
+// which is at least the maximum number of nonzero elements in a row. This
+// makes finite-difference Jacobians practical for large least-squares and
+// root-finding problems where every residual depends only on a few variables.
+//
+// Reference:
+//  Curtis, A.R., Powell, M.J.D., Reid, J.K.: On the estimation of sparse
+//  Jacobian matrices. IMA J Appl Math 13 (1974), 117-119
+type SparseJacobian struct {
+	// Step is the relative finite-difference step size. The step for the
+	// j-th coordinate is Step*max(1, |x_j|).
+	// If Step is zero, it will be set to 1.5e-8.
+	Step float64
+
+	m, n    int
+	colors  []int
+	nColors int
+	rows    [][]int // Rows of the nonzero elements in every column.
+
+	xh []float64
+	fh []float64
+	h  []float64
+}
+
+// NewSparseJacobian returns a new SparseJacobian for m×n Jacobians whose
+// nonzero elements are located at the rows and columns given by pattern.
+func NewSparseJacobian(m, n int, pattern SparsityPattern) *SparseJacobian {
+	s := &SparseJacobian{
+		m:    m,
+		n:    n,
+		rows: make([][]int, n),
+		xh:   make([]float64, n),
+		fh:   make([]float64, m),
+		h:    make([]float64, n),
+	}
+	s.colors, s.nColors = colorColumns(m, n, pattern)
+	present := make(map[[2]int]bool)
+	for k, i := range pattern.Row {
+		j := pattern.Col[k]
+		if present[[2]int{i, j}] {
+			continue
+		}
+		present[[2]int{i, j}] = true
+		s.rows[j] = append(s.rows[j], i)
+	}
+	return s
+}
+
+// Colors returns the number of column groups, that is, the number of
+// function evaluations needed by Jacobian.
+func (s *SparseJacobian) Colors() int {
+	return s.nColors
+}
+
+// Jacobian stores into jac the finite-difference approximation of the
+// Jacobian at x. f evaluates the function at x and stores the result in place
+// into y, and fx must contain the value of the function at x. Elements of jac
+// outside of the sparsity pattern are set to zero. Jacobian does not modify x
+// and fx.
+func (s *SparseJacobian) Jacobian(jac *mat64.Dense, f func(x, y []float64), x, fx []float64) {
+	r, c := jac.Dims()
+	if r != s.m || c != s.n || len(x) != s.n || len(fx) != s.m {
+		panic("optimize: sparse Jacobian size mismatch")
+	}
+	step := s.Step
+	if step == 0 {
+		step = defaultFDStep
+	}
+	for i := 0; i < s.m; i++ {
+		for j := 0; j < s.n; j++ {
+			jac.Set(i, j, 0)
+		}
+	}
+	for c := 0; c < s.nColors; c++ {
+		copy(s.xh, x)
+		for j, cj := range s.colors {
+			if cj == c {
+				s.h[j] = step * math.Max(1, math.Abs(x[j]))
+				s.xh[j] += s.h[j]
+			}
+		}
+		f(s.xh, s.fh)
+		floats.Sub(s.fh, fx)
+		for j, cj := range s.colors {
+			if cj != c {
+				continue
+			}
+			for _, i := range s.rows[j] {
+				jac.Set(i, j, s.fh[i]/s.h[j])
+			}
+		}
+	}
+}
+
+// envelopeCholesky is a Cholesky factorization A = L Lᵀ of a sparse symmetric
+// positive definite matrix stored in envelope (skyline) format. The envelope
+// of the i-th row of L consists of the columns from the first nonzero element
+// of the i-th row of A to the diagonal. The factorization produces no fill-in
+// outside of the envelope, so for matrices with a small profile, for example
+// the banded normal equations of least-squares problems where every residual
+// depends on a few neighboring variables, both the storage and the work are
+// much smaller than for a dense factorization.
+//
+// Reference:
+//  George, A., Liu, J.W.: Computer Solution of Large Sparse Positive Definite
+//  Systems. Prentice-Hall (1981), Chapter 4
+type envelopeCholesky struct {
+	n     int
+	first []int     // Column of the first element of the envelope of every row.
+	ptr   []int     // Offset of every row in l.
+	l     []float64 // Rows of L from first[i] to i.
+}
+
+// factorize computes the factorization of a and returns false if a is not
+// positive definite. The envelope is determined from the zero elements of a.
+func (c *envelopeCholesky) factorize(a mat64.Symmetric) bool {
+	n := a.Symmetric()
+	c.first = resizeInts(c.first, n)
+	for i := 0; i < n; i++ {
+		j := 0
+		for j < i && a.At(i, j) == 0 {
+			j++
+		}
+		c.first[i] = j
+	}
+	c.setEnvelope(c.first)
+	return c.factorizeFunc(a.At)
+}
+
+// setEnvelope sets the envelope of the rows of L, where first[i] <= i is the
+// column of the first element of the envelope of the i-th row.
+func (c *envelopeCholesky) setEnvelope(first []int) {
+	n := len(first)
+	c.n = n
+	c.first = resizeInts(c.first, n)
+	copy(c.first, first)
+	c.ptr = resizeInts(c.ptr, n+1)
+	c.ptr[0] = 0
+	for i, j := range c.first {
+		c.ptr[i+1] = c.ptr[i] + i - j + 1
+	}
+	c.l = resize(c.l, c.ptr[n])
+}
+
+// factorizeFunc computes the factorization of the matrix whose elements in the
+// envelope set by setEnvelope are returned by at, and returns false if the
+// matrix is not positive definite. The elements outside of the envelope are
+// assumed to be zero.
+func (c *envelopeCholesky) factorizeFunc(at func(i, j int) float64) bool {
+	for i := 0; i < c.n; i++ {
+		fi := c.first[i]
+		row := c.l[c.ptr[i]:c.ptr[i+1]]
+		for j := fi; j <= i; j++ {
+			// The inner product of the rows i and j of L over the
+			// columns in both envelopes that precede j.
+			k0 := fi
+			if c.first[j] > k0 {
+				k0 = c.first[j]
+			}
+			rowJ := c.l[c.ptr[j]+k0-c.first[j] : c.ptr[j]+j-c.first[j]]
+			v := at(i, j) - floats.Dot(row[k0-fi:j-fi], rowJ)
+			if j < i {
+				row[j-fi] = v / c.l[c.ptr[j+1]-1]
+				continue
+			}
+			if !(v > 0) {
+				return false
+			}
+			row[i-fi] = math.Sqrt(v)
+		}
+	}
+	return true
+}
+
+// solve stores the solution of A x = b into dst. dst and b may be the same.
+func (c *envelopeCholesky) solve(dst, b []float64) {
+	copy(dst, b)
+	// Forward substitution with L.
+	for i := 0; i < c.n; i++ {
+		fi := c.first[i]
+		row := c.l[c.ptr[i]:c.ptr[i+1]]
+		dst[i] = (dst[i] - floats.Dot(row[:i-fi], dst[fi:i])) / row[i-fi]
+	}
+	// Backward substitution with Lᵀ by columns of Lᵀ, which are the rows
+	// of L.
+	for i := c.n - 1; i >= 0; i-- {
+		fi := c.first[i]
+		row := c.l[c.ptr[i]:c.ptr[i+1]]
+		dst[i] /= row[i-fi]
+		floats.AddScaled(dst[fi:i], -dst[i], row[:i-fi])
+	}
+}
+
+// sparseCholesky is a Cholesky factorization of sparse symmetric positive
+// definite matrices with a fixed sparsity pattern. The rows and columns are
+// reordered by the reverse Cuthill-McKee algorithm to reduce the envelope of
+// the matrix, and the permuted matrix is factorized by envelopeCholesky. Only
+// the elements of the matrix in the pattern and on the diagonal are read.
+//
+// Reference:
+//  George, A., Liu, J.W.: Computer Solution of Large Sparse Positive Definite
+//  Systems. Prentice-Hall (1981), Chapter 4
+type sparseCholesky struct {
+	perm []int // perm[k] is the row of the matrix that is the k-th row of L.
+	env  envelopeCholesky
+	tmp  []float64
+}
+
+// newSparseCholesky returns a new sparseCholesky for n×n matrices with the
+// given sparsity pattern. Only one of the (i,j) and (j,i) elements needs to be
+// present in the pattern.
+func newSparseCholesky(n int, pattern SparsityPattern) *sparseCholesky {
+	if len(pattern.Row) != len(pattern.Col) {
+		panic("optimize: sparsity pattern length mismatch")
+	}
+	adj := make([][]int, n)
+	for k, i := range pattern.Row {
+		j := pattern.Col[k]
+		if i < 0 || i >= n || j < 0 || j >= n {
+			panic("optimize: sparsity pattern index out of range")
+		}
+		if i != j {
+			adj[i] = append(adj[i], j)
+			adj[j] = append(adj[j], i)
+		}
+	}
+
+	// Cuthill-McKee ordering: a breadth-first search of every connected
+	// component from a vertex of minimum degree, visiting the neighbors in
+	// order of increasing degree.
+	perm := make([]int, 0, n)
+	visited := make([]bool, n)
+	for len(perm) < n {
+		start := -1
+		for i := 0; i < n; i++ {
+			if !visited[i] && (start < 0 || len(adj[i]) < len(adj[start])) {
+				start = i
+			}
+		}
+		visited[start] = true
+		perm = append(perm, start)
+		for head := len(perm) - 1; head < len(perm); head++ {
+			next := len(perm)
+			for _, j := range adj[perm[head]] {
+				if !visited[j] {
+					visited[j] = true
+					perm = append(perm, j)
+				}
+			}
+			for a := next + 1; a < len(perm); a++ {
+				for b := a; b > next && len(adj[perm[b]]) < len(adj[perm[b-1]]); b-- {
+					perm[b], perm[b-1] = perm[b-1], perm[b]
+				}
+			}
+		}
+	}
+	// Reversing the ordering does not increase the envelope and often
+	// reduces it.
+	for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+
+	inv := make([]int, n)
+	for k, i := range perm {
+		inv[i] = k
+	}
+	first := make([]int, n)
+	for k, i := range perm {
+		first[k] = k
+		for _, j := range adj[i] {
+			if inv[j] < first[k] {
+				first[k] = inv[j]
+			}
+		}
+	}
+	c := &sparseCholesky{
+		perm: perm,
+		tmp:  make([]float64, n),
+	}
+	c.env.setEnvelope(first)
+	return c
+}
+
+// factorize computes the factorization of a + tau I and returns false if it
+// is not positive definite.
+func (c *sparseCholesky) factorize(a mat64.Symmetric, tau float64) bool {
+	if a.Symmetric() != len(c.perm) {
+		panic("optimize: sparse Cholesky size mismatch")
+	}
+	return c.env.factorizeFunc(func(i, j int) float64 {
+		v := a.At(c.perm[i], c.perm[j])
+		if i == j {
+			v += tau
+		}
+		return v
+	})
+}
+
+// solve stores the solution of (A + tau I) x = b into dst. dst and b may be
+// the same.
+func (c *sparseCholesky) solve(dst, b []float64) {
+	for k, i := range c.perm {
+		c.tmp[k] = b[i]
+	}
+	c.env.solve(c.tmp, c.tmp)
+	for k, i := range c.perm {
+		dst[i] = c.tmp[k]
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/functions"
+)
+
+// rosenbrockHessian returns the exact Hessian of the extended Rosenbrock
+// function at x.
+func rosenbrockHessian(x []float64) *mat64.SymDense {
+	dim := len(x)
+	hess := mat64.NewSymDense(dim, nil)
+	for i := 0; i < dim-1; i++ {
+		hess.SetSym(i, i, hess.At(i, i)+2+1200*x[i]*x[i]-400*x[i+1])
+		hess.SetSym(i, i+1, -400*x[i])
+		hess.SetSym(i+1, i+1, hess.At(i+1, i+1)+200)
+	}
+	return hess
+}
+
+func TestSparseHessian(t *testing.T) {
+	const dim = 50
+	grad := functions.ExtendedRosenbrock{}.Grad
+	x := make([]float64, dim)
+	for i := range x {
+		x[i] = 1 + 0.1*math.Sin(float64(i))
+	}
+	gx := make([]float64, dim)
+	grad(x, gx)
+	want := rosenbrockHessian(x)
+
+	// Declare the superdiagonal of the tridiagonal Hessian.
+	var declared SparsityPattern
+	for i := 0; i < dim-1; i++ {
+		declared.Row = append(declared.Row, i)
+		declared.Col = append(declared.Col, i+1)
+	}
+
+	for _, test := range []struct {
+		name    string
+		pattern SparsityPattern
+	}{
+		{
+			name:    "Declared",
+			pattern: declared,
+		},
+		{
+			name:    "Detected",
+			pattern: DetectHessianSparsity(grad, x, 1e-3),
+		},
+	} {
+		sh := NewSparseHessian(dim, test.pattern)
+		if sh.Colors() != 3 {
+			t.Errorf("%s: unexpected number of colors for a tridiagonal Hessian. Want 3, got %d", test.name, sh.Colors())
+		}
+		var evals int
+		hess := mat64.NewSymDense(dim, nil)
+		sh.Hessian(hess, func(x, g []float64) {
+			evals++
+			grad(x, g)
+		}, x, gx)
+		if evals != sh.Colors() {
+			t.Errorf("%s: unexpected number of gradient evaluations. Want %d, got %d", test.name, sh.Colors(), evals)
+		}
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				if math.Abs(hess.At(i, j)-want.At(i, j)) > 1e-4*math.Max(1, math.Abs(want.At(i, j))) {
+					t.Errorf("%s: mismatch at (%d,%d): want %v, got %v", test.name, i, j, want.At(i, j), hess.At(i, j))
+				}
+			}
+		}
+	}
+}
+
+func TestSparseJacobian(t *testing.T) {
+	const (
+		m = 40
+		n = 30
+	)
+	// Every residual depends on at most three consecutive variables.
+	f := func(x, y []float64) {
+		for i := range y {
+			j := i % n
+			y[i] = x[j] * x[j]
+			if j+1 < n {
+				y[i] -= math.Sin(x[j+1])
+			}
+			if j+2 < n {
+				y[i] += x[j] * x[j+2]
+			}
+		}
+	}
+	var pattern SparsityPattern
+	want := mat64.NewDense(m, n, nil)
+	x := make([]float64, n)
+	for j := range x {
+		x[j] = 0.5 + 0.1*float64(j)
+	}
+	for i := 0; i < m; i++ {
+		j := i % n
+		pattern.Row = append(pattern.Row, i)
+		pattern.Col = append(pattern.Col, j)
+		want.Set(i, j, 2*x[j])
+		if j+1 < n {
+			pattern.Row = append(pattern.Row, i)
+			pattern.Col = append(pattern.Col, j+1)
+			want.Set(i, j+1, -math.Cos(x[j+1]))
+		}
+		if j+2 < n {
+			pattern.Row = append(pattern.Row, i, i)
+			pattern.Col = append(pattern.Col, j+2, j)
+			want.Set(i, j, want.At(i, j)+x[j+2])
+			want.Set(i, j+2, x[j])
+		}
+	}
+
+	sj := NewSparseJacobian(m, n, pattern)
+	if sj.Colors() != 3 {
+		t.Errorf("unexpected number of colors. Want 3, got %d", sj.Colors())
+	}
+	fx := make([]float64, m)
+	f(x, fx)
+	var evals int
+	jac := mat64.NewDense(m, n, nil)
+	sj.Jacobian(jac, func(x, y []float64) {
+		evals++
+		f(x, y)
+	}, x, fx)
+	if evals != sj.Colors() {
+		t.Errorf("unexpected number of function evaluations. Want %d, got %d", sj.Colors(), evals)
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			if math.Abs(jac.At(i, j)-want.At(i, j)) > 1e-6 {
+				t.Errorf("mismatch at (%d,%d): want %v, got %v", i, j, want.At(i, j), jac.At(i, j))
+			}
+		}
+	}
+}
+
+func TestEnvelopeCholesky(t *testing.T) {
+	const n = 30
+	rnd := rand.New(rand.NewSource(1))
+	for _, bw := range []int{0, 1, 3, n} {
+		// A diagonally dominant matrix with bandwidth bw and a few
+		// elements farther away from the diagonal.
+		a := mat64.NewSymDense(n, nil)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n && j <= i+bw; j++ {
+				a.SetSym(i, j, rnd.NormFloat64())
+			}
+		}
+		if bw > 1 {
+			a.SetSym(2, n-1, 0.5)
+		}
+		for i := 0; i < n; i++ {
+			var s float64
+			for j := 0; j < n; j++ {
+				if j != i {
+					s += math.Abs(a.At(i, j))
+				}
+			}
+			a.SetSym(i, i, s+1)
+		}
+		b := make([]float64, n)
+		for i := range b {
+			b[i] = rnd.NormFloat64()
+		}
+
+		var c envelopeCholesky
+		if !c.factorize(a) {
+			t.Errorf("bandwidth %d: factorization failed", bw)
+			continue
+		}
+		x := make([]float64, n)
+		c.solve(x, b)
+		for i := 0; i < n; i++ {
+			var v float64
+			for j := 0; j < n; j++ {
+				v += a.At(i, j) * x[j]
+			}
+			if math.Abs(v-b[i]) > 1e-12 {
+				t.Errorf("bandwidth %d: residual %v in row %d", bw, v-b[i], i)
+			}
+		}
+		if bw == 1 && len(c.l) != 2*n-1 {
+			t.Errorf("unexpected storage %d of a tridiagonal matrix", len(c.l))
+		}
+	}
+
+	a := mat64.NewSymDense(2, []float64{1, 2, 2, 1})
+	var c envelopeCholesky
+	if c.factorize(a) {
+		t.Errorf("factorization of an indefinite matrix did not fail")
+	}
+}
+
+func TestSparseCholesky(t *testing.T) {
+	const n = 40
+	rnd := rand.New(rand.NewSource(1))
+	// A tridiagonal matrix with randomly permuted rows and columns, whose
+	// envelope is large without reordering.
+	p := rnd.Perm(n)
+	a := mat64.NewSymDense(n, nil)
+	var pattern SparsityPattern
+	for k := 0; k < n; k++ {
+		a.SetSym(p[k], p[k], 4)
+		if k > 0 {
+			a.SetSym(p[k-1], p[k], -1)
+			pattern.Row = append(pattern.Row, p[k])
+			pattern.Col = append(pattern.Col, p[k-1])
+		}
+	}
+	// An element outside of the pattern is ignored.
+	noisy := mat64.NewSymDense(n, nil)
+	noisy.CopySym(a)
+	noisy.SetSym(p[0], p[n-1], 100)
+
+	c := newSparseCholesky(n, pattern)
+	if len(c.env.l) != 2*n-1 {
+		t.Errorf("unexpected size of the factor of a tridiagonal matrix. Want %d, got %d", 2*n-1, len(c.env.l))
+	}
+	b := make([]float64, n)
+	for i := range b {
+		b[i] = rnd.NormFloat64()
+	}
+	x := make([]float64, n)
+	for _, tau := range []float64{0, 0.5} {
+		if !c.factorize(noisy, tau) {
+			t.Errorf("tau = %v: factorization failed", tau)
+			continue
+		}
+		c.solve(x, b)
+		for i := 0; i < n; i++ {
+			v := tau * x[i]
+			for j := 0; j < n; j++ {
+				v += a.At(i, j) * x[j]
+			}
+			if math.Abs(v-b[i]) > 1e-12 {
+				t.Errorf("tau = %v: residual %v in row %d", tau, v-b[i], i)
+			}
+		}
+	}
+	if c.factorize(a, -5) {
+		t.Errorf("factorization of an indefinite matrix did not fail")
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultSPSAPerturbation = 0.1
+	defaultSPSAAlpha        = 0.602
+	defaultSPSAGamma        = 0.101
+)
+
+// spsaStage is the evaluation that SPSA waits for.
+type spsaStage int
+
+const (
+	spsaPlus  spsaStage = iota // f(x + c_k Δ)
+	spsaMinus                  // f(x - c_k Δ)
+	spsaStep                   // f at the new iterate.
+)
+
+// SPSA implements simultaneous perturbation stochastic approximation for
+// minimizing functions whose values are corrupted by noise, like the outcome
+// of a simulation. Every iteration estimates the gradient from the function
+// values at two locations, regardless of the dimension of the problem: with a
+// random vector Δ whose elements are ±1,
+//  ĝ_i = (f(x_k + c_k Δ) - f(x_k - c_k Δ)) / (2 c_k Δ_i),
+// and steps along the negative estimate,
+//  x_{k+1} = x_k - a_k ĝ,
+// with the decaying gain sequences
+//  a_k = Gain / (k + 1 + Stability)^Alpha,
+//  c_k = Perturbation / (k + 1)^Gamma.
+// The iterates converge to a minimum for a large class of noise
+// distributions, although the convergence is slow. The gradient estimate is
+// unbiased for smooth functions up to O(c_k^2).
+//
+// The new iterate is evaluated as the major iteration, so that the driver can
+// track the progress, and every iteration costs three evaluations. With
+// noisy function values, Local returns the location with the lowest observed
+// value, which is biased towards favorable noise; averaging evaluations with
+// Settings.FuncAverages reduces the bias. For problems with bounds, the
+// iterates are projected onto the bounds, and perturbations are taken around
+// the nearest location at least c_k away from the bounds, so that the
+// perturbed locations are feasible and the gradient estimate stays
+// symmetric. SPSA does not converge by itself, and the run should be
+// terminated by the limits in Settings.
+//
+// References:
+//  - Spall, J.C.: Multivariate stochastic approximation using a simultaneous
+//    perturbation gradient approximation. IEEE Trans. Autom. Control 37
+//    (1992), 332-341
+//  - Spall, J.C.: Implementation of the simultaneous perturbation algorithm
+//    for stochastic optimization. IEEE Trans. Aerosp. Electron. Syst. 34
+//    (1998), 817-823
+type SPSA struct {
+	// Gain is the numerator a of the step gain sequence. If Gain is zero, it
+	// will be set at the first iteration such that the first step has the
+	// infinity-norm length Perturbation.
+	Gain float64
+	// Perturbation is the numerator c of the perturbation sequence. It
+	// should be about the standard deviation of the noise. If Perturbation
+	// is zero, it will be set to 0.1.
+	Perturbation float64
+	// Stability is the offset A of the step gain sequence, which allows
+	// larger gains without unstable early iterations. Spall recommends
+	// about a tenth of the expected number of iterations. If Stability is
+	// zero, no offset is used.
+	Stability float64
+	// Alpha and Gamma are the exponents of the gain sequences. If they are
+	// zero, they will be set to 0.602 and 0.101, the lowest values that
+	// guarantee convergence.
+	Alpha float64
+	Gamma float64
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+
+	rnd    *rand.Rand
+	bounds *Bounds
+	gain   float64
+	stage  spsaStage
+	k      int // Number of the current iteration.
+	ck     float64
+	fPlus  float64
+	x      []float64 // Current iterate.
+	delta  []float64
+}
+
+func (s *SPSA) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if s.Perturbation == 0 {
+		s.Perturbation = defaultSPSAPerturbation
+	}
+	if s.Alpha == 0 {
+		s.Alpha = defaultSPSAAlpha
+	}
+	if s.Gamma == 0 {
+		s.Gamma = defaultSPSAGamma
+	}
+	if s.Gain < 0 || s.Perturbation < 0 || s.Stability < 0 || s.Alpha < 0 || s.Gamma < 0 {
+		panic("spsa: negative parameter")
+	}
+	s.rnd = newRand(s.Src, p.Src)
+	s.bounds = p.Bounds
+	if s.bounds == nil {
+		s.bounds = &Bounds{}
+	}
+
+	dim := len(loc.X)
+	s.x = resize(s.x, dim)
+	s.delta = resize(s.delta, dim)
+	copy(s.x, loc.X)
+	s.gain = s.Gain
+	s.k = 0
+	return s.perturb(xNext)
+}
+
+func (s *SPSA) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch s.stage {
+	case spsaPlus:
+		s.fPlus = loc.F
+		for i, v := range s.x {
+			xNext[i] = v - s.ck*s.delta[i]
+		}
+		s.stage = spsaMinus
+		return FuncEvaluation, MinorIteration, nil
+	case spsaMinus:
+		// The elements of the gradient estimate differ only in the sign of
+		// Δ_i, so the step is computed from the common magnitude.
+		df := (s.fPlus - loc.F) / (2 * s.ck)
+		if s.gain == 0 && df != 0 {
+			// Set the gain from the first nonzero estimate. Until then the
+			// step is zero.
+			s.gain = s.Perturbation * math.Pow(s.Stability+1, s.Alpha) / math.Abs(df)
+		}
+		ak := s.gain / math.Pow(float64(s.k+1)+s.Stability, s.Alpha)
+		for i, v := range s.x {
+			// 1/Δ_i = Δ_i for Δ_i = ±1.
+			xNext[i] = v - ak*df*s.delta[i]
+		}
+		s.bounds.Project(xNext)
+		s.k++
+		s.stage = spsaStep
+		return FuncEvaluation, MajorIteration, nil
+	case spsaStep:
+		copy(s.x, loc.X)
+		return s.perturb(xNext)
+	}
+	panic("spsa: unknown stage")
+}
+
+// perturb draws a new perturbation and stores the first perturbed location
+// into xNext.
+func (s *SPSA) perturb(xNext []float64) (EvaluationType, IterationType, error) {
+	s.ck = s.Perturbation / math.Pow(float64(s.k+1), s.Gamma)
+	// Move the center of the perturbations away from the bounds.
+	for i, v := range s.x {
+		lo := s.bounds.lower(i) + s.ck
+		hi := s.bounds.upper(i) - s.ck
+		if lo > hi {
+			lo = (s.bounds.lower(i) + s.bounds.upper(i)) / 2
+			hi = lo
+		}
+		s.x[i] = math.Max(lo, math.Min(v, hi))
+	}
+	for i := range s.delta {
+		s.delta[i] = 1
+		if s.rnd.Intn(2) == 0 {
+			s.delta[i] = -1
+		}
+	}
+	for i, v := range s.x {
+		xNext[i] = v + s.ck*s.delta[i]
+	}
+	s.stage = spsaPlus
+	return FuncEvaluation, MinorIteration, nil
+}
+
+// HandlesBounds returns true. All locations evaluated by SPSA are within the
+// bounds.
+func (*SPSA) HandlesBounds() bool {
+	return true
+}
+
+func (*SPSA) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
@@ -0,0 +1,61 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestSPSA(t *testing.T) {
+	want := []float64{1, -2, 0.5, 3, -1, 2, 0, -0.5, 1.5, -3}
+	for _, test := range []struct {
+		name   string
+		noise  float64
+		bounds *Bounds
+		tol    float64
+	}{
+		{"Exact", 0, nil, 1e-3},
+		{"Noisy", 0.01, nil, 0.1},
+		{"Bounded", 0.01, &Bounds{Lower: []float64{-1, -3, 0, 2, -2, 1, -1, -1, 1, -4}, Upper: []float64{0.9, 0, 1, 4, 0, 3, 1, 0, 2, 0}}, 0.1},
+	} {
+		rnd := rand.New(rand.NewSource(1))
+		p := Problem{
+			Func: func(x []float64) float64 {
+				var f float64
+				for i, v := range x {
+					d := v - want[i]
+					f += float64(i+1) * d * d
+				}
+				return f + test.noise*rnd.NormFloat64()
+			},
+			Bounds: test.bounds,
+		}
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.MajorIterations = 2000
+		method := &SPSA{Stability: 200, Src: rand.NewSource(1)}
+		result, err := Local(p, make([]float64, len(want)), settings, method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.FuncEvaluations != 3*2000+1 {
+			t.Errorf("%s: unexpected number of evaluations %d", test.name, result.FuncEvaluations)
+		}
+		x := make([]float64, len(want))
+		copy(x, want)
+		if test.bounds != nil {
+			test.bounds.Project(x)
+		}
+		// The last iterate is closer to the minimum than the location with
+		// the lowest observed function value.
+		if !floats.EqualApprox(method.x, x, test.tol) {
+			t.Errorf("%s: unexpected location, want %v, got %v", test.name, x, method.x)
+		}
+	}
+}
@@ -0,0 +1,598 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize/qp"
+)
+
+const (
+	defaultSQPGradientTol   = 1e-6
+	defaultSQPConstraintTol = 1e-6
+	defaultSQPElasticWeight = 100
+	// sqpDecrease is the sufficient decrease parameter of the line search
+	// on the merit function.
+	sqpDecrease = 1e-4
+	// sqpMinStep is the smallest step size tried by the line search.
+	sqpMinStep = 1e-12
+	// sqpPenaltyFactor is the factor by which the penalty parameter of the
+	// merit function exceeds the largest multiplier estimate when it is
+	// increased.
+	sqpPenaltyFactor = 1.5
+	// sqpSubproblemTol is the tolerance of the quadratic subproblems.
+	sqpSubproblemTol = 1e-10
+)
+
+// SQP implements a sequential quadratic programming method for minimization
+// subject to the nonlinear constraints Problem.Equality and
+// Problem.Inequality, and the bounds Problem.Bounds. At every major iteration
+// it computes the step d from the quadratic subproblem
+//  minimize    ½ dᵀ B d + ∇f(x)ᵀ d
+//  subject to  c_E(x) + J_E(x) d = 0
+//              c_I(x) + J_I(x) d <= 0
+//              lower <= x + d <= upper,
+// where B is a damped BFGS approximation of the Hessian of the Lagrangian
+//  L(x, λ, μ) = f(x) + λᵀ c_E(x) + μᵀ c_I(x),
+// and the multipliers of the subproblem are the new estimates of λ and μ. If
+// the linearized constraints are inconsistent, the violation of the
+// constraints is instead penalized in the subproblem by their l1 norm
+// weighted by ElasticWeight. The step is then shortened by a backtracking
+// line search on the l1 merit function
+//  φ(x) = f(x) + ν (|c_E(x)|_1 + |max(0, c_I(x))|_1),
+// whose penalty parameter ν is increased as needed to exceed the multiplier
+// estimates. If the full step is rejected, the step with a second-order
+// correction for the curvature of the constraints is tried before
+// backtracking, which prevents the rejection of good steps close to the
+// solution (the Maratos effect). The evaluations of the line search are
+// reported as minor iterations.
+//
+// The optimization terminates with MethodConverge when the infinity norm of
+// the gradient of the Lagrangian, including the multipliers of the bounds, is
+// below GradientTolerance, and the violation of the constraints and of
+// complementarity is below ConstraintTolerance. SQP implements
+// MultiplierEstimator, so Result.Multipliers holds the multiplier estimates
+// at the solution.
+//
+// SQP also minimizes problems without nonlinear constraints, in which case it
+// is a quasi-Newton method for bound-constrained problems.
+//
+// Reference:
+//  Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//  (2006), Chapter 18
+type SQP struct {
+	// GradientTolerance is the tolerance on the infinity norm of the
+	// gradient of the Lagrangian.
+	// If GradientTolerance is zero, it will be set to 1e-6.
+	GradientTolerance float64
+	// ConstraintTolerance is the tolerance on the violation of the
+	// constraints and of complementarity.
+	// If ConstraintTolerance is zero, it will be set to 1e-6.
+	ConstraintTolerance float64
+	// ElasticWeight is the smallest weight of the violation of the
+	// constraints in the subproblem if the linearized constraints are
+	// inconsistent.
+	// If ElasticWeight is zero, it will be set to 100.
+	ElasticWeight float64
+
+	eq, in Constraint
+	bounds *Bounds
+	status Status
+	first  bool // Whether the Hessian approximation has not been updated yet.
+
+	solver qp.Solver
+	hess   *mat64.SymDense // Approximation of the Hessian of the Lagrangian.
+
+	penalty float64   // Penalty parameter of the merit function.
+	lambda  []float64 // Multiplier estimates of the equality constraints.
+	mu      []float64 // Multiplier estimates of the inequality constraints.
+	zl, zu  []float64 // Multiplier estimates of the bounds.
+
+	// Data at the last major iteration.
+	x, grad      []float64
+	f            float64
+	cEqK, cInK   []float64
+	jacEqK       *mat64.Dense
+	jacInK       *mat64.Dense
+	merit, deriv float64 // Merit function and its directional derivative.
+
+	// Data at the last evaluated location.
+	cEq, cIn     []float64
+	jacEq, jacIn *mat64.Dense
+
+	dir       []float64 // Step of the subproblem.
+	step      float64   // Step size of the line search.
+	corrected bool      // Whether the second-order correction has been tried.
+
+	s, y, bs, gl []float64 // Work space of the BFGS update.
+	lower, upper []float64 // Bounds of the subproblem.
+}
+
+func (s *SQP) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if s.GradientTolerance == 0 {
+		s.GradientTolerance = defaultSQPGradientTol
+	}
+	if s.ConstraintTolerance == 0 {
+		s.ConstraintTolerance = defaultSQPConstraintTol
+	}
+	if s.ElasticWeight == 0 {
+		s.ElasticWeight = defaultSQPElasticWeight
+	}
+	if s.GradientTolerance < 0 {
+		panic("sqp: negative gradient tolerance")
+	}
+	if s.ConstraintTolerance < 0 {
+		panic("sqp: negative constraint tolerance")
+	}
+	if s.ElasticWeight < 0 {
+		panic("sqp: negative elastic weight")
+	}
+
+	dim := len(loc.X)
+	s.eq = p.Equality
+	s.in = p.Inequality
+	s.bounds = p.Bounds
+	s.status = NotTerminated
+	s.first = true
+	s.solver.Tolerance = sqpSubproblemTol
+	s.penalty = 0
+
+	var nEq, nIn int
+	if s.eq != nil {
+		nEq = s.eq.Len()
+		s.jacEq = resizeDense(s.jacEq, nEq, dim)
+		s.jacEqK = resizeDense(s.jacEqK, nEq, dim)
+	}
+	if s.in != nil {
+		nIn = s.in.Len()
+		s.jacIn = resizeDense(s.jacIn, nIn, dim)
+		s.jacInK = resizeDense(s.jacInK, nIn, dim)
+	}
+	s.cEq = resize(s.cEq, nEq)
+	s.cEqK = resize(s.cEqK, nEq)
+	s.cIn = resize(s.cIn, nIn)
+	s.cInK = resize(s.cInK, nIn)
+	s.lambda = resize(s.lambda, nEq)
+	s.mu = resize(s.mu, nIn)
+	s.zl = resize(s.zl, dim)
+	s.zu = resize(s.zu, dim)
+	s.x = resize(s.x, dim)
+	s.grad = resize(s.grad, dim)
+	s.dir = resize(s.dir, dim)
+	s.s = resize(s.s, dim)
+	s.y = resize(s.y, dim)
+	s.bs = resize(s.bs, dim)
+	s.gl = resize(s.gl, dim)
+	s.lower = resize(s.lower, dim)
+	s.upper = resize(s.upper, dim)
+	if s.hess == nil || s.hess.Symmetric() != dim {
+		s.hess = mat64.NewSymDense(dim, nil)
+	}
+	for i := 0; i < dim; i++ {
+		for j := i; j < dim; j++ {
+			s.hess.SetSym(i, j, 0)
+		}
+		s.hess.SetSym(i, i, 1)
+	}
+
+	s.evaluateConstraints(loc.X, FuncEvaluation|GradEvaluation)
+	s.accept(loc)
+	if err := s.subproblem(); err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	if s.converged() {
+		s.status = MethodConverge
+		s.step = 0
+		copy(xNext, loc.X)
+		return NoEvaluation, MajorIteration, nil
+	}
+	return s.nextStep(xNext)
+}
+
+func (s *SQP) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	if s.step == 0 {
+		// A MajorIteration has been announced and the step of the new
+		// subproblem is tried.
+		return s.nextStep(xNext)
+	}
+
+	s.evaluateConstraints(loc.X, FuncEvaluation|GradEvaluation)
+	if s.meritFunc(loc.F, s.cEq, s.cIn) > s.merit+sqpDecrease*s.step*s.deriv {
+		if s.step == 1 && !s.corrected && (s.eq != nil || s.in != nil) {
+			// The full step may be rejected because of the curvature of
+			// the constraints although the iteration is close to the
+			// solution. Try the step with a second-order correction
+			// before backtracking.
+			s.corrected = true
+			if s.correction(xNext) {
+				return FuncEvaluation | GradEvaluation, MinorIteration, nil
+			}
+		}
+		s.step /= 2
+		if s.step < sqpMinStep {
+			return NoEvaluation, NoIteration, ErrLinesearchFailure
+		}
+		floats.AddScaledTo(xNext, s.x, s.step, s.dir)
+		return FuncEvaluation | GradEvaluation, MinorIteration, nil
+	}
+
+	// The step is accepted. Update the Hessian approximation with the
+	// multiplier estimates of the last subproblem.
+	floats.SubTo(s.s, loc.X, s.x)
+	s.gradLagrangian(s.y, loc.Gradient, s.jacEq, s.jacIn)
+	s.gradLagrangian(s.gl, s.grad, s.jacEqK, s.jacInK)
+	floats.Sub(s.y, s.gl)
+	dampedBFGSUpdate(s.hess, s.s, s.y, s.bs, s.first)
+	s.first = false
+
+	s.accept(loc)
+	if err := s.subproblem(); err != nil {
+		return NoEvaluation, NoIteration, err
+	}
+	if s.converged() {
+		s.status = MethodConverge
+	}
+	s.step = 0
+	copy(xNext, loc.X)
+	return NoEvaluation, MajorIteration, nil
+}
+
+// accept stores loc and the constraints evaluated at loc as the last major
+// iteration.
+func (s *SQP) accept(loc *Location) {
+	copy(s.x, loc.X)
+	copy(s.grad, loc.Gradient)
+	s.f = loc.F
+	if s.eq != nil {
+		s.jacEqK.Copy(s.jacEq)
+		copy(s.cEqK, s.cEq)
+	}
+	if s.in != nil {
+		s.jacInK.Copy(s.jacIn)
+		copy(s.cInK, s.cIn)
+	}
+}
+
+// nextStep starts the line search along the step of the subproblem.
+func (s *SQP) nextStep(xNext []float64) (EvaluationType, IterationType, error) {
+	s.merit = s.meritFunc(s.f, s.cEqK, s.cInK)
+	if s.deriv >= 0 {
+		return NoEvaluation, NoIteration, ErrNonNegativeStepDirection
+	}
+	s.step = 1
+	s.corrected = false
+	floats.AddTo(xNext, s.x, s.dir)
+	return FuncEvaluation | GradEvaluation, MinorIteration, nil
+}
+
+// subproblem solves the quadratic subproblem at the last major iteration,
+// stores the step and the multiplier estimates, updates the penalty parameter
+// and computes the directional derivative of the merit function.
+func (s *SQP) subproblem() error {
+	n := len(s.x)
+	for j := range s.x {
+		s.lower[j] = math.Inf(-1)
+		s.upper[j] = math.Inf(1)
+		if s.bounds != nil {
+			s.lower[j] = s.bounds.lower(j) - s.x[j]
+			s.upper[j] = s.bounds.upper(j) - s.x[j]
+		}
+	}
+	p := s.qpProblem(s.cEqK, s.cInK)
+	res, err := s.solver.Solve(&p)
+	elastic := err == qp.ErrInfeasible || err == qp.ErrIterationLimit
+	if elastic {
+		// The linearized constraints are inconsistent.
+		res, err = s.solver.Solve(s.elasticProblem(&p))
+	}
+	if err != nil {
+		return err
+	}
+	copy(s.dir, res.X[:n])
+	copy(s.lambda, res.Eq)
+	copy(s.mu, res.Ineq)
+	copy(s.zl, res.Lower[:n])
+	copy(s.zu, res.Upper[:n])
+
+	var maxMult float64
+	for _, v := range s.lambda {
+		maxMult = math.Max(maxMult, math.Abs(v))
+	}
+	for _, v := range s.mu {
+		maxMult = math.Max(maxMult, v)
+	}
+	if s.penalty < maxMult {
+		s.penalty = sqpPenaltyFactor * maxMult
+	}
+
+	// The directional derivative of the merit function is computed with the
+	// optimality conditions of the subproblem
+	//  B d + ∇f + J_Eᵀ λ + J_Iᵀ μ - z_l + z_u = 0,
+	//  J_E d = -c_E,  μ ∘ (c_I + J_I d) = 0,
+	//  z_l ∘ (x + d - lower) = 0,  z_u ∘ (upper - x - d) = 0,
+	// which makes it negative despite the inexact solution of the
+	// subproblem. For the elastic subproblem it includes the violation of
+	// the linearized constraints at the step instead.
+	viol := s.violation(s.cEqK, s.cInK)
+	if elastic {
+		s.deriv = floats.Dot(s.grad, s.dir) - s.penalty*viol
+		for i, c := range s.cEqK {
+			s.deriv += s.penalty * math.Abs(c+floats.Dot(s.jacEqK.Row(nil, i), s.dir))
+		}
+		for i, c := range s.cInK {
+			s.deriv += s.penalty * math.Max(0, c+floats.Dot(s.jacInK.Row(nil, i), s.dir))
+		}
+		return nil
+	}
+	mat64.NewVector(n, s.bs).MulVec(s.hess, false, mat64.NewVector(n, s.dir))
+	s.deriv = -floats.Dot(s.dir, s.bs) + floats.Dot(s.lambda, s.cEqK) + floats.Dot(s.mu, s.cInK) - s.penalty*viol
+	for j := range s.dir {
+		if s.zl[j] != 0 {
+			s.deriv += s.zl[j] * s.lower[j]
+		}
+		if s.zu[j] != 0 {
+			s.deriv -= s.zu[j] * s.upper[j]
+		}
+	}
+	return nil
+}
+
+// qpProblem returns the quadratic subproblem at the last major iteration for
+// the constraint values cEq and cIn.
+func (s *SQP) qpProblem(cEq, cIn []float64) qp.Problem {
+	p := qp.Problem{
+		Q:     s.hess,
+		C:     s.grad,
+		Lower: s.lower,
+		Upper: s.upper,
+	}
+	if s.eq != nil {
+		p.Aeq = s.jacEqK
+		p.Beq = make([]float64, len(cEq))
+		copy(p.Beq, cEq)
+		floats.Scale(-1, p.Beq)
+	}
+	if s.in != nil {
+		p.A = s.jacInK
+		p.B = make([]float64, len(cIn))
+		copy(p.B, cIn)
+		floats.Scale(-1, p.B)
+	}
+	return p
+}
+
+// correction stores the location with the second-order correction of the
+// step into xNext. The correction accounts for the curvature of the
+// constraints, whose values at the full step are in cEq and cIn, by solving
+// the subproblem with the constraint values
+//  c(x + d) - J(x) d
+// instead of c(x). It returns false if the subproblem cannot be solved.
+func (s *SQP) correction(xNext []float64) bool {
+	cEq := make([]float64, len(s.cEq))
+	for i, c := range s.cEq {
+		cEq[i] = c - floats.Dot(s.jacEqK.Row(nil, i), s.dir)
+	}
+	cIn := make([]float64, len(s.cIn))
+	for i, c := range s.cIn {
+		cIn[i] = c - floats.Dot(s.jacInK.Row(nil, i), s.dir)
+	}
+	p := s.qpProblem(cEq, cIn)
+	res, err := s.solver.Solve(&p)
+	if err != nil {
+		return false
+	}
+	floats.AddTo(xNext, s.x, res.X)
+	return true
+}
+
+// elasticProblem returns the subproblem p with the elastic variables
+// v, w, t >= 0,
+//  minimize    ½ dᵀ B d + ∇f(x)ᵀ d + ν Σ(v + w + t)
+//  subject to  c_E(x) + J_E(x) d - v + w = 0
+//              c_I(x) + J_I(x) d - t <= 0
+//              lower <= x + d <= upper,
+// which is always feasible. The penalty parameter of the merit function is
+// increased to the weight ν of the elastic variables.
+func (s *SQP) elasticProblem(p *qp.Problem) *qp.Problem {
+	n := len(s.x)
+	nEq, nIn := len(s.cEqK), len(s.cInK)
+	dim := n + 2*nEq + nIn
+	s.penalty = math.Max(s.penalty, s.ElasticWeight)
+
+	hess := mat64.NewSymDense(dim, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			hess.SetSym(i, j, s.hess.At(i, j))
+		}
+	}
+	c := make([]float64, dim)
+	copy(c, s.grad)
+	lower := make([]float64, dim)
+	upper := make([]float64, dim)
+	copy(lower, s.lower)
+	copy(upper, s.upper)
+	for j := n; j < dim; j++ {
+		c[j] = s.penalty
+		upper[j] = math.Inf(1)
+	}
+	e := &qp.Problem{
+		Q:     hess,
+		C:     c,
+		B:     p.B,
+		Beq:   p.Beq,
+		Lower: lower,
+		Upper: upper,
+	}
+	if nEq > 0 {
+		aeq := mat64.NewDense(nEq, dim, nil)
+		for i := 0; i < nEq; i++ {
+			for j := 0; j < n; j++ {
+				aeq.Set(i, j, s.jacEqK.At(i, j))
+			}
+			aeq.Set(i, n+i, -1)
+			aeq.Set(i, n+nEq+i, 1)
+		}
+		e.Aeq = aeq
+	}
+	if nIn > 0 {
+		a := mat64.NewDense(nIn, dim, nil)
+		for i := 0; i < nIn; i++ {
+			for j := 0; j < n; j++ {
+				a.Set(i, j, s.jacInK.At(i, j))
+			}
+			a.Set(i, n+2*nEq+i, -1)
+		}
+		e.A = a
+	}
+	return e
+}
+
+// converged returns whether the first-order optimality conditions hold at the
+// last major iteration with the multiplier estimates of the last subproblem.
+func (s *SQP) converged() bool {
+	s.gradLagrangian(s.gl, s.grad, s.jacEqK, s.jacInK)
+	floats.Sub(s.gl, s.zl)
+	floats.Add(s.gl, s.zu)
+	if floats.Norm(s.gl, math.Inf(1)) > s.GradientTolerance {
+		return false
+	}
+	var viol float64
+	for _, c := range s.cEqK {
+		viol = math.Max(viol, math.Abs(c))
+	}
+	for i, c := range s.cInK {
+		viol = math.Max(viol, math.Max(c, math.Abs(s.mu[i]*c)))
+	}
+	if s.bounds != nil {
+		for j, x := range s.x {
+			if l := s.bounds.lower(j); !math.IsInf(l, -1) {
+				viol = math.Max(viol, s.zl[j]*(x-l))
+			}
+			if u := s.bounds.upper(j); !math.IsInf(u, 1) {
+				viol = math.Max(viol, s.zu[j]*(u-x))
+			}
+		}
+	}
+	return viol <= s.ConstraintTolerance
+}
+
+// dampedBFGSUpdate updates the approximation hess of the Hessian of a
+// Lagrangian with the damped BFGS formula for the step s and the change of the
+// gradient of the Lagrangian y, which is overwritten. If first is true, the
+// identity matrix in hess is scaled before the first update. bs is work space
+// of the same length as s.
+//
+// Reference:
+//  Powell, M.J.D.: A fast algorithm for nonlinearly constrained optimization
+//  calculations. Numerical Analysis, Lecture Notes in Mathematics 630 (1978),
+//  144-157
+func dampedBFGSUpdate(hess *mat64.SymDense, s, y, bs []float64, first bool) {
+	sy := floats.Dot(s, y)
+	if first && sy > 0 {
+		// Scale the initial identity matrix, as for BFGS.
+		scale := floats.Dot(y, y) / sy
+		for i := range s {
+			hess.SetSym(i, i, scale)
+		}
+	}
+	mat64.NewVector(len(s), bs).MulVec(hess, false, mat64.NewVector(len(s), s))
+	sbs := floats.Dot(s, bs)
+	if sbs <= 0 {
+		return
+	}
+	if sy < 0.2*sbs {
+		// Powell's damping keeps the approximation positive definite.
+		theta := 0.8 * sbs / (sbs - sy)
+		floats.Scale(theta, y)
+		floats.AddScaled(y, 1-theta, bs)
+		sy = floats.Dot(s, y)
+	}
+	hess.SymRankOne(hess, 1/sy, y)
+	hess.SymRankOne(hess, -1/sbs, bs)
+}
+
+// gradLagrangian stores the gradient of the Lagrangian without the bound
+// terms, for the gradient grad and the Jacobians jacEq and jacIn, into dst.
+func (s *SQP) gradLagrangian(dst, grad []float64, jacEq, jacIn *mat64.Dense) {
+	copy(dst, grad)
+	if s.eq != nil {
+		addJacTVec(dst, jacEq, s.lambda)
+	}
+	if s.in != nil {
+		addJacTVec(dst, jacIn, s.mu)
+	}
+}
+
+// meritFunc returns the l1 merit function for the function value f and the
+// constraint values cEq and cIn.
+func (s *SQP) meritFunc(f float64, cEq, cIn []float64) float64 {
+	return f + s.penalty*s.violation(cEq, cIn)
+}
+
+// violation returns the l1 norm of the violation of the constraints.
+func (s *SQP) violation(cEq, cIn []float64) float64 {
+	return l1Violation(cEq, cIn)
+}
+
+// l1Violation returns the l1 norm of the violation of the equality and
+// inequality constraints with the values cEq and cIn.
+func l1Violation(cEq, cIn []float64) float64 {
+	viol := floats.Norm(cEq, 1)
+	for _, c := range cIn {
+		viol += math.Max(0, c)
+	}
+	return viol
+}
+
+// evaluateConstraints evaluates the constraint functions at x, and their
+// Jacobians if evalType includes GradEvaluation.
+func (s *SQP) evaluateConstraints(x []float64, evalType EvaluationType) {
+	if s.eq != nil {
+		s.eq.Func(x, s.cEq)
+		if evalType&GradEvaluation != 0 {
+			s.eq.Jac(x, s.jacEq)
+		}
+	}
+	if s.in != nil {
+		s.in.Func(x, s.cIn)
+		if evalType&GradEvaluation != 0 {
+			s.in.Jac(x, s.jacIn)
+		}
+	}
+}
+
+// Status returns MethodConverge when the first-order optimality conditions
+// hold at the last major iteration.
+func (s *SQP) Status() (Status, error) {
+	return s.status, nil
+}
+
+// Multipliers stores the multiplier estimates of the last subproblem into eq
+// and ineq.
+func (s *SQP) Multipliers(eq, ineq []float64) {
+	copy(eq, s.lambda)
+	copy(ineq, s.mu)
+}
+
+func (*SQP) HandlesBounds() bool {
+	return true
+}
+
+func (*SQP) HandlesConstraints() bool {
+	return true
+}
+
+func (*SQP) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
@@ -0,0 +1,135 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const defaultSR1SkipTolerance = 1e-8
+
+// SR1 implements the symmetric rank-one quasi-Newton method in a
+// trust-region framework. After every accepted step, the Hessian
+// approximation is updated by
+//  B_{k+1} = B_k + (y - B_k s)(y - B_k s)ᵀ / (y - B_k s)ᵀs,
+// where s is the step and y is the change in the gradient. Unlike the BFGS
+// update, the SR1 update does not keep B_k positive definite, which lets it
+// capture negative curvature and often makes it approximate the Hessian
+// better. The steps are therefore computed by a trust-region method, see
+// TrustRegion, whose SubproblemSolver should handle indefinite matrices.
+//
+// The update is skipped when its denominator is small, that is when
+//  |sᵀ(y - B_k s)| < SkipTolerance |s|_2 |y - B_k s|_2.
+//
+// References:
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Section 6.2
+type SR1 struct {
+	// Solver computes the steps. If Solver is nil, it will be set to a
+	// Steihaug solver.
+	Solver SubproblemSolver
+	// InitialRadius, MaxRadius and Eta are the parameters of the trust-region
+	// method, see TrustRegion.
+	InitialRadius float64
+	MaxRadius     float64
+	Eta           float64
+	// SkipTolerance is the relative tolerance on the denominator of the
+	// update below which the update is skipped. If SkipTolerance is zero,
+	// it will be set to 1e-8. SR1 panics if SkipTolerance is negative.
+	SkipTolerance float64
+
+	tr TrustRegion
+}
+
+func (sr *SR1) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if sr.SkipTolerance == 0 {
+		sr.SkipTolerance = defaultSR1SkipTolerance
+	}
+	if sr.SkipTolerance < 0 {
+		panic("sr1: negative skip tolerance")
+	}
+	sr.tr.Solver = sr.Solver
+	sr.tr.InitialRadius = sr.InitialRadius
+	sr.tr.MaxRadius = sr.MaxRadius
+	sr.tr.Eta = sr.Eta
+	sr.tr.QuasiNewton = true
+	sr.tr.update = sr.update
+	evalType, iterType, err := sr.tr.Init(loc, p, xNext)
+	sr.Solver = sr.tr.Solver
+	sr.InitialRadius = sr.tr.InitialRadius
+	sr.Eta = sr.tr.Eta
+	return evalType, iterType, err
+}
+
+func (sr *SR1) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	return sr.tr.Iterate(loc, xNext)
+}
+
+// update performs the SR1 update of hess in place. If scale is true and sᵀy
+// is positive, hess is first replaced by (yᵀy / sᵀy) I. tmp must have the
+// same length as s and is used as temporary storage. update returns whether
+// hess has been modified.
+func (sr *SR1) update(hess *mat64.SymDense, s, y, tmp []float64, scale bool) bool {
+	dim := len(s)
+	var scaled bool
+	if sDotY := floats.Dot(s, y); scale && sDotY > 0 {
+		gamma := floats.Dot(y, y) / sDotY
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				hess.SetSym(i, j, 0)
+			}
+			hess.SetSym(i, i, gamma)
+		}
+		scaled = true
+	}
+	// tmp = y - B s.
+	bs := mat64.NewVector(dim, tmp)
+	bs.MulVec(hess, false, mat64.NewVector(dim, s))
+	floats.Scale(-1, tmp)
+	floats.Add(tmp, y)
+	den := floats.Dot(s, tmp)
+	if math.Abs(den) < sr.SkipTolerance*floats.Norm(s, 2)*floats.Norm(tmp, 2) || den == 0 {
+		return scaled
+	}
+	hess.SymRankOne(hess, 1/den, tmp)
+	return true
+}
+
+// Reset discards the estimate of the Hessian and the trust-region radius of
+// the last run, keeping the exported fields.
+func (sr *SR1) Reset() {
+	*sr = SR1{
+		Solver:        sr.Solver,
+		InitialRadius: sr.InitialRadius,
+		MaxRadius:     sr.MaxRadius,
+		Eta:           sr.Eta,
+		SkipTolerance: sr.SkipTolerance,
+	}
+}
+
+// Clone returns a new SR1 with the configuration of sr and a copy of its
+// Solver.
+func (sr *SR1) Clone() Method {
+	return &SR1{
+		Solver:        cloneSubproblemSolver(sr.Solver),
+		InitialRadius: sr.InitialRadius,
+		MaxRadius:     sr.MaxRadius,
+		Eta:           sr.Eta,
+		SkipTolerance: sr.SkipTolerance,
+	}
+}
+
+func (*SR1) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
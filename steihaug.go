@@ -0,0 +1,151 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// Steihaug is a SubproblemSolver that implements the truncated conjugate
+// gradient method of Steihaug and Toint. It applies the conjugate gradient
+// method to the Newton equations B p = -∇f starting from p = 0 and stops when
+//  - the residual is small enough,
+//  - a direction of nonpositive curvature is encountered, or
+//  - the iterate leaves the trust region.
+// In the last two cases the step is moved along the current direction to the
+// boundary of the trust region. The first iterate is the Cauchy point, so
+// Steihaug always achieves at least the reduction of the Cauchy point.
+//
+// Steihaug accesses B only through matrix-vector products and does not need
+// any factorization, so it is suitable for large problems, see SolveHessVec.
+// Unlike Dogleg, it handles indefinite B.
+//
+// References:
+//  - Steihaug, T.: The conjugate gradient method and trust regions in large
+//    scale optimization. SIAM J Numer Anal 20 (1983), 626-637
+//  - Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//    (2006), Section 7.1
+type Steihaug struct {
+	// Tolerance is the relative tolerance on the residual of the Newton
+	// equations. The iteration terminates when
+	//  |B p + ∇f|_2 <= Tolerance*|∇f|_2.
+	// If Tolerance is zero, min(0.5, sqrt(|∇f|_2)) is used, which yields
+	// superlinear convergence of a Newton trust-region method.
+	Tolerance float64
+	// MaxIterations is the maximum number of conjugate gradient iterations,
+	// and thus of matrix-vector products with B.
+	// If MaxIterations is zero, it will be set to the problem dimension.
+	MaxIterations int
+
+	r  []float64 // Residual B p + ∇f.
+	d  []float64 // Search direction.
+	bd []float64 // B d.
+}
+
+// Solve computes the step p using the explicit matrix hess for computing
+// matrix-vector products.
+func (s *Steihaug) Solve(p, grad []float64, hess *mat64.SymDense, radius float64) float64 {
+	dim := len(p)
+	if hess.Symmetric() != dim {
+		panic("steihaug: size mismatch")
+	}
+	return s.SolveHessVec(p, grad, func(dst, v []float64) {
+		mat64.NewVector(dim, dst).MulVec(hess, false, mat64.NewVector(dim, v))
+	}, radius)
+}
+
+// SolveHessVec computes the step p using hessVec to compute matrix-vector
+// products with B. hessVec must store the product B*v in place into dst and
+// must not modify v. SolveHessVec stores the step in place into p and returns
+// the value of the quadratic model m(p).
+func (s *Steihaug) SolveHessVec(p, grad []float64, hessVec func(dst, v []float64), radius float64) float64 {
+	dim := len(p)
+	if len(grad) != dim {
+		panic("steihaug: size mismatch")
+	}
+	if radius <= 0 {
+		panic("steihaug: radius not positive")
+	}
+	if s.Tolerance < 0 {
+		panic("steihaug: negative tolerance")
+	}
+	maxIter := s.MaxIterations
+	if maxIter == 0 {
+		maxIter = dim
+	}
+	s.r = resize(s.r, dim)
+	s.d = resize(s.d, dim)
+	s.bd = resize(s.bd, dim)
+
+	for i := range p {
+		p[i] = 0
+	}
+	gNorm := floats.Norm(grad, 2)
+	if gNorm == 0 {
+		return 0
+	}
+	tol := s.Tolerance
+	if tol == 0 {
+		tol = math.Min(0.5, math.Sqrt(gNorm))
+	}
+	tol *= gNorm
+
+	// The model value is tracked as m(p) = ½ (∇f + r)·p, which holds
+	// because r = Bp + ∇f.
+	copy(s.r, grad)
+	copy(s.d, grad)
+	floats.Scale(-1, s.d)
+	rr := gNorm * gNorm
+	for k := 0; k < maxIter; k++ {
+		hessVec(s.bd, s.d)
+		dBd := floats.Dot(s.d, s.bd)
+		if dBd <= 0 {
+			// Negative curvature. The model decreases along d, so move to
+			// the boundary in the direction of decrease.
+			return s.toBoundary(p, grad, radius, dBd)
+		}
+		alpha := rr / dBd
+		floats.AddScaled(p, alpha, s.d)
+		if floats.Norm(p, 2) >= radius {
+			// Undo the step and move to the boundary instead.
+			floats.AddScaled(p, -alpha, s.d)
+			return s.toBoundary(p, grad, radius, dBd)
+		}
+		floats.AddScaled(s.r, alpha, s.bd)
+		rrNext := floats.Dot(s.r, s.r)
+		if math.Sqrt(rrNext) <= tol {
+			break
+		}
+		floats.Scale(rrNext/rr, s.d)
+		floats.Sub(s.d, s.r)
+		rr = rrNext
+	}
+	return s.model(p, grad)
+}
+
+// toBoundary moves p along s.d to the boundary of the trust region choosing
+// the root that yields the lower model value, and returns the model value.
+// dBd is the curvature along s.d.
+func (s *Steihaug) toBoundary(p, grad []float64, radius, dBd float64) float64 {
+	tau1, tau2 := boundaryStep(p, s.d, radius)
+	// The change of the model along d is
+	//  m(p + τd) - m(p) = τ r·d + ½ τ^2 dᵀBd.
+	rd := floats.Dot(s.r, s.d)
+	tau := tau2
+	if tau1*rd+0.5*tau1*tau1*dBd < tau2*rd+0.5*tau2*tau2*dBd {
+		tau = tau1
+	}
+	floats.AddScaled(p, tau, s.d)
+	floats.AddScaled(s.r, tau, s.bd)
+	return s.model(p, grad)
+}
+
+// model returns m(p) using the residual s.r = Bp + ∇f.
+func (s *Steihaug) model(p, grad []float64) float64 {
+	return 0.5 * (floats.Dot(grad, p) + floats.Dot(s.r, p))
+}
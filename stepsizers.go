@@ -19,6 +19,9 @@ const (
 
 	firstOrderMinimumStepSize = quadraticMinimumStepSize
 	firstOrderMaximumStepSize = quadraticMaximumStepSize
+
+	decreaseMinimumStepSize = quadraticMinimumStepSize
+	decreaseMaximumStepSize = quadraticMaximumStepSize
 )
 
 // ConstantStepSize is a StepSizer that returns the same step size for
@@ -35,6 +38,52 @@ func (c ConstantStepSize) StepSize(_ *Location, _ []float64) float64 {
 	return c.Size
 }
 
+// DiminishingStepSize is a StepSizer that returns the step size
+//  Scale / k^Exponent
+// for the k-th direction, k = 1, 2, .... With Exponent in (1/2, 1], the step
+// sizes are square summable but not summable, which is the classical
+// condition for the convergence of subgradient and stochastic gradient
+// methods.
+type DiminishingStepSize struct {
+	// Scale is the step size for the first direction. If Scale is zero, it
+	// will be set to 1.
+	Scale float64
+	// Exponent is the order of the decrease of the step size. If Exponent
+	// is zero, it will be set to 1.
+	Exponent float64
+	// Normalized specifies whether the step size is divided by the norm of
+	// the direction, so that the length of the step is Scale / k^Exponent.
+	Normalized bool
+
+	k int
+}
+
+func (d *DiminishingStepSize) Init(loc *Location, dir []float64) float64 {
+	if d.Scale == 0 {
+		d.Scale = 1
+	}
+	if d.Scale < 0 {
+		panic("optimize: negative step size scale")
+	}
+	if d.Exponent == 0 {
+		d.Exponent = 1
+	}
+	if d.Exponent < 0 {
+		panic("optimize: negative step size exponent")
+	}
+	d.k = 0
+	return d.StepSize(loc, dir)
+}
+
+func (d *DiminishingStepSize) StepSize(_ *Location, dir []float64) float64 {
+	d.k++
+	stepSize := d.Scale / math.Pow(float64(d.k), d.Exponent)
+	if d.Normalized {
+		stepSize /= floats.Norm(dir, 2)
+	}
+	return stepSize
+}
+
 // QuadraticStepSize estimates the initial line search step size as the minimum
 // of a quadratic that interpolates f(x_{k-1}), f(x_k) and ∇f_k⋅p_k.
 // This is useful for line search methods that do not produce well-scaled
@@ -183,3 +232,85 @@ func (fo *FirstOrderStepSize) StepSize(loc *Location, dir []float64) (stepSize f
 	copy(fo.xPrev, loc.X)
 	return stepSize
 }
+
+// FunctionDecreaseStepSize estimates the initial line search step size by
+// Fletcher's formula, which assumes that the function decreases by the same
+// amount as in the previous iteration and that it is quadratic along the
+// direction. That is, the initial step size s^0_k is the minimizer of the
+// quadratic with the derivative ∇f_k⋅p_k that decreases by f_{k-1} - f_k,
+//   s^0_k = 2 (f_k - f_{k-1}) / ∇f_k⋅p_k.
+// This is useful for line search methods that do not produce well-scaled
+// descent directions, such as gradient descent or conjugate gradient methods.
+type FunctionDecreaseStepSize struct {
+	// InitialStepFactor sets the step size for the first iteration to be InitialStepFactor / |g|_∞.
+	// If InitialStepFactor is zero, it will be set to one.
+	InitialStepFactor float64
+	// MinStepSize is the lower bound on the estimated step size.
+	// If MinStepSize is zero, it will be set to 1e-3.
+	MinStepSize float64
+	// MaxStepSize is the upper bound on the estimated step size.
+	// If MaxStepSize is zero, it will be set to 1.
+	MaxStepSize float64
+
+	fPrev float64
+}
+
+func (d *FunctionDecreaseStepSize) Init(loc *Location, dir []float64) (stepSize float64) {
+	if d.InitialStepFactor == 0 {
+		d.InitialStepFactor = initialStepFactor
+	}
+	if d.MinStepSize == 0 {
+		d.MinStepSize = decreaseMinimumStepSize
+	}
+	if d.MaxStepSize == 0 {
+		d.MaxStepSize = decreaseMaximumStepSize
+	}
+	if d.MaxStepSize <= d.MinStepSize {
+		panic("optimize: MinStepSize not smaller than MaxStepSize")
+	}
+
+	gNorm := floats.Norm(loc.Gradient, math.Inf(1))
+	stepSize = math.Max(d.MinStepSize, math.Min(d.InitialStepFactor/gNorm, d.MaxStepSize))
+
+	d.fPrev = loc.F
+	return stepSize
+}
+
+func (d *FunctionDecreaseStepSize) StepSize(loc *Location, dir []float64) (stepSize float64) {
+	stepSize = 2 * (loc.F - d.fPrev) / floats.Dot(loc.Gradient, dir)
+	// Bound the step size to lie in [MinStepSize, MaxStepSize]. This also
+	// handles a zero decrease.
+	stepSize = math.Max(d.MinStepSize, math.Min(stepSize, d.MaxStepSize))
+
+	d.fPrev = loc.F
+	return stepSize
+}
+
+// cloneStepSizer returns a copy of the configuration of s without its state.
+// StepSizers of types defined outside this package are returned unchanged.
+func cloneStepSizer(s StepSizer) StepSizer {
+	switch s := s.(type) {
+	case *DiminishingStepSize:
+		return &DiminishingStepSize{Scale: s.Scale, Exponent: s.Exponent, Normalized: s.Normalized}
+	case *QuadraticStepSize:
+		return &QuadraticStepSize{
+			Threshold:         s.Threshold,
+			InitialStepFactor: s.InitialStepFactor,
+			MinStepSize:       s.MinStepSize,
+			MaxStepSize:       s.MaxStepSize,
+		}
+	case *FirstOrderStepSize:
+		return &FirstOrderStepSize{
+			InitialStepFactor: s.InitialStepFactor,
+			MinStepSize:       s.MinStepSize,
+			MaxStepSize:       s.MaxStepSize,
+		}
+	case *FunctionDecreaseStepSize:
+		return &FunctionDecreaseStepSize{
+			InitialStepFactor: s.InitialStepFactor,
+			MinStepSize:       s.MinStepSize,
+			MaxStepSize:       s.MaxStepSize,
+		}
+	}
+	return s
+}
@@ -0,0 +1,147 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+// StochasticProblem describes the minimization of a function that is a sum
+// over data split into mini-batches, such as the training loss of a
+// machine learning model. Stochastic gradient methods like Adam, AdaGrad and
+// RMSProp take one step per mini-batch.
+type StochasticProblem struct {
+	// Batches is the number of mini-batches in one pass over the data.
+	Batches int
+	// Func evaluates the objective function on the given mini-batch at x.
+	// Func must not modify x.
+	Func func(x []float64, batch int) float64
+	// Grad evaluates the gradient of the objective function on the given
+	// mini-batch at x and stores the result in-place in grad. Grad must not
+	// modify x.
+	Grad func(x, grad []float64, batch int)
+}
+
+// Problem returns a Problem that evaluates the objective function and the
+// gradient on one mini-batch at a time. The mini-batches are used in
+// order, and an evaluation at a location different from the last evaluated
+// one advances to the next mini-batch, so every step of a stochastic
+// gradient method uses the next mini-batch and the evaluations at the same
+// location use the same one. The returned Problem must not be used
+// concurrently.
+//
+// The function values and the gradients seen by Local are those of single
+// mini-batches. Local thus returns the location with the lowest mini-batch
+// function value, and the gradient and function convergence tests apply to
+// mini-batch values. A run is usually limited by Settings.MajorIterations
+// instead.
+func (sp StochasticProblem) Problem() Problem {
+	if sp.Batches <= 0 {
+		panic("optimize: number of batches not positive")
+	}
+	if sp.Func == nil {
+		panic("optimize: objective function is undefined")
+	}
+	c := &batchCycler{sp: sp}
+	p := Problem{Func: c.Func}
+	if sp.Grad != nil {
+		p.Grad = c.Grad
+	}
+	return p
+}
+
+// batchCycler evaluates a StochasticProblem on successive mini-batches.
+type batchCycler struct {
+	sp    StochasticProblem
+	x     []float64 // Last evaluated location.
+	batch int       // Mini-batch of x.
+}
+
+// next advances to the next mini-batch if x is a new location.
+func (c *batchCycler) next(x []float64) {
+	if c.x == nil {
+		c.x = make([]float64, len(x))
+		copy(c.x, x)
+		return
+	}
+	if floats.Equal(x, c.x) {
+		return
+	}
+	copy(c.x, x)
+	c.batch = (c.batch + 1) % c.sp.Batches
+}
+
+func (c *batchCycler) Func(x []float64) float64 {
+	c.next(x)
+	return c.sp.Func(x, c.batch)
+}
+
+func (c *batchCycler) Grad(x, grad []float64) {
+	c.next(x)
+	c.sp.Grad(x, grad, c.batch)
+}
+
+// LearningRateSchedule determines the step size of stochastic gradient
+// methods.
+type LearningRateSchedule interface {
+	// LearningRate returns the learning rate at the step k >= 1.
+	LearningRate(k int) float64
+}
+
+// ConstantLearningRate is a learning rate that does not change.
+type ConstantLearningRate float64
+
+func (c ConstantLearningRate) LearningRate(int) float64 {
+	return float64(c)
+}
+
+// ExponentialDecay is the learning rate
+//  Initial Rate^(k/Steps).
+type ExponentialDecay struct {
+	Initial float64
+	// Rate is the decay factor per Steps steps.
+	Rate float64
+	// Steps is the number of steps over which the learning rate decays by
+	// Rate. If Steps is zero, it will be treated as 1.
+	Steps int
+}
+
+func (e ExponentialDecay) LearningRate(k int) float64 {
+	steps := e.Steps
+	if steps == 0 {
+		steps = 1
+	}
+	return e.Initial * math.Pow(e.Rate, float64(k)/float64(steps))
+}
+
+// InverseTimeDecay is the learning rate
+//  Initial / (1 + Decay k).
+type InverseTimeDecay struct {
+	Initial float64
+	Decay   float64
+}
+
+func (i InverseTimeDecay) LearningRate(k int) float64 {
+	return i.Initial / (1 + i.Decay*float64(k))
+}
+
+// StepDecay is the piecewise constant learning rate
+//  Initial Factor^⌊k/Every⌋.
+type StepDecay struct {
+	Initial float64
+	Factor  float64
+	// Every is the number of steps between decreases. StepDecay panics if
+	// Every is not positive.
+	Every int
+}
+
+func (s StepDecay) LearningRate(k int) float64 {
+	if s.Every <= 0 {
+		panic("stepdecay: Every must be positive")
+	}
+	return s.Initial * math.Pow(s.Factor, float64(k/s.Every))
+}
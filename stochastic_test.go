@@ -0,0 +1,95 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// linearRegression returns a StochasticProblem that fits the linear model
+// w_0 a + w_1 b + w_2 to noise-free samples of 2 a - 3 b + 1 on a grid,
+// split into 4 mini-batches.
+func linearRegression() StochasticProblem {
+	const batches = 4
+	var a, b, y [batches][]float64
+	for i := 0; i < 40; i++ {
+		ai := float64(i%8)/4 - 1
+		bi := float64(i/8)/2 - 1
+		k := i % batches
+		a[k] = append(a[k], ai)
+		b[k] = append(b[k], bi)
+		y[k] = append(y[k], 2*ai-3*bi+1)
+	}
+	return StochasticProblem{
+		Batches: batches,
+		Func: func(x []float64, batch int) float64 {
+			var f float64
+			for i, v := range y[batch] {
+				r := x[0]*a[batch][i] + x[1]*b[batch][i] + x[2] - v
+				f += r * r
+			}
+			return f / float64(2*len(y[batch]))
+		},
+		Grad: func(x, grad []float64, batch int) {
+			for i := range grad {
+				grad[i] = 0
+			}
+			n := float64(len(y[batch]))
+			for i, v := range y[batch] {
+				r := x[0]*a[batch][i] + x[1]*b[batch][i] + x[2] - v
+				grad[0] += r * a[batch][i] / n
+				grad[1] += r * b[batch][i] / n
+				grad[2] += r / n
+			}
+		},
+	}
+}
+
+func TestStochastic(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		method Method
+	}{
+		{"Adam", &Adam{LearningRate: ConstantLearningRate(0.01)}},
+		{"AdamDecay", &Adam{LearningRate: InverseTimeDecay{Initial: 0.05, Decay: 0.001}}},
+		{"AdaGrad", &AdaGrad{LearningRate: ConstantLearningRate(0.5)}},
+		{"RMSProp", &RMSProp{LearningRate: ExponentialDecay{Initial: 0.01, Rate: 0.5, Steps: 1000}}},
+		{"RMSPropStep", &RMSProp{LearningRate: StepDecay{Initial: 0.01, Factor: 0.5, Every: 1000}}},
+	} {
+		settings := DefaultSettings()
+		settings.Recorder = nil
+		settings.FunctionConverge = nil
+		settings.MajorIterations = 10000
+		result, err := Local(linearRegression().Problem(), []float64{0, 0, 0}, settings, test.method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !floats.EqualApprox(result.X, []float64{2, -3, 1}, 1e-3) {
+			t.Errorf("%s: unexpected solution. Want %v, got %v", test.name, []float64{2, -3, 1}, result.X)
+		}
+	}
+}
+
+func TestStochasticProblem(t *testing.T) {
+	sp := linearRegression()
+	p := sp.Problem()
+	x := []float64{1, 2, 3}
+	grad := make([]float64, 3)
+	want := make([]float64, 3)
+	for batch := 0; batch < 2*sp.Batches; batch++ {
+		// Evaluations at the same location use the same mini-batch.
+		f := p.Func(x)
+		p.Grad(x, grad)
+		sp.Grad(x, want, batch%sp.Batches)
+		if math.Abs(f-sp.Func(x, batch%sp.Batches)) > 1e-14 || !floats.Equal(grad, want) {
+			t.Errorf("unexpected mini-batch at step %d", batch)
+		}
+		x[0]++
+	}
+}
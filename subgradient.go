@@ -0,0 +1,143 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+// SubgradientStepRule is a step size rule of the Subgradient method.
+type SubgradientStepRule interface {
+	// StepSize returns the step size t of the k-th iteration, k = 0, 1, ...,
+	// from a location with the function value f, where the subgradient has
+	// the Euclidean norm gnorm > 0. fBest is the lowest function value found
+	// so far.
+	StepSize(k int, f, fBest, gnorm float64) float64
+}
+
+// DiminishingStep is the normalized diminishing step size rule
+//  t_k = Scale / (sqrt(k+1) |g_k|),
+// with which the length of the k-th step is Scale/sqrt(k+1). The best
+// function value converges to the optimal value for convex functions, at the
+// rate O(1/sqrt(k)). If Scale is zero, it is set to 1.
+type DiminishingStep struct {
+	Scale float64
+}
+
+func (d DiminishingStep) StepSize(k int, f, fBest, gnorm float64) float64 {
+	scale := d.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if scale < 0 {
+		panic("optimize: negative step scale")
+	}
+	return scale / (math.Sqrt(float64(k+1)) * gnorm)
+}
+
+// PolyakStep is the Polyak step size rule
+//  t_k = (f(x_k) - f*) / |g_k|^2
+// for functions with the known optimal value f* = OptimalValue. The step
+// minimizes an upper bound on the distance to the set of minimizers, and the
+// iterates converge to a minimizer for convex functions. The step is zero
+// once the optimal value has been reached.
+type PolyakStep struct {
+	OptimalValue float64
+}
+
+func (p PolyakStep) StepSize(k int, f, fBest, gnorm float64) float64 {
+	return math.Max(0, f-p.OptimalValue) / (gnorm * gnorm)
+}
+
+// Subgradient implements the subgradient method for minimizing nonsmooth
+// convex functions, like objectives with a hinge loss or the maximum of a set
+// of functions. Problem.Grad is used as the subgradient oracle: it may store
+// any subgradient of the objective function at x in grad, and it need only be
+// the gradient where the function is differentiable. Every iteration steps
+// along the negative subgradient,
+//  x_{k+1} = P(x_k - t_k g_k),
+// where P is the projection onto the bounds of the problem, if any, and the
+// step size t_k is given by StepRule. Every iteration is a major iteration.
+//
+// The function value need not decrease at every iteration, but Local returns
+// the location with the lowest function value found, the best iterate. The
+// norm of the subgradient need not vanish at a minimum either, so the run is
+// usually terminated by the budgets of Settings or by FunctionConverge, which
+// tests the best function value. Subgradient converges with MethodConverge
+// status if the subgradient is zero or the step size is zero.
+//
+// References:
+//  - Shor, N.Z.: Minimization Methods for Non-Differentiable Functions.
+//    Springer (1985)
+//  - Boyd, S., Xiao, L., Mutapcic, A.: Subgradient methods. Lecture notes,
+//    Stanford University (2003)
+type Subgradient struct {
+	// StepRule is the step size rule. If StepRule is nil, it will be set to
+	// DiminishingStep{}.
+	StepRule SubgradientStepRule
+
+	status Status
+	bounds *Bounds
+	k      int     // Number of the next iteration.
+	fBest  float64 // Lowest function value found.
+}
+
+func (sg *Subgradient) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if sg.StepRule == nil {
+		sg.StepRule = DiminishingStep{}
+	}
+	sg.bounds = p.Bounds
+	if sg.bounds == nil {
+		sg.bounds = &Bounds{}
+	}
+	sg.status = NotTerminated
+	sg.k = 0
+	sg.fBest = math.Inf(1)
+	return sg.Iterate(loc, xNext)
+}
+
+func (sg *Subgradient) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	sg.fBest = math.Min(sg.fBest, loc.F)
+	gnorm := floats.Norm(loc.Gradient, 2)
+	var t float64
+	if gnorm != 0 {
+		t = sg.StepRule.StepSize(sg.k, loc.F, sg.fBest, gnorm)
+	}
+	if t <= 0 {
+		// A zero subgradient proves that loc is a minimum, and a zero
+		// step that the step rule considers it optimal.
+		sg.status = MethodConverge
+		copy(xNext, loc.X)
+		return NoEvaluation, MajorIteration, nil
+	}
+	sg.k++
+	floats.AddScaledTo(xNext, loc.X, -t, loc.Gradient)
+	sg.bounds.Project(xNext)
+	return FuncEvaluation | GradEvaluation, MajorIteration, nil
+}
+
+// Status returns MethodConverge when the subgradient or the step size is
+// zero.
+func (sg *Subgradient) Status() (Status, error) {
+	return sg.status, nil
+}
+
+// HandlesBounds returns true. All locations evaluated by Subgradient are
+// projected onto the bounds.
+func (*Subgradient) HandlesBounds() bool {
+	return true
+}
+
+func (*Subgradient) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
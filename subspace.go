@@ -0,0 +1,135 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// Relative norm below which the orthogonalized Newton direction is considered
+// linearly dependent on the gradient.
+const subspaceDependenceTol = 1e-10
+
+// TwoDimensionalSubspace is a SubproblemSolver that minimizes the quadratic
+// model over the intersection of the trust region with the two-dimensional
+// subspace
+//  span{∇f, (B + τI)^{-1}∇f},
+// where τ >= 0 is zero if B is positive definite, and otherwise is chosen by
+// successively increasing it until B + τI is positive definite. The
+// subspace contains the steepest descent direction and the (modified) Newton
+// direction, so the step is at least as good as the dogleg step, but unlike
+// Dogleg, TwoDimensionalSubspace takes negative curvature of B into account.
+// The reduced two-dimensional problem is solved exactly with the Moré–Sorensen
+// method.
+//
+// TwoDimensionalSubspace requires Cholesky factorizations of B, so it is
+// suited for small and medium-sized problems.
+//
+// Reference:
+//  Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//  (2006), Section 4.1
+type TwoDimensionalSubspace struct {
+	reduced MoreSorensen
+
+	hess *mat64.SymDense
+	chol *mat64.TriDense
+	v1   []float64
+	v2   []float64
+	bv1  []float64
+	bv2  []float64
+}
+
+func (s *TwoDimensionalSubspace) Solve(p, grad []float64, hess *mat64.SymDense, radius float64) float64 {
+	dim := len(p)
+	if len(grad) != dim || hess.Symmetric() != dim {
+		panic("subspace: size mismatch")
+	}
+	if radius <= 0 {
+		panic("subspace: radius not positive")
+	}
+	s.hess = resizeSymDense(s.hess, dim)
+	s.chol = resizeTriDense(s.chol, dim)
+	s.v1 = resize(s.v1, dim)
+	s.v2 = resize(s.v2, dim)
+	s.bv1 = resize(s.bv1, dim)
+	s.bv2 = resize(s.bv2, dim)
+
+	for i := range p {
+		p[i] = 0
+	}
+	gNorm := floats.Norm(grad, 2)
+	if gNorm == 0 {
+		return 0
+	}
+	copy(s.v1, grad)
+	floats.Scale(1/gNorm, s.v1)
+
+	// Compute the (modified) Newton direction and orthogonalize it against
+	// the gradient to obtain the second basis vector.
+	basis := 1
+	if s.newtonDirection(hess, grad) {
+		dNorm := floats.Norm(s.v2, 2)
+		floats.AddScaled(s.v2, -floats.Dot(s.v1, s.v2), s.v1)
+		norm := floats.Norm(s.v2, 2)
+		if norm > subspaceDependenceTol*dNorm {
+			floats.Scale(1/norm, s.v2)
+			basis = 2
+		}
+	}
+
+	// Form and solve the reduced problem with gradient Vᵀ∇f = |∇f| e_1 and
+	// matrix VᵀBV.
+	v1Vec := mat64.NewVector(dim, s.v1)
+	mat64.NewVector(dim, s.bv1).MulVec(hess, false, v1Vec)
+	rHess := mat64.NewSymDense(basis, nil)
+	rHess.SetSym(0, 0, floats.Dot(s.v1, s.bv1))
+	rGrad := make([]float64, basis)
+	rGrad[0] = gNorm
+	if basis == 2 {
+		mat64.NewVector(dim, s.bv2).MulVec(hess, false, mat64.NewVector(dim, s.v2))
+		rHess.SetSym(0, 1, floats.Dot(s.v1, s.bv2))
+		rHess.SetSym(1, 1, floats.Dot(s.v2, s.bv2))
+	}
+	h := make([]float64, basis)
+	mp := s.reduced.Solve(h, rGrad, rHess, radius)
+
+	floats.AddScaled(p, h[0], s.v1)
+	if basis == 2 {
+		floats.AddScaled(p, h[1], s.v2)
+	}
+	return mp
+}
+
+// newtonDirection stores the solution of (B + τI) d = -∇f into s.v2, where
+// τ >= 0 is chosen so that B + τI is positive definite. It returns false if
+// no such τ has been found.
+func (s *TwoDimensionalSubspace) newtonDirection(hess *mat64.SymDense, grad []float64) bool {
+	dim := len(grad)
+	minA := math.Inf(1)
+	for i := 0; i < dim; i++ {
+		minA = math.Min(minA, hess.At(i, i))
+	}
+	var tau float64
+	if minA <= 0 {
+		tau = -minA + 0.001
+	}
+	s.hess.CopySym(hess)
+	for k := 0; k < maxNewtonModifications; k++ {
+		for i := 0; i < dim; i++ {
+			s.hess.SetSym(i, i, hess.At(i, i)+tau)
+		}
+		if s.chol.Cholesky(s.hess, true) {
+			d := mat64.NewVector(dim, s.v2)
+			d.SolveCholeskyVec(s.chol, mat64.NewVector(dim, grad))
+			floats.Scale(-1, s.v2)
+			return true
+		}
+		tau = math.Max(5*tau, 0.001)
+	}
+	return false
+}
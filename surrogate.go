@@ -0,0 +1,334 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/floats"
+)
+
+const defaultSurrogateCandidates = 100
+
+// defaultSurrogateDistances is the default cycle of minimum distances of
+// SurrogateOptimizer.
+var defaultSurrogateDistances = []float64{0.3, 0.1, 0.03, 0.01, 0.003, 0.001}
+
+// surrogateStage is the stage of SurrogateOptimizer.
+type surrogateStage int
+
+const (
+	surrogateInitialize surrogateStage = iota // Evaluating the initial samples.
+	surrogateModel                            // Evaluating the proposed candidates.
+)
+
+// SurrogateOptimizer implements surrogate-assisted optimization for
+// gradient-free global optimization of expensive functions subject to finite
+// bound constraints. It fits an RBFModel to all evaluated function values and
+// evaluates the function next at the location proposed by minimizing the
+// model, so it needs far fewer evaluations than population methods like
+// CMAES for smooth functions.
+//
+// The initial location and InitialSamples locations generated by Sampler are
+// evaluated first. Afterwards, every evaluation is a major iteration. The
+// proposed location is the candidate with the lowest model value among those
+// at least the current minimum distance away from all evaluated locations.
+// The candidates are the minimizer of the model found by Method from the
+// best location, perturbations of the best location and random locations.
+// The minimum distance cycles through Distances, so that the search
+// alternates between exploring regions far from the evaluated locations and
+// refining the best location. If no candidate is far enough, the candidate
+// farthest from the evaluated locations is proposed.
+//
+// The model works in coordinates normalized to the unit cube, so Distances
+// are relative to the size of the box given by the bounds. SurrogateOptimizer
+// does not converge by itself, and the optimization should be terminated by
+// Settings.FuncEvaluations.
+//
+// References:
+//  - Gutmann, H.-M.: A radial basis function method for global optimization.
+//    J. Global Optim. 19 (2001), 201-227
+//  - Regis, R.G., Shoemaker, C.A.: Constrained global optimization of
+//    expensive black box functions using radial basis functions. J. Global
+//    Optim. 31 (2005), 153-171
+type SurrogateOptimizer struct {
+	// Model is the surrogate of the function. If Model is nil, it will be
+	// set to &RBFModel{}.
+	Model *RBFModel
+	// InitialSamples is the number of locations sampled before the model is
+	// used. If InitialSamples is zero, it will be set to twice the dimension
+	// plus one. SurrogateOptimizer panics if InitialSamples is nonzero and
+	// smaller than the dimension.
+	InitialSamples int
+	// Sampler generates the initial samples. If Sampler is nil, it will be
+	// set to a LatinHypercube with a source from Src.
+	Sampler Sampler
+	// Candidates is the number of random locations and of perturbations of
+	// the best location generated at every iteration. If Candidates is zero,
+	// it will be set to 100 times the dimension.
+	Candidates int
+	// Distances is the cycle of minimum distances of the proposed locations
+	// from the evaluated locations in normalized coordinates, which must be
+	// non-negative. If Distances is nil, it will be set to
+	// {0.3, 0.1, 0.03, 0.01, 0.003, 0.001}.
+	Distances []float64
+	// Method returns the Method that minimizes the model from the best
+	// location. The Method is given a finite-difference gradient, and the
+	// bounds if it handles them. If Method is nil, NelderMead is used.
+	Method func() Method
+	// Src is the source of random numbers. If Src is nil, the source is
+	// seeded from Settings.Src, or from the global source of math/rand if
+	// Settings.Src is nil.
+	Src rand.Source
+
+	rnd          *rand.Rand
+	lower, upper []float64
+	unit         *Bounds
+
+	stage   surrogateStage
+	samples [][]float64 // Initial samples in normalized coordinates.
+	idx     int         // Index of the sample being evaluated.
+	iter    int         // Number of proposed locations.
+
+	x    [][]float64 // Evaluated locations in normalized coordinates.
+	f    []float64   // Function values at x.
+	best int         // Index of the lowest function value.
+	next []float64   // Next location in normalized coordinates.
+	cand []float64   // Candidate being considered.
+	far  []float64   // Candidate farthest from the evaluated locations.
+}
+
+func (so *SurrogateOptimizer) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	dim := len(loc.X)
+	if so.Model == nil {
+		so.Model = &RBFModel{}
+	}
+	if so.InitialSamples == 0 {
+		so.InitialSamples = 2*dim + 1
+	}
+	if so.InitialSamples < dim {
+		// Together with the initial location, the samples must determine
+		// the linear tail of the model.
+		panic("surrogate: too few initial samples")
+	}
+	if so.Candidates == 0 {
+		so.Candidates = defaultSurrogateCandidates * dim
+	}
+	if so.Candidates < 0 {
+		panic("surrogate: negative number of candidates")
+	}
+	if so.Distances == nil {
+		so.Distances = defaultSurrogateDistances
+	}
+	if len(so.Distances) == 0 {
+		panic("surrogate: empty distance cycle")
+	}
+	for _, d := range so.Distances {
+		if d < 0 {
+			panic("surrogate: negative distance")
+		}
+	}
+	so.rnd = newRand(so.Src, p.Src)
+	b := p.Bounds
+	if b == nil || b.Lower == nil || b.Upper == nil {
+		return NoEvaluation, NoIteration, errors.New("surrogate: problem must have finite bounds")
+	}
+	for i := 0; i < dim; i++ {
+		if math.IsInf(b.Lower[i], 0) || math.IsInf(b.Upper[i], 0) {
+			return NoEvaluation, NoIteration, errors.New("surrogate: problem must have finite bounds")
+		}
+	}
+	so.lower = b.Lower
+	so.upper = b.Upper
+	so.unit = &Bounds{Lower: make([]float64, dim), Upper: make([]float64, dim)}
+	for i := range so.unit.Upper {
+		so.unit.Upper[i] = 1
+	}
+	so.x = so.x[:0]
+	so.f = so.f[:0]
+	so.best = -1
+	so.iter = 0
+	so.next = resize(so.next, dim)
+	so.cand = resize(so.cand, dim)
+	so.far = resize(so.far, dim)
+	so.addData(loc.X, loc.F)
+
+	so.samples = resizeMembers(so.samples, so.InitialSamples, dim)
+	sampler := so.Sampler
+	if sampler == nil {
+		sampler = LatinHypercube{Src: rand.NewSource(so.rnd.Int63())}
+	}
+	sampler.Sample(so.samples, so.unit)
+	so.stage = surrogateInitialize
+	so.idx = 0
+	if len(so.samples) == 0 {
+		return so.propose(xNext)
+	}
+	so.fromUnit(xNext, so.samples[0])
+	return FuncEvaluation, InitIteration, nil
+}
+
+func (so *SurrogateOptimizer) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	so.addData(loc.X, loc.F)
+	if so.stage == surrogateInitialize {
+		so.idx++
+		if so.idx < len(so.samples) {
+			so.fromUnit(xNext, so.samples[so.idx])
+			return FuncEvaluation, InitIteration, nil
+		}
+	}
+	return so.propose(xNext)
+}
+
+// propose fits the model and stores the proposed location into xNext.
+func (so *SurrogateOptimizer) propose(xNext []float64) (EvaluationType, IterationType, error) {
+	so.stage = surrogateModel
+	minDist := so.Distances[so.iter%len(so.Distances)]
+	so.iter++
+
+	// Without a model, for example if all function values are infinite,
+	// the farthest candidate is proposed.
+	fitted := so.best >= 0 && so.Model.Fit(so.x, so.f) == nil
+
+	bestVal := math.Inf(1)
+	farthest := math.Inf(-1)
+	consider := func(u []float64) {
+		d := so.distance(u)
+		if d > farthest {
+			farthest = d
+			copy(so.far, u)
+		}
+		if !fitted || d < minDist {
+			return
+		}
+		if v := so.Model.Predict(u); v < bestVal {
+			bestVal = v
+			copy(so.next, u)
+		}
+	}
+
+	u := so.cand
+	if fitted {
+		so.minimizeModel(u)
+		consider(u)
+	}
+	sigma := math.Max(2*minDist, 1e-3)
+	for i := 0; i < so.Candidates; i++ {
+		for j := range u {
+			u[j] = so.rnd.Float64()
+		}
+		consider(u)
+		if so.best < 0 {
+			continue
+		}
+		for j := range u {
+			u[j] = so.x[so.best][j] + sigma*so.rnd.NormFloat64()
+		}
+		so.unit.Project(u)
+		consider(u)
+	}
+	if math.IsInf(bestVal, 1) {
+		copy(so.next, so.far)
+	}
+	so.fromUnit(xNext, so.next)
+	return FuncEvaluation, MajorIteration, nil
+}
+
+// minimizeModel stores the minimizer of the model found from the best
+// location into u.
+func (so *SurrogateOptimizer) minimizeModel(u []float64) {
+	dim := len(u)
+	copy(u, so.x[so.best])
+	var method Method = &NelderMead{}
+	if so.Method != nil {
+		method = so.Method()
+	}
+	// Locations outside the unit cube are projected onto it unless the
+	// Method handles the bounds itself.
+	bounded := false
+	if b, ok := method.(BoundsHandler); ok && b.HandlesBounds() {
+		bounded = true
+	}
+	x := make([]float64, dim)
+	p := Problem{
+		Func: func(v []float64) float64 {
+			copy(x, v)
+			if !bounded {
+				so.unit.Project(x)
+			}
+			return so.Model.Predict(x)
+		},
+	}
+	p = FDGradient{Formula: CentralDifference}.Problem(p)
+	if bounded {
+		p.Bounds = so.unit
+	}
+	settings := DefaultSettings()
+	settings.FuncEvaluations = defaultAcquisitionEvals * dim
+	result, err := Local(p, u, settings, method)
+	if err != nil {
+		return
+	}
+	copy(u, result.X)
+	so.unit.Project(u)
+}
+
+// distance returns the Euclidean distance of u from the nearest evaluated
+// location.
+func (so *SurrogateOptimizer) distance(u []float64) float64 {
+	d := math.Inf(1)
+	for _, x := range so.x {
+		d = math.Min(d, floats.Distance(u, x, 2))
+	}
+	return d
+}
+
+// addData adds the evaluated location x with the function value f to the
+// data of the model. Infeasible locations and locations with non-finite
+// function values are ignored.
+func (so *SurrogateOptimizer) addData(x []float64, f float64) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return
+	}
+	u := make([]float64, len(x))
+	for i, v := range x {
+		if so.upper[i] > so.lower[i] {
+			u[i] = (v - so.lower[i]) / (so.upper[i] - so.lower[i])
+		}
+	}
+	if !so.unit.Feasible(u) {
+		return
+	}
+	so.x = append(so.x, u)
+	so.f = append(so.f, f)
+	if so.best < 0 || f < so.f[so.best] {
+		so.best = len(so.f) - 1
+	}
+}
+
+// fromUnit stores the location of the normalized point u into x.
+func (so *SurrogateOptimizer) fromUnit(x, u []float64) {
+	for i, v := range u {
+		x[i] = so.lower[i] + v*(so.upper[i]-so.lower[i])
+	}
+}
+
+func (*SurrogateOptimizer) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// HandlesBounds returns true. All locations evaluated by SurrogateOptimizer
+// are feasible.
+func (*SurrogateOptimizer) HandlesBounds() bool {
+	return true
+}
@@ -0,0 +1,91 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRBFModel(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	x := make([][]float64, 20)
+	for i := range x {
+		x[i] = []float64{rnd.Float64(), rnd.Float64()}
+	}
+	linear := func(x []float64) float64 { return 1 + 2*x[0] - 3*x[1] }
+	smooth := func(x []float64) float64 { return math.Sin(3*x[0]) * math.Cos(2*x[1]) }
+	for _, basis := range []RadialBasis{CubicBasis{}, ThinPlateBasis{}, MultiquadricBasis{}} {
+		for _, test := range []struct {
+			name string
+			f    func([]float64) float64
+			tol  float64 // Tolerance away from the data.
+		}{
+			{"Linear", linear, 1e-8},
+			{"Smooth", smooth, 0.05},
+		} {
+			y := make([]float64, len(x))
+			for i, xi := range x {
+				y[i] = test.f(xi)
+			}
+			m := &RBFModel{Basis: basis}
+			if err := m.Fit(x, y); err != nil {
+				t.Errorf("%T %s: unexpected error: %v", basis, test.name, err)
+				continue
+			}
+			for i, xi := range x {
+				if got := m.Predict(xi); math.Abs(got-y[i]) > 1e-8 {
+					t.Errorf("%T %s: model does not interpolate, want %v, got %v", basis, test.name, y[i], got)
+				}
+			}
+			u := []float64{0.4, 0.6}
+			if got, want := m.Predict(u), test.f(u); math.Abs(got-want) > test.tol {
+				t.Errorf("%T %s: inaccurate prediction, want %v, got %v", basis, test.name, want, got)
+			}
+		}
+	}
+
+	m := &RBFModel{}
+	if err := m.Fit(x[:2], []float64{0, 1}); err == nil {
+		t.Errorf("expected error with too few locations")
+	}
+	if err := m.Fit([][]float64{{0, 0}, {1, 1}, {2, 2}, {3, 3}}, []float64{0, 1, 2, 3}); err == nil {
+		t.Errorf("expected error with collinear locations")
+	}
+}
+
+func TestSurrogateOptimizer(t *testing.T) {
+	const fOpt = -1.031628453
+	p := Problem{
+		Func:   sixHumpCamel,
+		Bounds: &Bounds{Lower: []float64{-3, -2}, Upper: []float64{3, 2}},
+	}
+	for _, method := range []*SurrogateOptimizer{
+		{Src: rand.NewSource(1)},
+		{Src: rand.NewSource(1), Model: &RBFModel{Basis: ThinPlateBasis{}}, Sampler: Sobol{}},
+		{Src: rand.NewSource(1), Method: func() Method { return &LBFGSB{} }},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.FuncEvaluations = 60
+		result, err := Local(p, []float64{2.5, 1.5}, settings, method)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result.Status != FunctionEvaluationLimit {
+			t.Errorf("unexpected status %v", result.Status)
+		}
+		if result.F > fOpt+1e-2 {
+			t.Errorf("global minimum not found: x = %v, f = %v", result.X, result.F)
+		}
+	}
+
+	p.Bounds = nil
+	if _, err := Local(p, []float64{1, 1}, nil, &SurrogateOptimizer{}); err == nil {
+		t.Errorf("expected error without bounds")
+	}
+}
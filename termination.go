@@ -11,19 +11,45 @@ import "errors"
 type Status int
 
 const (
+	// NotTerminated is the status of a run that has not finished.
 	NotTerminated Status = iota
+	// Success is a generic status of a successful run. The Methods and
+	// drivers in this package do not return it, and report MethodConverge
+	// instead.
 	Success
+	// FunctionThreshold is returned when the function value is below
+	// Settings.FunctionThreshold.
 	FunctionThreshold
+	// FunctionConvergence is returned when the function value has not
+	// decreased significantly, see Settings.FunctionConverge.
 	FunctionConvergence
+	// GradientThreshold is returned when the norm of the gradient is below
+	// Settings.GradientThreshold.
 	GradientThreshold
+	// StepConvergence is returned when the step is below
+	// Settings.StepThreshold.
 	StepConvergence
+	// FunctionNegativeInfinity is returned when the function value is -∞.
 	FunctionNegativeInfinity
+	// Failure is returned when the run has failed, together with the error.
 	Failure
+	// IterationLimit, RuntimeLimit, FunctionEvaluationLimit,
+	// GradientEvaluationLimit and HessianEvaluationLimit are returned when the
+	// corresponding budget in Settings is exhausted.
 	IterationLimit
 	RuntimeLimit
 	FunctionEvaluationLimit
 	GradientEvaluationLimit
 	HessianEvaluationLimit
+	// Canceled is returned when the context of LocalContext is done.
+	Canceled
+	// HessianVectorEvaluationLimit is returned when the budget of
+	// Settings.HessVecEvaluations is exhausted.
+	HessianVectorEvaluationLimit
+	// MethodConverge is returned when the Method has converged by its own
+	// convergence criteria, reported through Statuser, and by the drivers
+	// Root, Penalty and BranchAndBound when they have converged by theirs.
+	MethodConverge
 )
 
 func (s Status) String() string {
@@ -100,6 +126,19 @@ var statuses = []struct {
 		early: true,
 		err:   errors.New("optimize: maximum number of Hessian evaluations reached"),
 	},
+	{
+		name:  "Canceled",
+		early: true,
+		err:   errors.New("optimize: optimization canceled"),
+	},
+	{
+		name:  "HessianVectorEvaluationLimit",
+		early: true,
+		err:   errors.New("optimize: maximum number of Hessian-vector products reached"),
+	},
+	{
+		name: "MethodConverge",
+	},
 }
 
 // NewStatus returns a unique Status variable to represent a custom status.
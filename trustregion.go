@@ -0,0 +1,414 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// SubproblemSolver computes an approximate solution to the trust-region
+// subproblem
+//  minimize   m(p) = ∇f_k·p + ½ pᵀ B_k p
+//  subject to |p|_2 <= Δ_k,
+// where ∇f_k is the gradient at the current location, B_k is the Hessian or
+// its approximation (which need not be positive definite) and Δ_k is the
+// trust-region radius. Typically, a SubproblemSolver will not be called by
+// the user directly, as it will be called by a trust-region Method.
+type SubproblemSolver interface {
+	// Solve stores the computed step in place into p and returns the value of
+	// the quadratic model m(p). Solve must not modify grad and hess.
+	Solve(p, grad []float64, hess *mat64.SymDense, radius float64) (mp float64)
+}
+
+// HessVecSolver is a SubproblemSolver that can compute the step using only
+// products of B_k with vectors, so that B_k need not be formed.
+type HessVecSolver interface {
+	SubproblemSolver
+	// SolveHessVec is like Solve, but it computes the products with B_k by
+	// hessVec, which stores B_k*v in place into dst and must not modify v.
+	SolveHessVec(p, grad []float64, hessVec func(dst, v []float64), radius float64) (mp float64)
+}
+
+// quadraticModel returns the value of the quadratic model
+//  m(p) = g·p + ½ pᵀBp.
+// tmp must have the same length as p and is used as temporary storage.
+func quadraticModel(p, grad []float64, hess *mat64.SymDense, tmp []float64) float64 {
+	symMulVec(tmp, hess, p)
+	return floats.Dot(grad, p) + 0.5*floats.Dot(p, tmp)
+}
+
+// curvature returns vᵀBv. tmp must have the same length as v and is used as
+// temporary storage.
+func curvature(v []float64, hess *mat64.SymDense, tmp []float64) float64 {
+	symMulVec(tmp, hess, v)
+	return floats.Dot(v, tmp)
+}
+
+// symMulVec stores a x into dst without allocating. dst must not overlap x.
+func symMulVec(dst []float64, a *mat64.SymDense, x []float64) {
+	raw := a.RawSymmetric()
+	n := raw.N
+	if len(x) != n || len(dst) != n {
+		panic("optimize: slice length mismatch")
+	}
+	for i := range dst {
+		dst[i] = 0
+	}
+	// SymDense stores the upper triangle, whose i-th row is also the i-th
+	// column of the lower triangle.
+	for i := 0; i < n; i++ {
+		row := raw.Data[i*raw.Stride+i : i*raw.Stride+n]
+		dst[i] += floats.Dot(row, x[i:])
+		floats.AddScaled(dst[i+1:], x[i], row[1:])
+	}
+}
+
+// boundaryStep returns the values τ_1 <= τ_2 such that |p + τ d|_2 == radius.
+// p must satisfy |p|_2 <= radius and d must not be zero.
+func boundaryStep(p, d []float64, radius float64) (tau1, tau2 float64) {
+	a := floats.Dot(d, d)
+	b := floats.Dot(p, d)
+	c := floats.Dot(p, p) - radius*radius
+	if c > 0 {
+		// Guard against round-off placing p slightly outside the region.
+		c = 0
+	}
+	// Compute the roots of a τ^2 + 2bτ + c in a numerically stable way.
+	disc := b*b - a*c
+	var q float64
+	if b >= 0 {
+		q = -(b + math.Sqrt(disc))
+	} else {
+		q = -b + math.Sqrt(disc)
+	}
+	if q == 0 {
+		return 0, 0
+	}
+	tau1, tau2 = q/a, c/q
+	if tau1 > tau2 {
+		tau1, tau2 = tau2, tau1
+	}
+	return tau1, tau2
+}
+
+const (
+	defaultTrustRegionRadius = 1
+	defaultTrustRegionEta    = 1e-4
+
+	// Thresholds on the ratio of the actual to the predicted reduction
+	// below which the trust region is shrunk and above which it is expanded.
+	trustRegionShrinkRatio = 0.25
+	trustRegionExpandRatio = 0.75
+
+	// Multiple of the relative machine precision that is added to the actual
+	// and predicted reductions.
+	trustRegionRoundoff = 10 * 2.220446049250313e-16
+)
+
+// TrustRegion implements a trust-region method for unconstrained
+// minimization. At every iteration it forms the quadratic model
+//  m_k(p) = f_k + ∇f_k·p + ½ pᵀ B_k p
+// of the objective function around the current location x_k, where B_k is
+// the Hessian or its approximation, and computes a step p_k that
+// approximately minimizes the model within the trust region |p|_2 <= Δ_k
+// using the given SubproblemSolver. The step is accepted if the ratio
+//  ρ_k = (f(x_k) - f(x_k + p_k)) / (m_k(0) - m_k(p_k))
+// of the actual to the predicted reduction is larger than Eta, and the radius
+// Δ_k is updated depending on how well the model agrees with the function.
+// Close to a minimizer, where the predicted reduction is below the rounding
+// errors in f, the gradient is evaluated together with the function value
+// and a step is also accepted if it decreases the norm of the gradient.
+//
+// Unlike line search methods, TrustRegion does not require B_k to be
+// positive definite, provided that the SubproblemSolver handles indefinite
+// matrices, and so it can use the exact Hessian directly. If QuasiNewton is
+// true, a BFGS approximation of the Hessian is used instead, and the problem
+// does not need to provide Hess. If HessVec is true, the Hessian is accessed
+// only through the products with vectors of ProblemInfo.HessVec, which are
+// provided by Problem.HessVec, computed from Problem.Hess or approximated by
+// finite differences of gradients, so that the O(n^2) storage of the Hessian
+// is avoided. The Solver must then implement HessVecSolver.
+//
+// Reference:
+//  Nocedal, J., Wright, S.J.: Numerical Optimization (2nd ed). Springer
+//  (2006), Chapter 4
+type TrustRegion struct {
+	// Solver computes the steps.
+	// If Solver is nil, it will be set to a Steihaug solver.
+	Solver SubproblemSolver
+	// QuasiNewton specifies whether the Hessian is approximated by BFGS
+	// updates instead of being evaluated.
+	QuasiNewton bool
+	// HessVec specifies whether the steps are computed using only
+	// Hessian-vector products. HessVec and QuasiNewton must not both be
+	// true.
+	HessVec bool
+	// InitialRadius is the initial trust-region radius.
+	// If InitialRadius is zero, it will be set to 1.
+	InitialRadius float64
+	// MaxRadius is the largest allowed trust-region radius.
+	// If MaxRadius is zero, the radius is not bounded.
+	MaxRadius float64
+	// Eta is the smallest ratio of the actual to the predicted reduction for
+	// which a step is accepted. It must be in the interval [0, 0.25).
+	// If Eta is zero, it will be set to 1e-4.
+	Eta float64
+
+	hessVec func(x, grad, v, hv []float64)
+	// update is the quasi-Newton update of the Hessian approximation. If it
+	// is nil, bfgsHessianUpdate is used.
+	update func(hess *mat64.SymDense, s, y, tmp []float64, scale bool) bool
+
+	radius   float64
+	iterType IterationType
+	evalType EvaluationType
+
+	x    []float64 // Location of the last major iteration.
+	f    float64   // Function value at x.
+	grad []float64 // Gradient at x.
+	step []float64
+	mp   float64 // Value of the model at the step, relative to f.
+
+	hess  *mat64.SymDense // Hessian or its BFGS approximation.
+	first bool            // Whether the BFGS approximation has not been scaled yet.
+	s, y  []float64
+	tmp   []float64
+}
+
+func (tr *TrustRegion) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if tr.Solver == nil {
+		tr.Solver = &Steihaug{}
+	}
+	if tr.InitialRadius == 0 {
+		tr.InitialRadius = defaultTrustRegionRadius
+	}
+	if tr.Eta == 0 {
+		tr.Eta = defaultTrustRegionEta
+	}
+	if tr.InitialRadius < 0 {
+		panic("trustregion: InitialRadius must be positive")
+	}
+	if tr.MaxRadius < 0 || (tr.MaxRadius > 0 && tr.MaxRadius < tr.InitialRadius) {
+		panic("trustregion: MaxRadius must not be smaller than InitialRadius")
+	}
+	if tr.Eta < 0 || tr.Eta >= trustRegionShrinkRatio {
+		panic("trustregion: Eta must be in [0, 0.25)")
+	}
+	if tr.HessVec {
+		if tr.QuasiNewton {
+			panic("trustregion: HessVec and QuasiNewton are both true")
+		}
+		if _, ok := tr.Solver.(HessVecSolver); !ok {
+			panic("trustregion: solver does not support Hessian-vector products")
+		}
+		tr.hessVec = p.HessVec
+	}
+
+	dim := len(loc.X)
+	tr.radius = tr.InitialRadius
+	tr.x = resize(tr.x, dim)
+	tr.grad = resize(tr.grad, dim)
+	tr.step = resize(tr.step, dim)
+	tr.s = resize(tr.s, dim)
+	tr.y = resize(tr.y, dim)
+	tr.tmp = resize(tr.tmp, dim)
+	if !tr.HessVec {
+		tr.hess = resizeSymDense(tr.hess, dim)
+	}
+	if tr.QuasiNewton {
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				tr.hess.SetSym(i, j, 0)
+			}
+			tr.hess.SetSym(i, i, 1)
+		}
+		tr.first = true
+	}
+	return tr.newStep(loc, xNext)
+}
+
+func (tr *TrustRegion) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch tr.iterType {
+	case SubIteration:
+		// The remaining fields of the accepted location have been evaluated.
+		tr.iterType = MajorIteration
+		copy(xNext, loc.X)
+		return NoEvaluation, tr.iterType, nil
+	case MajorIteration:
+		if tr.QuasiNewton {
+			tr.updateHessian(loc)
+		}
+		return tr.newStep(loc, xNext)
+	}
+
+	// Compare the actual and the predicted reduction and update the radius.
+	// Both reductions are increased by a small multiple of the rounding error
+	// in f, so that ρ tends to one when they are both at the level of
+	// rounding errors close to a minimizer, see Conn, Gould, Toint (2000),
+	// Section 17.4.2.
+	stepNorm := floats.Norm(tr.step, 2)
+	delta := tr.roundoff()
+	rho := (tr.f - loc.F + delta) / (-tr.mp + delta)
+	if loc.F > tr.f && !tr.gradientDecreased(loc) {
+		// Steps that increase the function value are not accepted, even if
+		// ρ is large due to rounding errors.
+		rho = 0
+	}
+	if rho < trustRegionShrinkRatio || math.IsNaN(rho) {
+		tr.radius = trustRegionShrinkRatio * stepNorm
+	} else if rho > trustRegionExpandRatio && stepNorm >= (1-1e-8)*tr.radius {
+		tr.radius *= 2
+		if tr.MaxRadius > 0 {
+			tr.radius = math.Min(tr.radius, tr.MaxRadius)
+		}
+	}
+
+	if rho > tr.Eta {
+		// The step is accepted.
+		copy(xNext, loc.X)
+		complEval := complementEval(loc, tr.evalType)
+		if complEval == NoEvaluation {
+			tr.iterType = MajorIteration
+		} else {
+			tr.iterType = SubIteration
+		}
+		return complEval, tr.iterType, nil
+	}
+	// The step is rejected, compute a new one within the smaller region.
+	return tr.computeStep(xNext)
+}
+
+// newStep stores the data at the new location loc and computes the first
+// trial step.
+func (tr *TrustRegion) newStep(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	copy(tr.x, loc.X)
+	copy(tr.grad, loc.Gradient)
+	tr.f = loc.F
+	if !tr.QuasiNewton && !tr.HessVec {
+		tr.hess.CopySym(loc.Hessian)
+	}
+	return tr.computeStep(xNext)
+}
+
+// computeStep solves the trust-region subproblem at the stored location and
+// stores the trial location into xNext.
+func (tr *TrustRegion) computeStep(xNext []float64) (EvaluationType, IterationType, error) {
+	if tr.HessVec {
+		tr.mp = tr.Solver.(HessVecSolver).SolveHessVec(tr.step, tr.grad, func(dst, v []float64) {
+			tr.hessVec(tr.x, tr.grad, v, dst)
+		}, tr.radius)
+	} else {
+		tr.mp = tr.Solver.Solve(tr.step, tr.grad, tr.hess, tr.radius)
+	}
+	floats.AddTo(xNext, tr.x, tr.step)
+	if tr.mp >= 0 || floats.Equal(tr.x, xNext) {
+		// The model predicts no decrease or the step is too small to
+		// change the location.
+		return NoEvaluation, NoIteration, ErrNoProgress
+	}
+	tr.evalType = FuncEvaluation
+	if -tr.mp <= tr.roundoff() {
+		// The function value cannot decide whether the step is accepted,
+		// see gradientDecreased.
+		tr.evalType |= GradEvaluation
+	}
+	tr.iterType = MinorIteration
+	return tr.evalType, tr.iterType, nil
+}
+
+// roundoff returns the estimate of the rounding error in f that is added to
+// the actual and predicted reductions.
+func (tr *TrustRegion) roundoff() float64 {
+	return trustRegionRoundoff * math.Max(1, math.Abs(tr.f))
+}
+
+// gradientDecreased reports whether the predicted reduction of the step to
+// loc is at the level of rounding errors in f and the norm of the gradient
+// at loc is smaller than at the current location. The differences of the
+// function values are then dominated by rounding errors, and such steps are
+// accepted even if the function value increases, so that the gradient can
+// be reduced further close to a minimizer.
+func (tr *TrustRegion) gradientDecreased(loc *Location) bool {
+	if tr.evalType&GradEvaluation == 0 {
+		return false
+	}
+	return floats.Norm(loc.Gradient, 2) < floats.Norm(tr.grad, 2)
+}
+
+// updateHessian performs the quasi-Newton update of the Hessian
+// approximation using the step from the previous major iteration to loc.
+func (tr *TrustRegion) updateHessian(loc *Location) {
+	floats.SubTo(tr.s, loc.X, tr.x)
+	floats.SubTo(tr.y, loc.Gradient, tr.grad)
+	update := tr.update
+	if update == nil {
+		update = bfgsHessianUpdate
+	}
+	if update(tr.hess, tr.s, tr.y, tr.tmp, tr.first) {
+		tr.first = false
+	}
+}
+
+// Reset discards the trust-region radius and, if QuasiNewton is true, the
+// estimate of the Hessian of the last run, keeping the exported fields.
+func (tr *TrustRegion) Reset() {
+	*tr = TrustRegion{
+		Solver:        tr.Solver,
+		QuasiNewton:   tr.QuasiNewton,
+		HessVec:       tr.HessVec,
+		InitialRadius: tr.InitialRadius,
+		MaxRadius:     tr.MaxRadius,
+		Eta:           tr.Eta,
+	}
+}
+
+// Clone returns a new TrustRegion with the configuration of tr and a copy of
+// its Solver.
+func (tr *TrustRegion) Clone() Method {
+	return &TrustRegion{
+		Solver:        cloneSubproblemSolver(tr.Solver),
+		QuasiNewton:   tr.QuasiNewton,
+		HessVec:       tr.HessVec,
+		InitialRadius: tr.InitialRadius,
+		MaxRadius:     tr.MaxRadius,
+		Eta:           tr.Eta,
+	}
+}
+
+func (tr *TrustRegion) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, !tr.QuasiNewton && !tr.HessVec}
+}
+
+// cloneSubproblemSolver returns a copy of the configuration of s without its
+// state. SubproblemSolvers of types defined outside this package are returned
+// unchanged.
+func cloneSubproblemSolver(s SubproblemSolver) SubproblemSolver {
+	switch s := s.(type) {
+	case *Steihaug:
+		return &Steihaug{Tolerance: s.Tolerance, MaxIterations: s.MaxIterations}
+	case *GLTR:
+		return &GLTR{Tolerance: s.Tolerance, MaxIterations: s.MaxIterations}
+	case *MoreSorensen:
+		return &MoreSorensen{
+			Tolerance:         s.Tolerance,
+			HardCaseTolerance: s.HardCaseTolerance,
+			MaxIterations:     s.MaxIterations,
+		}
+	case *CauchyPoint:
+		return &CauchyPoint{}
+	case *Dogleg:
+		return &Dogleg{}
+	}
+	return s
+}
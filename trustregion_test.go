@@ -0,0 +1,175 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+type subproblemTest struct {
+	name   string
+	grad   []float64
+	hess   []float64 // Row-major dense Hessian.
+	radius float64
+	// wantF is the optimal value of the model. If NaN, only descent and
+	// feasibility are checked.
+	wantF float64
+}
+
+var subproblemTests = []subproblemTest{
+	{
+		name:   "Interior",
+		grad:   []float64{2, 4},
+		hess:   []float64{2, 0, 0, 4},
+		radius: 10,
+		wantF:  -3,
+	},
+	{
+		name:   "Boundary",
+		grad:   []float64{3, 4},
+		hess:   []float64{1, 0, 0, 1},
+		radius: 1,
+		wantF:  -4.5,
+	},
+	{
+		name:   "Indefinite",
+		grad:   []float64{1, 1},
+		hess:   []float64{-1, 0, 0, 1},
+		radius: 1,
+		// Minimizer lies on the boundary with λ in (1, ∞).
+		wantF: math.NaN(),
+	},
+	{
+		name:   "HardCase",
+		grad:   []float64{0, 1},
+		hess:   []float64{-1, 0, 0, 1},
+		radius: 2,
+		wantF:  -2.25,
+	},
+	{
+		name:   "ZeroGradientIndefinite",
+		grad:   []float64{0, 0, 0},
+		hess:   []float64{2, 0, 0, 0, -3, 0, 0, 0, 1},
+		radius: 1,
+		wantF:  -1.5,
+	},
+}
+
+func testSubproblemSolver(t *testing.T, solver SubproblemSolver, exact bool) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, test := range subproblemTests {
+		dim := len(test.grad)
+		hess := mat64.NewSymDense(dim, nil)
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				hess.SetSym(i, j, test.hess[i*dim+j])
+			}
+		}
+		p := make([]float64, dim)
+		tmp := make([]float64, dim)
+		mp := solver.Solve(p, test.grad, hess, test.radius)
+
+		if norm := floats.Norm(p, 2); norm > test.radius*(1+1e-8) {
+			t.Errorf("%s: step outside trust region: |p| = %v, radius = %v", test.name, norm, test.radius)
+		}
+		if m := quadraticModel(p, test.grad, hess, tmp); math.Abs(m-mp) > 1e-12*math.Max(1, math.Abs(m)) {
+			t.Errorf("%s: returned model value %v does not match m(p) = %v", test.name, mp, m)
+		}
+		if !exact {
+			if mp > 0 {
+				t.Errorf("%s: step does not decrease the model: m(p) = %v", test.name, mp)
+			}
+			continue
+		}
+		if !math.IsNaN(test.wantF) && math.Abs(mp-test.wantF) > 1e-6 {
+			t.Errorf("%s: unexpected model value. Want %v, got %v", test.name, test.wantF, mp)
+		}
+		// No feasible point may have a smaller model value.
+		q := make([]float64, dim)
+		for k := 0; k < 1000; k++ {
+			for i := range q {
+				q[i] = rnd.NormFloat64()
+			}
+			floats.Scale(test.radius*rnd.Float64()/floats.Norm(q, 2), q)
+			if m := quadraticModel(q, test.grad, hess, tmp); m < mp-1e-6 {
+				t.Errorf("%s: found feasible point with smaller model value %v than %v", test.name, m, mp)
+				break
+			}
+		}
+	}
+}
+
+func TestMoreSorensen(t *testing.T) {
+	testSubproblemSolver(t, &MoreSorensen{}, true)
+}
+
+func TestGLTR(t *testing.T) {
+	testSubproblemSolver(t, &GLTR{}, false)
+}
+
+func TestCauchyPoint(t *testing.T) {
+	testSubproblemSolver(t, &CauchyPoint{}, false)
+}
+
+func TestDogleg(t *testing.T) {
+	testSubproblemSolver(t, &Dogleg{}, false)
+}
+
+func TestTwoDimensionalSubspace(t *testing.T) {
+	testSubproblemSolver(t, &TwoDimensionalSubspace{}, false)
+}
+
+func TestSteihaug(t *testing.T) {
+	testSubproblemSolver(t, &Steihaug{}, false)
+}
+
+func TestSubproblemSolverOrdering(t *testing.T) {
+	// For positive definite B, the model reductions must be ordered as
+	//  Cauchy point >= dogleg >= two-dimensional subspace >= exact.
+	rnd := rand.New(rand.NewSource(1))
+	const dim = 6
+	for trial := 0; trial < 100; trial++ {
+		a := mat64.NewDense(dim, dim, nil)
+		for i := 0; i < dim; i++ {
+			for j := 0; j < dim; j++ {
+				a.Set(i, j, rnd.NormFloat64())
+			}
+		}
+		hess := mat64.NewSymDense(dim, nil)
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				var v float64
+				for k := 0; k < dim; k++ {
+					v += a.At(k, i) * a.At(k, j)
+				}
+				if i == j {
+					v += 0.1
+				}
+				hess.SetSym(i, j, v)
+			}
+		}
+		grad := make([]float64, dim)
+		for i := range grad {
+			grad[i] = rnd.NormFloat64()
+		}
+		radius := math.Exp(2 * rnd.NormFloat64())
+		p := make([]float64, dim)
+		cauchy := (&CauchyPoint{}).Solve(p, grad, hess, radius)
+		dogleg := (&Dogleg{}).Solve(p, grad, hess, radius)
+		subspace := (&TwoDimensionalSubspace{}).Solve(p, grad, hess, radius)
+		exact := (&MoreSorensen{}).Solve(p, grad, hess, radius)
+		steihaug := (&Steihaug{}).Solve(p, grad, hess, radius)
+		tol := 1e-8 * math.Max(1, math.Abs(exact))
+		if dogleg > cauchy+tol || subspace > dogleg+tol || exact > subspace+tol || exact > steihaug+tol || steihaug > cauchy+tol {
+			t.Errorf("unexpected ordering of model values: Cauchy %v, dogleg %v, subspace %v, Steihaug %v, exact %v",
+				cauchy, dogleg, subspace, steihaug, exact)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright ©2015 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultTwoMetricEpsilon  = 1e-3
+	defaultTwoMetricFunConst = 1e-4
+	defaultTwoMetricDecrease = 0.5
+	twoMetricIncrease        = 5
+)
+
+// TwoMetric implements the two-metric projection method of Bertsekas for
+// minimization subject to bound constraints. It can also be used for
+// unconstrained problems, in which case it reduces to a Newton or quasi-Newton
+// method with a backtracking line search.
+//
+// At every iteration, TwoMetric identifies the set of variables that lie
+// within Epsilon of a bound and whose gradient points out of the feasible
+// region. For these variables a scaled steepest descent step is taken, while
+// for the remaining free variables a Newton step based on the corresponding
+// submatrix of the Hessian is used. The next iterate is found by backtracking
+// along the projection arc
+//  x(α) = P(x + α d),
+// where P is the projection onto the feasible region, until a sufficient
+// decrease condition is satisfied. Unlike plain projected gradient methods,
+// TwoMetric uses second-order information on the free variables and converges
+// superlinearly once the set of active bounds has been identified.
+//
+// The Hessian is modified by adding a multiple of the identity where
+// necessary so that the Newton step on the free variables is a descent
+// direction. If QuasiNewton is true, a BFGS approximation of the Hessian is
+// used instead, and the problem does not need to provide Hess.
+//
+// Reference:
+//  Bertsekas, D.P.: Projected Newton methods for optimization problems with
+//  simple constraints. SIAM J Control Optim 20 (1982), 221-246
+type TwoMetric struct {
+	// QuasiNewton specifies whether the Hessian is approximated by BFGS
+	// updates instead of being evaluated.
+	QuasiNewton bool
+	// Epsilon is the largest distance from a bound at which a variable may
+	// be considered active. The actual threshold is the minimum of Epsilon
+	// and the norm of the projected gradient, so that it shrinks to zero as
+	// the iterates approach a solution.
+	// If Epsilon is zero, it will be set to 1e-3.
+	Epsilon float64
+	// FunConst is the constant of the sufficient decrease condition and must
+	// be between zero and one.
+	// If FunConst is zero, it will be set to 1e-4.
+	FunConst float64
+	// Decrease is the factor by which the step size is decreased when the
+	// sufficient decrease condition is not met. It must be between zero and
+	// one.
+	// If Decrease is zero, it will be set to 0.5.
+	Decrease float64
+
+	bounds   *Bounds
+	iterType IterationType
+	evalType EvaluationType
+
+	x    []float64 // Location of the last major iteration.
+	f    float64   // Function value at x.
+	grad []float64 // Gradient at x.
+	dir  []float64 // Search direction.
+	step float64
+
+	active []bool
+	free   []int
+
+	hess    *mat64.SymDense // Hessian or its BFGS approximation.
+	sub     *mat64.SymDense // Hessian restricted to the free variables.
+	chol    *mat64.TriDense
+	subGrad []float64
+	subDir  []float64
+	tau     float64
+	first   bool // Whether the BFGS approximation has not been scaled yet.
+	s, y    []float64
+	tmp     []float64
+}
+
+func (tm *TwoMetric) Init(loc *Location, p *ProblemInfo, xNext []float64) (EvaluationType, IterationType, error) {
+	if tm.Epsilon == 0 {
+		tm.Epsilon = defaultTwoMetricEpsilon
+	}
+	if tm.FunConst == 0 {
+		tm.FunConst = defaultTwoMetricFunConst
+	}
+	if tm.Decrease == 0 {
+		tm.Decrease = defaultTwoMetricDecrease
+	}
+	if tm.Epsilon < 0 {
+		panic("twometric: Epsilon must be positive")
+	}
+	if tm.FunConst <= 0 || tm.FunConst >= 1 {
+		panic("twometric: FunConst must be between 0 and 1")
+	}
+	if tm.Decrease <= 0 || tm.Decrease >= 1 {
+		panic("twometric: Decrease must be between 0 and 1")
+	}
+
+	dim := len(loc.X)
+	tm.bounds = p.Bounds
+	if tm.bounds == nil {
+		tm.bounds = &Bounds{}
+	}
+	tm.x = resize(tm.x, dim)
+	tm.grad = resize(tm.grad, dim)
+	tm.dir = resize(tm.dir, dim)
+	tm.subGrad = resize(tm.subGrad, dim)
+	tm.subDir = resize(tm.subDir, dim)
+	tm.s = resize(tm.s, dim)
+	tm.y = resize(tm.y, dim)
+	tm.tmp = resize(tm.tmp, dim)
+	if cap(tm.active) < dim {
+		tm.active = make([]bool, dim)
+	}
+	tm.active = tm.active[:dim]
+	tm.hess = resizeSymDense(tm.hess, dim)
+	tm.tau = 0
+	if tm.QuasiNewton {
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				tm.hess.SetSym(i, j, 0)
+			}
+			tm.hess.SetSym(i, i, 1)
+		}
+		tm.first = true
+	}
+	return tm.nextDirection(loc, xNext)
+}
+
+func (tm *TwoMetric) Iterate(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	switch tm.iterType {
+	case SubIteration:
+		// The remaining fields of the accepted location have been evaluated.
+		tm.iterType = MajorIteration
+		copy(xNext, loc.X)
+		return NoEvaluation, tm.iterType, nil
+	case MajorIteration:
+		if tm.QuasiNewton {
+			tm.updateHessian(loc)
+		}
+		return tm.nextDirection(loc, xNext)
+	}
+
+	if loc.F <= tm.f-tm.FunConst*tm.predictedDecrease(loc.X) {
+		// Sufficient decrease has been achieved.
+		copy(xNext, loc.X)
+		complEval := complementEval(loc, tm.evalType)
+		if complEval == NoEvaluation {
+			tm.iterType = MajorIteration
+		} else {
+			tm.iterType = SubIteration
+		}
+		return complEval, tm.iterType, nil
+	}
+
+	tm.step *= tm.Decrease
+	tm.projectedStep(xNext)
+	if floats.Equal(tm.x, xNext) {
+		return NoEvaluation, NoIteration, ErrNoProgress
+	}
+	tm.evalType = FuncEvaluation
+	tm.iterType = MinorIteration
+	return tm.evalType, tm.iterType, nil
+}
+
+// nextDirection computes the search direction at loc and stores the first
+// trial location into xNext.
+func (tm *TwoMetric) nextDirection(loc *Location, xNext []float64) (EvaluationType, IterationType, error) {
+	copy(tm.x, loc.X)
+	copy(tm.grad, loc.Gradient)
+	tm.f = loc.F
+	if !tm.QuasiNewton {
+		tm.hess.CopySym(loc.Hessian)
+	}
+
+	// Identify the variables close to a bound whose gradient points out of
+	// the feasible region.
+	eps := math.Min(tm.Epsilon, tm.bounds.ProjectedGradientNorm(tm.x, tm.grad))
+	tm.free = tm.free[:0]
+	for i, v := range tm.x {
+		g := tm.grad[i]
+		tm.active[i] = (g > 0 && v <= tm.bounds.lower(i)+eps) || (g < 0 && v >= tm.bounds.upper(i)-eps)
+		if tm.active[i] {
+			// Scaled steepest descent for the active variables.
+			d := -g
+			if h := tm.hess.At(i, i); h > 0 {
+				d /= h
+			}
+			tm.dir[i] = d
+			continue
+		}
+		tm.free = append(tm.free, i)
+	}
+	tm.newtonStep()
+
+	tm.step = 1
+	tm.projectedStep(xNext)
+	if floats.Equal(tm.x, xNext) {
+		return NoEvaluation, NoIteration, ErrNoProgress
+	}
+	tm.evalType = FuncEvaluation
+	tm.iterType = MinorIteration
+	return tm.evalType, tm.iterType, nil
+}
+
+// newtonStep computes the Newton step on the free variables using the
+// Hessian restricted to them, modified if necessary so that it is positive
+// definite. The step is stored into the corresponding elements of tm.dir.
+func (tm *TwoMetric) newtonStep() {
+	n := len(tm.free)
+	if n == 0 {
+		return
+	}
+	tm.sub = resizeSymDense(tm.sub, n)
+	tm.chol = resizeTriDense(tm.chol, n)
+	subGrad := tm.subGrad[:n]
+	subDir := tm.subDir[:n]
+	minA := math.Inf(1)
+	for k, i := range tm.free {
+		subGrad[k] = tm.grad[i]
+		minA = math.Min(minA, tm.hess.At(i, i))
+	}
+	if minA > 0 {
+		tm.tau = 0
+	} else if tm.tau == 0 {
+		tm.tau = -minA + 0.001
+	}
+	for iter := 0; iter < maxNewtonModifications; iter++ {
+		for k, i := range tm.free {
+			for l := k; l < n; l++ {
+				tm.sub.SetSym(k, l, tm.hess.At(i, tm.free[l]))
+			}
+			tm.sub.SetSym(k, k, tm.hess.At(i, i)+tm.tau)
+		}
+		if tm.chol.Cholesky(tm.sub, true) {
+			d := mat64.NewVector(n, subDir)
+			d.SolveCholeskyVec(tm.chol, mat64.NewVector(n, subGrad))
+			for k, i := range tm.free {
+				tm.dir[i] = -subDir[k]
+			}
+			return
+		}
+		tm.tau = math.Max(twoMetricIncrease*tm.tau, 0.001)
+	}
+	// The modification failed, use the steepest descent direction.
+	for _, i := range tm.free {
+		tm.dir[i] = -tm.grad[i]
+	}
+}
+
+// projectedStep stores P(x + step*dir) into xNext.
+func (tm *TwoMetric) projectedStep(xNext []float64) {
+	floats.AddScaledTo(xNext, tm.x, tm.step, tm.dir)
+	tm.bounds.Project(xNext)
+}
+
+// predictedDecrease returns the decrease in the function value required by
+// the Armijo-like condition along the projection arc,
+//  -α Σ_{i free} g_i d_i + Σ_{i active} g_i (x_i - x_i(α)).
+func (tm *TwoMetric) predictedDecrease(x []float64) float64 {
+	var dec float64
+	for i, g := range tm.grad {
+		if tm.active[i] {
+			dec += g * (tm.x[i] - x[i])
+		} else {
+			dec -= tm.step * g * tm.dir[i]
+		}
+	}
+	return dec
+}
+
+// updateHessian performs the BFGS update of the Hessian approximation using
+// the step from the previous major iteration to loc. The update is skipped if
+// the curvature condition does not hold, which may happen when bounds are hit.
+func (tm *TwoMetric) updateHessian(loc *Location) {
+	floats.SubTo(tm.s, loc.X, tm.x)
+	floats.SubTo(tm.y, loc.Gradient, tm.grad)
+	if bfgsHessianUpdate(tm.hess, tm.s, tm.y, tm.tmp, tm.first) {
+		tm.first = false
+	}
+}
+
+func (tm *TwoMetric) HandlesBounds() bool {
+	return true
+}
+
+func (tm *TwoMetric) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, !tm.QuasiNewton}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/rand"
 	"time"
 
 	"github.com/gonum/matrix/mat64"
@@ -86,27 +87,96 @@ type Result struct {
 	Location
 	Stats
 	Status Status
+	// Diagnostics describes the quality of the returned location. It is nil
+	// unless Settings.Diagnostics is true.
+	Diagnostics *Diagnostics
+	// ConstraintViolation is the infinity norm of the violation of the
+	// nonlinear constraints at the returned location. It is zero for
+	// problems without nonlinear constraints.
+	ConstraintViolation float64
+	// Multipliers are the estimates of the Lagrange multipliers of the
+	// nonlinear constraints at the returned location. It is nil unless the
+	// problem has nonlinear constraints and the Method implements
+	// MultiplierEstimator.
+	Multipliers *Multipliers
+	// Covariance is the estimate of the covariance of the parameters at the
+	// returned location. It is nil unless the Method implements
+	// CovarianceEstimator and the estimate is available.
+	Covariance *mat64.SymDense
+}
+
+// Multipliers are the Lagrange multipliers of the nonlinear constraints of a
+// Problem. At a solution without active bounds they satisfy
+//  ∇f(x) + J_E(x)ᵀ Equality + J_I(x)ᵀ Inequality = 0,
+// where J_E and J_I are the Jacobians of the equality and the inequality
+// constraints, and the multipliers of the inequality constraints are
+// non-negative.
+type Multipliers struct {
+	Equality   []float64
+	Inequality []float64
 }
 
 // Stats contains the statistics of the run.
 type Stats struct {
-	MajorIterations int           // Total number of major iterations
-	FuncEvaluations int           // Number of evaluations of Func()
-	GradEvaluations int           // Number of evaluations of Grad()
-	HessEvaluations int           // Number of evaluations of Hess()
-	Runtime         time.Duration // Total runtime of the optimization
+	MajorIterations    int           // Total number of major iterations
+	FuncEvaluations    int           // Number of evaluations of Func()
+	GradEvaluations    int           // Number of evaluations of Grad()
+	HessEvaluations    int           // Number of evaluations of Hess()
+	HessVecEvaluations int           // Number of evaluations of HessVec()
+	Runtime            time.Duration // Total runtime of the optimization
 }
 
 // ProblemInfo is data to give to the optimizer about the objective function.
 type ProblemInfo struct {
 	HasGradient bool
 	HasHessian  bool
+	// Bounds are the bound constraints of the problem. If Bounds is nil,
+	// the problem is unconstrained.
+	Bounds *Bounds
+	// Equality and Inequality are the nonlinear constraints of the problem.
+	Equality   Constraint
+	Inequality Constraint
+	// HasHessVec specifies whether the problem provides Hessian-vector
+	// products by Problem.HessVec.
+	HasHessVec bool
+	// HessVec computes the product of the Hessian at x with v and stores
+	// the result in hv, see Problem.HessVec. grad must hold the gradient at
+	// x. If the problem does not provide HessVec, the products are computed
+	// with the Hessian if the problem provides Hess, and otherwise
+	// approximated by forward differences of gradients starting from grad.
+	// HessVec is nil if the problem provides none of them. The calls are
+	// counted in Stats.HessVecEvaluations, and the evaluations of the
+	// Hessian or the gradient they need are counted as well.
+	HessVec func(x, grad, v, hv []float64)
+	// Prox is the non-smooth term of a composite objective function, see
+	// Problem.Prox.
+	Prox ProximalOperator
+	// NonFiniteRetries is the number of retries of a line search with a
+	// smaller step, see Settings.NonFiniteRetries.
+	NonFiniteRetries int
+	// LinesearchRestarts is the number of restarts of the direction after
+	// failed line searches, see Settings.LinesearchRestarts.
+	LinesearchRestarts int
+	// LinesearchIterations, LinesearchMinStep and LinesearchMaxStep bound
+	// the line searches, see the fields of Settings with the same names.
+	LinesearchIterations int
+	LinesearchMinStep    float64
+	LinesearchMaxStep    float64
+	// Src is the source of random numbers of the run, see Settings.Src.
+	// Stochastic Methods whose own source is nil draw their seed from Src.
+	Src rand.Source
 }
 
-func newProblemInfo(p *Problem) *ProblemInfo {
+func newProblemInfo(p *Problem, stats *Stats) *ProblemInfo {
 	return &ProblemInfo{
 		HasGradient: p.Grad != nil,
 		HasHessian:  p.Hess != nil,
+		HasHessVec:  p.HessVec != nil,
+		Bounds:      p.Bounds,
+		Equality:    p.Equality,
+		Inequality:  p.Inequality,
+		HessVec:     newHessVec(p, stats),
+		Prox:        p.Prox,
 	}
 }
 
@@ -131,14 +201,54 @@ type Problem struct {
 	// must not modify x.
 	Func func(x []float64) float64
 	// Grad evaluates the gradient at x and stores the result in-place in grad.
-	// Grad must not modify x.
+	// Grad must not modify x. If the gradient is not available, it can be
+	// approximated by finite differences, see FDGradient.
 	Grad func(x []float64, grad []float64)
+	// FuncGrad evaluates the objective function and the gradient at x
+	// together, stores the gradient in-place in grad and returns the function
+	// value. It is optional and allows the computations shared by Func and
+	// Grad to be done only once. If FuncGrad is not nil, it is called
+	// whenever a Method requests both the function value and the gradient,
+	// and Func and Grad are called when only one of them is requested. Func
+	// and Grad, if it is not nil, must agree with FuncGrad. If Grad is nil,
+	// the gradient alone is evaluated by FuncGrad. FuncGrad must not modify x.
+	FuncGrad func(x, grad []float64) float64
 	// Hess evaluates the Hessian at x and stores the result in-place in hess.
 	// Hess must not modify x.
 	Hess func(x []float64, hess *mat64.SymDense)
+	// HessVec evaluates the product of the Hessian at x with the vector v
+	// and stores the result in-place in hv. It allows methods like NewtonCG
+	// to use second-order information without forming the Hessian. If
+	// HessVec is nil, such methods compute the products with Hess or
+	// approximate them by finite differences of Grad. HessVec must not
+	// modify x or v.
+	HessVec func(x, v, hv []float64)
 	// Status reports the status of the optimization problem and reports
 	// any error.
 	Status func() (Status, error)
+	// Bounds specifies simple bound constraints on the variables. If Bounds
+	// is nil, the problem is unconstrained. Problems with bounds can only be
+	// solved by Methods that implement BoundsHandler. The initial location
+	// is projected onto the feasible region before the optimization starts.
+	Bounds *Bounds
+	// Equality and Inequality specify nonlinear constraints
+	//  Equality.Func(x) = 0,
+	//  Inequality.Func(x) <= 0.
+	// If both are nil, the problem has no nonlinear constraints. Problems
+	// with nonlinear constraints can only be solved by Methods that implement
+	// ConstraintHandler. Unlike for bounds, evaluated locations may violate
+	// the constraints.
+	Equality   Constraint
+	Inequality Constraint
+	// Prox specifies the non-smooth term g of a composite objective function
+	//  Func(x) + g(x),
+	// where g is convex and has an easy proximal operator. If Prox is nil,
+	// the objective function is Func. Func must be smooth and Grad is its
+	// gradient. The values of the objective function seen by the Method and
+	// returned in Result include g. Problems with a proximal term can only
+	// be solved by Methods that implement ProximalHandler, and the initial
+	// location must be in the domain of g.
+	Prox ProximalOperator
 }
 
 // TODO(btracey): Think about making this an exported function when the
@@ -150,6 +260,21 @@ func (p Problem) satisfies(method Method) error {
 	if method.Needs().Hessian && p.Hess == nil {
 		return errors.New("optimize: problem does not provide needed Hess function")
 	}
+	if p.Bounds != nil {
+		if b, ok := method.(BoundsHandler); !ok || !b.HandlesBounds() {
+			return errors.New("optimize: method does not handle bound constraints")
+		}
+	}
+	if p.constrained() {
+		if c, ok := method.(ConstraintHandler); !ok || !c.HandlesConstraints() {
+			return errors.New("optimize: method does not handle nonlinear constraints")
+		}
+	}
+	if p.Prox != nil {
+		if h, ok := method.(ProximalHandler); !ok || !h.HandlesProx() {
+			return errors.New("optimize: method does not handle proximal operators")
+		}
+	}
 	return nil
 }
 
@@ -175,8 +300,10 @@ type Settings struct {
 
 	// GradientThreshold determines the accuracy to which the minimum is found.
 	// GradientThreshold status is returned if the infinity norm of
-	// the gradient is less than this value.
-	// Has no effect if gradient information is not used.
+	// the gradient is less than this value. For problems with bounds, the
+	// infinity norm of the projected gradient is used instead.
+	// Has no effect if gradient information is not used or if the problem
+	// has nonlinear constraints.
 	// The default value is 1e-6.
 	GradientThreshold float64
 
@@ -188,6 +315,14 @@ type Settings struct {
 	// is returned. If this is nil or if Iterations == 0, it has no effect.
 	FunctionConverge *FunctionConverge
 
+	// StepThreshold is the threshold for acceptably small steps.
+	// StepConvergence status is returned if the infinity norm of the
+	// difference between the locations of two consecutive major iterations
+	// is less than this value.
+	// If it equals zero, this setting has no effect.
+	// The default value is 0.
+	StepThreshold float64
+
 	// MajorIterations is the maximum number of iterations allowed.
 	// IterationLimit status is returned if the number of major iterations
 	// equals or exceeds this value.
@@ -209,6 +344,17 @@ type Settings struct {
 	// The default value is 0.
 	FuncEvaluations int
 
+	// FuncAverages is the number of evaluations of the objective function
+	// that are averaged at every location, to reduce the noise of stochastic
+	// objective functions. If FuncAverages is greater than one, Func is
+	// called FuncAverages times whenever the function value is requested,
+	// and the mean of the values is used. Every call counts toward
+	// FuncEvaluations. Averaging K evaluations reduces the standard
+	// deviation of independent noise by a factor of sqrt(K).
+	// If it equals zero, this setting has no effect.
+	// The default value is 0.
+	FuncAverages int
+
 	// GradEvaluations is the maximum allowed number of gradient evaluations.
 	// GradientEvaluationLimit status is returned if the total number of calls
 	// to Grad() equals or exceeds this number.
@@ -223,6 +369,169 @@ type Settings struct {
 	// The default value is 0.
 	HessEvaluations int
 
+	// HessVecEvaluations is the maximum allowed number of Hessian-vector
+	// products. HessianVectorEvaluationLimit status is returned if the total
+	// number of products computed for ProblemInfo.HessVec equals or exceeds
+	// this number.
+	// If it equals zero, this setting has no effect.
+	// The default value is 0.
+	HessVecEvaluations int
+
+	// NonFiniteRetries is the maximum number of times a line search is
+	// restarted with half the step after it has landed at a location where
+	// the objective function value is NaN or +Inf or the gradient is not
+	// finite, for example outside the domain of the function. The retries
+	// are performed by the Methods built on Linesearch, such as BFGS, LBFGS
+	// and CG.
+	//
+	// Local stops with Failure status and an ErrNonFinite error at a NaN
+	// function value or a gradient that is not finite, and at a +Inf
+	// function value at a major iteration. If NonFiniteRetries is positive,
+	// the values at minor iterations are passed to the Method instead, and
+	// a line search returns ErrNonFinite when its retries are exhausted.
+	// +Inf function values at minor iterations are always passed to the
+	// Method, which rejects the location. Non-finite values at the initial
+	// location are reported by ErrNonFinite as well, and a non-finite
+	// initial location by ErrInitialX, also with Failure status and the
+	// offending location in the Result.
+	// The default value is 0.
+	NonFiniteRetries int
+
+	// LinesearchRestarts is the maximum number of consecutive restarts of
+	// the search direction after a line search has failed, for example
+	// because no acceptable step has been found due to errors in the
+	// gradient, or because the direction is not a descent direction. The
+	// direction is restarted at the location of the last major iteration by
+	// Methods whose NextDirectioner implements DirectionRestarter, such as
+	// BFGS, which discards its estimate of the inverse Hessian and continues
+	// with the steepest descent direction. The error of the line search is
+	// returned if the restarts are exhausted, or if the Method cannot
+	// restart its direction.
+	// The default value is 0.
+	LinesearchRestarts int
+
+	// LinesearchIterations is the maximum number of iterations of a line
+	// search of a Method built on Linesearch, not counting the evaluation at
+	// the initial step size. A line search that has not found an acceptable
+	// step size within LinesearchIterations iterations fails with
+	// ErrLinesearchFailure.
+	// If it equals zero, this setting has no effect.
+	// The default value is 0.
+	LinesearchIterations int
+
+	// LinesearchMinStep and LinesearchMaxStep bound the step sizes of the
+	// line searches of the Methods built on Linesearch. The step sizes are
+	// multiples of the search direction. The initial step size of a line
+	// search is reduced to LinesearchMaxStep if it is larger, and
+	// Bisection does not extend the step size beyond it. A line search
+	// fails with ErrLinesearchFailure if the LinesearchMethod requests a
+	// step size smaller than LinesearchMinStep or larger than
+	// LinesearchMaxStep.
+	// If LinesearchMaxStep equals zero, the step sizes are not bounded
+	// above. Local panics if LinesearchMinStep is not smaller than a
+	// positive LinesearchMaxStep.
+	// The default values are 0.
+	LinesearchMinStep float64
+	LinesearchMaxStep float64
+
+	// Diagnostics specifies whether the quality of the returned location is
+	// assessed after the optimization, see the Diagnostics type. Computing
+	// the diagnostics may require additional evaluations of the gradient or
+	// the Hessian, which are counted in the returned Stats.
+	// The default value is false.
+	Diagnostics bool
+
+	// HessianApprox, if not nil, approximates the Hessian from the gradient
+	// when the Method needs the Hessian and the Problem does not provide
+	// Hess, so that for example Newton can be used with problems that
+	// provide only Func and Grad. See HessianApprox for details.
+	// The default value is nil.
+	HessianApprox *HessianApprox
+
+	// Concurrent is the maximum number of concurrent evaluations of the
+	// objective function and its derivatives. If Concurrent is greater than
+	// one and the Method implements Batcher, the locations in a batch are
+	// evaluated by up to Concurrent goroutines, so the functions of the
+	// Problem must be safe for concurrent use. Evaluation limits may then be
+	// exceeded by up to the size of a batch. Otherwise, the evaluations are
+	// sequential.
+	// The default value is 0.
+	Concurrent int
+
+	// CheckpointIterations is the number of major iterations between
+	// checkpoints. If CheckpointIterations is positive and the Method
+	// implements Checkpointer, SaveCheckpoint is called with a Checkpoint
+	// every CheckpointIterations major iterations, and the run can be
+	// resumed from it by ResumeLocal. If SaveCheckpoint returns an error, the
+	// optimization stops with Failure status and the error is returned.
+	// The default value is 0.
+	CheckpointIterations int
+	SaveCheckpoint       func(*Checkpoint) error
+
+	// Callback, if not nil, is called after every major iteration with the
+	// number of major iterations and the location of the iteration, for
+	// example to implement custom stopping rules or to monitor the progress.
+	// If Callback returns a Status other than NotTerminated, the
+	// optimization stops with that Status. If it returns an error, the
+	// optimization stops with Failure status, or the returned Status if it
+	// is not NotTerminated, and the error is returned.
+	//
+	// Callback may modify loc.X, for example to clip the parameters to a
+	// valid range. The run then continues from the modified location as
+	// from the initial location: the location is projected onto the
+	// Bounds, evaluated and sent to the Recorder as InitIteration, it
+	// replaces the best location found so far, and the Method is
+	// initialized there with Init. The other fields of loc are ignored.
+	// The default value is nil.
+	Callback func(iter int, loc *Location) (Status, error)
+
+	// Maximize specifies whether the objective function is maximized
+	// instead of minimized. The Method then minimizes the negated objective
+	// function, transparently to the user: the Recorder, the Callback and
+	// the returned Result see the values of the original objective function
+	// and its derivatives, and so does the initial data. FunctionThreshold
+	// status is returned if the objective function is greater than
+	// FunctionThreshold, and infinite values of FunctionThreshold have no
+	// effect. Checkpoints hold the negated values and must be resumed with
+	// the same Maximize. Maximization of problems with a proximal term is
+	// not supported.
+	// The default value is false.
+	Maximize bool
+
+	// Scale, if not nil, holds the characteristic magnitudes of the
+	// variables, which must be positive and finite. The Method then works
+	// with the scaled variables
+	//  x_i / Scale[i],
+	// which are of order one if the magnitudes are accurate, so that poorly
+	// scaled problems become well-conditioned. The scaling is transparent:
+	// the functions of the Problem, the Recorder, the Callback and the
+	// returned Result use the original variables. GradientThreshold and
+	// StepThreshold apply to the scaled variables, and so do the locations
+	// in checkpoints, which must be resumed with the same Scale.
+	// The default value is nil.
+	Scale []float64
+
+	// Src, if not nil, is the source of random numbers of the stochastic
+	// Methods, such as DifferentialEvolution, CmaEs and SimulatedAnnealing,
+	// whose own Src field is nil. Such a Method seeds its generator from Src
+	// in Init, so the run is reproducible if Src is seeded with the same
+	// value before the run. Drivers that execute several runs, such as
+	// MultiStart and BasinHopping, draw the seeds of their own random
+	// choices and of every run from Src, so their results are reproducible
+	// as well, also when the runs are concurrent.
+	// The default value is nil, in which case the Methods are seeded from
+	// the global source of math/rand.
+	Src rand.Source
+
+	// Events, if not nil, receives an Event for the initial location, for
+	// every major iteration and after the optimization, for example to
+	// update a progress bar or a dashboard while the optimization runs.
+	// Local never blocks on Events: if the channel is full, the oldest event
+	// in it is dropped, and Event.Dropped counts the dropped events. Events
+	// should therefore be buffered, and it is not closed by Local.
+	// The default value is nil.
+	Events chan Event
+
 	Recorder Recorder
 }
 
@@ -238,6 +547,19 @@ func DefaultSettings() *Settings {
 	}
 }
 
+// newRand returns a random number generator with the source src. If src is
+// nil, the generator is seeded from runSrc, or from the global source of
+// math/rand if runSrc is nil as well.
+func newRand(src, runSrc rand.Source) *rand.Rand {
+	if src != nil {
+		return rand.New(src)
+	}
+	if runSrc != nil {
+		return rand.New(rand.NewSource(runSrc.Int63()))
+	}
+	return rand.New(rand.NewSource(rand.Int63()))
+}
+
 // resize takes x and returns a slice of length dim. It returns a resliced x
 // if cap(x) >= dim, and a new slice otherwise.
 func resize(x []float64, dim int) []float64 {
@@ -247,6 +569,14 @@ func resize(x []float64, dim int) []float64 {
 	return x[:dim]
 }
 
+// resizeInts is like resize for a slice of ints.
+func resizeInts(x []int, dim int) []int {
+	if dim > cap(x) {
+		return make([]int, dim)
+	}
+	return x[:dim]
+}
+
 func resizeSymDense(m *mat64.SymDense, dim int) *mat64.SymDense {
 	if m == nil || cap(m.RawSymmetric().Data) < dim*dim {
 		return mat64.NewSymDense(dim, nil)
@@ -7,6 +7,7 @@ package optimize
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/gonum/floats"
@@ -1008,10 +1009,252 @@ func TestNelderMead(t *testing.T) {
 	testLocal(t, tests, &NelderMead{})
 }
 
+func TestHookeJeeves(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		p    Problem
+		x    []float64
+		want []float64
+	}{
+		{"Rosenbrock", Problem{Func: functions.ExtendedRosenbrock{}.Func}, []float64{-1.2, 1}, []float64{1, 1}},
+		{"Beale", Problem{Func: functions.Beale{}.Func}, []float64{1, 1}, []float64{3, 0.5}},
+		{"Wood", Problem{Func: functions.Wood{}.Func}, []float64{-3, -1, -3, -1}, []float64{1, 1, 1, 1}},
+		{
+			// The minimum is on the lower bound of x[1], where
+			// x[0] is slightly larger than sqrt(1.5).
+			"BoundedRosenbrock",
+			Problem{
+				Func:   functions.ExtendedRosenbrock{}.Func,
+				Bounds: &Bounds{Lower: []float64{-2, 1.5}, Upper: []float64{2, 3}},
+			},
+			[]float64{1.5, 2},
+			[]float64{1.224371, 1.5},
+		},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		result, err := Local(test.p, test.x, settings, &HookeJeeves{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if !floats.EqualApprox(result.X, test.want, 1e-4) {
+			t.Errorf("%s: unexpected minimum location, want %v, got %v", test.name, test.want, result.X)
+		}
+		if test.p.Bounds != nil && !test.p.Bounds.Feasible(result.X) {
+			t.Errorf("%s: infeasible minimum location %v", test.name, result.X)
+		}
+	}
+}
+
+func TestPowell(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		p    Problem
+		x    []float64
+		want []float64
+	}{
+		{"Rosenbrock", Problem{Func: functions.ExtendedRosenbrock{}.Func}, []float64{-1.2, 1}, []float64{1, 1}},
+		{"Beale", Problem{Func: functions.Beale{}.Func}, []float64{1, 1}, []float64{3, 0.5}},
+		{"Wood", Problem{Func: functions.Wood{}.Func}, []float64{-3, -1, -3, -1}, []float64{1, 1, 1, 1}},
+		{
+			"ExtendedRosenbrock",
+			Problem{Func: functions.ExtendedRosenbrock{}.Func},
+			[]float64{-1.2, 1, -1.2, 1, -1.2, 1, -1.2, 1},
+			[]float64{1, 1, 1, 1, 1, 1, 1, 1},
+		},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		result, err := Local(test.p, test.x, settings, &Powell{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if !floats.EqualApprox(result.X, test.want, 1e-6) {
+			t.Errorf("%s: unexpected minimum location, want %v, got %v", test.name, test.want, result.X)
+		}
+	}
+}
+
+func TestCoordinateDescent(t *testing.T) {
+	// The coupled quadratic ½ xᵀ A x - bᵀ x.
+	a := mat64.NewSymDense(3, []float64{
+		4, 1, 0,
+		1, 3, 1,
+		0, 1, 2,
+	})
+	b := []float64{1, 2, 3}
+	quad := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i := range x {
+				for j := range x {
+					f += 0.5 * x[i] * a.At(i, j) * x[j]
+				}
+				f -= b[i] * x[i]
+			}
+			return f
+		},
+		Grad: func(x, grad []float64) {
+			for i := range x {
+				grad[i] = -b[i]
+				for j := range x {
+					grad[i] += a.At(i, j) * x[j]
+				}
+			}
+		},
+	}
+	quadMin := []float64{2.0 / 9, 1.0 / 9, 13.0 / 9}
+
+	// The LASSO objective ½ |M x - y|_2^2 + λ |x|_1.
+	m := mat64.NewDense(4, 3, []float64{
+		1, 0.5, 0,
+		0, 1, 0.2,
+		0.3, 0, 1,
+		1, 1, 1,
+	})
+	y := []float64{1, -2, 0.05, 0.5}
+	const lambda = 0.5
+	lassoResid := func(x, r []float64) {
+		for i := range r {
+			r[i] = floats.Dot(m.Row(nil, i), x) - y[i]
+		}
+	}
+	lasso := Problem{
+		Func: func(x []float64) float64 {
+			r := make([]float64, len(y))
+			lassoResid(x, r)
+			return 0.5*floats.Dot(r, r) + lambda*floats.Norm(x, 1)
+		},
+	}
+
+	for _, test := range []struct {
+		name   string
+		p      Problem
+		method *CoordinateDescent
+		lasso  bool
+	}{
+		{"QuadraticCyclic", quad, &CoordinateDescent{}, false},
+		{"QuadraticRandom", quad, &CoordinateDescent{Rule: RandomCoordinates, Src: rand.NewSource(1)}, false},
+		{"QuadraticGaussSouthwell", quad, &CoordinateDescent{Rule: GaussSouthwell}, false},
+		{"QuadraticBlocks", quad, &CoordinateDescent{Blocks: [][]int{{0, 2}, {1}}}, false},
+		{"LassoCyclic", lasso, &CoordinateDescent{}, true},
+		{"LassoRandom", lasso, &CoordinateDescent{Rule: RandomCoordinates, Src: rand.NewSource(1)}, true},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.GradientThreshold = 1e-10
+		result, err := Local(test.p, []float64{1, 1, 1}, settings, test.method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge && result.Status != GradientThreshold {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if !test.lasso {
+			if !floats.EqualApprox(result.X, quadMin, 1e-6) {
+				t.Errorf("%s: unexpected minimum location, want %v, got %v", test.name, quadMin, result.X)
+			}
+			continue
+		}
+		// Check the optimality conditions of the LASSO objective.
+		r := make([]float64, len(y))
+		lassoResid(result.X, r)
+		for j, xj := range result.X {
+			g := floats.Dot(m.Col(nil, j), r)
+			if math.Abs(xj) > 1e-8 {
+				if math.Abs(g+math.Copysign(lambda, xj)) > 1e-5 {
+					t.Errorf("%s: coordinate %d not optimal: x = %v, gradient %v", test.name, j, xj, g)
+				}
+			} else if math.Abs(g) > lambda+1e-5 {
+				t.Errorf("%s: zero coordinate %d not optimal: gradient %v", test.name, j, g)
+			}
+		}
+	}
+}
+
+func TestSubgradient(t *testing.T) {
+	// The function |x[0] - 1| + 2 |x[1] + 0.5| + max(0, x[0] + x[1] - 2) with
+	// the minimum 0 at (1, -0.5).
+	nonsmooth := Problem{
+		Func: func(x []float64) float64 {
+			return math.Abs(x[0]-1) + 2*math.Abs(x[1]+0.5) + math.Max(0, x[0]+x[1]-2)
+		},
+		Grad: func(x, grad []float64) {
+			grad[0] = sign(x[0] - 1)
+			grad[1] = 2 * sign(x[1]+0.5)
+			if x[0]+x[1] > 2 {
+				grad[0]++
+				grad[1]++
+			}
+		},
+	}
+	bounded := nonsmooth
+	bounded.Bounds = &Bounds{Lower: []float64{-5, 0}, Upper: []float64{5, 5}}
+
+	for _, test := range []struct {
+		name  string
+		p     Problem
+		rule  SubgradientStepRule
+		want  []float64
+		fWant float64
+		tol   float64
+	}{
+		{"Polyak", nonsmooth, PolyakStep{}, []float64{1, -0.5}, 0, 1e-6},
+		{"Diminishing", nonsmooth, DiminishingStep{}, []float64{1, -0.5}, 0, 1e-2},
+		{"BoundedDiminishing", bounded, DiminishingStep{Scale: 0.5}, []float64{1, 0}, 1, 1e-2},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.MajorIterations = 10000
+		result, err := Local(test.p, []float64{3, 3}, settings, &Subgradient{StepRule: test.rule})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != MethodConverge && result.Status != IterationLimit && result.Status != GradientThreshold {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		if !floats.EqualApprox(result.X, test.want, test.tol) {
+			t.Errorf("%s: unexpected minimum location, want %v, got %v", test.name, test.want, result.X)
+		}
+		if math.Abs(result.F-test.fWant) > test.tol {
+			t.Errorf("%s: unexpected minimum, want %v, got %v", test.name, test.fWant, result.F)
+		}
+		if test.p.Bounds != nil && !test.p.Bounds.Feasible(result.X) {
+			t.Errorf("%s: infeasible minimum location %v", test.name, result.X)
+		}
+	}
+}
+
+// sign returns the sign of x, and 0 for x = 0.
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
 func TestGradientDescent(t *testing.T) {
 	testLocal(t, gradientDescentTests, &GradientDescent{})
 }
 
+func TestGradientDescentMomentum(t *testing.T) {
+	testLocal(t, gradientDescentTests, &GradientDescent{Momentum: 0.5})
+	testLocal(t, gradientDescentTests, &GradientDescent{Momentum: 0.5, Nesterov: true})
+}
+
 func TestGradientDescentBacktracking(t *testing.T) {
 	testLocal(t, gradientDescentTests, &GradientDescent{
 		LinesearchMethod: &Backtracking{
@@ -1020,12 +1263,109 @@ func TestGradientDescentBacktracking(t *testing.T) {
 	})
 }
 
+func TestGradientDescentFunctionDecreaseStep(t *testing.T) {
+	testLocal(t, gradientDescentTests, &GradientDescent{
+		StepSizer: &FunctionDecreaseStepSize{},
+	})
+}
+
+func TestGradientDescentNonmonotone(t *testing.T) {
+	testLocal(t, gradientDescentTests, &GradientDescent{
+		LinesearchMethod: &Nonmonotone{},
+	})
+}
+
+func TestNonmonotoneReference(t *testing.T) {
+	n := &Nonmonotone{Memory: 2}
+	p := &ProblemInfo{}
+	n.Init(LinesearchLocation{F: 10, Derivative: -1}, 1, p)
+	n.Init(LinesearchLocation{F: 1, Derivative: -1}, 1, p)
+	// The function value 5 is larger than the value at the start of the line
+	// search, but smaller than the maximum of the last two.
+	if !n.Finished(LinesearchLocation{F: 5}) {
+		t.Errorf("increase within the memory not accepted")
+	}
+	n.Init(LinesearchLocation{F: 2, Derivative: -1}, 1, p)
+	if n.Finished(LinesearchLocation{F: 5}) {
+		t.Errorf("increase beyond the memory accepted")
+	}
+	// A new run discards the previous function values, also if the
+	// ProblemInfo is reused.
+	gd := &GradientDescent{LinesearchMethod: n}
+	loc := &Location{X: []float64{0}, F: 1, Gradient: []float64{1}}
+	gd.Init(loc, p, make([]float64, 1))
+	if n.Finished(LinesearchLocation{F: 1.5}) {
+		t.Errorf("function values of the previous run used")
+	}
+}
+
 func TestGradientDescentBisection(t *testing.T) {
 	testLocal(t, gradientDescentTests, &GradientDescent{
 		LinesearchMethod: &Bisection{},
 	})
 }
 
+func TestGradientDescentFixedStep(t *testing.T) {
+	// The gradient of the quadratic is Lipschitz continuous with the
+	// constant 4, so constant steps smaller than 0.5 converge.
+	quadratic := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i, v := range x {
+				f += 0.5 * float64(i+1) * v * v
+			}
+			return f
+		},
+		Grad: func(x, grad []float64) {
+			for i, v := range x {
+				grad[i] = float64(i+1) * v
+			}
+		},
+	}
+	settings := DefaultSettings()
+	settings.FunctionConverge = nil
+	settings.GradientThreshold = 1e-10
+	result, err := Local(quadratic, []float64{1, -2, 3, -4}, settings, &GradientDescent{
+		LinesearchMethod: FixedStep{},
+		StepSizer:        ConstantStepSize{Size: 0.25},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != GradientThreshold {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	if result.FuncEvaluations != result.MajorIterations+1 {
+		t.Errorf("line searches with more than one evaluation: %d evaluations, %d iterations",
+			result.FuncEvaluations, result.MajorIterations)
+	}
+
+	// Nonsmooth function with a subgradient, for which the steps must
+	// diminish.
+	abs := Problem{
+		Func: func(x []float64) float64 {
+			return math.Abs(x[0]-1) + 2*math.Abs(x[1]+0.5)
+		},
+		Grad: func(x, grad []float64) {
+			grad[0] = math.Copysign(1, x[0]-1)
+			grad[1] = 2 * math.Copysign(1, x[1]+0.5)
+		},
+	}
+	settings = DefaultSettings()
+	settings.FunctionConverge = nil
+	settings.MajorIterations = 1000
+	result, _ = Local(abs, []float64{3, 2}, settings, &GradientDescent{
+		LinesearchMethod: FixedStep{},
+		StepSizer:        &DiminishingStepSize{Normalized: true},
+	})
+	if result.Status != IterationLimit {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	if !floats.EqualApprox(result.X, []float64{1, -0.5}, 1e-2) {
+		t.Errorf("unexpected minimum location %v", result.X)
+	}
+}
+
 func TestCG(t *testing.T) {
 	var tests []unconstrainedTest
 	tests = append(tests, gradientDescentTests...)
@@ -1141,6 +1481,17 @@ func TestBFGS(t *testing.T) {
 	testLocal(t, tests, &BFGS{})
 }
 
+func TestDFP(t *testing.T) {
+	// DFP is too slow on the long tests.
+	var tests []unconstrainedTest
+	for _, test := range gradientDescentTests {
+		if !test.long {
+			tests = append(tests, test)
+		}
+	}
+	testLocal(t, tests, &DFP{})
+}
+
 func TestLBFGS(t *testing.T) {
 	var tests []unconstrainedTest
 	tests = append(tests, gradientDescentTests...)
@@ -1149,10 +1500,148 @@ func TestLBFGS(t *testing.T) {
 	testLocal(t, tests, &LBFGS{})
 }
 
+func TestLBFGSB(t *testing.T) {
+	testLocal(t, gradientDescentTests, &LBFGSB{})
+}
+
+func TestProjectedGradient(t *testing.T) {
+	testLocal(t, gradientDescentTests, &ProjectedGradient{})
+}
+
+func TestProjectedGradientNonmonotone(t *testing.T) {
+	testLocal(t, gradientDescentTests, &ProjectedGradient{
+		LinesearchMethod: &Nonmonotone{},
+		Step:             AlternatingBBStep,
+	})
+}
+
+func TestBarzilaiBorwein(t *testing.T) {
+	testLocal(t, gradientDescentTests, &BarzilaiBorwein{})
+	testLocal(t, gradientDescentTests, &BarzilaiBorwein{Step: BB2Step})
+	testLocal(t, gradientDescentTests, &BarzilaiBorwein{Step: AlternatingBBStep})
+}
+
 func TestNewton(t *testing.T) {
 	testLocal(t, newtonTests, &Newton{})
 }
 
+func TestNewtonCG(t *testing.T) {
+	// Provide the Hessian only through products with vectors. The inexact
+	// Newton directions do not resolve the tightest gradient tolerances of
+	// newtonTests, which are at the level of roundoff in f, so they are
+	// loosened and BrownAndDennis is skipped as in TestTrustRegion.
+	var tests []unconstrainedTest
+	for _, test := range newtonTests {
+		if test.name == "BrownAndDennis" {
+			continue
+		}
+		if test.gradTol == 0 || test.gradTol < 1e-10 {
+			test.gradTol = 1e-10
+		}
+		test.p.HessVec = hessVecFromHess(test.p.Hess, len(test.x))
+		test.p.Hess = nil
+		tests = append(tests, test)
+	}
+	testLocal(t, tests, &NewtonCG{})
+
+	// Approximate the Hessian-vector products by finite differences. On the
+	// ill-conditioned Watson function, the conjugate gradient method
+	// amplifies their errors and the directions converge only linearly, so
+	// the line search fails at the level of roundoff in f before the
+	// gradient reaches 1e-10. The 10-dimensional Watson function takes a
+	// very large number of iterations.
+	var fdTests []unconstrainedTest
+	for _, test := range tests {
+		if test.name == "Watson" {
+			if len(test.x) == 10 {
+				continue
+			}
+			test.gradTol = 1e-8
+		}
+		test.p.HessVec = nil
+		fdTests = append(fdTests, test)
+	}
+	testLocal(t, fdTests, &NewtonCG{})
+}
+
+// hessVecFromHess returns a function that computes Hessian-vector products
+// by forming the Hessian with hess.
+func hessVecFromHess(hess func(x []float64, hess *mat64.SymDense), dim int) func(x, v, hv []float64) {
+	h := mat64.NewSymDense(dim, nil)
+	return func(x, v, hv []float64) {
+		hess(x, h)
+		mat64.NewVector(dim, hv).MulVec(h, false, mat64.NewVector(dim, v))
+	}
+}
+
+func TestTrustRegion(t *testing.T) {
+	// Trust-region methods accept steps based on the ratio of the actual and
+	// predicted reduction of the function value, which cannot be resolved
+	// once the predicted reduction is below the roundoff in f. The gradient
+	// tolerances of newtonTests are therefore loosened, and BrownAndDennis,
+	// whose tolerance is out of reach at its minimum value of about 8.6e4,
+	// is skipped.
+	var tests []unconstrainedTest
+	for _, test := range newtonTests {
+		if test.name == "BrownAndDennis" {
+			continue
+		}
+		if test.gradTol == 0 || test.gradTol < 1e-9 {
+			test.gradTol = 1e-9
+		}
+		tests = append(tests, test)
+	}
+	for _, solver := range []SubproblemSolver{
+		nil,
+		&Dogleg{},
+		&TwoDimensionalSubspace{},
+		&MoreSorensen{},
+		&GLTR{},
+	} {
+		testLocal(t, tests, &TrustRegion{Solver: solver})
+	}
+}
+
+func TestTrustRegionHessVec(t *testing.T) {
+	var tests []unconstrainedTest
+	for _, test := range newtonTests {
+		if test.name == "BrownAndDennis" {
+			continue
+		}
+		if test.gradTol == 0 || test.gradTol < 1e-9 {
+			test.gradTol = 1e-9
+		}
+		tests = append(tests, test)
+	}
+	for _, solver := range []SubproblemSolver{
+		&Steihaug{},
+		&GLTR{},
+	} {
+		// Hessian-vector products computed with the Hessian.
+		testLocal(t, tests, &TrustRegion{Solver: solver, HessVec: true})
+	}
+}
+
+func TestTrustRegionQuasiNewton(t *testing.T) {
+	testLocal(t, gradientDescentTests, &TrustRegion{
+		Solver:      &Dogleg{},
+		QuasiNewton: true,
+	})
+}
+
+func TestSR1(t *testing.T) {
+	testLocal(t, gradientDescentTests, &SR1{})
+	testLocal(t, gradientDescentTests, &SR1{Solver: &GLTR{}})
+}
+
+func TestTwoMetric(t *testing.T) {
+	testLocal(t, newtonTests, &TwoMetric{})
+}
+
+func TestTwoMetricQuasiNewton(t *testing.T) {
+	testLocal(t, gradientDescentTests, &TwoMetric{QuasiNewton: true})
+}
+
 func testLocal(t *testing.T, tests []unconstrainedTest, method Method) {
 	for _, test := range tests {
 		if test.long && testing.Short() {
@@ -1302,3 +1791,411 @@ func TestIssue76(t *testing.T) {
 		t.Error("Issue https://github.com/gonum/optimize/issues/76 not fixed")
 	}
 }
+
+func TestNelderMeadOneDimensional(t *testing.T) {
+	p := Problem{
+		Func: func(x []float64) float64 { return (x[0] - 3) * (x[0] - 3) },
+	}
+	result, err := Local(p, []float64{0}, nil, &NelderMead{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(result.X[0]-3) > 1e-4 {
+		t.Errorf("unexpected minimum location: want 3, got %v", result.X[0])
+	}
+}
+
+func TestWarmStart(t *testing.T) {
+	// BFGS solves a sequence of problems with slowly moving minima of the
+	// Rosenbrock function, each starting at the solution of the previous one.
+	f := functions.ExtendedRosenbrock{}
+	shifted := func(c float64) Problem {
+		x := make([]float64, 4)
+		return Problem{
+			Func: func(y []float64) float64 {
+				for i, v := range y {
+					x[i] = v - c
+				}
+				return f.Func(x)
+			},
+			Grad: func(y, grad []float64) {
+				for i, v := range y {
+					x[i] = v - c
+				}
+				f.Grad(x, grad)
+			},
+		}
+	}
+	var iters [2]int
+	for i, warm := range []bool{false, true} {
+		x := []float64{1, 1, 1, 1}
+		invHess := mat64.NewSymDense(4, nil)
+		for k := 1; k <= 5; k++ {
+			m := &BFGS{}
+			if warm && k > 1 {
+				m.InitialInverseHessian = invHess
+			}
+			c := 0.01 * float64(k)
+			result, err := Local(shifted(c), x, nil, m)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !floats.EqualApprox(result.X, []float64{1 + c, 1 + c, 1 + c, 1 + c}, 1e-4) {
+				t.Errorf("unexpected minimum location %v", result.X)
+			}
+			iters[i] += result.MajorIterations
+			copy(x, result.X)
+			m.InverseHessian(invHess)
+		}
+	}
+	if iters[1] >= iters[0] {
+		t.Errorf("BFGS: warm start does not save iterations: %d cold, %d warm", iters[0], iters[1])
+	}
+
+	// With the exact inverse Hessian of an ill-conditioned quadratic, the
+	// first step of LBFGS reaches the minimum.
+	a := []float64{1, 10, 100, 1000}
+	quad := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i, v := range x {
+				f += a[i] * (v - 1) * (v - 1)
+			}
+			return f
+		},
+		Grad: func(x, grad []float64) {
+			for i, v := range x {
+				grad[i] = 2 * a[i] * (v - 1)
+			}
+		},
+	}
+	diag := make([]float64, len(a))
+	for i, v := range a {
+		diag[i] = 1 / (2 * v)
+	}
+	x := []float64{0, 0, 0, 0}
+	cold, err := Local(quad, x, nil, &LBFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	warm, err := Local(quad, x, nil, &LBFGS{InitialDiagonal: diag})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if warm.MajorIterations != 1 || warm.MajorIterations >= cold.MajorIterations {
+		t.Errorf("LBFGS: warm start does not save iterations: %d cold, %d warm", cold.MajorIterations, warm.MajorIterations)
+	}
+
+	// Newton starts the modification of an indefinite Hessian with
+	// InitialTau.
+	loc := &Location{
+		X:        []float64{0, 0},
+		Gradient: []float64{1, 1},
+		Hessian:  mat64.NewSymDense(2, []float64{-1, 0, 0, 2}),
+	}
+	dir := make([]float64, 2)
+	n := &Newton{InitialTau: 3}
+	n.InitDirection(loc, dir)
+	if n.Tau() != 3 || !floats.EqualApprox(dir, []float64{-0.5, -0.2}, 1e-14) {
+		t.Errorf("Newton: unexpected direction %v with tau %v", dir, n.Tau())
+	}
+}
+
+func TestNewtonSparse(t *testing.T) {
+	const dim = 100
+	f := functions.ExtendedRosenbrock{}
+	p := Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		Hess: func(x []float64, hess *mat64.SymDense) {
+			hess.CopySym(rosenbrockHessian(x))
+		},
+	}
+	var pattern SparsityPattern
+	for i := 0; i < dim-1; i++ {
+		pattern.Row = append(pattern.Row, i)
+		pattern.Col = append(pattern.Col, i+1)
+	}
+	x0 := make([]float64, dim)
+	for i := range x0 {
+		x0[i] = 1 + 0.5*math.Sin(float64(i))
+	}
+	want, err := Local(p, x0, nil, &Newton{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := Local(p, x0, nil, &Newton{HessianSparsity: &pattern})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != want.Status || got.MajorIterations != want.MajorIterations {
+		t.Errorf("sparse Newton differs from dense Newton: status %v, %d iterations, want %v, %d iterations",
+			got.Status, got.MajorIterations, want.Status, want.MajorIterations)
+	}
+	if !floats.EqualApprox(got.X, want.X, 1e-8) {
+		t.Errorf("sparse Newton differs from dense Newton")
+	}
+}
+
+func TestNewtonModifiedCholesky(t *testing.T) {
+	testLocal(t, newtonTests, &Newton{ModifiedCholesky: true})
+
+	rnd := rand.New(rand.NewSource(1))
+	const n = 6
+	for _, shift := range []float64{10, -2} {
+		// A random symmetric matrix that is positive definite for the
+		// positive shift and indefinite for the negative one.
+		a := mat64.NewSymDense(n, nil)
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				a.SetSym(i, j, rnd.Float64()-0.5)
+			}
+			a.SetSym(i, i, a.At(i, i)+shift)
+		}
+		var c modifiedCholesky
+		c.factorize(a)
+
+		// Reconstruct L D Lᵀ = A + E and check that E is diagonal and
+		// non-negative, and zero for the positive definite matrix.
+		for i := 0; i < n; i++ {
+			if !(c.d[i] > 0) {
+				t.Errorf("shift %v: non-positive element of D %v", shift, c.d[i])
+			}
+			for j := 0; j <= i; j++ {
+				var v float64
+				for k := 0; k <= j; k++ {
+					v += c.l[i*n+k] * c.d[k] * c.l[j*n+k]
+				}
+				e := v - a.At(i, j)
+				switch {
+				case i != j && math.Abs(e) > 1e-12:
+					t.Errorf("shift %v: non-diagonal modification %v at (%d,%d)", shift, e, i, j)
+				case i == j && e < -1e-12:
+					t.Errorf("shift %v: negative modification %v at %d", shift, e, i)
+				case i == j && shift > 0 && math.Abs(e) > 1e-12:
+					t.Errorf("shift %v: modification %v of a positive definite matrix", shift, e)
+				}
+			}
+		}
+		b := make([]float64, n)
+		for i := range b {
+			b[i] = rnd.NormFloat64()
+		}
+		x := make([]float64, n)
+		c.solve(x, b)
+		if shift > 0 {
+			for i := 0; i < n; i++ {
+				var v float64
+				for j := 0; j < n; j++ {
+					v += a.At(i, j) * x[j]
+				}
+				if math.Abs(v-b[i]) > 1e-12 {
+					t.Errorf("shift %v: residual %v in row %d", shift, v-b[i], i)
+				}
+			}
+		}
+		// The direction is a descent direction for the gradient b.
+		if floats.Dot(x, b) <= 0 {
+			t.Errorf("shift %v: modified Newton direction is not a descent direction", shift)
+		}
+	}
+}
+
+func TestMethodReuse(t *testing.T) {
+	f := functions.ExtendedRosenbrock{}
+	p := Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		Hess: func(x []float64, hess *mat64.SymDense) {
+			hess.CopySym(rosenbrockHessian(x))
+		},
+	}
+	lsTests := leastSquaresTests()
+	for _, test := range []struct {
+		name string
+		new  func() Resetter
+		p    [2]Problem
+		x    [2][]float64
+	}{
+		{name: "BFGS", new: func() Resetter { return &BFGS{} }},
+		{name: "LBFGS", new: func() Resetter { return &LBFGS{} }},
+		{name: "DFP", new: func() Resetter { return &DFP{} }},
+		{name: "SR1", new: func() Resetter { return &SR1{} }},
+		{name: "CG", new: func() Resetter { return &CG{} }},
+		{name: "Newton", new: func() Resetter { return &Newton{} }},
+		{name: "TrustRegion", new: func() Resetter { return &TrustRegion{QuasiNewton: true} }},
+		{
+			name: "LevenbergMarquardt",
+			new:  func() Resetter { return &LevenbergMarquardt{Scale: true} },
+			p:    [2]Problem{lsTests[2].p.Problem(), lsTests[0].p.Problem()},
+			x:    [2][]float64{lsTests[2].x, lsTests[0].x},
+		},
+	} {
+		if test.p[0].Func == nil {
+			test.p = [2]Problem{p, p}
+			test.x = [2][]float64{{-1.2, 1, -1.2, 1}, {-1.5, 2}}
+		}
+		settings := func() *Settings {
+			s := DefaultSettings()
+			s.FuncEvaluations = 300
+			return s
+		}
+		want, wantErr := Local(test.p[1], test.x[1], settings(), test.new().(Method))
+
+		// A Method gives the same result when it is reused for a problem
+		// of a different dimension, with and without Reset.
+		for _, reset := range []bool{false, true} {
+			m := test.new()
+			Local(test.p[0], test.x[0], settings(), m.(Method))
+			if reset {
+				m.Reset()
+			}
+			got, err := Local(test.p[1], test.x[1], settings(), m.(Method))
+			if (err == nil) != (wantErr == nil) || !floats.Equal(got.X, want.X) || got.FuncEvaluations != want.FuncEvaluations {
+				t.Errorf("%s, reset %t: reused method differs from a new one", test.name, reset)
+			}
+		}
+	}
+
+	// Reset keeps the configuration and discards the state.
+	n := &Newton{Increase: 3, InitialTau: 2}
+	Local(p, []float64{-1.2, 1}, nil, n)
+	n.Reset()
+	if n.Increase != 3 || n.InitialTau != 2 || n.Tau() != 0 || n.linesearch != nil {
+		t.Errorf("unexpected Newton after Reset: %+v", n)
+	}
+	b := &BFGS{}
+	Local(p, []float64{-1.2, 1}, nil, b)
+	b.Reset()
+	if b.invHess != nil || b.x != nil || b.dim != 0 {
+		t.Errorf("BFGS state not discarded by Reset")
+	}
+}
+
+func TestMethodClone(t *testing.T) {
+	f := functions.ExtendedRosenbrock{}
+	p := Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		Hess: func(x []float64, hess *mat64.SymDense) {
+			hess.CopySym(rosenbrockHessian(x))
+		},
+	}
+	x := []float64{-1.2, 1, -1.2, 1}
+	for _, test := range []struct {
+		name string
+		new  func() Cloner
+	}{
+		{name: "BFGS", new: func() Cloner { return &BFGS{LinesearchMethod: &Bisection{GradConst: 0.5}} }},
+		{name: "LBFGS", new: func() Cloner { return &LBFGS{Store: 3} }},
+		{name: "DFP", new: func() Cloner { return &DFP{} }},
+		{name: "SR1", new: func() Cloner { return &SR1{Solver: &GLTR{}} }},
+		{name: "CG", new: func() Cloner { return &CG{Variant: &DaiYuan{}, InitialStep: &QuadraticStepSize{}} }},
+		{name: "Newton", new: func() Cloner { return &Newton{Increase: 3} }},
+		{name: "TrustRegion", new: func() Cloner { return &TrustRegion{Solver: &MoreSorensen{}} }},
+		{name: "GradientDescent", new: func() Cloner { return &GradientDescent{Momentum: 0.5} }},
+		{name: "NelderMead", new: func() Cloner { return &NelderMead{} }},
+		{name: "BarzilaiBorwein", new: func() Cloner { return &BarzilaiBorwein{Step: AlternatingBBStep} }},
+	} {
+		settings := func() *Settings {
+			s := DefaultSettings()
+			s.FuncEvaluations = 300
+			return s
+		}
+		want, wantErr := Local(p, x, settings(), test.new().(Method))
+
+		// A clone of a used Method gives the same result as a new Method.
+		m := test.new()
+		Local(p, []float64{-1.5, 2}, settings(), m.(Method))
+		c := m.Clone()
+		got, err := Local(p, x, settings(), c)
+		if (err == nil) != (wantErr == nil) || !floats.Equal(got.X, want.X) || got.FuncEvaluations != want.FuncEvaluations {
+			t.Errorf("%s: clone differs from a new method", test.name)
+		}
+	}
+
+	// The components of a clone are not shared with the original.
+	cg := &CG{LinesearchMethod: &Bisection{GradConst: 0.2}, Variant: &HagerZhang{}, InitialStep: &FirstOrderStepSize{}}
+	c := cg.Clone().(*CG)
+	if c.LinesearchMethod == cg.LinesearchMethod || c.Variant == cg.Variant || c.InitialStep == cg.InitialStep {
+		t.Errorf("CG clone shares components with the original")
+	}
+	if c.LinesearchMethod.(*Bisection).GradConst != 0.2 {
+		t.Errorf("CG clone does not keep the configuration of the LinesearchMethod")
+	}
+}
+
+// failingLinesearch is a LinesearchMethod that fails in the line search
+// number fail and is a Bisection otherwise.
+type failingLinesearch struct {
+	Bisection
+	fail    int
+	n       int
+	failing bool
+}
+
+func (f *failingLinesearch) Init(loc LinesearchLocation, step float64, p *ProblemInfo) EvaluationType {
+	f.n++
+	f.failing = f.n == f.fail
+	return f.Bisection.Init(loc, step, p)
+}
+
+func (f *failingLinesearch) Finished(loc LinesearchLocation) bool {
+	return !f.failing && f.Bisection.Finished(loc)
+}
+
+func (f *failingLinesearch) Iterate(loc LinesearchLocation) (float64, EvaluationType, error) {
+	if f.failing {
+		return 0, NoEvaluation, ErrLinesearchFailure
+	}
+	return f.Bisection.Iterate(loc)
+}
+
+func TestLinesearchRestarts(t *testing.T) {
+	f := functions.ExtendedRosenbrock{}
+	p := Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		Hess: func(x []float64, hess *mat64.SymDense) {
+			hess.CopySym(rosenbrockHessian(x))
+		},
+	}
+	for _, test := range []struct {
+		name       string
+		new        func(LinesearchMethod) Method
+		canRestart bool
+	}{
+		{"BFGS", func(ls LinesearchMethod) Method { return &BFGS{LinesearchMethod: ls} }, true},
+		{"LBFGS", func(ls LinesearchMethod) Method { return &LBFGS{LinesearchMethod: ls} }, true},
+		{"DFP", func(ls LinesearchMethod) Method { return &DFP{LinesearchMethod: ls} }, true},
+		{"Newton", func(ls LinesearchMethod) Method { return &Newton{LinesearchMethod: ls} }, false},
+	} {
+		// The line searches are accurate, which DFP needs. Without
+		// restarts, the failure of the third line search stops the
+		// optimization.
+		newLinesearch := func() LinesearchMethod {
+			return &failingLinesearch{Bisection: Bisection{GradConst: 0.1}, fail: 3}
+		}
+		_, err := Local(p, []float64{-1.2, 1}, nil, test.new(newLinesearch()))
+		if err != ErrLinesearchFailure {
+			t.Errorf("%s: unexpected error without restarts: %v", test.name, err)
+		}
+
+		settings := DefaultSettings()
+		settings.LinesearchRestarts = 1
+		result, err := Local(p, []float64{-1.2, 1}, settings, test.new(newLinesearch()))
+		if !test.canRestart {
+			if err != ErrLinesearchFailure {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error with restarts: %v", test.name, err)
+			continue
+		}
+		if !floats.EqualApprox(result.X, []float64{1, 1}, 1e-4) {
+			t.Errorf("%s: unexpected minimum location %v", test.name, result.X)
+		}
+	}
+}